@@ -0,0 +1,174 @@
+package arrangement
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+
+	"github.com/philipparndt/go3mf/internal/geometry"
+	"github.com/philipparndt/go3mf/internal/models"
+)
+
+// Version is the current version of the arrangement file format
+const Version = 1
+
+// Arrangement is the placement of all objects on the build plate(s).
+// It is stored as JSON so that a layout can be tweaked by hand and reused
+// across rebuilds instead of packing the objects again.
+type Arrangement struct {
+	Version int         `json:"version"`
+	Objects []Placement `json:"objects"`
+}
+
+// Placement is the position and rotation of a single object
+type Placement struct {
+	Name      string  `json:"name"`
+	Plate     int     `json:"plate,omitempty"` // 1-based plate number for multi-plate builds
+	X         float64 `json:"x"`
+	Y         float64 `json:"y"`
+	Z         float64 `json:"z"`
+	RotationZ float64 `json:"rotation_z,omitempty"` // Rotation around the object origin in degrees
+}
+
+// New creates an empty arrangement
+func New() *Arrangement {
+	return &Arrangement{Version: Version, Objects: []Placement{}}
+}
+
+// Load reads an arrangement file
+func Load(path string) (*Arrangement, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read arrangement file: %w", err)
+	}
+
+	var a Arrangement
+	if err := json.Unmarshal(data, &a); err != nil {
+		return nil, fmt.Errorf("failed to parse arrangement file %s: %w", path, err)
+	}
+	if a.Version != Version {
+		return nil, fmt.Errorf("unsupported arrangement file version %d in %s (expected %d)", a.Version, path, Version)
+	}
+
+	seen := make(map[string]bool)
+	for i, p := range a.Objects {
+		if p.Name == "" {
+			return nil, fmt.Errorf("arrangement file %s: object %d has no name", path, i+1)
+		}
+		if seen[p.Name] {
+			return nil, fmt.Errorf("arrangement file %s: object %q is listed more than once", path, p.Name)
+		}
+		seen[p.Name] = true
+	}
+
+	return &a, nil
+}
+
+// Save writes the arrangement as indented JSON
+func (a *Arrangement) Save(path string) error {
+	data, err := json.MarshalIndent(a, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write arrangement file: %w", err)
+	}
+	return nil
+}
+
+// Add appends a placement
+func (a *Arrangement) Add(p Placement) {
+	a.Objects = append(a.Objects, p)
+}
+
+// Lookup returns the placement of the object with the given name
+func (a *Arrangement) Lookup(name string) (Placement, bool) {
+	for _, p := range a.Objects {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return Placement{}, false
+}
+
+// Transform returns the 3MF build item transform of the placement
+func (p Placement) Transform() string {
+	if p.RotationZ == 0 {
+		return geometry.BuildTranslationTransform(p.X, p.Y, p.Z)
+	}
+	return geometry.BuildRotationTransform(0, 0, p.RotationZ, p.X, p.Y, p.Z)
+}
+
+// FromTransform creates the placement of an object from its 3MF build item transform.
+// Only the translation and the rotation around Z are kept; an empty transform is the identity.
+func FromTransform(name string, plate int, transform string) (Placement, error) {
+	placement := Placement{Name: name, Plate: plate}
+	m, err := geometry.ParseTransform(transform)
+	if err != nil {
+		return placement, fmt.Errorf("%s: %w", name, err)
+	}
+
+	placement.X = round(m[9])
+	placement.Y = round(m[10])
+	placement.Z = round(m[11])
+	placement.RotationZ = round(math.Atan2(m[1], m[0]) * 180 / math.Pi)
+	return placement, nil
+}
+
+// FromModel captures the arrangement of the build items of a 3MF model, e.g. one
+// that was rearranged and saved in a slicer. Object names and plates are taken from
+// the Bambu Studio settings if available.
+func FromModel(model *models.Model, settings *models.ModelSettings) (*Arrangement, error) {
+	names := make(map[string]string)
+	for _, obj := range model.Resources.Objects {
+		names[obj.ID] = obj.Name
+	}
+	plates := make(map[string]int)
+	if settings != nil {
+		for _, obj := range settings.Objects {
+			if name := models.MetadataValue(obj.Metadata, "name"); name != "" {
+				names[obj.ID] = name
+			}
+		}
+	}
+	// Plate numbers are only recorded for multi-plate builds
+	if settings != nil && len(settings.Plates) > 1 {
+		for i, plate := range settings.Plates {
+			plateNumber := i + 1
+			if id, err := strconv.Atoi(models.MetadataValue(plate.Metadata, "plater_id")); err == nil {
+				plateNumber = id
+			}
+			for _, instance := range plate.ModelInstances {
+				plates[models.MetadataValue(instance.Metadata, "object_id")] = plateNumber
+			}
+		}
+	}
+
+	a := New()
+	for _, item := range model.Build.Items {
+		name := names[item.ObjectID]
+		if name == "" {
+			return nil, fmt.Errorf("build item for object %s has no name", item.ObjectID)
+		}
+		if _, exists := a.Lookup(name); exists {
+			return nil, fmt.Errorf("object name %q is used more than once", name)
+		}
+		placement, err := FromTransform(name, plates[item.ObjectID], item.Transform)
+		if err != nil {
+			return nil, err
+		}
+		a.Add(placement)
+	}
+	return a, nil
+}
+
+// round rounds to the precision used in 3MF transforms
+func round(v float64) float64 {
+	r := math.Round(v*100) / 100
+	if r == 0 {
+		return 0 // Avoid -0 in the JSON output
+	}
+	return r
+}
@@ -0,0 +1,69 @@
+package arrangement
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTransformRoundTrip(t *testing.T) {
+	tests := []Placement{
+		{Name: "a", X: 10, Y: 20.5, Z: 0},
+		{Name: "b", X: -5, Y: 3, Z: 1.25, RotationZ: 90},
+		{Name: "c", X: 100, Y: 0, Z: 0, RotationZ: -45},
+	}
+
+	for _, want := range tests {
+		got, err := FromTransform(want.Name, 0, want.Transform())
+		if err != nil {
+			t.Fatalf("FromTransform(%q) error = %v", want.Transform(), err)
+		}
+		if got != want {
+			t.Errorf("round trip of %+v = %+v", want, got)
+		}
+	}
+}
+
+func TestFromTransform_Invalid(t *testing.T) {
+	if _, err := FromTransform("a", 0, "1 0 0 1"); err == nil {
+		t.Errorf("expected an error for a transform with 4 values")
+	}
+	if p, err := FromTransform("a", 0, ""); err != nil || p.X != 0 || p.RotationZ != 0 {
+		t.Errorf("empty transform = %+v, %v, want identity", p, err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{name: "valid", content: `{"version":1,"objects":[{"name":"a","x":1,"y":2,"z":0}]}`},
+		{name: "wrong version", content: `{"version":2,"objects":[]}`, wantErr: "unsupported arrangement file version 2"},
+		{name: "missing name", content: `{"version":1,"objects":[{"x":1}]}`, wantErr: "object 1 has no name"},
+		{name: "duplicate name", content: `{"version":1,"objects":[{"name":"a"},{"name":"a"}]}`, wantErr: `object "a" is listed more than once`},
+		{name: "invalid json", content: `{`, wantErr: "failed to parse arrangement file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "layout.json")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := Load(path)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package bom
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/philipparndt/go3mf/internal/arrangement"
+	"github.com/philipparndt/go3mf/internal/geometry"
+	"github.com/philipparndt/go3mf/internal/models"
+)
+
+// DefaultDensity is the density in g/cm³ used to estimate weights (PLA)
+const DefaultDensity = 1.24
+
+// Densities are the filament densities in g/cm³ by slot (first entry = slot 1)
+type Densities []float64
+
+// NewDensities returns the densities of the filament slots by their type, looked
+// up in the custom materials and the bundled table. Slots of an unknown type use
+// DefaultDensity.
+func NewDensities(filaments []models.YamlFilament, materials map[string]float64) Densities {
+	densities := make(Densities, len(filaments))
+	for i, filament := range filaments {
+		material := filament.Type
+		if material == "" {
+			material = models.DefaultMaterial
+		}
+		density, ok := models.LookupDensity(material, materials)
+		if !ok {
+			density = DefaultDensity
+		}
+		densities[i] = density
+	}
+	return densities
+}
+
+// Slot returns the density of a filament slot (DefaultDensity for unknown slots)
+func (d Densities) Slot(slot int) float64 {
+	if slot < 1 || slot > len(d) {
+		return DefaultDensity
+	}
+	return d[slot-1]
+}
+
+// Item is a line of the bill of materials: a part of an object and how often it is built
+type Item struct {
+	Object   string
+	Part     string // Part name ("" for objects with a single part)
+	Quantity int
+	Filament int     // Filament slot (0 = unknown)
+	Volume   float64 // Volume of a single piece in mm³
+	Source   string  // File or generator the part was made from
+}
+
+// Weight returns the estimated weight of a single piece in grams
+func (i Item) Weight(density float64) float64 {
+	return i.Volume / 1000 * density
+}
+
+// FromModel returns an item for every part of every build item of a 3MF model.
+// Names and filament slots are taken from the Bambu Studio settings if available.
+func FromModel(model *models.Model, settings *models.ModelSettings) ([]Item, error) {
+	placements, err := arrangement.FromModel(model, settings)
+	if err != nil {
+		return nil, err
+	}
+
+	objects := make(map[string]*models.Object)
+	for i := range model.Resources.Objects {
+		objects[model.Resources.Objects[i].ID] = &model.Resources.Objects[i]
+	}
+	partSettings := make(map[string][]models.Part)
+	objectFilaments := make(map[string]int)
+	if settings != nil {
+		for _, obj := range settings.Objects {
+			partSettings[obj.ID] = obj.Parts
+			objectFilaments[obj.ID], _ = strconv.Atoi(models.MetadataValue(obj.Metadata, "extruder"))
+		}
+	}
+
+	var items []Item
+	for i, item := range model.Build.Items {
+		obj := objects[item.ObjectID]
+		if obj == nil {
+			return nil, fmt.Errorf("build item references unknown object %s", item.ObjectID)
+		}
+		name := placements.Objects[i].Name
+
+		var meshes []*models.Object
+		for _, component := range models.ObjectComponents(obj) {
+			meshes = append(meshes, objects[component.ObjectID])
+		}
+
+		parts := partSettings[item.ObjectID]
+		for j, mesh := range meshes {
+			// Support and other meshes are not parts to print
+			if mesh != nil && !models.ObjectType(mesh.Type).Printable() {
+				continue
+			}
+			bomItem := Item{Object: name, Quantity: 1, Filament: objectFilaments[item.ObjectID]}
+			if j < len(parts) {
+				bomItem.Part = strings.TrimPrefix(models.MetadataValue(parts[j].Metadata, "name"), name+"/")
+				if bomItem.Part == name {
+					bomItem.Part = ""
+				}
+				if filament, err := strconv.Atoi(models.MetadataValue(parts[j].Metadata, "extruder")); err == nil {
+					bomItem.Filament = filament
+				}
+			}
+			if mesh != nil {
+				if bomItem.Volume, err = geometry.MeshVolume(mesh); err != nil {
+					return nil, fmt.Errorf("object %s: %w", name, err)
+				}
+			}
+			items = append(items, bomItem)
+		}
+	}
+	return items, nil
+}
+
+// Merge combines equal items (same object, part, filament and source) into one
+// item with the sum of their quantities, in the order of their first occurrence
+func Merge(items []Item) []Item {
+	type key struct {
+		object, part, source string
+		filament             int
+	}
+	index := make(map[key]int)
+	var merged []Item
+	for _, item := range items {
+		k := key{item.Object, item.Part, item.Source, item.Filament}
+		if i, ok := index[k]; ok {
+			merged[i].Quantity += item.Quantity
+			continue
+		}
+		index[k] = len(merged)
+		merged = append(merged, item)
+	}
+	return merged
+}
+
+// WriteCSV writes the bill of materials as CSV with the weights estimated from
+// the density of the filament slot of every item
+func WriteCSV(path string, items []Item, densities Densities) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create BOM: %w", err)
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	w.Write([]string{"object", "part", "quantity", "filament", "volume_cm3", "weight_g", "total_weight_g", "source"})
+	for _, item := range items {
+		filament := ""
+		if item.Filament > 0 {
+			filament = strconv.Itoa(item.Filament)
+		}
+		weight := item.Weight(densities.Slot(item.Filament))
+		w.Write([]string{
+			item.Object,
+			item.Part,
+			strconv.Itoa(item.Quantity),
+			filament,
+			strconv.FormatFloat(item.Volume/1000, 'f', 2, 64),
+			strconv.FormatFloat(weight, 'f', 1, 64),
+			strconv.FormatFloat(weight*float64(item.Quantity), 'f', 1, 64),
+			item.Source,
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("failed to write BOM: %w", err)
+	}
+	return f.Close()
+}
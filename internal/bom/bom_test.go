@@ -0,0 +1,89 @@
+package bom
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/philipparndt/go3mf/internal/models"
+)
+
+func TestMerge(t *testing.T) {
+	items := []Item{
+		{Object: "Clip", Part: "body", Quantity: 1, Filament: 1, Source: "clip.scad"},
+		{Object: "Clip", Part: "pin", Quantity: 1, Filament: 2, Source: "pin.scad"},
+		{Object: "Clip", Part: "body", Quantity: 1, Filament: 1, Source: "clip.scad"},
+		{Object: "Clip", Part: "body", Quantity: 1, Filament: 3, Source: "clip.scad"},
+	}
+
+	merged := Merge(items)
+
+	if len(merged) != 3 {
+		t.Fatalf("got %d items, want 3: %+v", len(merged), merged)
+	}
+	if merged[0].Part != "body" || merged[0].Quantity != 2 {
+		t.Errorf("first item = %+v, want body twice", merged[0])
+	}
+	// Other filaments are listed separately
+	if merged[2].Filament != 3 || merged[2].Quantity != 1 {
+		t.Errorf("last item = %+v, want body with filament 3 once", merged[2])
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bom.csv")
+	items := []Item{
+		{Object: "Clip", Part: "body", Quantity: 4, Filament: 1, Volume: 2500, Source: "clip.scad"},
+		{Object: "Clip", Part: "pin", Quantity: 4, Filament: 2, Volume: 1000, Source: "pin.scad"},
+		{Object: "Plate", Quantity: 1, Volume: 1000, Source: "plate generator"},
+	}
+
+	if err := WriteCSV(path, items, Densities{1.24, 1.04}); err != nil {
+		t.Fatalf("WriteCSV() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "object,part,quantity,filament,volume_cm3,weight_g,total_weight_g,source\n" +
+		"Clip,body,4,1,2.50,3.1,12.4,clip.scad\n" +
+		"Clip,pin,4,2,1.00,1.0,4.2,pin.scad\n" +
+		"Plate,,1,,1.00,1.2,1.2,plate generator\n"
+	if string(data) != want {
+		t.Errorf("CSV =\n%s\nwant\n%s", data, want)
+	}
+}
+
+func TestNewDensities(t *testing.T) {
+	filaments := []models.YamlFilament{
+		{Type: "PLA"},
+		{Type: "petg"},
+		{Type: "PETG-CF"},
+		{Type: "Nylon"},
+		{Type: "Wood"},
+		{},
+	}
+	materials := map[string]float64{"nylon": 1.08, "PETG-CF": 1.30}
+
+	densities := NewDensities(filaments, materials)
+
+	tests := []struct {
+		slot int
+		want float64
+	}{
+		{1, 1.24},
+		{2, 1.27},
+		{3, 1.30}, // Custom material before its base type
+		{4, 1.08}, // Custom material only
+		{5, DefaultDensity},
+		{6, 1.24}, // No type = PLA
+		{7, DefaultDensity},
+		{0, DefaultDensity},
+	}
+	for _, tt := range tests {
+		if got := densities.Slot(tt.slot); got != tt.want {
+			t.Errorf("Slot(%d) = %v, want %v", tt.slot, got, tt.want)
+		}
+	}
+}
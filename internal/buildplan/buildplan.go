@@ -1,20 +1,43 @@
 package buildplan
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/philipparndt/go3mf/internal/arrangement"
+	"github.com/philipparndt/go3mf/internal/bom"
 	"github.com/philipparndt/go3mf/internal/config"
+	"github.com/philipparndt/go3mf/internal/estimate"
+	"github.com/philipparndt/go3mf/internal/exitcode"
+	"github.com/philipparndt/go3mf/internal/extract"
+	"github.com/philipparndt/go3mf/internal/generator"
+	"github.com/philipparndt/go3mf/internal/geometry"
 	"github.com/philipparndt/go3mf/internal/inspect"
+	"github.com/philipparndt/go3mf/internal/layout"
+	"github.com/philipparndt/go3mf/internal/manifest"
 	"github.com/philipparndt/go3mf/internal/models"
 	"github.com/philipparndt/go3mf/internal/preconditions"
 	"github.com/philipparndt/go3mf/internal/renderer"
+	"github.com/philipparndt/go3mf/internal/report"
 	"github.com/philipparndt/go3mf/internal/stl"
+	"github.com/philipparndt/go3mf/internal/telemetry"
 	"github.com/philipparndt/go3mf/internal/threemf"
 	"github.com/philipparndt/go3mf/internal/threemf/combine"
+	"github.com/philipparndt/go3mf/internal/thumbnail"
 	"github.com/philipparndt/go3mf/internal/ui"
+	"github.com/philipparndt/go3mf/version"
 )
 
 // FileType represents the type of input file
@@ -28,9 +51,13 @@ const (
 	FileTypeSTL
 )
 
+// StdoutPath is the output path that streams the resulting 3MF to stdout
+const StdoutPath = "-"
+
 // ObjectGroup represents a group of files belonging to the same object
 type ObjectGroup struct {
 	Name  string
+	Count int // Number of copies (0 = 1)
 	Files []string
 }
 
@@ -40,12 +67,202 @@ type BuildStep interface {
 	Execute() error
 }
 
+// StepDependencies is implemented by steps that only depend on some of the steps
+// before them. Steps without it, or returning nil, wait for all steps before them,
+// like the built-in steps, which run one after another.
+type StepDependencies interface {
+	DependsOn() []string
+}
+
 // BuildPlan contains all steps needed to process and combine files
 type BuildPlan struct {
 	Steps      []BuildStep
 	OutputFile string
 }
 
+// StepFunc is a build step running a function with the shared build context,
+// e.g. a custom check inserted between rendering and combining. It may run
+// concurrently with other steps, so it locks the context while it reads or
+// updates it.
+type StepFunc struct {
+	StepName string
+	Run      func(ctx *Context) error
+	After    []string // Names of the steps it waits for (nil = all steps before it)
+}
+
+func (s *StepFunc) Name() string {
+	return s.StepName
+}
+
+func (s *StepFunc) DependsOn() []string {
+	return s.After
+}
+
+func (s *StepFunc) Execute() error {
+	return s.Run(buildContext)
+}
+
+// lockedStep is a step that updates the shared context throughout, so it holds
+// the lock of the context while it runs. Only StepFunc steps lock it themselves.
+func lockedStep(step BuildStep) bool {
+	_, ok := step.(*StepFunc)
+	return !ok
+}
+
+// SharedContext returns the context shared between the build steps. Custom
+// steps read the rendered files and settings from it and may update them.
+func SharedContext() *Context {
+	return buildContext
+}
+
+// StepNames returns the names of the steps of the plan in execution order
+func (p *BuildPlan) StepNames() []string {
+	names := make([]string, len(p.Steps))
+	for i, step := range p.Steps {
+		names[i] = step.Name()
+	}
+	return names
+}
+
+// InsertBefore inserts steps directly before the step called name
+func (p *BuildPlan) InsertBefore(name string, steps ...BuildStep) error {
+	i, err := p.insertIndex(name, steps)
+	if err != nil {
+		return err
+	}
+	p.Steps = append(p.Steps[:i], append(append([]BuildStep{}, steps...), p.Steps[i:]...)...)
+	return nil
+}
+
+// InsertAfter inserts steps directly after the step called name
+func (p *BuildPlan) InsertAfter(name string, steps ...BuildStep) error {
+	i, err := p.insertIndex(name, steps)
+	if err != nil {
+		return err
+	}
+	i++
+	p.Steps = append(p.Steps[:i], append(append([]BuildStep{}, steps...), p.Steps[i:]...)...)
+	return nil
+}
+
+// insertIndex returns the index of the step called name. Step names identify
+// the steps for ordering, so the inserted steps must not reuse a name.
+func (p *BuildPlan) insertIndex(name string, steps []BuildStep) (int, error) {
+	index := -1
+	names := make(map[string]bool)
+	for i, step := range p.Steps {
+		if step.Name() == name && index < 0 {
+			index = i
+		}
+		names[step.Name()] = true
+	}
+	if index < 0 {
+		return 0, fmt.Errorf("build plan has no step %q (steps: %s)", name, strings.Join(p.StepNames(), ", "))
+	}
+	for _, step := range steps {
+		if step == nil {
+			return 0, fmt.Errorf("cannot insert an empty step next to %q", name)
+		}
+		if names[step.Name()] {
+			return 0, fmt.Errorf("build plan already has a step %q", step.Name())
+		}
+		names[step.Name()] = true
+	}
+	return index, nil
+}
+
+// runSteps executes the steps of the plan. A step starts once the steps it
+// depends on have finished, so custom steps declaring their dependencies run
+// concurrently with other steps, at most --jobs at a time. After a failure, no further steps are started and the
+// error of the first failed step in plan order is returned.
+func (p *BuildPlan) runSteps(recorder *telemetry.Recorder) error {
+	dependencies, err := p.dependencies()
+	if err != nil {
+		return err
+	}
+
+	done := make([]chan struct{}, len(p.Steps))
+	for i := range done {
+		done[i] = make(chan struct{})
+	}
+	errs := make([]error, len(p.Steps))
+	slots := make(chan struct{}, max(buildContext.Jobs, 1))
+	var failed atomic.Bool
+	var wg sync.WaitGroup
+	var headerMu sync.Mutex // Keeps the header of a step next to its first messages
+
+	for i, step := range p.Steps {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer close(done[i])
+			for _, dependency := range dependencies[i] {
+				<-done[dependency]
+			}
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			if failed.Load() {
+				return
+			}
+
+			run := step.Execute
+			if lockedStep(step) {
+				run = func() error {
+					buildContext.Lock()
+					defer buildContext.Unlock()
+					return step.Execute()
+				}
+			}
+			if ui.IsVerbose() {
+				headerMu.Lock()
+				ui.PrintHeader(fmt.Sprintf("Step %d/%d: %s", i+1, len(p.Steps), step.Name()))
+				headerMu.Unlock()
+			}
+			if err := recorder.Measure(step.Name(), run); err != nil {
+				errs[i] = err
+				failed.Store(true)
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dependencies returns the indices of the steps every step waits for. Steps
+// may only depend on steps before them, which keeps the graph acyclic.
+func (p *BuildPlan) dependencies() ([][]int, error) {
+	index := make(map[string]int)
+	dependencies := make([][]int, len(p.Steps))
+	for i, step := range p.Steps {
+		var names []string
+		if deps, ok := step.(StepDependencies); ok {
+			names = deps.DependsOn()
+		}
+		if names == nil {
+			for j := range i {
+				dependencies[i] = append(dependencies[i], j)
+			}
+		}
+		for _, name := range names {
+			j, ok := index[name]
+			if !ok {
+				return nil, fmt.Errorf("step %q depends on %q, which is not a step before it", step.Name(), name)
+			}
+			dependencies[i] = append(dependencies[i], j)
+		}
+		if _, ok := index[step.Name()]; !ok {
+			index[step.Name()] = i
+		}
+	}
+	return dependencies, nil
+}
+
 // Planner creates build plans based on input files
 type Planner struct{}
 
@@ -54,31 +271,63 @@ func NewPlanner() *Planner {
 	return &Planner{}
 }
 
-// CreatePlan analyzes input files and creates an execution plan
+// CreatePlan analyzes input files and creates an execution plan.
+// An empty outputFile selects the default: the YAML "output" field for YAML
+// plans and combined.3mf otherwise. Use StdoutPath to stream the result to stdout.
 func (p *Planner) CreatePlan(inputs []string, objects []ObjectGroup, outputFile string) (*BuildPlan, error) {
+	buildContext.ResumeKey = resumeKey(inputs, objects, outputFile)
+
+	// If single input is a YAML file (or "-" for stdin), use YAML-based plan
+	if len(objects) == 0 && len(inputs) == 1 && (inputs[0] == config.StdinPath || detectFileType(inputs[0]) == FileTypeYAML) {
+		return p.createYAMLPlan(inputs[0], outputFile)
+	}
+
+	// YAML configs may define custom printers, so they are checked when the config is loaded
+	if buildContext.Printer != "" {
+		if _, err := models.LookupPrinter(buildContext.Printer, nil); err != nil {
+			return nil, exitcode.Wrap(exitcode.Usage, fmt.Errorf("--printer: %w", err))
+		}
+	}
+	if buildContext.Profile != "" {
+		return nil, exitcode.Wrap(exitcode.Usage, fmt.Errorf("--profile requires a YAML config"))
+	}
+	if len(buildContext.Vars) > 0 {
+		return nil, exitcode.Wrap(exitcode.Usage, fmt.Errorf("--var requires a YAML config"))
+	}
+
+	if outputFile == "" && buildContext.OutputTemplate != "" {
+		expanded, err := expandOutput(buildContext.OutputTemplate, ".")
+		if err != nil {
+			return nil, exitcode.Wrap(exitcode.Usage, fmt.Errorf("--output-template: %w", err))
+		}
+		outputFile = expanded
+	}
+	if outputFile == "" {
+		outputFile = "combined.3mf"
+	}
+	outputFile, err := prepareOutput(outputFile)
+	if err != nil {
+		return nil, err
+	}
+
 	// If objects are specified via --object flags, create YAML-style plan
 	if len(objects) > 0 {
 		return p.createObjectGroupPlan(objects, outputFile)
 	}
 
-	// If single input is a YAML file, use YAML-based plan
-	if len(inputs) == 1 && detectFileType(inputs[0]) == FileTypeYAML {
-		return p.createYAMLPlan(inputs[0])
-	}
-
 	// Otherwise, detect file types and create appropriate plan
 	fileTypes := make(map[FileType][]string)
 	for _, input := range inputs {
 		ft := detectFileType(input)
 		if ft == FileTypeUnknown {
-			return nil, fmt.Errorf("unknown file type: %s", input)
+			return nil, exitcode.Wrap(exitcode.Usage, fmt.Errorf("unknown file type: %s", input))
 		}
 		fileTypes[ft] = append(fileTypes[ft], input)
 	}
 
 	// Check if all files are the same type
 	if len(fileTypes) > 1 {
-		return nil, fmt.Errorf("cannot mix different file types (found: %v)", getFileTypeNames(fileTypes))
+		return nil, exitcode.Wrap(exitcode.Usage, fmt.Errorf("cannot mix different file types (found: %v)", getFileTypeNames(fileTypes)))
 	}
 
 	// Get the single file type
@@ -92,30 +341,37 @@ func (p *Planner) CreatePlan(inputs []string, objects []ObjectGroup, outputFile
 	switch fileType {
 	case FileTypeSCAD:
 		return p.createSCADPlan(files, outputFile)
-	case FileType3MF:
-		return p.create3MFPlan(files, outputFile)
-	case FileTypeSTL:
+	case FileType3MF, FileTypeSTL:
+		// Plain 3MF and STL files are placed side by side without object groups
+		if buildContext.ArrangementFile != "" || buildContext.ExportArrangementFile != "" {
+			return nil, exitcode.Wrap(exitcode.Usage, fmt.Errorf("arrangements are only supported for SCAD files, --object groups and YAML configs"))
+		}
+		if fileType == FileType3MF {
+			return p.create3MFPlan(files, outputFile)
+		}
 		return p.createSTLPlan(files, outputFile)
 	default:
-		return nil, fmt.Errorf("unsupported file type")
+		return nil, exitcode.Wrap(exitcode.Usage, fmt.Errorf("unsupported file type"))
 	}
 }
 
-// createYAMLPlan creates a plan for YAML configuration file
-func (p *Planner) createYAMLPlan(yamlFile string) (*BuildPlan, error) {
+// createYAMLPlan creates a plan for YAML configuration file.
+// A non-empty outputFile overrides the output path from the configuration.
+func (p *Planner) createYAMLPlan(yamlFile string, outputFile string) (*BuildPlan, error) {
 	plan := &BuildPlan{}
 
 	// Step 1: Load YAML configuration
 	plan.Steps = append(plan.Steps, &LoadYAMLStep{
 		ConfigPath: yamlFile,
+		OutputFile: outputFile,
 	})
 
-	// Step 2: Check preconditions (OpenSCAD)
-	plan.Steps = append(plan.Steps, &CheckPreconditionsStep{})
-
-	// Step 3: Validate files
+	// Step 2: Validate files
 	plan.Steps = append(plan.Steps, &ValidateFilesStep{})
 
+	// Step 3: Check preconditions (the tools the files of the plan need)
+	plan.Steps = append(plan.Steps, &CheckPreconditionsStep{})
+
 	// Step 4: Render SCAD files
 	plan.Steps = append(plan.Steps, &RenderSCADFilesStep{})
 
@@ -137,12 +393,12 @@ func (p *Planner) createObjectGroupPlan(objectGroups []ObjectGroup, outputFile s
 		OutputFile:   outputFile,
 	})
 
-	// Step 2: Check preconditions (OpenSCAD)
-	plan.Steps = append(plan.Steps, &CheckPreconditionsStep{})
-
-	// Step 3: Validate files
+	// Step 2: Validate files
 	plan.Steps = append(plan.Steps, &ValidateFilesStep{})
 
+	// Step 3: Check preconditions (the tools the files of the plan need)
+	plan.Steps = append(plan.Steps, &CheckPreconditionsStep{})
+
 	// Step 4: Render SCAD files
 	plan.Steps = append(plan.Steps, &RenderSCADFilesStep{})
 
@@ -164,12 +420,12 @@ func (p *Planner) createSCADPlan(scadFiles []string, outputFile string) (*BuildP
 		OutputFile: outputFile,
 	})
 
-	// Step 2: Check preconditions (OpenSCAD)
-	plan.Steps = append(plan.Steps, &CheckPreconditionsStep{})
-
-	// Step 3: Validate files
+	// Step 2: Validate files
 	plan.Steps = append(plan.Steps, &ValidateFilesStep{})
 
+	// Step 3: Check preconditions (the tools the files of the plan need)
+	plan.Steps = append(plan.Steps, &CheckPreconditionsStep{})
+
 	// Step 4: Render SCAD files
 	plan.Steps = append(plan.Steps, &RenderSCADFilesStep{})
 
@@ -185,6 +441,8 @@ func (p *Planner) create3MFPlan(files []string, outputFile string) (*BuildPlan,
 		OutputFile: outputFile,
 	}
 
+	buildContext.InputFiles = files
+
 	// Step 1: Validate 3MF files
 	plan.Steps = append(plan.Steps, &Validate3MFFilesStep{
 		Files: files,
@@ -205,6 +463,8 @@ func (p *Planner) createSTLPlan(files []string, outputFile string) (*BuildPlan,
 		OutputFile: outputFile,
 	}
 
+	buildContext.InputFiles = files
+
 	// Step 1: Validate STL files
 	plan.Steps = append(plan.Steps, &ValidateSTLFilesStep{
 		Files: files,
@@ -223,28 +483,224 @@ func (p *Planner) createSTLPlan(files []string, outputFile string) (*BuildPlan,
 	return plan, nil
 }
 
+// loadStep returns the step loading the YAML config of the plan, nil for plans
+// of other files
+func (p *BuildPlan) loadStep() *LoadYAMLStep {
+	for _, step := range p.Steps {
+		if load, ok := step.(*LoadYAMLStep); ok {
+			return load
+		}
+	}
+	return nil
+}
+
 // Execute runs all steps in the plan
-func (p *BuildPlan) Execute() error {
+func (p *BuildPlan) Execute() (err error) {
+	// UI messages go back to their writer after the messages of the cleanup below
+	previousOutput := ui.Output()
+	defer ui.SetOutput(previousOutput)
+
+	recorder := telemetry.NewRecorder()
+	defer func() {
+		buildContext.StepTimings = recorder.Steps
+		if buildContext.OTLPEndpoint != "" {
+			exportSpans(recorder.Steps, err)
+		}
+	}()
+
+	// The state of a failed build is kept for --resume
+	defer func() {
+		if buildContext.ResumeDir == "" {
+			return
+		}
+		if err == nil {
+			os.RemoveAll(buildContext.ResumeDir)
+			return
+		}
+		failed := failedStep(recorder.Steps)
+		if failed == "" {
+			return
+		}
+		if err := writeResumeState(buildContext.ResumeDir, recorder.Steps); err != nil {
+			ui.PrintWarning(fmt.Sprintf("The state of the build cannot be kept for --resume: %v", err))
+			return
+		}
+		ui.PrintInfo(fmt.Sprintf("Completed steps and renders were kept. Run the build again with --resume to continue from %q.", failed))
+	}()
+
+	// The stdout temp file is only known once the output has been resolved
+	defer func() {
+		if buildContext.StdoutTempFile != "" {
+			os.Remove(buildContext.StdoutTempFile)
+		}
+		// A failed build leaves an existing output untouched
+		if buildContext.TempOutputFile != "" {
+			os.Remove(buildContext.TempOutputFile)
+			buildContext.TempOutputFile = ""
+		}
+		renderer.CleanupTempFiles(buildContext.GeneratedSources)
+	}()
+
+	// A 3MF streamed to stdout moves all UI messages to stderr before the plan
+	// prints anything; the output of a YAML config is known once it is loaded
+	if load := p.loadStep(); load != nil && load.load() == nil && load.cfg.Output == StdoutPath {
+		ui.SetOutput(os.Stderr)
+	}
+
 	if ui.IsVerbose() {
 		ui.PrintTitle("Build Plan Execution")
 		ui.PrintInfo(fmt.Sprintf("Total steps: %d", len(p.Steps)))
 		ui.PrintSeparator()
 	}
 
-	for i, step := range p.Steps {
-		if ui.IsVerbose() {
-			ui.PrintHeader(fmt.Sprintf("Step %d/%d: %s", i+1, len(p.Steps), step.Name()))
-		}
-		if err := step.Execute(); err != nil {
-			return err
-		}
+	if err := p.runSteps(recorder); err != nil {
+		return err
 	}
+	buildContext.StepTimings = recorder.Steps
 
 	// Update OutputFile from buildContext if not already set
 	if p.OutputFile == "" && buildContext.OutputFile != "" {
 		p.OutputFile = buildContext.OutputFile
 	}
 
+	// Vendor metadata is stripped before the output is read back for anything else
+	if buildContext.StripMetadata && buildContext.TempOutputFile != "" {
+		removed, err := threemf.StripMetadata(buildContext.TempOutputFile, buildContext.CompactXML)
+		if err != nil {
+			return exitcode.Wrap(exitcode.Output, fmt.Errorf("cannot strip the metadata: %w", err))
+		}
+		ui.PrintItem(fmt.Sprintf("Vendor metadata stripped, %d auxiliary file(s) removed", len(removed)))
+	}
+
+	// Thumbnails are embedded before the checksum is taken
+	if buildContext.Thumbnails != nil {
+		if err := writeThumbnails(buildContext.OutputFile, buildContext.Thumbnails); err != nil {
+			return exitcode.Wrap(exitcode.Output, fmt.Errorf("cannot write the thumbnails: %w", err))
+		}
+	}
+
+	if buildContext.Checksum && buildContext.TempOutputFile != "" {
+		if err := threemf.StampIntegrity(buildContext.TempOutputFile, version.Version); err != nil {
+			return exitcode.Wrap(exitcode.Output, fmt.Errorf("failed to write integrity metadata: %w", err))
+		}
+	}
+
+	// A broken output must not replace an existing one
+	if buildContext.Verify && buildContext.TempOutputFile != "" {
+		if problems := threemf.Verify(buildContext.TempOutputFile); len(problems) > 0 {
+			for _, problem := range problems {
+				ui.PrintError(problem)
+			}
+			return exitcode.Wrap(exitcode.Output, fmt.Errorf("the output is broken: %d problem(s) found", len(problems)))
+		}
+		ui.PrintItem("Output verified")
+	}
+
+	// Replace the output with the complete build
+	if buildContext.TempOutputFile != "" {
+		if err := os.Rename(buildContext.TempOutputFile, buildContext.OutputTarget); err != nil {
+			return exitcode.Wrap(exitcode.Output, fmt.Errorf("failed to write output file: %w", err))
+		}
+		buildContext.TempOutputFile = ""
+		buildContext.OutputFile = buildContext.OutputTarget
+		p.OutputFile = buildContext.OutputTarget
+
+		if buildContext.Checksum {
+			sidecar, err := threemf.WriteChecksum(p.OutputFile)
+			if err != nil {
+				return exitcode.Wrap(exitcode.Output, err)
+			}
+			ui.PrintItem(fmt.Sprintf("Checksum written to %s", sidecar))
+		}
+	}
+
+	if err := writeOutputs(p.OutputFile); err != nil {
+		return exitcode.Wrap(exitcode.Output, fmt.Errorf("cannot write the outputs: %w", err))
+	}
+
+	if buildContext.ManifestFile != "" {
+		if err := writeManifest(p.OutputFile, buildContext.ManifestFile); err != nil {
+			return exitcode.Wrap(exitcode.Output, fmt.Errorf("cannot create the build manifest: %w", err))
+		}
+		ui.PrintItem(fmt.Sprintf("Build manifest written to %s", buildContext.ManifestFile))
+	}
+
+	if buildContext.BOMFile != "" {
+		if err := writeBOM(p.OutputFile, buildContext.BOMFile); err != nil {
+			return exitcode.Wrap(exitcode.Output, fmt.Errorf("cannot create the bill of materials: %w", err))
+		}
+		ui.PrintItem(fmt.Sprintf("Bill of materials written to %s", buildContext.BOMFile))
+	}
+
+	// The plate layout is read back from the output for the SVG and the utilization summary
+	plateLayout, layoutErr := readLayout(p.OutputFile)
+	if buildContext.LayoutSVGFile != "" {
+		if layoutErr != nil {
+			return exitcode.Wrap(exitcode.Output, fmt.Errorf("cannot create the layout SVG: %w", layoutErr))
+		}
+		if err := plateLayout.WriteSVG(buildContext.LayoutSVGFile); err != nil {
+			return exitcode.Wrap(exitcode.Output, err)
+		}
+		ui.PrintItem(fmt.Sprintf("Layout SVG written to %s", buildContext.LayoutSVGFile))
+	}
+
+	if buildContext.ArrangementJSONFile != "" {
+		if layoutErr != nil {
+			return exitcode.Wrap(exitcode.Output, fmt.Errorf("cannot write the packing results: %w", layoutErr))
+		}
+		if err := writeResults(plateLayout, buildContext.ArrangementJSONFile); err != nil {
+			return exitcode.Wrap(exitcode.Output, err)
+		}
+		if buildContext.ArrangementJSONFile != StdoutPath {
+			ui.PrintItem(fmt.Sprintf("Packing results written to %s", buildContext.ArrangementJSONFile))
+		}
+	}
+
+	if buildContext.ReportFile != "" {
+		if layoutErr != nil {
+			return exitcode.Wrap(exitcode.Output, fmt.Errorf("cannot create the build report: %w", layoutErr))
+		}
+		if err := writeReport(p.OutputFile, buildContext.ReportFile, plateLayout); err != nil {
+			return exitcode.Wrap(exitcode.Output, fmt.Errorf("cannot create the build report: %w", err))
+		}
+		ui.PrintItem(fmt.Sprintf("Build report written to %s", buildContext.ReportFile))
+	}
+
+	var usage []layout.PlateUsage
+	if layoutErr == nil {
+		usage = usedPlates(plateLayout.Usage())
+	}
+	minUtilization, maxUtilization := utilizationLimits()
+	if minUtilization > 0 || maxUtilization > 0 {
+		if layoutErr != nil {
+			return exitcode.Wrap(exitcode.Output, fmt.Errorf("cannot check the plate utilization: %w", layoutErr))
+		}
+		if err := checkUtilization(usage, minUtilization, maxUtilization); err != nil {
+			ui.PrintSeparator()
+			printUsage(usage)
+			return exitcode.Wrap(exitcode.Utilization, err)
+		}
+	}
+
+	if buildContext.Estimate {
+		if err := printEstimate(p.OutputFile); err != nil {
+			ui.PrintWarning(fmt.Sprintf("Cannot estimate the print: %v", err))
+		}
+	}
+
+	// Stream the result to stdout if requested
+	if buildContext.StdoutTempFile != "" {
+		if err := streamToStdout(buildContext.StdoutTempFile); err != nil {
+			return err
+		}
+		p.OutputFile = ""
+		ui.PrintSeparator()
+		ui.PrintSuccess("Build completed successfully!")
+		ui.PrintKeyValue("Output file", "stdout")
+		printUsage(usage)
+		return nil
+	}
+
 	ui.PrintSeparator()
 	ui.PrintSuccess("Build completed successfully!")
 	if p.OutputFile != "" {
@@ -255,74 +711,1377 @@ func (p *BuildPlan) Execute() error {
 		}
 		ui.PrintKeyValue("Output file", relPath)
 	}
+	printUsage(usage)
+	if ui.IsVerbose() {
+		printStepTimings(recorder)
+	}
 	return nil
 }
 
-// DetectFileType determines the file type based on extension
-func (p *Planner) DetectFileType(path string) FileType {
-	return detectFileType(path)
+// printStepTimings prints the duration and peak memory of every build step
+func printStepTimings(recorder *telemetry.Recorder) {
+	ui.PrintHeader("Build steps")
+	ui.PrintTableHeader("Step", "Time", "Peak memory", "OpenSCAD peak memory")
+	for _, step := range recorder.Steps {
+		ui.PrintTableRow(step.Name, step.Duration.Round(time.Millisecond).String(), formatMemory(step.PeakMemory), formatMemory(step.RenderPeakMemory))
+	}
+	ui.PrintKeyValue("Total time", recorder.Total().Round(time.Millisecond).String())
 }
 
-// detectFileType determines the file type based on extension (internal helper)
-func detectFileType(path string) FileType {
-	// Handle colon-separated format (e.g., "file.scad:name:slot")
-	// Extract just the file path part
-	if colonIdx := strings.Index(path, ":"); colonIdx != -1 {
-		path = path[:colonIdx]
+// formatMemory formats a memory size for display ("-" = unknown)
+func formatMemory(b uint64) string {
+	if b == 0 {
+		return "-"
 	}
+	return telemetry.FormatBytes(b)
+}
 
-	ext := strings.ToLower(filepath.Ext(path))
-	switch ext {
-	case ".yaml", ".yml":
-		return FileTypeYAML
-	case ".scad":
-		return FileTypeSCAD
-	case ".3mf":
-		return FileType3MF
-	case ".stl":
-		return FileTypeSTL
-	default:
-		return FileTypeUnknown
+// exportSpans sends the build steps as OpenTelemetry spans. A failed export is
+// reported as a warning and does not fail the build.
+func exportSpans(steps []telemetry.StepTiming, buildErr error) {
+	attributes := map[string]string{}
+	if buildContext.ConfigFile != "" {
+		attributes["go3mf.config"] = buildContext.ConfigFile
+	}
+	if buildContext.OutputTarget != "" {
+		attributes["go3mf.output"] = buildContext.OutputTarget
+	}
+	exporter := telemetry.NewExporter(buildContext.OTLPEndpoint, "go3mf", version.Version)
+	if err := exporter.Export("go3mf build", attributes, steps, buildErr); err != nil {
+		ui.PrintWarning(err.Error())
+	}
+}
+
+// prepareOutput resolves the output path for a build. For StdoutPath the
+// result is written to a temporary file that is streamed to stdout after the
+// build, and all UI output is moved to stderr to keep the stream clean.
+func prepareOutput(outputFile string) (string, error) {
+	if outputFile != StdoutPath {
+		return prepareFileOutput(outputFile)
+	}
+
+	if buildContext.Checksum {
+		return "", exitcode.Wrap(exitcode.Usage, fmt.Errorf("--checksum requires an output file, not stdout"))
+	}
+
+	ui.SetOutput(os.Stderr)
+
+	tmp, err := os.CreateTemp("", "go3mf_stdout_*.3mf")
+	if err != nil {
+		return "", exitcode.Wrap(exitcode.Output, fmt.Errorf("failed to create temporary output file: %w", err))
+	}
+	tmp.Close()
+
+	buildContext.StdoutTempFile = tmp.Name()
+	return tmp.Name(), nil
+}
+
+// expandOutput replaces the variables of an output file name template for the
+// current build; {git} is the commit of the repository of dir
+func expandOutput(template, dir string) (string, error) {
+	return config.ExpandOutput(template, config.OutputVars{
+		Profile: buildContext.Profile,
+		Dir:     dir,
+		Date:    time.Now(),
+	})
+}
+
+// prepareFileOutput checks that the output file can be written and returns a temporary
+// file next to it. The build is written to the temporary file, which replaces the
+// output once the build succeeded, so a failed build never leaves a broken output.
+func prepareFileOutput(outputFile string) (string, error) {
+	if err := preconditions.ValidateOutputPath(outputFile); err != nil {
+		return "", exitcode.Wrap(exitcode.Output, err)
+	}
+	if !buildContext.Force {
+		if err := preconditions.CheckOverwrite(outputFile); err != nil {
+			return "", exitcode.Wrap(exitcode.Output, err)
+		}
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(outputFile), ".go3mf-*-"+filepath.Base(outputFile))
+	if err != nil {
+		return "", exitcode.Wrap(exitcode.Output, fmt.Errorf("output directory is not writable: %w", err))
+	}
+	tmp.Close()
+	// Temporary files are private, outputs are not
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		os.Remove(tmp.Name())
+		return "", exitcode.Wrap(exitcode.Output, fmt.Errorf("failed to create output file: %w", err))
+	}
+
+	buildContext.TempOutputFile = tmp.Name()
+	buildContext.OutputTarget = outputFile
+	return tmp.Name(), nil
+}
+
+// displayOutput returns the name of an output file for messages: the output a
+// temporary file stands for
+func displayOutput(path string) string {
+	switch path {
+	case buildContext.TempOutputFile:
+		return buildContext.OutputTarget
+	case buildContext.StdoutTempFile:
+		return "stdout"
+	}
+	return path
+}
+
+// writeResults writes the final positions of the objects on their plates as JSON
+// to a file or, for StdoutPath, to stdout
+func writeResults(plateLayout *layout.Layout, path string) error {
+	if path == StdoutPath {
+		return plateLayout.WriteResults(os.Stdout)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("cannot write the packing results: %w", err)
+	}
+	if err := plateLayout.WriteResults(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// readLayout reads the plate layout of the built 3MF file
+func readLayout(outputFile string) (*layout.Layout, error) {
+	if outputFile == "" {
+		return nil, fmt.Errorf("no output file")
+	}
+	model, settings, err := inspect.NewInspector().Read3MFFile(outputFile)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read %s: %w", outputFile, err)
+	}
+	return layout.FromModel(model, settings, printerProfile())
+}
+
+// writeManifest writes the build manifest of the output: the objects and transforms
+// of the output, the input files with their hashes and the sources of the parts
+func writeManifest(outputFile, manifestFile string) error {
+	model, settings, err := inspect.NewInspector().Read3MFFile(outputFile)
+	if err != nil {
+		return fmt.Errorf("cannot read %s: %w", outputFile, err)
+	}
+	m, err := manifest.FromModel(model, settings)
+	if err != nil {
+		return err
+	}
+	m.Generator = "go3mf " + version.Version
+
+	addFile := func(path string) (manifest.File, error) {
+		file, err := manifest.HashFile(path)
+		if err != nil {
+			return file, err
+		}
+		file.Path = manifest.RelativePath(manifestFile, path)
+		return file, nil
+	}
+
+	if m.Output, err = addFile(outputFile); err != nil {
+		return err
+	}
+	if outputFile == buildContext.StdoutTempFile {
+		m.Output.Path = StdoutPath
+	}
+	if buildContext.ConfigFile != "" && buildContext.ConfigFile != config.StdinPath {
+		file, err := addFile(buildContext.ConfigFile)
+		if err != nil {
+			return err
+		}
+		m.Config = &file
+	}
+	for _, path := range buildContext.InputFiles {
+		file, err := addFile(path)
+		if err != nil {
+			return err
+		}
+		m.AddInput(file)
+	}
+
+	sources := partSources()
+	for i := range m.Objects {
+		parts := sources[m.Objects[i].Name]
+		for j := range m.Objects[i].Parts {
+			if j >= len(parts) {
+				break
+			}
+			source := partSource(parts[j], manifestFile)
+			if source.File != "" {
+				file, err := addFile(sourcePath(parts[j]))
+				if err != nil {
+					return err
+				}
+				m.AddInput(file)
+			}
+			m.Objects[i].Parts[j].Source = source
+		}
+	}
+
+	return m.Save(manifestFile)
+}
+
+// partSources returns the parts of every object by object name. Parts are matched
+// to the objects of the output by object name and part order.
+func partSources() map[string][]models.ScadFile {
+	sources := make(map[string][]models.ScadFile)
+	for _, scadFile := range buildContext.SCADFiles {
+		sources[scadFile.Name] = []models.ScadFile{scadFile}
+	}
+	for _, group := range buildContext.ObjectGroups {
+		sources[group.Name] = group.Parts
+	}
+	for _, plate := range buildContext.PlateGroups {
+		for _, group := range plate.Objects {
+			sources[group.Name] = group.Parts
+		}
+	}
+	return sources
+}
+
+// writeBOM writes the bill of materials of the output: every part with its
+// quantity, filament, volume, estimated weight and source
+func writeBOM(outputFile, bomFile string) error {
+	items, err := partItems(outputFile, bomFile)
+	if err != nil {
+		return err
+	}
+
+	// Copies of an object (count) are listed once with their quantity
+	copyOf := copyNames()
+	for i := range items {
+		items[i].Object = copyOf(items[i].Object)
+	}
+
+	return bom.WriteCSV(bomFile, bom.Merge(items), filamentDensities())
+}
+
+// copyNames returns a function that maps the names of the copies of an object
+// (count), e.g. box_2, to the name of the object. Other names are kept.
+func copyNames() func(string) string {
+	copies := make(map[string]string)
+	if cfg := buildContext.YAMLConfig; cfg != nil {
+		objects := cfg.Objects
+		for _, plate := range cfg.Plates {
+			objects = append(objects, plate.Objects...)
+		}
+		for _, obj := range objects {
+			for i := 1; i <= obj.Count && obj.Count > 1; i++ {
+				copies[fmt.Sprintf("%s_%d", obj.Name, i)] = obj.Name
+			}
+		}
+	}
+	return func(name string) string {
+		if base, ok := copies[name]; ok {
+			return base
+		}
+		return name
+	}
+}
+
+// printEstimate prints the estimated filament and print time of every object of
+// the output and in total. With a slicer, the total is the slicer's estimate.
+func printEstimate(outputFile string) error {
+	model, settings, err := inspect.NewInspector().Read3MFFile(outputFile)
+	if err != nil {
+		return fmt.Errorf("cannot read %s: %w", outputFile, err)
+	}
+	estimateSettings := estimate.DefaultSettings
+	estimateSettings.Densities = filamentDensities()
+	estimates, err := estimate.FromModel(model, settings, estimateSettings)
+	if err != nil {
+		return err
+	}
+	grams, total := estimate.Total(estimates, estimateSettings)
+	source := "heuristic"
+	if buildContext.Slicer != "" {
+		if result, err := estimate.Slice(buildContext.Slicer, outputFile); err != nil {
+			ui.PrintWarning(fmt.Sprintf("Cannot get the estimate of the slicer, using the heuristic: %v", err))
+		} else {
+			grams, total, source = result.Grams, result.Time, filepath.Base(buildContext.Slicer)
+		}
+	}
+
+	ui.PrintHeader("Print Estimate")
+	ui.PrintTableHeader("Object", "Copies", "Filament", "Time (each)")
+	for _, e := range estimate.Merge(estimates, copyNames()) {
+		ui.PrintTableRow(e.Name, strconv.Itoa(e.Quantity), fmt.Sprintf("%.1f g", e.Grams), estimate.FormatDuration(e.Time))
+	}
+	ui.PrintKeyValue("Total", fmt.Sprintf("%.1f g, %s (%s)", grams, estimate.FormatDuration(total), source))
+	return nil
+}
+
+// writeReport writes the HTML build report with the plate layout, the statistics
+// of all parts and the warnings of the build
+func writeReport(outputFile, reportFile string, plateLayout *layout.Layout) error {
+	items, err := partItems(outputFile, reportFile)
+	if err != nil {
+		return err
+	}
+
+	output := displayOutput(outputFile)
+	title := filepath.Base(output)
+	if buildContext.ConfigFile != "" && buildContext.ConfigFile != config.StdinPath {
+		title = filepath.Base(buildContext.ConfigFile)
+	}
+	r := &report.Report{
+		Title:    title,
+		Output:   output,
+		Layout:   plateLayout,
+		Parts:    items,
+		Warnings: ui.Warnings(),
+		Steps:    buildContext.StepTimings,
+		Density:  filamentDensities(),
+	}
+	return r.Write(reportFile)
+}
+
+// partItems returns every part of the output with its filament, volume and
+// source. Sources are relative to the directory of relativeTo.
+func partItems(outputFile, relativeTo string) ([]bom.Item, error) {
+	model, settings, err := inspect.NewInspector().Read3MFFile(outputFile)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read %s: %w", outputFile, err)
+	}
+	items, err := bom.FromModel(model, settings)
+	if err != nil {
+		return nil, err
+	}
+
+	// Items are listed by object, so the part index restarts with every object
+	sources := partSources()
+	object, j := "", 0
+	for i := range items {
+		if items[i].Object != object {
+			object, j = items[i].Object, 0
+		}
+		if parts := sources[object]; j < len(parts) {
+			if parts[j].Generator != "" {
+				items[i].Source = parts[j].Generator + " generator"
+			} else {
+				items[i].Source = manifest.RelativePath(relativeTo, sourcePath(parts[j]))
+			}
+		}
+		j++
+	}
+	return items, nil
+}
+
+// partSource returns the input and render parameters of a part for the build manifest
+func partSource(part models.ScadFile, manifestFile string) *manifest.Source {
+	source := &manifest.Source{
+		Generator:     part.Generator,
+		Params:        part.Params,
+		Fn:            part.Quality.Fn,
+		Fa:            part.Quality.Fa,
+		Fs:            part.Quality.Fs,
+		ExtrudeHeight: part.ExtrudeHeight,
+	}
+	if part.Generator == "" {
+		source.File = manifest.RelativePath(manifestFile, sourcePath(part))
+	}
+	if part.RotationX != 0 || part.RotationY != 0 || part.RotationZ != 0 {
+		source.Rotation = []float64{part.RotationX, part.RotationY, part.RotationZ}
+	}
+	if part.WorkDir != "" {
+		source.WorkDir = manifest.RelativePath(manifestFile, part.WorkDir)
+	}
+	for _, dir := range part.LibraryPaths {
+		source.LibraryPaths = append(source.LibraryPaths, manifest.RelativePath(manifestFile, dir))
+	}
+	if len(part.ConfigFiles) > 0 {
+		source.ConfigFiles = make(map[string]string, len(part.ConfigFiles))
+		for name, content := range part.ConfigFiles {
+			source.ConfigFiles[name] = manifest.HashContent(content)
+		}
+	}
+	return source
+}
+
+// sourcePath returns the input file of a part, not the wrapper generated for it
+func sourcePath(part models.ScadFile) string {
+	if part.SourcePath != "" {
+		return part.SourcePath
+	}
+	return part.Path
+}
+
+// usedPlates returns the usage of the plates that hold at least one object
+func usedPlates(usage []layout.PlateUsage) []layout.PlateUsage {
+	var used []layout.PlateUsage
+	for _, u := range usage {
+		if u.Objects > 0 {
+			used = append(used, u)
+		}
+	}
+	return used
+}
+
+// printUsage prints the number of used plates, the total footprint and the
+// utilization of the plate area
+func printUsage(usage []layout.PlateUsage) {
+	if len(usage) == 0 {
+		return
+	}
+
+	var footprint, area float64
+	var perPlate []string
+	for _, u := range usage {
+		footprint += u.Footprint
+		area += u.Area
+		perPlate = append(perPlate, fmt.Sprintf("plate %d: %.1f%%", u.Plate, u.Utilization()))
+	}
+
+	utilization := fmt.Sprintf("%.1f%%", footprint/area*100)
+	if len(usage) > 1 {
+		utilization += " (" + strings.Join(perPlate, ", ") + ")"
+	}
+	ui.PrintKeyValue("Plates used", fmt.Sprint(len(usage)))
+	ui.PrintKeyValue("Footprint", fmt.Sprintf("%.0f mm²", footprint))
+	ui.PrintKeyValue("Utilization", utilization)
+}
+
+// checkUtilization returns an error if a used plate is covered less than minUtilization
+// or more than maxUtilization percent (0 disables a limit)
+func checkUtilization(usage []layout.PlateUsage, minUtilization, maxUtilization float64) error {
+	var problems []string
+	for _, u := range usage {
+		switch {
+		case minUtilization > 0 && u.Utilization() < minUtilization:
+			problems = append(problems, fmt.Sprintf("plate %d is %.1f%% used, below the minimum of %.1f%%", u.Plate, u.Utilization(), minUtilization))
+		case maxUtilization > 0 && u.Utilization() > maxUtilization:
+			problems = append(problems, fmt.Sprintf("plate %d is %.1f%% used, above the maximum of %.1f%%", u.Plate, u.Utilization(), maxUtilization))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("plate utilization out of range: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// prepareOutputs expands the variables of the further outputs of a config and
+// checks that their files can be written
+func prepareOutputs(outputs []models.YamlOutput, dir string) ([]models.YamlOutput, error) {
+	var prepared []models.YamlOutput
+	for _, output := range outputs {
+		path, err := expandOutput(output.Path, dir)
+		if err != nil {
+			return nil, exitcode.Wrap(exitcode.Config, fmt.Errorf("outputs: %w", err))
+		}
+		output.Path = path
+		if format, _ := models.ParseOutputFormat(output.Format); !format.IsDirectory() {
+			if err := preconditions.ValidateOutputPath(path); err != nil {
+				return nil, exitcode.Wrap(exitcode.Output, err)
+			}
+			if !buildContext.Force {
+				if err := preconditions.CheckOverwrite(path); err != nil {
+					return nil, exitcode.Wrap(exitcode.Output, err)
+				}
+			}
+		}
+		prepared = append(prepared, output)
+	}
+	return prepared, nil
+}
+
+// writeOutputs writes the further outputs of the config from the output of the
+// build, so the meshes are rendered once for all of them
+func writeOutputs(outputFile string) error {
+	for _, output := range buildContext.Outputs {
+		format, _ := models.ParseOutputFormat(output.Format)
+		message := fmt.Sprintf("Output (%s) written to %s", format, output.Path)
+		var err error
+		switch format {
+		case models.OutputFormatBambu:
+			err = copyFile(outputFile, output.Path)
+		case models.OutputFormat3MF:
+			err = threemf.WritePlain(outputFile, output.Path)
+		case models.OutputFormatSTL, models.OutputFormatObjects:
+			var files []string
+			files, err = inspect.NewInspector().ExportObjects(outputFile, output.Path, format, copyNames())
+			message = fmt.Sprintf("%d object(s) written (%s) to %s", len(files), format, output.Path)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", output.Path, err)
+		}
+		ui.PrintItem(message)
+	}
+	return nil
+}
+
+// writeThumbnails renders a PNG preview of every object of the output. The
+// previews are written to the directory of the thumbnails and/or embedded in the
+// output below Auxiliaries/thumbnails/. The copies of an object share a preview.
+func writeThumbnails(outputFile string, thumbnails *models.YamlThumbnails) error {
+	inspector := inspect.NewInspector()
+	model, settings, err := inspector.Read3MFFile(outputFile)
+	if err != nil {
+		return fmt.Errorf("cannot read %s: %w", outputFile, err)
+	}
+	objects, err := thumbnail.FromModel(model, settings, filamentColors(inspector))
+	if err != nil {
+		return err
+	}
+	if thumbnails.Dir != "" {
+		if err := os.MkdirAll(thumbnails.Dir, 0755); err != nil {
+			return fmt.Errorf("error creating directory: %w", err)
+		}
+	}
+	size := thumbnails.Size
+	if size == 0 {
+		size = thumbnail.DefaultSize
+	}
+
+	name := copyNames()
+	rendered := make(map[string]bool) // Names of the rendered objects
+	used := make(map[string]bool)     // Names of the written files
+	embedded := make(map[string][]byte)
+	for _, obj := range objects {
+		objectName := name(obj.Name)
+		if rendered[objectName] {
+			continue
+		}
+		rendered[objectName] = true
+
+		data, err := thumbnail.EncodePNG(thumbnail.Render(obj.Faces, size))
+		if err != nil {
+			return fmt.Errorf("object %s: %w", objectName, err)
+		}
+		base := extract.SafeFilename(objectName, false)
+		file := base + ".png"
+		for n := 2; used[file]; n++ {
+			file = fmt.Sprintf("%s_%d.png", base, n)
+		}
+		used[file] = true
+
+		if thumbnails.Dir != "" {
+			if err := os.WriteFile(filepath.Join(thumbnails.Dir, file), data, 0644); err != nil {
+				return err
+			}
+		}
+		if thumbnails.Embed {
+			embedded["Auxiliaries/thumbnails/"+file] = data
+		}
+	}
+
+	if len(embedded) > 0 {
+		if err := threemf.AddEntries(outputFile, embedded); err != nil {
+			return err
+		}
+	}
+	if thumbnails.Dir != "" {
+		ui.PrintItem(fmt.Sprintf("%d thumbnail(s) written to %s", len(used), thumbnails.Dir))
+	}
+	if thumbnails.Embed {
+		ui.PrintItem(fmt.Sprintf("%d thumbnail(s) embedded in the output", len(used)))
+	}
+	return nil
+}
+
+// copyFile copies a file
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// streamToStdout copies the temporary output file to stdout
+func streamToStdout(tempFile string) error {
+	f, err := os.Open(tempFile)
+	if err != nil {
+		return exitcode.Wrap(exitcode.Output, fmt.Errorf("failed to open output file: %w", err))
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(os.Stdout, f); err != nil {
+		return exitcode.Wrap(exitcode.Output, fmt.Errorf("failed to write output to stdout: %w", err))
+	}
+	return nil
+}
+
+// DetectFileType determines the file type based on extension
+func (p *Planner) DetectFileType(path string) FileType {
+	return detectFileType(path)
+}
+
+// detectFileType determines the file type based on extension (internal helper)
+func detectFileType(path string) FileType {
+	// Handle colon-separated format (e.g., "file.scad:name:slot")
+	// Extract just the file path part
+	if colonIdx := strings.Index(path, ":"); colonIdx != -1 {
+		path = path[:colonIdx]
+	}
+
+	ext := strings.ToLower(filepath.Ext(path))
+	switch ext {
+	case ".yaml", ".yml":
+		return FileTypeYAML
+	case ".scad":
+		return FileTypeSCAD
+	case ".3mf":
+		return FileType3MF
+	case ".stl":
+		return FileTypeSTL
+	default:
+		return FileTypeUnknown
+	}
+}
+
+// getFileTypeNames returns a list of file type names for error messages
+func getFileTypeNames(fileTypes map[FileType][]string) []string {
+	var names []string
+	for ft := range fileTypes {
+		switch ft {
+		case FileTypeYAML:
+			names = append(names, "YAML")
+		case FileTypeSCAD:
+			names = append(names, "SCAD")
+		case FileType3MF:
+			names = append(names, "3MF")
+		case FileTypeSTL:
+			names = append(names, "STL")
+		}
+	}
+	return names
+}
+
+// Context holds shared data between build steps
+type Context struct {
+	mu sync.Mutex
+
+	YAMLConfig       *models.YamlConfig
+	SCADFiles        []models.ScadFile
+	ObjectGroups     []models.ObjectGroup // Object groups with normalization settings
+	PlateGroups      []models.PlateGroup  // Plate groups for multi-plate builds
+	RenderedFiles    []string
+	GeneratedFiles   []string // Temporary files among RenderedFiles, removed after combining
+	GeneratedSources []string // Temporary OpenSCAD sources of generated parts, removed after the build
+	OutputFile       string
+	ConfigDir        string   // Directory where the config.yaml file is located
+	OriginalSTLs     []string // Store original STL filenames for proper naming
+	PlateWidth       float64  // Width of a single plate (for multi-plate positioning)
+	Debug            bool     // Enable debug output
+	KeepGoing        bool     // Process all files and report all failures at the end
+	Jobs             int      // Files converted and SCAD parts rendered in parallel from the command line (0 = one file, renders use YAML or one per CPU)
+
+	PackingDistance  float64                 // Distance between objects from the command line (0 = use YAML or default)
+	PackingAlgorithm models.PackingAlgorithm // Packing algorithm from the command line ("" = use YAML or default)
+	PackingOrder     models.PackingOrder     // Packing order from the command line ("" = use YAML or default)
+	PlacementGrid    float64                 // Placement grid from the command line (0 = use YAML, no snapping by default)
+	MaxRowWidth      float64                 // Maximum row width of the default packer from the command line (0 = plate width)
+	Footprint        models.Footprint        // Footprint from the command line ("" = use YAML or bbox)
+	PackingOrigin    models.PackingOrigin    // Packing origin from the command line ("" = use YAML or front)
+	Normalization    models.Normalization    // Z normalization from the command line ("" = use YAML or ground)
+
+	ArrangementFile       string // Arrangement file with fixed object placements ("" = pack all objects)
+	ExportArrangementFile string // File to write the final object placements to ("" = no export)
+	LayoutSVGFile         string // File to render the final plate layout to ("" = no SVG)
+	ArrangementJSONFile   string // File to write the final positions of the objects on their plates to ("" = none, "-" = stdout)
+
+	Printer        string            // Printer from the command line ("" = use YAML or default)
+	Profile        string            // Build profile of the YAML config from the command line ("" = none)
+	OutputTemplate string            // Output file name template from the command line ("" = none, see config.ExpandOutput)
+	Vars           map[string]string // Variables of the YAML config from the command line
+
+	Renderer      models.Renderer // Renderer from the command line ("" = use YAML or local)
+	RendererImage string          // Docker image of the docker renderer from the command line ("" = use YAML or default)
+	RenderWorkers []string        // SSH destinations of the render workers from the command line (nil = use YAML)
+	Precision     int             // Decimals of written vertex coordinates from the command line (0 = use YAML or default)
+
+	CacheDir  string            // Render cache directory from the command line ("" = use the workspace cache, if any)
+	Workspace *models.Workspace // Workspace the YAML config is a member of (nil = none)
+
+	MinUtilization float64 // Minimum plate utilization in percent from the command line (0 = use YAML)
+	MaxUtilization float64 // Maximum plate utilization in percent from the command line (0 = use YAML)
+
+	StdoutTempFile string                 // Temporary output file streamed to stdout after the build ("-o -")
+	TempOutputFile string                 // Temporary output file that replaces OutputTarget once the build succeeded
+	OutputTarget   string                 // Output file the build is written to
+	Outputs        []models.YamlOutput    // Further outputs of the YAML config, with their variables expanded
+	Thumbnails     *models.YamlThumbnails // Object previews of the YAML config, with the variables of the directory expanded (nil = none)
+	Force          bool                   // Overwrite existing output files that are no 3MF files
+	Checksum       bool                   // Write a .sha256 sidecar and stamp the version and geometry hash into the model
+	ManifestFile   string                 // File to write the JSON build manifest to ("" = no manifest)
+	BOMFile        string                 // File to write the bill of materials to as CSV ("" = no BOM)
+	Estimate       bool                   // Print the estimated filament and print time
+	Slicer         string                 // Slicer command line for the estimate ("" = heuristic only)
+	ReportFile     string                 // File to write the HTML build report to ("" = no report)
+	OTLPEndpoint   string                 // OpenTelemetry collector to export the build steps to ("" = no export)
+	Resume         bool                   // Continue a failed build from the state of the previous run
+	ResumeKey      string                 // Identifies the build across runs for --resume
+	ResumeDir      string                 // Directory keeping the state of the build until it succeeded ("" = not kept)
+	ConfigHash     string                 // Identifies the inputs of the parsed YAML config ("" = read from stdin)
+	ParsedConfig   []byte                 // YAML config as parsed, before its output was resolved, kept for --resume
+	StepTimings    []telemetry.StepTiming // Duration and peak memory of the executed build steps
+	ConfigFile     string                 // YAML config of the build ("" = none)
+	InputFiles     []string               // 3MF or STL files combined without object groups
+	Auxiliary      models.AuxiliaryPolicy // Auxiliary archive entries of the inputs copied to the output
+	IgnoreExt      bool                   // Combine inputs requiring unsupported 3MF extensions instead of failing
+	CompactXML     bool                   // Write the model XML without indentation
+	Flatten        bool                   // Merge objects made of components of input 3MF files into one mesh each
+	Explode        []string               // Objects built with every part as an object of its own
+	StripMetadata  bool                   // Write a plain geometric model without slicer settings, thumbnails and vendor metadata
+	Verify         bool                   // Read the output back and fail the build if it is structurally broken
+}
+
+var buildContext = &Context{}
+
+// Lock locks the context for a step that runs concurrently with others. The
+// built-in steps hold the lock while they run.
+func (c *Context) Lock() {
+	c.mu.Lock()
+}
+
+// Unlock unlocks the context locked by Lock
+func (c *Context) Unlock() {
+	c.mu.Unlock()
+}
+
+// defaultCacheDir is the render cache of builds without another cache ("" = none)
+var defaultCacheDir string
+
+// Reset discards the context of the previous build, so that a long-running
+// process can run several builds one after another
+func Reset() {
+	buildContext = &Context{}
+}
+
+// SetDefaultCacheDir sets the render cache directory of builds that have no
+// cache directory from the command line or their workspace
+func SetDefaultCacheDir(dir string) {
+	defaultCacheDir = dir
+}
+
+// SetDebug enables or disables debug mode
+func SetDebug(debug bool) {
+	buildContext.Debug = debug
+}
+
+// SetKeepGoing enables or disables collect-all-errors mode.
+// When enabled, all files are processed even if some fail, and all failures
+// are reported together at the end instead of aborting on the first one.
+func SetKeepGoing(keepGoing bool) {
+	buildContext.KeepGoing = keepGoing
+}
+
+// SetJobs sets the number of files converted in parallel, which also overrides
+// the number of local renders at a time of the YAML configuration (0 keeps the
+// configured one)
+func SetJobs(jobs int) {
+	buildContext.Jobs = jobs
+}
+
+// SetAuxiliaryPolicy sets which auxiliary archive entries (thumbnails, custom
+// metadata, ...) of the input files are copied to the output
+func SetAuxiliaryPolicy(policy models.AuxiliaryPolicy) {
+	buildContext.Auxiliary = policy
+}
+
+// SetStripMetadata removes the slicer settings, thumbnails, auxiliary files and
+// vendor metadata from the output
+func SetStripMetadata(strip bool) {
+	buildContext.StripMetadata = strip
+}
+
+// SetVerify reads the output back after writing it and fails the build if it is
+// structurally broken
+func SetVerify(verify bool) {
+	buildContext.Verify = verify
+}
+
+// SetCompactXML writes the model XML without indentation instead of pretty printed
+func SetCompactXML(compact bool) {
+	buildContext.CompactXML = compact
+}
+
+// SetFlatten merges the objects made of components of input 3MF files into one
+// mesh each
+func SetFlatten(flatten bool) {
+	buildContext.Flatten = flatten
+}
+
+// SetExplode builds every part of the named objects as an object of its own
+func SetExplode(names []string) {
+	buildContext.Explode = names
+}
+
+// SetIgnoreExtensions sets whether 3MF inputs with unsupported extensions are combined
+func SetIgnoreExtensions(ignore bool) {
+	buildContext.IgnoreExt = ignore
+}
+
+// SetPacking overrides the packing distance, algorithm and order of the YAML configuration.
+// A zero distance or an empty algorithm or order keeps the configured (or default) value.
+func SetPacking(distance float64, algorithm models.PackingAlgorithm, order models.PackingOrder) {
+	buildContext.PackingDistance = distance
+	buildContext.PackingAlgorithm = algorithm
+	buildContext.PackingOrder = order
+}
+
+// SetPlacementGrid overrides the placement grid of the YAML configuration (0 keeps the configured value)
+func SetPlacementGrid(grid float64) {
+	buildContext.PlacementGrid = grid
+}
+
+// SetMaxRowWidth limits the rows of the default packer to the given width in mm (0 = the plate width)
+func SetMaxRowWidth(width float64) {
+	buildContext.MaxRowWidth = width
+}
+
+// SetFootprint overrides the footprint of the YAML configuration ("" keeps the configured value)
+func SetFootprint(footprint models.Footprint) {
+	buildContext.Footprint = footprint
+}
+
+// SetPackingOrigin overrides the packing origin of the YAML configuration ("" keeps the configured value)
+func SetPackingOrigin(origin models.PackingOrigin) {
+	buildContext.PackingOrigin = origin
+}
+
+// SetNormalization overrides the Z normalization of the YAML configuration ("" keeps the configured one)
+func SetNormalization(normalize models.Normalization) {
+	buildContext.Normalization = normalize
+}
+
+// SetArrangement sets the arrangement file to place objects from and the file
+// to export the final placements to. Empty paths disable import or export.
+func SetArrangement(importFile, exportFile string) {
+	buildContext.ArrangementFile = importFile
+	buildContext.ExportArrangementFile = exportFile
+}
+
+// SetLayoutSVG sets the SVG file to render the final plate layout to ("" disables it)
+func SetLayoutSVG(path string) {
+	buildContext.LayoutSVGFile = path
+}
+
+// SetArrangementJSON sets the file to write the final positions of the objects on
+// their plates to for external tools ("" disables it, "-" writes to stdout)
+func SetArrangementJSON(path string) {
+	buildContext.ArrangementJSONFile = path
+}
+
+// SetPrinter overrides the printer of the YAML configuration ("" keeps the configured printer)
+func SetPrinter(printer string) {
+	buildContext.Printer = printer
+}
+
+// SetProfile selects the build profile of the YAML configuration ("" = none)
+func SetProfile(profile string) {
+	buildContext.Profile = profile
+}
+
+// SetVars overrides variables of the YAML configuration used by enabled_if conditions
+func SetVars(vars map[string]string) {
+	buildContext.Vars = vars
+}
+
+// SetForce allows overwriting existing output files that are no 3MF files
+func SetForce(force bool) {
+	buildContext.Force = force
+}
+
+// SetChecksum enables the checksum sidecar and the integrity metadata of the output
+func SetChecksum(checksum bool) {
+	buildContext.Checksum = checksum
+}
+
+// SetManifest sets the file to write the JSON build manifest to ("" disables it)
+func SetManifest(path string) {
+	buildContext.ManifestFile = path
+}
+
+// SetEstimate enables the print estimate after the build, sliced by the given
+// slicer command line ("" = heuristic only)
+func SetEstimate(enabled bool, slicer string) {
+	buildContext.Estimate = enabled || slicer != ""
+	buildContext.Slicer = slicer
+}
+
+// SetBOM sets the CSV file to write the bill of materials to ("" disables it)
+func SetBOM(path string) {
+	buildContext.BOMFile = path
+}
+
+// SetOutputTemplate sets the output file name template used when no output file
+// is given on the command line ("" = none, see config.ExpandOutput)
+func SetOutputTemplate(template string) {
+	buildContext.OutputTemplate = template
+}
+
+// SetReport sets the HTML file to write the build report to ("" disables it)
+func SetReport(path string) {
+	buildContext.ReportFile = path
+}
+
+// SetOTLPEndpoint sets the OpenTelemetry collector to export the build steps to as spans ("" disables the export)
+func SetOTLPEndpoint(endpoint string) {
+	buildContext.OTLPEndpoint = endpoint
+}
+
+// SetRenderer overrides the renderer and the Docker image of the YAML configuration ("" keeps the configured ones)
+func SetRenderer(kind models.Renderer, image string) {
+	buildContext.Renderer = kind
+	buildContext.RendererImage = image
+}
+
+// SetRenderWorkers overrides the render workers of the YAML configuration (nil keeps the configured ones)
+func SetRenderWorkers(workers []string) {
+	buildContext.RenderWorkers = workers
+}
+
+// renderWorkers returns the SSH destinations renders are delegated to (command line flag before YAML configuration)
+func renderWorkers() []string {
+	if len(buildContext.RenderWorkers) > 0 {
+		return buildContext.RenderWorkers
+	}
+	if cfg := buildContext.YAMLConfig; cfg != nil {
+		return cfg.RenderWorkers
+	}
+	return nil
+}
+
+// renderJobs returns the number of SCAD parts rendered at a time on this machine
+// (command line flag before YAML configuration, 0 = one per CPU)
+func renderJobs() int {
+	if buildContext.Jobs > 0 {
+		return buildContext.Jobs
+	}
+	if cfg := buildContext.YAMLConfig; cfg != nil {
+		return cfg.RenderJobs
+	}
+	return 0
+}
+
+// SetPrecision overrides the decimals of the vertex coordinates of the YAML configuration (0 keeps the configured ones)
+func SetPrecision(decimals int) {
+	buildContext.Precision = decimals
+}
+
+// precision returns the decimals of written vertex coordinates (command line flag before YAML configuration)
+func precision() int {
+	if buildContext.Precision > 0 {
+		return buildContext.Precision
+	}
+	if cfg := buildContext.YAMLConfig; cfg != nil && cfg.Precision > 0 {
+		return cfg.Precision
+	}
+	return geometry.DefaultPrecision
+}
+
+// auxiliaryPolicy returns which auxiliary archive entries are copied to the
+// output, with the settings file of the YAML configuration unless one is given
+// on the command line
+func auxiliaryPolicy() models.AuxiliaryPolicy {
+	policy := buildContext.Auxiliary
+	if cfg := buildContext.YAMLConfig; policy.Settings == "" && cfg != nil && cfg.SettingsFrom != "" {
+		policy.Settings = cfg.SettingsFrom
+		if !filepath.IsAbs(policy.Settings) {
+			policy.Settings = filepath.Join(buildContext.ConfigDir, policy.Settings)
+		}
+	}
+	return policy
+}
+
+// rendererSettings returns the renderer and its Docker image (command line flags before YAML configuration)
+func rendererSettings() (models.Renderer, string) {
+	kind, image := buildContext.Renderer, buildContext.RendererImage
+	if cfg := buildContext.YAMLConfig; cfg != nil {
+		if kind == "" {
+			kind, _ = models.ParseRenderer(cfg.Renderer)
+		}
+		if image == "" {
+			image = cfg.RendererImage
+		}
+	}
+	if kind == "" {
+		kind = models.RendererLocal
+	}
+	if image == "" {
+		image = renderer.DefaultDockerImage
+	}
+	return kind, image
+}
+
+// SetCacheDir sets the directory to cache OpenSCAD renders in ("" uses the cache of the workspace, if any)
+func SetCacheDir(dir string) {
+	buildContext.CacheDir = dir
+}
+
+// cacheDir returns the render cache directory (command line flag before workspace
+// before the renders kept for --resume before the default, "" = no caching).
+// Renders are only kept for --resume without another cache.
+func cacheDir() string {
+	if buildContext.CacheDir != "" {
+		return buildContext.CacheDir
+	}
+	if buildContext.Workspace != nil {
+		return config.CacheDir(buildContext.Workspace)
+	}
+	if buildContext.ResumeDir != "" {
+		return filepath.Join(buildContext.ResumeDir, "renders")
+	}
+	return defaultCacheDir
+}
+
+// SetResume continues a failed build from the state of the previous run
+func SetResume(resume bool) {
+	buildContext.Resume = resume
+}
+
+// resumeState is what a failed build keeps in its resume directory besides the
+// renders, so --resume continues where it stopped
+type resumeState struct {
+	Failed     string            `json:"failed"`              // Step that failed
+	Completed  []string          `json:"completed"`           // Steps completed before
+	ConfigHash string            `json:"config_hash"`         // Inputs the config was parsed from ("" = none)
+	Config     json.RawMessage   `json:"config,omitempty"`    // YAML config as parsed
+	Workspace  *models.Workspace `json:"workspace,omitempty"` // Workspace of the config
+}
+
+// resumeKey identifies a build by its working directory, inputs and output, so
+// a rerun of the same build finds the state of a failed run
+func resumeKey(inputs []string, objects []ObjectGroup, outputFile string) string {
+	var key strings.Builder
+	cwd, _ := os.Getwd()
+	key.WriteString(cwd + "\x00" + outputFile + "\x00")
+	for _, input := range inputs {
+		key.WriteString(input + "\x00")
+	}
+	for _, obj := range objects {
+		key.WriteString(obj.Name + "\x00" + strings.Join(obj.Files, "\x00") + "\x00")
+	}
+	sum := sha256.Sum256([]byte(key.String()))
+	return hex.EncodeToString(sum[:8])
+}
+
+// resumePath returns the directory for the state of the build ("" = none)
+func resumePath() string {
+	if buildContext.ResumeKey == "" {
+		return ""
+	}
+	return filepath.Join(os.TempDir(), "go3mf-resume", buildContext.ResumeKey)
+}
+
+// prepareResume selects the directory that keeps the state of a build that
+// renders until it succeeded. With another render cache, the cache keeps the
+// renders and nothing else is kept. Without --resume, the state of a failed
+// previous run is discarded and the build starts from scratch.
+func prepareResume() error {
+	dir := resumePath()
+	if dir == "" {
+		return nil
+	}
+	switch {
+	case !buildContext.Resume:
+		if hasResumeState(dir) {
+			if err := os.RemoveAll(dir); err != nil {
+				return exitcode.Wrap(exitcode.Output, fmt.Errorf("failed to remove the state of a previous build: %w", err))
+			}
+		}
+	case hasResumeState(dir):
+		state, err := readResumeState(dir)
+		if err != nil {
+			return exitcode.Wrap(exitcode.Output, fmt.Errorf("failed to read the state of the previous build: %w", err))
+		}
+		ui.PrintInfo(fmt.Sprintf("Resuming the previous build that failed at %q: unchanged renders are reused", state.Failed))
+	default:
+		ui.PrintWarning("No failed build to resume, building from scratch")
+	}
+
+	if buildContext.CacheDir != "" || buildContext.Workspace != nil || defaultCacheDir != "" {
+		return nil
+	}
+	buildContext.ResumeDir = dir
+	return nil
+}
+
+// hasResumeState reports whether a failed previous run kept its state in dir
+func hasResumeState(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, "state.json"))
+	return err == nil
+}
+
+// readResumeState reads the state a failed run kept in dir
+func readResumeState(dir string) (*resumeState, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "state.json"))
+	if err != nil {
+		return nil, err
+	}
+	var state resumeState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// writeResumeState keeps the steps of a failed build and its parsed config in dir
+func writeResumeState(dir string, steps []telemetry.StepTiming) error {
+	state := resumeState{
+		Failed:     failedStep(steps),
+		ConfigHash: buildContext.ConfigHash,
+		Config:     buildContext.ParsedConfig,
+		Workspace:  buildContext.Workspace,
+	}
+	for _, step := range steps {
+		if step.Err == nil {
+			state.Completed = append(state.Completed, step.Name)
+		}
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, "state.json"), data, 0o644)
+}
+
+// configHash identifies the inputs a YAML config is parsed from: the config
+// file, its workspace and the flags that change the parsed config ("" for
+// stdin, which cannot be read again)
+func configHash(configPath string) string {
+	if configPath == config.StdinPath {
+		return ""
+	}
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return ""
+	}
+	h := sha256.New()
+	h.Write(data)
+	if path, err := config.FindWorkspace(filepath.Dir(configPath)); err == nil && path != "" {
+		if data, err := os.ReadFile(path); err == nil {
+			h.Write(data)
+		}
+	}
+	fmt.Fprintf(h, "\x00%s\x00%s\x00%v\x00%v", buildContext.Printer, buildContext.Profile, buildContext.Vars, buildContext.Explode)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// parsedConfig is a YAML config parsed by an earlier build of the process
+type parsedConfig struct {
+	config    []byte // The config as JSON, so every build gets a copy of its own
+	workspace *models.Workspace
+	warnings  []string
+}
+
+var (
+	parsedConfigsMu sync.Mutex
+	parsedConfigs   map[string]parsedConfig // Hash of the inputs of a config -> the config (nil = not kept)
+)
+
+// KeepConfigs keeps the parsed YAML configs in memory, so a long-running process
+// only parses a config again once the config file, its workspace or the flags
+// changing the config changed
+func KeepConfigs() {
+	parsedConfigsMu.Lock()
+	defer parsedConfigsMu.Unlock()
+	if parsedConfigs == nil {
+		parsedConfigs = make(map[string]parsedConfig)
+	}
+}
+
+// keptConfig returns the config parsed from the inputs with the given hash by
+// an earlier build (nil = the config must be loaded)
+func keptConfig(hash string) (*models.YamlConfig, *models.Workspace, []string) {
+	parsedConfigsMu.Lock()
+	kept, ok := parsedConfigs[hash]
+	parsedConfigsMu.Unlock()
+	if !ok || hash == "" {
+		return nil, nil, nil
+	}
+	var cfg models.YamlConfig
+	if err := json.Unmarshal(kept.config, &cfg); err != nil {
+		return nil, nil, nil
+	}
+	return &cfg, kept.workspace, kept.warnings
+}
+
+// keepConfig keeps the config of the build, as parsed from the inputs with the
+// given hash, if configs are kept
+func keepConfig(hash string, workspace *models.Workspace, warnings []string) {
+	parsedConfigsMu.Lock()
+	defer parsedConfigsMu.Unlock()
+	if parsedConfigs != nil {
+		parsedConfigs[hash] = parsedConfig{config: buildContext.ParsedConfig, workspace: workspace, warnings: warnings}
+	}
+}
+
+// resumedConfig returns the config a failed previous run parsed from the same
+// inputs when the build is resumed (nil = the config must be loaded)
+func resumedConfig(hash string) (*models.YamlConfig, *models.Workspace) {
+	if !buildContext.Resume || hash == "" {
+		return nil, nil
+	}
+	state, err := readResumeState(resumePath())
+	if err != nil || state.ConfigHash != hash || len(state.Config) == 0 {
+		return nil, nil
+	}
+	var cfg models.YamlConfig
+	if err := json.Unmarshal(state.Config, &cfg); err != nil {
+		return nil, nil
+	}
+	return &cfg, state.Workspace
+}
+
+// failedStep returns the name of the step that failed ("" = none)
+func failedStep(steps []telemetry.StepTiming) string {
+	for _, step := range steps {
+		if step.Err != nil {
+			return step.Name
+		}
+	}
+	return ""
+}
+
+// SetUtilizationLimits overrides the plate utilization limits of the YAML configuration
+// in percent (0 keeps the configured limit)
+func SetUtilizationLimits(minUtilization, maxUtilization float64) {
+	buildContext.MinUtilization = minUtilization
+	buildContext.MaxUtilization = maxUtilization
+}
+
+// utilizationLimits returns the minimum and maximum plate utilization in percent
+// (command line flags before YAML configuration, 0 = no limit)
+func utilizationLimits() (float64, float64) {
+	minUtilization, maxUtilization := buildContext.MinUtilization, buildContext.MaxUtilization
+	if cfg := buildContext.YAMLConfig; cfg != nil {
+		if minUtilization == 0 {
+			minUtilization = cfg.MinUtilization
+		}
+		if maxUtilization == 0 {
+			maxUtilization = cfg.MaxUtilization
+		}
+	}
+	return minUtilization, maxUtilization
+}
+
+// printerProfile returns the profile of the printer to build for (command line flag
+// before YAML configuration). The printer has been validated when the plan was created
+// or the configuration was loaded, so unknown printers fall back to the default.
+func printerProfile() models.PrinterProfile {
+	name := buildContext.Printer
+	var custom map[string]models.PrinterProfile
+	if cfg := buildContext.YAMLConfig; cfg != nil {
+		if name == "" {
+			name = cfg.Printer
+		}
+		custom = cfg.Printers
+	}
+
+	profile, err := models.LookupPrinter(name, custom)
+	if err != nil {
+		profile, _ = models.LookupPrinter(models.DefaultPrinter, nil)
+	}
+	return profile
+}
+
+// packingSettings returns the packing distance and algorithm to use:
+// command line flags take precedence over the YAML configuration, then the defaults apply
+func packingSettings() (float64, models.PackingAlgorithm) {
+	distance := models.DefaultPackingDistance
+	algorithm := models.PackingAlgorithmDefault
+
+	if cfg := buildContext.YAMLConfig; cfg != nil {
+		if cfg.PackingDistance > 0 {
+			distance = cfg.PackingDistance
+		}
+		if cfg.PackingAlgorithm != "" {
+			algorithm = models.NewPackingAlgorithm(cfg.PackingAlgorithm)
+		}
+	}
+
+	if buildContext.PackingDistance > 0 {
+		distance = buildContext.PackingDistance
+	}
+	if buildContext.PackingAlgorithm != "" {
+		algorithm = buildContext.PackingAlgorithm
+	}
+
+	return distance, algorithm
+}
+
+// placementGrid returns the placement grid in mm (command line flag before YAML configuration)
+func placementGrid() float64 {
+	if buildContext.PlacementGrid > 0 {
+		return buildContext.PlacementGrid
+	}
+	if cfg := buildContext.YAMLConfig; cfg != nil {
+		return cfg.PlacementGrid
+	}
+	return 0
+}
+
+// footprint returns the footprint used for packing (command line flag before YAML configuration)
+func footprint() models.Footprint {
+	if buildContext.Footprint != "" {
+		return buildContext.Footprint
+	}
+	if cfg := buildContext.YAMLConfig; cfg != nil {
+		if parsed, err := models.ParseFootprint(cfg.Footprint); err == nil {
+			return parsed
+		}
 	}
+	return models.FootprintBBox
 }
 
-// getFileTypeNames returns a list of file type names for error messages
-func getFileTypeNames(fileTypes map[FileType][]string) []string {
-	var names []string
-	for ft := range fileTypes {
-		switch ft {
-		case FileTypeYAML:
-			names = append(names, "YAML")
-		case FileTypeSCAD:
-			names = append(names, "SCAD")
-		case FileType3MF:
-			names = append(names, "3MF")
-		case FileTypeSTL:
-			names = append(names, "STL")
+// packingOrigin returns the plate edge packed layouts start at (command line flag before YAML configuration)
+func packingOrigin() models.PackingOrigin {
+	if buildContext.PackingOrigin != "" {
+		return buildContext.PackingOrigin
+	}
+	if cfg := buildContext.YAMLConfig; cfg != nil {
+		if parsed, err := models.ParsePackingOrigin(cfg.PackingOrigin); err == nil {
+			return parsed
 		}
 	}
-	return names
+	return models.PackingOriginFront
 }
 
-// Context holds shared data between build steps
-type Context struct {
-	YAMLConfig    *models.YamlConfig
-	SCADFiles     []models.ScadFile
-	ObjectGroups  []models.ObjectGroup // Object groups with normalization settings
-	PlateGroups   []models.PlateGroup  // Plate groups for multi-plate builds
-	RenderedFiles []string
-	OutputFile    string
-	ConfigDir     string   // Directory where the config.yaml file is located
-	OriginalSTLs  []string // Store original STL filenames for proper naming
-	PlateWidth    float64  // Width of a single plate (for multi-plate positioning)
-	Debug         bool     // Enable debug output
+// normalization returns the Z normalization (command line flag before YAML configuration)
+func normalization() models.Normalization {
+	if buildContext.Normalization != "" {
+		return buildContext.Normalization
+	}
+	if cfg := buildContext.YAMLConfig; cfg != nil {
+		if parsed, err := models.ParseNormalization(cfg.Normalize); err == nil {
+			return parsed
+		}
+	}
+	return models.NormalizationGround
 }
 
-var buildContext = &Context{}
+// packingOrder returns the packing order (command line flag before YAML configuration)
+// and the sequential print layout of the YAML configuration, if any
+func packingOrder() (models.PackingOrder, *models.SequentialPrint) {
+	order := models.PackingOrderDefault
+	var sequential *models.SequentialPrint
 
-// SetDebug enables or disables debug mode
-func SetDebug(debug bool) {
-	buildContext.Debug = debug
+	if cfg := buildContext.YAMLConfig; cfg != nil {
+		if parsed, err := models.ParsePackingOrder(cfg.PackingOrder); err == nil {
+			order = parsed
+		}
+		sequential = cfg.Sequential
+	}
+
+	if buildContext.PackingOrder != "" {
+		order = buildContext.PackingOrder
+	}
+
+	return order, sequential
 }
 
 // IsDebug returns true if debug mode is enabled
@@ -350,6 +2109,7 @@ func (s *ParseObjectGroupsStep) Execute() error {
 	for _, objGroup := range s.ObjectGroups {
 		yamlObj := models.YamlObject{
 			Name:  objGroup.Name,
+			Count: objGroup.Count,
 			Parts: make([]models.YamlPart, 0, len(objGroup.Files)),
 		}
 
@@ -357,11 +2117,14 @@ func (s *ParseObjectGroupsStep) Execute() error {
 			// Parse file argument: path or path:name:slot
 			parts := strings.Split(fileArg, ":")
 			path := parts[0]
+			if len(parts) > 3 {
+				return exitcode.Wrap(exitcode.Usage, fmt.Errorf("%s: files of an object group have no count, use --count for the copies of object %s", fileArg, objGroup.Name))
+			}
 
 			// Convert to absolute path
 			absPath, err := filepath.Abs(path)
 			if err != nil {
-				return fmt.Errorf("invalid file path %s: %w", path, err)
+				return exitcode.Wrap(exitcode.Usage, fmt.Errorf("invalid file path %s: %w", path, err))
 			}
 
 			// Use custom name if provided, otherwise derive from filename
@@ -381,7 +2144,7 @@ func (s *ParseObjectGroupsStep) Execute() error {
 				if err == nil && slot >= 1 && slot <= 4 {
 					filamentSlot = slot
 				} else if parts[2] != "" {
-					return fmt.Errorf("invalid filament slot '%s' for %s. Must be 1-4", parts[2], path)
+					return exitcode.Wrap(exitcode.Usage, fmt.Errorf("invalid filament slot '%s' for %s. Must be 1-4", parts[2], path))
 				}
 			}
 
@@ -394,6 +2157,9 @@ func (s *ParseObjectGroupsStep) Execute() error {
 
 		yamlConfig.Objects = append(yamlConfig.Objects, yamlObj)
 	}
+	if err := config.ExplodeObjects(yamlConfig, buildContext.Explode); err != nil {
+		return exitcode.Wrap(exitcode.Usage, fmt.Errorf("--%w", err))
+	}
 
 	buildContext.YAMLConfig = yamlConfig
 	buildContext.OutputFile = s.OutputFile
@@ -427,23 +2193,108 @@ func pluralize(count int) string {
 // LoadYAMLStep loads and validates YAML configuration
 type LoadYAMLStep struct {
 	ConfigPath string
+	OutputFile string // Overrides the output path from the configuration if set
 	Plan       *BuildPlan
+
+	// The configuration is loaded once, possibly by the plan before the step runs
+	once      sync.Once
+	cfg       *models.YamlConfig
+	workspace *models.Workspace
+	warnings  []string
+	resumed   bool
+	err       error
 }
 
 func (s *LoadYAMLStep) Name() string {
 	return "Load YAML configuration"
 }
 
+// load loads the configuration and resolves its output path. The plan calls it
+// before printing anything, so nothing is printed to a 3MF streamed to stdout.
+func (s *LoadYAMLStep) load() error {
+	s.once.Do(func() {
+		s.err = s.loadConfig()
+	})
+	return s.err
+}
+
+func (s *LoadYAMLStep) loadConfig() error {
+	// A resumed build continues with the config parsed by the failed run
+	buildContext.ConfigHash = configHash(s.ConfigPath)
+	cfg, workspace := resumedConfig(buildContext.ConfigHash)
+	var warnings []string
+	s.resumed = cfg != nil
+	if cfg == nil {
+		cfg, workspace, warnings = keptConfig(buildContext.ConfigHash)
+	}
+	if cfg == nil {
+		loader := config.NewLoader()
+		loader.SetPrinter(buildContext.Printer)
+		loader.SetProfile(buildContext.Profile)
+		loader.SetVars(buildContext.Vars)
+		loader.SetExplode(buildContext.Explode)
+		var err error
+		if cfg, err = loader.Load(s.ConfigPath); err != nil {
+			return exitcode.Wrap(exitcode.Config, fmt.Errorf("failed to load config: %w", err))
+		}
+		workspace, warnings = loader.Workspace(), loader.Warnings()
+	}
+	if buildContext.ConfigHash != "" {
+		buildContext.ParsedConfig, _ = json.Marshal(cfg)
+		keepConfig(buildContext.ConfigHash, workspace, warnings)
+	}
+	var err error
+	switch {
+	case s.OutputFile != "":
+		cfg.Output = s.OutputFile
+	case buildContext.OutputTemplate != "":
+		cfg.Output = buildContext.OutputTemplate
+	}
+	if s.OutputFile == "" {
+		if cfg.Output, err = expandOutput(cfg.Output, filepath.Dir(s.ConfigPath)); err != nil {
+			return exitcode.Wrap(exitcode.Config, err)
+		}
+	}
+	s.cfg, s.workspace, s.warnings = cfg, workspace, warnings
+	return nil
+}
+
 func (s *LoadYAMLStep) Execute() error {
-	loader := config.NewLoader()
-	cfg, err := loader.Load(s.ConfigPath)
+	if err := s.load(); err != nil {
+		return err
+	}
+	cfg, workspace, warnings := s.cfg, s.workspace, s.warnings
+	if s.resumed {
+		ui.PrintInfo("Reusing the configuration parsed by the previous run")
+	}
+	outputFile, err := prepareOutput(cfg.Output)
 	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
+		return err
+	}
+	if buildContext.Outputs, err = prepareOutputs(cfg.Outputs, filepath.Dir(s.ConfigPath)); err != nil {
+		return err
+	}
+	buildContext.Thumbnails = nil
+	if cfg.Thumbnails != nil {
+		thumbnails := *cfg.Thumbnails
+		if thumbnails.Dir, err = expandOutput(thumbnails.Dir, filepath.Dir(s.ConfigPath)); err != nil {
+			return exitcode.Wrap(exitcode.Config, fmt.Errorf("thumbnails: %w", err))
+		}
+		buildContext.Thumbnails = &thumbnails
 	}
 	buildContext.YAMLConfig = cfg
-	buildContext.OutputFile = cfg.Output
+	buildContext.Workspace = workspace
+	buildContext.OutputFile = outputFile
 	buildContext.ConfigDir = filepath.Dir(s.ConfigPath)
-	ui.PrintSuccess(fmt.Sprintf("Loaded configuration with %d object(s)", len(cfg.Objects)))
+	buildContext.ConfigFile = s.ConfigPath
+	profileInfo := ""
+	if buildContext.Profile != "" {
+		profileInfo = fmt.Sprintf(" (profile %s)", buildContext.Profile)
+	}
+	ui.PrintSuccess(fmt.Sprintf("Loaded configuration with %d object(s)%s", len(cfg.Objects), profileInfo))
+	for _, warning := range warnings {
+		ui.PrintWarning(warning)
+	}
 
 	// Display configuration summary only in verbose mode
 	if ui.IsVerbose() {
@@ -454,14 +2305,19 @@ func (s *LoadYAMLStep) Execute() error {
 				if part.Filament > 0 {
 					filamentInfo = fmt.Sprintf(" [filament %d]", part.Filament)
 				}
-				ui.PrintItem(fmt.Sprintf("  └─ %s: %s%s", part.Name, filepath.Base(part.File), filamentInfo))
+				source := filepath.Base(part.File)
+				if part.Generator != "" {
+					source = part.Generator + " generator"
+				}
+				ui.PrintItem(fmt.Sprintf("  └─ %s: %s%s", part.Name, source, filamentInfo))
 			}
 		}
 	}
 	return nil
 }
 
-// CheckPreconditionsStep checks if OpenSCAD is installed (only if SCAD files are present)
+// CheckPreconditionsStep checks that the tools the files of the plan need are
+// installed: OpenSCAD (or Docker, or ssh for render workers) only if parts are rendered
 type CheckPreconditionsStep struct{}
 
 func (s *CheckPreconditionsStep) Name() string {
@@ -469,43 +2325,74 @@ func (s *CheckPreconditionsStep) Name() string {
 }
 
 func (s *CheckPreconditionsStep) Execute() error {
-	// Check if there are any SCAD files that need rendering
-	hasScadFiles := false
-	if buildContext.YAMLConfig != nil {
-		for _, obj := range buildContext.YAMLConfig.Objects {
-			for _, part := range obj.Parts {
-				if preconditions.IsScadFile(part.File) {
-					hasScadFiles = true
-					break
-				}
-			}
-			if hasScadFiles {
-				break
-			}
-		}
-	} else if len(buildContext.SCADFiles) > 0 {
-		for _, scad := range buildContext.SCADFiles {
-			if preconditions.IsScadFile(scad.Path) {
-				hasScadFiles = true
-				break
-			}
+	hasScadFiles := needsOpenSCAD()
+
+	kind, image := rendererSettings()
+	if kind == models.RendererDocker {
+		renderer.UseDocker(image)
+	} else {
+		renderer.UseDocker("")
+	}
+	workers := renderWorkers()
+	renderer.UseWorkers(workers)
+	renderer.SetJobs(renderJobs())
+	geometry.SetPrecision(precision())
+	if hasScadFiles {
+		if err := prepareResume(); err != nil {
+			return err
 		}
 	}
 
 	// Only check for OpenSCAD if there are SCAD files to render
-	if hasScadFiles {
+	switch {
+	case hasScadFiles && len(workers) > 0:
+		// Workers render with their own OpenSCAD
+		if err := preconditions.CheckSSH(); err != nil {
+			return exitcode.Wrap(exitcode.Preconditions, fmt.Errorf("ssh not found: %w", err))
+		}
+		if ui.IsVerbose() {
+			ui.PrintSuccess(fmt.Sprintf("✓ ssh is available, rendering on %d worker(s)", len(workers)))
+		}
+	case hasScadFiles && kind == models.RendererDocker:
+		if err := preconditions.CheckDocker(); err != nil {
+			return exitcode.Wrap(exitcode.Preconditions, fmt.Errorf("Docker not found: %w", err))
+		}
+		if ui.IsVerbose() {
+			ui.PrintSuccess(fmt.Sprintf("✓ Docker is available, rendering with %s", image))
+		}
+	case hasScadFiles:
 		if err := preconditions.Check(); err != nil {
-			return fmt.Errorf("OpenSCAD not found: %w", err)
+			return exitcode.Wrap(exitcode.Preconditions, fmt.Errorf("OpenSCAD not found: %w", err))
 		}
 		if ui.IsVerbose() {
 			ui.PrintSuccess("✓ OpenSCAD is available")
 		}
-	} else if ui.IsVerbose() {
+	case ui.IsVerbose():
 		ui.PrintInfo("No SCAD files to render, skipping OpenSCAD check")
 	}
 	return nil
 }
 
+// needsOpenSCAD reports whether any part of the plan is rendered with OpenSCAD.
+// Generated parts and 2D outlines are SCAD files once ValidateFilesStep wrote their sources.
+func needsOpenSCAD() bool {
+	files := append([]models.ScadFile{}, buildContext.SCADFiles...)
+	for _, group := range buildContext.ObjectGroups {
+		files = append(files, group.Parts...)
+	}
+	for _, plate := range buildContext.PlateGroups {
+		for _, group := range plate.Objects {
+			files = append(files, group.Parts...)
+		}
+	}
+	for _, file := range files {
+		if preconditions.IsScadFile(file.Path) {
+			return true
+		}
+	}
+	return false
+}
+
 // ValidateFilesStep validates that all files exist
 type ValidateFilesStep struct{}
 
@@ -516,6 +2403,10 @@ func (s *ValidateFilesStep) Name() string {
 func (s *ValidateFilesStep) Execute() error {
 	var allPaths []string
 	if buildContext.YAMLConfig != nil {
+		// Objects without normalize_position follow the normalization of the command line
+		if buildContext.Normalization != "" {
+			buildContext.YAMLConfig.Normalize = string(buildContext.Normalization)
+		}
 		loader := config.NewLoader()
 		scadFiles := loader.ConvertToScadFiles(buildContext.YAMLConfig)
 		objectGroups := loader.ConvertToObjectGroups(buildContext.YAMLConfig)
@@ -523,10 +2414,12 @@ func (s *ValidateFilesStep) Execute() error {
 		buildContext.SCADFiles = scadFiles
 		buildContext.ObjectGroups = objectGroups
 		buildContext.PlateGroups = plateGroups
+		if err := generateParts(); err != nil {
+			return err
+		}
 
 		// Set plate width based on printer setting
-		plateSize := models.GetPrinterPlateSize(buildContext.YAMLConfig.Printer)
-		buildContext.PlateWidth = plateSize.Width
+		buildContext.PlateWidth = printerProfile().Width
 
 		for _, scad := range scadFiles {
 			allPaths = append(allPaths, scad.Path)
@@ -539,7 +2432,7 @@ func (s *ValidateFilesStep) Execute() error {
 
 	if len(allPaths) > 0 {
 		if err := preconditions.ValidateFiles(allPaths); err != nil {
-			return err
+			return exitcode.Wrap(exitcode.Input, err)
 		}
 		if ui.IsVerbose() {
 			ui.PrintSuccess(fmt.Sprintf("✓ Validated %d file(s)", len(allPaths)))
@@ -548,9 +2441,78 @@ func (s *ValidateFilesStep) Execute() error {
 	return nil
 }
 
+// generateParts writes the OpenSCAD sources of generated parts and of extruded 2D
+// outlines and uses them as the part files. With a render cache, the sources are kept in the cache directory so
+// that their renders can be reused; otherwise they are removed after the build.
+func generateParts() error {
+	dir := os.TempDir()
+	if cache := cacheDir(); cache != "" {
+		dir = filepath.Join(cache, "generated")
+	}
+
+	generate := func(part *models.ScadFile) error {
+		var path string
+		var err error
+		switch {
+		case part.Generator != "" && part.Path == "":
+			path, err = generator.Write(dir, part.Generator, part.Params)
+			if err != nil {
+				return exitcode.Wrap(exitcode.Config, fmt.Errorf("%s: generator %s: %w", part.Name, part.Generator, err))
+			}
+		case preconditions.IsOutlineFile(part.Path):
+			// 2D outlines are extruded by a wrapper that imports them
+			file, err := filepath.Abs(part.Path)
+			if err != nil {
+				return exitcode.Wrap(exitcode.Input, fmt.Errorf("%s: %w", part.Name, err))
+			}
+			path, err = generator.WriteExtrusion(dir, file, part.ExtrudeHeight)
+			if err != nil {
+				return exitcode.Wrap(exitcode.Output, fmt.Errorf("%s: %w", part.Name, err))
+			}
+		default:
+			return nil
+		}
+		if part.Generator == "" {
+			part.SourcePath = part.Path
+		}
+		part.Path = path
+		if cacheDir() == "" && !slices.Contains(buildContext.GeneratedSources, path) {
+			buildContext.GeneratedSources = append(buildContext.GeneratedSources, path)
+		}
+		return nil
+	}
+
+	for i := range buildContext.SCADFiles {
+		if err := generate(&buildContext.SCADFiles[i]); err != nil {
+			return err
+		}
+	}
+	for i := range buildContext.ObjectGroups {
+		for j := range buildContext.ObjectGroups[i].Parts {
+			if err := generate(&buildContext.ObjectGroups[i].Parts[j]); err != nil {
+				return err
+			}
+		}
+	}
+	for i := range buildContext.PlateGroups {
+		for j := range buildContext.PlateGroups[i].Objects {
+			for k := range buildContext.PlateGroups[i].Objects[j].Parts {
+				if err := generate(&buildContext.PlateGroups[i].Objects[j].Parts[k]); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
 // RenderSCADFilesStep renders SCAD files to 3MF and converts STL files to 3MF
 // 3MF files are passed through directly
-type RenderSCADFilesStep struct{}
+type RenderSCADFilesStep struct {
+	progress *ui.Progress  // Render progress (nil = none, e.g. in verbose mode)
+	tasks    map[int]int   // Index of a SCAD file -> its progress task
+	converts chan struct{} // Bounds the STL conversions at a time to --jobs
+}
 
 func (s *RenderSCADFilesStep) Name() string {
 	return "Process input files"
@@ -558,7 +2520,7 @@ func (s *RenderSCADFilesStep) Name() string {
 
 func (s *RenderSCADFilesStep) Execute() error {
 	if len(buildContext.SCADFiles) == 0 {
-		return fmt.Errorf("no files to process")
+		return exitcode.Wrap(exitcode.Input, fmt.Errorf("no files to process"))
 	}
 
 	// Use the config directory as the base directory if available
@@ -596,55 +2558,250 @@ func (s *RenderSCADFilesStep) Execute() error {
 		ui.PrintInfo(fmt.Sprintf("Processing %s file(s)...", strings.Join(parts, ", ")))
 	}
 
-	var tempFiles []string
-	stlConverter := stl.NewConverter()
-
+	// Equal parts (e.g. object copies or a part shared by several objects) are processed once
+	first := make(map[string]int) // render key -> index of the first part with the key
+	var unique []int
 	for i, scadFile := range buildContext.SCADFiles {
-		tempFile := fmt.Sprintf("/tmp/scad_render_%d.3mf", i)
+		key := renderKey(scadFile)
+		if _, ok := first[key]; !ok {
+			first[key] = i
+			unique = append(unique, i)
+		}
+	}
+	results := s.processFiles(unique, baseDir)
 
-		switch {
-		case preconditions.IsScadFile(scadFile.Path):
-			// Render SCAD file to 3MF
-			// Write config files to the base directory with their original names
-			for filename, content := range scadFile.ConfigFiles {
-				configPath := filepath.Join(baseDir, filename)
-				if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
-					return fmt.Errorf("failed to write config file %s: %w", configPath, err)
-				}
+	var tempFiles []string
+	var generatedFiles []string
+	var failures []renderFailure
+	for i, scadFile := range buildContext.SCADFiles {
+		j := first[renderKey(scadFile)]
+		result := results[j]
+		if result.err != nil {
+			if i != j {
+				continue // Reported with the first part
 			}
-			if err := renderer.RenderSCAD(baseDir, scadFile.Path, tempFile); err != nil {
-				return err
+			if !buildContext.KeepGoing {
+				renderer.PrintError(result.err)
+				return result.err
 			}
-			tempFiles = append(tempFiles, tempFile)
+			// Keep going: remember the failure and continue with the next file
+			failures = append(failures, renderFailure{Name: scadFile.Name, Path: scadFile.Path, Err: result.err})
+			ui.PrintWarning(fmt.Sprintf("Failed to process %s (%s), continuing", filepath.Base(scadFile.Path), scadFile.Name))
+			continue
+		}
+		if !result.done {
+			continue // Skipped after the failure of another part
+		}
+		tempFiles = append(tempFiles, result.tempFile)
+		if i != j {
 			if ui.IsVerbose() {
-				ui.PrintItem(fmt.Sprintf("✓ Rendered %s → %s", filepath.Base(scadFile.Path), scadFile.Name))
+				ui.PrintItem(fmt.Sprintf("✓ Reused %s → %s", filepath.Base(scadFile.Path), scadFile.Name))
 			}
+			continue
+		}
+		if result.generated {
+			generatedFiles = append(generatedFiles, result.tempFile)
+		}
+	}
 
-		case preconditions.IsSTLFile(scadFile.Path):
-			// Convert STL file to 3MF
-			if err := stlConverter.ConvertTo3MF(scadFile.Path, tempFile); err != nil {
-				return fmt.Errorf("error converting %s: %w", scadFile.Path, err)
-			}
-			tempFiles = append(tempFiles, tempFile)
-			if ui.IsVerbose() {
-				ui.PrintItem(fmt.Sprintf("✓ Converted %s → %s", filepath.Base(scadFile.Path), scadFile.Name))
+	if len(failures) > 0 {
+		renderer.CleanupTempFiles(generatedFiles)
+		reportRenderFailures(failures)
+		return exitcode.Wrap(exitcode.Render, fmt.Errorf("%d of %d file(s) failed to process", len(failures), len(buildContext.SCADFiles)))
+	}
+
+	buildContext.RenderedFiles = tempFiles
+	buildContext.GeneratedFiles = generatedFiles
+	ui.PrintSuccess(fmt.Sprintf("Processed %d file(s)", len(tempFiles)))
+	return nil
+}
+
+// processResult is the outcome of processing an input file
+type processResult struct {
+	tempFile  string
+	generated bool
+	err       error
+	done      bool // Whether the file was processed (false if skipped after a failure)
+}
+
+// processFiles processes the input files with the given indices. With remote
+// render workers, each worker processes a file at a time; otherwise renderer.Jobs
+// files are processed in parallel on this machine. STL files are converted on
+// this machine, at most --jobs at a time. The results keep the order of the
+// files. Without --keep-going, the remaining files are skipped after the first
+// failure.
+func (s *RenderSCADFilesStep) processFiles(indices []int, baseDir string) []processResult {
+	results := make([]processResult, len(buildContext.SCADFiles))
+	stlConverter := stl.NewConverter()
+
+	// Show a row per SCAD render, as renders may take long
+	var names []string
+	s.tasks = make(map[int]int)
+	for _, i := range indices {
+		if scadFile := buildContext.SCADFiles[i]; preconditions.IsScadFile(scadFile.Path) {
+			s.tasks[i] = len(names)
+			names = append(names, fmt.Sprintf("%s (%s)", scadFile.Name, filepath.Base(scadFile.Path)))
+		}
+	}
+	if len(names) > 0 && !ui.IsVerbose() {
+		s.progress = ui.NewProgress(names)
+		defer s.progress.Stop()
+	}
+	s.converts = make(chan struct{}, max(buildContext.Jobs, 1))
+	var failed atomic.Bool
+	var configMu sync.Mutex
+	var wg sync.WaitGroup
+	queue := make(chan int)
+
+	for range max(renderer.Jobs(), 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				if failed.Load() && !buildContext.KeepGoing {
+					continue
+				}
+				scadFile := buildContext.SCADFiles[i]
+				// Config files are written to the working directory of the part, so
+				// parts with config files are rendered one after another
+				if len(scadFile.ConfigFiles) > 0 {
+					configMu.Lock()
+				}
+				tempFile, generated, err := s.processFile(i, scadFile, baseDir, stlConverter)
+				if len(scadFile.ConfigFiles) > 0 {
+					configMu.Unlock()
+				}
+				results[i] = processResult{tempFile: tempFile, generated: generated, err: err, done: true}
+				if err != nil {
+					failed.Store(true)
+				}
 			}
+		}()
+	}
+	for _, i := range indices {
+		queue <- i
+	}
+	close(queue)
+	wg.Wait()
+	return results
+}
 
-		case preconditions.Is3MFFile(scadFile.Path):
-			// 3MF files are passed through directly (use the original path)
-			tempFiles = append(tempFiles, scadFile.Path)
-			if ui.IsVerbose() {
-				ui.PrintItem(fmt.Sprintf("✓ Using %s → %s", filepath.Base(scadFile.Path), scadFile.Name))
+// processFile renders, converts, or passes through a single input file.
+// Returns the 3MF file to combine and whether it is a generated temporary file.
+func (s *RenderSCADFilesStep) processFile(index int, scadFile models.ScadFile, baseDir string, stlConverter *stl.Converter) (string, bool, error) {
+	tempFile := renderer.TempFile("scad_render", index)
+
+	switch {
+	case preconditions.IsScadFile(scadFile.Path):
+		// Render SCAD file to 3MF in the working directory of the part
+		workDir := baseDir
+		if scadFile.WorkDir != "" {
+			workDir = scadFile.WorkDir
+		}
+		// Write config files to the working directory with their original names
+		for filename, content := range scadFile.ConfigFiles {
+			configPath := filepath.Join(workDir, filename)
+			if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+				return "", false, exitcode.Wrap(exitcode.Render, fmt.Errorf("failed to write config file %s: %w", configPath, err))
+			}
+		}
+		task, tracked := s.tasks[index]
+		if tracked {
+			s.progress.Start(task)
+		}
+		cached := false
+		var err error
+		if dir := cacheDir(); dir != "" {
+			variant := configVariant(scadFile.ConfigFiles)
+			if scadFile.WorkDir != "" {
+				variant += "\x00" + scadFile.WorkDir
+			}
+			cached, err = renderer.NewCache(dir).Render(workDir, scadFile.Path, tempFile, variant, scadFile.Quality, scadFile.LibraryPaths)
+		} else {
+			err = renderer.RenderSCADWithQuality(workDir, scadFile.Path, tempFile, scadFile.Quality, scadFile.LibraryPaths)
+		}
+		if err != nil {
+			if tracked {
+				s.progress.Fail(task)
+			}
+			return "", false, exitcode.Wrap(exitcode.Render, err)
+		}
+		if tracked {
+			detail := ""
+			if cached {
+				detail = "cached"
+			}
+			s.progress.Done(task, detail)
+		}
+		if ui.IsVerbose() {
+			action := "Rendered"
+			if cached {
+				action = "Reused cached render of"
 			}
+			ui.PrintItem(fmt.Sprintf("✓ %s %s → %s", action, filepath.Base(scadFile.Path), scadFile.Name))
+		}
+		return tempFile, true, nil
 
-		default:
-			return fmt.Errorf("unsupported file type: %s", scadFile.Path)
+	case preconditions.IsSTLFile(scadFile.Path):
+		// Convert STL file to 3MF
+		s.converts <- struct{}{}
+		err := stlConverter.ConvertTo3MF(scadFile.Path, tempFile)
+		<-s.converts
+		if err != nil {
+			return "", false, exitcode.Wrap(exitcode.Render, fmt.Errorf("error converting %s: %w", scadFile.Path, err))
+		}
+		if ui.IsVerbose() {
+			ui.PrintItem(fmt.Sprintf("✓ Converted %s → %s", filepath.Base(scadFile.Path), scadFile.Name))
+		}
+		return tempFile, true, nil
+
+	case preconditions.Is3MFFile(scadFile.Path):
+		// 3MF files are passed through directly (use the original path)
+		if ui.IsVerbose() {
+			ui.PrintItem(fmt.Sprintf("✓ Using %s → %s", filepath.Base(scadFile.Path), scadFile.Name))
 		}
+		return scadFile.Path, false, nil
+
+	default:
+		return "", false, exitcode.Wrap(exitcode.Input, fmt.Errorf("unsupported file type: %s", scadFile.Path))
 	}
+}
 
-	buildContext.RenderedFiles = tempFiles
-	ui.PrintSuccess(fmt.Sprintf("Processed %d file(s)", len(tempFiles)))
-	return nil
+// renderKey identifies the file of a part with everything that affects its render
+func renderKey(scadFile models.ScadFile) string {
+	return fmt.Sprintf("%s\x00%s\x00%+v\x00%s\x00%q", scadFile.Path, configVariant(scadFile.ConfigFiles), scadFile.Quality, scadFile.WorkDir, scadFile.LibraryPaths)
+}
+
+// configVariant identifies the config files of a part, so that renders of the same
+// SCAD file with different configs are cached separately
+func configVariant(configFiles map[string]string) string {
+	names := make([]string, 0, len(configFiles))
+	for name := range configFiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(name + "\x00" + configFiles[name] + "\x00")
+	}
+	return b.String()
+}
+
+// renderFailure records a file that failed to process in keep-going mode
+type renderFailure struct {
+	Name string
+	Path string
+	Err  error
+}
+
+// reportRenderFailures prints all collected failures together with their OpenSCAD logs
+func reportRenderFailures(failures []renderFailure) {
+	ui.PrintHeader(fmt.Sprintf("%d file(s) failed", len(failures)))
+	for _, f := range failures {
+		ui.PrintError(fmt.Sprintf("%s (%s): %v", f.Name, filepath.Base(f.Path), f.Err))
+		renderer.PrintError(f.Err)
+	}
 }
 
 // CombineWithGroupsStep combines rendered files using YAML grouping
@@ -655,55 +2812,152 @@ func (s *CombineWithGroupsStep) Name() string {
 }
 
 func (s *CombineWithGroupsStep) Execute() error {
-	defer renderer.CleanupTempFiles(buildContext.RenderedFiles)
+	// Only temporary files are removed; 3MF inputs are combined from their original paths
+	defer renderer.CleanupTempFiles(buildContext.GeneratedFiles)
 
 	ui.PrintInfo("Merging objects and materials...")
 
 	combiner := threemf.NewCombiner()
 	combiner.SetDebug(buildContext.Debug)
+	combiner.SetAuxiliaryPolicy(auxiliaryPolicy())
+	combiner.SetIgnoreExtensions(buildContext.IgnoreExt)
+	combiner.SetFlatten(buildContext.Flatten)
+	combiner.SetCompactXML(buildContext.CompactXML)
+	if buildContext.YAMLConfig != nil {
+		combiner.SetFilaments(buildContext.YAMLConfig.Filaments)
+	}
 
-	// Use packing distance from config if available, otherwise default to 10.0
-	packingDistance := 10.0
-	if buildContext.YAMLConfig != nil && buildContext.YAMLConfig.PackingDistance > 0 {
-		packingDistance = buildContext.YAMLConfig.PackingDistance
+	packingDistance, packingAlgo := packingSettings()
+	order, sequential := packingOrder()
+	combiner.SetPackingOrder(order, sequential)
+	grid := placementGrid()
+	combiner.SetPlacementGrid(grid)
+	combiner.SetMaxRowWidth(buildContext.MaxRowWidth)
+	shape := footprint()
+	combiner.SetFootprint(shape)
+	origin := packingOrigin()
+	combiner.SetPackingOrigin(origin)
+	combiner.SetPrinter(printerProfile())
+	combiner.SetNormalization(normalization())
+	if ui.IsVerbose() {
+		ui.PrintItem(fmt.Sprintf("Packing: %s algorithm, %.1fmm distance, %s order, %s footprint", packingAlgo, packingDistance, order, shape))
+		if sequential != nil {
+			ui.PrintItem(fmt.Sprintf("Sequential printing: %.1fx%.1fmm clearance", sequential.ClearanceX, sequential.ClearanceY))
+		}
+		if grid > 0 {
+			ui.PrintItem(fmt.Sprintf("Placement grid: %.1fmm", grid))
+		}
+		if width := buildContext.MaxRowWidth; width > 0 {
+			ui.PrintItem(fmt.Sprintf("Maximum row width: %.1fmm", width))
+		}
+		if origin == models.PackingOriginBack {
+			ui.PrintItem("Packing origin: back of the plate")
+		}
+		if normalize := normalization(); normalize != models.NormalizationGround {
+			ui.PrintItem(fmt.Sprintf("Z normalization: %s", normalize))
+		}
 	}
 
-	// Use packing algorithm from config if available, otherwise default to "default"
-	packingAlgo := models.PackingAlgorithmDefault
-	if buildContext.YAMLConfig != nil && buildContext.YAMLConfig.PackingAlgorithm != "" {
-		packingAlgo = models.NewPackingAlgorithm(buildContext.YAMLConfig.PackingAlgorithm)
+	var fixed *arrangement.Arrangement
+	if buildContext.ArrangementFile != "" {
+		a, err := arrangement.Load(buildContext.ArrangementFile)
+		if err != nil {
+			return exitcode.Wrap(exitcode.Input, err)
+		}
+		fixed = a
+		combiner.SetArrangement(fixed)
+		if ui.IsVerbose() {
+			ui.PrintItem(fmt.Sprintf("Arrangement: %s (%d object(s))", buildContext.ArrangementFile, len(fixed.Objects)))
+		}
 	}
 
 	// Use CombineWithPlateGroups if we have multiple plates, otherwise fall back to existing methods
 	if len(buildContext.PlateGroups) > 1 {
 		if err := combiner.CombineWithPlateGroups(buildContext.RenderedFiles, buildContext.PlateGroups, buildContext.OutputFile, packingDistance, packingAlgo, buildContext.PlateWidth); err != nil {
-			return err
+			return exitcode.Wrap(exitcode.Output, err)
 		}
 	} else if len(buildContext.ObjectGroups) > 0 {
 		if err := combiner.CombineWithObjectGroups(buildContext.RenderedFiles, buildContext.ObjectGroups, buildContext.OutputFile, packingDistance, packingAlgo); err != nil {
-			return err
+			return exitcode.Wrap(exitcode.Output, err)
 		}
 	} else {
 		if err := combiner.CombineWithGroupsAndDistance(buildContext.RenderedFiles, buildContext.SCADFiles, buildContext.OutputFile, packingDistance, packingAlgo); err != nil {
-			return err
+			return exitcode.Wrap(exitcode.Output, err)
 		}
 	}
 
 	// Print success
 	ui.PrintSuccess("Combined 3MF file created!")
 
+	for _, warning := range combiner.Warnings() {
+		ui.PrintWarning(warning)
+	}
+	if fixed != nil {
+		reportArrangement(fixed, combiner)
+	}
+	if buildContext.ExportArrangementFile != "" {
+		if err := combiner.Placements().Save(buildContext.ExportArrangementFile); err != nil {
+			return exitcode.Wrap(exitcode.Output, err)
+		}
+		ui.PrintItem(fmt.Sprintf("Arrangement exported to %s", buildContext.ExportArrangementFile))
+	}
+
 	// Show objects using the same printer as inspect
 	inspector := inspect.NewInspector()
 	model, settings, err := inspector.Read3MFFile(buildContext.OutputFile)
 	if err == nil {
 		ui.PrintHeader("Model Contents")
 		printer := inspect.NewModelPrinter()
+		printer.SetFilamentColors(filamentColors(inspector))
 		printer.PrintObjectHierarchy(model, settings)
 	}
 
 	return nil
 }
 
+// filamentColors returns the filament colors of the output's project settings,
+// or else the colors of the configured filaments
+func filamentColors(inspector *inspect.Inspector) []string {
+	if colors := inspector.ReadFilamentColors(buildContext.OutputFile); colors != nil {
+		return colors
+	}
+	if buildContext.YAMLConfig == nil {
+		return nil
+	}
+	var colors []string
+	for _, filament := range buildContext.YAMLConfig.Filaments {
+		colors = append(colors, filament.Color)
+	}
+	return colors
+}
+
+// filamentDensities returns the densities of the filament slots of the
+// configuration for the weight estimates
+func filamentDensities() bom.Densities {
+	if buildContext.YAMLConfig == nil {
+		return nil
+	}
+	return bom.NewDensities(buildContext.YAMLConfig.Filaments, buildContext.YAMLConfig.Materials)
+}
+
+// reportArrangement warns about objects that were packed because the arrangement
+// did not contain them, and about arrangement entries that match no object
+func reportArrangement(fixed *arrangement.Arrangement, combiner *threemf.Combiner) {
+	if unarranged := combiner.UnarrangedObjects(); len(unarranged) > 0 {
+		ui.PrintWarning(fmt.Sprintf("%d object(s) not in the arrangement were packed and may overlap: %s", len(unarranged), strings.Join(unarranged, ", ")))
+	}
+
+	var unused []string
+	for _, placement := range fixed.Objects {
+		if _, ok := combiner.Placements().Lookup(placement.Name); !ok {
+			unused = append(unused, placement.Name)
+		}
+	}
+	if len(unused) > 0 {
+		ui.PrintWarning(fmt.Sprintf("Arrangement contains unknown object(s): %s", strings.Join(unused, ", ")))
+	}
+}
+
 // ParseSCADArgsStep parses SCAD file arguments
 type ParseSCADArgsStep struct {
 	Args []string
@@ -722,7 +2976,7 @@ func (s *ParseSCADArgsStep) Execute() error {
 		// Convert to absolute path
 		absPath, err := filepath.Abs(path)
 		if err != nil {
-			return fmt.Errorf("invalid file path %s: %w", path, err)
+			return exitcode.Wrap(exitcode.Usage, fmt.Errorf("invalid file path %s: %w", path, err))
 		}
 
 		// Use custom name if provided
@@ -743,7 +2997,7 @@ func (s *ParseSCADArgsStep) Execute() error {
 			if err == nil && slot >= 1 && slot <= 4 {
 				filamentSlot = slot
 			} else {
-				return fmt.Errorf("invalid filament slot '%s' for %s. Must be 1-4", parts[2], path)
+				return exitcode.Wrap(exitcode.Usage, fmt.Errorf("invalid filament slot '%s' for %s. Must be 1-4", parts[2], path))
 			}
 		}
 
@@ -769,6 +3023,7 @@ func (s *ParseSCADArgsAsSingleObjectStep) Name() string {
 
 func (s *ParseSCADArgsAsSingleObjectStep) Execute() error {
 	var parts []models.YamlPart
+	var objects []models.YamlObject // Files with a count, each an object of its own
 	for _, arg := range s.Args {
 		argParts := strings.Split(arg, ":")
 		path := argParts[0]
@@ -776,7 +3031,7 @@ func (s *ParseSCADArgsAsSingleObjectStep) Execute() error {
 		// Convert to absolute path
 		absPath, err := filepath.Abs(path)
 		if err != nil {
-			return fmt.Errorf("invalid file path %s: %w", path, err)
+			return exitcode.Wrap(exitcode.Usage, fmt.Errorf("invalid file path %s: %w", path, err))
 		}
 
 		// Use custom name if provided
@@ -790,33 +3045,43 @@ func (s *ParseSCADArgsAsSingleObjectStep) Execute() error {
 			name = filepath.Base(absPath[:len(absPath)-len(filepath.Ext(absPath))])
 		}
 
-		// Parse optional filament slot (format: path:name:slot)
-		if len(argParts) > 2 {
+		// Parse optional filament slot (format: path:name:slot or path:name::count)
+		if len(argParts) > 2 && argParts[2] != "" {
 			slot := 0
 			_, err := fmt.Sscanf(argParts[2], "%d", &slot)
 			if err == nil && slot >= 1 && slot <= 4 {
 				filamentSlot = slot
 			} else {
-				return fmt.Errorf("invalid filament slot '%s' for %s. Must be 1-4", argParts[2], path)
+				return exitcode.Wrap(exitcode.Usage, fmt.Errorf("invalid filament slot '%s' for %s. Must be 1-4", argParts[2], path))
 			}
 		}
 
-		parts = append(parts, models.YamlPart{
+		part := models.YamlPart{
 			Name:     name,
 			File:     absPath,
 			Filament: filamentSlot,
-		})
+		}
+
+		// Parse optional count (format: path:name:slot:count); the copies of a
+		// file are an object of their own instead of a part of the combined object
+		if len(argParts) > 3 {
+			count, err := strconv.Atoi(argParts[3])
+			if err != nil || count < 1 || len(argParts) > 4 {
+				return exitcode.Wrap(exitcode.Usage, fmt.Errorf("invalid count '%s' for %s. Must be 1 or more", strings.Join(argParts[3:], ":"), path))
+			}
+			objects = append(objects, models.YamlObject{Name: name, Count: count, Parts: []models.YamlPart{part}})
+			continue
+		}
+		parts = append(parts, part)
 	}
 
 	// Create a YAML config with a single object containing all parts
+	if len(parts) > 0 {
+		objects = append([]models.YamlObject{{Name: "Combined", Parts: parts}}, objects...)
+	}
 	buildContext.YAMLConfig = &models.YamlConfig{
-		Output: s.OutputFile,
-		Objects: []models.YamlObject{
-			{
-				Name:  "Combined",
-				Parts: parts,
-			},
-		},
+		Output:  s.OutputFile,
+		Objects: objects,
 	}
 	buildContext.OutputFile = s.OutputFile
 
@@ -835,15 +3100,21 @@ func (s *CombineRenderedStep) Name() string {
 func (s *CombineRenderedStep) Execute() error {
 	ui.PrintHeader("Combining 3MF files...")
 
-	defer renderer.CleanupTempFiles(buildContext.RenderedFiles)
+	// Only temporary files are removed; 3MF inputs are combined from their original paths
+	defer renderer.CleanupTempFiles(buildContext.GeneratedFiles)
 
+	// Parts are placed side by side, so only the packing distance applies
+	packingDistance, _ := packingSettings()
 	combiner := threemf.NewCombiner()
-	if err := combiner.Combine(buildContext.RenderedFiles, buildContext.SCADFiles, s.OutputFile); err != nil {
-		return err
+	combiner.SetAuxiliaryPolicy(auxiliaryPolicy())
+	combiner.SetFlatten(buildContext.Flatten)
+	combiner.SetCompactXML(buildContext.CompactXML)
+	if err := combiner.CombineWithDistance(buildContext.RenderedFiles, buildContext.SCADFiles, s.OutputFile, packingDistance); err != nil {
+		return exitcode.Wrap(exitcode.Output, err)
 	}
 
 	// Print success
-	ui.PrintSuccess("Combined 3MF file created: " + s.OutputFile)
+	ui.PrintSuccess("Combined 3MF file created: " + displayOutput(s.OutputFile))
 	var names []string
 	for _, scad := range buildContext.SCADFiles {
 		names = append(names, scad.Name)
@@ -864,7 +3135,7 @@ func (s *Validate3MFFilesStep) Name() string {
 func (s *Validate3MFFilesStep) Execute() error {
 	for _, file := range s.Files {
 		if _, err := os.Stat(file); err != nil {
-			return fmt.Errorf("file not found: %s", file)
+			return exitcode.Wrap(exitcode.Input, fmt.Errorf("file not found: %s", file))
 		}
 		ui.PrintItem(fmt.Sprintf("✓ %s", filepath.Base(file)))
 	}
@@ -885,8 +3156,15 @@ func (s *Combine3MFFilesStep) Name() string {
 func (s *Combine3MFFilesStep) Execute() error {
 	ui.PrintInfo("Merging 3MF files...")
 	combiner := combine.NewCombiner()
+	combiner.SetAuxiliaryPolicy(auxiliaryPolicy())
+	combiner.SetIgnoreExtensions(buildContext.IgnoreExt)
+	combiner.SetCompactXML(buildContext.CompactXML)
+	combiner.SetFlatten(buildContext.Flatten)
 	if err := combiner.Combine(s.Files, s.OutputFile); err != nil {
-		return err
+		return exitcode.Wrap(exitcode.Output, err)
+	}
+	for _, warning := range combiner.Warnings() {
+		ui.PrintWarning(warning)
 	}
 	ui.PrintSuccess("Combined 3MF created successfully!")
 	return nil
@@ -904,7 +3182,7 @@ func (s *ValidateSTLFilesStep) Name() string {
 func (s *ValidateSTLFilesStep) Execute() error {
 	for _, file := range s.Files {
 		if _, err := os.Stat(file); err != nil {
-			return fmt.Errorf("file not found: %s", file)
+			return exitcode.Wrap(exitcode.Input, fmt.Errorf("file not found: %s", file))
 		}
 		ui.PrintItem(fmt.Sprintf("✓ %s", filepath.Base(file)))
 	}
@@ -922,23 +3200,49 @@ func (s *ConvertSTLTo3MFStep) Name() string {
 }
 
 func (s *ConvertSTLTo3MFStep) Execute() error {
+	geometry.SetPrecision(precision())
 	converter := stl.NewConverter()
 	buildContext.RenderedFiles = []string{}
 	buildContext.OriginalSTLs = s.Files
 
 	ui.PrintInfo(fmt.Sprintf("Converting %d STL file(s) to 3MF...", len(s.Files)))
 
-	for i, stlFile := range s.Files {
-		// Create temp 3MF file
-		tempFile := filepath.Join(os.TempDir(), fmt.Sprintf("stl_converted_%d.3mf", i))
+	// Files are converted by jobs workers; the temp file of a file only depends on
+	// its index, so the result does not depend on the order the conversions finish
+	tempFiles := make([]string, len(s.Files))
+	errs := make([]error, len(s.Files))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	queue := make(chan int)
+
+	for range max(buildContext.Jobs, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				tempFiles[i] = renderer.TempFile("stl_converted", i)
+				errs[i] = converter.ConvertTo3MF(s.Files[i], tempFiles[i])
+				if errs[i] == nil {
+					mu.Lock()
+					ui.PrintItem(fmt.Sprintf("✓ %s → %s", filepath.Base(s.Files[i]), filepath.Base(tempFiles[i])))
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	for i := range s.Files {
+		queue <- i
+	}
+	close(queue)
+	wg.Wait()
 
-		if err := converter.ConvertTo3MF(stlFile, tempFile); err != nil {
-			return fmt.Errorf("error converting %s: %w", stlFile, err)
+	for i, err := range errs {
+		if err != nil {
+			renderer.CleanupTempFiles(tempFiles)
+			return exitcode.Wrap(exitcode.Render, fmt.Errorf("error converting %s: %w", s.Files[i], err))
 		}
-
-		buildContext.RenderedFiles = append(buildContext.RenderedFiles, tempFile)
-		ui.PrintItem(fmt.Sprintf("✓ %s → %s", filepath.Base(stlFile), filepath.Base(tempFile)))
 	}
+	buildContext.RenderedFiles = tempFiles
 
 	ui.PrintSuccess(fmt.Sprintf("Converted %d file(s)", len(s.Files)))
 	return nil
@@ -957,10 +3261,11 @@ func (s *CombineConverted3MFFilesStep) Execute() error {
 	ui.PrintHeader("Combining converted 3MF files...")
 
 	if len(buildContext.RenderedFiles) == 0 {
-		return fmt.Errorf("no converted files to combine")
+		return exitcode.Wrap(exitcode.Input, fmt.Errorf("no converted files to combine"))
 	}
 
 	combiner := threemf.NewCombiner()
+	combiner.SetCompactXML(buildContext.CompactXML)
 
 	// Create ScadFile entries using original STL filenames for proper naming
 	scadFiles := make([]models.ScadFile, len(buildContext.RenderedFiles))
@@ -975,11 +3280,13 @@ func (s *CombineConverted3MFFilesStep) Execute() error {
 		}
 	}
 
-	if err := combiner.Combine(buildContext.RenderedFiles, scadFiles, s.OutputFile); err != nil {
-		return err
+	// Parts are placed side by side, so only the packing distance applies
+	packingDistance, _ := packingSettings()
+	if err := combiner.CombineWithDistance(buildContext.RenderedFiles, scadFiles, s.OutputFile, packingDistance); err != nil {
+		return exitcode.Wrap(exitcode.Output, err)
 	}
 
-	ui.PrintSuccess("Combined 3MF file created: " + s.OutputFile)
+	ui.PrintSuccess("Combined 3MF file created: " + displayOutput(s.OutputFile))
 
 	// Clean up temp files
 	for _, file := range buildContext.RenderedFiles {
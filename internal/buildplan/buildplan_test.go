@@ -0,0 +1,702 @@
+package buildplan
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/philipparndt/go3mf/internal/exitcode"
+	"github.com/philipparndt/go3mf/internal/models"
+	"github.com/philipparndt/go3mf/internal/renderer"
+	"github.com/philipparndt/go3mf/internal/telemetry"
+	"github.com/philipparndt/go3mf/internal/ui"
+)
+
+const tetraSTL = `solid tetra
+facet normal 0 0 0
+outer loop
+vertex 0 0 0
+vertex 10 0 0
+vertex 0 10 0
+endloop
+endfacet
+facet normal 0 0 0
+outer loop
+vertex 0 0 0
+vertex 0 10 0
+vertex 0 0 10
+endloop
+endfacet
+facet normal 0 0 0
+outer loop
+vertex 0 0 0
+vertex 0 0 10
+vertex 10 0 0
+endloop
+endfacet
+facet normal 0 0 0
+outer loop
+vertex 10 0 0
+vertex 0 0 10
+vertex 0 10 0
+endloop
+endfacet
+endsolid tetra
+`
+
+// writeFiles writes files with the given names and contents to a temporary
+// directory and returns it
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// captureStdout runs fn with os.Stdout and the UI output redirected to a file,
+// like a terminal where both end up in the same stream, and returns what was written
+func captureStdout(t *testing.T, fn func() error) ([]byte, error) {
+	t.Helper()
+	f, err := os.Create(filepath.Join(t.TempDir(), "stdout"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	stdout, uiOutput := os.Stdout, ui.Output()
+	os.Stdout = f
+	ui.SetOutput(f)
+	defer func() {
+		os.Stdout = stdout
+		ui.SetOutput(uiOutput)
+	}()
+
+	fnErr := fn()
+	data, err := os.ReadFile(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data, fnErr
+}
+
+func TestYAMLBuildToStdout(t *testing.T) {
+	tests := []struct {
+		name       string
+		output     string // output of the config
+		outputFlag string
+	}{
+		{name: "output flag", output: "tetra.3mf", outputFlag: StdoutPath},
+		{name: "output of the config", output: `"-"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			Reset()
+			defer Reset()
+			// Verbose builds print a header for every step
+			t.Setenv("CI", "true")
+
+			dir := writeFiles(t, map[string]string{
+				"tetra.stl": tetraSTL,
+				"config.yaml": `output: ` + tt.output + `
+objects:
+  - name: tetra
+    parts:
+      - name: tetra
+        file: tetra.stl
+`,
+			})
+
+			data, err := captureStdout(t, func() error {
+				uiOutput := ui.Output()
+				plan, err := NewPlanner().CreatePlan([]string{filepath.Join(dir, "config.yaml")}, nil, tt.outputFlag)
+				if err != nil {
+					return err
+				}
+				err = plan.Execute()
+				if ui.Output() != uiOutput {
+					t.Error("the UI output was not restored after the build")
+				}
+				return err
+			})
+			if err != nil {
+				t.Fatalf("build failed: %v", err)
+			}
+
+			// Readers tolerate data in front of a ZIP, so the start is checked as well
+			if !bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+				t.Fatalf("stdout does not start with the 3MF: %q", data[:min(len(data), 40)])
+			}
+			zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+			if err != nil {
+				t.Fatalf("stdout is not a valid 3MF: %v", err)
+			}
+			var found bool
+			for _, f := range zr.File {
+				found = found || f.Name == "3D/3dmodel.model"
+			}
+			if !found {
+				t.Error("the 3MF on stdout has no 3D/3dmodel.model")
+			}
+			if _, err := os.Stat(filepath.Join(dir, "tetra.3mf")); !os.IsNotExist(err) {
+				t.Error("the output of the config was written although stdout was requested")
+			}
+		})
+	}
+}
+
+func TestOutputs(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	dir := writeFiles(t, map[string]string{"tetra.stl": tetraSTL})
+	out := filepath.Join(dir, "out")
+	if err := os.Mkdir(out, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	config := fmt.Sprintf(`output: %[1]s/tetra.3mf
+outputs:
+  - path: %[1]s/plain.3mf
+    format: 3mf
+  - path: %[1]s/stl
+    format: stl
+  - path: %[1]s/objects
+    format: objects
+objects:
+  - name: tetra
+    count: 2
+    parts:
+      - name: tetra
+        file: %[2]s
+`, out, filepath.Join(dir, "tetra.stl"))
+	configPath := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(configPath, []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	plan, err := NewPlanner().CreatePlan([]string{configPath}, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := plan.Execute(); err != nil {
+		t.Fatalf("build failed: %v", err)
+	}
+
+	for _, file := range []string{"tetra.3mf", "plain.3mf", "stl/tetra.stl", "objects/tetra.3mf"} {
+		if _, err := os.Stat(filepath.Join(out, file)); err != nil {
+			t.Errorf("%s was not written: %v", file, err)
+		}
+	}
+	// The copies of an object are written once
+	for _, output := range []string{"stl", "objects"} {
+		entries, err := os.ReadDir(filepath.Join(out, output))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 1 {
+			t.Errorf("%s: %d files, want one for both copies of the object", output, len(entries))
+		}
+	}
+}
+
+func TestFailedStep(t *testing.T) {
+	steps := []telemetry.StepTiming{{Name: "Load"}, {Name: "Render", Err: errors.New("render failed")}, {Name: "Combine", Err: errors.New("combine failed")}}
+	if got := failedStep(steps); got != "Render" {
+		t.Errorf("failedStep() = %q, want the first failed step", got)
+	}
+	if got := failedStep(steps[:1]); got != "" {
+		t.Errorf("failedStep() = %q without a failed step, want none", got)
+	}
+}
+
+func TestPrepareResume(t *testing.T) {
+	failedRun := []telemetry.StepTiming{{Name: "Load YAML configuration"}, {Name: "Render SCAD files", Err: errors.New("render failed")}}
+	tests := []struct {
+		name       string
+		resume     bool
+		failed     bool   // A previous run failed
+		cacheDir   string // Render cache from the command line
+		wantDir    bool   // The state of the build is kept
+		wantState  bool   // The state of the failed run is still there
+		wantOutput string
+	}{
+		{name: "first run", wantDir: true},
+		{name: "rerun after a failure", failed: true, wantDir: true},
+		{name: "resume", resume: true, failed: true, wantDir: true, wantState: true, wantOutput: `Resuming the previous build that failed at "Render SCAD files"`},
+		{name: "resume without a failed run", resume: true, wantDir: true, wantOutput: "No failed build to resume"},
+		{name: "render cache", cacheDir: "cache", failed: true},
+		{name: "resume with a render cache", resume: true, failed: true, cacheDir: "cache", wantState: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			Reset()
+			defer Reset()
+			t.Setenv("TMPDIR", t.TempDir())
+			SetResume(tt.resume)
+			SetCacheDir(tt.cacheDir)
+			buildContext.ResumeKey = resumeKey([]string{"config.yaml"}, nil, "")
+			dir := resumePath()
+			if tt.failed {
+				if err := writeResumeState(dir, failedRun); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var out bytes.Buffer
+			previous := ui.Output()
+			ui.SetOutput(&out)
+			err := prepareResume()
+			ui.SetOutput(previous)
+			if err != nil {
+				t.Fatalf("prepareResume() error = %v", err)
+			}
+			if got := buildContext.ResumeDir != ""; got != tt.wantDir {
+				t.Errorf("ResumeDir = %q, want kept: %v", buildContext.ResumeDir, tt.wantDir)
+			}
+			if tt.wantDir && buildContext.ResumeDir != dir {
+				t.Errorf("ResumeDir = %q, want %q", buildContext.ResumeDir, dir)
+			}
+			if got := hasResumeState(dir); got != tt.wantState {
+				t.Errorf("hasResumeState() = %v after prepareResume", got)
+			}
+			if !bytes.Contains(out.Bytes(), []byte(tt.wantOutput)) {
+				t.Errorf("output = %q, want %q", out.String(), tt.wantOutput)
+			}
+		})
+	}
+}
+
+func TestHasResumeState(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "resume")
+	if hasResumeState(dir) {
+		t.Error("hasResumeState() without a directory = true")
+	}
+	// Renders alone are no state: the run that kept them may still be going
+	if err := os.MkdirAll(filepath.Join(dir, "renders"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if hasResumeState(dir) {
+		t.Error("hasResumeState() with renders only = true")
+	}
+	if err := writeResumeState(dir, []telemetry.StepTiming{{Name: "Render SCAD files", Err: errors.New("render failed")}}); err != nil {
+		t.Fatal(err)
+	}
+	if !hasResumeState(dir) {
+		t.Error("hasResumeState() after a failed run = false")
+	}
+}
+
+func TestResumedConfig(t *testing.T) {
+	Reset()
+	defer Reset()
+	t.Setenv("TMPDIR", t.TempDir())
+	configPath := filepath.Join(writeFiles(t, map[string]string{"config.yaml": "output: a.3mf\n"}), "config.yaml")
+	buildContext.ResumeKey = resumeKey([]string{configPath}, nil, "")
+
+	// The failed run parsed the config
+	want := &models.YamlConfig{Output: "parsed.3mf", Objects: []models.YamlObject{{Name: "box"}}}
+	buildContext.ConfigHash = configHash(configPath)
+	buildContext.ParsedConfig = []byte(`{"Output":"parsed.3mf","Objects":[{"Name":"box"}]}`)
+	failedRun := []telemetry.StepTiming{{Name: "Load YAML configuration"}, {Name: "Render SCAD files", Err: errors.New("render failed")}}
+	if err := writeResumeState(resumePath(), failedRun); err != nil {
+		t.Fatal(err)
+	}
+	state, err := readResumeState(resumePath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Failed != "Render SCAD files" || !reflect.DeepEqual(state.Completed, []string{"Load YAML configuration"}) {
+		t.Errorf("state = %+v, want the failed and the completed steps", state)
+	}
+
+	if cfg, _ := resumedConfig(configHash(configPath)); cfg != nil {
+		t.Error("resumedConfig() without --resume returned the config of the failed run")
+	}
+	SetResume(true)
+	if cfg, _ := resumedConfig(configHash(configPath)); !reflect.DeepEqual(cfg, want) {
+		t.Errorf("resumedConfig() = %+v, want %+v", cfg, want)
+	}
+	SetProfile("draft")
+	if cfg, _ := resumedConfig(configHash(configPath)); cfg != nil {
+		t.Error("resumedConfig() with another profile returned the config of the failed run")
+	}
+	SetProfile("")
+	if err := os.WriteFile(configPath, []byte("output: b.3mf\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if cfg, _ := resumedConfig(configHash(configPath)); cfg != nil {
+		t.Error("resumedConfig() of a changed config returned the config of the failed run")
+	}
+}
+
+// recordingStep is a step that records when it starts and finishes
+func recordingStep(name string, after []string, events chan<- string, err error) *StepFunc {
+	return &StepFunc{StepName: name, After: after, Run: func(ctx *Context) error {
+		events <- "start " + name
+		time.Sleep(10 * time.Millisecond)
+		events <- "end " + name
+		return err
+	}}
+}
+
+// eventOrder returns the events of a run by their position
+func eventOrder(events chan string) map[string]int {
+	close(events)
+	order := make(map[string]int)
+	for event := range events {
+		order[event] = len(order)
+	}
+	return order
+}
+
+func TestRunStepsOrder(t *testing.T) {
+	Reset()
+	defer Reset()
+	SetJobs(4)
+
+	events := make(chan string, 20)
+	plan := &BuildPlan{Steps: []BuildStep{
+		recordingStep("load", nil, events, nil),
+		recordingStep("a", []string{"load"}, events, nil),
+		recordingStep("b", []string{"load"}, events, nil),
+		recordingStep("combine", nil, events, nil),
+	}}
+	if err := plan.runSteps(telemetry.NewRecorder()); err != nil {
+		t.Fatal(err)
+	}
+	order := eventOrder(events)
+	if order["start a"] < order["end load"] || order["start b"] < order["end load"] {
+		t.Errorf("a step started before its dependency finished: %v", order)
+	}
+	if order["start a"] > order["end b"] || order["start b"] > order["end a"] {
+		t.Errorf("independent steps did not run concurrently: %v", order)
+	}
+	if order["start combine"] < order["end a"] || order["start combine"] < order["end b"] {
+		t.Errorf("a step without dependencies started before the steps before it finished: %v", order)
+	}
+}
+
+func TestRunStepsInserted(t *testing.T) {
+	Reset()
+	defer Reset()
+	SetJobs(4)
+
+	plan, err := NewPlanner().createYAMLPlan("config.yaml", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Only the dependencies are checked, the steps are not run
+	check := &StepFunc{StepName: "check", After: []string{"Load YAML configuration"}}
+	if err := plan.InsertBefore("Check preconditions", check); err != nil {
+		t.Fatal(err)
+	}
+	dependencies, err := plan.dependencies()
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := func(indices []int) []string {
+		var result []string
+		for _, i := range indices {
+			result = append(result, plan.Steps[i].Name())
+		}
+		return result
+	}
+	// The check runs next to Validate files, the built-in steps run in order
+	want := map[string][]string{
+		"Validate files":      {"Load YAML configuration"},
+		"check":               {"Load YAML configuration"},
+		"Check preconditions": {"Load YAML configuration", "Validate files", "check"},
+	}
+	for i, step := range plan.Steps {
+		if want, ok := want[step.Name()]; ok && !reflect.DeepEqual(names(dependencies[i]), want) {
+			t.Errorf("%s depends on %v, want %v", step.Name(), names(dependencies[i]), want)
+		}
+	}
+}
+
+func TestRunStepsJobs(t *testing.T) {
+	for _, jobs := range []int{0, 1, 2} {
+		Reset()
+		SetJobs(jobs)
+
+		var running, peak atomic.Int32
+		step := func(name string) BuildStep {
+			return &StepFunc{StepName: name, After: []string{}, Run: func(ctx *Context) error {
+				n := running.Add(1)
+				for {
+					p := peak.Load()
+					if n <= p || peak.CompareAndSwap(p, n) {
+						break
+					}
+				}
+				time.Sleep(10 * time.Millisecond)
+				running.Add(-1)
+				return nil
+			}}
+		}
+		plan := &BuildPlan{Steps: []BuildStep{step("a"), step("b"), step("c"), step("d")}}
+		if err := plan.runSteps(telemetry.NewRecorder()); err != nil {
+			t.Fatal(err)
+		}
+		if want := int32(max(jobs, 1)); peak.Load() != want {
+			t.Errorf("--jobs %d: %d steps ran at once, want %d", jobs, peak.Load(), want)
+		}
+	}
+	Reset()
+}
+
+func TestRunStepsFirstError(t *testing.T) {
+	Reset()
+	defer Reset()
+	SetJobs(2)
+
+	events := make(chan string, 20)
+	started, release := make(chan struct{}), make(chan struct{})
+	plan := &BuildPlan{Steps: []BuildStep{
+		// Fails after the second step, but comes first in the plan
+		&StepFunc{StepName: "slow", After: []string{}, Run: func(ctx *Context) error {
+			close(started)
+			<-release
+			return errors.New("slow failed")
+		}},
+		&StepFunc{StepName: "fast", After: []string{}, Run: func(ctx *Context) error {
+			<-started
+			defer close(release)
+			return errors.New("fast failed")
+		}},
+		recordingStep("next", nil, events, nil),
+	}}
+	err := plan.runSteps(telemetry.NewRecorder())
+	if err == nil || err.Error() != "slow failed" {
+		t.Errorf("runSteps() error = %v, want the error of the first failed step in plan order", err)
+	}
+	if order := eventOrder(events); len(order) > 0 {
+		t.Errorf("steps were started after a failure: %v", order)
+	}
+}
+
+func TestKeepConfigs(t *testing.T) {
+	Reset()
+	defer Reset()
+	KeepConfigs()
+	defer func() { parsedConfigs = nil }()
+
+	// The outputs are written to the temporary directory, not the working directory
+	dir := writeFiles(t, map[string]string{"tetra.stl": tetraSTL})
+	configPath := filepath.Join(dir, "config.yaml")
+	writeConfig := func(name string) {
+		config := fmt.Sprintf("output: %s\nobjects:\n  - name: %s\n    parts:\n      - name: tetra\n        file: tetra.stl\n", filepath.Join(dir, name+".3mf"), name)
+		if err := os.WriteFile(configPath, []byte(config), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeConfig("tetra")
+	load := func() *Context {
+		Reset()
+		var out bytes.Buffer
+		previous := ui.Output()
+		ui.SetOutput(&out)
+		defer ui.SetOutput(previous)
+		if err := (&LoadYAMLStep{ConfigPath: configPath}).Execute(); err != nil {
+			t.Fatal(err)
+		}
+		return buildContext
+	}
+
+	first := load()
+	if _, ok := parsedConfigs[first.ConfigHash]; !ok {
+		t.Fatal("the parsed config was not kept")
+	}
+	// Every build gets a copy of its own, which it may change
+	first.YAMLConfig.Objects[0].Name = "changed"
+	second := load()
+	if second.YAMLConfig == first.YAMLConfig || second.YAMLConfig.Objects[0].Name != "tetra" {
+		t.Errorf("the second build got the config of the first one: %+v", second.YAMLConfig.Objects)
+	}
+
+	// A changed config is parsed again
+	writeConfig("other")
+	if third := load(); third.YAMLConfig.Objects[0].Name != "other" {
+		t.Errorf("the changed config was not parsed again: %+v", third.YAMLConfig.Objects)
+	}
+}
+
+func TestConvertSTLJobs(t *testing.T) {
+	tests := []struct {
+		name    string
+		broken  []int // Indices of the files that are no STL files
+		wantErr int   // Index of the file in the error (-1 = none)
+	}{
+		{name: "all converted", wantErr: -1},
+		{name: "first error in input order", broken: []int{2, 4}, wantErr: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			previousContext := buildContext
+			buildContext = &Context{}
+			defer func() { buildContext = previousContext }()
+			SetJobs(4)
+			tmp := t.TempDir()
+			t.Setenv("TMPDIR", tmp)
+			var out bytes.Buffer
+			previous := ui.Output()
+			ui.SetOutput(&out)
+			defer ui.SetOutput(previous)
+
+			// Every file is scaled differently, so the conversions can be told apart
+			dir := t.TempDir()
+			var files []string
+			for i := range 6 {
+				content := strings.ReplaceAll(tetraSTL, "10", strconv.Itoa(10*(i+1)))
+				if slices.Contains(tt.broken, i) {
+					content = "broken"
+				}
+				path := filepath.Join(dir, fmt.Sprintf("part%d.stl", i))
+				if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				files = append(files, path)
+			}
+
+			err := (&ConvertSTLTo3MFStep{Files: files}).Execute()
+			defer renderer.CleanupTempFiles(buildContext.RenderedFiles)
+			if tt.wantErr >= 0 {
+				want := "error converting " + files[tt.wantErr]
+				if err == nil || !strings.Contains(err.Error(), want) {
+					t.Fatalf("Execute() error = %v, want %q", err, want)
+				}
+				if code := exitcode.FromError(err); code != exitcode.Render {
+					t.Errorf("exit code = %v, want %v", code, exitcode.Render)
+				}
+				if len(buildContext.RenderedFiles) > 0 {
+					t.Errorf("RenderedFiles = %v after a failure, want none", buildContext.RenderedFiles)
+				}
+				if left, _ := os.ReadDir(tmp); len(left) > 0 {
+					t.Errorf("temporary files left after a failure: %v", left)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+
+			if len(buildContext.RenderedFiles) != len(files) {
+				t.Fatalf("%d converted files, want %d", len(buildContext.RenderedFiles), len(files))
+			}
+			for i, converted := range buildContext.RenderedFiles {
+				zr, err := zip.OpenReader(converted)
+				if err != nil {
+					t.Fatal(err)
+				}
+				model, err := zr.Open("3D/3dmodel.model")
+				if err != nil {
+					zr.Close()
+					t.Fatal(err)
+				}
+				data, err := io.ReadAll(model)
+				zr.Close()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if want := fmt.Sprintf(`x="%d"`, 10*(i+1)); !strings.Contains(string(data), want) {
+					t.Errorf("converted file %d is not the conversion of %s (no vertex with %s)", i, filepath.Base(files[i]), want)
+				}
+			}
+		})
+	}
+}
+
+func TestProcessFilesKeepGoing(t *testing.T) {
+	tests := []struct {
+		name      string
+		keepGoing bool
+		wantErr   string
+		converted []string // Files converted before the step returned
+		reported  []string // Failures in the output
+	}{
+		{
+			name:      "keep going",
+			keepGoing: true,
+			wantErr:   "2 of 4 file(s) failed to process",
+			converted: []string{"part0.stl", "part2.stl"},
+			reported:  []string{"2 file(s) failed", "part1 (part1.stl): error converting", "part3 (part3.stl): error converting"},
+		},
+		{
+			name:      "stop at the first failure",
+			wantErr:   "part1.stl",
+			converted: []string{"part0.stl"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			previousContext := buildContext
+			buildContext = &Context{}
+			defer func() { buildContext = previousContext }()
+			SetKeepGoing(tt.keepGoing)
+			t.Setenv("CI", "true") // Verbose output lists every converted file
+			tmp := t.TempDir()
+			t.Setenv("TMPDIR", tmp)
+			var out bytes.Buffer
+			previous := ui.Output()
+			ui.SetOutput(&out)
+			defer ui.SetOutput(previous)
+
+			// Every second part is no STL file
+			dir := t.TempDir()
+			for i := range 4 {
+				content := tetraSTL
+				if i%2 == 1 {
+					content = "broken"
+				}
+				name := fmt.Sprintf("part%d", i)
+				path := filepath.Join(dir, name+".stl")
+				if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				buildContext.SCADFiles = append(buildContext.SCADFiles, models.ScadFile{Name: name, Path: path})
+			}
+
+			err := (&RenderSCADFilesStep{}).Execute()
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Execute() error = %v, want %q", err, tt.wantErr)
+			}
+			if code := exitcode.FromError(err); code != exitcode.Render {
+				t.Errorf("exit code = %v, want %v", code, exitcode.Render)
+			}
+			for i := range 4 {
+				name := fmt.Sprintf("part%d.stl", i)
+				converted := strings.Contains(out.String(), "Converted "+name)
+				if want := slices.Contains(tt.converted, name); converted != want {
+					t.Errorf("%s converted = %v, want %v", name, converted, want)
+				}
+			}
+			for _, want := range tt.reported {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("output does not report %q:\n%s", want, out.String())
+				}
+			}
+			if tt.keepGoing {
+				if got := strings.Count(out.String(), "continuing"); got != 2 {
+					t.Errorf("%d warnings, want one per failed file:\n%s", got, out.String())
+				}
+				if left, _ := os.ReadDir(tmp); len(left) > 0 {
+					t.Errorf("temporary files left after the failures: %v", left)
+				}
+			}
+		})
+	}
+}
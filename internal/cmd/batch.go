@@ -0,0 +1,212 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alecthomas/kong"
+	"github.com/philipparndt/go3mf/internal/config"
+	"github.com/philipparndt/go3mf/internal/exitcode"
+	"github.com/philipparndt/go3mf/internal/ui"
+)
+
+// batchResult is the outcome of building one config of a batch
+type batchResult struct {
+	config   string
+	code     exitcode.Code
+	duration time.Duration
+	output   []byte // Captured output of parallel builds
+}
+
+// isBatch reports whether the files are several YAML configs, which are built one by one
+func isBatch(files []string) bool {
+	if len(files) < 2 {
+		return false
+	}
+	for _, file := range files {
+		ext := strings.ToLower(filepath.Ext(file))
+		if ext != ".yaml" && ext != ".yml" {
+			return false
+		}
+	}
+	return true
+}
+
+// batchArgs returns the command line for building a single config of a batch:
+// the arguments of this invocation without the configs and --all. Flags are
+// looked up in the Kong model, so only the positional configs are removed and
+// flag values naming a config (e.g. --settings-from b.yaml) are kept. The jobs
+// of a parallel batch are spent on building configs, so every build then runs
+// with a single job.
+func batchArgs(args, configs []string, configPath string, parallel bool) []string {
+	cmdIndex, node := findObjectCommand(newParser().Model, args)
+	flags := make(map[string]*kong.Flag)
+	if node != nil {
+		flags = flagsByName(node)
+	}
+
+	result := append([]string{}, args[:cmdIndex+1]...)
+	for i := cmdIndex + 1; i < len(args); i++ {
+		arg := args[i]
+		flag, inline, ok := lookupFlag(flags, arg)
+		switch {
+		case arg == "--all":
+		case parallel && ok && flag.Name == "jobs":
+			if !inline {
+				i++ // Skip the value
+			}
+		case parallel && !ok && strings.HasPrefix(arg, "-j"):
+		case ok:
+			result = append(result, arg)
+			if !flag.IsBool() && !flag.IsCounter() && !inline && i+1 < len(args) {
+				i++
+				result = append(result, args[i])
+			}
+		case !contains(configs, arg):
+			result = append(result, arg)
+		}
+	}
+	if parallel {
+		result = append(result, "--jobs=1")
+	}
+	return append(result, configPath)
+}
+
+// workspaceMembers returns the configs of the workspace of the current directory,
+// relative to it where possible
+func workspaceMembers() ([]string, error) {
+	path, err := config.FindWorkspace(".")
+	if err != nil {
+		return nil, exitcode.Wrap(exitcode.Config, err)
+	}
+	if path == "" {
+		return nil, exitcode.Wrap(exitcode.Usage, fmt.Errorf("--all: no %s found in the current directory or its parents", config.WorkspaceFile))
+	}
+	ws, err := config.LoadWorkspace(path)
+	if err != nil {
+		return nil, exitcode.Wrap(exitcode.Config, err)
+	}
+
+	cwd, _ := os.Getwd()
+	members := make([]string, len(ws.Members))
+	for i, member := range ws.Members {
+		members[i] = member
+		if rel, err := filepath.Rel(cwd, member); err == nil {
+			members[i] = rel
+		}
+	}
+	return members, nil
+}
+
+// runBatch builds every config in a separate go3mf process, as builds share
+// global state. With jobs > 1 the builds run in parallel and their output is
+// only shown for failed builds. All configs are built even if some fail.
+func (c *CombineCmd) runBatch() error {
+	if c.Output != "" || c.ExportArrangement != "" || c.LayoutSVG != "" || c.ArrangementJSON != "" || c.Manifest != "" || c.BOM != "" || c.ReportHTML != "" {
+		return exitcode.Wrap(exitcode.Usage, fmt.Errorf("--output, --export-arrangement, --arrangement-json, --layout-svg, --manifest, --bom and --report-html cannot be used when building several configs"))
+	}
+	if c.Jobs < 0 {
+		return exitcode.Wrap(exitcode.Usage, fmt.Errorf("--jobs must not be negative"))
+	}
+	jobs := max(c.Jobs, 1)
+
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("cannot run batch build: %w", err)
+	}
+
+	results := make([]batchResult, len(c.Files))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	queue := make(chan int)
+
+	for range jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				configPath := c.Files[i]
+				cmd := exec.Command(executable, batchArgs(os.Args[1:], c.Files, configPath, jobs > 1)...)
+				var output bytes.Buffer
+				if jobs == 1 {
+					ui.PrintTitle(fmt.Sprintf("Building %s (%d/%d)", configPath, i+1, len(c.Files)))
+					cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+				} else {
+					cmd.Stdout, cmd.Stderr = &output, &output
+				}
+
+				start := time.Now()
+				results[i] = batchResult{config: configPath, code: exitCodeOf(cmd.Run()), duration: time.Since(start), output: output.Bytes()}
+
+				if jobs > 1 {
+					mu.Lock()
+					reportBatchResult(results[i])
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	for i := range c.Files {
+		queue <- i
+	}
+	close(queue)
+	wg.Wait()
+
+	return summarizeBatch(results)
+}
+
+// exitCodeOf returns the exit code of a finished build process
+func exitCodeOf(err error) exitcode.Code {
+	if err == nil {
+		return exitcode.OK
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitcode.Code(exitErr.ExitCode())
+	}
+	return exitcode.General
+}
+
+// reportBatchResult prints the outcome of a parallel build, with its output if it failed
+func reportBatchResult(result batchResult) {
+	if result.code == exitcode.OK {
+		ui.PrintSuccess(fmt.Sprintf("%s (%s)", result.config, result.duration.Round(time.Millisecond)))
+		return
+	}
+	ui.PrintError(fmt.Sprintf("%s failed (exit code %d)", result.config, result.code))
+	ui.Output().Write(result.output)
+}
+
+// summarizeBatch prints a table of all builds and returns an error carrying the
+// exit code of the first failed build
+func summarizeBatch(results []batchResult) error {
+	ui.PrintHeader("Batch Summary")
+	ui.PrintTableHeader("Config", "Result", "Time")
+
+	failed := 0
+	var firstCode exitcode.Code
+	for _, result := range results {
+		status := "ok"
+		if result.code != exitcode.OK {
+			status = fmt.Sprintf("failed (%s)", result.code)
+			if failed == 0 {
+				firstCode = result.code
+			}
+			failed++
+		}
+		ui.PrintTableRow(result.config, status, result.duration.Round(time.Millisecond).String())
+	}
+
+	if failed > 0 {
+		return exitcode.Wrap(firstCode, fmt.Errorf("%d of %d builds failed", failed, len(results)))
+	}
+	ui.PrintSuccess(fmt.Sprintf("All %d builds completed successfully!", len(results)))
+	return nil
+}
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIsBatch(t *testing.T) {
+	tests := []struct {
+		files []string
+		want  bool
+	}{
+		{files: []string{"a.yaml"}, want: false},
+		{files: []string{"a.yaml", "b.YML"}, want: true},
+		{files: []string{"a.yaml", "b.scad"}, want: false},
+		{files: []string{"a.scad", "b.scad"}, want: false},
+	}
+
+	for _, tt := range tests {
+		if got := isBatch(tt.files); got != tt.want {
+			t.Errorf("isBatch(%v) = %v, want %v", tt.files, got, tt.want)
+		}
+	}
+}
+
+func TestBatchArgs(t *testing.T) {
+	args := []string{"build", "a.yaml", "--packing-distance", "5", "b.yaml", "-j", "2", "--all"}
+
+	tests := []struct {
+		name     string
+		args     []string
+		parallel bool
+		want     []string
+	}{
+		{name: "one config at a time", args: args, want: []string{"build", "--packing-distance", "5", "-j", "2", "b.yaml"}},
+		{name: "parallel", args: args, parallel: true, want: []string{"build", "--packing-distance", "5", "--jobs=1", "b.yaml"}},
+		{name: "inline jobs", args: []string{"build", "a.yaml", "b.yaml", "-j4", "--jobs=3"}, parallel: true, want: []string{"build", "--jobs=1", "b.yaml"}},
+		{
+			name: "flag values naming a config",
+			args: []string{"build", "--settings-from", "b.yaml", "a.yaml", "--arrangement=a.yaml", "b.yaml"},
+			want: []string{"build", "--settings-from", "b.yaml", "--arrangement=a.yaml", "b.yaml"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := batchArgs(tt.args, []string{"a.yaml", "b.yaml"}, "b.yaml", tt.parallel)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("batchArgs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
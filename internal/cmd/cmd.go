@@ -10,34 +10,109 @@ import (
 
 	"github.com/alecthomas/kong"
 	"github.com/charmbracelet/huh"
+	"github.com/philipparndt/go3mf/internal/arrangement"
 	"github.com/philipparndt/go3mf/internal/buildplan"
+	"github.com/philipparndt/go3mf/internal/config"
+	"github.com/philipparndt/go3mf/internal/exitcode"
 	"github.com/philipparndt/go3mf/internal/extract"
+	"github.com/philipparndt/go3mf/internal/geometry"
 	"github.com/philipparndt/go3mf/internal/inspect"
+	"github.com/philipparndt/go3mf/internal/limits"
+	"github.com/philipparndt/go3mf/internal/manifest"
+	"github.com/philipparndt/go3mf/internal/models"
+	"github.com/philipparndt/go3mf/internal/selftest"
+	"github.com/philipparndt/go3mf/internal/threemf"
 	"github.com/philipparndt/go3mf/internal/ui"
 	"github.com/philipparndt/go3mf/version"
 )
 
 type CLI struct {
-	Combine    *CombineCmd    `cmd:"" help:"Combine files into single 3MF (supports YAML, SCAD, 3MF, STL)"`
-	Build      *CombineCmd    `cmd:"" help:"Alias for 'combine' - build files into single 3MF (supports YAML, SCAD, 3MF, STL)" aliases:"build"`
-	Init       *InitCmd       `cmd:"" help:"Generate a default YAML configuration file from input files"`
-	Inspect    *InspectCmd    `cmd:"" help:"Inspect a 3MF file and show its contents"`
-	Extract    *ExtractCmd    `cmd:"" help:"Extract 3D models from a 3MF file as STL files"`
-	Version    *VersionCmd    `cmd:"" help:"Show version information"`
-	Completion *CompletionCmd `cmd:"" help:"Generate shell completion script"`
+	Combine       *CombineCmd       `cmd:"" help:"Combine files into single 3MF (supports YAML, SCAD, 3MF, STL)"`
+	Build         *CombineCmd       `cmd:"" help:"Alias for 'combine' - build files into single 3MF (supports YAML, SCAD, 3MF, STL)" aliases:"build"`
+	Init          *InitCmd          `cmd:"" help:"Generate a default YAML configuration file from input files"`
+	Inspect       *InspectCmd       `cmd:"" help:"Inspect a 3MF file and show its contents"`
+	Extract       *ExtractCmd       `cmd:"" help:"Extract 3D models from a 3MF file as STL files"`
+	ApplySettings *ApplySettingsCmd `cmd:"" name:"apply-settings" help:"Write filaments, print settings and metadata from a YAML file into an existing 3MF"`
+	Scrub         *ScrubCmd         `cmd:"" help:"Remove user names, machine serials, timestamps and other identifying metadata from a 3MF file"`
+	Group         *GroupCmd         `cmd:"" help:"Group standalone objects of an existing 3MF file into multi-part objects"`
+	Selftest      *SelftestCmd      `cmd:"" help:"Build bundled SCAD and STL fixtures and compare the results with the expected objects to check the installation"`
+	Daemon        *DaemonCmd        `cmd:"" help:"Keep caches warm in a background process and run builds sent with 'build --daemon'"`
+	Version       *VersionCmd       `cmd:"" help:"Show version information"`
+	SelfUpdate    *SelfUpdateCmd    `cmd:"" help:"Update go3mf to the latest (or a specific) release. Downloads are checked against the checksums of the release, which detects damaged downloads but not a compromised release; no signature is verified."`
+	Completion    *CompletionCmd    `cmd:"" help:"Generate shell completion script"`
+	Docs          *DocsCmd          `cmd:"" hidden:"" help:"Generate man pages or a markdown CLI reference"`
+
+	MaxVertices  int64 `help:"Fail on meshes of input files with more vertices (0 = unlimited)" default:"50000000" env:"GO3MF_MAX_VERTICES" placeholder:"N"`
+	MaxEntries   int   `help:"Fail on 3MF input files with more archive entries (0 = unlimited)" default:"10000" env:"GO3MF_MAX_ENTRIES" placeholder:"N"`
+	MaxEntrySize int64 `help:"Fail on 3MF input files with a larger archive entry in MB (0 = unlimited)" default:"1024" env:"GO3MF_MAX_ENTRY_SIZE" placeholder:"MB"`
 }
 
-// AfterApply adds examples to the help output
+// AfterApply sets the limits of the files read
 func (cli *CLI) AfterApply() error {
+	limits.Set(limits.Limits{
+		MaxVertices:  cli.MaxVertices,
+		MaxEntries:   cli.MaxEntries,
+		MaxEntrySize: cli.MaxEntrySize << 20,
+	})
 	return nil
 }
 
 type CombineCmd struct {
-	Output string   `help:"Output file path (default: combined.3mf)" short:"o"`
-	Object bool     `help:"Start a new object group. Follow with: -n NAME [-c FILAMENT] file1 file2... Repeat --object for multiple groups." name:"object"`
-	Open   bool     `help:"Open the result file in the default application after combining"`
-	Debug  bool     `help:"Enable debug output (verbose mode)"`
-	Files  []string `arg:"" optional:"" help:"Files to combine. Simple mode: file.scad or file.scad:name:filament. Object mode: use --object flag (see below)."`
+	Output        string   `help:"Output file path, or - to write the 3MF to stdout (default: combined.3mf, or the YAML output)" short:"o" predictor:"files:3mf"`
+	Object        bool     `help:"Start a new object group. Follow with: -n NAME [--count N] [-c FILAMENT] file1 file2... Repeat --object for multiple groups." name:"object"`
+	Open          bool     `help:"Open the result file in the default application after combining"`
+	Debug         bool     `help:"Enable debug output (verbose mode)"`
+	KeepGoing     bool     `help:"Process all files even if some fail and report all failures at the end" name:"keep-going"`
+	Jobs          int      `help:"Number of YAML configs built in parallel when several are given, of SCAD parts rendered or STL files converted in parallel, or of custom build steps run in parallel (default: 1, SCAD parts: render_jobs of a YAML config or one per CPU)" short:"j" placeholder:"N"`
+	All           bool     `help:"Build all configs of the workspace (go3mf.workspace.yaml in the current directory or a parent)"`
+	Force         bool     `help:"Overwrite the output file even if it exists and is not a 3MF file"`
+	Resume        bool     `help:"Continue a failed build: its parsed config and completed renders are reused while their files are unchanged"`
+	Daemon        bool     `help:"Run the build on a running 'go3mf daemon', which reuses its warm caches (socket: $GO3MF_DAEMON_SOCKET or the default)"`
+	Checksum      bool     `help:"Write a .sha256 sidecar next to the output and record the go3mf version and geometry hash in the model metadata"`
+	CacheDir      string   `help:"Reuse OpenSCAD renders from this directory while the SCAD files and their dependencies are unchanged (default: the cache of the workspace)" placeholder:"DIR" predictor:"dirs"`
+	Renderer      string   `help:"How OpenSCAD is run: local or docker to render in a container for machines without OpenSCAD (overrides renderer of a YAML config)" placeholder:"RENDERER"`
+	RendererImage string   `help:"Docker image with OpenSCAD for the docker renderer (default: openscad/openscad:2021.01)" placeholder:"IMAGE"`
+	RenderWorker  []string `help:"Delegate renders to this SSH destination (user@host), can be repeated to render parts in parallel (overrides render_workers of a YAML config)" placeholder:"HOST"`
+	Precision     int      `help:"Decimals of the vertex coordinates written to the 3MF file, fewer make smaller files (overrides precision of a YAML config, default: 6)" placeholder:"DECIMALS"`
+
+	PackingDistance  float64  `help:"Distance between objects in mm (overrides packing_distance of a YAML config, default: 10)" placeholder:"MM"`
+	PackingAlgorithm string   `help:"Packing algorithm: default or compact (overrides packing_algorithm of a YAML config)" placeholder:"ALGORITHM"`
+	PackingOrder     string   `help:"Packing order: default or by_height to place objects from the lowest to the tallest (overrides packing_order of a YAML config)" placeholder:"ORDER"`
+	PlacementGrid    float64  `help:"Snap packed object positions to a grid of this size in mm (overrides placement_grid of a YAML config)" placeholder:"MM"`
+	MaxRowWidth      float64  `help:"Maximum width in mm of the rows the default packing algorithm fills (default: the plate width of the printer)" placeholder:"MM"`
+	Footprint        string   `help:"Footprint for collision checks: bbox or hull to pack the convex outlines of the objects (overrides footprint of a YAML config)" placeholder:"SHAPE"`
+	PackingOrigin    string   `help:"Plate edge the first row is packed at: front or back, to start at the top of the plate as shown by slicers (overrides packing_origin of a YAML config)" placeholder:"EDGE"`
+	Normalize        string   `help:"Z normalization: true to place objects on the build plate, false to keep the Z of the meshes, or preserve to also keep the Z offsets of input 3MF files (overrides normalize of a YAML config)" placeholder:"MODE"`
+	Profile          string   `help:"Build profile of the YAML config to apply, e.g. draft (see profiles in the YAML config)" placeholder:"NAME"`
+	OutputTemplate   string   `help:"Output file name with variables: {profile}, {git} (short commit hash), {date} (YYYY-MM-DD), e.g. dist/widget_{git}.3mf (overrides the YAML output)" placeholder:"TEMPLATE"`
+	Var              []string `help:"Set a variable of the YAML config for enabled_if conditions (repeatable)" placeholder:"NAME=VALUE" sep:"none"`
+	Printer          string   `help:"Printer profile for plate size, exclusion zones and filament slots: a preset (x1c, p1s, a1, a1-mini, h2d, mk4, ...) or a printer of the YAML config (overrides printer of a YAML config)" placeholder:"NAME"`
+
+	MinUtilization float64 `help:"Fail if a used plate is covered less than this percentage of its area (overrides min_utilization of a YAML config)" placeholder:"PERCENT"`
+	MaxUtilization float64 `help:"Fail if a plate is covered more than this percentage of its area (overrides max_utilization of a YAML config)" placeholder:"PERCENT"`
+
+	Arrangement       string   `help:"Place objects at the positions of an arrangement file instead of packing them" placeholder:"FILE" predictor:"files:json"`
+	ExportArrangement string   `help:"Write the final object positions to an arrangement file" placeholder:"FILE" predictor:"files:json"`
+	ArrangementJSON   string   `help:"Write the final position, rotation, plate and footprint of every object as JSON for external tools, or - to write it to stdout" name:"arrangement-json" placeholder:"FILE" predictor:"files:json"`
+	LayoutSVG         string   `help:"Render the final plate layout (footprints, names, filaments) as an SVG image" name:"layout-svg" placeholder:"FILE" predictor:"files:svg"`
+	BOM               string   `help:"Write a bill of materials with the quantity, filament, volume, estimated weight and source of every part as CSV" name:"bom" placeholder:"FILE" predictor:"files:csv"`
+	Estimate          bool     `help:"Print the estimated filament (g) and print time of every object, e.g. for quotes"`
+	Slicer            string   `help:"Slicer command line (Bambu Studio or OrcaSlicer) to slice the output for the total of the print estimate (implies --estimate)" placeholder:"PATH"`
+	ReportHTML        string   `help:"Write a single-file HTML build report with the plate layout, object thumbnails, part statistics and warnings" name:"report-html" placeholder:"FILE" predictor:"files:html"`
+	OTLPEndpoint      string   `help:"Export the build steps with their durations as OpenTelemetry spans to this OTLP/HTTP collector, e.g. http://localhost:4318" name:"otlp-endpoint" env:"OTEL_EXPORTER_OTLP_ENDPOINT" placeholder:"URL"`
+	AuxMerge          string   `help:"Auxiliary archive entries (thumbnails, custom metadata, ...) to copy from the input files: first (default), all (first input with an entry wins) or namespace (other inputs in a folder named after the input)" name:"aux-merge" placeholder:"POLICY"`
+	AuxInclude        []string `help:"Copy only the auxiliary archive entries matching this glob, e.g. 'Metadata/*.png' (repeatable)" name:"aux-include" placeholder:"GLOB" sep:"none"`
+	AuxExclude        []string `help:"Do not copy the auxiliary archive entries matching this glob (repeatable)" name:"aux-exclude" placeholder:"GLOB" sep:"none"`
+	SettingsFrom      string   `help:"Take the printer and process presets and other auxiliary archive entries from this 3MF file (an input or a template) instead of the first input" name:"settings-from" placeholder:"FILE" predictor:"files:3mf"`
+	Verify            bool     `help:"Read the output back and fail if it is structurally broken (content types, relationships, object references, meshes, model settings)"`
+	StripMetadata     bool     `help:"Write a plain geometric model: drop the project settings, thumbnails, auxiliary files and Bambu Studio metadata of the inputs and the build" name:"strip-metadata"`
+	IgnoreExtensions  bool     `help:"Combine input 3MF files that require unsupported 3MF extensions instead of failing (the output may be broken)" name:"ignore-extensions"`
+	CompactXML        bool     `help:"Write the model XML without indentation, which makes large files smaller (default: indented for readability)" name:"compact-xml"`
+	Flatten           bool     `help:"Merge objects of input 3MF files that are made of (nested) components into one mesh each"`
+	Explode           []string `help:"Build every part of this object as an object of its own with its own filament and place on the plate, e.g. to print the parts separately (repeatable)" placeholder:"OBJECT" sep:"none"`
+	Manifest          string   `help:"Write a JSON build manifest with the input files and their hashes, render parameters, filaments and final transforms" placeholder:"FILE" predictor:"files:json"`
+
+	Files []string `arg:"" optional:"" help:"Files to combine. Simple mode: file.scad, file.scad:name:filament or file.scad:name:filament:count. Object mode: use --object flag (see below). Use - to read a YAML config from stdin." predictor:"files:scad,3mf,stl,yaml,yml"`
 
 	Objects []buildplan.ObjectGroup `kong:"-"` // Parsed object groups
 }
@@ -65,51 +140,183 @@ func openFile(filepath string) error {
 	return cmd.Start()
 }
 
-func (c *CombineCmd) Run() error {
-	// If --object flag is used anywhere, parse from raw args for better UX
-	if c.Object || containsObjectFlag(os.Args) {
-		var err error
-		c.Objects, err = parseObjectGroupsFromRawArgs(os.Args)
-		if err != nil {
-			ui.PrintError("Failed to parse object groups: " + err.Error())
-			os.Exit(1)
+func (c *CombineCmd) Run(objects objectGroups) error {
+	if c.Daemon {
+		return c.runOnDaemon()
+	}
+
+	// Object groups are parsed from the command line before Kong (see splitObjectArgs)
+	if len(objects) > 0 {
+		if len(c.Files) > 0 {
+			return exitcode.Wrap(exitcode.Usage, fmt.Errorf("files must be listed inside an --object group when --object is used: %s", strings.Join(c.Files, " ")))
+		}
+		c.Objects = objects
+	}
+
+	if c.All {
+		if len(c.Files) > 0 || len(c.Objects) > 0 {
+			return exitcode.Wrap(exitcode.Usage, fmt.Errorf("--all cannot be combined with files or objects"))
 		}
-		if len(c.Objects) > 0 {
-			c.Files = nil
+		members, err := workspaceMembers()
+		if err != nil {
+			return err
 		}
+		c.Files = members
+		return c.runBatch()
 	}
 
 	// Validate that we have either Files or Objects, but require at least one
 	if len(c.Files) == 0 && len(c.Objects) == 0 {
-		ui.PrintError("No files or objects specified")
-		os.Exit(1)
+		return exitcode.Wrap(exitcode.Usage, fmt.Errorf("no files or objects specified"))
 	}
 
-	// Determine output file if not specified
-	outputFile := c.Output
-	if outputFile == "" {
-		outputFile = "combined.3mf"
+	// Several YAML configs are built one by one, each like a single config
+	if len(c.Objects) == 0 && isBatch(c.Files) {
+		return c.runBatch()
 	}
 
 	// Set debug mode if requested
 	buildplan.SetDebug(c.Debug)
+	buildplan.SetKeepGoing(c.KeepGoing)
+	if c.Jobs < 0 {
+		return exitcode.Wrap(exitcode.Usage, fmt.Errorf("--jobs must not be negative"))
+	}
+	buildplan.SetJobs(c.Jobs)
 
-	// Create build plan
+	if c.PackingDistance < 0 {
+		return exitcode.Wrap(exitcode.Usage, fmt.Errorf("--packing-distance must not be negative"))
+	}
+	packingAlgorithm := models.PackingAlgorithm("")
+	if c.PackingAlgorithm != "" {
+		var err error
+		if packingAlgorithm, err = models.ParsePackingAlgorithm(c.PackingAlgorithm); err != nil {
+			return exitcode.Wrap(exitcode.Usage, fmt.Errorf("--packing-algorithm: %w", err))
+		}
+	}
+	packingOrder := models.PackingOrder("")
+	if c.PackingOrder != "" {
+		var err error
+		if packingOrder, err = models.ParsePackingOrder(c.PackingOrder); err != nil {
+			return exitcode.Wrap(exitcode.Usage, fmt.Errorf("--packing-order: %w", err))
+		}
+	}
+	buildplan.SetPacking(c.PackingDistance, packingAlgorithm, packingOrder)
+	if c.PlacementGrid < 0 {
+		return exitcode.Wrap(exitcode.Usage, fmt.Errorf("--placement-grid must not be negative"))
+	}
+	buildplan.SetPlacementGrid(c.PlacementGrid)
+	if c.MaxRowWidth < 0 {
+		return exitcode.Wrap(exitcode.Usage, fmt.Errorf("--max-row-width must not be negative"))
+	}
+	buildplan.SetMaxRowWidth(c.MaxRowWidth)
+	footprint := models.Footprint("")
+	if c.Footprint != "" {
+		var err error
+		if footprint, err = models.ParseFootprint(c.Footprint); err != nil {
+			return exitcode.Wrap(exitcode.Usage, fmt.Errorf("--footprint: %w", err))
+		}
+	}
+	buildplan.SetFootprint(footprint)
+	origin := models.PackingOrigin("")
+	if c.PackingOrigin != "" {
+		var err error
+		if origin, err = models.ParsePackingOrigin(c.PackingOrigin); err != nil {
+			return exitcode.Wrap(exitcode.Usage, fmt.Errorf("--packing-origin: %w", err))
+		}
+	}
+	buildplan.SetPackingOrigin(origin)
+	normalize := models.Normalization("")
+	if c.Normalize != "" {
+		var err error
+		if normalize, err = models.ParseNormalization(c.Normalize); err != nil {
+			return exitcode.Wrap(exitcode.Usage, fmt.Errorf("--normalize: %w", err))
+		}
+	}
+	buildplan.SetNormalization(normalize)
+	buildplan.SetPrinter(c.Printer)
+	buildplan.SetProfile(c.Profile)
+	if c.OutputTemplate != "" && c.Output != "" {
+		return exitcode.Wrap(exitcode.Usage, fmt.Errorf("--output and --output-template cannot be combined"))
+	}
+	buildplan.SetOutputTemplate(c.OutputTemplate)
+	buildplan.SetForce(c.Force)
+	buildplan.SetResume(c.Resume)
+	buildplan.SetChecksum(c.Checksum)
+	vars, err := config.ParseVars(c.Var)
+	if err != nil {
+		return exitcode.Wrap(exitcode.Usage, fmt.Errorf("--var: %w", err))
+	}
+	buildplan.SetVars(vars)
+	buildplan.SetCacheDir(c.CacheDir)
+	rendererKind := models.Renderer("")
+	if c.Renderer != "" {
+		if rendererKind, err = models.ParseRenderer(c.Renderer); err != nil {
+			return exitcode.Wrap(exitcode.Usage, fmt.Errorf("--renderer: %w", err))
+		}
+	}
+	buildplan.SetRenderer(rendererKind, c.RendererImage)
+	if err := config.ValidateRenderWorkers(c.RenderWorker); err != nil {
+		return exitcode.Wrap(exitcode.Usage, fmt.Errorf("--render-worker: %w", err))
+	}
+	buildplan.SetRenderWorkers(c.RenderWorker)
+	if err := geometry.ValidatePrecision(c.Precision); err != nil {
+		return exitcode.Wrap(exitcode.Usage, fmt.Errorf("--precision: %w", err))
+	}
+	buildplan.SetPrecision(c.Precision)
+	if err := models.ValidateUtilizationLimits(c.MinUtilization, c.MaxUtilization); err != nil {
+		return exitcode.Wrap(exitcode.Usage, fmt.Errorf("--min-utilization/--max-utilization: %w", err))
+	}
+	buildplan.SetUtilizationLimits(c.MinUtilization, c.MaxUtilization)
+	buildplan.SetArrangement(c.Arrangement, c.ExportArrangement)
+	buildplan.SetLayoutSVG(c.LayoutSVG)
+	if c.ArrangementJSON == buildplan.StdoutPath {
+		if c.Output == buildplan.StdoutPath {
+			return exitcode.Wrap(exitcode.Usage, fmt.Errorf("--arrangement-json and --output cannot both write to stdout"))
+		}
+		// Keep stdout clean for the JSON
+		ui.SetOutput(os.Stderr)
+	}
+	buildplan.SetArrangementJSON(c.ArrangementJSON)
+	buildplan.SetManifest(c.Manifest)
+	buildplan.SetBOM(c.BOM)
+	buildplan.SetEstimate(c.Estimate, c.Slicer)
+	buildplan.SetReport(c.ReportHTML)
+	buildplan.SetOTLPEndpoint(c.OTLPEndpoint)
+	auxMerge, err := models.ParseAuxiliaryMerge(c.AuxMerge)
+	if err != nil {
+		return exitcode.Wrap(exitcode.Usage, fmt.Errorf("--aux-merge: %w", err))
+	}
+	auxiliary := models.AuxiliaryPolicy{Merge: auxMerge, Include: c.AuxInclude, Exclude: c.AuxExclude, Settings: c.SettingsFrom}
+	if err := auxiliary.Validate(); err != nil {
+		return exitcode.Wrap(exitcode.Usage, fmt.Errorf("--aux-include/--aux-exclude: %w", err))
+	}
+	if c.SettingsFrom != "" {
+		if _, err := os.Stat(c.SettingsFrom); err != nil {
+			return exitcode.Wrap(exitcode.Usage, fmt.Errorf("--settings-from: file not found: %s", c.SettingsFrom))
+		}
+	}
+	buildplan.SetAuxiliaryPolicy(auxiliary)
+	buildplan.SetStripMetadata(c.StripMetadata)
+	buildplan.SetVerify(c.Verify)
+	buildplan.SetIgnoreExtensions(c.IgnoreExtensions)
+	buildplan.SetCompactXML(c.CompactXML)
+	buildplan.SetFlatten(c.Flatten)
+	buildplan.SetExplode(c.Explode)
+
+	// Create build plan (an empty output selects the plan's default output file)
 	planner := buildplan.NewPlanner()
-	plan, err := planner.CreatePlan(c.Files, c.Objects, outputFile)
+	plan, err := planner.CreatePlan(c.Files, c.Objects, c.Output)
 	if err != nil {
-		ui.PrintError("Failed to create build plan: " + err.Error())
-		os.Exit(1)
+		return exitcode.Wrap(exitcode.Usage, fmt.Errorf("failed to create build plan: %w", err))
 	}
 
 	// Execute the plan
 	if err := plan.Execute(); err != nil {
-		ui.PrintError(err.Error())
-		os.Exit(1)
+		return err
 	}
 
-	// Open the file in default application if requested
-	if c.Open {
+	// Open the file in default application if requested (not possible when streaming to stdout)
+	if c.Open && plan.OutputFile != "" {
 		if err := openFile(plan.OutputFile); err != nil {
 			ui.PrintError("Failed to open file: " + err.Error())
 		}
@@ -118,184 +325,288 @@ func (c *CombineCmd) Run() error {
 	return nil
 }
 
-// containsObjectFlag checks if --object is present in args
-func containsObjectFlag(args []string) bool {
-	for _, arg := range args {
-		if arg == "--object" {
-			return true
-		}
-	}
-	return false
+type InspectCmd struct {
+	File              string `arg:"" help:"3MF file to inspect" predictor:"files:3mf"`
+	ExportArrangement string `help:"Write the object positions to an arrangement file (e.g. after rearranging in a slicer)" placeholder:"FILE" predictor:"files:json"`
+	Config            string `help:"Compare the 3MF file with the YAML config it was built from and fail if they differ" placeholder:"FILE" predictor:"files:yaml,yml"`
+	ToYAML            string `help:"Reconstruct a YAML config from the 3MF file (the part meshes are written to <config>_parts next to it)" name:"to-yaml" placeholder:"FILE" predictor:"files:yaml,yml"`
+	Format            string `help:"Output format: text (full inspection), table or csv (one row per object: name, id, parts, filament, size, triangles)" enum:"text,table,csv" default:"text"`
 }
 
-// parseObjectGroupsFromRawArgs parses the new flag-based format:
-// --object -n "Name" -c 1 file1.scad -c 2 file2.scad --object -n "Next" file3.scad
-func parseObjectGroupsFromRawArgs(args []string) ([]buildplan.ObjectGroup, error) {
-	var groups []buildplan.ObjectGroup
-	var currentGroup *buildplan.ObjectGroup
-	var currentFilament int
-	inCombineCmd := false
-	i := 0
-
-	for i < len(args) {
-		arg := args[i]
-
-		// Wait until we're in the combine or build command
-		if arg == "combine" || arg == "build" {
-			inCombineCmd = true
-			i++
-			continue
+func (c *InspectCmd) Run() error {
+	inspector := inspect.NewInspector()
+	if c.Format == "text" {
+		if err := inspector.Inspect(c.File); err != nil {
+			return exitcode.Wrap(exitcode.Input, err)
 		}
+	} else if err := c.printObjects(inspector); err != nil {
+		return err
+	}
 
-		if !inCombineCmd {
-			i++
-			continue
+	if c.ExportArrangement != "" {
+		model, settings, err := inspector.Read3MFFile(c.File)
+		if err != nil {
+			return exitcode.Wrap(exitcode.Input, err)
 		}
-
-		// Skip output flag and its value
-		if arg == "-o" || arg == "--output" {
-			i += 2
-			continue
+		a, err := arrangement.FromModel(model, settings)
+		if err != nil {
+			return exitcode.Wrap(exitcode.Input, fmt.Errorf("cannot capture arrangement: %w", err))
 		}
-
-		// Skip open flag
-		if arg == "--open" {
-			i++
-			continue
+		if err := a.Save(c.ExportArrangement); err != nil {
+			return exitcode.Wrap(exitcode.Output, err)
 		}
+		ui.PrintSuccess(fmt.Sprintf("Arrangement of %d object(s) exported to %s", len(a.Objects), c.ExportArrangement))
+	}
 
-		// Skip debug flag
-		if arg == "--debug" {
-			i++
-			continue
+	if c.ToYAML != "" {
+		cfg, err := inspector.ToYAML(c.File, c.ToYAML)
+		if err != nil {
+			return exitcode.Wrap(exitcode.Output, err)
 		}
-
-		// Start new object group
-		if arg == "--object" {
-			// Save previous group if exists
-			if currentGroup != nil && len(currentGroup.Files) > 0 {
-				groups = append(groups, *currentGroup)
-			}
-			currentGroup = &buildplan.ObjectGroup{
-				Name:  "",
-				Files: []string{},
-			}
-			currentFilament = 0
-			i++
-			continue
+		objects := len(cfg.Objects)
+		for _, plate := range cfg.Plates {
+			objects += len(plate.Objects)
 		}
+		ui.PrintSuccess(fmt.Sprintf("Config with %d object(s) written to %s", objects, c.ToYAML))
+	}
 
-		// Parse -n flag (object name)
-		if (arg == "-n" || arg == "--name") && currentGroup != nil {
-			if i+1 < len(args) {
-				currentGroup.Name = args[i+1]
-				i += 2
-				continue
-			}
-		}
+	if c.Config != "" {
+		return c.compareConfig(inspector)
+	}
+	return nil
+}
 
-		// Parse -c flag (filament/color)
-		if (arg == "-c" || arg == "--color" || arg == "--filament") && currentGroup != nil {
-			if i+1 < len(args) {
-				fmt.Sscanf(args[i+1], "%d", &currentFilament)
-				i += 2
-				continue
-			}
+// printObjects prints the objects of the file as table or CSV
+func (c *InspectCmd) printObjects(inspector *inspect.Inspector) error {
+	if _, err := os.Stat(c.File); err != nil {
+		return exitcode.Wrap(exitcode.Input, fmt.Errorf("file not found: %s", c.File))
+	}
+	rows, err := inspector.ObjectRows(c.File)
+	if err != nil {
+		return exitcode.Wrap(exitcode.Input, err)
+	}
+	if c.Format == "csv" {
+		if err := inspect.WriteObjectCSV(ui.Output(), rows); err != nil {
+			return exitcode.Wrap(exitcode.Output, fmt.Errorf("error writing CSV: %w", err))
 		}
+		return nil
+	}
+	inspect.PrintObjectTable(rows)
+	return nil
+}
 
-		// If we have a current group and this looks like a file, add it
-		if currentGroup != nil && isFile(arg) {
-			// Format: path or path:name or path:name:filament
-			// If filament was set via -c, append it
-			fileSpec := arg
-			if currentFilament > 0 {
-				// Check how many colons are in the arg
-				colonCount := strings.Count(arg, ":")
-				if colonCount == 0 {
-					// Simple path, use filename as name and add filament
-					// Will be: path::filament (name will be derived from filename)
-					fileSpec = fmt.Sprintf("%s::%d", arg, currentFilament)
-				} else if colonCount == 1 {
-					// path:name format, add filament
-					fileSpec = fmt.Sprintf("%s:%d", arg, currentFilament)
-				}
-				// If already has 2+ colons, keep as-is (fully specified)
-			}
-			currentGroup.Files = append(currentGroup.Files, fileSpec)
-			currentFilament = 0 // Reset after using
-			i++
-			continue
-		}
+// compareConfig reports the differences between the 3MF file and the YAML config
+func (c *InspectCmd) compareConfig(inspector *inspect.Inspector) error {
+	cfg, err := config.NewLoader().Load(c.Config)
+	if err != nil {
+		return exitcode.Wrap(exitcode.Config, fmt.Errorf("failed to load config: %w", err))
+	}
+	model, settings, err := inspector.Read3MFFile(c.File)
+	if err != nil {
+		return exitcode.Wrap(exitcode.Input, err)
+	}
+	m, err := manifest.FromModel(model, settings)
+	if err != nil {
+		return exitcode.Wrap(exitcode.Input, fmt.Errorf("cannot read objects: %w", err))
+	}
 
-		i++
+	ui.PrintHeader("Comparison with " + c.Config)
+	drifts := inspect.CompareConfig(m, cfg)
+	if len(drifts) == 0 {
+		ui.PrintSuccess("The 3MF file matches the config")
+		return nil
+	}
+	for _, drift := range drifts {
+		ui.PrintWarning(drift.String())
 	}
+	return exitcode.Wrap(exitcode.Drift, fmt.Errorf("%s differs from %s in %d place(s)", c.File, c.Config, len(drifts)))
+}
 
-	// Don't forget the last group
-	if currentGroup != nil && len(currentGroup.Files) > 0 {
-		if currentGroup.Name == "" {
-			return nil, fmt.Errorf("object group must have a name (use -n flag)")
-		}
-		groups = append(groups, *currentGroup)
+type ExtractCmd struct {
+	File       string `arg:"" help:"3MF file to extract models from" predictor:"files:3mf"`
+	OutputDir  string `help:"Output directory for STL files (default: current directory)" short:"o" default:"." predictor:"dirs"`
+	ASCII      bool   `help:"Output ASCII STL files instead of binary" short:"a"`
+	ASCIINames bool   `help:"Transliterate object names to ASCII for the file names (ä → ae, é → e, other characters → _)" name:"ascii-names"`
+	Flatten    bool   `help:"Extract every object with all its (nested) components to one STL file instead of every mesh"`
+}
+
+func (c *ExtractCmd) Run() error {
+	extractor := extract.NewExtractor()
+	extractor.SetASCIINames(c.ASCIINames)
+	extractor.SetFlatten(c.Flatten)
+	return exitcode.Wrap(exitcode.Input, extractor.Extract(c.File, c.OutputDir, !c.ASCII))
+}
+
+type ApplySettingsCmd struct {
+	File     string `arg:"" help:"3MF file to update" predictor:"files:3mf"`
+	Settings string `arg:"" help:"YAML file with filaments, print_settings and metadata, or - to read it from stdin" predictor:"files:yaml,yml"`
+	Output   string `help:"Output file path (default: update the 3MF file in place)" short:"o" predictor:"files:3mf"`
+}
+
+func (c *ApplySettingsCmd) Run() error {
+	settings, err := config.LoadSettings(c.Settings)
+	if err != nil {
+		return exitcode.Wrap(exitcode.Config, err)
+	}
+	if _, err := os.Stat(c.File); err != nil {
+		return exitcode.Wrap(exitcode.Input, fmt.Errorf("cannot read %s: %w", c.File, err))
 	}
 
-	return groups, nil
+	output := c.Output
+	if output == "" {
+		output = c.File
+	}
+	if err := threemf.ApplySettings(c.File, output, settings); err != nil {
+		return exitcode.Wrap(exitcode.Output, fmt.Errorf("failed to apply settings: %w", err))
+	}
+
+	ui.PrintSuccess(fmt.Sprintf("Applied %d filament(s), %d print setting(s) and %d metadata value(s) to %s",
+		len(settings.Filaments), len(settings.PrintSettings), len(settings.Metadata), output))
+	return nil
 }
 
-// isFile checks if a string looks like a file path
-func isFile(s string) bool {
-	// Check for file extensions or path indicators
-	return strings.HasSuffix(s, ".scad") ||
-		strings.HasSuffix(s, ".3mf") ||
-		strings.HasSuffix(s, ".stl") ||
-		strings.Contains(s, "/") ||
-		strings.Contains(s, "\\") ||
-		(strings.Contains(s, ".") && !strings.HasPrefix(s, "-"))
+type ScrubCmd struct {
+	File   string `arg:"" help:"3MF file to scrub" predictor:"files:3mf"`
+	Output string `help:"Output file path (default: update the 3MF file in place)" short:"o" predictor:"files:3mf"`
 }
 
-type InspectCmd struct {
-	File string `arg:"" help:"3MF file to inspect"`
+func (c *ScrubCmd) Run() error {
+	if _, err := os.Stat(c.File); err != nil {
+		return exitcode.Wrap(exitcode.Input, fmt.Errorf("cannot read %s: %w", c.File, err))
+	}
+
+	output := c.Output
+	if output == "" {
+		output = c.File
+	}
+	removed, err := threemf.Scrub(c.File, output)
+	if err != nil {
+		return exitcode.Wrap(exitcode.Output, fmt.Errorf("failed to scrub: %w", err))
+	}
+
+	for _, value := range removed {
+		ui.PrintItem(value)
+	}
+	ui.PrintSuccess(fmt.Sprintf("Removed %d identifying value(s), written to %s", len(removed), output))
+	return nil
 }
 
-func (c *InspectCmd) Run() error {
-	inspector := inspect.NewInspector()
-	return inspector.Inspect(c.File)
+type GroupCmd struct {
+	File   string   `arg:"" help:"3MF file with the objects to group" predictor:"files:3mf"`
+	Object []string `help:"Group objects into a new object with one part per object, e.g. Case=top,bottom (repeatable)" placeholder:"NAME=OBJECT,..." sep:"none" required:""`
+	Output string   `help:"Output file path (default: update the 3MF file in place)" short:"o" predictor:"files:3mf"`
 }
 
-type ExtractCmd struct {
-	File      string `arg:"" help:"3MF file to extract models from"`
-	OutputDir string `help:"Output directory for STL files (default: current directory)" short:"o" default:"."`
-	ASCII     bool   `help:"Output ASCII STL files instead of binary" short:"a"`
+func (c *GroupCmd) Run() error {
+	var groupings []threemf.Grouping
+	for _, value := range c.Object {
+		grouping, err := threemf.ParseGrouping(value)
+		if err != nil {
+			return exitcode.Wrap(exitcode.Usage, fmt.Errorf("--object: %w", err))
+		}
+		groupings = append(groupings, grouping)
+	}
+	if _, err := os.Stat(c.File); err != nil {
+		return exitcode.Wrap(exitcode.Input, fmt.Errorf("cannot read %s: %w", c.File, err))
+	}
+
+	output := c.Output
+	if output == "" {
+		output = c.File
+	}
+	if err := threemf.Group(c.File, output, groupings); err != nil {
+		return exitcode.Wrap(exitcode.Input, fmt.Errorf("failed to group objects: %w", err))
+	}
+
+	for _, grouping := range groupings {
+		ui.PrintItem(fmt.Sprintf("%s: %s", grouping.Name, strings.Join(grouping.Objects, ", ")))
+	}
+	ui.PrintSuccess(fmt.Sprintf("Grouped objects into %d object(s), written to %s", len(groupings), output))
+	return nil
 }
 
-func (c *ExtractCmd) Run() error {
-	extractor := extract.NewExtractor()
-	return extractor.Extract(c.File, c.OutputDir, !c.ASCII)
+type SelftestCmd struct {
+	NoSCAD bool   `help:"Skip the fixtures that need OpenSCAD" name:"no-scad"`
+	Keep   string `help:"Keep the fixtures and outputs in this directory (e.g. to attach them to a bug report)" placeholder:"DIR" predictor:"dirs"`
+}
+
+func (c *SelftestCmd) Run() error {
+	ui.PrintHeader("go3mf self-test")
+	ui.PrintKeyValue("go3mf", version.Get().String())
+	openSCAD := selftest.OpenSCADVersion()
+	switch {
+	case openSCAD != "":
+		ui.PrintKeyValue("OpenSCAD", openSCAD)
+	case c.NoSCAD:
+		ui.PrintKeyValue("OpenSCAD", "not found")
+	default:
+		return exitcode.Wrap(exitcode.Preconditions, fmt.Errorf("OpenSCAD cannot be run, install it from https://openscad.org/ or skip the SCAD fixtures with --no-scad"))
+	}
+
+	dir := c.Keep
+	if dir == "" {
+		tmp, err := os.MkdirTemp("", "go3mf-selftest-")
+		if err != nil {
+			return exitcode.Wrap(exitcode.Output, fmt.Errorf("cannot create work directory: %w", err))
+		}
+		defer os.RemoveAll(tmp)
+		dir = tmp
+	} else if err := os.MkdirAll(dir, 0755); err != nil {
+		return exitcode.Wrap(exitcode.Output, fmt.Errorf("cannot create %s: %w", dir, err))
+	}
+
+	results, err := selftest.NewTester(dir, c.NoSCAD).Run()
+	if err != nil {
+		return exitcode.Wrap(exitcode.Output, err)
+	}
+
+	ui.PrintSeparator()
+	failed := 0
+	for _, result := range results {
+		switch {
+		case result.Skipped:
+			ui.PrintWarning(fmt.Sprintf("%s: skipped", result.Case.Name))
+		case result.Err != nil:
+			failed++
+			ui.PrintError(fmt.Sprintf("%s: %v", result.Case.Name, result.Err))
+		default:
+			ui.PrintSuccess(fmt.Sprintf("%s: passed", result.Case.Name))
+		}
+	}
+	if c.Keep != "" {
+		ui.PrintInfo(fmt.Sprintf("Fixtures and outputs kept in %s", c.Keep))
+	}
+	if failed > 0 {
+		return exitcode.Wrap(exitcode.General, fmt.Errorf("self-test failed: %d of %d case(s)", failed, len(results)))
+	}
+	return nil
 }
 
 type InitCmd struct {
-	Output string   `help:"Output YAML file path (default: config.yaml)" short:"o" default:"config.yaml"`
-	Files  []string `arg:"" help:"Files or glob patterns to include (e.g., *.stl, models/*.scad)"`
+	Output       string   `help:"Output YAML file path (default: config.yaml)" short:"o" default:"config.yaml" predictor:"files:yaml,yml"`
+	FromSTLNames bool     `help:"Set the filament slots of parts whose file name ends with a color (e.g. logo_red.stl) and add the colors as filaments" name:"from-stl-names"`
+	Group        string   `help:"How files are grouped into objects: dirs (an object per directory), parts (all files in one object), objects (an object per file) or auto (dirs if the files are in several directories, otherwise ask)" enum:"auto,dirs,parts,objects" default:"auto"`
+	Files        []string `arg:"" help:"Files, directories or glob patterns to include (e.g., *.stl, models/*.scad, case/)" predictor:"files:scad,3mf,stl"`
 }
 
 func (c *InitCmd) Run() error {
 	if len(c.Files) == 0 {
-		return fmt.Errorf("at least one file or pattern must be specified")
+		return exitcode.Wrap(exitcode.Usage, fmt.Errorf("at least one file or pattern must be specified"))
 	}
 
 	// Expand glob patterns
 	expandedFiles, err := expandGlobPatterns(c.Files)
 	if err != nil {
-		return fmt.Errorf("error expanding patterns: %w", err)
+		return exitcode.Wrap(exitcode.Usage, fmt.Errorf("error expanding patterns: %w", err))
 	}
 
 	if len(expandedFiles) == 0 {
-		return fmt.Errorf("no files matched the specified pattern(s)")
+		return exitcode.Wrap(exitcode.Input, fmt.Errorf("no files matched the specified pattern(s)"))
 	}
 
 	// Check if output file already exists
 	if _, err := os.Stat(c.Output); err == nil {
-		ui.PrintError(fmt.Sprintf("File %s already exists. Please remove it or specify a different output file with -o", c.Output))
-		os.Exit(1)
+		return exitcode.Wrap(exitcode.Output, fmt.Errorf("file %s already exists. Please remove it or specify a different output file with -o", c.Output))
 	}
 
 	ui.PrintTitle("go3mf Init")
@@ -304,31 +615,54 @@ func (c *InitCmd) Run() error {
 	ui.PrintInfo(fmt.Sprintf("Creating configuration from %d file(s)", len(expandedFiles)))
 	fmt.Println()
 
-	// Ask the user if files should be separate parts or separate objects
-	var organizationType string
-	err = huh.NewSelect[string]().
-		Title("How should the files be organized?").
-		Options(
-			huh.NewOption("Separate parts (all files in one object)", "parts"),
-			huh.NewOption("Separate objects (each file is a separate object)", "objects"),
-		).
-		Value(&organizationType).
-		Run()
+	var guess *filamentGuess
+	if c.FromSTLNames {
+		var warnings []string
+		guess, warnings = guessFilaments(expandedFiles)
+		for _, warning := range warnings {
+			ui.PrintWarning(warning)
+		}
+		ui.PrintInfo(fmt.Sprintf("Found %d filament color(s) in the file names", len(guess.colors)))
+	}
 
-	if err != nil {
-		return fmt.Errorf("selection cancelled: %w", err)
+	// Files from several directories are grouped by directory unless a grouping is chosen
+	organizationType := c.Group
+	if organizationType == "auto" && len(groupByDirectory(expandedFiles)) > 1 {
+		organizationType = "dirs"
+		ui.PrintInfo("Files are in several directories, creating an object per directory (use --group to change)")
+	}
+	if organizationType == "auto" {
+		// Ask the user if files should be separate parts or separate objects
+		err = huh.NewSelect[string]().
+			Title("How should the files be organized?").
+			Options(
+				huh.NewOption("Separate parts (all files in one object)", "parts"),
+				huh.NewOption("Separate objects (each file is a separate object)", "objects"),
+			).
+			Value(&organizationType).
+			Run()
+
+		if err != nil {
+			return fmt.Errorf("selection cancelled: %w", err)
+		}
 	}
 
 	var yamlContent string
-	if organizationType == "parts" {
-		yamlContent = generateSeparatePartsYAML(expandedFiles, c.Output)
-	} else {
-		yamlContent = generateSeparateObjectsYAML(expandedFiles, c.Output)
+	switch organizationType {
+	case "parts":
+		yamlContent, err = generateSeparatePartsYAML(expandedFiles, c.Output, guess)
+	case "dirs":
+		yamlContent, err = generateDirectoryObjectsYAML(expandedFiles, c.Output, guess)
+	default:
+		yamlContent, err = generateSeparateObjectsYAML(expandedFiles, c.Output, guess)
+	}
+	if err != nil {
+		return err
 	}
 
 	// Write the YAML file
 	if err := os.WriteFile(c.Output, []byte(yamlContent), 0644); err != nil {
-		return fmt.Errorf("failed to write config file: %w", err)
+		return exitcode.Wrap(exitcode.Output, fmt.Errorf("failed to write config file: %w", err))
 	}
 
 	fmt.Println()
@@ -348,113 +682,53 @@ func (c *InitCmd) Run() error {
 	return nil
 }
 
-// generateSeparatePartsYAML generates a YAML config with all files as parts in one object
-func generateSeparatePartsYAML(files []string, outputPath string) string {
-	var builder strings.Builder
-
-	// Determine output 3MF filename from config filename
-	baseOutput := strings.TrimSuffix(outputPath, filepath.Ext(outputPath))
-	threemfOutput := baseOutput + ".3mf"
-
-	builder.WriteString("# Generated configuration file\n")
-	builder.WriteString("# All files are organized as separate parts within a single object\n")
-	builder.WriteString("# Documentation: https://github.com/philipparndt/go3mf\n\n")
-
-	builder.WriteString(fmt.Sprintf("output: %s\n\n", threemfOutput))
-
-	builder.WriteString("# Packing distance between objects in mm (default: 10.0)\n")
-	builder.WriteString("# packing_distance: 10.0\n\n")
-
-	builder.WriteString("# Packing algorithm: \"default\" or \"compact\" (default: \"default\")\n")
-	builder.WriteString("# packing_algorithm: default\n\n")
-
-	builder.WriteString("objects:\n")
-	builder.WriteString("  - name: Combined\n")
-	builder.WriteString("    # count: 1  # Number of copies of this object (default: 1)\n")
-	builder.WriteString("    # normalize_position: true  # Place object at ground level (default: true)\n")
-	builder.WriteString("    # config:  # OpenSCAD config applied to all parts\n")
-	builder.WriteString("    #   - config.scad:\n")
-	builder.WriteString("    #       variable_name: value\n")
-	builder.WriteString("    parts:\n")
-
-	for i, file := range files {
-		partName := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
-		builder.WriteString(fmt.Sprintf("      - name: %s\n", partName))
-		builder.WriteString(fmt.Sprintf("        file: %s\n", file))
-
-		// Add all optional fields as comments
-		builder.WriteString("        # filament: 1  # AMS slot (1-4), 0 or omit for auto\n")
-		builder.WriteString("        # rotation_x: 0  # Rotation around X axis in degrees\n")
-		builder.WriteString("        # rotation_y: 0  # Rotation around Y axis in degrees\n")
-		builder.WriteString("        # rotation_z: 0  # Rotation around Z axis in degrees\n")
-		builder.WriteString("        # position_x: 0  # Relative X position offset in mm\n")
-		builder.WriteString("        # position_y: 0  # Relative Y position offset in mm\n")
-		builder.WriteString("        # position_z: 0  # Relative Z position offset in mm\n")
-		builder.WriteString("        # config:  # Part-specific OpenSCAD config (overrides object config)\n")
-		builder.WriteString("        #   - config.scad:\n")
-		builder.WriteString("        #       variable_name: value\n")
-
-		if i < len(files)-1 {
-			builder.WriteString("\n")
+// groupByDirectory groups files by their directory in the order the directories
+// first appear. Objects are named after the directory path, or after the current
+// directory for files in it.
+func groupByDirectory(files []string) []buildplan.ObjectGroup {
+	var groups []buildplan.ObjectGroup
+	index := make(map[string]int)
+	for _, file := range files {
+		dir := filepath.Dir(file)
+		i, ok := index[dir]
+		if !ok {
+			name := filepath.ToSlash(dir)
+			if dir == "." {
+				cwd, _ := os.Getwd()
+				name = filepath.Base(cwd)
+			}
+			i = len(groups)
+			index[dir] = i
+			groups = append(groups, buildplan.ObjectGroup{Name: name})
 		}
+		groups[i].Files = append(groups[i].Files, file)
 	}
-
-	return builder.String()
+	return groups
 }
 
-// generateSeparateObjectsYAML generates a YAML config with each file as a separate object
-func generateSeparateObjectsYAML(files []string, outputPath string) string {
-	var builder strings.Builder
-
-	// Determine output 3MF filename from config filename
-	baseOutput := strings.TrimSuffix(outputPath, filepath.Ext(outputPath))
-	threemfOutput := baseOutput + ".3mf"
-
-	builder.WriteString("# Generated configuration file\n")
-	builder.WriteString("# Each file is organized as a separate object\n")
-	builder.WriteString("# Documentation: https://github.com/philipparndt/go3mf\n\n")
-
-	builder.WriteString(fmt.Sprintf("output: %s\n\n", threemfOutput))
-
-	builder.WriteString("# Packing distance between objects in mm (default: 10.0)\n")
-	builder.WriteString("# packing_distance: 10.0\n\n")
-
-	builder.WriteString("# Packing algorithm: \"default\" or \"compact\" (default: \"default\")\n")
-	builder.WriteString("# packing_algorithm: default\n\n")
-
-	builder.WriteString("objects:\n")
-
-	for i, file := range files {
-		objectName := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
-		builder.WriteString(fmt.Sprintf("  - name: %s\n", objectName))
-		builder.WriteString("    # count: 1  # Number of copies of this object (default: 1)\n")
-		builder.WriteString("    # normalize_position: true  # Place object at ground level (default: true)\n")
-		builder.WriteString("    # config:  # OpenSCAD config applied to all parts\n")
-		builder.WriteString("    #   - config.scad:\n")
-		builder.WriteString("    #       variable_name: value\n")
-		builder.WriteString("    parts:\n")
-		builder.WriteString("      - name: main\n")
-		builder.WriteString(fmt.Sprintf("        file: %s\n", file))
-		builder.WriteString("        # filament: 1  # AMS slot (1-4), 0 or omit for auto\n")
-		builder.WriteString("        # rotation_x: 0  # Rotation around X axis in degrees\n")
-		builder.WriteString("        # rotation_y: 0  # Rotation around Y axis in degrees\n")
-		builder.WriteString("        # rotation_z: 0  # Rotation around Z axis in degrees\n")
-		builder.WriteString("        # position_x: 0  # Relative X position offset in mm\n")
-		builder.WriteString("        # position_y: 0  # Relative Y position offset in mm\n")
-		builder.WriteString("        # position_z: 0  # Relative Z position offset in mm\n")
-		builder.WriteString("        # config:  # Part-specific OpenSCAD config (overrides object config)\n")
-		builder.WriteString("        #   - config.scad:\n")
-		builder.WriteString("        #       variable_name: value\n")
-
-		if i < len(files)-1 {
-			builder.WriteString("\n")
-		}
-	}
+// initFileTypes are the file types init adds from directories
+var initFileTypes = map[string]bool{".scad": true, ".stl": true, ".3mf": true}
 
-	return builder.String()
+// expandDirectory returns the SCAD, STL and 3MF files below a directory in lexical order
+func expandDirectory(dir string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() && path != dir && strings.HasPrefix(entry.Name(), ".") {
+			return filepath.SkipDir
+		}
+		if !entry.IsDir() && initFileTypes[strings.ToLower(filepath.Ext(path))] {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files, err
 }
 
-// expandGlobPatterns expands glob patterns in the file list
+// expandGlobPatterns expands glob patterns in the file list. Directories are
+// expanded to the SCAD, STL and 3MF files below them.
 func expandGlobPatterns(patterns []string) ([]string, error) {
 	var result []string
 	seen := make(map[string]bool)
@@ -475,9 +749,17 @@ func expandGlobPatterns(patterns []string) ([]string, error) {
 		} else {
 			// Add all matches, avoiding duplicates
 			for _, match := range matches {
-				if !seen[match] {
-					result = append(result, match)
-					seen[match] = true
+				files := []string{match}
+				if info, err := os.Stat(match); err == nil && info.IsDir() {
+					if files, err = expandDirectory(match); err != nil {
+						return nil, fmt.Errorf("cannot read directory %s: %w", match, err)
+					}
+				}
+				for _, file := range files {
+					if !seen[file] {
+						result = append(result, file)
+						seen[file] = true
+					}
 				}
 			}
 		}
@@ -494,74 +776,48 @@ func (c *VersionCmd) Run() error {
 	return nil
 }
 
-// Parse parses command line arguments and executes the appropriate command
+// Parse parses command line arguments and executes the appropriate command.
+// The process exits with the exit code of the failure class (see exitcode).
 func Parse() {
-	// Check if we're using the new --object syntax before Kong parses
-	if containsObjectFlag(os.Args) {
-		// Handle this specially
-		if err := parseAndRunWithObjects(); err != nil {
-			ui.PrintError(err.Error())
-			os.Exit(1)
-		}
-		return
-	}
-
-	// Normal Kong parsing for other cases
-	cli := &CLI{}
-	ctx := kong.Parse(cli,
-		kong.Name("go3mf"),
-		kong.Description("3D model file combiner and SCAD renderer"),
-		kong.UsageOnError(),
-	)
-	err := ctx.Run()
-	if err != nil {
-		ui.PrintError(err.Error())
-		os.Exit(1)
-	}
-}
-
-// parseAndRunWithObjects handles the special --object syntax separately from Kong
-func parseAndRunWithObjects() error {
-	// Extract output file and open flag
-	outputFile := "combined.3mf"
-	shouldOpen := false
-	for i, arg := range os.Args {
-		if (arg == "-o" || arg == "--output") && i+1 < len(os.Args) {
-			outputFile = os.Args[i+1]
-		}
-		if arg == "--open" {
-			shouldOpen = true
+	parser := newParser(kong.Exit(func(code int) {
+		// Kong exits with 1 on parse errors, report them as usage errors
+		if code != 0 {
+			code = int(exitcode.Usage)
 		}
-		// Debug flag is handled globally by IsVerbose(), no need to parse here
-	}
-
-	// Parse object groups
-	groups, err := parseObjectGroupsFromRawArgs(os.Args)
-	if err != nil {
-		return fmt.Errorf("failed to parse object groups: %w", err)
-	}
+		os.Exit(code)
+	}))
 
-	if len(groups) == 0 {
-		return fmt.Errorf("no objects defined")
+	// Shell completion requests are answered from the Kong model
+	if len(os.Args) > 1 && os.Args[1] == completeCommand {
+		writeCompletions(os.Stdout, parser.Model, os.Args[2:])
+		return
 	}
 
-	// Create and execute build plan
-	planner := buildplan.NewPlanner()
-	plan, err := planner.CreatePlan(nil, groups, outputFile)
+	// Object groups (--object -n NAME FILE...) are a repeated grammar that Kong
+	// cannot express, so they are split off before Kong parses the rest
+	args, objects, err := splitObjectArgs(parser.Model, os.Args[1:])
 	if err != nil {
-		return fmt.Errorf("failed to create build plan: %w", err)
+		exit(exitcode.Wrap(exitcode.Usage, fmt.Errorf("invalid --object arguments: %w", err)))
 	}
 
-	if err := plan.Execute(); err != nil {
-		return err
+	ctx, err := parser.Parse(args)
+	parser.FatalIfErrorf(err)
+	if err := ctx.Run(objects); err != nil {
+		exit(err)
 	}
+}
 
-	// Open the file in default application if requested
-	if shouldOpen {
-		if err := openFile(plan.OutputFile); err != nil {
-			ui.PrintError("Failed to open file: " + err.Error())
-		}
-	}
+// newParser creates the command line parser of go3mf
+func newParser(options ...kong.Option) *kong.Kong {
+	return kong.Must(&CLI{}, append([]kong.Option{
+		kong.Name("go3mf"),
+		kong.Description("3D model file combiner and SCAD renderer"),
+		kong.UsageOnError(),
+	}, options...)...)
+}
 
-	return nil
+// exit prints the error and terminates the process with its exit code
+func exit(err error) {
+	ui.PrintError(err.Error())
+	os.Exit(int(exitcode.FromError(err)))
 }
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// initFilamentSlots is the number of filament slots init assigns colors to (an AMS)
+const initFilamentSlots = 4
+
+// fileColors maps color names used as file name suffixes to display colors
+var fileColors = map[string]string{
+	"black":       "#000000",
+	"white":       "#FFFFFF",
+	"gray":        "#808080",
+	"grey":        "#808080",
+	"silver":      "#C0C0C0",
+	"red":         "#FF0000",
+	"orange":      "#FF8000",
+	"yellow":      "#FFFF00",
+	"gold":        "#FFD700",
+	"green":       "#00A000",
+	"blue":        "#0000FF",
+	"cyan":        "#00FFFF",
+	"purple":      "#800080",
+	"magenta":     "#FF00FF",
+	"pink":        "#FFC0CB",
+	"brown":       "#8B4513",
+	"beige":       "#F5F5DC",
+	"clear":       "#FFFFFF00",
+	"transparent": "#FFFFFF00",
+}
+
+// filamentGuess assigns filament slots to files by the color at the end of their name
+type filamentGuess struct {
+	colors []string       // Color of every slot, the first entry is slot 1
+	slots  map[string]int // File -> slot (missing = no color in the name)
+}
+
+// fileColor returns the color name a file name ends with, e.g. "red" for
+// logo_red.stl or Logo-Red.stl, or "" if it ends with no known color
+func fileColor(file string) string {
+	name := strings.ToLower(strings.TrimSuffix(filepath.Base(file), filepath.Ext(file)))
+	i := strings.LastIndexAny(name, "_- .")
+	if i < 0 {
+		return ""
+	}
+	if suffix := name[i+1:]; fileColors[suffix] != "" {
+		return suffix
+	}
+	return ""
+}
+
+// guessFilaments assigns a filament slot to every color the file names end with,
+// in the order the colors first appear. Colors beyond the available slots are
+// left unassigned with a warning.
+func guessFilaments(files []string) (*filamentGuess, []string) {
+	guess := &filamentGuess{slots: make(map[string]int)}
+	slotOf := make(map[string]int)
+	var warnings []string
+	for _, file := range files {
+		color := fileColor(file)
+		if color == "" {
+			continue
+		}
+		if color == "grey" {
+			color = "gray"
+		}
+		if color == "transparent" {
+			color = "clear"
+		}
+		slot, ok := slotOf[color]
+		if !ok {
+			if len(guess.colors) == initFilamentSlots {
+				warnings = append(warnings, fmt.Sprintf("%s: no filament slot left for %s", file, color))
+				continue
+			}
+			guess.colors = append(guess.colors, color)
+			slot = len(guess.colors)
+			slotOf[color] = slot
+		}
+		guess.slots[file] = slot
+	}
+	return guess, warnings
+}
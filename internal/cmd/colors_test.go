@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFileColor(t *testing.T) {
+	tests := []struct {
+		file string
+		want string
+	}{
+		{"logo_red.stl", "red"},
+		{"parts/Logo-Blue.STL", "blue"},
+		{"base grey.stl", "grey"},
+		{"red.stl", ""},
+		{"logo_reddish.stl", ""},
+		{"logo.stl", ""},
+	}
+
+	for _, tt := range tests {
+		if got := fileColor(tt.file); got != tt.want {
+			t.Errorf("fileColor(%q) = %q, want %q", tt.file, got, tt.want)
+		}
+	}
+}
+
+func TestGuessFilaments(t *testing.T) {
+	files := []string{"logo_red.stl", "base.stl", "text_white.stl", "logo_Red.stl", "a_black.stl", "b_grey.stl", "c_gray.stl", "d_blue.stl"}
+
+	guess, warnings := guessFilaments(files)
+
+	if want := []string{"red", "white", "black", "gray"}; !reflect.DeepEqual(guess.colors, want) {
+		t.Errorf("colors = %v, want %v", guess.colors, want)
+	}
+	wantSlots := map[string]int{"logo_red.stl": 1, "text_white.stl": 2, "logo_Red.stl": 1, "a_black.stl": 3, "b_grey.stl": 4, "c_gray.stl": 4}
+	if !reflect.DeepEqual(guess.slots, wantSlots) {
+		t.Errorf("slots = %v, want %v", guess.slots, wantSlots)
+	}
+	if want := []string{"d_blue.stl: no filament slot left for blue"}; !reflect.DeepEqual(warnings, want) {
+		t.Errorf("warnings = %v, want %v", warnings, want)
+	}
+
+	yaml, err := generateSeparateObjectsYAML([]string{"logo_red.stl", "base.stl"}, "config.yaml", guess)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"filaments:\n  - color: '#FF0000' # red\n  - color: '#FFFFFF' # white\n",
+		"file: logo_red.stl\n        filament: 1 # red, from the file name\n",
+		"file: base.stl\n",
+	} {
+		if !strings.Contains(yaml, want) {
+			t.Errorf("generated YAML is missing %q:\n%s", want, yaml)
+		}
+	}
+}
@@ -0,0 +1,267 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/alecthomas/kong"
+)
+
+// completeCommand is the hidden command the shell scripts call to get completions:
+//
+//	go3mf __complete WORD... CURRENT
+//
+// It prints one candidate per line, optionally followed by a tab and a description.
+const completeCommand = "__complete"
+
+// Predictors for flags and positional arguments, set with the `predictor` struct tag:
+//
+//	predictor:"files:scad,stl"  files with one of the extensions (and directories)
+//	predictor:"dirs"            directories only
+//
+// Enum values are completed automatically.
+const (
+	predictFiles = "files:"
+	predictDirs  = "dirs"
+)
+
+// objectGroupFlags are the flags accepted inside an --object group (see splitObjectArgs)
+var objectGroupFlags = []struct {
+	names []string
+	help  string
+	value []string
+}{
+	{names: []string{"--name", "-n"}, help: "Set object name (required)"},
+	{names: []string{"--filament", "--color", "-c"}, help: "Set filament slot for the next file", value: []string{"1", "2", "3", "4"}},
+	{names: []string{"--count"}, help: "Set number of copies of the object"},
+}
+
+// completion is a single completion candidate
+type completion struct {
+	Value       string
+	Description string
+}
+
+// writeCompletions prints the completions for words to w
+func writeCompletions(w io.Writer, model *kong.Application, words []string) {
+	for _, c := range complete(model, words) {
+		if c.Description != "" {
+			fmt.Fprintf(w, "%s\t%s\n", c.Value, c.Description)
+		} else {
+			fmt.Fprintln(w, c.Value)
+		}
+	}
+}
+
+// complete returns the candidates for the last word in words, which is the
+// (possibly empty) word being completed. Commands, flags and their values are
+// taken from the Kong model, so new commands and flags are completed automatically.
+func complete(model *kong.Application, words []string) []completion {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	current := words[len(words)-1]
+
+	node := model.Node
+	var valueOf *kong.Value   // Flag whose value is being completed
+	var groupValue []string   // Values of an --object group flag being completed
+	groupFlagPending := false // Previous word was an --object group flag
+	inObjectGroup := false
+	positionals := 0
+
+	for _, word := range words[:len(words)-1] {
+		if valueOf != nil || groupFlagPending {
+			valueOf, groupValue, groupFlagPending = nil, nil, false
+			continue
+		}
+
+		if word == "--object" && acceptsObjectGroups(node) {
+			inObjectGroup = true
+			continue
+		}
+		if inObjectGroup {
+			if values, ok := lookupObjectGroupFlag(word); ok {
+				groupFlagPending, groupValue = !strings.Contains(word, "="), values
+				continue
+			}
+		}
+
+		if strings.HasPrefix(word, "-") && word != "-" {
+			if flag, inline, ok := lookupFlag(flagsByName(node), word); ok && !inline && !flag.IsBool() && !flag.IsCounter() {
+				valueOf = flag.Value
+			}
+			continue
+		}
+
+		if child := findChild(node, word); child != nil {
+			node = child
+			positionals = 0
+			continue
+		}
+		positionals++
+	}
+
+	switch {
+	case groupFlagPending:
+		return filterPrefix(valuesToCompletions(groupValue), current)
+	case valueOf != nil:
+		return predict(valueOf, current)
+	case strings.HasPrefix(current, "-"):
+		return filterPrefix(flagCompletions(node, inObjectGroup), current)
+	}
+
+	if len(node.Positional) > 0 {
+		index := positionals
+		if index >= len(node.Positional) {
+			index = len(node.Positional) - 1
+			if !node.Positional[index].IsCumulative() {
+				return nil
+			}
+		}
+		return predict(node.Positional[index], current)
+	}
+
+	var commands []completion
+	for _, child := range node.Children {
+		if child.Hidden {
+			continue
+		}
+		commands = append(commands, completion{Value: child.Name, Description: child.Help})
+	}
+	return filterPrefix(commands, current)
+}
+
+// acceptsObjectGroups reports whether node is a command that supports --object groups
+func acceptsObjectGroups(node *kong.Node) bool {
+	return node.Type == kong.CommandNode && (node.Name == "combine" || node.Name == "build")
+}
+
+// lookupObjectGroupFlag returns the completion values of an --object group flag
+func lookupObjectGroupFlag(word string) ([]string, bool) {
+	name, _, _ := strings.Cut(word, "=")
+	for _, f := range objectGroupFlags {
+		if contains(f.names, name) {
+			return f.value, true
+		}
+	}
+	return nil, false
+}
+
+// findChild returns the sub command of node named by word (or one of its aliases)
+func findChild(node *kong.Node, word string) *kong.Node {
+	for _, child := range node.Children {
+		if child.Name == word || contains(child.Aliases, word) {
+			return child
+		}
+	}
+	return nil
+}
+
+// flagCompletions lists the visible flags of node, plus the --object group flags
+func flagCompletions(node *kong.Node, inObjectGroup bool) []completion {
+	var flags []completion
+	for _, group := range node.AllFlags(true) {
+		for _, flag := range group {
+			flags = append(flags, completion{Value: "--" + flag.Name, Description: flag.Help})
+			if flag.Short != 0 {
+				flags = append(flags, completion{Value: "-" + string(flag.Short), Description: flag.Help})
+			}
+		}
+	}
+	if inObjectGroup {
+		for _, f := range objectGroupFlags {
+			for _, name := range f.names {
+				flags = append(flags, completion{Value: name, Description: f.help})
+			}
+		}
+	}
+	return flags
+}
+
+// predict completes the value of a flag or positional argument
+func predict(value *kong.Value, current string) []completion {
+	if value.Enum != "" {
+		return filterPrefix(valuesToCompletions(value.EnumSlice()), current)
+	}
+
+	predictor := value.Tag.Get("predictor")
+	switch {
+	case predictor == predictDirs:
+		return completeFiles(current, nil)
+	case strings.HasPrefix(predictor, predictFiles):
+		return completeFiles(current, strings.Split(strings.TrimPrefix(predictor, predictFiles), ","))
+	}
+	return nil
+}
+
+// completeFiles lists the directories and files matching current. Directories end
+// with a slash so the shell can continue completing inside them. Only files with
+// one of the extensions are included; without extensions only directories are listed.
+func completeFiles(current string, extensions []string) []completion {
+	dir, prefix := filepath.Split(current)
+	readDir := dir
+	if readDir == "" {
+		readDir = "."
+	}
+
+	entries, err := os.ReadDir(readDir)
+	if err != nil {
+		return nil
+	}
+
+	var files []completion
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, prefix) || (strings.HasPrefix(name, ".") && !strings.HasPrefix(prefix, ".")) {
+			continue
+		}
+		if isDir(readDir, entry) {
+			files = append(files, completion{Value: dir + name + "/"})
+			continue
+		}
+		if hasExtension(name, extensions) {
+			files = append(files, completion{Value: dir + name})
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Value < files[j].Value })
+	return files
+}
+
+// isDir reports whether entry is a directory, following symbolic links
+func isDir(dir string, entry os.DirEntry) bool {
+	if entry.Type()&os.ModeSymlink != 0 {
+		info, err := os.Stat(filepath.Join(dir, entry.Name()))
+		return err == nil && info.IsDir()
+	}
+	return entry.IsDir()
+}
+
+// hasExtension reports whether name ends with one of the extensions (case-insensitive)
+func hasExtension(name string, extensions []string) bool {
+	ext := strings.TrimPrefix(strings.ToLower(filepath.Ext(name)), ".")
+	return ext != "" && contains(extensions, ext)
+}
+
+// valuesToCompletions converts plain values to completions
+func valuesToCompletions(values []string) []completion {
+	completions := make([]completion, len(values))
+	for i, v := range values {
+		completions[i] = completion{Value: v}
+	}
+	return completions
+}
+
+// filterPrefix returns the completions starting with prefix
+func filterPrefix(completions []completion, prefix string) []completion {
+	var result []completion
+	for _, c := range completions {
+		if strings.HasPrefix(c.Value, prefix) {
+			result = append(result, c)
+		}
+	}
+	return result
+}
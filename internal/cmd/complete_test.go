@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/alecthomas/kong"
+)
+
+func TestComplete(t *testing.T) {
+	model := kong.Must(&CLI{}).Model
+
+	dir := t.TempDir()
+	for _, name := range []string{"part.scad", "part.stl", "plate.3mf", "notes.txt", "parts/inner.scad"} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Chdir(dir)
+
+	tests := []struct {
+		name  string
+		words []string
+		want  []string
+	}{
+		{name: "commands", words: []string{"in"}, want: []string{"init", "inspect"}},
+		{name: "flags", words: []string{"combine", "--o"}, want: []string{"--output", "--object", "--open", "--output-template", "--otlp-endpoint"}},
+		{name: "object group flags", words: []string{"build", "--object", "--na"}, want: []string{"--name"}},
+		{name: "filament slot", words: []string{"combine", "--object", "-n", "A", "-c", ""}, want: []string{"1", "2", "3", "4"}},
+		{name: "object name", words: []string{"combine", "--object", "-n", ""}, want: nil},
+		{name: "enum", words: []string{"completion", "z"}, want: []string{"zsh"}},
+		{name: "input files", words: []string{"combine", "p"}, want: []string{"part.scad", "part.stl", "parts/", "plate.3mf"}},
+		{name: "output file", words: []string{"combine", "-o", "p"}, want: []string{"parts/", "plate.3mf"}},
+		{name: "nested directory", words: []string{"init", "parts/"}, want: []string{"parts/inner.scad"}},
+		{name: "directories", words: []string{"extract", "-o", ""}, want: []string{"parts/"}},
+		{name: "single positional", words: []string{"inspect", "plate.3mf", ""}, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, c := range complete(model, tt.words) {
+				got = append(got, c.Value)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("complete(%q) = %q, want %q", tt.words, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,305 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/alecthomas/kong"
+	"github.com/philipparndt/go3mf/internal/buildplan"
+	"github.com/philipparndt/go3mf/internal/config"
+	"github.com/philipparndt/go3mf/internal/exitcode"
+	"github.com/philipparndt/go3mf/internal/renderer"
+	"github.com/philipparndt/go3mf/internal/ui"
+)
+
+// daemonSocketEnv overrides the socket the daemon listens on
+const daemonSocketEnv = "GO3MF_DAEMON_SOCKET"
+
+type DaemonCmd struct {
+	Socket   string `help:"Unix socket to listen on (default: $GO3MF_DAEMON_SOCKET, or daemon.sock in go3mf of $XDG_RUNTIME_DIR or go3mf/daemon of the user cache directory)" placeholder:"PATH"`
+	CacheDir string `help:"Render cache of builds without a cache of their own (default: go3mf/renders in the user cache directory)" placeholder:"DIR" predictor:"dirs"`
+}
+
+// daemonRequest is a build sent to the daemon
+type daemonRequest struct {
+	Dir     string   `json:"dir"`     // Working directory of the build
+	Args    []string `json:"args"`    // Command line of the build without --daemon
+	Verbose bool     `json:"verbose"` // Verbose output, as detected by the client
+}
+
+// daemonMessage is a line of the response of the daemon: output of the build
+// or, as the last message, its result
+type daemonMessage struct {
+	Output string `json:"output,omitempty"`
+	Done   bool   `json:"done,omitempty"`
+	Code   int    `json:"code,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// daemonSocket returns the socket of the daemon. Anyone who can connect to it
+// runs builds as the user, so the default socket is in a directory of the user
+// (see daemonDir) instead of the shared temporary directory.
+func daemonSocket(socket string) (string, error) {
+	if socket != "" {
+		return socket, nil
+	}
+	if socket := os.Getenv(daemonSocketEnv); socket != "" {
+		return socket, nil
+	}
+	dir, err := daemonDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "daemon.sock"), nil
+}
+
+// daemonDir returns the directory of the default socket: go3mf in the runtime
+// directory of the user ($XDG_RUNTIME_DIR), or go3mf/daemon in the user cache
+// directory where there is none
+func daemonDir() (string, error) {
+	if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); runtimeDir != "" {
+		return filepath.Join(runtimeDir, "go3mf"), nil
+	}
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheDir, "go3mf", "daemon"), nil
+}
+
+// Run serves builds until the daemon is interrupted. Builds share global state,
+// so they run one after another; the render cache, the dependencies of the
+// renders with the hashes of their files, the parsed configs and the OpenSCAD
+// version stay warm between them.
+func (c *DaemonCmd) Run() error {
+	cacheDir := c.CacheDir
+	if cacheDir == "" {
+		userCache, err := os.UserCacheDir()
+		if err != nil {
+			return exitcode.Wrap(exitcode.Usage, fmt.Errorf("cannot determine the render cache, use --cache-dir: %w", err))
+		}
+		cacheDir = filepath.Join(userCache, "go3mf", "renders")
+	}
+	cacheDir, err := filepath.Abs(cacheDir)
+	if err != nil {
+		return exitcode.Wrap(exitcode.Usage, err)
+	}
+
+	socket, err := daemonSocket(c.Socket)
+	if err != nil {
+		return exitcode.Wrap(exitcode.Usage, fmt.Errorf("cannot determine the socket, use --socket: %w", err))
+	}
+	if c.Socket == "" && os.Getenv(daemonSocketEnv) == "" {
+		// Only the user may enter the directory of the default socket
+		dir := filepath.Dir(socket)
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return exitcode.Wrap(exitcode.Output, fmt.Errorf("cannot create %s: %w", dir, err))
+		}
+		if err := os.Chmod(dir, 0o700); err != nil {
+			return exitcode.Wrap(exitcode.Output, fmt.Errorf("cannot make %s private: %w", dir, err))
+		}
+	}
+	if conn, err := net.Dial("unix", socket); err == nil {
+		conn.Close()
+		return exitcode.Wrap(exitcode.Usage, fmt.Errorf("a daemon is already listening on %s", socket))
+	}
+	// A socket nobody listens on is left over from a daemon that did not stop cleanly
+	_ = os.Remove(socket)
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		return exitcode.Wrap(exitcode.Output, fmt.Errorf("cannot listen on %s: %w", socket, err))
+	}
+	// A socket given with --socket may be in a shared directory
+	if err := os.Chmod(socket, 0o600); err != nil {
+		listener.Close()
+		return exitcode.Wrap(exitcode.Output, fmt.Errorf("cannot make %s private: %w", socket, err))
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		listener.Close()
+	}()
+
+	renderer.KeepHashes()
+	buildplan.KeepConfigs()
+	buildplan.SetDefaultCacheDir(cacheDir)
+	ui.PrintSuccess(fmt.Sprintf("go3mf daemon listening on %s", socket))
+	ui.PrintItem(fmt.Sprintf("Render cache: %s", cacheDir))
+
+	var builds sync.Mutex
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				ui.PrintInfo("go3mf daemon stopped")
+				return nil
+			}
+			return exitcode.Wrap(exitcode.General, fmt.Errorf("daemon: %w", err))
+		}
+		go func() {
+			defer conn.Close()
+			builds.Lock()
+			defer builds.Unlock()
+			serveBuild(conn)
+		}()
+	}
+}
+
+// serveBuild runs the build of a request and streams its output back
+func serveBuild(conn net.Conn) {
+	encoder := json.NewEncoder(conn)
+	var request daemonRequest
+	if err := json.NewDecoder(conn).Decode(&request); err != nil {
+		_ = encoder.Encode(daemonMessage{Done: true, Code: int(exitcode.Usage), Error: fmt.Sprintf("invalid request: %v", err)})
+		return
+	}
+
+	start := time.Now()
+	out := &messageWriter{encoder: encoder}
+	err := runDaemonBuild(request, out)
+	result := daemonMessage{Done: true, Code: int(exitcode.FromError(err))}
+	if err != nil {
+		result.Error = err.Error()
+		ui.PrintError(fmt.Sprintf("%s: %v", request.Dir, err))
+	} else {
+		ui.PrintSuccess(fmt.Sprintf("Built in %s (%s)", request.Dir, time.Since(start).Round(time.Millisecond)))
+	}
+	_ = encoder.Encode(result)
+}
+
+// daemonExit is raised by the parser of a daemon build instead of exiting the daemon
+type daemonExit struct {
+	code int
+}
+
+// runDaemonBuild runs the command line of a request in its working directory
+// with the UI output written to out
+func runDaemonBuild(request daemonRequest, out io.Writer) (err error) {
+	if len(request.Args) == 0 || (request.Args[0] != "combine" && request.Args[0] != "build") {
+		return exitcode.Wrap(exitcode.Usage, fmt.Errorf("the daemon only runs combine and build commands"))
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	if err := os.Chdir(request.Dir); err != nil {
+		return exitcode.Wrap(exitcode.Input, fmt.Errorf("cannot change to %s: %w", request.Dir, err))
+	}
+	defer os.Chdir(cwd)
+
+	previous := ui.Output()
+	ui.SetOutput(out)
+	defer ui.SetOutput(previous)
+	ui.SetVerbose(request.Verbose)
+	defer ui.ResetVerbose()
+	ui.ResetWarnings()
+	buildplan.Reset()
+
+	defer func() {
+		if r := recover(); r != nil {
+			exit, ok := r.(daemonExit)
+			if !ok {
+				panic(r)
+			}
+			if exit.code != 0 {
+				err = exitcode.Wrap(exitcode.Usage, fmt.Errorf("invalid command line"))
+			}
+		}
+	}()
+	parser := newParser(kong.Writers(out, out), kong.Exit(func(code int) {
+		panic(daemonExit{code: code})
+	}))
+
+	args, objects, err := splitObjectArgs(parser.Model, request.Args)
+	if err != nil {
+		return exitcode.Wrap(exitcode.Usage, fmt.Errorf("invalid --object arguments: %w", err))
+	}
+	ctx, err := parser.Parse(args)
+	if err != nil {
+		return exitcode.Wrap(exitcode.Usage, err)
+	}
+	return ctx.Run(objects)
+}
+
+// messageWriter sends everything written to it as output messages
+type messageWriter struct {
+	encoder *json.Encoder
+}
+
+func (w *messageWriter) Write(p []byte) (int, error) {
+	if err := w.encoder.Encode(daemonMessage{Output: string(p)}); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// runOnDaemon sends the build to a running daemon and shows its output
+func (c *CombineCmd) runOnDaemon() error {
+	if slices.Contains(c.Files, config.StdinPath) || c.Output == buildplan.StdoutPath || c.ArrangementJSON == buildplan.StdoutPath {
+		return exitcode.Wrap(exitcode.Usage, fmt.Errorf("--daemon cannot read from stdin or write to stdout"))
+	}
+	socket, err := daemonSocket("")
+	if err != nil {
+		return exitcode.Wrap(exitcode.Preconditions, fmt.Errorf("cannot determine the socket of the daemon, set %s: %w", daemonSocketEnv, err))
+	}
+	conn, err := net.Dial("unix", socket)
+	if err != nil {
+		return exitcode.Wrap(exitcode.Preconditions, fmt.Errorf("no go3mf daemon is listening on %s (start one with 'go3mf daemon'): %w", socket, err))
+	}
+	defer conn.Close()
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	request := daemonRequest{Dir: cwd, Args: daemonArgs(os.Args[1:]), Verbose: ui.IsVerbose()}
+	if err := json.NewEncoder(conn).Encode(request); err != nil {
+		return exitcode.Wrap(exitcode.General, fmt.Errorf("cannot send the build to the daemon: %w", err))
+	}
+	return readDaemonResponse(conn, ui.Output())
+}
+
+// daemonArgs returns the command line of a build on the daemon
+func daemonArgs(args []string) []string {
+	var result []string
+	for _, arg := range args {
+		if arg != "--daemon" && !strings.HasPrefix(arg, "--daemon=") {
+			result = append(result, arg)
+		}
+	}
+	return result
+}
+
+// readDaemonResponse writes the output of a daemon build to out and returns an
+// error carrying the exit code of a failed build
+func readDaemonResponse(r io.Reader, out io.Writer) error {
+	decoder := json.NewDecoder(r)
+	for {
+		var message daemonMessage
+		if err := decoder.Decode(&message); err != nil {
+			return exitcode.Wrap(exitcode.General, fmt.Errorf("lost the connection to the daemon: %w", err))
+		}
+		if !message.Done {
+			io.WriteString(out, message.Output)
+			continue
+		}
+		if message.Code != int(exitcode.OK) {
+			return exitcode.Wrap(exitcode.Code(message.Code), errors.New(message.Error))
+		}
+		return nil
+	}
+}
@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/philipparndt/go3mf/internal/exitcode"
+	"github.com/philipparndt/go3mf/internal/ui"
+)
+
+func TestDaemonArgs(t *testing.T) {
+	got := daemonArgs([]string{"build", "--daemon", "config.yaml", "--daemon=true", "-j", "2"})
+
+	want := []string{"build", "config.yaml", "-j", "2"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("daemonArgs() = %v, want %v", got, want)
+	}
+}
+
+func TestDaemonSocket(t *testing.T) {
+	runtimeDir := t.TempDir()
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		t.Skip("no user cache directory")
+	}
+
+	tests := []struct {
+		name       string
+		socket     string
+		env        string
+		runtimeDir string
+		want       string
+	}{
+		{name: "--socket", socket: "a.sock", env: "b.sock", want: "a.sock"},
+		{name: "environment", env: "b.sock", runtimeDir: runtimeDir, want: "b.sock"},
+		{name: "runtime directory", runtimeDir: runtimeDir, want: filepath.Join(runtimeDir, "go3mf", "daemon.sock")},
+		{name: "user cache directory", want: filepath.Join(cacheDir, "go3mf", "daemon", "daemon.sock")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(daemonSocketEnv, tt.env)
+			t.Setenv("XDG_RUNTIME_DIR", tt.runtimeDir)
+			got, err := daemonSocket(tt.socket)
+			if err != nil {
+				t.Fatalf("daemonSocket() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("daemonSocket() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunDaemonBuildPerRequest(t *testing.T) {
+	t.Setenv("CI", "")
+	dir := t.TempDir()
+	// An optional part whose file is missing is skipped with a warning
+	config := "output: out.3mf\nobjects:\n  - name: box\n    parts:\n      - name: base\n        file: base.stl\n      - name: lid\n        file: lid.stl\n        optional: true\n"
+	stl := "solid base\nfacet normal 0 0 0\nouter loop\nvertex 0 0 0\nvertex 10 0 0\nvertex 0 10 0\nendloop\nendfacet\nendsolid base\n"
+	for name, content := range map[string]string{"config.yaml": config, "base.stl": stl} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	defer ui.ResetWarnings()
+
+	for _, verbose := range []bool{true, false} {
+		var out bytes.Buffer
+		if err := runDaemonBuild(daemonRequest{Dir: dir, Args: []string{"build", "config.yaml"}, Verbose: verbose}, &out); err != nil {
+			t.Fatalf("runDaemonBuild() error = %v", err)
+		}
+		if got := strings.Contains(out.String(), "Build Plan Execution"); got != verbose {
+			t.Errorf("verbose %v: output has the verbose header: %v", verbose, got)
+		}
+		if warnings := ui.Warnings(); len(warnings) != 1 {
+			t.Errorf("verbose %v: warnings = %q, want the one of this build", verbose, warnings)
+		}
+	}
+	if ui.IsVerbose() {
+		t.Error("the verbosity of the request stayed set after the build")
+	}
+}
+
+func TestReadDaemonResponse(t *testing.T) {
+	var response bytes.Buffer
+	encoder := json.NewEncoder(&response)
+	encoder.Encode(daemonMessage{Output: "Rendering...\n"})
+	encoder.Encode(daemonMessage{Done: true, Code: int(exitcode.Render), Error: "render failed"})
+
+	var out bytes.Buffer
+	err := readDaemonResponse(&response, &out)
+	if out.String() != "Rendering...\n" {
+		t.Errorf("output = %q, want the output of the build", out.String())
+	}
+	if err == nil || err.Error() != "render failed" || exitcode.FromError(err) != exitcode.Render {
+		t.Errorf("readDaemonResponse() error = %v, want the render error of the build", err)
+	}
+
+	if err := readDaemonResponse(strings.NewReader(""), &out); exitcode.FromError(err) != exitcode.General {
+		t.Errorf("readDaemonResponse() on a closed connection = %v, want a general error", err)
+	}
+}
+
+func TestServeBuildRejectsOtherCommands(t *testing.T) {
+	client, server := net.Pipe()
+	go func() {
+		defer server.Close()
+		serveBuild(server)
+	}()
+
+	request := daemonRequest{Dir: t.TempDir(), Args: []string{"self-update"}}
+	go json.NewEncoder(client).Encode(request)
+
+	var out bytes.Buffer
+	err := readDaemonResponse(client, &out)
+	if err == nil || !strings.Contains(err.Error(), "only runs combine and build") || exitcode.FromError(err) != exitcode.Usage {
+		t.Errorf("serveBuild() error = %v, want a usage error", err)
+	}
+}
@@ -0,0 +1,231 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/alecthomas/kong"
+	"github.com/philipparndt/go3mf/internal/exitcode"
+	"github.com/philipparndt/go3mf/internal/ui"
+	"github.com/philipparndt/go3mf/version"
+)
+
+// DocsCmd generates man pages and a markdown CLI reference from the Kong model.
+// It is hidden because it is only meant for packaging (Homebrew, deb, ...).
+type DocsCmd struct {
+	Format string `help:"Documentation format: man or markdown" enum:"man,markdown" default:"man"`
+	Output string `help:"Output directory" short:"o" default:"docs" predictor:"dirs"`
+}
+
+// ansiPattern matches terminal escape sequences of the styled help texts
+var ansiPattern = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+func (c *DocsCmd) Run(ctx *kong.Context) error {
+	if err := os.MkdirAll(c.Output, 0755); err != nil {
+		return exitcode.Wrap(exitcode.Output, fmt.Errorf("failed to create output directory: %w", err))
+	}
+
+	files := map[string]string{}
+	switch c.Format {
+	case "man":
+		files["go3mf.1"] = manPage(ctx.Model.Node)
+		for _, cmd := range visibleCommands(ctx.Model.Node) {
+			files["go3mf-"+cmd.Name+".1"] = manPage(cmd)
+		}
+	case "markdown":
+		files["go3mf.md"] = markdownReference(ctx.Model)
+	}
+
+	for name, content := range files {
+		path := filepath.Join(c.Output, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			return exitcode.Wrap(exitcode.Output, fmt.Errorf("failed to write %s: %w", path, err))
+		}
+		if ui.IsVerbose() {
+			ui.PrintItem(path)
+		}
+	}
+
+	ui.PrintSuccess(fmt.Sprintf("Generated %d %s file(s) in %s", len(files), c.Format, c.Output))
+	return nil
+}
+
+// visibleCommands returns the commands below node that are not hidden
+func visibleCommands(node *kong.Node) []*kong.Node {
+	var commands []*kong.Node
+	for _, child := range node.Children {
+		if child.Type == kong.CommandNode && !child.Hidden {
+			commands = append(commands, child)
+		}
+	}
+	return commands
+}
+
+// visibleFlags returns the flags of node (including inherited ones) that are not hidden
+func visibleFlags(node *kong.Node) []*kong.Flag {
+	var flags []*kong.Flag
+	for _, group := range node.AllFlags(true) {
+		flags = append(flags, group...)
+	}
+	return flags
+}
+
+// plainText removes terminal styling and trailing whitespace from a help text
+func plainText(s string) string {
+	lines := strings.Split(ansiPattern.ReplaceAllString(s, ""), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " ")
+	}
+	return strings.Trim(strings.Join(lines, "\n"), "\n")
+}
+
+// commandSynopsis formats the usage of a command without the program name,
+// e.g. "combine [<files> ...] [flags]"
+func commandSynopsis(node *kong.Node) string {
+	parts := []string{}
+	if node.Type == kong.CommandNode {
+		parts = append(parts, node.Name)
+	}
+	for _, arg := range node.Positional {
+		parts = append(parts, arg.Summary())
+	}
+	if len(visibleCommands(node)) > 0 {
+		parts = append(parts, "<command>")
+	}
+	if len(visibleFlags(node)) > 0 {
+		parts = append(parts, "[flags]")
+	}
+	return strings.Join(parts, " ")
+}
+
+// flagSynopsis formats a flag as "-o, --output=STRING"
+func flagSynopsis(flag *kong.Flag) string {
+	s := "--" + flag.Name
+	if flag.Short != 0 {
+		s = fmt.Sprintf("-%c, %s", flag.Short, s)
+	}
+	if !flag.IsBool() && !flag.IsCounter() {
+		s += "=" + flag.FormatPlaceHolder()
+	}
+	return s
+}
+
+// manPage renders the man page of the application (root node) or a command
+func manPage(node *kong.Node) string {
+	name := "go3mf"
+	if node.Type == kong.CommandNode {
+		name += "-" + node.Name
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, ".TH %s 1 \"\" \"go3mf %s\" \"go3mf Manual\"\n", strings.ToUpper(roffEscape(name)), roffEscape(version.Version))
+
+	b.WriteString(".SH NAME\n")
+	fmt.Fprintf(&b, "%s \\- %s\n", roffEscape(name), roffEscape(node.Help))
+
+	b.WriteString(".SH SYNOPSIS\n")
+	fmt.Fprintf(&b, ".B %s\n", roffEscape("go3mf "+commandSynopsis(node)))
+
+	if detail := plainText(node.Detail); detail != "" {
+		b.WriteString(".SH DESCRIPTION\n.nf\n")
+		b.WriteString(roffEscapeLines(detail))
+		b.WriteString("\n.fi\n")
+	}
+
+	if commands := visibleCommands(node); len(commands) > 0 {
+		b.WriteString(".SH COMMANDS\n")
+		for _, cmd := range commands {
+			fmt.Fprintf(&b, ".TP\n\\fB%s\\fR\n%s\n", roffEscape(commandSynopsis(cmd)), roffEscape(cmd.Help))
+		}
+	}
+
+	if len(node.Positional) > 0 {
+		b.WriteString(".SH ARGUMENTS\n")
+		for _, arg := range node.Positional {
+			fmt.Fprintf(&b, ".TP\n\\fB%s\\fR\n%s\n", roffEscape(arg.Summary()), roffEscape(arg.Help))
+		}
+	}
+
+	if flags := visibleFlags(node); len(flags) > 0 {
+		b.WriteString(".SH OPTIONS\n")
+		for _, flag := range flags {
+			fmt.Fprintf(&b, ".TP\n\\fB%s\\fR\n%s\n", roffEscape(flagSynopsis(flag)), roffEscape(flag.Help))
+		}
+	}
+
+	b.WriteString(".SH SEE ALSO\n")
+	if node.Type == kong.CommandNode {
+		b.WriteString("go3mf(1)\n")
+	} else {
+		var refs []string
+		for _, cmd := range visibleCommands(node) {
+			refs = append(refs, fmt.Sprintf("go3mf\\-%s(1)", roffEscape(cmd.Name)))
+		}
+		b.WriteString(strings.Join(refs, ", ") + "\n")
+	}
+
+	return b.String()
+}
+
+// roffEscape escapes backslashes and dashes for roff
+func roffEscape(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\e`)
+	return strings.ReplaceAll(s, "-", `\-`)
+}
+
+// roffEscapeLines escapes a multi-line text so that no line is read as a roff request
+func roffEscapeLines(s string) string {
+	lines := strings.Split(roffEscape(s), "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, ".") || strings.HasPrefix(line, "'") {
+			lines[i] = `\&` + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// markdownReference renders a markdown reference of all commands
+func markdownReference(app *kong.Application) string {
+	var b strings.Builder
+	b.WriteString("# go3mf CLI reference\n\n")
+	fmt.Fprintf(&b, "%s\n\n", app.Help)
+	b.WriteString("<!-- Generated by `go3mf docs --format markdown`, do not edit. -->\n\n")
+
+	b.WriteString("| Command | Description |\n|---|---|\n")
+	for _, cmd := range visibleCommands(app.Node) {
+		fmt.Fprintf(&b, "| [`%s`](#go3mf-%s) | %s |\n", cmd.Name, cmd.Name, markdownCell(cmd.Help))
+	}
+
+	for _, cmd := range visibleCommands(app.Node) {
+		fmt.Fprintf(&b, "\n## go3mf %s\n\n%s\n\n", cmd.Name, cmd.Help)
+		fmt.Fprintf(&b, "```\ngo3mf %s\n```\n", commandSynopsis(cmd))
+
+		if detail := plainText(cmd.Detail); detail != "" {
+			fmt.Fprintf(&b, "\n```\n%s\n```\n", detail)
+		}
+
+		if len(cmd.Positional) > 0 {
+			b.WriteString("\n**Arguments:**\n\n| Argument | Description |\n|---|---|\n")
+			for _, arg := range cmd.Positional {
+				fmt.Fprintf(&b, "| `%s` | %s |\n", arg.Summary(), markdownCell(arg.Help))
+			}
+		}
+
+		if flags := visibleFlags(cmd); len(flags) > 0 {
+			b.WriteString("\n**Flags:**\n\n| Flag | Description |\n|---|---|\n")
+			for _, flag := range flags {
+				fmt.Fprintf(&b, "| `%s` | %s |\n", flagSynopsis(flag), markdownCell(flag.Help))
+			}
+		}
+	}
+
+	return b.String()
+}
+
+// markdownCell escapes text for use in a markdown table cell
+func markdownCell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
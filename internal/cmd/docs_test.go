@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/kong"
+)
+
+// runDocs runs the docs command for the CLI model and returns the generated files
+func runDocs(t *testing.T, format string) map[string]string {
+	t.Helper()
+	parser := kong.Must(&CLI{})
+	ctx, err := parser.Parse([]string{"docs"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Join(t.TempDir(), "docs")
+	if err := (&DocsCmd{Format: format, Output: dir}).Run(ctx); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := make(map[string]string)
+	for _, entry := range entries {
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[entry.Name()] = string(data)
+	}
+	return files
+}
+
+func TestDocsMan(t *testing.T) {
+	files := runDocs(t, "man")
+
+	want := []string{"go3mf.1"}
+	for _, cmd := range visibleCommands(kong.Must(&CLI{}).Model.Node) {
+		want = append(want, "go3mf-"+cmd.Name+".1")
+	}
+	var got []string
+	for name := range files {
+		got = append(got, name)
+	}
+	sort.Strings(want)
+	sort.Strings(got)
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("man pages = %v, want %v", got, want)
+	}
+	if _, ok := files["go3mf-docs.1"]; ok {
+		t.Error("the hidden docs command has a man page")
+	}
+
+	build := files["go3mf-build.1"]
+	for _, want := range []string{
+		".TH GO3MF\\-BUILD 1",
+		".SH NAME\ngo3mf\\-build \\- ",
+		".SH SYNOPSIS\n.B go3mf build ",
+		".SH OPTIONS\n",
+		"\\fB\\-o, \\-\\-output=",
+		".SH SEE ALSO\ngo3mf(1)\n",
+	} {
+		if !strings.Contains(build, want) {
+			t.Errorf("go3mf-build.1 does not contain %q:\n%s", want, build)
+		}
+	}
+	if strings.Contains(build, "\x1b[") {
+		t.Error("go3mf-build.1 contains terminal escape sequences")
+	}
+	if root := files["go3mf.1"]; !strings.Contains(root, ".SH COMMANDS\n") || !strings.Contains(root, "go3mf\\-build(1)") {
+		t.Errorf("go3mf.1 does not list the commands:\n%s", root)
+	}
+}
+
+func TestDocsMarkdown(t *testing.T) {
+	files := runDocs(t, "markdown")
+	if len(files) != 1 {
+		t.Fatalf("%d files, want go3mf.md only", len(files))
+	}
+	reference := files["go3mf.md"]
+	for _, want := range []string{
+		"# go3mf CLI reference\n",
+		"| [`build`](#go3mf-build) | ",
+		"\n## go3mf build\n",
+		"```\ngo3mf build ",
+		"**Flags:**",
+		"| `-o, --output=",
+	} {
+		if !strings.Contains(reference, want) {
+			t.Errorf("go3mf.md does not contain %q", want)
+		}
+	}
+	if strings.Contains(reference, "## go3mf docs") || strings.Contains(reference, "(#go3mf-docs)") {
+		t.Error("go3mf.md documents the hidden docs command")
+	}
+}
+
+func TestRoffEscapeLines(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"dashes", "--output -o", `\-\-output \-o`},
+		{"backslash", `C:\parts`, `C:\eparts`},
+		{"request lines", ".SH not a section\n'quoted\ntext", "\\&.SH not a section\n\\&'quoted\ntext"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := roffEscapeLines(tt.text); got != tt.want {
+				t.Errorf("roffEscapeLines(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlainText(t *testing.T) {
+	got := plainText("\n\x1b[1mBold\x1b[0m text   \n  indented  \n\n")
+	if want := "Bold text\n  indented"; got != want {
+		t.Errorf("plainText() = %q, want %q", got, want)
+	}
+}
+
+func TestMarkdownCell(t *testing.T) {
+	if got := markdownCell("man|markdown"); got != `man\|markdown` {
+		t.Errorf("markdownCell() = %q", got)
+	}
+}
@@ -48,9 +48,11 @@ func renderCombineHelp() string {
 	b.WriteString("\n\n")
 
 	// Simple mode with names
-	b.WriteString(sectionStyle.Render("Simple mode - specify names and filament slots"))
+	b.WriteString(sectionStyle.Render("Simple mode - specify names, filament slots and copies"))
 	b.WriteString("\n")
 	b.WriteString("  " + commandStyle.Render("go3mf combine file1.scad:part1:1 file2.scad:part2:2 -o output.3mf"))
+	b.WriteString("\n")
+	b.WriteString("  " + commandStyle.Render("go3mf combine clip.scad:Clip:1:8 -o batch.3mf") + "  " + commentStyle.Render("# 8 copies"))
 	b.WriteString("\n\n")
 
 	// Object grouping mode
@@ -75,6 +77,7 @@ func renderCombineHelp() string {
 		{"--object", "Start new object group"},
 		{"-n \"Name\"", "Set object name (required)"},
 		{"-c N", "Set filament slot 1-4 for next file (optional)"},
+		{"--count N", "Build N copies of the object (optional)"},
 		{"Files", "List of files to include in this object (.stl, .3mf, .scad)"},
 	}
 
@@ -98,6 +101,8 @@ func renderCombineHelp() string {
 	b.WriteString(sectionStyle.Render("YAML config mode"))
 	b.WriteString("\n")
 	b.WriteString("  " + commandStyle.Render("go3mf combine config.yaml"))
+	b.WriteString("\n")
+	b.WriteString("  " + commandStyle.Render("go3mf combine configs/*.yaml --jobs 4") + "  " + commentStyle.Render("# Build several configs"))
 	b.WriteString("\n\n")
 
 	// Add YAML example with syntax highlighting
@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/philipparndt/go3mf/internal/buildplan"
+	"github.com/philipparndt/go3mf/internal/models"
+	"gopkg.in/yaml.v3"
+)
+
+func TestGroupByDirectory(t *testing.T) {
+	files := []string{"case/top.scad", "inserts/a.stl", "case/bottom.scad", "inserts/small/b.stl"}
+
+	got := groupByDirectory(files)
+
+	want := []buildplan.ObjectGroup{
+		{Name: "case", Files: []string{"case/top.scad", "case/bottom.scad"}},
+		{Name: "inserts", Files: []string{"inserts/a.stl"}},
+		{Name: "inserts/small", Files: []string{"inserts/small/b.stl"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("groupByDirectory() = %+v, want %+v", got, want)
+	}
+}
+
+func TestExpandGlobPatternsDirectories(t *testing.T) {
+	dir := t.TempDir()
+	for _, file := range []string{"case/top.scad", "case/notes.txt", "case/.cache/old.stl", "inserts/a.STL", "inserts/b.3mf"} {
+		path := filepath.Join(dir, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := expandGlobPatterns([]string{filepath.Join(dir, "case"), filepath.Join(dir, "inserts"), filepath.Join(dir, "case", "top.scad")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{filepath.Join(dir, "case", "top.scad"), filepath.Join(dir, "inserts", "a.STL"), filepath.Join(dir, "inserts", "b.3mf")}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expandGlobPatterns() = %v, want %v", got, want)
+	}
+}
+
+func TestGenerateDirectoryObjectsYAML(t *testing.T) {
+	generated, err := generateDirectoryObjectsYAML([]string{"case/top.scad", "case/bottom.scad", "inserts/a.stl"}, "desk.yaml", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		"output: desk.3mf\n",
+		"  - name: case\n",
+		"      - name: top\n        file: case/top.scad\n",
+		"      - name: bottom\n        file: case/bottom.scad\n",
+		"  - name: inserts\n",
+		"      - name: a\n        file: inserts/a.stl\n",
+	} {
+		if !strings.Contains(generated, want) {
+			t.Errorf("generated YAML is missing %q:\n%s", want, generated)
+		}
+	}
+}
+
+func TestGenerateYAMLQuotesNames(t *testing.T) {
+	files := []string{"a: b #1.stl", "true.stl", "- 'quoted\".stl"}
+	generators := map[string]func([]string, string, *filamentGuess) (string, error){
+		"parts":   generateSeparatePartsYAML,
+		"objects": generateSeparateObjectsYAML,
+		"dirs":    generateDirectoryObjectsYAML,
+	}
+
+	for name, generate := range generators {
+		t.Run(name, func(t *testing.T) {
+			generated, err := generate(files, "out: put.yaml", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var config models.YamlConfig
+			if err := yaml.Unmarshal([]byte(generated), &config); err != nil {
+				t.Fatalf("generated YAML does not parse: %v\n%s", err, generated)
+			}
+			if config.Output != "out: put.3mf" {
+				t.Errorf("output = %q, want %q", config.Output, "out: put.3mf")
+			}
+			var got []string
+			for _, obj := range config.Objects {
+				for _, part := range obj.Parts {
+					got = append(got, part.File)
+				}
+			}
+			if !reflect.DeepEqual(got, files) {
+				t.Errorf("files = %q, want %q", got, files)
+			}
+		})
+	}
+}
+
+func TestGenerateYAMLListsOptions(t *testing.T) {
+	generated, err := generateSeparatePartsYAML([]string{"a.stl"}, "out.yaml", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{"    # count: 1  # Number of copies", "    # rotation_z: 0  # Rotation around Z", "    # supports: none"} {
+		if !strings.Contains(generated, want) {
+			t.Errorf("generated YAML is missing %q:\n%s", want, generated)
+		}
+	}
+}
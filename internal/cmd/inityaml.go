@@ -0,0 +1,223 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/philipparndt/go3mf/internal/models"
+	"gopkg.in/yaml.v3"
+)
+
+// optionHelp is the example value and description of a commented out option in
+// a generated config. Multi-line examples are written below the option.
+type optionHelp struct {
+	example string
+	help    string
+}
+
+// objectOptionHelp describes the object options listed in generated configs.
+// Options without an entry are listed with an example for their type.
+var objectOptionHelp = map[string]optionHelp{
+	"count":              {"1", "Number of copies of this object (default: 1)"},
+	"config":             {"- config.scad:\n    variable_name: value", "OpenSCAD config applied to all parts"},
+	"normalize_position": {"true", "Place object at ground level (default: true)"},
+	"margin":             {"0", "Extra clearance in mm around this object, e.g. for brims"},
+	"brim":               {"auto", "auto, none, outer_only, inner_only, outer_and_inner or ears"},
+	"supports":           {"none", "none, normal or tree"},
+	"seam":               {"aligned", "nearest, aligned, rear or random"},
+	"infill":             {"15%", "Sparse infill density"},
+	"enabled":            {"false", "Leave the object out"},
+	"enabled_if":         {"${vars.name}", "Only include the object if the condition is true"},
+	"explode":            {"true", "Build every part as an object of its own"},
+}
+
+// partOptionHelp describes the part options listed in generated configs
+var partOptionHelp = map[string]optionHelp{
+	"generator":      {"text", "Built-in generator instead of a file"},
+	"params":         {"{}", "Parameters of the generator"},
+	"extrude_height": {"2", "Height in mm to extrude a 2D SVG or DXF file to"},
+	"enabled":        {"false", "Leave the part out"},
+	"optional":       {"true", "Skip the part with a warning if its file is missing"},
+	"enabled_if":     {"${vars.name}", "Only include the part if the condition is true"},
+	"quality":        {"{fn: 64}", "OpenSCAD resolution ($fn, $fa, $fs)"},
+	"workdir":        {"dir", "Directory OpenSCAD runs in (default: config directory)"},
+	"library_paths":  {"[libs]", "Directories added to OPENSCADPATH"},
+	"config":         {"- config.scad:\n    variable_name: value", "Part-specific OpenSCAD config (overrides object config)"},
+	"filament":       {"1", "AMS slot (1-4), 0 or omit for auto"},
+	"rotation_x":     {"0", "Rotation around X axis in degrees"},
+	"rotation_y":     {"0", "Rotation around Y axis in degrees"},
+	"rotation_z":     {"0", "Rotation around Z axis in degrees"},
+	"position_x":     {"0", "Relative X position offset in mm"},
+	"position_y":     {"0", "Relative Y position offset in mm"},
+	"position_z":     {"0", "Relative Z position offset in mm"},
+	"align":          {"{on_top_of: base, centered: true}", "Stack this part on another part"},
+	"anchor":         {"bed", "relative (default) or bed"},
+	"type":           {"support", "3MF object type: model (default), solidsupport, support, surface or other"},
+}
+
+// initObject is an object of a generated config
+type initObject struct {
+	name  string
+	files []string // Files of the parts, named after the files
+	main  bool     // Name the only part "main" instead
+}
+
+// generateSeparatePartsYAML generates a YAML config with all files as parts in one object
+func generateSeparatePartsYAML(files []string, outputPath string, guess *filamentGuess) (string, error) {
+	return generateInitYAML("All files are organized as separate parts within a single object", outputPath, guess,
+		[]initObject{{name: "Combined", files: files}})
+}
+
+// generateSeparateObjectsYAML generates a YAML config with each file as a separate object
+func generateSeparateObjectsYAML(files []string, outputPath string, guess *filamentGuess) (string, error) {
+	var objects []initObject
+	for _, file := range files {
+		objects = append(objects, initObject{name: strings.TrimSuffix(filepath.Base(file), filepath.Ext(file)), files: []string{file}, main: true})
+	}
+	return generateInitYAML("Each file is organized as a separate object", outputPath, guess, objects)
+}
+
+// generateDirectoryObjectsYAML generates a YAML config with an object per
+// directory that has the files of the directory as parts
+func generateDirectoryObjectsYAML(files []string, outputPath string, guess *filamentGuess) (string, error) {
+	var objects []initObject
+	for _, group := range groupByDirectory(files) {
+		objects = append(objects, initObject{name: group.Name, files: group.Files})
+	}
+	return generateInitYAML("Each directory is organized as an object with its files as parts", outputPath, guess, objects)
+}
+
+// generateInitYAML encodes a generated config. The options that are not set are
+// listed as comments at the first object, so they can be uncommented.
+func generateInitYAML(description, outputPath string, guess *filamentGuess, objects []initObject) (string, error) {
+	// Determine output 3MF filename from config filename
+	threemfOutput := strings.TrimSuffix(outputPath, filepath.Ext(outputPath)) + ".3mf"
+
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	root.Content = append(root.Content, keyNode("output", "", ""), stringNode(threemfOutput))
+
+	if guess != nil && len(guess.colors) > 0 {
+		filaments := &yaml.Node{Kind: yaml.SequenceNode}
+		for _, color := range guess.colors {
+			filament := &yaml.Node{Kind: yaml.MappingNode}
+			filament.Content = append(filament.Content, keyNode("color", "", color), stringNode(fileColors[color]))
+			filaments.Content = append(filaments.Content, filament)
+		}
+		root.Content = append(root.Content, keyNode("filaments", "\nFilament colors guessed from the file names (slot 1 first)", ""), filaments)
+	}
+
+	objectsNode := &yaml.Node{Kind: yaml.SequenceNode}
+	for i, obj := range objects {
+		parts := &yaml.Node{Kind: yaml.SequenceNode}
+		for _, file := range obj.files {
+			name := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+			if obj.main {
+				name = "main"
+			}
+			part := &yaml.Node{Kind: yaml.MappingNode}
+			part.Content = append(part.Content, keyNode("name", "", ""), stringNode(name), keyNode("file", "", ""), stringNode(file))
+			if guess != nil && guess.slots[file] > 0 {
+				slot := guess.slots[file]
+				part.Content = append(part.Content,
+					keyNode("filament", "", guess.colors[slot-1]+", from the file name"),
+					&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: fmt.Sprint(slot)})
+			}
+			parts.Content = append(parts.Content, part)
+		}
+
+		object := &yaml.Node{Kind: yaml.MappingNode}
+		partsHead := ""
+		if i == 0 {
+			partsHead = "Object options (uncomment to use):\n" + optionComments(reflect.TypeFor[models.YamlObject](), objectOptionHelp, "name", "parts") +
+				"\n\nPart options (uncomment below a part to use):\n" + optionComments(reflect.TypeFor[models.YamlPart](), partOptionHelp, "name", "file")
+		}
+		object.Content = append(object.Content, keyNode("name", "", ""), stringNode(obj.name), keyNode("parts", partsHead, ""), parts)
+		objectsNode.Content = append(objectsNode.Content, object)
+	}
+	objectsHead := "\nPacking distance between objects in mm (default: 10.0)\npacking_distance: 10.0\n\n" +
+		"Packing algorithm: \"default\" or \"compact\" (default: \"default\")\npacking_algorithm: default\n\n" +
+		"Packing order: \"default\" or \"by_height\" (lowest objects first, default: \"default\")\npacking_order: default\n"
+	root.Content = append(root.Content, keyNode("objects", objectsHead, ""), objectsNode)
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	document := &yaml.Node{
+		Kind:        yaml.DocumentNode,
+		HeadComment: "Generated configuration file\n" + description + "\nDocumentation: https://github.com/philipparndt/go3mf",
+		Content:     []*yaml.Node{root},
+	}
+	if err := encoder.Encode(document); err != nil {
+		return "", fmt.Errorf("cannot generate the configuration: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return "", fmt.Errorf("cannot generate the configuration: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// optionComments lists the options of a config type by their YAML names with an
+// example and description, leaving out the options that are skipped
+func optionComments(t reflect.Type, help map[string]optionHelp, skip ...string) string {
+	var lines []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if name == "" || name == "-" || contains(skip, name) {
+			continue
+		}
+		option, ok := help[name]
+		if !ok {
+			option.example = exampleValue(field.Type)
+		}
+		line := name + ": " + option.example
+		example, more, multiline := strings.Cut(option.example, "\n")
+		if multiline || strings.HasPrefix(example, "- ") {
+			line = name + ":"
+		}
+		if option.help != "" {
+			line += "  # " + option.help
+		}
+		lines = append(lines, line)
+		if multiline || strings.HasPrefix(example, "- ") {
+			for _, exampleLine := range strings.Split(example+"\n"+more, "\n") {
+				if exampleLine != "" {
+					lines = append(lines, "  "+exampleLine)
+				}
+			}
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// exampleValue returns an example of a value of a type for an option without help
+func exampleValue(t reflect.Type) string {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return "true"
+	case reflect.Int, reflect.Int64, reflect.Float64:
+		return "0"
+	case reflect.String:
+		return `""`
+	case reflect.Slice:
+		return "[]"
+	default:
+		return "{}"
+	}
+}
+
+// keyNode returns a mapping key with an optional comment above and after it
+func keyNode(key, head, line string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Value: key, HeadComment: head, LineComment: line}
+}
+
+// stringNode returns a string value, quoted where YAML needs it
+func stringNode(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
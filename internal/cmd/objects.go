@@ -0,0 +1,276 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/alecthomas/kong"
+	"github.com/philipparndt/go3mf/internal/buildplan"
+)
+
+// objectGroups are the object groups defined with --object on the command line.
+// They are bound into the Kong context so CombineCmd.Run can receive them.
+type objectGroups []buildplan.ObjectGroup
+
+// objectGroupBuilder collects the tokens of a single --object group
+type objectGroupBuilder struct {
+	index    int // 1-based position of the group on the command line
+	group    buildplan.ObjectGroup
+	filament int // Filament slot for the next file (0 = auto)
+}
+
+// splitObjectArgs separates the --object groups of a combine/build command from
+// the remaining arguments, which are returned for Kong to parse.
+//
+// Grammar (repeatable):
+//
+//	--object -n NAME [--count N] [-c SLOT] FILE [-c SLOT] FILE...
+//
+// Flags known to the Kong model (e.g. -o, --open) may appear anywhere; they and
+// their values are passed through to Kong. Everything else after --object must be
+// an object flag or a file, otherwise an error is returned.
+func splitObjectArgs(model *kong.Application, args []string) ([]string, objectGroups, error) {
+	cmdIndex, node := findObjectCommand(model, args)
+	if node == nil || !containsObjectFlag(args[cmdIndex+1:]) {
+		return args, nil, nil
+	}
+
+	kongFlags := flagsByName(node)
+	rest := append([]string{}, args[:cmdIndex+1]...)
+	var groups objectGroups
+	var current *objectGroupBuilder
+
+	for i := cmdIndex + 1; i < len(args); i++ {
+		arg := args[i]
+
+		// Start a new object group
+		if arg == "--object" {
+			if current != nil {
+				group, err := current.finish()
+				if err != nil {
+					return nil, nil, err
+				}
+				groups = append(groups, group)
+			}
+			current = &objectGroupBuilder{index: len(groups) + 1}
+			continue
+		}
+		if strings.HasPrefix(arg, "--object=") {
+			return nil, nil, fmt.Errorf("--object does not take a value, use: --object -n NAME [-c SLOT] FILE")
+		}
+
+		// Flags handled by Kong are passed through together with their value
+		if flag, inline, ok := lookupFlag(kongFlags, arg); ok {
+			rest = append(rest, arg)
+			if !flag.IsBool() && !flag.IsCounter() && !inline {
+				if i+1 >= len(args) {
+					return nil, nil, fmt.Errorf("flag %s requires a value", arg)
+				}
+				i++
+				rest = append(rest, args[i])
+			}
+			continue
+		}
+
+		// Arguments before the first --object are left for Kong
+		if current == nil {
+			rest = append(rest, arg)
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(arg, "=")
+		switch name {
+		case "-n", "--name":
+			if !hasValue {
+				if i+1 >= len(args) || strings.HasPrefix(args[i+1], "-") {
+					return nil, nil, fmt.Errorf("object %s: %s requires a name", current.label(), name)
+				}
+				i++
+				value = args[i]
+			}
+			if err := current.setName(value); err != nil {
+				return nil, nil, err
+			}
+		case "-c", "--color", "--filament":
+			if !hasValue {
+				if i+1 >= len(args) {
+					return nil, nil, fmt.Errorf("object %s: %s requires a filament slot", current.label(), name)
+				}
+				i++
+				value = args[i]
+			}
+			if err := current.setFilament(value); err != nil {
+				return nil, nil, err
+			}
+		case "--count":
+			if !hasValue {
+				if i+1 >= len(args) {
+					return nil, nil, fmt.Errorf("object %s: %s requires a number of copies", current.label(), name)
+				}
+				i++
+				value = args[i]
+			}
+			if err := current.setCount(value); err != nil {
+				return nil, nil, err
+			}
+		default:
+			if strings.HasPrefix(arg, "-") && arg != "-" {
+				return nil, nil, fmt.Errorf("object %s: unknown flag %s (object groups accept -n NAME, --count N, -c SLOT and files)", current.label(), arg)
+			}
+			current.addFile(arg)
+		}
+	}
+
+	group, err := current.finish()
+	if err != nil {
+		return nil, nil, err
+	}
+	groups = append(groups, group)
+
+	return rest, groups, nil
+}
+
+// setName sets the object name; each group must be named exactly once
+func (b *objectGroupBuilder) setName(name string) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("object %s: name must not be empty", b.label())
+	}
+	if b.group.Name != "" {
+		return fmt.Errorf("object %s: name given twice (%q and %q)", b.label(), b.group.Name, name)
+	}
+	b.group.Name = name
+	return nil
+}
+
+// setFilament sets the filament slot used for the next file
+func (b *objectGroupBuilder) setFilament(value string) error {
+	if b.filament != 0 {
+		return fmt.Errorf("object %s: filament slot %d is not followed by a file", b.label(), b.filament)
+	}
+	slot, err := strconv.Atoi(value)
+	if err != nil || slot < 1 || slot > 4 {
+		return fmt.Errorf("object %s: invalid filament slot %q, must be 1-4", b.label(), value)
+	}
+	b.filament = slot
+	return nil
+}
+
+// setCount sets the number of copies of the object
+func (b *objectGroupBuilder) setCount(value string) error {
+	if b.group.Count != 0 {
+		return fmt.Errorf("object %s: count given twice", b.label())
+	}
+	count, err := strconv.Atoi(value)
+	if err != nil || count < 1 {
+		return fmt.Errorf("object %s: invalid count %q, must be 1 or more", b.label(), value)
+	}
+	b.group.Count = count
+	return nil
+}
+
+// addFile adds a file to the group, applying a pending filament slot.
+// Files use the format path, path:name or path:name:filament.
+func (b *objectGroupBuilder) addFile(spec string) {
+	if b.filament > 0 {
+		switch strings.Count(spec, ":") {
+		case 0:
+			// Name will be derived from the filename
+			spec = fmt.Sprintf("%s::%d", spec, b.filament)
+		case 1:
+			spec = fmt.Sprintf("%s:%d", spec, b.filament)
+		}
+		// Fully specified files (path:name:filament) are kept as-is
+		b.filament = 0
+	}
+	b.group.Files = append(b.group.Files, spec)
+}
+
+// finish validates the group once all of its tokens have been consumed
+func (b *objectGroupBuilder) finish() (buildplan.ObjectGroup, error) {
+	if b.group.Name == "" {
+		return buildplan.ObjectGroup{}, fmt.Errorf("object %s: missing name (use -n NAME)", b.label())
+	}
+	if b.filament != 0 {
+		return buildplan.ObjectGroup{}, fmt.Errorf("object %s: filament slot %d is not followed by a file", b.label(), b.filament)
+	}
+	if len(b.group.Files) == 0 {
+		return buildplan.ObjectGroup{}, fmt.Errorf("object %s: no files specified", b.label())
+	}
+	return b.group, nil
+}
+
+// label identifies the group in error messages by its name, or by its
+// position if it has no name yet
+func (b *objectGroupBuilder) label() string {
+	if b.group.Name != "" {
+		return fmt.Sprintf("%q", b.group.Name)
+	}
+	return fmt.Sprintf("#%d", b.index)
+}
+
+// findObjectCommand returns the index and Kong node of the command that accepts
+// --object groups (combine/build), or nil if another command is invoked
+func findObjectCommand(model *kong.Application, args []string) (int, *kong.Node) {
+	for i, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+			continue
+		}
+		for _, child := range model.Children {
+			if child.Name != "combine" && child.Name != "build" {
+				continue
+			}
+			if child.Name == arg || contains(child.Aliases, arg) {
+				return i, child
+			}
+		}
+		// The first positional argument selects the command
+		return 0, nil
+	}
+	return 0, nil
+}
+
+// containsObjectFlag checks if --object is present in args
+func containsObjectFlag(args []string) bool {
+	for _, arg := range args {
+		if arg == "--object" || strings.HasPrefix(arg, "--object=") {
+			return true
+		}
+	}
+	return false
+}
+
+// flagsByName indexes the flags of a command (including inherited ones) by their
+// long and short names, without the --object flag itself
+func flagsByName(node *kong.Node) map[string]*kong.Flag {
+	flags := make(map[string]*kong.Flag)
+	for _, group := range node.AllFlags(false) {
+		for _, flag := range group {
+			if flag.Name == "object" {
+				continue
+			}
+			flags["--"+flag.Name] = flag
+			if flag.Short != 0 {
+				flags["-"+string(flag.Short)] = flag
+			}
+		}
+	}
+	return flags
+}
+
+// lookupFlag finds the Kong flag for arg, which may use the --flag=value form
+func lookupFlag(flags map[string]*kong.Flag, arg string) (*kong.Flag, bool, bool) {
+	name, _, inline := strings.Cut(arg, "=")
+	flag, ok := flags[name]
+	return flag, inline, ok
+}
+
+// contains reports whether values contains s
+func contains(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
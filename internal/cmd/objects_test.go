@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/kong"
+	"github.com/philipparndt/go3mf/internal/buildplan"
+)
+
+func TestSplitObjectArgs(t *testing.T) {
+	model := kong.Must(&CLI{}).Model
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantRest   []string
+		wantGroups objectGroups
+		wantErr    string
+	}{
+		{
+			name:     "no object groups",
+			args:     []string{"combine", "a.scad", "-o", "out.3mf"},
+			wantRest: []string{"combine", "a.scad", "-o", "out.3mf"},
+		},
+		{
+			name:     "other command is left alone",
+			args:     []string{"inspect", "--object"},
+			wantRest: []string{"inspect", "--object"},
+		},
+		{
+			name:     "groups with filament slots and kong flags",
+			args:     []string{"build", "-o", "-", "--object", "-n", "Case", "-c", "1", "bottom.scad", "top.scad:lid", "--open", "--object", "--name=Insert", "--filament=2", "insert.scad:in:3"},
+			wantRest: []string{"build", "-o", "-", "--open"},
+			wantGroups: objectGroups{
+				{Name: "Case", Files: []string{"bottom.scad::1", "top.scad:lid"}},
+				{Name: "Insert", Files: []string{"insert.scad:in:3"}},
+			},
+		},
+		{
+			name:     "filament applies to the next file only",
+			args:     []string{"combine", "--object", "-n", "A", "a.scad", "-c", "2", "b.scad:b", "c.scad"},
+			wantRest: []string{"combine"},
+			wantGroups: objectGroups{
+				{Name: "A", Files: []string{"a.scad", "b.scad:b:2", "c.scad"}},
+			},
+		},
+		{
+			name:     "count",
+			args:     []string{"combine", "--object", "-n", "Clip", "--count", "8", "clip.scad", "--object", "--count=2", "-n", "Base", "base.scad"},
+			wantRest: []string{"combine"},
+			wantGroups: objectGroups{
+				{Name: "Clip", Count: 8, Files: []string{"clip.scad"}},
+				{Name: "Base", Count: 2, Files: []string{"base.scad"}},
+			},
+		},
+		{
+			name:    "invalid count",
+			args:    []string{"combine", "--object", "-n", "A", "--count", "0", "a.scad"},
+			wantErr: `object "A": invalid count "0"`,
+		},
+		{
+			name:    "count given twice",
+			args:    []string{"combine", "--object", "-n", "A", "--count", "2", "a.scad", "--count", "3"},
+			wantErr: `object "A": count given twice`,
+		},
+		{
+			name:    "missing name",
+			args:    []string{"combine", "--object", "a.scad"},
+			wantErr: "object #1: missing name",
+		},
+		{
+			name:    "name without value",
+			args:    []string{"combine", "--object", "-n", "--open", "a.scad"},
+			wantErr: "-n requires a name",
+		},
+		{
+			name:    "invalid filament slot",
+			args:    []string{"combine", "--object", "-n", "A", "-c", "x", "a.scad"},
+			wantErr: `invalid filament slot "x"`,
+		},
+		{
+			name:    "dangling filament slot",
+			args:    []string{"combine", "--object", "-n", "A", "a.scad", "-c", "2"},
+			wantErr: "filament slot 2 is not followed by a file",
+		},
+		{
+			name:    "empty group",
+			args:    []string{"combine", "--object", "-n", "A", "--object", "-n", "B", "b.scad"},
+			wantErr: `object "A": no files specified`,
+		},
+		{
+			name:    "unknown flag",
+			args:    []string{"combine", "--object", "-n", "A", "--colour", "1", "a.scad"},
+			wantErr: "unknown flag --colour",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rest, groups, err := splitObjectArgs(model, tt.args)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(rest, tt.wantRest) {
+				t.Errorf("rest = %q, want %q", rest, tt.wantRest)
+			}
+			if !reflect.DeepEqual([]buildplan.ObjectGroup(groups), []buildplan.ObjectGroup(tt.wantGroups)) {
+				t.Errorf("groups = %+v, want %+v", groups, tt.wantGroups)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/philipparndt/go3mf/internal/exitcode"
+	"github.com/philipparndt/go3mf/internal/selfupdate"
+	"github.com/philipparndt/go3mf/internal/ui"
+	"github.com/philipparndt/go3mf/version"
+)
+
+type SelfUpdateCmd struct {
+	Check   bool   `help:"Only check whether an update is available (exit code 8 if the installed version differs)"`
+	Version string `help:"Install (or check against) a specific release instead of the latest one" placeholder:"VERSION"`
+	Force   bool   `help:"Update even when installed by a package manager, running a development build, or already up to date"`
+}
+
+// packageManagerUpdate is the command to use instead of self-update for managed installations
+var packageManagerUpdate = map[string]string{
+	"brew":  "brew upgrade go3mf",
+	"scoop": "scoop update go3mf",
+}
+
+func (c *SelfUpdateCmd) Run() error {
+	current := version.Get().Version
+	updater := selfupdate.NewUpdater()
+
+	release, err := updater.FindRelease(c.Version)
+	if err != nil {
+		return exitcode.Wrap(exitcode.Update, err)
+	}
+	target := release.Version()
+
+	// A pinned version must match exactly, otherwise the installed one must not be older
+	upToDate := current != "dev"
+	if upToDate && c.Version != "" {
+		upToDate = selfupdate.CompareVersions(current, target) == 0
+	} else if upToDate {
+		upToDate = selfupdate.CompareVersions(current, target) >= 0
+	}
+
+	if c.Check {
+		if !upToDate {
+			return exitcode.Wrap(exitcode.Outdated, fmt.Errorf("go3mf %s is installed, release %s is available", current, target))
+		}
+		ui.PrintSuccess(fmt.Sprintf("go3mf %s is up to date", current))
+		return nil
+	}
+
+	if upToDate && !c.Force {
+		ui.PrintSuccess(fmt.Sprintf("go3mf %s is up to date", current))
+		return nil
+	}
+
+	executable, err := os.Executable()
+	if err == nil {
+		executable, err = filepath.EvalSymlinks(executable)
+	}
+	if err != nil {
+		return exitcode.Wrap(exitcode.Update, fmt.Errorf("cannot locate the go3mf executable: %w", err))
+	}
+
+	if !c.Force {
+		if manager := selfupdate.ManagedBy(executable); manager != "" {
+			return exitcode.Wrap(exitcode.Update, fmt.Errorf("go3mf was installed with %s, run '%s' instead (or use --force)", manager, packageManagerUpdate[manager]))
+		}
+		if current == "dev" {
+			return exitcode.Wrap(exitcode.Update, fmt.Errorf("this is a development build, use --force to replace it with release %s", target))
+		}
+	}
+
+	ui.PrintStep(fmt.Sprintf("Downloading go3mf %s", target))
+	binary, err := updater.Download(release)
+	if err != nil {
+		return exitcode.Wrap(exitcode.Update, err)
+	}
+	ui.PrintItem("Checksum verified")
+
+	if err := selfupdate.Replace(executable, binary); err != nil {
+		return exitcode.Wrap(exitcode.Update, fmt.Errorf("failed to replace %s: %w", executable, err))
+	}
+
+	ui.PrintSuccess(fmt.Sprintf("Updated go3mf %s → %s", current, target))
+	return nil
+}
@@ -0,0 +1,151 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/philipparndt/go3mf/internal/models"
+)
+
+// varPattern matches a variable reference in an enabled_if condition
+var varPattern = regexp.MustCompile(`^\$\{\s*vars\.([A-Za-z0-9_-]+)\s*\}$`)
+
+// applyConditions removes the objects and parts that are disabled by enabled or
+// enabled_if. Objects whose parts are all disabled are removed as well.
+func applyConditions(config *models.YamlConfig, vars map[string]string) error {
+	var err error
+	if config.Objects, err = enabledObjects(config.Objects, vars); err != nil {
+		return err
+	}
+	for i := range config.Plates {
+		plate := &config.Plates[i]
+		if plate.Objects, err = enabledObjects(plate.Objects, vars); err != nil {
+			return fmt.Errorf("plate %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// enabledObjects returns the enabled objects with their enabled parts
+func enabledObjects(objects []models.YamlObject, vars map[string]string) ([]models.YamlObject, error) {
+	var result []models.YamlObject
+	for _, obj := range objects {
+		enabled, err := isEnabled(obj.Enabled, obj.EnabledIf, vars)
+		if err != nil {
+			return nil, fmt.Errorf("object %s: enabled_if: %w", obj.Name, err)
+		}
+		if !enabled {
+			continue
+		}
+
+		var parts []models.YamlPart
+		for _, part := range obj.Parts {
+			enabled, err := isEnabled(part.Enabled, part.EnabledIf, vars)
+			if err != nil {
+				return nil, fmt.Errorf("object %s, part %s: enabled_if: %w", obj.Name, part.Name, err)
+			}
+			if enabled {
+				parts = append(parts, part)
+			}
+		}
+		// Objects without parts are reported by the validation
+		if len(obj.Parts) > 0 && len(parts) == 0 {
+			continue
+		}
+		obj.Parts = parts
+		result = append(result, obj)
+	}
+	return result, nil
+}
+
+// isEnabled reports whether an object or part with the given enabled flag and condition is built
+func isEnabled(enabled *bool, condition string, vars map[string]string) (bool, error) {
+	if enabled != nil && !*enabled {
+		return false, nil
+	}
+	if strings.TrimSpace(condition) == "" {
+		return true, nil
+	}
+	return evaluateCondition(condition, vars)
+}
+
+// evaluateCondition evaluates an enabled_if condition. Supported are a variable
+// reference (${vars.name}) that holds a boolean, its negation (!${vars.name}) and
+// comparisons of variables and values with == and !=.
+func evaluateCondition(condition string, vars map[string]string) (bool, error) {
+	for _, op := range []string{"==", "!="} {
+		left, right, found := strings.Cut(condition, op)
+		if !found {
+			continue
+		}
+		a, err := conditionOperand(left, vars)
+		if err != nil {
+			return false, err
+		}
+		b, err := conditionOperand(right, vars)
+		if err != nil {
+			return false, err
+		}
+		return (a == b) == (op == "=="), nil
+	}
+
+	expr := strings.TrimSpace(condition)
+	negate := strings.HasPrefix(expr, "!")
+	expr = strings.TrimPrefix(expr, "!")
+	if !varPattern.MatchString(strings.TrimSpace(expr)) {
+		return false, fmt.Errorf("invalid condition %q (expected ${vars.name}, !${vars.name} or a comparison with == or !=)", condition)
+	}
+	value, err := conditionOperand(expr, vars)
+	if err != nil {
+		return false, err
+	}
+	b, err := strconv.ParseBool(strings.ToLower(value))
+	if err != nil {
+		return false, fmt.Errorf("variable in %q is %q, not true or false", condition, value)
+	}
+	return b != negate, nil
+}
+
+// conditionOperand resolves a variable reference or returns a literal value without quotes
+func conditionOperand(operand string, vars map[string]string) (string, error) {
+	operand = strings.TrimSpace(operand)
+	if m := varPattern.FindStringSubmatch(operand); m != nil {
+		value, ok := vars[m[1]]
+		if !ok {
+			return "", fmt.Errorf("unknown variable %q (define it in vars or with --var)", m[1])
+		}
+		return value, nil
+	}
+	if len(operand) >= 2 && (operand[0] == '"' || operand[0] == '\'') && operand[len(operand)-1] == operand[0] {
+		return operand[1 : len(operand)-1], nil
+	}
+	return operand, nil
+}
+
+// mergeVars returns the variables of the config overridden by those from the command line
+func mergeVars(configVars, overrides map[string]string) map[string]string {
+	vars := make(map[string]string, len(configVars)+len(overrides))
+	for name, value := range configVars {
+		vars[name] = value
+	}
+	for name, value := range overrides {
+		vars[name] = value
+	}
+	return vars
+}
+
+// ParseVars parses variables given as name=value
+func ParseVars(assignments []string) (map[string]string, error) {
+	vars := make(map[string]string, len(assignments))
+	for _, assignment := range assignments {
+		name, value, found := strings.Cut(assignment, "=")
+		name = strings.TrimSpace(name)
+		if !found || name == "" {
+			return nil, fmt.Errorf("invalid variable %q (expected name=value)", assignment)
+		}
+		vars[name] = value
+	}
+	return vars, nil
+}
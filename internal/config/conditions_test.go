@@ -0,0 +1,117 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/philipparndt/go3mf/internal/models"
+	"gopkg.in/yaml.v3"
+)
+
+func TestEvaluateCondition(t *testing.T) {
+	vars := map[string]string{"with_lid": "true", "flat": "False", "size": "large"}
+
+	tests := []struct {
+		condition string
+		want      bool
+		wantErr   string
+	}{
+		{condition: "${vars.with_lid}", want: true},
+		{condition: "${ vars.flat }", want: false},
+		{condition: "!${vars.with_lid}", want: false},
+		{condition: "! ${vars.flat}", want: true},
+		{condition: "${vars.size} == large", want: true},
+		{condition: `${vars.size} == "small"`, want: false},
+		{condition: "${vars.size} != 'small'", want: true},
+		{condition: "${vars.lid}", wantErr: `unknown variable "lid"`},
+		{condition: "${vars.size}", wantErr: `is "large", not true or false`},
+		{condition: "with_lid", wantErr: "invalid condition"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.condition, func(t *testing.T) {
+			got, err := evaluateCondition(tt.condition, vars)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestApplyConditions(t *testing.T) {
+	data := `
+output: out.3mf
+vars:
+  with_lid: false
+objects:
+  - name: Box
+    parts:
+      - name: body
+        file: body.scad
+      - name: lid
+        file: lid.scad
+        enabled_if: ${vars.with_lid}
+  - name: Spare
+    enabled: false
+    parts:
+      - name: spare
+        file: spare.scad
+  - name: Lid
+    parts:
+      - name: lid
+        file: lid.scad
+        enabled_if: ${vars.with_lid}
+`
+	tests := []struct {
+		name      string
+		overrides map[string]string
+		want      []string
+	}{
+		{name: "defaults of the config", want: []string{"Box/body"}},
+		{name: "overridden", overrides: map[string]string{"with_lid": "true"}, want: []string{"Box/body", "Box/lid", "Lid/lid"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var config models.YamlConfig
+			if err := yaml.Unmarshal([]byte(data), &config); err != nil {
+				t.Fatal(err)
+			}
+			if err := applyConditions(&config, mergeVars(config.Vars, tt.overrides)); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var got []string
+			for _, obj := range config.Objects {
+				for _, part := range obj.Parts {
+					got = append(got, obj.Name+"/"+part.Name)
+				}
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("expected parts %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestParseVars(t *testing.T) {
+	vars, err := ParseVars([]string{"with_lid=true", "label=a=b"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if vars["with_lid"] != "true" || vars["label"] != "a=b" {
+		t.Errorf("unexpected vars %v", vars)
+	}
+	if _, err := ParseVars([]string{"with_lid"}); err == nil {
+		t.Error("expected error for missing value")
+	}
+}
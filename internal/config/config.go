@@ -1,27 +1,63 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
 
+	"github.com/philipparndt/go3mf/internal/generator"
+	"github.com/philipparndt/go3mf/internal/geometry"
 	"github.com/philipparndt/go3mf/internal/models"
+	"github.com/philipparndt/go3mf/internal/preconditions"
 	"gopkg.in/yaml.v3"
 )
 
+// StdinPath is the config path that makes the loader read YAML from stdin.
+// Relative part paths are then resolved against the current working directory.
+const StdinPath = "-"
+
 // Loader handles loading and validating YAML configuration files
-type Loader struct{}
+type Loader struct {
+	printer   string            // Overrides the printer of the configuration if set
+	profile   string            // Build profile applied to the configuration ("" = none)
+	vars      map[string]string // Overrides variables of the configuration
+	explode   []string          // Objects built with every part as an object of its own
+	warnings  []string          // Problems of the last loaded configuration that did not stop loading
+	workspace *models.Workspace // Workspace of the last loaded configuration (nil if it is no member)
+}
 
 // NewLoader creates a new config loader
 func NewLoader() *Loader {
 	return &Loader{}
 }
 
+// SetPrinter overrides the printer of the loaded configurations ("" keeps the configured printer)
+func (l *Loader) SetPrinter(printer string) {
+	l.printer = printer
+}
+
+// SetProfile selects the build profile applied to the loaded configurations ("" = none)
+func (l *Loader) SetProfile(profile string) {
+	l.profile = profile
+}
+
+// SetVars overrides variables of the loaded configurations for their enabled_if conditions
+func (l *Loader) SetVars(vars map[string]string) {
+	l.vars = vars
+}
+
+// SetExplode builds every part of the named objects as an object of its own
+func (l *Loader) SetExplode(names []string) {
+	l.explode = names
+}
+
 // Load reads and parses a YAML configuration file
 func (l *Loader) Load(configPath string) (*models.YamlConfig, error) {
-	// Read the config file
-	data, err := os.ReadFile(configPath)
+	// Read the config file (or stdin)
+	data, err := readConfig(configPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
@@ -29,12 +65,47 @@ func (l *Loader) Load(configPath string) (*models.YamlConfig, error) {
 	// Parse YAML
 	var config models.YamlConfig
 	if err := yaml.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+		return nil, withLocation(data, configPath, fmt.Errorf("failed to parse YAML: %w", err))
+	}
+	// Members of a workspace use its shared settings
+	l.workspace = nil
+	l.warnings = nil
+	if configPath != StdinPath {
+		ws, err := l.findWorkspace(configPath)
+		if err != nil {
+			return nil, err
+		}
+		if ws != nil {
+			applyWorkspace(&config, ws)
+			l.workspace = ws
+		}
+	}
+	if l.printer != "" {
+		config.Printer = l.printer
+	}
+	if err := expandInstances(&config); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	if l.profile != "" {
+		if err := applyProfile(&config, l.profile); err != nil {
+			return nil, fmt.Errorf("invalid configuration: profile: %w", err)
+		}
+	}
+	// Leave out disabled objects and parts before they are validated
+	if err := applyConditions(&config, mergeVars(config.Vars, l.vars)); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	l.warnings = skipMissingParts(&config, filepath.Dir(configPath))
+	if err := ExplodeObjects(&config, l.explode); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
 	}
 
 	// Validate the configuration
 	if err := l.Validate(&config, configPath); err != nil {
-		return nil, fmt.Errorf("invalid configuration: %w", err)
+		return nil, withLocation(data, configPath, fmt.Errorf("invalid configuration: %w", err))
+	}
+	if printer, err := models.LookupPrinter(config.Printer, config.Printers); err == nil {
+		l.warnings = append(l.warnings, lintConfig(&config, printer)...)
 	}
 
 	// Convert relative paths to absolute paths (relative to config file)
@@ -48,10 +119,7 @@ func (l *Loader) Load(configPath string) (*models.YamlConfig, error) {
 	for i := range config.Plates {
 		for j := range config.Plates[i].Objects {
 			for k := range config.Plates[i].Objects[j].Parts {
-				part := &config.Plates[i].Objects[j].Parts[k]
-				if !filepath.IsAbs(part.File) {
-					part.File = filepath.Join(absConfigDir, part.File)
-				}
+				resolvePartPaths(&config.Plates[i].Objects[j].Parts[k], absConfigDir)
 			}
 		}
 	}
@@ -59,20 +127,107 @@ func (l *Loader) Load(configPath string) (*models.YamlConfig, error) {
 	// Handle paths in direct objects
 	for i := range config.Objects {
 		for j := range config.Objects[i].Parts {
-			part := &config.Objects[i].Parts[j]
-			if !filepath.IsAbs(part.File) {
-				part.File = filepath.Join(absConfigDir, part.File)
-			}
+			resolvePartPaths(&config.Objects[i].Parts[j], absConfigDir)
 		}
 	}
 
 	return &config, nil
 }
 
+// resolvePartPaths makes the file, working directory and library paths of a part absolute
+func resolvePartPaths(part *models.YamlPart, configDir string) {
+	resolve := func(path string) string {
+		if path != "" && !filepath.IsAbs(path) {
+			return filepath.Join(configDir, path)
+		}
+		return path
+	}
+	part.File = resolve(part.File)
+	part.Workdir = resolve(part.Workdir)
+	for i, dir := range part.LibraryPaths {
+		part.LibraryPaths[i] = resolve(dir)
+	}
+}
+
+// readConfig reads the raw config data from a file, or from stdin for StdinPath
+func readConfig(configPath string) ([]byte, error) {
+	if configPath == StdinPath {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(configPath)
+}
+
+// Warnings returns the problems of the last loaded configuration that did not stop
+// loading, like optional parts that were skipped or settings that look like mistakes
+func (l *Loader) Warnings() []string {
+	return l.warnings
+}
+
+// skipMissingParts removes optional parts whose file does not exist, and objects that
+// have no parts left, and returns a warning for each of them
+func skipMissingParts(config *models.YamlConfig, configDir string) []string {
+	var warnings []string
+	skip := func(objects []models.YamlObject) []models.YamlObject {
+		var result []models.YamlObject
+		for _, obj := range objects {
+			var parts []models.YamlPart
+			var skipped []string
+			for _, part := range obj.Parts {
+				if part.Optional && part.File != "" {
+					path := part.File
+					if !filepath.IsAbs(path) {
+						path = filepath.Join(configDir, path)
+					}
+					if _, err := os.Stat(path); err != nil {
+						skipped = append(skipped, fmt.Sprintf("Skipped optional part %s of object %s: file not found: %s", part.Name, obj.Name, part.File))
+						continue
+					}
+				}
+				parts = append(parts, part)
+			}
+			if len(obj.Parts) > 0 && len(parts) == 0 {
+				warnings = append(warnings, fmt.Sprintf("Skipped object %s: the files of its optional parts were not found", obj.Name))
+				continue
+			}
+			warnings = append(warnings, skipped...)
+			obj.Parts = parts
+			result = append(result, obj)
+		}
+		return result
+	}
+
+	config.Objects = skip(config.Objects)
+	for i := range config.Plates {
+		config.Plates[i].Objects = skip(config.Plates[i].Objects)
+	}
+	return warnings
+}
+
+// Workspace returns the workspace the last loaded configuration is a member of, or nil
+func (l *Loader) Workspace() *models.Workspace {
+	return l.workspace
+}
+
+// findWorkspace returns the workspace of the config file if it is one of its builds
+func (l *Loader) findWorkspace(configPath string) (*models.Workspace, error) {
+	path, err := FindWorkspace(filepath.Dir(configPath))
+	if err != nil || path == "" {
+		return nil, err
+	}
+	ws, err := LoadWorkspace(path)
+	if err != nil {
+		return nil, err
+	}
+	if !IsMember(ws, configPath) {
+		return nil, nil
+	}
+	return ws, nil
+}
+
 // Validate checks if the configuration is valid
 func (l *Loader) Validate(config *models.YamlConfig, configPath string) error {
 	if config.Output == "" {
-		return fmt.Errorf("output file must be specified")
+		return atKey("output", fmt.Errorf("output file must be specified"))
 	}
 
 	// Must have either objects or plates defined
@@ -82,7 +237,101 @@ func (l *Loader) Validate(config *models.YamlConfig, configPath string) error {
 
 	// Cannot mix plates and objects at the top level
 	if len(config.Objects) > 0 && len(config.Plates) > 0 {
-		return fmt.Errorf("cannot mix 'objects' and 'plates' at top level - use one or the other")
+		return atKey("plates", fmt.Errorf("cannot mix 'objects' and 'plates' at top level - use one or the other"))
+	}
+
+	if config.PackingDistance < 0 {
+		return atKey("packing_distance", fmt.Errorf("packing_distance must not be negative"))
+	}
+	if _, err := models.ParsePackingAlgorithm(config.PackingAlgorithm); err != nil {
+		return atKey("packing_algorithm", fmt.Errorf("packing_algorithm: %w", err))
+	}
+	if config.PlacementGrid < 0 {
+		return atKey("placement_grid", fmt.Errorf("placement_grid must not be negative"))
+	}
+	if _, err := models.ParseFootprint(config.Footprint); err != nil {
+		return atKey("footprint", fmt.Errorf("footprint: %w", err))
+	}
+	if _, err := models.ParsePackingOrigin(config.PackingOrigin); err != nil {
+		return atKey("packing_origin", fmt.Errorf("packing_origin: %w", err))
+	}
+	if _, err := models.ParsePackingOrder(config.PackingOrder); err != nil {
+		return atKey("packing_order", fmt.Errorf("packing_order: %w", err))
+	}
+	if _, err := models.ParseNormalization(config.Normalize); err != nil {
+		return atKey("normalize", fmt.Errorf("normalize: %w", err))
+	}
+	for i, output := range config.Outputs {
+		if output.Path == "" {
+			return atKey("outputs", fmt.Errorf("outputs: output %d: path is required", i+1))
+		}
+		if _, err := models.ParseOutputFormat(output.Format); err != nil {
+			return atKey("outputs", fmt.Errorf("outputs: %s: %w", output.Path, err))
+		}
+	}
+	if thumbnails := config.Thumbnails; thumbnails != nil {
+		if thumbnails.Dir == "" && !thumbnails.Embed {
+			return atKey("thumbnails", fmt.Errorf("thumbnails: dir or embed is required"))
+		}
+		if thumbnails.Size != 0 && (thumbnails.Size < 16 || thumbnails.Size > 2048) {
+			return atKey("thumbnails", fmt.Errorf("thumbnails: size must be between 16 and 2048 pixels"))
+		}
+	}
+	if config.SettingsFrom != "" {
+		settingsFile := config.SettingsFrom
+		if !filepath.IsAbs(settingsFile) {
+			settingsFile = filepath.Join(filepath.Dir(configPath), settingsFile)
+		}
+		if _, err := os.Stat(settingsFile); err != nil {
+			return atKey("settings_from", fmt.Errorf("settings_from: file not found: %s", config.SettingsFrom))
+		}
+	}
+	if _, err := models.ParseRenderer(config.Renderer); err != nil {
+		return atKey("renderer", fmt.Errorf("renderer: %w", err))
+	}
+	if err := ValidateRenderWorkers(config.RenderWorkers); err != nil {
+		return atKey("render_workers", fmt.Errorf("render_workers: %w", err))
+	}
+	if config.RenderJobs < 0 {
+		return atKey("render_jobs", fmt.Errorf("render_jobs must not be negative"))
+	}
+	if err := validateFilaments(config.Filaments); err != nil {
+		return atKey("filaments", fmt.Errorf("filaments: %w", err))
+	}
+	for name, density := range config.Materials {
+		if name == "" || density <= 0 {
+			return atKey("materials", fmt.Errorf("materials: %q: density must be a positive value in g/cm³", name))
+		}
+	}
+	if err := geometry.ValidatePrecision(config.Precision); err != nil {
+		return atKey("precision", err)
+	}
+	if err := validateQuality(config.Quality); err != nil {
+		return atKey("quality", fmt.Errorf("quality: %w", err))
+	}
+	if err := validateProfiles(config.Profiles); err != nil {
+		return atKey("profiles", fmt.Errorf("profiles: %w", err))
+	}
+	if seq := config.Sequential; seq != nil {
+		if seq.ClearanceX < 0 || seq.ClearanceY < 0 {
+			return atKey("sequential", fmt.Errorf("sequential: clearance_x and clearance_y must not be negative"))
+		}
+		if seq.GantryHeight < 0 {
+			return atKey("sequential", fmt.Errorf("sequential: gantry_height must not be negative"))
+		}
+	}
+
+	if err := models.ValidateUtilizationLimits(config.MinUtilization, config.MaxUtilization); err != nil {
+		return atKey("min_utilization", fmt.Errorf("min_utilization/max_utilization: %w", err))
+	}
+	for name, profile := range config.Printers {
+		if err := profile.Validate(); err != nil {
+			return atKey("printers", fmt.Errorf("printers: %s: %w", name, err))
+		}
+	}
+	printer, err := models.LookupPrinter(config.Printer, config.Printers)
+	if err != nil {
+		return atKey("printer", fmt.Errorf("printer: %w", err))
 	}
 
 	configDir := filepath.Dir(configPath)
@@ -94,7 +343,11 @@ func (l *Loader) Validate(config *models.YamlConfig, configPath string) error {
 				return fmt.Errorf("plate %d: at least one object must be defined", plateIdx+1)
 			}
 			for i, obj := range plate.Objects {
-				if err := l.validateObject(obj, i, configDir, fmt.Sprintf("plate %d, ", plateIdx+1)); err != nil {
+				if err := l.validateObject(obj, i, configDir, fmt.Sprintf("plate %d, ", plateIdx+1), printer.FilamentSlots); err != nil {
+					var located *locationError
+					if errors.As(err, &located) {
+						located.plate = plateIdx + 1
+					}
 					return err
 				}
 			}
@@ -102,7 +355,7 @@ func (l *Loader) Validate(config *models.YamlConfig, configPath string) error {
 	} else {
 		// Validate direct objects
 		for i, obj := range config.Objects {
-			if err := l.validateObject(obj, i, configDir, ""); err != nil {
+			if err := l.validateObject(obj, i, configDir, "", printer.FilamentSlots); err != nil {
 				return err
 			}
 		}
@@ -112,7 +365,14 @@ func (l *Loader) Validate(config *models.YamlConfig, configPath string) error {
 }
 
 // validateObject validates a single object configuration
-func (l *Loader) validateObject(obj models.YamlObject, index int, configDir, prefix string) error {
+func (l *Loader) validateObject(obj models.YamlObject, index int, configDir, prefix string, filamentSlots int) (err error) {
+	current := "" // Part being validated
+	defer func() {
+		if err != nil {
+			err = &locationError{object: obj.Name, part: current, err: err}
+		}
+	}()
+
 	if obj.Name == "" {
 		return fmt.Errorf("%sobject %d: name is required", prefix, index)
 	}
@@ -121,34 +381,201 @@ func (l *Loader) validateObject(obj models.YamlObject, index int, configDir, pre
 		return fmt.Errorf("%sobject %s: at least one part must be defined", prefix, obj.Name)
 	}
 
+	if obj.Margin < 0 {
+		return fmt.Errorf("%sobject %s: margin must not be negative", prefix, obj.Name)
+	}
+	if _, err := obj.SlicerOptions().SettingsMetadata(); err != nil {
+		return fmt.Errorf("%sobject %s: %w", prefix, obj.Name, err)
+	}
+
 	for j, part := range obj.Parts {
+		current = part.Name
 		if part.Name == "" {
 			return fmt.Errorf("%sobject %s, part %d: name is required", prefix, obj.Name, j)
 		}
 
-		if part.File == "" {
-			return fmt.Errorf("%sobject %s, part %s: file is required", prefix, obj.Name, part.Name)
+		if part.Generator != "" {
+			// Generated parts have no file
+			if part.File != "" {
+				return fmt.Errorf("%sobject %s, part %s: file and generator cannot be combined", prefix, obj.Name, part.Name)
+			}
+			if err := generator.Validate(part.Generator, part.Params); err != nil {
+				return fmt.Errorf("%sobject %s, part %s: generator %s: %w", prefix, obj.Name, part.Name, part.Generator, err)
+			}
+		} else {
+			if part.File == "" {
+				return fmt.Errorf("%sobject %s, part %s: file or generator is required", prefix, obj.Name, part.Name)
+			}
+			if len(part.Params) > 0 {
+				return fmt.Errorf("%sobject %s, part %s: params require a generator", prefix, obj.Name, part.Name)
+			}
+
+			// Check if file exists (handle relative paths)
+			filePath := part.File
+			if !filepath.IsAbs(filePath) {
+				filePath = filepath.Join(configDir, filePath)
+			}
+
+			if _, err := os.Stat(filePath); err != nil {
+				return fmt.Errorf("%sobject %s, part %s: file not found: %s", prefix, obj.Name, part.Name, part.File)
+			}
+			if err := validatePartType(part); err != nil {
+				return fmt.Errorf("%sobject %s, part %s: %w", prefix, obj.Name, part.Name, err)
+			}
+		}
+
+		// 2D outlines become parts by extrusion
+		if preconditions.IsOutlineFile(part.File) {
+			if part.ExtrudeHeight <= 0 {
+				return fmt.Errorf("%sobject %s, part %s: extrude_height must be positive for %s files", prefix, obj.Name, part.Name, filepath.Ext(part.File))
+			}
+		} else if part.ExtrudeHeight != 0 {
+			return fmt.Errorf("%sobject %s, part %s: extrude_height is only supported for .svg and .dxf files", prefix, obj.Name, part.Name)
+		}
+
+		if err := validateQuality(part.Quality); err != nil {
+			return fmt.Errorf("%sobject %s, part %s: quality: %w", prefix, obj.Name, part.Name, err)
 		}
 
-		// Check if file exists (handle relative paths)
-		filePath := part.File
-		if !filepath.IsAbs(filePath) {
-			filePath = filepath.Join(configDir, filePath)
+		if err := validateDirectories(part, configDir); err != nil {
+			return fmt.Errorf("%sobject %s, part %s: %w", prefix, obj.Name, part.Name, err)
 		}
 
-		if _, err := os.Stat(filePath); err != nil {
-			return fmt.Errorf("%sobject %s, part %s: file not found: %s", prefix, obj.Name, part.Name, part.File)
+		if _, err := models.ParseAnchor(part.Anchor); err != nil {
+			return fmt.Errorf("%sobject %s, part %s: anchor: %w", prefix, obj.Name, part.Name, err)
+		}
+
+		if _, err := models.ParseObjectType(part.Type); err != nil {
+			return fmt.Errorf("%sobject %s, part %s: type: %w", prefix, obj.Name, part.Name, err)
 		}
 
 		// Validate filament slot
-		if part.Filament < 0 || part.Filament > 4 {
-			return fmt.Errorf("%sobject %s, part %s: filament must be 0-4 (0=auto, 1-4=AMS slots)", prefix, obj.Name, part.Name)
+		if part.Filament < 0 || part.Filament > filamentSlots {
+			return fmt.Errorf("%sobject %s, part %s: filament must be 0-%d (0=auto, 1-%d=filament slots of the printer)", prefix, obj.Name, part.Name, filamentSlots, filamentSlots)
 		}
 	}
 
+	current = ""
+	if err := validateAlignments(obj.Parts); err != nil {
+		return fmt.Errorf("%sobject %s, %w", prefix, obj.Name, err)
+	}
+
 	return nil
 }
 
+// validatePartType checks that the file of a part has a supported type and that
+// STL and 3MF parts, which are used as they are, have no OpenSCAD settings
+func validatePartType(part models.YamlPart) error {
+	switch {
+	case preconditions.IsScadFile(part.File) || preconditions.IsOutlineFile(part.File):
+		return nil
+	case preconditions.IsSTLFile(part.File) || preconditions.Is3MFFile(part.File):
+		if len(part.Config) > 0 || part.Quality != nil || part.Workdir != "" || len(part.LibraryPaths) > 0 {
+			return fmt.Errorf("config, quality, workdir and library_paths are only supported for parts rendered with OpenSCAD, %s is used as it is", filepath.Base(part.File))
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported file type: %s (supported: .scad, .stl, .3mf, .svg, .dxf)", part.File)
+	}
+}
+
+// validateDirectories checks that the working directory and the library paths of a part exist
+func validateDirectories(part models.YamlPart, configDir string) error {
+	for _, dir := range append([]string{part.Workdir}, part.LibraryPaths...) {
+		if dir == "" {
+			continue
+		}
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(configDir, dir)
+		}
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			return fmt.Errorf("directory not found: %s", dir)
+		}
+	}
+	return nil
+}
+
+// validateQuality checks that the OpenSCAD resolution variables are not negative
+func validateQuality(quality *models.Quality) error {
+	if quality == nil {
+		return nil
+	}
+	if quality.Fn < 0 || quality.Fa < 0 || quality.Fs < 0 {
+		return fmt.Errorf("fn, fa and fs must not be negative")
+	}
+	return nil
+}
+
+// ValidateRenderWorkers checks that render workers are SSH destinations
+func ValidateRenderWorkers(workers []string) error {
+	for _, worker := range workers {
+		lower := strings.ToLower(worker)
+		switch {
+		case strings.TrimSpace(worker) == "":
+			return fmt.Errorf("worker must not be empty")
+		case strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://"):
+			return fmt.Errorf("%s: HTTP workers are not supported, use an SSH destination such as user@host", worker)
+		case strings.HasPrefix(worker, "-"):
+			return fmt.Errorf("%s: invalid SSH destination", worker)
+		}
+	}
+	return nil
+}
+
+// validateAlignments checks that parts are aligned with other existing parts of the
+// object and that the alignments do not form a cycle
+func validateAlignments(parts []models.YamlPart) error {
+	targets := make(map[string]string, len(parts)) // part name -> part it is aligned with
+	for _, part := range parts {
+		targets[part.Name] = ""
+	}
+	for _, part := range parts {
+		align := part.Align
+		if align == nil {
+			continue
+		}
+		if align.OnTopOf == "" {
+			return fmt.Errorf("part %s: align: on_top_of is required", part.Name)
+		}
+		if align.OnTopOf == part.Name {
+			return fmt.Errorf("part %s: align: a part cannot be aligned with itself", part.Name)
+		}
+		if _, ok := targets[align.OnTopOf]; !ok {
+			return fmt.Errorf("part %s: align: unknown part %q", part.Name, align.OnTopOf)
+		}
+		if align.Gap < 0 {
+			return fmt.Errorf("part %s: align: gap must not be negative", part.Name)
+		}
+		if anchor, _ := models.ParseAnchor(part.Anchor); anchor == models.AnchorBed {
+			return fmt.Errorf("part %s: align cannot be combined with anchor: bed", part.Name)
+		}
+		targets[part.Name] = align.OnTopOf
+	}
+
+	for _, part := range parts {
+		seen := map[string]bool{}
+		for name := part.Name; name != ""; name = targets[name] {
+			if seen[name] {
+				return fmt.Errorf("part %s: align: constraints form a cycle", part.Name)
+			}
+			seen[name] = true
+		}
+	}
+	return nil
+}
+
+// partAnchor returns the validated anchor of a part
+func partAnchor(part models.YamlPart) models.Anchor {
+	anchor, _ := models.ParseAnchor(part.Anchor)
+	return anchor
+}
+
+// partType returns the validated object type of a part
+func partType(part models.YamlPart) models.ObjectType {
+	objectType, _ := models.ParseObjectType(part.Type)
+	return objectType
+}
+
 // convertMapToScadFunctions converts a map of key-value pairs to SCAD function definitions
 // Example: {"h": 6, "width": 38} -> "function get_h() = 6;\nfunction get_width() = 38;\n"
 func convertMapToScadFunctions(configMap map[string]interface{}) string {
@@ -252,16 +679,26 @@ func (l *Loader) ConvertToScadFiles(config *models.YamlConfig) []models.ScadFile
 				}
 
 				scadFiles = append(scadFiles, models.ScadFile{
-					Path:         part.File,
-					Name:         compositeName,
-					FilamentSlot: part.Filament,
-					ConfigFiles:  configFiles,
-					RotationX:    part.RotationX,
-					RotationY:    part.RotationY,
-					RotationZ:    part.RotationZ,
-					PositionX:    part.PositionX,
-					PositionY:    part.PositionY,
-					PositionZ:    part.PositionZ,
+					Path:          part.File,
+					Name:          compositeName,
+					Group:         objName,
+					FilamentSlot:  part.Filament,
+					ConfigFiles:   configFiles,
+					RotationX:     part.RotationX,
+					RotationY:     part.RotationY,
+					RotationZ:     part.RotationZ,
+					PositionX:     part.PositionX,
+					PositionY:     part.PositionY,
+					PositionZ:     part.PositionZ,
+					Anchor:        partAnchor(part),
+					Type:          partType(part),
+					Align:         part.Align,
+					Generator:     part.Generator,
+					Params:        part.Params,
+					ExtrudeHeight: part.ExtrudeHeight,
+					Quality:       partQuality(config, part),
+					WorkDir:       part.Workdir,
+					LibraryPaths:  part.LibraryPaths,
 				})
 			}
 		}
@@ -274,9 +711,10 @@ func (l *Loader) ConvertToScadFiles(config *models.YamlConfig) []models.ScadFile
 func (l *Loader) ConvertToObjectGroups(config *models.YamlConfig) []models.ObjectGroup {
 	var objectGroups []models.ObjectGroup
 
+	normalizeByDefault := normalizesByDefault(config)
 	for _, obj := range config.Objects {
-		// Default normalize_position to true if not specified
-		normalizePosition := true
+		// Default normalize_position to the global normalization if not specified
+		normalizePosition := normalizeByDefault
 		if obj.NormalizePosition != nil {
 			normalizePosition = *obj.NormalizePosition
 		}
@@ -320,16 +758,26 @@ func (l *Loader) ConvertToObjectGroups(config *models.YamlConfig) []models.Objec
 				}
 
 				parts = append(parts, models.ScadFile{
-					Path:         part.File,
-					Name:         compositeName,
-					FilamentSlot: part.Filament,
-					ConfigFiles:  configFiles,
-					RotationX:    part.RotationX,
-					RotationY:    part.RotationY,
-					RotationZ:    part.RotationZ,
-					PositionX:    part.PositionX,
-					PositionY:    part.PositionY,
-					PositionZ:    part.PositionZ,
+					Path:          part.File,
+					Name:          compositeName,
+					Group:         objName,
+					FilamentSlot:  part.Filament,
+					ConfigFiles:   configFiles,
+					RotationX:     part.RotationX,
+					RotationY:     part.RotationY,
+					RotationZ:     part.RotationZ,
+					PositionX:     part.PositionX,
+					PositionY:     part.PositionY,
+					PositionZ:     part.PositionZ,
+					Anchor:        partAnchor(part),
+					Type:          partType(part),
+					Align:         part.Align,
+					Generator:     part.Generator,
+					Params:        part.Params,
+					ExtrudeHeight: part.ExtrudeHeight,
+					Quality:       partQuality(config, part),
+					WorkDir:       part.Workdir,
+					LibraryPaths:  part.LibraryPaths,
 				})
 			}
 
@@ -337,6 +785,8 @@ func (l *Loader) ConvertToObjectGroups(config *models.YamlConfig) []models.Objec
 				Name:              objName,
 				Parts:             parts,
 				NormalizePosition: normalizePosition,
+				Margin:            obj.Margin,
+				Slicer:            obj.SlicerOptions(),
 			})
 		}
 	}
@@ -359,7 +809,7 @@ func (l *Loader) ConvertToPlateGroups(config *models.YamlConfig) []models.PlateG
 
 			var objectGroups []models.ObjectGroup
 			for _, obj := range plate.Objects {
-				objectGroups = append(objectGroups, l.convertYamlObjectToGroups(obj)...)
+				objectGroups = append(objectGroups, l.convertYamlObjectToGroups(obj, config)...)
 			}
 
 			plateGroups = append(plateGroups, models.PlateGroup{
@@ -378,12 +828,27 @@ func (l *Loader) ConvertToPlateGroups(config *models.YamlConfig) []models.PlateG
 	return plateGroups
 }
 
+// partQuality returns the OpenSCAD resolution of a part: its own quality over the
+// default of the config. STL and 3MF parts are not rendered and have none.
+func partQuality(config *models.YamlConfig, part models.YamlPart) models.Quality {
+	if preconditions.IsSTLFile(part.File) || preconditions.Is3MFFile(part.File) {
+		return models.Quality{}
+	}
+	return models.Quality{}.Override(config.Quality).Override(part.Quality)
+}
+
+// normalizesByDefault reports whether objects without normalize_position are moved onto the build plate
+func normalizesByDefault(config *models.YamlConfig) bool {
+	normalization, err := models.ParseNormalization(config.Normalize)
+	return err != nil || normalization == models.NormalizationGround
+}
+
 // convertYamlObjectToGroups converts a single YamlObject to ObjectGroups (handling count)
-func (l *Loader) convertYamlObjectToGroups(obj models.YamlObject) []models.ObjectGroup {
+func (l *Loader) convertYamlObjectToGroups(obj models.YamlObject, config *models.YamlConfig) []models.ObjectGroup {
 	var objectGroups []models.ObjectGroup
 
-	// Default normalize_position to true if not specified
-	normalizePosition := true
+	// Default normalize_position to the global normalization if not specified
+	normalizePosition := normalizesByDefault(config)
 	if obj.NormalizePosition != nil {
 		normalizePosition = *obj.NormalizePosition
 	}
@@ -427,16 +892,26 @@ func (l *Loader) convertYamlObjectToGroups(obj models.YamlObject) []models.Objec
 			}
 
 			parts = append(parts, models.ScadFile{
-				Path:         part.File,
-				Name:         compositeName,
-				FilamentSlot: part.Filament,
-				ConfigFiles:  configFiles,
-				RotationX:    part.RotationX,
-				RotationY:    part.RotationY,
-				RotationZ:    part.RotationZ,
-				PositionX:    part.PositionX,
-				PositionY:    part.PositionY,
-				PositionZ:    part.PositionZ,
+				Path:          part.File,
+				Name:          compositeName,
+				Group:         objName,
+				FilamentSlot:  part.Filament,
+				ConfigFiles:   configFiles,
+				RotationX:     part.RotationX,
+				RotationY:     part.RotationY,
+				RotationZ:     part.RotationZ,
+				PositionX:     part.PositionX,
+				PositionY:     part.PositionY,
+				PositionZ:     part.PositionZ,
+				Anchor:        partAnchor(part),
+				Type:          partType(part),
+				Align:         part.Align,
+				Generator:     part.Generator,
+				Params:        part.Params,
+				ExtrudeHeight: part.ExtrudeHeight,
+				Quality:       partQuality(config, part),
+				WorkDir:       part.Workdir,
+				LibraryPaths:  part.LibraryPaths,
 			})
 		}
 
@@ -444,6 +919,8 @@ func (l *Loader) convertYamlObjectToGroups(obj models.YamlObject) []models.Objec
 			Name:              objName,
 			Parts:             parts,
 			NormalizePosition: normalizePosition,
+			Margin:            obj.Margin,
+			Slicer:            obj.SlicerOptions(),
 		})
 	}
 
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -292,3 +294,543 @@ func TestConvertToScadFiles_PartOverridesObject(t *testing.T) {
 		t.Errorf("Object-level config should be overridden, got: %s", content)
 	}
 }
+
+// TestValidate_Packing tests validation of the packing settings
+func TestValidate_Packing(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(filepath.Join(dir, "part.stl"), []byte("solid part\nendsolid part\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		distance  float64
+		algorithm string
+		order     string
+		seq       *models.SequentialPrint
+		grid      float64
+		footprint string
+		origin    string
+		renderer  string
+		workers   []string
+		jobs      int
+		filaments []models.YamlFilament
+		materials map[string]float64
+		outputs   []models.YamlOutput
+		thumbs    *models.YamlThumbnails
+		settings  string
+		precision int
+		margin    float64
+		minUtil   float64
+		maxUtil   float64
+		wantErr   string
+	}{
+		{name: "defaults", distance: 0, algorithm: ""},
+		{name: "compact", distance: 5, algorithm: "Compact"},
+		{name: "negative distance", distance: -1, wantErr: "packing_distance must not be negative"},
+		{name: "unknown algorithm", algorithm: "tight", wantErr: `unknown packing algorithm "tight"`},
+		{name: "object margin", margin: 4},
+		{name: "negative object margin", margin: -2, wantErr: "object obj: margin must not be negative"},
+		{name: "by height", order: "by_height"},
+		{name: "unknown order", order: "tallest", wantErr: `unknown packing order "tallest"`},
+		{name: "sequential", seq: &models.SequentialPrint{ClearanceX: 35, ClearanceY: 30, GantryHeight: 25}},
+		{name: "placement grid", grid: 5},
+		{name: "negative placement grid", grid: -5, wantErr: "placement_grid must not be negative"},
+		{name: "hull footprint", footprint: "hull"},
+		{name: "unknown footprint", footprint: "outline", wantErr: `unknown footprint "outline"`},
+		{name: "back packing origin", origin: "back"},
+		{name: "unknown packing origin", origin: "top", wantErr: `packing_origin: unknown packing origin "top"`},
+		{name: "docker renderer", renderer: "docker"},
+		{name: "unknown renderer", renderer: "podman", wantErr: `unknown renderer "podman"`},
+		{name: "render workers", workers: []string{"builder@farm1", "farm2"}},
+		{name: "HTTP render worker", workers: []string{"http://farm:8080"}, wantErr: "HTTP workers are not supported"},
+		{name: "render jobs", jobs: 2},
+		{name: "negative render jobs", jobs: -1, wantErr: "render_jobs must not be negative"},
+		{name: "filament colors", filaments: []models.YamlFilament{{Type: "PLA", Color: "#FF0000"}, {}}},
+		{name: "invalid filament color", filaments: []models.YamlFilament{{Color: "red"}}, wantErr: `filaments: filament 1: color "red"`},
+		{name: "materials", materials: map[string]float64{"PETG-CF": 1.3}},
+		{name: "outputs", outputs: []models.YamlOutput{{Path: "plain.3mf", Format: "3mf"}, {Path: "stl", Format: "STL"}, {Path: "parts", Format: "objects"}, {Path: "copy.3mf"}}},
+		{name: "output without path", outputs: []models.YamlOutput{{Format: "stl"}}, wantErr: "outputs: output 1: path is required"},
+		{name: "unknown output format", outputs: []models.YamlOutput{{Path: "a.obj", Format: "obj"}}, wantErr: `outputs: a.obj: unknown output format "obj"`},
+		{name: "thumbnails", thumbs: &models.YamlThumbnails{Dir: "thumbs", Size: 128}},
+		{name: "thumbnails without target", thumbs: &models.YamlThumbnails{Size: 128}, wantErr: "thumbnails: dir or embed is required"},
+		{name: "thumbnail size", thumbs: &models.YamlThumbnails{Embed: true, Size: 4096}, wantErr: "size must be between 16 and 2048 pixels"},
+		{name: "settings from", settings: "part.stl"},
+		{name: "missing settings file", settings: "template.3mf", wantErr: "settings_from: file not found: template.3mf"},
+		{name: "invalid material density", materials: map[string]float64{"Wood": 0}, wantErr: `materials: "Wood": density must be a positive value`},
+		{name: "precision", precision: 4},
+		{name: "precision too high", precision: 12, wantErr: "precision must be between 1 and 9 decimals"},
+		{name: "utilization limits", minUtil: 40, maxUtil: 90},
+		{name: "utilization above 100", maxUtil: 120, wantErr: "limits must be between 0 and 100 percent"},
+		{name: "minimum above maximum", minUtil: 80, maxUtil: 60, wantErr: "the minimum must not be greater than the maximum"},
+		{name: "negative clearance", seq: &models.SequentialPrint{ClearanceX: -1}, wantErr: "clearance_x and clearance_y must not be negative"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &models.YamlConfig{
+				Output:           "out.3mf",
+				PackingDistance:  tt.distance,
+				PackingAlgorithm: tt.algorithm,
+				PackingOrder:     tt.order,
+				Sequential:       tt.seq,
+				PlacementGrid:    tt.grid,
+				Footprint:        tt.footprint,
+				PackingOrigin:    tt.origin,
+				Renderer:         tt.renderer,
+				RenderWorkers:    tt.workers,
+				RenderJobs:       tt.jobs,
+				Filaments:        tt.filaments,
+				Materials:        tt.materials,
+				Outputs:          tt.outputs,
+				Thumbnails:       tt.thumbs,
+				SettingsFrom:     tt.settings,
+				Precision:        tt.precision,
+				MinUtilization:   tt.minUtil,
+				MaxUtilization:   tt.maxUtil,
+				Objects: []models.YamlObject{
+					{Name: "obj", Margin: tt.margin, Parts: []models.YamlPart{{Name: "part", File: "part.stl"}}},
+				},
+			}
+
+			err := NewLoader().Validate(config, configPath)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestValidate_Printer(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(filepath.Join(dir, "part.stl"), []byte("solid part\nendsolid part\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	voron := map[string]models.PrinterProfile{"Voron 350": {Width: 350, Depth: 350, Height: 340, FilamentSlots: 8}}
+
+	tests := []struct {
+		name     string
+		printer  string
+		printers map[string]models.PrinterProfile
+		filament int
+		wantErr  string
+	}{
+		{name: "default", filament: 4},
+		{name: "preset alias", printer: "A1 mini", filament: 2},
+		{name: "unknown printer", printer: "ender3", wantErr: `printer: unknown printer "ender3"`},
+		{name: "single slot printer", printer: "mk4", filament: 2, wantErr: "filament must be 0-1"},
+		{name: "too many slots", filament: 5, wantErr: "filament must be 0-4"},
+		{name: "custom printer", printer: "voron-350", printers: voron, filament: 8},
+		{name: "invalid custom printer", printers: map[string]models.PrinterProfile{"small": {Width: 100}}, wantErr: "printers: small: width and depth must be positive"},
+		{name: "invalid exclusion zone", printers: map[string]models.PrinterProfile{"small": {Width: 100, Depth: 100, ExclusionZones: []models.ExclusionZone{{Width: 10}}}}, wantErr: "exclusion zone 1: width and depth must be positive"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &models.YamlConfig{
+				Output:   "out.3mf",
+				Printer:  tt.printer,
+				Printers: tt.printers,
+				Objects: []models.YamlObject{
+					{Name: "obj", Parts: []models.YamlPart{{Name: "part", File: "part.stl", Filament: tt.filament}}},
+				},
+			}
+
+			err := NewLoader().Validate(config, configPath)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestConvertToObjectGroups_Normalize(t *testing.T) {
+	enabled := true
+	tests := []struct {
+		name      string
+		normalize string
+		want      map[string]bool
+	}{
+		{name: "default", want: map[string]bool{"auto": true, "explicit": true}},
+		{name: "disabled", normalize: "false", want: map[string]bool{"auto": false, "explicit": true}},
+		{name: "preserve", normalize: "preserve", want: map[string]bool{"auto": false, "explicit": true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &models.YamlConfig{
+				Normalize: tt.normalize,
+				Objects: []models.YamlObject{
+					{Name: "auto", Parts: []models.YamlPart{{Name: "a", File: "a.stl"}}},
+					{Name: "explicit", NormalizePosition: &enabled, Parts: []models.YamlPart{{Name: "b", File: "b.stl"}}},
+				},
+			}
+
+			for _, group := range NewLoader().ConvertToObjectGroups(config) {
+				if group.NormalizePosition != tt.want[group.Name] {
+					t.Errorf("%s: expected normalize position %v, got %v", group.Name, tt.want[group.Name], group.NormalizePosition)
+				}
+			}
+		})
+	}
+}
+
+func TestValidate_Anchor(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(filepath.Join(dir, "part.stl"), []byte("solid part\nendsolid part\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		anchor  string
+		want    models.Anchor
+		wantErr string
+	}{
+		{anchor: "", want: models.AnchorRelative},
+		{anchor: "relative", want: models.AnchorRelative},
+		{anchor: "Bed", want: models.AnchorBed},
+		{anchor: "top", wantErr: `anchor: unknown anchor "top"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.anchor, func(t *testing.T) {
+			config := &models.YamlConfig{
+				Output: "out.3mf",
+				Objects: []models.YamlObject{
+					{Name: "obj", Parts: []models.YamlPart{{Name: "part", File: "part.stl", PositionZ: 20, Anchor: tt.anchor}}},
+				},
+			}
+
+			loader := NewLoader()
+			err := loader.Validate(config, configPath)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := loader.ConvertToObjectGroups(config)[0].Parts[0].Anchor; got != tt.want {
+				t.Errorf("expected anchor %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestValidate_ObjectType(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(filepath.Join(dir, "part.stl"), []byte("solid part\nendsolid part\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		objectType string
+		want       models.ObjectType
+		wantErr    string
+	}{
+		{objectType: "", want: ""},
+		{objectType: "Support", want: models.ObjectTypeSupport},
+		{objectType: "other", want: models.ObjectTypeOther},
+		{objectType: "modifier", wantErr: `type: unknown object type "modifier"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.objectType, func(t *testing.T) {
+			config := &models.YamlConfig{
+				Output: "out.3mf",
+				Objects: []models.YamlObject{
+					{Name: "obj", Parts: []models.YamlPart{{Name: "part", File: "part.stl", Type: tt.objectType}}},
+				},
+			}
+
+			loader := NewLoader()
+			err := loader.Validate(config, configPath)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := loader.ConvertToObjectGroups(config)[0].Parts[0].Type; got != tt.want {
+				t.Errorf("expected type %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestValidate_ExtrudeHeight(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.yaml")
+	for _, name := range []string{"logo.svg", "gasket.DXF", "part.stl"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		file    string
+		height  float64
+		wantErr string
+	}{
+		{name: "svg", file: "logo.svg", height: 0.6},
+		{name: "dxf", file: "gasket.DXF", height: 2},
+		{name: "svg without height", file: "logo.svg", wantErr: "extrude_height must be positive for .svg files"},
+		{name: "negative height", file: "gasket.DXF", height: -1, wantErr: "extrude_height must be positive"},
+		{name: "height for mesh", file: "part.stl", height: 1, wantErr: "extrude_height is only supported for .svg and .dxf files"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &models.YamlConfig{
+				Output: "out.3mf",
+				Objects: []models.YamlObject{
+					{Name: "obj", Parts: []models.YamlPart{{Name: "part", File: tt.file, ExtrudeHeight: tt.height}}},
+				},
+			}
+
+			err := NewLoader().Validate(config, configPath)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestConvertToObjectGroups_Quality(t *testing.T) {
+	config := &models.YamlConfig{
+		Quality: &models.Quality{Fn: 32, Fs: 0.5},
+		Objects: []models.YamlObject{
+			{Name: "obj", Parts: []models.YamlPart{
+				{Name: "default", File: "a.scad"},
+				{Name: "fine", File: "b.scad", Quality: &models.Quality{Fn: 128, Fa: 2}},
+			}},
+		},
+	}
+
+	parts := NewLoader().ConvertToObjectGroups(config)[0].Parts
+	if want := (models.Quality{Fn: 32, Fs: 0.5}); parts[0].Quality != want {
+		t.Errorf("expected default quality %+v, got %+v", want, parts[0].Quality)
+	}
+	if want := (models.Quality{Fn: 128, Fa: 2, Fs: 0.5}); parts[1].Quality != want {
+		t.Errorf("expected part quality %+v, got %+v", want, parts[1].Quality)
+	}
+
+	dir := t.TempDir()
+	for _, name := range []string{"a.scad", "b.scad"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("cube(1);"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	config.Output = "out.3mf"
+	config.Objects[0].Parts[1].Quality.Fs = -1
+	if err := NewLoader().Validate(config, filepath.Join(dir, "config.yaml")); err == nil || !strings.Contains(err.Error(), "quality: fn, fa and fs must not be negative") {
+		t.Errorf("expected quality error, got %v", err)
+	}
+}
+
+func TestLoad_OptionalParts(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "body.scad"), []byte("cube(1);"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	configPath := filepath.Join(dir, "config.yaml")
+	data := `
+output: out.3mf
+objects:
+  - name: Box
+    parts:
+      - name: body
+        file: body.scad
+      - name: insert
+        file: accessories/insert.scad
+        optional: true
+  - name: Accessory
+    parts:
+      - name: clip
+        file: accessories/clip.scad
+        optional: true
+`
+	if err := os.WriteFile(configPath, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	loader := NewLoader()
+	config, err := loader.Load(configPath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(config.Objects) != 1 || len(config.Objects[0].Parts) != 1 || config.Objects[0].Parts[0].Name != "body" {
+		t.Errorf("expected only Box/body to remain, got %+v", config.Objects)
+	}
+	if len(loader.Warnings()) != 2 {
+		t.Errorf("expected 2 warnings, got %v", loader.Warnings())
+	}
+
+	// Parts that are not optional still fail validation
+	data = strings.Replace(data, "        optional: true\n  - name: Accessory", "  - name: Accessory", 1)
+	if err := os.WriteFile(configPath, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loader.Load(configPath); err == nil || !strings.Contains(err.Error(), "file not found: accessories/insert.scad") {
+		t.Errorf("expected file not found error, got %v", err)
+	}
+}
+
+func TestLoad_PartDirectories(t *testing.T) {
+	dir := t.TempDir()
+	for _, sub := range []string{"src", "lib"} {
+		if err := os.Mkdir(filepath.Join(dir, sub), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range []string{"src/body.scad", "part.stl"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("cube(1);"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	configPath := filepath.Join(dir, "config.yaml")
+
+	tests := []struct {
+		name    string
+		part    string
+		wantErr string
+	}{
+		{name: "valid", part: "{name: body, file: src/body.scad, workdir: src, library_paths: [lib]}"},
+		{name: "missing library", part: "{name: body, file: src/body.scad, library_paths: [missing]}", wantErr: "directory not found"},
+		{name: "workdir is a file", part: "{name: body, file: src/body.scad, workdir: part.stl}", wantErr: "directory not found"},
+		{name: "mesh part", part: "{name: body, file: part.stl, library_paths: [lib]}", wantErr: "only supported for parts rendered with OpenSCAD"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := "output: out.3mf\nobjects:\n  - name: Box\n    parts:\n      - " + tt.part + "\n"
+			if err := os.WriteFile(configPath, []byte(data), 0644); err != nil {
+				t.Fatal(err)
+			}
+			config, err := NewLoader().Load(configPath)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			part := NewLoader().ConvertToObjectGroups(config)[0].Parts[0]
+			if part.WorkDir != filepath.Join(dir, "src") {
+				t.Errorf("WorkDir = %q, want %q", part.WorkDir, filepath.Join(dir, "src"))
+			}
+			if len(part.LibraryPaths) != 1 || part.LibraryPaths[0] != filepath.Join(dir, "lib") {
+				t.Errorf("LibraryPaths = %q, want [%q]", part.LibraryPaths, filepath.Join(dir, "lib"))
+			}
+		})
+	}
+}
+
+func TestLoad_PartTypes(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"body.scad", "insert.stl", "label.3mf", "model.obj"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("cube(1);"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	configPath := filepath.Join(dir, "config.yaml")
+
+	tests := []struct {
+		name    string
+		parts   string
+		wantErr string
+	}{
+		{name: "mixed sources", parts: "[{name: body, file: body.scad}, {name: insert, file: insert.stl}, {name: label, file: label.3mf}]"},
+		{name: "unsupported type", parts: "[{name: model, file: model.obj}]", wantErr: "unsupported file type"},
+		{name: "quality of a mesh", parts: "[{name: insert, file: insert.stl, quality: {fn: 64}}]", wantErr: "only supported for parts rendered with OpenSCAD"},
+		{name: "config of a 3MF", parts: "[{name: label, file: label.3mf, config: [{cfg.scad: {size: 1}}]}]", wantErr: "only supported for parts rendered with OpenSCAD"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := "output: out.3mf\nquality: {fn: 32}\nobjects:\n  - name: Box\n    parts: " + tt.parts + "\n"
+			if err := os.WriteFile(configPath, []byte(data), 0644); err != nil {
+				t.Fatal(err)
+			}
+			config, err := NewLoader().Load(configPath)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			// Only SCAD parts are rendered with the quality of the config
+			for _, part := range NewLoader().ConvertToScadFiles(config) {
+				rendered := filepath.Ext(part.Path) == ".scad"
+				if (part.Quality.Fn == 32) != rendered {
+					t.Errorf("%s: unexpected quality %+v", part.Name, part.Quality)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateAlignments(t *testing.T) {
+	onTopOf := func(name string) *models.PartAlign { return &models.PartAlign{OnTopOf: name} }
+
+	tests := []struct {
+		name    string
+		parts   []models.YamlPart
+		wantErr string
+	}{
+		{name: "stack", parts: []models.YamlPart{{Name: "base"}, {Name: "middle", Align: onTopOf("base")}, {Name: "lid", Align: onTopOf("middle")}}},
+		{name: "unknown part", parts: []models.YamlPart{{Name: "lid", Align: onTopOf("base")}}, wantErr: `part lid: align: unknown part "base"`},
+		{name: "itself", parts: []models.YamlPart{{Name: "lid", Align: onTopOf("lid")}}, wantErr: "cannot be aligned with itself"},
+		{name: "missing target", parts: []models.YamlPart{{Name: "lid", Align: &models.PartAlign{Centered: true}}}, wantErr: "on_top_of is required"},
+		{name: "cycle", parts: []models.YamlPart{{Name: "a", Align: onTopOf("b")}, {Name: "b", Align: onTopOf("a")}}, wantErr: "constraints form a cycle"},
+		{name: "bed anchor", parts: []models.YamlPart{{Name: "base"}, {Name: "lid", Align: onTopOf("base"), Anchor: "bed"}}, wantErr: "cannot be combined with anchor: bed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateAlignments(tt.parts)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/philipparndt/go3mf/internal/models"
+)
+
+// ExplodeObjects replaces the objects with explode set, and the objects named in
+// names, by one object per part. The new objects are named <object>_<part>, keep
+// the settings of their object and are packed on their own, so the positions and
+// alignments of the parts relative to each other are dropped.
+func ExplodeObjects(config *models.YamlConfig, names []string) error {
+	requested := make(map[string]bool, len(names))
+	for _, name := range names {
+		requested[name] = true
+	}
+	found := make(map[string]bool, len(names))
+	explode := func(objects []models.YamlObject) []models.YamlObject {
+		var result []models.YamlObject
+		for _, obj := range objects {
+			if !obj.Explode && !requested[obj.Name] {
+				result = append(result, obj)
+				continue
+			}
+			found[obj.Name] = true
+			result = append(result, explodeObject(obj)...)
+		}
+		return result
+	}
+
+	config.Objects = explode(config.Objects)
+	for i := range config.Plates {
+		config.Plates[i].Objects = explode(config.Plates[i].Objects)
+	}
+
+	var unknown []string
+	for _, name := range names {
+		if !found[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("explode: unknown object(s): %s", strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// explodeObject returns an object for each part of obj
+func explodeObject(obj models.YamlObject) []models.YamlObject {
+	objects := make([]models.YamlObject, 0, len(obj.Parts))
+	for _, part := range obj.Parts {
+		single := obj
+		single.Name = obj.Name + "_" + part.Name
+		single.Explode = false
+		part.PositionX, part.PositionY, part.PositionZ = 0, 0, 0
+		part.Align = nil
+		single.Parts = []models.YamlPart{part}
+		objects = append(objects, single)
+	}
+	return objects
+}
@@ -0,0 +1,91 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/philipparndt/go3mf/internal/models"
+	"gopkg.in/yaml.v3"
+)
+
+func TestExplodeObjects(t *testing.T) {
+	data := `
+output: out.3mf
+objects:
+  - name: Box
+    count: 2
+    margin: 3
+    parts:
+      - name: body
+        file: body.scad
+        filament: 1
+      - name: lid
+        file: lid.scad
+        filament: 2
+        position_z: 20
+        align:
+          on_top_of: body
+  - name: Stand
+    explode: true
+    parts:
+      - name: base
+        file: base.scad
+      - name: pole
+        file: pole.scad
+        rotation_x: 90
+  - name: Clip
+    parts:
+      - name: clip
+        file: clip.scad
+`
+	var config models.YamlConfig
+	if err := yaml.Unmarshal([]byte(data), &config); err != nil {
+		t.Fatal(err)
+	}
+	if err := ExplodeObjects(&config, []string{"Box"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var names []string
+	for _, obj := range config.Objects {
+		names = append(names, obj.Name)
+	}
+	if got := strings.Join(names, ", "); got != "Box_body, Box_lid, Stand_base, Stand_pole, Clip" {
+		t.Fatalf("unexpected objects %s", got)
+	}
+
+	lid := config.Objects[1]
+	if lid.Count != 2 || lid.Margin != 3 || len(lid.Parts) != 1 || lid.Parts[0].Filament != 2 {
+		t.Errorf("lid did not keep the settings of its object: %+v", lid)
+	}
+	if lid.Parts[0].PositionZ != 0 || lid.Parts[0].Align != nil {
+		t.Errorf("lid kept its placement relative to the body: %+v", lid.Parts[0])
+	}
+	pole := config.Objects[3]
+	if pole.Explode || pole.Parts[0].RotationX != 90 {
+		t.Errorf("unexpected pole %+v", pole)
+	}
+	if len(config.Objects[4].Parts) != 1 || config.Objects[4].Parts[0].Name != "clip" {
+		t.Errorf("clip changed: %+v", config.Objects[4])
+	}
+}
+
+func TestExplodeObjectsOnPlates(t *testing.T) {
+	config := &models.YamlConfig{Plates: []models.YamlPlate{{Objects: []models.YamlObject{
+		{Name: "Box", Parts: []models.YamlPart{{Name: "body"}, {Name: "lid"}}},
+	}}}}
+	if err := ExplodeObjects(config, []string{"Box"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if objects := config.Plates[0].Objects; len(objects) != 2 || objects[0].Name != "Box_body" || objects[1].Name != "Box_lid" {
+		t.Errorf("unexpected plate objects %+v", objects)
+	}
+}
+
+func TestExplodeUnknownObject(t *testing.T) {
+	config := &models.YamlConfig{Objects: []models.YamlObject{{Name: "Box", Parts: []models.YamlPart{{Name: "body"}}}}}
+	err := ExplodeObjects(config, []string{"Lid", "Box", "Base"})
+	if err == nil || err.Error() != "explode: unknown object(s): Base, Lid" {
+		t.Errorf("expected unknown object error, got %v", err)
+	}
+}
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+
+	"github.com/philipparndt/go3mf/internal/models"
+)
+
+// lintConfig checks a valid configuration for settings that are allowed but most
+// likely mistakes and returns a warning with the location of each of them
+func lintConfig(config *models.YamlConfig, printer models.PrinterProfile) []string {
+	var warnings []string
+	if printer.FilamentSlots == 1 && len(config.Filaments) > 1 {
+		warnings = append(warnings, fmt.Sprintf("filaments: %d filaments are configured, but the printer has a single filament slot", len(config.Filaments)))
+	}
+	for i, filament := range config.Filaments {
+		if filament.Type == "" {
+			continue
+		}
+		if _, ok := models.LookupDensity(filament.Type, config.Materials); !ok {
+			warnings = append(warnings, fmt.Sprintf("filament %d: the density of %s is unknown, weights are estimated for %s (add it to materials)", i+1, filament.Type, models.DefaultMaterial))
+		}
+	}
+
+	lintObjects := func(objects []models.YamlObject, prefix string) {
+		for _, obj := range objects {
+			for i, part := range obj.Parts {
+				location := fmt.Sprintf("%sobject %s, part %s", prefix, obj.Name, part.Name)
+				warnings = append(warnings, lintPart(part, location, config, printer)...)
+				for _, other := range obj.Parts[:i] {
+					if sameGeometry(part, other) {
+						warnings = append(warnings, fmt.Sprintf("%s: same file, config and placement as part %s, the parts overlap", location, other.Name))
+						break
+					}
+				}
+			}
+		}
+	}
+	lintObjects(config.Objects, "")
+	for i, plate := range config.Plates {
+		lintObjects(plate.Objects, fmt.Sprintf("plate %d, ", i+1))
+	}
+	return warnings
+}
+
+// lintPart checks the rotation, position and filament of a part
+func lintPart(part models.YamlPart, location string, config *models.YamlConfig, printer models.PrinterProfile) []string {
+	var warnings []string
+	for _, rotation := range []struct {
+		name  string
+		value float64
+	}{{"rotation_x", part.RotationX}, {"rotation_y", part.RotationY}, {"rotation_z", part.RotationZ}} {
+		if math.Abs(rotation.value) > 360 {
+			warnings = append(warnings, fmt.Sprintf("%s: %s is %g degrees, outside of -360 to 360 (radians or a typo?)", location, rotation.name, rotation.value))
+		}
+	}
+
+	// Positions are offsets within the object, so they are at most the plate size
+	for _, position := range []struct {
+		name  string
+		value float64
+		limit float64
+	}{{"position_x", part.PositionX, printer.Width}, {"position_y", part.PositionY, printer.Depth}, {"position_z", part.PositionZ, printer.Height}} {
+		if position.limit > 0 && math.Abs(position.value) > position.limit {
+			warnings = append(warnings, fmt.Sprintf("%s: %s is %g mm, larger than the printer's %g mm", location, position.name, position.value, position.limit))
+		}
+	}
+
+	if len(config.Filaments) > 0 && part.Filament > len(config.Filaments) {
+		warnings = append(warnings, fmt.Sprintf("%s: uses filament %d, but only %d filament(s) are configured", location, part.Filament, len(config.Filaments)))
+	}
+	return warnings
+}
+
+// sameGeometry reports whether two parts render the same geometry at the same place
+func sameGeometry(a, b models.YamlPart) bool {
+	a.Name, b.Name = "", ""
+	a.Filament, b.Filament = 0, 0
+	a.Optional, b.Optional = false, false
+	return reflect.DeepEqual(a, b)
+}
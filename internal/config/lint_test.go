@@ -0,0 +1,83 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/philipparndt/go3mf/internal/models"
+)
+
+func TestLintConfig(t *testing.T) {
+	printer := models.PrinterProfile{Width: 256, Depth: 256, Height: 256, FilamentSlots: 4}
+	part := func(name string) models.YamlPart {
+		return models.YamlPart{Name: name, File: "box.scad", Config: []map[string]interface{}{{"cfg.scad": map[string]interface{}{"size": 10}}}}
+	}
+
+	tests := []struct {
+		name    string
+		config  models.YamlConfig
+		printer models.PrinterProfile
+		want    []string
+	}{
+		{
+			name:   "no findings",
+			config: models.YamlConfig{Objects: []models.YamlObject{{Name: "Box", Parts: []models.YamlPart{part("a"), {Name: "b", File: "box.scad", PositionX: 20}}}}},
+			want:   nil,
+		},
+		{
+			name: "duplicate part",
+			config: models.YamlConfig{Plates: []models.YamlPlate{{Objects: []models.YamlObject{
+				{Name: "Box", Parts: []models.YamlPart{part("a"), {Name: "lid", File: "lid.scad"}, func() models.YamlPart { p := part("b"); p.Filament = 2; return p }()}},
+			}}}},
+			want: []string{"plate 1, object Box, part b: same file, config and placement as part a, the parts overlap"},
+		},
+		{
+			name: "rotation and position",
+			config: models.YamlConfig{Objects: []models.YamlObject{{Name: "Box", Parts: []models.YamlPart{
+				{Name: "a", File: "box.scad", RotationZ: 450, PositionY: -300, PositionZ: 10},
+			}}}},
+			want: []string{
+				"object Box, part a: rotation_z is 450 degrees, outside of -360 to 360 (radians or a typo?)",
+				"object Box, part a: position_y is -300 mm, larger than the printer's 256 mm",
+			},
+		},
+		{
+			name: "unconfigured filament",
+			config: models.YamlConfig{
+				Filaments: []models.YamlFilament{{Type: "PLA"}},
+				Objects:   []models.YamlObject{{Name: "Box", Parts: []models.YamlPart{{Name: "a", File: "box.scad", Filament: 2}}}},
+			},
+			want: []string{"object Box, part a: uses filament 2, but only 1 filament(s) are configured"},
+		},
+		{
+			name: "single filament slot",
+			config: models.YamlConfig{
+				Filaments: []models.YamlFilament{{Type: "PLA"}, {Type: "PETG"}},
+				Objects:   []models.YamlObject{{Name: "Box", Parts: []models.YamlPart{{Name: "a", File: "box.scad", Filament: 1}}}},
+			},
+			printer: models.PrinterProfile{Width: 250, Depth: 210, FilamentSlots: 1},
+			want:    []string{"filaments: 2 filaments are configured, but the printer has a single filament slot"},
+		},
+		{
+			name: "unknown material",
+			config: models.YamlConfig{
+				Filaments: []models.YamlFilament{{Type: "PETG-CF"}, {Type: "Wood"}, {Type: "Nylon"}},
+				Materials: map[string]float64{"nylon": 1.08},
+				Objects:   []models.YamlObject{{Name: "Box", Parts: []models.YamlPart{{Name: "a", File: "box.scad", Filament: 1}}}},
+			},
+			want: []string{"filament 2: the density of Wood is unknown, weights are estimated for PLA (add it to materials)"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := printer
+			if tt.printer.Width > 0 {
+				p = tt.printer
+			}
+			if got := lintConfig(&tt.config, p); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("lintConfig() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
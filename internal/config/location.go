@@ -0,0 +1,172 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// locationError marks a validation error with the setting it belongs to: a top
+// level key, or a part or object identified by name, as objects and parts may be
+// left out or created from templates before they are validated
+type locationError struct {
+	key    string // Top level key ("" = an object or part)
+	plate  int    // Plate of the object (0 = objects at the top level)
+	object string
+	part   string // "" = the object itself
+	err    error
+}
+
+func (e *locationError) Error() string {
+	return e.err.Error()
+}
+
+func (e *locationError) Unwrap() error {
+	return e.err
+}
+
+// atKey marks err as an error of a top level key of the configuration
+func atKey(key string, err error) error {
+	return &locationError{key: key, err: err}
+}
+
+// ConfigError is an error at a line of a configuration file
+type ConfigError struct {
+	File    string
+	Line    int
+	Column  int
+	Snippet string // Line of the file with a caret below the column
+	Err     error
+}
+
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("%s:%d:%d: %v\n%s", e.File, e.Line, e.Column, e.Err, e.Snippet)
+}
+
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
+
+// yamlLine matches the line number in the errors of the YAML parser
+var yamlLine = regexp.MustCompile(`line (\d+)`)
+
+// withLocation adds the line and column of the setting err belongs to in the
+// configuration data. Errors without a known location are returned unchanged.
+func withLocation(data []byte, configPath string, err error) error {
+	var root yaml.Node
+	if yaml.Unmarshal(data, &root) != nil || len(root.Content) == 0 {
+		return withParserLocation(data, configPath, err)
+	}
+	var located *locationError
+	if !errors.As(err, &located) {
+		return withParserLocation(data, configPath, err)
+	}
+	node := located.find(root.Content[0])
+	if node == nil {
+		return err
+	}
+	return newConfigError(data, configPath, node.Line, node.Column, err)
+}
+
+// withParserLocation adds a snippet to errors of the YAML parser, which only
+// report the line
+func withParserLocation(data []byte, configPath string, err error) error {
+	match := yamlLine.FindStringSubmatch(err.Error())
+	if match == nil {
+		return err
+	}
+	line, _ := strconv.Atoi(match[1])
+	return newConfigError(data, configPath, line, 1, err)
+}
+
+// newConfigError creates the error at a line and column of the configuration data
+func newConfigError(data []byte, configPath string, line, column int, err error) error {
+	lines := strings.Split(string(data), "\n")
+	if line < 1 || line > len(lines) {
+		return err
+	}
+	if configPath == StdinPath {
+		configPath = "<stdin>"
+	}
+	gutter := fmt.Sprintf("%4d | ", line)
+	snippet := gutter + strings.TrimRight(lines[line-1], "\r") + "\n" +
+		strings.Repeat(" ", len(gutter)-2) + "| " + strings.Repeat(" ", max(column-1, 0)) + "^"
+	return &ConfigError{File: configPath, Line: line, Column: column, Snippet: snippet, Err: err}
+}
+
+// find returns the node of the setting of the error in the root mapping
+func (e *locationError) find(root *yaml.Node) *yaml.Node {
+	if e.key != "" {
+		return mappingKey(root, e.key)
+	}
+
+	var objects *yaml.Node
+	if e.plate > 0 {
+		if plates := mappingValue(root, "plates"); plates != nil && e.plate <= len(plates.Content) {
+			objects = mappingValue(plates.Content[e.plate-1], "objects")
+		}
+	} else {
+		objects = mappingValue(root, "objects")
+	}
+	obj := namedItem(objects, e.object)
+	var parts *yaml.Node
+	if obj != nil {
+		parts = mappingValue(obj, "parts")
+	} else if instance := namedItem(mappingValue(root, "instances"), e.object); instance != nil {
+		// Objects of instances have the parts of their template
+		obj = instance
+		if template := mappingValue(instance, "template"); template != nil {
+			parts = mappingValue(mappingValue(mappingValue(root, "templates"), template.Value), "parts")
+		}
+	}
+	if obj == nil {
+		return nil
+	}
+	if part := namedItem(parts, e.part); e.part != "" && part != nil {
+		return part
+	}
+	return obj
+}
+
+// mappingKey returns the key node of key in a mapping node
+func mappingKey(mapping *yaml.Node, key string) *yaml.Node {
+	if mapping == nil || mapping.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i]
+		}
+	}
+	return nil
+}
+
+// mappingValue returns the value node of key in a mapping node
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	if mapping == nil || mapping.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// namedItem returns the item of a sequence node whose name is name
+func namedItem(sequence *yaml.Node, name string) *yaml.Node {
+	if sequence == nil || sequence.Kind != yaml.SequenceNode {
+		return nil
+	}
+	for _, item := range sequence.Content {
+		if value := mappingValue(item, "name"); value != nil && value.Value == name {
+			return item
+		}
+	}
+	return nil
+}
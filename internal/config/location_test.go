@@ -0,0 +1,70 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+const locationConfig = `output: out.3mf
+packing_distance: -3
+templates:
+  knob:
+    parts:
+      - name: body
+        file: knob.scad
+instances:
+  - template: knob
+    name: Small Knob
+plates:
+  - objects:
+      - name: Base
+        parts:
+          - name: bottom
+            file: base.scad
+`
+
+func TestWithLocation(t *testing.T) {
+	cause := errors.New("invalid")
+	tests := []struct {
+		name         string
+		err          error
+		line, column int
+	}{
+		{"top level key", atKey("packing_distance", cause), 2, 1},
+		{"part of a plate", &locationError{plate: 1, object: "Base", part: "bottom", err: cause}, 15, 13},
+		{"object", &locationError{plate: 1, object: "Base", err: cause}, 13, 9},
+		{"part of an instance", &locationError{object: "Small Knob", part: "body", err: cause}, 6, 9},
+		{"wrapped", fmt.Errorf("invalid configuration: %w", atKey("output", cause)), 1, 1},
+		{"parser error", errors.New("yaml: line 7: mapping values are not allowed"), 7, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var configErr *ConfigError
+			if !errors.As(withLocation([]byte(locationConfig), "config.yaml", tt.err), &configErr) {
+				t.Fatalf("withLocation() returned no location")
+			}
+			if configErr.File != "config.yaml" || configErr.Line != tt.line || configErr.Column != tt.column {
+				t.Errorf("location = %s:%d:%d, want config.yaml:%d:%d", configErr.File, configErr.Line, configErr.Column, tt.line, tt.column)
+			}
+		})
+	}
+
+	t.Run("unknown object", func(t *testing.T) {
+		err := &locationError{object: "Lid", err: cause}
+		if got := withLocation([]byte(locationConfig), "config.yaml", err); got != error(err) {
+			t.Errorf("withLocation() = %v, want the error unchanged", got)
+		}
+	})
+
+	t.Run("snippet", func(t *testing.T) {
+		err := withLocation([]byte(locationConfig), StdinPath, &locationError{plate: 1, object: "Base", part: "bottom", err: cause})
+		want := "<stdin>:15:13: invalid\n" +
+			"  15 |           - name: bottom\n" +
+			"     |             ^"
+		if err.Error() != want {
+			t.Errorf("Error() = %q, want %q", err.Error(), want)
+		}
+	})
+}
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OutputVars are the values of the variables of output file name templates
+type OutputVars struct {
+	Profile string    // Build profile ("" = none)
+	Dir     string    // Directory of the git repository of {git}
+	Date    time.Time // Build date of {date}
+	Plate   int       // Plate number of per-plate files (0 = a file of all plates)
+}
+
+// outputVariable matches a variable of an output file name template, e.g. {date}
+var outputVariable = regexp.MustCompile(`\{([^{}]*)\}`)
+
+// ExpandOutput replaces the variables of an output file name template:
+// {profile} (build profile, "default" without one), {git} (short commit hash of
+// the repository), {date} (YYYY-MM-DD) and {plate} (plate number of per-plate
+// files). A name without variables is returned unchanged.
+func ExpandOutput(template string, vars OutputVars) (string, error) {
+	var expandErr error
+	expanded := outputVariable.ReplaceAllStringFunc(template, func(match string) string {
+		if expandErr != nil {
+			return match
+		}
+		value, err := outputValue(strings.Trim(match, "{}"), vars)
+		if err != nil {
+			expandErr = fmt.Errorf("output %q: %w", template, err)
+		}
+		return value
+	})
+	if expandErr != nil {
+		return "", expandErr
+	}
+	return expanded, nil
+}
+
+// outputValue returns the value of a variable of an output file name template
+func outputValue(name string, vars OutputVars) (string, error) {
+	switch name {
+	case "profile":
+		if vars.Profile == "" {
+			return "default", nil
+		}
+		return vars.Profile, nil
+	case "git":
+		return gitShortHash(vars.Dir)
+	case "date":
+		return vars.Date.Format("2006-01-02"), nil
+	case "plate":
+		if vars.Plate < 1 {
+			return "", fmt.Errorf("{plate} is only available in the names of per-plate files")
+		}
+		return strconv.Itoa(vars.Plate), nil
+	}
+	return "", fmt.Errorf("unknown variable {%s} (available: {profile}, {git}, {date}, {plate})", name)
+}
+
+// gitShortHash returns the short hash of the commit checked out in the git
+// repository of dir
+func gitShortHash(dir string) (string, error) {
+	cmd := exec.Command("git", "rev-parse", "--short", "HEAD")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("{git} requires a git repository with a commit, %s is not in one", dir)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
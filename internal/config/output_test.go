@@ -0,0 +1,72 @@
+package config
+
+import (
+	"os/exec"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExpandOutput(t *testing.T) {
+	date := time.Date(2026, 3, 7, 15, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		template string
+		vars     OutputVars
+		want     string
+		wantErr  string
+	}{
+		{name: "no variables", template: "widget.3mf", want: "widget.3mf"},
+		{name: "profile and date", template: "dist/widget_{profile}_{date}.3mf", vars: OutputVars{Profile: "petg", Date: date}, want: "dist/widget_petg_2026-03-07.3mf"},
+		{name: "default profile", template: "widget_{profile}.3mf", want: "widget_default.3mf"},
+		{name: "plate", template: "widget_plate{plate}.3mf", vars: OutputVars{Plate: 2}, want: "widget_plate2.3mf"},
+		{name: "plate of all plates", template: "widget_{plate}.3mf", wantErr: "{plate} is only available in the names of per-plate files"},
+		{name: "unknown variable", template: "widget_{version}.3mf", wantErr: "unknown variable {version}"},
+		{name: "git outside of a repository", template: "widget_{git}.3mf", vars: OutputVars{Dir: t.TempDir()}, wantErr: "{git} requires a git repository"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExpandOutput(tt.template, tt.vars)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ExpandOutput() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ExpandOutput() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ExpandOutput() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandOutputGit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "--allow-empty", "-m", "initial"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+
+	got, err := ExpandOutput("widget_{git}.3mf", OutputVars{Dir: dir})
+	if err != nil {
+		t.Fatalf("ExpandOutput() error = %v", err)
+	}
+	if !regexp.MustCompile(`^widget_[0-9a-f]{7,}\.3mf$`).MatchString(got) {
+		t.Errorf("ExpandOutput() = %q, want the short commit hash", got)
+	}
+}
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/philipparndt/go3mf/internal/models"
+)
+
+// validateProfiles checks the settings of all build profiles, including those not selected
+func validateProfiles(profiles map[string]models.BuildProfile) error {
+	for name, profile := range profiles {
+		if name == "" {
+			return fmt.Errorf("profile names must not be empty")
+		}
+		if err := validateQuality(profile.Quality); err != nil {
+			return fmt.Errorf("%s: quality: %w", name, err)
+		}
+		if _, err := models.ParsePackingAlgorithm(profile.PackingAlgorithm); err != nil {
+			return fmt.Errorf("%s: packing_algorithm: %w", name, err)
+		}
+		if strings.ContainsAny(profile.OutputSuffix, `/\`) {
+			return fmt.Errorf("%s: output_suffix must not contain path separators", name)
+		}
+	}
+	return nil
+}
+
+// applyProfile applies the settings of the named build profile to the config
+func applyProfile(config *models.YamlConfig, name string) error {
+	if err := validateProfiles(config.Profiles); err != nil {
+		return err
+	}
+	profile, ok := config.Profiles[name]
+	if !ok {
+		return fmt.Errorf("unknown profile %q (defined: %s)", name, profileNames(config.Profiles))
+	}
+
+	if profile.Quality != nil {
+		quality := models.Quality{}.Override(config.Quality).Override(profile.Quality)
+		config.Quality = &quality
+	}
+	if profile.PackingAlgorithm != "" {
+		config.PackingAlgorithm = profile.PackingAlgorithm
+	}
+	if profile.OutputSuffix != "" && config.Output != StdinPath {
+		ext := filepath.Ext(config.Output)
+		config.Output = strings.TrimSuffix(config.Output, ext) + profile.OutputSuffix + ext
+	}
+	return nil
+}
+
+// profileNames lists the names of the profiles for error messages
+func profileNames(profiles map[string]models.BuildProfile) string {
+	if len(profiles) == 0 {
+		return "none"
+	}
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/philipparndt/go3mf/internal/models"
+)
+
+func TestApplyProfile(t *testing.T) {
+	profiles := map[string]models.BuildProfile{
+		"draft": {Quality: &models.Quality{Fn: 16}, PackingAlgorithm: "compact", OutputSuffix: "_draft"},
+		"final": {Quality: &models.Quality{Fs: 0.1}},
+	}
+
+	tests := []struct {
+		name        string
+		profile     string
+		output      string
+		profiles    map[string]models.BuildProfile
+		wantOutput  string
+		wantQuality models.Quality
+		wantPacking string
+		wantErr     string
+	}{
+		{name: "draft", profile: "draft", output: "out/box.3mf", profiles: profiles, wantOutput: "out/box_draft.3mf", wantQuality: models.Quality{Fn: 16, Fs: 1}, wantPacking: "compact"},
+		{name: "final keeps output", profile: "final", output: "box.3mf", profiles: profiles, wantOutput: "box.3mf", wantQuality: models.Quality{Fs: 0.1}},
+		{name: "no suffix for stdout", profile: "draft", output: "-", profiles: profiles, wantOutput: "-", wantQuality: models.Quality{Fn: 16, Fs: 1}, wantPacking: "compact"},
+		{name: "unknown profile", profile: "fast", output: "box.3mf", profiles: profiles, wantErr: `unknown profile "fast" (defined: draft, final)`},
+		{name: "no profiles", profile: "draft", output: "box.3mf", wantErr: "(defined: none)"},
+		{name: "invalid profile", profile: "draft", output: "box.3mf", profiles: map[string]models.BuildProfile{"draft": {OutputSuffix: "/x"}}, wantErr: "draft: output_suffix must not contain path separators"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &models.YamlConfig{Output: tt.output, Quality: &models.Quality{Fs: 1}, Profiles: tt.profiles}
+			err := applyProfile(config, tt.profile)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if config.Output != tt.wantOutput {
+				t.Errorf("expected output %q, got %q", tt.wantOutput, config.Output)
+			}
+			if *config.Quality != tt.wantQuality {
+				t.Errorf("expected quality %+v, got %+v", tt.wantQuality, *config.Quality)
+			}
+			if config.PackingAlgorithm != tt.wantPacking {
+				t.Errorf("expected packing algorithm %q, got %q", tt.wantPacking, config.PackingAlgorithm)
+			}
+		})
+	}
+}
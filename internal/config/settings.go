@@ -0,0 +1,67 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/philipparndt/go3mf/internal/models"
+	"gopkg.in/yaml.v3"
+)
+
+// colorPattern matches filament colors as #RRGGBB or #RRGGBBAA
+var colorPattern = regexp.MustCompile(`^#([0-9A-Fa-f]{6}|[0-9A-Fa-f]{8})$`)
+
+// LoadSettings reads and validates a settings file for apply-settings (or stdin for "-")
+func LoadSettings(path string) (*models.YamlSettings, error) {
+	data, err := readConfig(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read settings file: %w", err)
+	}
+
+	var settings models.YamlSettings
+	if err := yaml.Unmarshal(data, &settings); err != nil {
+		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+	if err := ValidateSettings(&settings); err != nil {
+		return nil, fmt.Errorf("invalid settings: %w", err)
+	}
+	return &settings, nil
+}
+
+// ValidateSettings checks that the settings change something and that their values can be written
+func ValidateSettings(settings *models.YamlSettings) error {
+	if len(settings.Filaments) == 0 && len(settings.PrintSettings) == 0 && len(settings.Metadata) == 0 {
+		return fmt.Errorf("at least one of filaments, print_settings or metadata must be set")
+	}
+	if err := validateFilaments(settings.Filaments); err != nil {
+		return err
+	}
+	for key, value := range settings.PrintSettings {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			return fmt.Errorf("print_settings: %s must be a value or a list of values", key)
+		case []interface{}:
+			for _, item := range v {
+				if _, ok := item.(map[string]interface{}); ok {
+					return fmt.Errorf("print_settings: %s must be a value or a list of values", key)
+				}
+			}
+		}
+	}
+	for name := range settings.Metadata {
+		if name == "" {
+			return fmt.Errorf("metadata: names must not be empty")
+		}
+	}
+	return nil
+}
+
+// validateFilaments checks the colors of filaments
+func validateFilaments(filaments []models.YamlFilament) error {
+	for i, filament := range filaments {
+		if filament.Color != "" && !colorPattern.MatchString(filament.Color) {
+			return fmt.Errorf("filament %d: color %q must be #RRGGBB or #RRGGBBAA", i+1, filament.Color)
+		}
+	}
+	return nil
+}
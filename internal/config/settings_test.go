@@ -0,0 +1,38 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/philipparndt/go3mf/internal/models"
+)
+
+func TestValidateSettings(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings models.YamlSettings
+		wantErr  string
+	}{
+		{name: "filaments", settings: models.YamlSettings{Filaments: []models.YamlFilament{{Color: "#FF8000"}, {Color: "#ff800080", Type: "PETG"}}}},
+		{name: "print settings", settings: models.YamlSettings{PrintSettings: map[string]interface{}{"layer_height": 0.2, "wall_loops": []interface{}{3, 4}}}},
+		{name: "metadata", settings: models.YamlSettings{Metadata: map[string]string{"Title": "Box"}}},
+		{name: "empty", wantErr: "at least one of filaments, print_settings or metadata must be set"},
+		{name: "invalid color", settings: models.YamlSettings{Filaments: []models.YamlFilament{{}, {Color: "red"}}}, wantErr: `filament 2: color "red"`},
+		{name: "nested print setting", settings: models.YamlSettings{PrintSettings: map[string]interface{}{"speed": map[string]interface{}{"outer": 200}}}, wantErr: "print_settings: speed must be a value or a list of values"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSettings(&tt.settings)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
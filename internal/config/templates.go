@@ -0,0 +1,103 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/philipparndt/go3mf/internal/models"
+)
+
+// expandInstances adds an object to the config for each instance of a template
+func expandInstances(config *models.YamlConfig) error {
+	if len(config.Instances) > 0 && len(config.Plates) > 0 {
+		return fmt.Errorf("instances cannot be combined with plates")
+	}
+	for i, instance := range config.Instances {
+		obj, err := instantiate(config.Templates, instance)
+		if err != nil {
+			if instance.Name == "" {
+				return fmt.Errorf("instance %d: %w", i+1, err)
+			}
+			return fmt.Errorf("instance %s: %w", instance.Name, err)
+		}
+		config.Objects = append(config.Objects, obj)
+	}
+	return nil
+}
+
+// instantiate creates the object of an instance from its template
+func instantiate(templates map[string]models.YamlObject, instance models.YamlInstance) (models.YamlObject, error) {
+	if instance.Name == "" {
+		return models.YamlObject{}, fmt.Errorf("name is required")
+	}
+	if instance.Template == "" {
+		return models.YamlObject{}, fmt.Errorf("template is required")
+	}
+	template, ok := templates[instance.Template]
+	if !ok {
+		return models.YamlObject{}, fmt.Errorf("unknown template %q", instance.Template)
+	}
+
+	obj := template
+	obj.Name = instance.Name
+	if instance.Count != 0 {
+		obj.Count = instance.Count
+	}
+	if instance.Enabled != nil {
+		obj.Enabled = instance.Enabled
+	}
+	if instance.EnabledIf != "" {
+		obj.EnabledIf = instance.EnabledIf
+	}
+	obj.Config = mergeConfigValues(template.Config, instance.Config)
+
+	// Copy the parts, so that instances do not share them with the template
+	obj.Parts = make([]models.YamlPart, len(template.Parts))
+	copy(obj.Parts, template.Parts)
+	if instance.Filament != 0 {
+		for i := range obj.Parts {
+			obj.Parts[i].Filament = instance.Filament
+		}
+	}
+	return obj, nil
+}
+
+// mergeConfigValues returns the config files of a template with the values of an
+// instance merged in. Values of config files given as maps are merged key by key;
+// other config files of the instance replace those of the template.
+func mergeConfigValues(template, instance []map[string]interface{}) []map[string]interface{} {
+	if len(instance) == 0 {
+		return template
+	}
+
+	// Later entries replace earlier ones, so the last value of each file counts
+	values := map[string]interface{}{}
+	for _, configMap := range template {
+		for filename, content := range configMap {
+			values[filename] = content
+		}
+	}
+
+	merged := make([]map[string]interface{}, 0, len(template)+len(instance))
+	merged = append(merged, template...)
+	for _, configMap := range instance {
+		entry := make(map[string]interface{}, len(configMap))
+		for filename, content := range configMap {
+			base, baseIsMap := values[filename].(map[string]interface{})
+			override, overrideIsMap := content.(map[string]interface{})
+			if baseIsMap && overrideIsMap {
+				combined := make(map[string]interface{}, len(base)+len(override))
+				for key, value := range base {
+					combined[key] = value
+				}
+				for key, value := range override {
+					combined[key] = value
+				}
+				content = combined
+			}
+			entry[filename] = content
+			values[filename] = content
+		}
+		merged = append(merged, entry)
+	}
+	return merged
+}
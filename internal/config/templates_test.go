@@ -0,0 +1,93 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/philipparndt/go3mf/internal/models"
+	"gopkg.in/yaml.v3"
+)
+
+func TestExpandInstances(t *testing.T) {
+	data := `
+output: out.3mf
+templates:
+  bin:
+    count: 2
+    config:
+      - bin.scad:
+          width: 20
+          depth: 40
+    parts:
+      - name: body
+        file: bin.scad
+        filament: 1
+      - name: label
+        file: label.scad
+instances:
+  - template: bin
+    name: Small bin
+  - template: bin
+    name: Wide bin
+    count: 1
+    filament: 3
+    config:
+      - bin.scad:
+          width: 60
+`
+	var config models.YamlConfig
+	if err := yaml.Unmarshal([]byte(data), &config); err != nil {
+		t.Fatal(err)
+	}
+	if err := expandInstances(&config); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(config.Objects) != 2 {
+		t.Fatalf("expected 2 objects, got %d", len(config.Objects))
+	}
+
+	small, wide := config.Objects[0], config.Objects[1]
+	if small.Name != "Small bin" || small.Count != 2 || small.Parts[0].Filament != 1 {
+		t.Errorf("unexpected small bin %+v", small)
+	}
+	if wide.Name != "Wide bin" || wide.Count != 1 || wide.Parts[0].Filament != 3 || wide.Parts[1].Filament != 3 {
+		t.Errorf("unexpected wide bin %+v", wide)
+	}
+	if config.Templates["bin"].Parts[0].Filament != 1 {
+		t.Error("instance modified the parts of the template")
+	}
+
+	groups := NewLoader().ConvertToObjectGroups(&config)
+	cfg := groups[len(groups)-1].Parts[0].ConfigFiles["bin.scad"]
+	for _, want := range []string{"function get_width() = 60;", "function get_depth() = 40;"} {
+		if !strings.Contains(cfg, want) {
+			t.Errorf("expected config of the wide bin to contain %q, got:\n%s", want, cfg)
+		}
+	}
+}
+
+func TestExpandInstances_Errors(t *testing.T) {
+	templates := map[string]models.YamlObject{"bin": {Parts: []models.YamlPart{{Name: "body", File: "bin.scad"}}}}
+
+	tests := []struct {
+		name     string
+		instance models.YamlInstance
+		plates   []models.YamlPlate
+		wantErr  string
+	}{
+		{name: "unknown template", instance: models.YamlInstance{Template: "box", Name: "Box"}, wantErr: `instance Box: unknown template "box"`},
+		{name: "missing name", instance: models.YamlInstance{Template: "bin"}, wantErr: "instance 1: name is required"},
+		{name: "missing template", instance: models.YamlInstance{Name: "Bin"}, wantErr: "instance Bin: template is required"},
+		{name: "with plates", instance: models.YamlInstance{Template: "bin", Name: "Bin"}, plates: []models.YamlPlate{{}}, wantErr: "cannot be combined with plates"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &models.YamlConfig{Templates: templates, Instances: []models.YamlInstance{tt.instance}, Plates: tt.plates}
+			err := expandInstances(config)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/philipparndt/go3mf/internal/models"
+	"gopkg.in/yaml.v3"
+)
+
+// WorkspaceFile is the name of the file that defines a workspace
+const WorkspaceFile = "go3mf.workspace.yaml"
+
+// DefaultCacheDir is the render cache directory of a workspace, relative to the workspace file
+const DefaultCacheDir = ".go3mf-cache"
+
+// FindWorkspace returns the path of the workspace file in dir or its closest
+// parent directory, or "" if there is none
+func FindWorkspace(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		path := filepath.Join(dir, WorkspaceFile)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return "", err
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// LoadWorkspace reads and validates a workspace file and resolves its members
+func LoadWorkspace(path string) (*models.Workspace, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read workspace file: %w", err)
+	}
+
+	var ws models.Workspace
+	if err := yaml.Unmarshal(data, &ws); err != nil {
+		return nil, fmt.Errorf("failed to parse workspace file %s: %w", path, err)
+	}
+	if ws.Dir, err = filepath.Abs(filepath.Dir(path)); err != nil {
+		return nil, err
+	}
+
+	if err := validateWorkspace(&ws); err != nil {
+		return nil, fmt.Errorf("invalid workspace %s: %w", path, err)
+	}
+	return &ws, nil
+}
+
+// validateWorkspace checks the shared settings and expands the build patterns into ws.Members
+func validateWorkspace(ws *models.Workspace) error {
+	if len(ws.Builds) == 0 {
+		return fmt.Errorf("at least one build must be listed")
+	}
+	for name, profile := range ws.Printers {
+		if err := profile.Validate(); err != nil {
+			return fmt.Errorf("printers: %s: %w", name, err)
+		}
+	}
+	if _, err := models.LookupPrinter(ws.Printer, ws.Printers); err != nil {
+		return fmt.Errorf("printer: %w", err)
+	}
+	if ws.PackingDistance < 0 {
+		return fmt.Errorf("packing_distance must not be negative")
+	}
+	if _, err := models.ParsePackingAlgorithm(ws.PackingAlgorithm); err != nil {
+		return fmt.Errorf("packing_algorithm: %w", err)
+	}
+	if _, err := models.ParsePackingOrder(ws.PackingOrder); err != nil {
+		return fmt.Errorf("packing_order: %w", err)
+	}
+	if ws.PlacementGrid < 0 {
+		return fmt.Errorf("placement_grid must not be negative")
+	}
+	if _, err := models.ParseFootprint(ws.Footprint); err != nil {
+		return fmt.Errorf("footprint: %w", err)
+	}
+	if _, err := models.ParsePackingOrigin(ws.PackingOrigin); err != nil {
+		return fmt.Errorf("packing_origin: %w", err)
+	}
+	if _, err := models.ParseRenderer(ws.Renderer); err != nil {
+		return fmt.Errorf("renderer: %w", err)
+	}
+	if err := ValidateRenderWorkers(ws.RenderWorkers); err != nil {
+		return fmt.Errorf("render_workers: %w", err)
+	}
+	if ws.RenderJobs < 0 {
+		return fmt.Errorf("render_jobs must not be negative")
+	}
+
+	ws.Members = nil
+	for _, pattern := range ws.Builds {
+		matches, err := filepath.Glob(filepath.Join(ws.Dir, pattern))
+		if err != nil {
+			return fmt.Errorf("builds: invalid pattern %q: %w", pattern, err)
+		}
+		if len(matches) == 0 {
+			return fmt.Errorf("builds: %q matches no config", pattern)
+		}
+		for _, match := range matches {
+			if !slices.Contains(ws.Members, match) {
+				ws.Members = append(ws.Members, match)
+			}
+		}
+	}
+	return nil
+}
+
+// IsMember reports whether the config file is one of the builds of the workspace
+func IsMember(ws *models.Workspace, configPath string) bool {
+	abs, err := filepath.Abs(configPath)
+	if err != nil {
+		return false
+	}
+	return slices.Contains(ws.Members, abs)
+}
+
+// CacheDir returns the absolute render cache directory of the workspace
+func CacheDir(ws *models.Workspace) string {
+	dir := ws.Cache
+	if dir == "" {
+		dir = DefaultCacheDir
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(ws.Dir, dir)
+	}
+	return dir
+}
+
+// applyWorkspace uses the shared settings of the workspace for everything the
+// config does not set itself. Printers of the config replace shared printers
+// with the same name.
+func applyWorkspace(config *models.YamlConfig, ws *models.Workspace) {
+	if config.Printer == "" {
+		config.Printer = ws.Printer
+	}
+	if len(ws.Printers) > 0 {
+		printers := make(map[string]models.PrinterProfile, len(ws.Printers)+len(config.Printers))
+		for name, profile := range ws.Printers {
+			printers[name] = profile
+		}
+		for name, profile := range config.Printers {
+			printers[name] = profile
+		}
+		config.Printers = printers
+	}
+	if config.PackingDistance == 0 {
+		config.PackingDistance = ws.PackingDistance
+	}
+	if config.PackingAlgorithm == "" {
+		config.PackingAlgorithm = ws.PackingAlgorithm
+	}
+	if config.PackingOrder == "" {
+		config.PackingOrder = ws.PackingOrder
+	}
+	if config.PlacementGrid == 0 {
+		config.PlacementGrid = ws.PlacementGrid
+	}
+	if config.Footprint == "" {
+		config.Footprint = ws.Footprint
+	}
+	if config.PackingOrigin == "" {
+		config.PackingOrigin = ws.PackingOrigin
+	}
+	if config.Renderer == "" {
+		config.Renderer = ws.Renderer
+	}
+	if config.RendererImage == "" {
+		config.RendererImage = ws.RendererImage
+	}
+	if len(config.RenderWorkers) == 0 {
+		config.RenderWorkers = ws.RenderWorkers
+	}
+	if config.RenderJobs == 0 {
+		config.RenderJobs = ws.RenderJobs
+	}
+}
@@ -0,0 +1,101 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/philipparndt/go3mf/internal/models"
+)
+
+func TestLoadWorkspace(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a", "b"} {
+		if err := os.MkdirAll(filepath.Join(dir, "products", name), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "products", name, "config.yaml"), []byte("objects: []\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name        string
+		content     string
+		wantMembers int
+		wantErr     string
+	}{
+		{name: "glob", content: "builds:\n  - products/*/config.yaml\n", wantMembers: 2},
+		{name: "duplicates", content: "builds:\n  - products/a/config.yaml\n  - products/*/config.yaml\n", wantMembers: 2},
+		{name: "no builds", content: "printer: a1\n", wantErr: "at least one build must be listed"},
+		{name: "no match", content: "builds:\n  - other/*.yaml\n", wantErr: `"other/*.yaml" matches no config`},
+		{name: "unknown printer", content: "builds:\n  - products/a/config.yaml\nprinter: ender3\n", wantErr: `printer: unknown printer "ender3"`},
+		{name: "negative distance", content: "builds:\n  - products/a/config.yaml\npacking_distance: -1\n", wantErr: "packing_distance must not be negative"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, WorkspaceFile)
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			ws, err := LoadWorkspace(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(ws.Members) != tt.wantMembers {
+				t.Errorf("expected %d members, got %v", tt.wantMembers, ws.Members)
+			}
+			if !IsMember(ws, filepath.Join(dir, "products", "a", "config.yaml")) {
+				t.Errorf("expected products/a/config.yaml to be a member")
+			}
+			if got, want := CacheDir(ws), filepath.Join(dir, DefaultCacheDir); got != want {
+				t.Errorf("expected cache dir %s, got %s", want, got)
+			}
+		})
+	}
+}
+
+func TestApplyWorkspace(t *testing.T) {
+	ws := &models.Workspace{
+		Printer:         "a1",
+		Printers:        map[string]models.PrinterProfile{"shared": {Width: 200, Depth: 200}},
+		PackingDistance: 4,
+		Footprint:       "hull",
+		PackingOrigin:   "back",
+		RenderJobs:      2,
+	}
+	config := &models.YamlConfig{
+		PackingDistance: 8,
+		Printers:        map[string]models.PrinterProfile{"own": {Width: 100, Depth: 100}},
+	}
+
+	applyWorkspace(config, ws)
+
+	if config.Printer != "a1" {
+		t.Errorf("expected shared printer a1, got %q", config.Printer)
+	}
+	if config.PackingDistance != 8 {
+		t.Errorf("expected the config's packing distance 8, got %v", config.PackingDistance)
+	}
+	if config.Footprint != "hull" {
+		t.Errorf("expected shared footprint hull, got %q", config.Footprint)
+	}
+	if config.PackingOrigin != "back" {
+		t.Errorf("expected shared packing origin back, got %q", config.PackingOrigin)
+	}
+	if config.RenderJobs != 2 {
+		t.Errorf("expected shared render jobs 2, got %d", config.RenderJobs)
+	}
+	if len(config.Printers) != 2 {
+		t.Errorf("expected shared and own printers, got %v", config.Printers)
+	}
+}
@@ -0,0 +1,208 @@
+package estimate
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/philipparndt/go3mf/internal/arrangement"
+	"github.com/philipparndt/go3mf/internal/bom"
+	"github.com/philipparndt/go3mf/internal/geometry"
+	"github.com/philipparndt/go3mf/internal/models"
+)
+
+// Settings are the assumptions of the heuristic estimate
+type Settings struct {
+	Densities   bom.Densities // Filament densities by slot (unknown slots: bom.DefaultDensity)
+	Flow        float64       // Average volumetric flow in mm³/s, including travel and slowdowns
+	LayerHeight float64       // Layer height in mm
+	LayerTime   float64       // Time of a layer change in seconds
+	Shell       float64       // Thickness of walls, top and bottom in mm
+	Infill      float64       // Sparse infill density of objects without their own (0-1)
+}
+
+// DefaultSettings are typical values for PLA on a current printer
+var DefaultSettings = Settings{
+	Flow:        8,
+	LayerHeight: 0.2,
+	LayerTime:   2,
+	Shell:       1.2,
+	Infill:      0.15,
+}
+
+// Object is the estimate of an object of a 3MF model
+type Object struct {
+	Name     string
+	Plate    int           // Plate of the object (0 = single plate)
+	Quantity int           // Number of copies
+	Material float64       // Printed volume of a single copy in mm³
+	Height   float64       // Height in mm
+	Grams    float64       // Filament of a single copy in g
+	Time     time.Duration // Print time of a single copy printed alone
+}
+
+// FromModel estimates every build item of a 3MF model. The printed volume is
+// the shell along the surface and the infill of the rest; the time follows from
+// the volumetric flow and the layer changes.
+func FromModel(model *models.Model, settings *models.ModelSettings, s Settings) ([]Object, error) {
+	placements, err := arrangement.FromModel(model, settings)
+	if err != nil {
+		return nil, err
+	}
+
+	objects := make(map[string]*models.Object)
+	for i := range model.Resources.Objects {
+		objects[model.Resources.Objects[i].ID] = &model.Resources.Objects[i]
+	}
+	infill := make(map[string]float64)
+	filaments := make(map[string]filamentSlots)
+	if settings != nil {
+		for _, obj := range settings.Objects {
+			var slots filamentSlots
+			for _, meta := range obj.Metadata {
+				switch meta.Key {
+				case "sparse_infill_density":
+					if density, err := models.ParseInfill(meta.Value); err == nil {
+						infill[obj.ID] = density / 100
+					}
+				case "extruder":
+					slots.object, _ = strconv.Atoi(meta.Value)
+				}
+			}
+			for _, part := range obj.Parts {
+				slot := 0
+				for _, meta := range part.Metadata {
+					if meta.Key == "extruder" {
+						slot, _ = strconv.Atoi(meta.Value)
+					}
+				}
+				slots.parts = append(slots.parts, slot)
+			}
+			filaments[obj.ID] = slots
+		}
+	}
+
+	var estimates []Object
+	for i, item := range model.Build.Items {
+		obj := objects[item.ObjectID]
+		if obj == nil {
+			return nil, fmt.Errorf("build item references unknown object %s", item.ObjectID)
+		}
+		placement := placements.Objects[i]
+
+		var meshes []models.Object
+		var transforms []string
+		for _, component := range models.ObjectComponents(obj) {
+			if mesh := objects[component.ObjectID]; mesh != nil {
+				meshes = append(meshes, *mesh)
+				transforms = append(transforms, component.Transform)
+			}
+		}
+
+		objectInfill, ok := infill[item.ObjectID]
+		if !ok {
+			objectInfill = s.Infill
+		}
+		estimate := Object{Name: placement.Name, Plate: placement.Plate, Quantity: 1}
+		for j, mesh := range meshes {
+			// Support and other meshes are not material of the model
+			if !models.ObjectType(mesh.Type).Printable() {
+				continue
+			}
+			volume, err := geometry.MeshVolume(&mesh)
+			if err != nil {
+				return nil, fmt.Errorf("object %s: %w", placement.Name, err)
+			}
+			area, err := geometry.MeshArea(&mesh)
+			if err != nil {
+				return nil, fmt.Errorf("object %s: %w", placement.Name, err)
+			}
+			material := printedVolume(volume, area, s.Shell, objectInfill)
+			estimate.Material += material
+			estimate.Grams += material / 1000 * s.Densities.Slot(filaments[item.ObjectID].part(j))
+		}
+		if bbox, err := geometry.CalculateCombinedBoundingBox(meshes, transforms); err == nil {
+			estimate.Height = bbox.Depth()
+		}
+		estimate.Time = s.printTime(estimate.Material, estimate.Height)
+		estimates = append(estimates, estimate)
+	}
+	return estimates, nil
+}
+
+// filamentSlots are the filament slots of an object and its parts (0 = not set)
+type filamentSlots struct {
+	object int
+	parts  []int
+}
+
+// part returns the filament slot of part j; parts without their own slot use
+// the slot of the object
+func (f filamentSlots) part(j int) int {
+	if j < len(f.parts) && f.parts[j] > 0 {
+		return f.parts[j]
+	}
+	return f.object
+}
+
+// printedVolume returns the volume printed for a solid: a shell of the given
+// thickness along the surface and infill of the given density inside
+func printedVolume(volume, area, shell, infill float64) float64 {
+	shellVolume := math.Min(volume, area*shell)
+	return shellVolume + (volume-shellVolume)*infill
+}
+
+// printTime returns the time to print a volume up to a height
+func (s Settings) printTime(material, height float64) time.Duration {
+	seconds := material / s.Flow
+	if s.LayerHeight > 0 {
+		seconds += math.Ceil(height/s.LayerHeight) * s.LayerTime
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// Merge combines the copies of objects into one estimate with their quantity,
+// in the order of their first occurrence. name returns the object a name is a
+// copy of.
+func Merge(estimates []Object, name func(string) string) []Object {
+	index := make(map[string]int)
+	var merged []Object
+	for _, estimate := range estimates {
+		estimate.Name = name(estimate.Name)
+		if i, ok := index[estimate.Name]; ok {
+			merged[i].Quantity += estimate.Quantity
+			continue
+		}
+		index[estimate.Name] = len(merged)
+		merged = append(merged, estimate)
+	}
+	return merged
+}
+
+// Total returns the filament and print time of all copies of the objects.
+// Objects on a plate are printed together, so the layer changes of a plate are
+// only counted once, up to its tallest object.
+func Total(estimates []Object, s Settings) (float64, time.Duration) {
+	var grams, material float64
+	heights := make(map[int]float64) // Plate -> tallest object
+	for _, estimate := range estimates {
+		grams += estimate.Grams * float64(estimate.Quantity)
+		material += estimate.Material * float64(estimate.Quantity)
+		heights[estimate.Plate] = math.Max(heights[estimate.Plate], estimate.Height)
+	}
+	total := s.printTime(material, 0)
+	for _, height := range heights {
+		total += s.printTime(0, height)
+	}
+	return grams, total
+}
+
+// FormatDuration formats a duration as hours and minutes, e.g. 2h 05m or 45m
+func FormatDuration(d time.Duration) string {
+	minutes := int(math.Ceil(d.Minutes()))
+	if minutes < 60 {
+		return fmt.Sprintf("%dm", minutes)
+	}
+	return fmt.Sprintf("%dh %02dm", minutes/60, minutes%60)
+}
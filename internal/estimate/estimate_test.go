@@ -0,0 +1,104 @@
+package estimate
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPrintedVolume(t *testing.T) {
+	tests := []struct {
+		name                        string
+		volume, area, shell, infill float64
+		want                        float64
+	}{
+		{"thin part is solid", 100, 200, 1.2, 0.15, 100},
+		{"shell and infill", 8000, 2400, 1, 0.25, 2400 + 5600*0.25},
+		{"full infill", 8000, 2400, 1, 1, 8000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := printedVolume(tt.volume, tt.area, tt.shell, tt.infill); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("printedVolume() = %f, want %f", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTotal(t *testing.T) {
+	s := Settings{Flow: 10, LayerHeight: 0.2, LayerTime: 1}
+	estimates := []Object{
+		{Name: "a", Quantity: 2, Material: 100, Height: 10, Grams: 1.5},
+		{Name: "b", Quantity: 1, Material: 300, Height: 20, Grams: 4},
+		{Name: "c", Plate: 2, Quantity: 1, Material: 0, Height: 2, Grams: 0},
+	}
+
+	grams, total := Total(estimates, s)
+
+	if grams != 7 {
+		t.Errorf("grams = %f, want 7", grams)
+	}
+	// 500 mm³ at 10 mm³/s, 100 layers on the first plate and 10 on the second
+	if want := 160 * time.Second; total != want {
+		t.Errorf("total = %v, want %v", total, want)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	copyOf := func(name string) string { return strings.TrimSuffix(strings.TrimSuffix(name, "_1"), "_2") }
+	merged := Merge([]Object{{Name: "box_1", Quantity: 1}, {Name: "lid", Quantity: 1}, {Name: "box_2", Quantity: 1}}, copyOf)
+
+	if len(merged) != 2 || merged[0].Name != "box" || merged[0].Quantity != 2 || merged[1].Name != "lid" {
+		t.Errorf("Merge() = %+v, want box x2 and lid", merged)
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := map[time.Duration]string{
+		30 * time.Second:                  "1m",
+		45 * time.Minute:                  "45m",
+		2*time.Hour + 5*time.Minute:       "2h 05m",
+		26*time.Hour + 59*time.Minute + 1: "27h 00m",
+	}
+	for d, want := range tests {
+		if got := FormatDuration(d); got != want {
+			t.Errorf("FormatDuration(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
+
+func TestParseGCode(t *testing.T) {
+	tests := []struct {
+		name  string
+		gcode string
+		grams float64
+		time  time.Duration
+	}{
+		{
+			"bambu studio",
+			"; HEADER_BLOCK_START\n; model printing time: 1h 2m 3s; total estimated time: 1h 10m 3s\n; total filament weight [g] : 12.34,5.66\nG28\n",
+			18, time.Hour + 10*time.Minute + 3*time.Second,
+		},
+		{
+			"orca and prusa slicer",
+			"G1 X0\n; filament used [g] = 7.5\n; estimated printing time (normal mode) = 1d 2h 3m 4s\n",
+			7.5, 26*time.Hour + 3*time.Minute + 4*time.Second,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			grams, d, err := parseGCode(strings.NewReader(tt.gcode))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(grams-tt.grams) > 1e-9 || d != tt.time {
+				t.Errorf("parseGCode() = %f g, %v, want %f g, %v", grams, d, tt.grams, tt.time)
+			}
+		})
+	}
+
+	if _, _, err := parseGCode(strings.NewReader("G28\n")); err == nil {
+		t.Error("parseGCode() without estimate should fail")
+	}
+}
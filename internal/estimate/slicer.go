@@ -0,0 +1,136 @@
+package estimate
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SlicerResult is the print time and filament the slicer reported for all plates
+type SlicerResult struct {
+	Grams  float64
+	Time   time.Duration
+	Plates int
+}
+
+var (
+	// gcodeTime matches the total print time in the G-code header of Bambu Studio
+	// ("; total estimated time: 1h 2m 3s"), OrcaSlicer and PrusaSlicer ("; estimated
+	// printing time (normal mode) = 1h 2m 3s")
+	gcodeTime = regexp.MustCompile(`^;\s*(?:total estimated time|estimated printing time \(normal mode\))\s*[:=]\s*(.+)$`)
+	// gcodeGrams matches the filament weight per slot in the G-code header
+	// ("; total filament weight [g] : 1.23,4.56" or "; filament used [g] = 1.23, 4.56")
+	gcodeGrams = regexp.MustCompile(`^;\s*(?:total filament weight|filament used) \[g\]\s*[:=]\s*(.+)$`)
+	// durationPart matches a part of a slicer duration such as "1h" or "2d"
+	durationPart = regexp.MustCompile(`(\d+(?:\.\d+)?)\s*([dhms])`)
+)
+
+// Slice slices a 3MF file with the command line of a slicer (Bambu Studio,
+// OrcaSlicer) and returns the print time and filament of the G-code of all plates
+func Slice(slicer, file string) (*SlicerResult, error) {
+	dir, err := os.MkdirTemp("", "go3mf-slice-")
+	if err != nil {
+		return nil, fmt.Errorf("cannot create slicer output directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	var output bytes.Buffer
+	cmd := exec.Command(slicer, "--slice", "0", "--outputdir", dir, file)
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err := cmd.Run(); err != nil {
+		if message := strings.TrimSpace(output.String()); message != "" {
+			return nil, fmt.Errorf("slicer %s failed: %w\n%s", slicer, err, message)
+		}
+		return nil, fmt.Errorf("slicer %s failed: %w", slicer, err)
+	}
+
+	gcodes, err := filepath.Glob(filepath.Join(dir, "*.gcode"))
+	if err != nil || len(gcodes) == 0 {
+		return nil, fmt.Errorf("slicer %s wrote no G-code", slicer)
+	}
+	result := &SlicerResult{}
+	for _, gcode := range gcodes {
+		f, err := os.Open(gcode)
+		if err != nil {
+			return nil, err
+		}
+		grams, duration, err := parseGCode(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Base(gcode), err)
+		}
+		result.Grams += grams
+		result.Time += duration
+		result.Plates++
+	}
+	return result, nil
+}
+
+// parseGCode returns the filament and print time of the comments a slicer writes
+// to G-code
+func parseGCode(r io.Reader) (float64, time.Duration, error) {
+	var grams float64
+	var duration time.Duration
+	foundTime, foundGrams := false, false
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, ";") {
+			continue
+		}
+		// Bambu Studio lists several values in a line separated by semicolons
+		for _, comment := range strings.Split(line, ";") {
+			comment = "; " + strings.TrimSpace(comment)
+			if match := gcodeTime.FindStringSubmatch(comment); match != nil && !foundTime {
+				d, err := parseSlicerDuration(match[1])
+				if err != nil {
+					return 0, 0, err
+				}
+				duration, foundTime = d, true
+			}
+			if match := gcodeGrams.FindStringSubmatch(comment); match != nil && !foundGrams {
+				for _, value := range strings.Split(match[1], ",") {
+					g, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+					if err != nil {
+						return 0, 0, fmt.Errorf("invalid filament weight %q", match[1])
+					}
+					grams += g
+				}
+				foundGrams = true
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, 0, err
+	}
+	if !foundTime || !foundGrams {
+		return 0, 0, fmt.Errorf("no print time or filament weight in the G-code")
+	}
+	return grams, duration, nil
+}
+
+// parseSlicerDuration parses a duration such as "1d 2h 3m 4s" or "2h 5m"
+func parseSlicerDuration(s string) (time.Duration, error) {
+	units := map[string]time.Duration{"d": 24 * time.Hour, "h": time.Hour, "m": time.Minute, "s": time.Second}
+	matches := durationPart.FindAllStringSubmatch(s, -1)
+	if len(matches) == 0 {
+		return 0, fmt.Errorf("invalid print time %q", s)
+	}
+	var d time.Duration
+	for _, match := range matches {
+		value, _ := strconv.ParseFloat(match[1], 64)
+		d += time.Duration(value * float64(units[match[2]]))
+	}
+	return d, nil
+}
@@ -0,0 +1,119 @@
+package exitcode
+
+import (
+	"errors"
+)
+
+// Code is a process exit code that identifies the class of a failure
+type Code int
+
+const (
+	// OK means the command completed successfully
+	OK Code = 0
+
+	// General is used for failures that do not belong to a more specific class
+	General Code = 1
+
+	// Usage means the command line was invalid (unknown flags, mixed file types, ...)
+	Usage Code = 2
+
+	// Config means the YAML configuration could not be read, parsed, or validated
+	Config Code = 3
+
+	// Preconditions means a required external tool (OpenSCAD) is missing
+	Preconditions Code = 4
+
+	// Input means an input file is missing, unreadable, or not supported
+	Input Code = 5
+
+	// Render means rendering a SCAD file or converting an STL file failed
+	Render Code = 6
+
+	// Output means combining the models or writing the output file failed
+	Output Code = 7
+
+	// Outdated means self-update --check found a newer (or different pinned) release
+	Outdated Code = 8
+
+	// Update means checking, downloading, or installing a release failed
+	Update Code = 9
+
+	// Utilization means a plate is used less or more than the configured thresholds allow
+	Utilization Code = 10
+
+	// Drift means inspect --config found differences between a 3MF file and its configuration
+	Drift Code = 11
+)
+
+// String returns a short name for the exit code
+func (c Code) String() string {
+	switch c {
+	case OK:
+		return "ok"
+	case Usage:
+		return "usage"
+	case Config:
+		return "config"
+	case Preconditions:
+		return "preconditions"
+	case Input:
+		return "input"
+	case Render:
+		return "render"
+	case Output:
+		return "output"
+	case Outdated:
+		return "outdated"
+	case Update:
+		return "update"
+	case Utilization:
+		return "utilization"
+	case Drift:
+		return "drift"
+	default:
+		return "general"
+	}
+}
+
+// Error is an error that carries the exit code the process should terminate with
+type Error struct {
+	Code Code
+	Err  error
+}
+
+// Error returns the message of the wrapped error
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the wrapped error
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Wrap attaches an exit code to err. A nil err stays nil.
+// If err already carries an exit code it is returned unchanged,
+// so the most specific classification (closest to the failure) wins.
+func Wrap(code Code, err error) error {
+	if err == nil {
+		return nil
+	}
+	var coded *Error
+	if errors.As(err, &coded) {
+		return err
+	}
+	return &Error{Code: code, Err: err}
+}
+
+// FromError returns the exit code for err: OK for nil, the attached code
+// for classified errors, and General for everything else
+func FromError(err error) Code {
+	if err == nil {
+		return OK
+	}
+	var coded *Error
+	if errors.As(err, &coded) {
+		return coded.Code
+	}
+	return General
+}
@@ -0,0 +1,48 @@
+package exitcode
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestFromError(t *testing.T) {
+	base := errors.New("boom")
+
+	tests := []struct {
+		name string
+		err  error
+		want Code
+	}{
+		{"nil", nil, OK},
+		{"unclassified", base, General},
+		{"classified", Wrap(Config, base), Config},
+		{"wrapped classified", fmt.Errorf("context: %w", Wrap(Render, base)), Render},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FromError(tt.err); got != tt.want {
+				t.Errorf("FromError() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWrap_KeepsMostSpecificCode(t *testing.T) {
+	inner := Wrap(Preconditions, errors.New("openscad missing"))
+	outer := Wrap(Usage, fmt.Errorf("failed to create build plan: %w", inner))
+
+	if got := FromError(outer); got != Preconditions {
+		t.Errorf("FromError() = %v, want %v", got, Preconditions)
+	}
+	if outer.Error() != "failed to create build plan: openscad missing" {
+		t.Errorf("Error() = %q, message should be preserved", outer.Error())
+	}
+}
+
+func TestWrap_Nil(t *testing.T) {
+	if err := Wrap(Output, nil); err != nil {
+		t.Errorf("Wrap(nil) = %v, want nil", err)
+	}
+}
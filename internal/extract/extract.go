@@ -3,6 +3,7 @@ package extract
 import (
 	"archive/zip"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
 	"math"
@@ -11,6 +12,9 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/philipparndt/go3mf/internal/exitcode"
+	"github.com/philipparndt/go3mf/internal/geometry"
+	"github.com/philipparndt/go3mf/internal/limits"
 	"github.com/philipparndt/go3mf/internal/models"
 	"github.com/philipparndt/go3mf/internal/stl"
 	"github.com/philipparndt/go3mf/internal/ui"
@@ -18,7 +22,9 @@ import (
 
 // Extractor extracts 3D models from 3MF files
 type Extractor struct {
-	stlWriter *stl.Writer
+	stlWriter  *stl.Writer
+	asciiNames bool // Transliterate object names to ASCII for the file names
+	flatten    bool // Extract every object with all its components instead of every mesh
 }
 
 // NewExtractor creates a new Extractor
@@ -28,45 +34,56 @@ func NewExtractor() *Extractor {
 	}
 }
 
+// SetASCIINames transliterates the object names in the file names of extracted
+// models to ASCII, e.g. for file systems or tools that do not handle UTF-8
+func (e *Extractor) SetASCIINames(ascii bool) {
+	e.asciiNames = ascii
+}
+
+// SetFlatten extracts every object that is not a component of another one to
+// a single STL file with the meshes of all its components, resolving nested
+// components, instead of extracting every mesh on its own
+func (e *Extractor) SetFlatten(flatten bool) {
+	e.flatten = flatten
+}
+
+// maxComponentDepth bounds the nesting of components, so cyclic references fail
+const maxComponentDepth = 32
+
 // Vertex represents a 3D vertex
 type Vertex struct {
 	X, Y, Z float32
 }
 
-// Triangle represents a triangle by vertex indices
-type Triangle struct {
-	V1, V2, V3 int
+// objectInfo is an object of a model without its mesh
+type objectInfo struct {
+	ID   string
+	Name string
+	// Components of the object; their path is empty for components in the
+	// same model
+	Components []models.Component
 }
 
-// ParsedMesh represents a parsed mesh with vertices and triangles
-type ParsedMesh struct {
-	Vertices  []Vertex
-	Triangles []Triangle
-}
+// errStop stops streaming the objects of a model
+var errStop = errors.New("stop")
 
-// Extract extracts all 3D models from a 3MF file to STL files
+// Extract extracts all 3D models from a 3MF file to STL files. The model is
+// decoded as a stream and the triangles are written as they are read, so only
+// the vertices of one mesh are held in memory.
 func (e *Extractor) Extract(filename string, outputDir string, binary bool) error {
 	// Create output directory if it doesn't exist
 	if err := ensureDir(outputDir); err != nil {
-		return fmt.Errorf("error creating output directory: %w", err)
+		return exitcode.Wrap(exitcode.Output, fmt.Errorf("error creating output directory: %w", err))
 	}
 
 	// Open the 3MF file
-	zr, err := zip.OpenReader(filename)
+	zr, err := limits.OpenZip(filename)
 	if err != nil {
 		return fmt.Errorf("error opening 3MF file: %w", err)
 	}
 	defer zr.Close()
 
-	// Find and read the model file
-	var modelFile *zip.File
-	for _, f := range zr.File {
-		if f.Name == "3D/3dmodel.model" {
-			modelFile = f
-			break
-		}
-	}
-
+	modelFile := findFile(&zr.Reader, "3D/3dmodel.model")
 	if modelFile == nil {
 		return fmt.Errorf("3D/3dmodel.model not found in archive")
 	}
@@ -77,68 +94,63 @@ func (e *Extractor) Extract(filename string, outputDir string, binary bool) erro
 	}
 	defer rc.Close()
 
-	data, err := io.ReadAll(rc)
-	if err != nil {
-		return fmt.Errorf("error reading model file: %w", err)
+	// Read object names from model_settings.config if available
+	objectNames := e.readObjectNames(&zr.Reader)
+	objectName := func(obj objectInfo) string {
+		if settingsName, ok := objectNames[obj.ID]; ok && settingsName != "" {
+			return settingsName
+		}
+		return obj.Name
 	}
 
-	var model models.Model
-	if err := xml.Unmarshal(data, &model); err != nil {
-		return fmt.Errorf("error parsing XML: %w", err)
+	if e.flatten {
+		return e.extractFlattened(&zr.Reader, rc, objectName, outputDir, binary)
 	}
 
-	// Read object names from model_settings.config if available
-	objectNames := e.readObjectNames(&zr.Reader)
-
 	// Extract each mesh object
 	extractedCount := 0
-	for _, obj := range model.Resources.Objects {
-		// Get the object name from settings if available
-		objectName := obj.Name
-		if settingsName, ok := objectNames[obj.ID]; ok && settingsName != "" {
-			objectName = settingsName
+	extractMesh := func(obj objectInfo, dec *xml.Decoder) error {
+		extracted, err := e.extractMesh(objectName(obj), obj.ID, dec, outputDir, binary, extractedCount)
+		if extracted {
+			extractedCount++
 		}
+		return err
+	}
 
-		// Check if object has a direct mesh
-		if obj.Mesh != nil && obj.Mesh.Vertices != nil && obj.Mesh.Triangles != nil {
-			if err := e.extractMesh(objectName, obj.ID, obj.Mesh, outputDir, binary, extractedCount); err != nil {
-				ui.PrintError(fmt.Sprintf("Error extracting mesh for object %s (ID: %s): %v", objectName, obj.ID, err))
+	// Objects with components need to look up the referenced models
+	extractComponents := func(obj objectInfo) error {
+		name := objectName(obj)
+		for compIdx, component := range obj.Components {
+			path := component.Path
+			if path == "" {
 				continue
 			}
-			extractedCount++
-		} else if obj.Components != nil && len(obj.Components.Component) > 0 {
-			// Object has components - need to look up referenced models
-			for compIdx, comp := range obj.Components.Component {
-				// Check if component references an external model file
-				if comp.Path != "" {
-					// Read the external model file
-					externalMesh, externalName, err := e.readExternalModel(&zr.Reader, comp.Path)
-					if err != nil {
-						ui.PrintError(fmt.Sprintf("Error reading external model %s: %v", comp.Path, err))
-						continue
-					}
-
-					// Generate a name for this component
-					name := objectName
-					if name == "" {
-						name = fmt.Sprintf("object_%s_component_%d", obj.ID, compIdx)
-					} else if len(obj.Components.Component) > 1 {
-						// Use part name from external model if available
-						if externalName != "" {
-							name = externalName
-						} else {
-							name = fmt.Sprintf("%s_part_%d", name, compIdx+1)
-						}
-					}
-
-					if err := e.extractMesh(name, obj.ID, externalMesh, outputDir, binary, extractedCount); err != nil {
-						ui.PrintError(fmt.Sprintf("Error extracting component mesh: %v", err))
-						continue
+			extracted, err := e.extractExternalModel(&zr.Reader, path, func(externalName string) string {
+				// Generate a name for this component
+				if name == "" {
+					return fmt.Sprintf("object_%s_component_%d", obj.ID, compIdx)
+				}
+				if len(obj.Components) > 1 {
+					// Use part name from external model if available
+					if externalName != "" {
+						return externalName
 					}
-					extractedCount++
+					return fmt.Sprintf("%s_part_%d", name, compIdx+1)
 				}
+				return name
+			}, obj.ID, outputDir, binary, extractedCount)
+			if err != nil {
+				ui.PrintError(fmt.Sprintf("Error reading external model %s: %v", path, err))
+			}
+			if extracted {
+				extractedCount++
 			}
 		}
+		return nil
+	}
+
+	if err := streamObjects(rc, extractMesh, extractComponents); err != nil {
+		return fmt.Errorf("error parsing XML: %w", err)
 	}
 
 	if extractedCount == 0 {
@@ -149,185 +161,390 @@ func (e *Extractor) Extract(filename string, outputDir string, binary bool) erro
 	return nil
 }
 
-// extractMesh extracts a single mesh and writes it to an STL file
-func (e *Extractor) extractMesh(name, id string, mesh *models.Mesh, outputDir string, binary bool, index int) error {
-	// Parse the mesh
-	parsedMesh, err := e.parseMesh(mesh)
-	if err != nil {
-		return fmt.Errorf("error parsing mesh: %w", err)
-	}
-
-	// Convert to STL mesh
-	stlMesh := e.convertToSTLMesh(parsedMesh, name)
-
-	// Generate output filename
-	outputFilename := e.generateFilename(name, id, outputDir, index)
-
-	// Write STL file
-	if binary {
-		err = e.stlWriter.WriteBinary(stlMesh, outputFilename)
-	} else {
-		err = e.stlWriter.WriteASCII(stlMesh, outputFilename)
-	}
+// ExtractObject writes the mesh of a single object to an STL file. path is the
+// model file of the object in the archive ("" for the main model), transform
+// the 3MF matrix applied to its vertices ("" for none) and name the name of the
+// solid in the STL file.
+func (e *Extractor) ExtractObject(zr *zip.Reader, path, objectID, transform, name, outputFile string, binary bool) error {
+	return e.ExtractComponents(zr, []models.Component{{ObjectID: objectID, Path: path, Transform: transform}}, name, outputFile, binary)
+}
 
+// ExtractComponents writes the meshes of several objects to one STL file, e.g.
+// the parts of an object. Each component names a mesh object, the model file it
+// is in ("" for the main model) and the 3MF matrix applied to its vertices.
+func (e *Extractor) ExtractComponents(zr *zip.Reader, components []models.Component, name, outputFile string, binary bool) error {
+	out, err := stl.NewStreamWriter(outputFile, name, binary)
 	if err != nil {
 		return fmt.Errorf("error writing STL file: %w", err)
 	}
-
-	ui.PrintInfo(fmt.Sprintf("Extracted: %s", outputFilename))
-	return nil
-}
-
-// readExternalModel reads an external model file from the ZIP archive
-func (e *Extractor) readExternalModel(zr *zip.Reader, path string) (*models.Mesh, string, error) {
-	// Remove leading slash if present (component paths often have it, but ZIP entries don't)
-	cleanPath := strings.TrimPrefix(path, "/")
-
-	// Find the external model file in the archive
-	var externalFile *zip.File
-	for _, f := range zr.File {
-		if f.Name == cleanPath || f.Name == path {
-			externalFile = f
+	for _, component := range components {
+		if err = writeComponent(zr, component, out, 0); err != nil {
 			break
 		}
 	}
-
-	if externalFile == nil {
-		return nil, "", fmt.Errorf("external model file %s not found", path)
+	if closeErr := out.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("error writing STL file: %w", closeErr)
 	}
-
-	// Open and read the file
-	rc, err := externalFile.Open()
 	if err != nil {
-		return nil, "", fmt.Errorf("error opening external model file: %w", err)
+		os.Remove(outputFile)
+		return err
 	}
-	defer rc.Close()
+	return nil
+}
 
-	data, err := io.ReadAll(rc)
+// writeComponent writes the transformed mesh of a component to an STL file. The
+// components of an object made of components are written recursively, with
+// their transforms applied before the one of the component.
+func writeComponent(zr *zip.Reader, component models.Component, out *stl.StreamWriter, depth int) error {
+	if depth > maxComponentDepth {
+		return fmt.Errorf("components of object %s are nested too deeply or refer to themselves", component.ObjectID)
+	}
+	m, err := geometry.ParseTransform(component.Transform)
 	if err != nil {
-		return nil, "", fmt.Errorf("error reading external model file: %w", err)
+		return err
 	}
-
-	// Parse the external model
-	var externalModel models.Model
-	if err := xml.Unmarshal(data, &externalModel); err != nil {
-		return nil, "", fmt.Errorf("error parsing external model XML: %w", err)
+	path := component.Path
+	if path == "" {
+		path = "3D/3dmodel.model"
+	}
+	modelFile := findFile(zr, strings.TrimPrefix(path, "/"))
+	if modelFile == nil {
+		return fmt.Errorf("model file %s not found", path)
+	}
+	rc, err := modelFile.Open()
+	if err != nil {
+		return fmt.Errorf("error opening model file: %w", err)
 	}
 
-	// Find the first object with a mesh in the external model
-	for _, obj := range externalModel.Resources.Objects {
-		if obj.Mesh != nil && obj.Mesh.Vertices != nil && obj.Mesh.Triangles != nil {
-			return obj.Mesh, obj.Name, nil
+	found := false
+	var children []models.Component
+	err = streamObjects(rc, func(obj objectInfo, dec *xml.Decoder) error {
+		if obj.ID != component.ObjectID {
+			return dec.Skip()
+		}
+		found = true
+		err := writeMesh(dec, out, func(v Vertex) Vertex {
+			x, y, z := geometry.TransformPoint(m, float64(v.X), float64(v.Y), float64(v.Z))
+			return Vertex{X: float32(x), Y: float32(y), Z: float32(z)}
+		})
+		if err != nil {
+			return err
+		}
+		return errStop
+	}, func(obj objectInfo) error {
+		if obj.ID != component.ObjectID {
+			return nil
+		}
+		found = true
+		children = obj.Components
+		return errStop
+	})
+	rc.Close()
+	if err != nil && !errors.Is(err, errStop) {
+		return fmt.Errorf("error parsing model XML: %w", err)
+	}
+	if !found {
+		return fmt.Errorf("object %s has no mesh in %s", component.ObjectID, path)
+	}
+
+	for _, child := range children {
+		t, err := geometry.ParseTransform(child.Transform)
+		if err != nil {
+			return err
+		}
+		// Components without a path are in the model file of their object
+		if child.Path == "" {
+			child.Path = component.Path
+		}
+		child.Transform = geometry.FormatTransform(geometry.MultiplyTransforms(t, m))
+		if err := writeComponent(zr, child, out, depth+1); err != nil {
+			return err
 		}
 	}
-
-	return nil, "", fmt.Errorf("no mesh found in external model")
+	return nil
 }
 
-// parseMesh parses the XML mesh data into a structured mesh
-func (e *Extractor) parseMesh(mesh *models.Mesh) (*ParsedMesh, error) {
-	parsed := &ParsedMesh{}
-
-	// Parse vertices
-	verticesXML := mesh.Vertices.RawContent
-	vertexLines := strings.Split(verticesXML, "<vertex")
+// extractFlattened extracts every object of the model read from r that is not a
+// component of another object to an STL file with all its meshes
+func (e *Extractor) extractFlattened(zr *zip.Reader, r io.Reader, objectName func(objectInfo) string, outputDir string, binary bool) error {
+	var objects []objectInfo
+	used := make(map[string]bool) // Objects used as components of the main model
+	err := streamObjects(r, func(obj objectInfo, dec *xml.Decoder) error {
+		objects = append(objects, obj)
+		return dec.Skip()
+	}, func(obj objectInfo) error {
+		objects = append(objects, obj)
+		for _, component := range obj.Components {
+			if component.Path == "" {
+				used[component.ObjectID] = true
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("error parsing XML: %w", err)
+	}
 
-	for _, line := range vertexLines {
-		if !strings.Contains(line, "x=") {
+	extractedCount := 0
+	for _, obj := range objects {
+		if used[obj.ID] {
 			continue
 		}
-
-		var x, y, z float32
-		// Extract x, y, z attributes
-		if _, err := fmt.Sscanf(line, ` x="%f" y="%f" z="%f"`, &x, &y, &z); err != nil {
-			// Try without quotes
-			if _, err := fmt.Sscanf(line, ` x=%f y=%f z=%f`, &x, &y, &z); err != nil {
-				continue
-			}
+		name := objectName(obj)
+		outputFilename := e.generateFilename(name, obj.ID, outputDir, extractedCount)
+		if err := e.ExtractObject(zr, "", obj.ID, "", name, outputFilename, binary); err != nil {
+			ui.PrintError(fmt.Sprintf("Error extracting object %s (ID: %s): %v", name, obj.ID, err))
+			continue
 		}
-
-		parsed.Vertices = append(parsed.Vertices, Vertex{X: x, Y: y, Z: z})
+		ui.PrintInfo(fmt.Sprintf("Extracted: %s", outputFilename))
+		extractedCount++
 	}
 
-	// Parse triangles
-	trianglesXML := mesh.Triangles.RawContent
-	triangleLines := strings.Split(trianglesXML, "<triangle")
+	if extractedCount == 0 {
+		return fmt.Errorf("no mesh objects found in 3MF file")
+	}
+	ui.PrintSuccess(fmt.Sprintf("Successfully extracted %d object(s) to %s", extractedCount, outputDir))
+	return nil
+}
 
-	for _, line := range triangleLines {
-		if !strings.Contains(line, "v1=") {
-			continue
+// streamObjects decodes the objects of a model. mesh is called for each object
+// with a mesh while the decoder is at its mesh element and must consume the mesh.
+// components is called after each object with components.
+func streamObjects(r io.Reader, mesh func(objectInfo, *xml.Decoder) error, components func(objectInfo) error) error {
+	dec := xml.NewDecoder(r)
+	var obj *objectInfo
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
 		}
 
-		var v1, v2, v3 int
-		// Extract v1, v2, v3 attributes
-		if _, err := fmt.Sscanf(line, ` v1="%d" v2="%d" v3="%d"`, &v1, &v2, &v3); err != nil {
-			// Try without quotes
-			if _, err := fmt.Sscanf(line, ` v1=%d v2=%d v3=%d`, &v1, &v2, &v3); err != nil {
-				continue
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "object":
+				obj = &objectInfo{ID: attr(t, "id"), Name: attr(t, "name")}
+			case "mesh":
+				if obj != nil {
+					if err := mesh(*obj, dec); err != nil {
+						return err
+					}
+				}
+			case "component":
+				if obj != nil {
+					obj.Components = append(obj.Components, models.Component{
+						ObjectID:  attr(t, "objectid"),
+						Path:      attr(t, "path"),
+						Transform: attr(t, "transform"),
+					})
+				}
+			}
+		case xml.EndElement:
+			if t.Name.Local == "object" && obj != nil {
+				if len(obj.Components) > 0 {
+					if err := components(*obj); err != nil {
+						return err
+					}
+				}
+				obj = nil
 			}
 		}
+	}
+}
+
+// extractMesh streams the mesh at the current position of the decoder to an STL
+// file. It reports whether the file was written; meshes without triangles are
+// reported and skipped, errors of the decoder or the file are returned.
+func (e *Extractor) extractMesh(name, id string, dec *xml.Decoder, outputDir string, binary bool, index int) (bool, error) {
+	// Generate output filename
+	outputFilename := e.generateFilename(name, id, outputDir, index)
 
-		parsed.Triangles = append(parsed.Triangles, Triangle{V1: v1, V2: v2, V3: v3})
+	out, err := stl.NewStreamWriter(outputFilename, name, binary)
+	if err != nil {
+		return false, fmt.Errorf("error writing STL file: %w", err)
 	}
 
-	if len(parsed.Vertices) == 0 || len(parsed.Triangles) == 0 {
-		return nil, fmt.Errorf("no vertices or triangles found")
+	err = writeMesh(dec, out, nil)
+	if closeErr := out.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("error writing STL file: %w", closeErr)
+	}
+	if err == nil && out.Count() == 0 {
+		ui.PrintError(fmt.Sprintf("Error extracting mesh for object %s (ID: %s): no vertices or triangles found", name, id))
+		os.Remove(outputFilename)
+		return false, nil
+	}
+	if err != nil {
+		os.Remove(outputFilename)
+		return false, err
 	}
 
-	return parsed, nil
+	ui.PrintInfo(fmt.Sprintf("Extracted: %s", outputFilename))
+	return true, nil
 }
 
-// convertToSTLMesh converts a parsed mesh to an STL mesh
-func (e *Extractor) convertToSTLMesh(mesh *ParsedMesh, name string) *stl.Mesh {
-	stlMesh := &stl.Mesh{
-		Name:      name,
-		Triangles: []stl.Triangle{},
+// extractExternalModel extracts the first mesh of an external model file from
+// the ZIP archive. name returns the STL name for the name of the object in the
+// external model.
+func (e *Extractor) extractExternalModel(zr *zip.Reader, path string, name func(string) string, id, outputDir string, binary bool, index int) (bool, error) {
+	// Remove leading slash if present (component paths often have it, but ZIP entries don't)
+	externalFile := findFile(zr, strings.TrimPrefix(path, "/"))
+	if externalFile == nil {
+		externalFile = findFile(zr, path)
+	}
+	if externalFile == nil {
+		return false, fmt.Errorf("external model file %s not found", path)
+	}
+
+	rc, err := externalFile.Open()
+	if err != nil {
+		return false, fmt.Errorf("error opening external model file: %w", err)
 	}
+	defer rc.Close()
 
-	for _, tri := range mesh.Triangles {
-		if tri.V1 >= len(mesh.Vertices) || tri.V2 >= len(mesh.Vertices) || tri.V3 >= len(mesh.Vertices) {
-			continue
+	found, extracted := false, false
+	err = streamObjects(rc, func(obj objectInfo, dec *xml.Decoder) error {
+		found = true
+		var err error
+		extracted, err = e.extractMesh(name(obj.Name), id, dec, outputDir, binary, index)
+		if err != nil {
+			return err
 		}
+		return errStop
+	}, func(objectInfo) error { return nil })
+	if err != nil && !errors.Is(err, errStop) {
+		return false, fmt.Errorf("error parsing external model XML: %w", err)
+	}
+	if !found {
+		return false, fmt.Errorf("no mesh found in external model")
+	}
+	return extracted, nil
+}
 
-		v1 := mesh.Vertices[tri.V1]
-		v2 := mesh.Vertices[tri.V2]
-		v3 := mesh.Vertices[tri.V3]
-
-		// Calculate normal (cross product of two edges)
-		// Edge1 = v2 - v1
-		// Edge2 = v3 - v1
-		// Normal = Edge1 x Edge2
-		edge1 := stl.Vector3{X: v2.X - v1.X, Y: v2.Y - v1.Y, Z: v2.Z - v1.Z}
-		edge2 := stl.Vector3{X: v3.X - v1.X, Y: v3.Y - v1.Y, Z: v3.Z - v1.Z}
-
-		normal := stl.Vector3{
-			X: edge1.Y*edge2.Z - edge1.Z*edge2.Y,
-			Y: edge1.Z*edge2.X - edge1.X*edge2.Z,
-			Z: edge1.X*edge2.Y - edge1.Y*edge2.X,
+// writeMesh reads the vertices and triangles of a mesh from the decoder until
+// the end of the mesh element and writes the triangles with their normals.
+// Vertices are transformed unless transform is nil. Vertices and triangles that
+// cannot be parsed are skipped.
+func writeMesh(dec *xml.Decoder, out *stl.StreamWriter, transform func(Vertex) Vertex) error {
+	var vertices []Vertex
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "vertex":
+				if v, ok := parseVertex(t); ok {
+					if transform != nil {
+						v = transform(v)
+					}
+					vertices = append(vertices, v)
+					if err := limits.CheckVertices(int64(len(vertices))); err != nil {
+						return err
+					}
+				}
+			case "triangle":
+				if tri, ok := parseTriangle(t, vertices); ok {
+					if err := out.Write(tri); err != nil {
+						return err
+					}
+				}
+			}
+		case xml.EndElement:
+			if t.Name.Local == "mesh" {
+				return nil
+			}
 		}
+	}
+}
 
-		// Normalize the normal vector
-		lengthSquared := float64(normal.X)*float64(normal.X) +
-			float64(normal.Y)*float64(normal.Y) +
-			float64(normal.Z)*float64(normal.Z)
-		if lengthSquared > 0 {
-			length := float32(math.Sqrt(lengthSquared))
-			normal.X /= length
-			normal.Y /= length
-			normal.Z /= length
+// parseVertex parses the coordinates of a vertex element
+func parseVertex(el xml.StartElement) (Vertex, bool) {
+	var coords [3]float32
+	for i, name := range []string{"x", "y", "z"} {
+		value, err := strconv.ParseFloat(strings.TrimSpace(attr(el, name)), 32)
+		if err != nil {
+			return Vertex{}, false
 		}
+		coords[i] = float32(value)
+	}
+	return Vertex{X: coords[0], Y: coords[1], Z: coords[2]}, true
+}
 
-		stlTriangle := stl.Triangle{
-			Normal: normal,
-			V1:     stl.Vector3{X: v1.X, Y: v1.Y, Z: v1.Z},
-			V2:     stl.Vector3{X: v2.X, Y: v2.Y, Z: v2.Z},
-			V3:     stl.Vector3{X: v3.X, Y: v3.Y, Z: v3.Z},
+// parseTriangle parses a triangle element and returns it as STL triangle with
+// its normal
+func parseTriangle(el xml.StartElement, vertices []Vertex) (stl.Triangle, bool) {
+	var corners [3]Vertex
+	for i, name := range []string{"v1", "v2", "v3"} {
+		index, err := strconv.Atoi(strings.TrimSpace(attr(el, name)))
+		if err != nil || index < 0 || index >= len(vertices) {
+			return stl.Triangle{}, false
 		}
+		corners[i] = vertices[index]
+	}
+	return toSTLTriangle(corners[0], corners[1], corners[2]), true
+}
 
-		stlMesh.Triangles = append(stlMesh.Triangles, stlTriangle)
+// toSTLTriangle converts three vertices to an STL triangle
+func toSTLTriangle(v1, v2, v3 Vertex) stl.Triangle {
+	// Calculate normal (cross product of two edges)
+	// Edge1 = v2 - v1
+	// Edge2 = v3 - v1
+	// Normal = Edge1 x Edge2
+	edge1 := stl.Vector3{X: v2.X - v1.X, Y: v2.Y - v1.Y, Z: v2.Z - v1.Z}
+	edge2 := stl.Vector3{X: v3.X - v1.X, Y: v3.Y - v1.Y, Z: v3.Z - v1.Z}
+
+	normal := stl.Vector3{
+		X: edge1.Y*edge2.Z - edge1.Z*edge2.Y,
+		Y: edge1.Z*edge2.X - edge1.X*edge2.Z,
+		Z: edge1.X*edge2.Y - edge1.Y*edge2.X,
+	}
+
+	// Normalize the normal vector
+	lengthSquared := float64(normal.X)*float64(normal.X) +
+		float64(normal.Y)*float64(normal.Y) +
+		float64(normal.Z)*float64(normal.Z)
+	if lengthSquared > 0 {
+		length := float32(math.Sqrt(lengthSquared))
+		normal.X /= length
+		normal.Y /= length
+		normal.Z /= length
+	}
+
+	return stl.Triangle{
+		Normal: normal,
+		V1:     stl.Vector3{X: v1.X, Y: v1.Y, Z: v1.Z},
+		V2:     stl.Vector3{X: v2.X, Y: v2.Y, Z: v2.Z},
+		V3:     stl.Vector3{X: v3.X, Y: v3.Y, Z: v3.Z},
 	}
+}
 
-	return stlMesh
+// attr returns the value of an attribute by its local name
+func attr(el xml.StartElement, name string) string {
+	for _, a := range el.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// findFile returns the file with the given name in the archive, or nil
+func findFile(zr *zip.Reader, name string) *zip.File {
+	for _, f := range zr.File {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
 }
 
 // generateFilename generates an output filename for an extracted model
@@ -339,15 +556,7 @@ func (e *Extractor) generateFilename(name string, id string, outputDir string, i
 	}
 
 	// Remove invalid filename characters
-	cleanName = strings.ReplaceAll(cleanName, "/", "_")
-	cleanName = strings.ReplaceAll(cleanName, "\\", "_")
-	cleanName = strings.ReplaceAll(cleanName, ":", "_")
-	cleanName = strings.ReplaceAll(cleanName, "*", "_")
-	cleanName = strings.ReplaceAll(cleanName, "?", "_")
-	cleanName = strings.ReplaceAll(cleanName, "\"", "_")
-	cleanName = strings.ReplaceAll(cleanName, "<", "_")
-	cleanName = strings.ReplaceAll(cleanName, ">", "_")
-	cleanName = strings.ReplaceAll(cleanName, "|", "_")
+	cleanName = SafeFilename(cleanName, e.asciiNames)
 
 	// Ensure unique filenames by adding index if needed
 	baseFilename := fmt.Sprintf("%s_%s.stl", cleanName, id)
@@ -380,13 +589,7 @@ func (e *Extractor) readObjectNames(zr *zip.Reader) map[string]string {
 	objectNames := make(map[string]string)
 
 	// Find the model_settings.config file
-	var settingsFile *zip.File
-	for _, f := range zr.File {
-		if f.Name == "Metadata/model_settings.config" {
-			settingsFile = f
-			break
-		}
-	}
+	settingsFile := findFile(zr, "Metadata/model_settings.config")
 
 	if settingsFile == nil {
 		// File doesn't exist, return empty map
@@ -0,0 +1,339 @@
+package extract
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/philipparndt/go3mf/internal/models"
+	"github.com/philipparndt/go3mf/internal/stl"
+)
+
+const testMesh = `<mesh>
+	<vertices>
+		<vertex x="0" y="0" z="0"/><vertex x="10" y="0" z="0"/><vertex x="0" y="10" z="0"/><vertex x="0" y="0" z="10"/>
+	</vertices>
+	<triangles>
+		<triangle v1="0" v2="2" v3="1"/><triangle v1="0" v2="1" v3="3"/>
+		<triangle v1="1" v2="2" v3="3"/><triangle v1="0" v2="3" v3="2"/>
+		<triangle v1="0" v2="1" v3="99"/>
+	</triangles>
+</mesh>`
+
+// writeTestArchive writes a 3MF file with the given entries
+func writeTestArchive(t *testing.T, entries map[string]string) string {
+	t.Helper()
+	filename := filepath.Join(t.TempDir(), "test.3mf")
+	file, err := os.Create(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	zw := zip.NewWriter(file)
+	for name, content := range entries {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+func TestExtract(t *testing.T) {
+	archive := writeTestArchive(t, map[string]string{
+		"3D/3dmodel.model": `<?xml version="1.0" encoding="UTF-8"?>
+<model unit="millimeter" xmlns="http://schemas.microsoft.com/3dmanufacturing/core/2015/02" xmlns:p="http://schemas.microsoft.com/3dmanufacturing/production/2015/06">
+	<resources>
+		<object id="1" name="direct" type="model">` + testMesh + `</object>
+		<object id="2" name="empty" type="model"><mesh><vertices/><triangles/></mesh></object>
+		<object id="3" type="model">
+			<components>
+				<component p:path="/3D/Objects/object_1.model" objectid="1"/>
+				<component p:path="/3D/Objects/object_2.model" objectid="1"/>
+			</components>
+		</object>
+	</resources>
+	<build><item objectid="1"/></build>
+</model>`,
+		"3D/Objects/object_1.model":      `<model><resources><object id="1" name="left">` + testMesh + `</object></resources></model>`,
+		"3D/Objects/object_2.model":      `<model><resources><object id="1">` + testMesh + `</object></resources></model>`,
+		"Metadata/model_settings.config": `<config><object id="3"><metadata key="name" value="assembly.stl"/></object></config>`,
+	})
+
+	for _, binary := range []bool{true, false} {
+		outputDir := t.TempDir()
+		if err := NewExtractor().Extract(archive, outputDir, binary); err != nil {
+			t.Fatalf("Extract() error = %v", err)
+		}
+
+		entries, err := os.ReadDir(outputDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		want := []string{"assembly_part_2_3_2.stl", "direct_1.stl", "left_3_1.stl"}
+		if len(names) != len(want) {
+			t.Fatalf("binary=%v: extracted %v, want %v", binary, names, want)
+		}
+		for i := range want {
+			if names[i] != want[i] {
+				t.Fatalf("binary=%v: extracted %v, want %v", binary, names, want)
+			}
+		}
+
+		for _, name := range names {
+			mesh, err := stl.NewParser().Parse(filepath.Join(outputDir, name))
+			if err != nil {
+				t.Fatalf("Parse(%s) error = %v", name, err)
+			}
+			// The triangle with an invalid vertex index is skipped
+			if len(mesh.Triangles) != 4 {
+				t.Errorf("binary=%v: %s has %d triangles, want 4", binary, name, len(mesh.Triangles))
+			}
+			if n := mesh.Triangles[0].Normal; n != (stl.Vector3{X: 0, Y: 0, Z: -1}) {
+				t.Errorf("binary=%v: %s normal = %v, want (0, 0, -1)", binary, name, n)
+			}
+		}
+	}
+}
+
+func TestExtractWithoutMeshes(t *testing.T) {
+	archive := writeTestArchive(t, map[string]string{
+		"3D/3dmodel.model": `<model><resources><object id="1"><mesh><vertices/><triangles/></mesh></object></resources></model>`,
+	})
+	outputDir := t.TempDir()
+	if err := NewExtractor().Extract(archive, outputDir, true); err == nil {
+		t.Error("Extract() should fail without triangles")
+	}
+	if entries, _ := os.ReadDir(outputDir); len(entries) != 0 {
+		t.Errorf("Extract() left %d files", len(entries))
+	}
+}
+
+func TestExtractObject(t *testing.T) {
+	archive := writeTestArchive(t, map[string]string{
+		"3D/3dmodel.model":          `<model><resources><object id="1"><components><component objectid="2"/></components></object></resources></model>`,
+		"3D/Objects/object_1.model": `<model><resources><object id="1">` + testMesh + `</object><object id="2">` + testMesh + `</object></resources></model>`,
+	})
+	zr, err := zip.OpenReader(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+
+	output := filepath.Join(t.TempDir(), "part.stl")
+	// Rotation by 90° around Z
+	if err := NewExtractor().ExtractObject(&zr.Reader, "/3D/Objects/object_1.model", "2", "0 1 0 -1 0 0 0 0 1 0 0 5", "part", output, true); err != nil {
+		t.Fatalf("ExtractObject() error = %v", err)
+	}
+	mesh, err := stl.NewParser().Parse(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mesh.Triangles) != 4 {
+		t.Fatalf("got %d triangles, want 4", len(mesh.Triangles))
+	}
+	// The first triangle is (0,0,0), (0,10,0), (10,0,0)
+	if tri := mesh.Triangles[0]; tri.V2 != (stl.Vector3{X: -10, Y: 0, Z: 5}) || tri.V3 != (stl.Vector3{X: 0, Y: 10, Z: 5}) {
+		t.Errorf("transformed triangle = %v", tri)
+	}
+
+	if err := NewExtractor().ExtractObject(&zr.Reader, "", "1", "", "part", output, true); err == nil {
+		t.Error("ExtractObject() should fail for an object without mesh")
+	}
+}
+
+func TestExtractComponents(t *testing.T) {
+	archive := writeTestArchive(t, map[string]string{
+		"3D/3dmodel.model": `<model><resources><object id="1">` + testMesh + `</object><object id="2">` + testMesh + `</object></resources></model>`,
+	})
+	zr, err := zip.OpenReader(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+
+	output := filepath.Join(t.TempDir(), "object.stl")
+	components := []models.Component{
+		{ObjectID: "1"},
+		{ObjectID: "2", Transform: "1 0 0 0 1 0 0 0 1 20 0 0"},
+	}
+	if err := NewExtractor().ExtractComponents(&zr.Reader, components, "object", output, true); err != nil {
+		t.Fatalf("ExtractComponents() error = %v", err)
+	}
+	mesh, err := stl.NewParser().Parse(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mesh.Triangles) != 8 {
+		t.Fatalf("got %d triangles, want 8", len(mesh.Triangles))
+	}
+	if v := mesh.Triangles[4].V1; v != (stl.Vector3{X: 20, Y: 0, Z: 0}) {
+		t.Errorf("first vertex of the second component = %v, want (20, 0, 0)", v)
+	}
+
+	components = append(components, models.Component{ObjectID: "3"})
+	if err := NewExtractor().ExtractComponents(&zr.Reader, components, "object", output, true); err == nil {
+		t.Error("ExtractComponents() should fail for a missing object")
+	}
+	if _, err := os.Stat(output); !os.IsNotExist(err) {
+		t.Error("ExtractComponents() should remove the file of a failed export")
+	}
+}
+
+func TestExtractNestedComponents(t *testing.T) {
+	archive := writeTestArchive(t, map[string]string{
+		"3D/3dmodel.model": `<model><resources>
+			<object id="1">` + testMesh + `</object>
+			<object id="2" name="pair"><components><component objectid="1" transform="1 0 0 0 1 0 0 0 1 20 0 0"/></components></object>
+			<object id="3" name="assembly"><components>
+				<component objectid="2" transform="1 0 0 0 1 0 0 0 1 0 0 5"/>
+				<component objectid="1"/>
+			</components></object>
+			<object id="4" name="loop"><components><component objectid="4"/></components></object>
+		</resources></model>`,
+	})
+	zr, err := zip.OpenReader(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+
+	output := filepath.Join(t.TempDir(), "assembly.stl")
+	if err := NewExtractor().ExtractObject(&zr.Reader, "", "3", "", "assembly", output, true); err != nil {
+		t.Fatalf("ExtractObject() error = %v", err)
+	}
+	mesh, err := stl.NewParser().Parse(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mesh.Triangles) != 8 {
+		t.Fatalf("got %d triangles, want 8", len(mesh.Triangles))
+	}
+	// The transforms of both levels are applied to the nested mesh
+	if v := mesh.Triangles[0].V1; v != (stl.Vector3{X: 20, Y: 0, Z: 5}) {
+		t.Errorf("first vertex of the nested mesh = %v, want (20, 0, 5)", v)
+	}
+
+	if err := NewExtractor().ExtractObject(&zr.Reader, "", "4", "", "loop", output, true); err == nil || !strings.Contains(err.Error(), "nested too deeply") {
+		t.Errorf("ExtractObject() error = %v, want an error for cyclic components", err)
+	}
+}
+
+func TestExtractFlattened(t *testing.T) {
+	archive := writeTestArchive(t, map[string]string{
+		"3D/3dmodel.model": `<model xmlns:p="http://schemas.microsoft.com/3dmanufacturing/production/2015/06"><resources>
+			<object id="1">` + testMesh + `</object>
+			<object id="2" name="pair"><components>
+				<component objectid="1"/>
+				<component objectid="1" transform="1 0 0 0 1 0 0 0 1 20 0 0"/>
+			</components></object>
+			<object id="3" name="external"><components><component p:path="/3D/Objects/object_1.model" objectid="1"/></components></object>
+			<object id="4" name="single">` + testMesh + `</object>
+		</resources></model>`,
+		"3D/Objects/object_1.model": `<model><resources><object id="1">` + testMesh + `</object></resources></model>`,
+	})
+
+	outputDir := t.TempDir()
+	extractor := NewExtractor()
+	extractor.SetFlatten(true)
+	if err := extractor.Extract(archive, outputDir, true); err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+
+	want := map[string]int{"pair_2.stl": 8, "external_3_1.stl": 4, "single_4_2.stl": 4}
+	entries, err := os.ReadDir(outputDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("extracted %d files, want %v", len(entries), want)
+	}
+	for _, entry := range entries {
+		triangles, ok := want[entry.Name()]
+		if !ok {
+			t.Errorf("unexpected file %s", entry.Name())
+			continue
+		}
+		mesh, err := stl.NewParser().Parse(filepath.Join(outputDir, entry.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(mesh.Triangles) != triangles {
+			t.Errorf("%s has %d triangles, want %d", entry.Name(), len(mesh.Triangles), triangles)
+		}
+	}
+}
+
+func TestSafeFilename(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		ascii bool
+		want  string
+	}{
+		{"plain", "box", false, "box"},
+		{"umlauts kept", "Würfel & Deckel", false, "Würfel & Deckel"},
+		{"CJK kept", "立方体", false, "立方体"},
+		{"unsafe characters", `a/b\c:d*e?f"g<h>i|j`, false, "a_b_c_d_e_f_g_h_i_j"},
+		{"control characters", "a\nb\tc", false, "a_b_c"},
+		{"invalid UTF-8", "a\xffb", false, "a_b"},
+		{"trailing dots and spaces", "box. .", false, "box"},
+		{"only dots", "..", false, "_"},
+		{"umlauts transliterated", "Würfel & Größe", true, "Wuerfel & Groesse"},
+		{"accents transliterated", "Crème brûlée", true, "Creme brulee"},
+		{"CJK replaced", "立方体 1", true, "___ 1"},
+		{"long name", strings.Repeat("ä", 150), false, strings.Repeat("ä", 100)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SafeFilename(tt.input, tt.ascii); got != tt.want {
+				t.Errorf("SafeFilename(%q, %v) = %q, want %q", tt.input, tt.ascii, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractNonASCIINames(t *testing.T) {
+	archive := writeTestArchive(t, map[string]string{
+		"3D/3dmodel.model": `<model><resources>` +
+			`<object id="1" name="Würfel &amp; Deckel">` + testMesh + `</object>` +
+			`<object id="2" name="立方体">` + testMesh + `</object>` +
+			`</resources></model>`,
+	})
+
+	tests := []struct {
+		ascii bool
+		want  []string
+	}{
+		{false, []string{"Würfel & Deckel_1.stl", "立方体_2_1.stl"}},
+		{true, []string{"Wuerfel & Deckel_1.stl", "____2_1.stl"}},
+	}
+	for _, tt := range tests {
+		outputDir := t.TempDir()
+		extractor := NewExtractor()
+		extractor.SetASCIINames(tt.ascii)
+		if err := extractor.Extract(archive, outputDir, true); err != nil {
+			t.Fatalf("Extract() error = %v", err)
+		}
+		for _, name := range tt.want {
+			if _, err := os.Stat(filepath.Join(outputDir, name)); err != nil {
+				entries, _ := os.ReadDir(outputDir)
+				t.Errorf("ascii=%v: %s not extracted, got %v", tt.ascii, name, entries)
+			}
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package extract
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// maxFilenameBytes limits the length of names in file names, leaving room for the
+// object ID and extension below the 255 bytes most file systems allow
+const maxFilenameBytes = 200
+
+// transliterations maps letters with diacritics and ligatures to ASCII
+var transliterations = func() map[rune]string {
+	m := map[rune]string{
+		'ä': "ae", 'ö': "oe", 'ü': "ue", 'Ä': "Ae", 'Ö': "Oe", 'Ü': "Ue", 'ß': "ss",
+		'æ': "ae", 'Æ': "Ae", 'œ': "oe", 'Œ': "Oe", 'þ': "th", 'Þ': "Th",
+	}
+	letters := map[string]string{
+		"àáâãåāăą": "a", "ÀÁÂÃÅĀĂĄ": "A",
+		"çćĉċč": "c", "ÇĆĈĊČ": "C",
+		"ďđð": "d", "ĎĐÐ": "D",
+		"èéêëēĕėęě": "e", "ÈÉÊËĒĔĖĘĚ": "E",
+		"ĝğġģ": "g", "ĜĞĠĢ": "G",
+		"ìíîïĩīĭįı": "i", "ÌÍÎÏĨĪĬĮİ": "I",
+		"ķ": "k", "Ķ": "K",
+		"ĺļľŀł": "l", "ĹĻĽĿŁ": "L",
+		"ñńņňŉ": "n", "ÑŃŅŇ": "N",
+		"òóôõøōŏő": "o", "ÒÓÔÕØŌŎŐ": "O",
+		"ŕŗř": "r", "ŔŖŘ": "R",
+		"śŝşšș": "s", "ŚŜŞŠȘ": "S",
+		"ţťŧț": "t", "ŢŤŦȚ": "T",
+		"ùúûũūŭůűų": "u", "ÙÚÛŨŪŬŮŰŲ": "U",
+		"ýÿŷ": "y", "ÝŸŶ": "Y",
+		"źżž": "z", "ŹŻŽ": "Z",
+	}
+	for runes, ascii := range letters {
+		for _, r := range runes {
+			m[r] = ascii
+		}
+	}
+	return m
+}()
+
+// Transliterate replaces letters with diacritics and ligatures in s by ASCII
+// letters, e.g. "Würfel" becomes "Wuerfel". Other characters are kept.
+func Transliterate(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if ascii, ok := transliterations[r]; ok {
+			b.WriteString(ascii)
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// SafeFilename returns name for use in a file name on all platforms. Path
+// separators, characters Windows does not allow, control characters and invalid
+// UTF-8 become "_", trailing dots and spaces are removed and long names are cut
+// at a character boundary. With ascii, letters are transliterated and all other
+// non-ASCII characters become "_" as well.
+func SafeFilename(name string, ascii bool) string {
+	name = strings.ToValidUTF8(name, "_")
+	if ascii {
+		name = Transliterate(name)
+	}
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r < 0x20 || r == 0x7f || strings.ContainsRune(`/\:*?"<>|`, r):
+			return '_'
+		case ascii && r >= utf8.RuneSelf:
+			return '_'
+		}
+		return r
+	}, name)
+
+	if len(name) > maxFilenameBytes {
+		cut := maxFilenameBytes
+		for cut > 0 && !utf8.RuneStart(name[cut]) {
+			cut--
+		}
+		name = name[:cut]
+	}
+	name = strings.TrimRight(name, " .")
+	if name == "" {
+		return "_"
+	}
+	return name
+}
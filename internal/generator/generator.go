@@ -0,0 +1,303 @@
+package generator
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// generators creates the OpenSCAD source of built-in parts by generator name
+var generators = map[string]func(p params) (string, error){
+	"text":     text,
+	"cube":     cube,
+	"cylinder": cylinder,
+	"plate":    plate,
+}
+
+// Names returns the names of all generators
+func Names() []string {
+	names := make([]string, 0, len(generators))
+	for name := range generators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SCAD returns the OpenSCAD source of a generated part and validates its parameters
+func SCAD(name string, parameters map[string]interface{}) (string, error) {
+	generate, ok := generators[name]
+	if !ok {
+		return "", fmt.Errorf("unknown generator %q (supported: %s)", name, strings.Join(Names(), ", "))
+	}
+	p := params{values: parameters, used: map[string]bool{}}
+	source, err := generate(p)
+	if err != nil {
+		return "", err
+	}
+	if err := p.checkUnused(); err != nil {
+		return "", err
+	}
+	return source, nil
+}
+
+// Validate checks the generator name and its parameters
+func Validate(name string, parameters map[string]interface{}) error {
+	_, err := SCAD(name, parameters)
+	return err
+}
+
+// Write writes the OpenSCAD source of a generated part to dir and returns its path.
+// The file name is derived from the source, so equal parts share one file.
+func Write(dir, name string, parameters map[string]interface{}) (string, error) {
+	source, err := SCAD(name, parameters)
+	if err != nil {
+		return "", err
+	}
+	return writeSource(dir, name, source)
+}
+
+// Extrusion returns the OpenSCAD source that extrudes a 2D SVG or DXF file
+func Extrusion(file string, height float64) string {
+	return fmt.Sprintf("// Generated by go3mf\nlinear_extrude(height = %s)\n    import(%s);\n",
+		number(height), quote(filepath.ToSlash(file)))
+}
+
+// WriteExtrusion writes the OpenSCAD source that extrudes a 2D SVG or DXF file to dir
+// and returns its path. file must be absolute, as the source is not written next to it.
+// The file is imported relative to dir, so the import also resolves when both are
+// copied to a render worker.
+func WriteExtrusion(dir, file string, height float64) (string, error) {
+	if absDir, err := filepath.Abs(dir); err == nil {
+		if rel, err := filepath.Rel(absDir, file); err == nil {
+			file = rel
+		}
+	}
+	return writeSource(dir, "extrude", Extrusion(file, height))
+}
+
+// writeSource writes a generated OpenSCAD source to a file named after its content
+func writeSource(dir, name, source string) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create directory for generated parts: %w", err)
+	}
+
+	sum := sha256.Sum256([]byte(source))
+	path := filepath.Join(dir, fmt.Sprintf("go3mf_%s_%s.scad", name, hex.EncodeToString(sum[:8])))
+	if err := os.WriteFile(path, []byte(source), 0644); err != nil {
+		return "", fmt.Errorf("failed to write generated part: %w", err)
+	}
+	return path, nil
+}
+
+// text generates an extruded text label
+func text(p params) (string, error) {
+	content, err := p.str("text", "")
+	if err != nil {
+		return "", err
+	}
+	if content == "" {
+		return "", fmt.Errorf("text is required")
+	}
+	font, err := p.str("font", "")
+	if err != nil {
+		return "", err
+	}
+	size, err := p.positive("size", 10)
+	if err != nil {
+		return "", err
+	}
+	depth, err := p.positive("depth", 1)
+	if err != nil {
+		return "", err
+	}
+
+	args := fmt.Sprintf("%s, size = %s", quote(content), number(size))
+	if font != "" {
+		args += ", font = " + quote(font)
+	}
+	return fmt.Sprintf("// Generated by go3mf\nlinear_extrude(height = %s)\n    text(%s, halign = \"center\", valign = \"center\", $fn = 64);\n",
+		number(depth), args), nil
+}
+
+// cube generates a box centered in X and Y that stands on the XY plane
+func cube(p params) (string, error) {
+	size, err := p.vector("size", 3, 10)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("// Generated by go3mf\ntranslate([%s, %s, 0])\n    cube([%s, %s, %s]);\n",
+		number(-size[0]/2), number(-size[1]/2), number(size[0]), number(size[1]), number(size[2])), nil
+}
+
+// cylinder generates a cylinder centered in X and Y that stands on the XY plane
+func cylinder(p params) (string, error) {
+	diameter, err := p.positive("diameter", 10)
+	if err != nil {
+		return "", err
+	}
+	height, err := p.positive("height", 10)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("// Generated by go3mf\ncylinder(d = %s, h = %s, $fn = 96);\n", number(diameter), number(height)), nil
+}
+
+// plate generates a flat plate with optionally rounded corners, centered in X and Y
+func plate(p params) (string, error) {
+	size, err := p.vector("size", 2, 50)
+	if err != nil {
+		return "", err
+	}
+	thickness, err := p.positive("thickness", 2)
+	if err != nil {
+		return "", err
+	}
+	radius, err := p.number("corner_radius", 0)
+	if err != nil {
+		return "", err
+	}
+	if radius < 0 {
+		return "", fmt.Errorf("corner_radius must not be negative")
+	}
+	if 2*radius >= min(size[0], size[1]) {
+		return "", fmt.Errorf("corner_radius must be less than half of the plate size")
+	}
+
+	shape := fmt.Sprintf("square([%s, %s], center = true);", number(size[0]), number(size[1]))
+	if radius > 0 {
+		shape = fmt.Sprintf("offset(r = %s, $fn = 64)\n        square([%s, %s], center = true);",
+			number(radius), number(size[0]-2*radius), number(size[1]-2*radius))
+	}
+	return fmt.Sprintf("// Generated by go3mf\nlinear_extrude(height = %s)\n    %s\n", number(thickness), shape), nil
+}
+
+// params gives typed access to generator parameters and tracks which were used
+type params struct {
+	values map[string]interface{}
+	used   map[string]bool
+}
+
+// str returns a string parameter
+func (p params) str(key, def string) (string, error) {
+	p.used[key] = true
+	value, ok := p.values[key]
+	if !ok || value == nil {
+		return def, nil
+	}
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case int, float64, bool:
+		return fmt.Sprint(v), nil
+	default:
+		return "", fmt.Errorf("%s must be a string", key)
+	}
+}
+
+// number returns a number parameter
+func (p params) number(key string, def float64) (float64, error) {
+	p.used[key] = true
+	value, ok := p.values[key]
+	if !ok || value == nil {
+		return def, nil
+	}
+	n, ok := toFloat(value)
+	if !ok {
+		return 0, fmt.Errorf("%s must be a number", key)
+	}
+	return n, nil
+}
+
+// positive returns a number parameter that must be greater than zero
+func (p params) positive(key string, def float64) (float64, error) {
+	n, err := p.number(key, def)
+	if err != nil {
+		return 0, err
+	}
+	if n <= 0 {
+		return 0, fmt.Errorf("%s must be positive", key)
+	}
+	return n, nil
+}
+
+// vector returns a parameter of n positive numbers, given as a list or as a single
+// number used for all of them
+func (p params) vector(key string, n int, def float64) ([]float64, error) {
+	p.used[key] = true
+	values := make([]float64, n)
+	value, ok := p.values[key]
+	if !ok || value == nil {
+		for i := range values {
+			values[i] = def
+		}
+		return values, nil
+	}
+
+	if list, ok := value.([]interface{}); ok {
+		if len(list) != n {
+			return nil, fmt.Errorf("%s must have %d values", key, n)
+		}
+		for i, item := range list {
+			if values[i], ok = toFloat(item); !ok {
+				return nil, fmt.Errorf("%s must be a list of numbers", key)
+			}
+		}
+	} else if f, ok := toFloat(value); ok {
+		for i := range values {
+			values[i] = f
+		}
+	} else {
+		return nil, fmt.Errorf("%s must be a number or a list of %d numbers", key, n)
+	}
+
+	for _, v := range values {
+		if v <= 0 {
+			return nil, fmt.Errorf("%s must be positive", key)
+		}
+	}
+	return values, nil
+}
+
+// checkUnused rejects parameters the generator does not know, which are usually typos
+func (p params) checkUnused() error {
+	var unknown []string
+	for key := range p.values {
+		if !p.used[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("unknown parameter(s): %s", strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// toFloat converts a number decoded from YAML
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+// quote returns s as an OpenSCAD string literal
+func quote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
+
+// number formats a number for OpenSCAD without needless digits
+func number(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
@@ -0,0 +1,119 @@
+package generator
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSCAD(t *testing.T) {
+	tests := []struct {
+		name      string
+		generator string
+		params    map[string]interface{}
+		want      []string
+		wantErr   string
+	}{
+		{
+			name:      "text defaults",
+			generator: "text",
+			params:    map[string]interface{}{"text": "Hi"},
+			want:      []string{"linear_extrude(height = 1)", `text("Hi", size = 10, halign`},
+		},
+		{
+			name:      "text with font",
+			generator: "text",
+			params:    map[string]interface{}{"text": `Say "hi" \o/`, "font": "Liberation Sans:style=Bold", "size": 7.5, "depth": 2},
+			want:      []string{"linear_extrude(height = 2)", `text("Say \"hi\" \\o/", size = 7.5, font = "Liberation Sans:style=Bold"`},
+		},
+		{
+			name:      "cube with size list",
+			generator: "cube",
+			params:    map[string]interface{}{"size": []interface{}{20, 10.5, 4}},
+			want:      []string{"translate([-10, -5.25, 0])", "cube([20, 10.5, 4]);"},
+		},
+		{
+			name:      "cube with single size",
+			generator: "cube",
+			params:    map[string]interface{}{"size": 5},
+			want:      []string{"cube([5, 5, 5]);"},
+		},
+		{
+			name:      "cylinder",
+			generator: "cylinder",
+			params:    map[string]interface{}{"diameter": 8, "height": 3},
+			want:      []string{"cylinder(d = 8, h = 3"},
+		},
+		{
+			name:      "plate with rounded corners",
+			generator: "plate",
+			params:    map[string]interface{}{"size": []interface{}{40, 20}, "thickness": 1.5, "corner_radius": 3},
+			want:      []string{"linear_extrude(height = 1.5)", "offset(r = 3", "square([34, 14], center = true);"},
+		},
+		{name: "cube size with wrong length", generator: "cube", params: map[string]interface{}{"size": []interface{}{1, 2}}, wantErr: "size must have 3 values"},
+		{name: "cube size not positive", generator: "cube", params: map[string]interface{}{"size": []interface{}{1, 0, 2}}, wantErr: "size must be positive"},
+		{name: "plate corner radius too large", generator: "plate", params: map[string]interface{}{"size": 10, "corner_radius": 5}, wantErr: "corner_radius must be less than half"},
+		{name: "missing text", generator: "text", wantErr: "text is required"},
+		{name: "invalid size", generator: "text", params: map[string]interface{}{"text": "A", "size": 0}, wantErr: "size must be positive"},
+		{name: "size not a number", generator: "text", params: map[string]interface{}{"text": "A", "size": "big"}, wantErr: "size must be a number"},
+		{name: "unknown parameter", generator: "text", params: map[string]interface{}{"text": "A", "hight": 2}, wantErr: "unknown parameter(s): hight"},
+		{name: "unknown generator", generator: "gear", wantErr: `unknown generator "gear"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source, err := SCAD(tt.generator, tt.params)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(source, want) {
+					t.Errorf("expected source to contain %q, got:\n%s", want, source)
+				}
+			}
+		})
+	}
+}
+
+func TestExtrusion(t *testing.T) {
+	source := Extrusion("/art/my logo.svg", 0.6)
+	for _, want := range []string{"linear_extrude(height = 0.6)", `import("/art/my logo.svg");`} {
+		if !strings.Contains(source, want) {
+			t.Errorf("expected source to contain %q, got:\n%s", want, source)
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	dir := t.TempDir()
+	params := map[string]interface{}{"text": "A"}
+
+	first, err := Write(dir, "text", params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := Write(dir, "text", params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != second {
+		t.Errorf("expected equal parts to share a file, got %s and %s", first, second)
+	}
+	if _, err := os.Stat(first); err != nil {
+		t.Errorf("expected generated file: %v", err)
+	}
+
+	other, err := Write(dir, "text", map[string]interface{}{"text": "B"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other == first {
+		t.Errorf("expected different parts to use different files")
+	}
+}
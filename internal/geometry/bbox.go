@@ -1,10 +1,8 @@
 package geometry
 
 import (
-	"encoding/xml"
 	"fmt"
 	"math"
-	"strconv"
 
 	"github.com/philipparndt/go3mf/internal/models"
 )
@@ -30,23 +28,6 @@ func (b *BoundingBox) Depth() float64 {
 	return b.MaxZ - b.MinZ
 }
 
-// Vertex represents a 3D vertex for parsing
-type Vertex struct {
-	X string `xml:"x,attr"`
-	Y string `xml:"y,attr"`
-	Z string `xml:"z,attr"`
-}
-
-// Vertices represents a collection of vertices
-type Vertices struct {
-	Vertex []Vertex `xml:"vertex"`
-}
-
-// Mesh represents a mesh for parsing
-type Mesh struct {
-	Vertices Vertices `xml:"vertices"`
-}
-
 // CalculateBoundingBox calculates the bounding box of a mesh object
 func CalculateBoundingBox(obj *models.Object) (*BoundingBox, error) {
 	if obj.Mesh == nil {
@@ -57,62 +38,29 @@ func CalculateBoundingBox(obj *models.Object) (*BoundingBox, error) {
 		return nil, fmt.Errorf("mesh has no vertices")
 	}
 
-	// Parse the raw vertices content
-	var vertices Vertices
-	verticesXML := fmt.Sprintf("<vertices>%s</vertices>", obj.Mesh.Vertices.RawContent)
-	if err := xml.Unmarshal([]byte(verticesXML), &vertices); err != nil {
-		return nil, fmt.Errorf("failed to parse mesh vertices: %w", err)
-	}
-
-	if len(vertices.Vertex) == 0 {
+	vertices := obj.Mesh.Vertices.Vertex
+	if len(vertices) == 0 {
 		return nil, fmt.Errorf("mesh has no vertices")
 	}
 
 	// Initialize with first vertex
-	firstVertex := vertices.Vertex[0]
-	x0, err := strconv.ParseFloat(firstVertex.X, 64)
-	if err != nil {
-		return nil, fmt.Errorf("invalid vertex X coordinate: %w", err)
-	}
-	y0, err := strconv.ParseFloat(firstVertex.Y, 64)
-	if err != nil {
-		return nil, fmt.Errorf("invalid vertex Y coordinate: %w", err)
-	}
-	z0, err := strconv.ParseFloat(firstVertex.Z, 64)
-	if err != nil {
-		return nil, fmt.Errorf("invalid vertex Z coordinate: %w", err)
-	}
-
 	bbox := &BoundingBox{
-		MinX: x0,
-		MinY: y0,
-		MinZ: z0,
-		MaxX: x0,
-		MaxY: y0,
-		MaxZ: z0,
+		MinX: vertices[0].X,
+		MinY: vertices[0].Y,
+		MinZ: vertices[0].Z,
+		MaxX: vertices[0].X,
+		MaxY: vertices[0].Y,
+		MaxZ: vertices[0].Z,
 	}
 
 	// Iterate through all vertices to find min/max
-	for _, vertex := range vertices.Vertex {
-		x, err := strconv.ParseFloat(vertex.X, 64)
-		if err != nil {
-			continue
-		}
-		y, err := strconv.ParseFloat(vertex.Y, 64)
-		if err != nil {
-			continue
-		}
-		z, err := strconv.ParseFloat(vertex.Z, 64)
-		if err != nil {
-			continue
-		}
-
-		bbox.MinX = math.Min(bbox.MinX, x)
-		bbox.MinY = math.Min(bbox.MinY, y)
-		bbox.MinZ = math.Min(bbox.MinZ, z)
-		bbox.MaxX = math.Max(bbox.MaxX, x)
-		bbox.MaxY = math.Max(bbox.MaxY, y)
-		bbox.MaxZ = math.Max(bbox.MaxZ, z)
+	for _, v := range vertices {
+		bbox.MinX = math.Min(bbox.MinX, v.X)
+		bbox.MinY = math.Min(bbox.MinY, v.Y)
+		bbox.MinZ = math.Min(bbox.MinZ, v.Z)
+		bbox.MaxX = math.Max(bbox.MaxX, v.X)
+		bbox.MaxY = math.Max(bbox.MaxY, v.Y)
+		bbox.MaxZ = math.Max(bbox.MaxZ, v.Z)
 	}
 
 	return bbox, nil
@@ -319,14 +267,8 @@ func RotateMeshVertices(obj *models.Object, rotX, rotY, rotZ float64) (float64,
 		return 0, fmt.Errorf("object has no mesh vertices")
 	}
 
-	// Parse vertices
-	var vertices Vertices
-	verticesXML := fmt.Sprintf("<vertices>%s</vertices>", obj.Mesh.Vertices.RawContent)
-	if err := xml.Unmarshal([]byte(verticesXML), &vertices); err != nil {
-		return 0, fmt.Errorf("failed to parse mesh vertices: %w", err)
-	}
-
-	if len(vertices.Vertex) == 0 {
+	vertices := obj.Mesh.Vertices.Vertex
+	if len(vertices) == 0 {
 		return 0, fmt.Errorf("mesh has no vertices")
 	}
 
@@ -354,38 +296,23 @@ func RotateMeshVertices(obj *models.Object, rotX, rotY, rotZ float64) (float64,
 	// Transform vertices and find minZ
 	minZ := math.MaxFloat64
 
-	// Build new vertices XML
-	var newVerticesXML string
-	for _, v := range vertices.Vertex {
-		x, err := strconv.ParseFloat(v.X, 64)
-		if err != nil {
-			continue
-		}
-		y, err := strconv.ParseFloat(v.Y, 64)
-		if err != nil {
-			continue
-		}
-		z, err := strconv.ParseFloat(v.Z, 64)
-		if err != nil {
-			continue
-		}
-
+	// Rotated vertices go into a new slice, slices taken from the mesh before stay unchanged
+	rotated := make([]models.Vertex, len(vertices))
+	for i, v := range vertices {
 		// Apply rotation
-		newX := m11*x + m21*y + m31*z
-		newY := m12*x + m22*y + m32*z
-		newZ := m13*x + m23*y + m33*z
+		newX := m11*v.X + m21*v.Y + m31*v.Z
+		newY := m12*v.X + m22*v.Y + m32*v.Z
+		newZ := m13*v.X + m23*v.Y + m33*v.Z
 
 		if newZ < minZ {
 			minZ = newZ
 		}
 
-		newVerticesXML += fmt.Sprintf("\n\t\t\t\t\t<vertex x=\"%.6f\" y=\"%.6f\" z=\"%.6f\"/>",
-			newX, newY, newZ)
+		rotated[i] = models.Vertex{X: newX, Y: newY, Z: newZ}
 	}
-	newVerticesXML += "\n\t\t\t\t"
 
 	// Update the mesh
-	obj.Mesh.Vertices.RawContent = newVerticesXML
+	obj.Mesh.Vertices.Vertex = rotated
 
 	return minZ, nil
 }
@@ -397,36 +324,14 @@ func ApplyZOffset(obj *models.Object, zOffset float64) error {
 		return fmt.Errorf("object has no mesh vertices")
 	}
 
-	// Parse vertices
-	var vertices Vertices
-	verticesXML := fmt.Sprintf("<vertices>%s</vertices>", obj.Mesh.Vertices.RawContent)
-	if err := xml.Unmarshal([]byte(verticesXML), &vertices); err != nil {
-		return fmt.Errorf("failed to parse mesh vertices: %w", err)
+	vertices := obj.Mesh.Vertices.Vertex
+	moved := make([]models.Vertex, len(vertices))
+	for i, v := range vertices {
+		moved[i] = models.Vertex{X: v.X, Y: v.Y, Z: v.Z + zOffset}
 	}
 
-	// Build new vertices XML with Z offset applied
-	var newVerticesXML string
-	for _, v := range vertices.Vertex {
-		x, err := strconv.ParseFloat(v.X, 64)
-		if err != nil {
-			continue
-		}
-		y, err := strconv.ParseFloat(v.Y, 64)
-		if err != nil {
-			continue
-		}
-		z, err := strconv.ParseFloat(v.Z, 64)
-		if err != nil {
-			continue
-		}
-
-		newVerticesXML += fmt.Sprintf("\n\t\t\t\t\t<vertex x=\"%.6f\" y=\"%.6f\" z=\"%.6f\"/>",
-			x, y, z+zOffset)
-	}
-	newVerticesXML += "\n\t\t\t\t"
-
 	// Update the mesh
-	obj.Mesh.Vertices.RawContent = newVerticesXML
+	obj.Mesh.Vertices.Vertex = moved
 
 	return nil
 }
@@ -449,4 +354,3 @@ func TransformMeshVertices(obj *models.Object, rotX, rotY, rotZ float64) (float6
 
 	return zOffset, nil
 }
-
@@ -0,0 +1,56 @@
+package geometry
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/philipparndt/go3mf/internal/models"
+)
+
+const (
+	// DefaultPrecision is the number of decimals of the vertex coordinates written to 3MF files
+	DefaultPrecision = models.DefaultCoordinatePrecision
+	// MaxPrecision is the largest supported number of decimals
+	MaxPrecision = 9
+)
+
+// SetPrecision sets the number of decimals of the vertex coordinates written to
+// 3MF files (0 = DefaultPrecision). Fewer decimals make smaller files.
+func SetPrecision(decimals int) {
+	models.SetCoordinatePrecision(decimals)
+}
+
+// ValidatePrecision checks a number of decimals of vertex coordinates (0 = default)
+func ValidatePrecision(decimals int) error {
+	if decimals < 0 || decimals > MaxPrecision {
+		return fmt.Errorf("precision must be between 1 and %d decimals", MaxPrecision)
+	}
+	return nil
+}
+
+// FormatFixed formats v with exactly decimals decimals. Numbers are always
+// written with a dot and without grouping, independent of the locale.
+func FormatFixed(v float64, decimals int) string {
+	s := strconv.FormatFloat(v, 'f', decimals, 64)
+	if isNegativeZero(s) {
+		return s[1:]
+	}
+	return s
+}
+
+// FormatCoordinate formats a vertex coordinate with the configured precision,
+// without trailing zeros (10.500000 is written as 10.5)
+func FormatCoordinate(v float64) string {
+	return models.FormatCoordinate(v)
+}
+
+// FormatVertex returns the vertex element of a 3MF mesh at x, y, z
+func FormatVertex(x, y, z float64) string {
+	return models.FormatVertex(x, y, z)
+}
+
+// isNegativeZero reports whether a formatted number is zero with a minus sign
+func isNegativeZero(s string) bool {
+	return strings.HasPrefix(s, "-") && strings.Trim(s[1:], "0.") == ""
+}
@@ -0,0 +1,66 @@
+package geometry
+
+import "testing"
+
+func TestFormatCoordinate(t *testing.T) {
+	defer SetPrecision(0)
+
+	tests := []struct {
+		name      string
+		precision int
+		value     float64
+		want      string
+	}{
+		{"default", 0, 10.123456789, "10.123457"},
+		{"trailing zeros", 0, 10.5, "10.5"},
+		{"integer", 0, 10, "10"},
+		{"negative", 0, -2.25, "-2.25"},
+		{"negative zero", 0, -0.0000001, "0"},
+		{"large", 0, 1234567.5, "1234567.5"},
+		{"small", 0, 1e-7, "0"},
+		{"four decimals", 4, 10.123456, "10.1235"},
+		{"nine decimals", 9, 0.123456789, "0.123456789"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetPrecision(tt.precision)
+			if got := FormatCoordinate(tt.value); got != tt.want {
+				t.Errorf("FormatCoordinate(%v) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatFixed(t *testing.T) {
+	tests := []struct {
+		value    float64
+		decimals int
+		want     string
+	}{
+		{1234.5, 2, "1234.50"},
+		{-0.001, 2, "0.00"},
+		{-1.005, 1, "-1.0"},
+		{0.5, 8, "0.50000000"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatFixed(tt.value, tt.decimals); got != tt.want {
+			t.Errorf("FormatFixed(%v, %d) = %q, want %q", tt.value, tt.decimals, got, tt.want)
+		}
+	}
+}
+
+func TestFormatVertex(t *testing.T) {
+	if got, want := FormatVertex(1, -0.5, 2.125), `<vertex x="1" y="-0.5" z="2.125"/>`; got != want {
+		t.Errorf("FormatVertex() = %q, want %q", got, want)
+	}
+}
+
+func TestValidatePrecision(t *testing.T) {
+	for decimals, valid := range map[int]bool{0: true, 1: true, 9: true, -1: false, 10: false} {
+		if err := ValidatePrecision(decimals); (err == nil) != valid {
+			t.Errorf("ValidatePrecision(%d) = %v, want valid %v", decimals, err, valid)
+		}
+	}
+}
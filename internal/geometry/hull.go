@@ -0,0 +1,121 @@
+package geometry
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/philipparndt/go3mf/internal/models"
+)
+
+// Point represents a 2D point on the build plate
+type Point struct {
+	X, Y float64
+}
+
+// FootprintPoints returns the vertices of a mesh object projected onto the build
+// plate (XY plane) and moved by the given offset
+func FootprintPoints(obj *models.Object, offsetX, offsetY float64) ([]Point, error) {
+	if obj.Mesh == nil || obj.Mesh.Vertices == nil {
+		return nil, fmt.Errorf("object has no mesh")
+	}
+
+	points := make([]Point, 0, len(obj.Mesh.Vertices.Vertex))
+	for _, v := range obj.Mesh.Vertices.Vertex {
+		points = append(points, Point{X: v.X + offsetX, Y: v.Y + offsetY})
+	}
+	if len(points) == 0 {
+		return nil, fmt.Errorf("mesh has no vertices")
+	}
+	return points, nil
+}
+
+// ConvexHull returns the convex hull of the points in counter-clockwise order
+// (Andrew's monotone chain). Collinear points are dropped.
+func ConvexHull(points []Point) []Point {
+	if len(points) < 3 {
+		return append([]Point(nil), points...)
+	}
+
+	sorted := append([]Point(nil), points...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].X != sorted[j].X {
+			return sorted[i].X < sorted[j].X
+		}
+		return sorted[i].Y < sorted[j].Y
+	})
+
+	hull := make([]Point, 0, 2*len(sorted))
+	// Lower hull
+	for _, p := range sorted {
+		for len(hull) >= 2 && cross(hull[len(hull)-2], hull[len(hull)-1], p) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, p)
+	}
+	// Upper hull
+	lower := len(hull) + 1
+	for i := len(sorted) - 2; i >= 0; i-- {
+		p := sorted[i]
+		for len(hull) >= lower && cross(hull[len(hull)-2], hull[len(hull)-1], p) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, p)
+	}
+
+	// The last point equals the first one
+	return hull[:len(hull)-1]
+}
+
+// PolygonArea returns the area of a simple polygon
+func PolygonArea(polygon []Point) float64 {
+	area := 0.0
+	for i := range polygon {
+		a, b := polygon[i], polygon[(i+1)%len(polygon)]
+		area += a.X*b.Y - b.X*a.Y
+	}
+	return math.Abs(area) / 2
+}
+
+// cross returns the z component of the cross product of (b-a) and (c-a)
+func cross(a, b, c Point) float64 {
+	return (b.X-a.X)*(c.Y-a.Y) - (b.Y-a.Y)*(c.X-a.X)
+}
+
+// separation returns the largest gap between the projections of two convex
+// polygons on the edge normals of both polygons (separating axis theorem).
+// A negative value means the polygons overlap. The real distance between the
+// polygons can be larger, so the result is a conservative lower bound.
+func separation(a, b []Point) float64 {
+	best := math.Inf(-1)
+	for _, polygon := range [][]Point{a, b} {
+		for i := range polygon {
+			p, q := polygon[i], polygon[(i+1)%len(polygon)]
+			nx, ny := q.Y-p.Y, p.X-q.X
+			length := math.Hypot(nx, ny)
+			if length == 0 {
+				continue
+			}
+			nx, ny = nx/length, ny/length
+
+			minA, maxA := project(a, nx, ny)
+			minB, maxB := project(b, nx, ny)
+			gap := math.Max(minB-maxA, minA-maxB)
+			if gap > best {
+				best = gap
+			}
+		}
+	}
+	return best
+}
+
+// project returns the interval of a polygon projected onto the axis (nx, ny)
+func project(polygon []Point, nx, ny float64) (float64, float64) {
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, p := range polygon {
+		d := p.X*nx + p.Y*ny
+		lo = math.Min(lo, d)
+		hi = math.Max(hi, d)
+	}
+	return lo, hi
+}
@@ -0,0 +1,73 @@
+package geometry
+
+import (
+	"math"
+	"testing"
+)
+
+func TestConvexHull(t *testing.T) {
+	points := []Point{{0, 0}, {10, 0}, {5, 5}, {10, 10}, {0, 10}, {5, 0}, {2, 8}}
+
+	hull := ConvexHull(points)
+
+	if len(hull) != 4 {
+		t.Fatalf("hull has %d points, want 4: %v", len(hull), hull)
+	}
+	if area := PolygonArea(hull); area != 100 {
+		t.Errorf("hull area = %.1f, want 100", area)
+	}
+}
+
+func TestSeparation(t *testing.T) {
+	square := []Point{{0, 0}, {10, 0}, {10, 10}, {0, 10}}
+
+	tests := []struct {
+		name   string
+		dx, dy float64
+		want   float64
+	}{
+		{name: "apart", dx: 15, want: 5},
+		{name: "touching", dx: 10, want: 0},
+		{name: "overlapping", dx: 8, dy: 8, want: -2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := separation(square, moveOutline(square, tt.dx, tt.dy)); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("separation() = %.2f, want %.2f", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPackHull(t *testing.T) {
+	// Two right triangles fill their bounding boxes only half, so with their
+	// outlines the second one fits into the free half of the first box
+	lower := []Point{{0, 0}, {40, 0}, {0, 40}}
+	upper := []Point{{40, 0}, {40, 40}, {0, 40}}
+	objects := []Rectangle{
+		{ID: 0, Width: 40, Height: 40, Outline: lower},
+		{ID: 1, Width: 40, Height: 40, Outline: upper},
+	}
+
+	bbox := NewPacker(2).PackHull([]Rectangle{{ID: 0, Width: 40, Height: 40}, {ID: 1, Width: 40, Height: 40}}, 60, 1)
+	if bbox[1].X != 0 || bbox[1].Y != 42 {
+		t.Errorf("rectangles: second object at (%.1f,%.1f), want (0.0,42.0)", bbox[1].X, bbox[1].Y)
+	}
+
+	hull := NewPacker(2).PackHull(objects, 60, 1)
+	if hull[1].Y >= 42 {
+		t.Errorf("outlines: second object at (%.1f,%.1f), want it next to the first one", hull[1].X, hull[1].Y)
+	}
+	if gap := separation(lower, moveOutline(upper, hull[1].X, hull[1].Y)); gap < 2 {
+		t.Errorf("outlines are %.2fmm apart, want at least the 2mm margin", gap)
+	}
+}
+
+func moveOutline(outline []Point, dx, dy float64) []Point {
+	moved := make([]Point, len(outline))
+	for i, p := range outline {
+		moved[i] = Point{p.X + dx, p.Y + dy}
+	}
+	return moved
+}
@@ -0,0 +1,46 @@
+package geometry
+
+import (
+	"fmt"
+
+	"github.com/philipparndt/go3mf/internal/models"
+)
+
+// MergeMeshes returns one mesh with the meshes of several objects, each with
+// its 3MF transformation matrix applied to the vertices, e.g. the parts of an
+// object made of components. Triangles of mirrored meshes are flipped so their
+// normals keep pointing outwards. Properties of the triangles are not kept.
+func MergeMeshes(objects []*models.Object, transforms [][12]float64) (*models.Mesh, error) {
+	if len(objects) != len(transforms) {
+		return nil, fmt.Errorf("%d meshes, but %d transforms", len(objects), len(transforms))
+	}
+
+	var vertices []models.Vertex
+	var triangles []models.Triangle
+	offset := 0
+	for i, obj := range objects {
+		points, tris, err := parseMesh(obj)
+		if err != nil {
+			return nil, fmt.Errorf("object %s: %w", obj.ID, err)
+		}
+		m := transforms[i]
+		for _, p := range points {
+			x, y, z := TransformPoint(m, p[0], p[1], p[2])
+			vertices = append(vertices, models.Vertex{X: x, Y: y, Z: z})
+		}
+		mirrored := determinant(m) < 0
+		for _, t := range tris {
+			v2, v3 := t.V2, t.V3
+			if mirrored {
+				v2, v3 = v3, v2
+			}
+			triangles = append(triangles, models.Triangle{V1: offset + t.V1, V2: offset + v2, V3: offset + v3})
+		}
+		offset += len(points)
+	}
+
+	return &models.Mesh{
+		Vertices:  &models.Vertices{Vertex: vertices},
+		Triangles: &models.Triangles{Triangle: triangles},
+	}, nil
+}
@@ -0,0 +1,59 @@
+package geometry
+
+import (
+	"math"
+	"testing"
+
+	"github.com/philipparndt/go3mf/internal/models"
+)
+
+func TestMergeMeshes(t *testing.T) {
+	// A 10 mm cube at the origin
+	cube := &models.Object{ID: "1", Mesh: &models.Mesh{
+		Vertices: &models.Vertices{Vertex: []models.Vertex{
+			{X: 0, Y: 0, Z: 0}, {X: 10, Y: 0, Z: 0}, {X: 10, Y: 10, Z: 0}, {X: 0, Y: 10, Z: 0},
+			{X: 0, Y: 0, Z: 10}, {X: 10, Y: 0, Z: 10}, {X: 10, Y: 10, Z: 10}, {X: 0, Y: 10, Z: 10},
+		}},
+		Triangles: &models.Triangles{Triangle: []models.Triangle{
+			{V1: 0, V2: 2, V3: 1}, {V1: 0, V2: 3, V3: 2},
+			{V1: 4, V2: 5, V3: 6}, {V1: 4, V2: 6, V3: 7},
+			{V1: 0, V2: 1, V3: 5}, {V1: 0, V2: 5, V3: 4},
+			{V1: 1, V2: 2, V3: 6}, {V1: 1, V2: 6, V3: 5},
+			{V1: 2, V2: 3, V3: 7}, {V1: 2, V2: 7, V3: 6},
+			{V1: 3, V2: 0, V3: 4}, {V1: 3, V2: 4, V3: 7},
+		}},
+	}}
+	moved, _ := ParseTransform(BuildTranslationTransform(20, 0, 0))
+	mirrored, _ := ParseTransform("-1 0 0 0 1 0 0 0 1 -20 0 0")
+	scaled, _ := ParseTransform("2 0 0 0 2 0 0 0 2 0 0 20")
+
+	mesh, err := MergeMeshes([]*models.Object{cube, cube, cube, cube}, [][12]float64{IdentityTransform, moved, mirrored, scaled})
+	if err != nil {
+		t.Fatalf("MergeMeshes() error = %v", err)
+	}
+	merged := &models.Object{Mesh: mesh}
+
+	// Mirrored triangles are flipped, so all volumes are positive: 3 cubes and one of 20 mm
+	volume, err := MeshVolume(merged)
+	if err != nil {
+		t.Fatalf("MeshVolume() error = %v", err)
+	}
+	if math.Abs(volume-11000) > 1e-6 {
+		t.Errorf("volume of the merged mesh = %f, want 11000", volume)
+	}
+
+	bbox, err := CalculateBoundingBox(merged)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bbox.MinX != -30 || bbox.MaxX != 30 || bbox.MinZ != 0 || bbox.MaxZ != 40 {
+		t.Errorf("bounding box of the merged mesh = %+v, want X -30..30, Z 0..40", bbox)
+	}
+
+	if _, err := MergeMeshes([]*models.Object{cube}, nil); err == nil {
+		t.Error("MergeMeshes() should fail without a transform for every mesh")
+	}
+	if _, err := MergeMeshes([]*models.Object{{ID: "2"}}, [][12]float64{IdentityTransform}); err == nil {
+		t.Error("MergeMeshes() should fail for objects without mesh")
+	}
+}
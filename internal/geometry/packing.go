@@ -3,13 +3,19 @@ package geometry
 import (
 	"math"
 	"sort"
+
+	"github.com/philipparndt/go3mf/internal/models"
 )
 
 // Rectangle represents a 2D rectangle for packing
 type Rectangle struct {
 	X, Y          float64 // Position
 	Width, Height float64 // Dimensions
+	Depth         float64 // Print height (Z) of the object, used to order objects by height
 	ID            int     // Object identifier
+	Name          string  // Object name, used in messages
+	Outline       []Point // Convex footprint relative to the rectangle corner (nil = the rectangle itself)
+	Margin        float64 // Extra space around Outline, already included in Width and Height
 }
 
 // PackingResult represents the result of packing an object
@@ -21,10 +27,14 @@ type PackingResult struct {
 	Height float64
 }
 
+// DefaultMaxRowWidth is the row width in mm Pack fills without SetMaxRowWidth
+const DefaultMaxRowWidth = 300.0
+
 // Packer implements a 2D bin packing algorithm
 type Packer struct {
-	margin float64
-	nodes  []*packNode
+	margin   float64
+	rowWidth float64 // Maximum row width of Pack in mm
+	nodes    []*packNode
 }
 
 type packNode struct {
@@ -38,9 +48,19 @@ type packNode struct {
 // NewPacker creates a new bin packer with the specified margin between objects
 func NewPacker(margin float64) *Packer {
 	return &Packer{
-		margin: margin,
-		nodes:  make([]*packNode, 0),
+		margin:   margin,
+		rowWidth: DefaultMaxRowWidth,
+		nodes:    make([]*packNode, 0),
+	}
+}
+
+// SetMaxRowWidth sets the width in mm after which Pack starts a new row, e.g.
+// the plate width of the printer (0 = DefaultMaxRowWidth)
+func (p *Packer) SetMaxRowWidth(width float64) {
+	if width <= 0 {
+		width = DefaultMaxRowWidth
 	}
+	p.rowWidth = width
 }
 
 // Pack arranges rectangles using a simple shelf packing algorithm
@@ -74,9 +94,9 @@ func (p *Packer) Pack(objects []Rectangle) []PackingResult {
 	for i, obj := range sorted {
 		// If this object doesn't fit in current row, start new row
 		if i > 0 && currentX > 0 {
-			// Simple heuristic: if we've used significant width, start new row
+			// Start a new row when the object would exceed the row width
 			// This prevents extremely wide layouts
-			if currentX+obj.Width > 300.0 { // 300mm max width heuristic
+			if currentX+obj.Width > p.rowWidth {
 				currentX = 0.0
 				currentY += rowHeight + p.margin
 				rowHeight = 0.0
@@ -407,3 +427,271 @@ func (p *Packer) PackCompact(objects []Rectangle) []PackingResult {
 
 	return results
 }
+
+// SortByPrintHeight returns the objects sorted by print height (lowest first).
+// Objects of equal height keep their original order.
+func SortByPrintHeight(objects []Rectangle) []Rectangle {
+	sorted := make([]Rectangle, len(objects))
+	copy(sorted, objects)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Depth < sorted[j].Depth
+	})
+	return sorted
+}
+
+// PackSequential places objects in the given order row by row from left to right,
+// starting a new row when maxBuildPlateWidth would be exceeded. Unlike the other
+// algorithms it never reorders objects, so the order of the results is the order
+// in which a slicer prints them one by one.
+// Neighbouring objects are at least clearanceX apart along X and clearanceY apart
+// along Y (but never closer than the packer margin), so the print head clearance
+// rectangle does not collide with objects printed before.
+func (p *Packer) PackSequential(objects []Rectangle, maxBuildPlateWidth, clearanceX, clearanceY float64) []PackingResult {
+	if len(objects) == 0 {
+		return []PackingResult{}
+	}
+
+	gapX := math.Max(p.margin, clearanceX)
+	gapY := math.Max(p.margin, clearanceY)
+
+	results := make([]PackingResult, len(objects))
+
+	currentX := 0.0
+	currentY := 0.0
+	rowHeight := 0.0
+
+	for i, obj := range objects {
+		if currentX > 0 && currentX+obj.Width > maxBuildPlateWidth {
+			currentX = 0.0
+			currentY += rowHeight + gapY
+			rowHeight = 0.0
+		}
+
+		results[i] = PackingResult{
+			X:      currentX,
+			Y:      currentY,
+			ID:     obj.ID,
+			Fits:   true,
+			Width:  obj.Width,
+			Height: obj.Height,
+		}
+
+		currentX += obj.Width + gapX
+		if obj.Height > rowHeight {
+			rowHeight = obj.Height
+		}
+	}
+
+	return results
+}
+
+// SnapToGrid returns the objects with their footprints enlarged so that width plus
+// gapX and height plus gapY are multiples of grid. As all packing algorithms place
+// objects at sums of these sizes, packing the result puts every object on the grid.
+func SnapToGrid(objects []Rectangle, gapX, gapY, grid float64) []Rectangle {
+	snapped := make([]Rectangle, len(objects))
+	for i, obj := range objects {
+		obj.Width = snapUp(obj.Width+gapX, grid) - gapX
+		obj.Height = snapUp(obj.Height+gapY, grid) - gapY
+		snapped[i] = obj
+	}
+	return snapped
+}
+
+// snapUp rounds v up to the next multiple of grid, tolerating floating point noise
+func snapUp(v, grid float64) float64 {
+	return math.Ceil(v/grid-1e-9) * grid
+}
+
+// AvoidZones moves the packed layout as a whole to the right or to the back until
+// no object is closer than margin to an exclusion zone. Moves that keep the layout
+// within plateWidth and plateDepth are preferred, otherwise the shorter one is taken.
+// Moves are rounded up to the grid (if any), so gridded layouts stay on the grid.
+// Zones that cannot be cleared this way, like zones at the back of the plate, are
+// left overlapping; see OverlapsZone.
+func AvoidZones(results []PackingResult, zones []models.ExclusionZone, margin, grid, plateWidth, plateDepth float64) []PackingResult {
+	moved := append([]PackingResult(nil), results...)
+	for attempt := 0; attempt <= len(zones)*len(moved); attempt++ {
+		var dx, dy float64
+		for _, r := range moved {
+			for _, zone := range zones {
+				if OverlapsZone(r, zone, margin) {
+					dx = zone.X + zone.Width + margin - r.X
+					dy = zone.Y + zone.Depth + margin - r.Y
+					break
+				}
+			}
+			if dx > 0 || dy > 0 {
+				break
+			}
+		}
+		if dx <= 0 && dy <= 0 {
+			break
+		}
+
+		if grid > 0 {
+			dx, dy = snapUp(dx, grid), snapUp(dy, grid)
+		}
+		maxX, maxY := 0.0, 0.0
+		for _, r := range moved {
+			maxX = math.Max(maxX, r.X+r.Width)
+			maxY = math.Max(maxY, r.Y+r.Height)
+		}
+		fitsRight := maxX+dx <= plateWidth
+		fitsBack := maxY+dy <= plateDepth
+		moveRight := dx <= dy
+		if fitsRight != fitsBack {
+			moveRight = fitsRight
+		}
+		if moveRight {
+			dy = 0
+		} else {
+			dx = 0
+		}
+		for i := range moved {
+			moved[i].X += dx
+			moved[i].Y += dy
+		}
+	}
+	return moved
+}
+
+// MirrorOutlinesY returns the objects with their outlines mirrored front to back
+// within their rectangles. Packing the mirrored objects and mirroring the layout
+// with MirrorY packs from the back of the plate without changing the objects.
+func MirrorOutlinesY(objects []Rectangle) []Rectangle {
+	mirrored := append([]Rectangle(nil), objects...)
+	for i, obj := range mirrored {
+		if len(obj.Outline) == 0 {
+			continue
+		}
+		outline := make([]Point, len(obj.Outline))
+		for j, p := range obj.Outline {
+			outline[j] = Point{p.X, obj.Height - p.Y}
+		}
+		mirrored[i].Outline = outline
+	}
+	return mirrored
+}
+
+// MirrorY mirrors a packed layout front to back on a plate of the given depth,
+// so a layout packed from the front starts at the back. Layouts deeper than the
+// plate are mirrored within their own depth and keep starting at the front edge.
+func MirrorY(results []PackingResult, plateDepth float64) []PackingResult {
+	depth := plateDepth
+	for _, r := range results {
+		depth = math.Max(depth, r.Y+r.Height)
+	}
+
+	mirrored := append([]PackingResult(nil), results...)
+	for i, r := range mirrored {
+		mirrored[i].Y = depth - r.Y - r.Height
+	}
+	return mirrored
+}
+
+// OverlapsZone reports whether a packed object is closer than margin to an exclusion zone
+func OverlapsZone(r PackingResult, zone models.ExclusionZone, margin float64) bool {
+	return r.X < zone.X+zone.Width+margin && r.X+r.Width+margin > zone.X &&
+		r.Y < zone.Y+zone.Depth+margin && r.Y+r.Height+margin > zone.Y
+}
+
+// placedShape is an object placed by PackHull
+type placedShape struct {
+	outline                []Point
+	minX, minY, maxX, maxY float64
+	margin                 float64
+}
+
+// PackHull places objects in the given order at the first free position on a grid
+// with the given step, scanning rows from the front left corner. Collisions are
+// checked with the convex outlines of the objects instead of their rectangles,
+// so round and diagonal objects can be placed closer together.
+func (p *Packer) PackHull(objects []Rectangle, maxBuildPlateWidth, step float64) []PackingResult {
+	if len(objects) == 0 {
+		return []PackingResult{}
+	}
+	if step <= 0 {
+		step = 1.0
+	}
+
+	results := make([]PackingResult, len(objects))
+	var placed []placedShape
+
+	for i, obj := range objects {
+		outline, margin := obj.Outline, obj.Margin
+		if len(outline) < 3 {
+			outline = []Point{{0, 0}, {obj.Width, 0}, {obj.Width, obj.Height}, {0, obj.Height}}
+			margin = 0
+		}
+
+		for y := 0.0; ; y += step {
+			x, ok := p.findHullPosition(placed, outline, margin, obj.Width, y, maxBuildPlateWidth, step)
+			if !ok {
+				continue
+			}
+
+			shape := placedShape{margin: margin, minX: math.Inf(1), minY: math.Inf(1), maxX: math.Inf(-1), maxY: math.Inf(-1)}
+			for _, pt := range outline {
+				moved := Point{X: pt.X + x, Y: pt.Y + y}
+				shape.outline = append(shape.outline, moved)
+				shape.minX, shape.maxX = math.Min(shape.minX, moved.X), math.Max(shape.maxX, moved.X)
+				shape.minY, shape.maxY = math.Min(shape.minY, moved.Y), math.Max(shape.maxY, moved.Y)
+			}
+			placed = append(placed, shape)
+
+			results[i] = PackingResult{
+				X:      x,
+				Y:      y,
+				ID:     obj.ID,
+				Fits:   true,
+				Width:  obj.Width,
+				Height: obj.Height,
+			}
+			break
+		}
+	}
+
+	return results
+}
+
+// findHullPosition returns the first X position in the row at y where the outline
+// keeps its distance to all placed shapes. The first position of a row is always
+// tried, even if the object is wider than the plate.
+func (p *Packer) findHullPosition(placed []placedShape, outline []Point, margin, width, y, maxBuildPlateWidth, step float64) (float64, bool) {
+	for x := 0.0; x == 0 || x+width <= maxBuildPlateWidth; x += step {
+		if p.hullFits(placed, outline, margin, x, y) {
+			return x, true
+		}
+	}
+	return 0, false
+}
+
+// hullFits reports whether the outline moved to (x, y) keeps the packer margin
+// plus both object margins to all placed shapes
+func (p *Packer) hullFits(placed []placedShape, outline []Point, margin, x, y float64) bool {
+	var moved []Point
+	minX, minY, maxX, maxY := math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
+	for _, pt := range outline {
+		minX, maxX = math.Min(minX, pt.X+x), math.Max(maxX, pt.X+x)
+		minY, maxY = math.Min(minY, pt.Y+y), math.Max(maxY, pt.Y+y)
+	}
+
+	for _, other := range placed {
+		gap := p.margin + margin + other.margin
+		// Quick check with the bounding boxes before the exact polygon test
+		if minX >= other.maxX+gap || other.minX >= maxX+gap || minY >= other.maxY+gap || other.minY >= maxY+gap {
+			continue
+		}
+		if moved == nil {
+			moved = make([]Point, len(outline))
+			for i, pt := range outline {
+				moved[i] = Point{X: pt.X + x, Y: pt.Y + y}
+			}
+		}
+		if separation(moved, other.outline) < gap {
+			return false
+		}
+	}
+	return true
+}
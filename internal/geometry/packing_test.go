@@ -0,0 +1,194 @@
+package geometry
+
+import (
+	"math"
+	"testing"
+
+	"github.com/philipparndt/go3mf/internal/models"
+)
+
+func TestSortByPrintHeight(t *testing.T) {
+	objects := []Rectangle{
+		{ID: 0, Depth: 30},
+		{ID: 1, Depth: 10},
+		{ID: 2, Depth: 30},
+		{ID: 3, Depth: 5},
+	}
+
+	sorted := SortByPrintHeight(objects)
+
+	want := []int{3, 1, 0, 2}
+	for i, obj := range sorted {
+		if obj.ID != want[i] {
+			t.Errorf("position %d: got ID %d, want %d", i, obj.ID, want[i])
+		}
+	}
+	if objects[0].ID != 0 {
+		t.Errorf("SortByPrintHeight must not modify its input")
+	}
+}
+
+func TestPackSequential(t *testing.T) {
+	objects := []Rectangle{
+		{ID: 0, Width: 40, Height: 20},
+		{ID: 1, Width: 40, Height: 30},
+		{ID: 2, Width: 40, Height: 10},
+	}
+
+	tests := []struct {
+		name                   string
+		clearanceX, clearanceY float64
+		want                   []PackingResult
+	}{
+		{
+			name: "margin only",
+			want: []PackingResult{{X: 0, Y: 0, ID: 0}, {X: 50, Y: 0, ID: 1}, {X: 0, Y: 40, ID: 2}},
+		},
+		{
+			name:       "clearance rectangle",
+			clearanceX: 35, clearanceY: 25,
+			want: []PackingResult{{X: 0, Y: 0, ID: 0}, {X: 0, Y: 45, ID: 1}, {X: 0, Y: 100, ID: 2}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := NewPacker(10).PackSequential(objects, 100, tt.clearanceX, tt.clearanceY)
+			if len(results) != len(tt.want) {
+				t.Fatalf("got %d results, want %d", len(results), len(tt.want))
+			}
+			for i, want := range tt.want {
+				got := results[i]
+				if got.ID != want.ID || got.X != want.X || got.Y != want.Y {
+					t.Errorf("result %d = ID %d at (%.1f,%.1f), want ID %d at (%.1f,%.1f)", i, got.ID, got.X, got.Y, want.ID, want.X, want.Y)
+				}
+			}
+		})
+	}
+}
+
+func TestPackMaxRowWidth(t *testing.T) {
+	objects := []Rectangle{{ID: 0, Width: 100, Height: 50}, {ID: 1, Width: 100, Height: 50}, {ID: 2, Width: 100, Height: 50}}
+
+	tests := []struct {
+		name     string
+		rowWidth float64
+		wantRows int
+	}{
+		{name: "default", rowWidth: 0, wantRows: 2},
+		{name: "plate of 256mm", rowWidth: 256, wantRows: 2},
+		{name: "wide plate", rowWidth: 350, wantRows: 1},
+		{name: "narrow plate", rowWidth: 180, wantRows: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			packer := NewPacker(10)
+			packer.SetMaxRowWidth(tt.rowWidth)
+			rows := make(map[float64]bool)
+			for _, r := range packer.Pack(objects) {
+				rows[r.Y] = true
+			}
+			if len(rows) != tt.wantRows {
+				t.Errorf("got %d rows, want %d", len(rows), tt.wantRows)
+			}
+		})
+	}
+}
+
+func TestSnapToGrid(t *testing.T) {
+	objects := []Rectangle{
+		{ID: 0, Width: 23.4, Height: 17.1},
+		{ID: 1, Width: 41.7, Height: 8.2},
+		{ID: 2, Width: 12.9, Height: 31.6},
+		{ID: 3, Width: 7.3, Height: 7.3},
+	}
+	const margin, grid = 3.0, 5.0
+
+	snapped := SnapToGrid(objects, margin, margin, grid)
+	for i, obj := range snapped {
+		if obj.Width < objects[i].Width || obj.Height < objects[i].Height {
+			t.Errorf("object %d shrank to %.1fx%.1f", i, obj.Width, obj.Height)
+		}
+	}
+
+	packer := NewPacker(margin)
+	layouts := map[string][]PackingResult{
+		"optimal":    packer.PackOptimal(snapped, 60),
+		"compact":    packer.PackCompact(snapped),
+		"sequential": packer.PackSequential(snapped, 60, 0, 0),
+	}
+	for name, results := range layouts {
+		for _, r := range results {
+			if math.Abs(math.Remainder(r.X, grid)) > 1e-9 || math.Abs(math.Remainder(r.Y, grid)) > 1e-9 {
+				t.Errorf("%s: object %d at (%.2f,%.2f) is not on the %.0fmm grid", name, r.ID, r.X, r.Y, grid)
+			}
+		}
+	}
+}
+
+func TestAvoidZones(t *testing.T) {
+	purge := models.ExclusionZone{X: 0, Y: 0, Width: 18, Depth: 28}
+	strip := models.ExclusionZone{X: 0, Y: 0, Width: 200, Depth: 5}
+	object := []PackingResult{{X: 0, Y: 0, Width: 40, Height: 40}}
+
+	tests := []struct {
+		name         string
+		results      []PackingResult
+		zone         models.ExclusionZone
+		grid         float64
+		wantX, wantY float64
+	}{
+		{name: "move right", results: object, zone: purge, wantX: 20},
+		{name: "move back", results: object, zone: strip, wantY: 7},
+		{name: "on grid", results: object, zone: strip, grid: 5, wantY: 10},
+		{name: "keep on plate", results: []PackingResult{{X: 0, Y: 0, Width: 90, Height: 40}}, zone: purge, wantY: 30},
+		{name: "clear", results: []PackingResult{{X: 30, Y: 0, Width: 40, Height: 40}}, zone: purge, wantX: 30},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			zones := []models.ExclusionZone{tt.zone}
+			moved := AvoidZones(tt.results, zones, 2, tt.grid, 100, 100)
+			if moved[0].X != tt.wantX || moved[0].Y != tt.wantY {
+				t.Errorf("object at (%.1f,%.1f), want (%.1f,%.1f)", moved[0].X, moved[0].Y, tt.wantX, tt.wantY)
+			}
+			if OverlapsZone(moved[0], tt.zone, 2) {
+				t.Errorf("object at (%.1f,%.1f) still overlaps the zone", moved[0].X, moved[0].Y)
+			}
+		})
+	}
+}
+
+func TestMirrorY(t *testing.T) {
+	results := []PackingResult{
+		{ID: 1, X: 0, Y: 0, Width: 40, Height: 30},
+		{ID: 2, X: 50, Y: 40, Width: 20, Height: 20},
+	}
+
+	mirrored := MirrorY(results, 100)
+	if mirrored[0].Y != 70 || mirrored[1].Y != 40 || mirrored[0].X != 0 || mirrored[1].X != 50 {
+		t.Errorf("mirrored layout = %+v, want the first row at the back", mirrored)
+	}
+	if results[0].Y != 0 {
+		t.Errorf("MirrorY changed its input: %+v", results)
+	}
+
+	// A layout deeper than the plate keeps starting at the front edge
+	deep := MirrorY(results, 50)
+	if deep[0].Y != 30 || deep[1].Y != 0 {
+		t.Errorf("mirrored deep layout = %+v, want it within 0..60", deep)
+	}
+
+	// Outlines are mirrored within their rectangles
+	triangle := Rectangle{ID: 1, Width: 10, Height: 10, Outline: []Point{{0, 0}, {10, 0}, {0, 10}}}
+	outline := MirrorOutlinesY([]Rectangle{triangle})[0].Outline
+	want := []Point{{0, 10}, {10, 10}, {0, 0}}
+	for i := range want {
+		if outline[i] != want[i] {
+			t.Fatalf("mirrored outline = %v, want %v", outline, want)
+		}
+	}
+	if triangle.Outline[0] != (Point{0, 0}) {
+		t.Errorf("MirrorOutlinesY changed its input: %v", triangle.Outline)
+	}
+}
@@ -3,6 +3,8 @@ package geometry
 import (
 	"fmt"
 	"math"
+	"strconv"
+	"strings"
 )
 
 // BuildRotationTransform creates a 3MF transformation matrix string with rotation and translation.
@@ -10,6 +12,22 @@ import (
 // Rotations are applied in the order: Z, Y, X (intrinsic rotations)
 // This matches the typical 3D transformation pipeline.
 func BuildRotationTransform(rotX, rotY, rotZ, tx, ty, tz float64) string {
+	r := rotationMatrix(rotX, rotY, rotZ)
+
+	// Format as 3MF transformation matrix string
+	// Use 8 decimals for the matrix to avoid rounding errors
+	fields := make([]string, 0, 12)
+	for _, v := range r {
+		fields = append(fields, FormatFixed(v, 8))
+	}
+	for _, v := range []float64{tx, ty, tz} {
+		fields = append(fields, FormatFixed(v, 2))
+	}
+	return strings.Join(fields, " ")
+}
+
+// rotationMatrix returns the rows of the rotation of BuildRotationTransform
+func rotationMatrix(rotX, rotY, rotZ float64) [9]float64 {
 	// Convert degrees to radians
 	rx := rotX * math.Pi / 180.0
 	ry := rotY * math.Pi / 180.0
@@ -34,16 +52,226 @@ func BuildRotationTransform(rotX, rotY, rotZ, tx, ty, tz float64) string {
 	m32 := cosX*sinY*sinZ - sinX*cosZ
 	m33 := cosX * cosY
 
-	// Format as 3MF transformation matrix string
-	// Use %.8f for precision to avoid rounding errors
-	return fmt.Sprintf("%.8f %.8f %.8f %.8f %.8f %.8f %.8f %.8f %.8f %.2f %.2f %.2f",
-		m11, m12, m13,
-		m21, m22, m23,
-		m31, m32, m33,
-		tx, ty, tz)
+	return [9]float64{m11, m12, m13, m21, m22, m23, m31, m32, m33}
 }
 
 // BuildTranslationTransform creates a simple translation transformation matrix (no rotation)
 func BuildTranslationTransform(tx, ty, tz float64) string {
-	return fmt.Sprintf("1 0 0 0 1 0 0 0 1 %.2f %.2f %.2f", tx, ty, tz)
+	return "1 0 0 0 1 0 0 0 1 " + FormatFixed(tx, 2) + " " + FormatFixed(ty, 2) + " " + FormatFixed(tz, 2)
+}
+
+// IdentityTransform is the 3MF transformation matrix that leaves objects unchanged
+var IdentityTransform = [12]float64{1, 0, 0, 0, 1, 0, 0, 0, 1, 0, 0, 0}
+
+// ParseTransform parses a 3MF transformation matrix string
+// "m11 m12 m13 m21 m22 m23 m31 m32 m33 tx ty tz". An empty string is the identity.
+func ParseTransform(transform string) ([12]float64, error) {
+	m := IdentityTransform
+	fields := strings.Fields(transform)
+	if len(fields) == 0 {
+		return m, nil
+	}
+	if len(fields) != 12 {
+		return m, fmt.Errorf("invalid transform %q: expected 12 values", transform)
+	}
+	for i, f := range fields {
+		v, err := strconv.ParseFloat(f, 64)
+		if err != nil {
+			return m, fmt.Errorf("invalid transform %q: %w", transform, err)
+		}
+		m[i] = v
+	}
+	return m, nil
+}
+
+// FormatTransform formats a 3MF transformation matrix as string, the inverse of ParseTransform
+func FormatTransform(m [12]float64) string {
+	fields := make([]string, len(m))
+	for i, v := range m {
+		fields[i] = strconv.FormatFloat(v, 'g', -1, 64)
+	}
+	return strings.Join(fields, " ")
+}
+
+// ParseMatrix parses a 4x4 matrix as Bambu Studio writes it in the model
+// settings ("m11 m12 m13 tx m21 m22 m23 ty m31 m32 m33 tz 0 0 0 1", row by row
+// for column vectors) into a 3MF transformation matrix
+func ParseMatrix(matrix string) ([12]float64, error) {
+	m := IdentityTransform
+	fields := strings.Fields(matrix)
+	if len(fields) != 16 {
+		return m, fmt.Errorf("invalid matrix %q: expected 16 values", matrix)
+	}
+	var v [16]float64
+	for i, f := range fields {
+		value, err := strconv.ParseFloat(f, 64)
+		if err != nil {
+			return m, fmt.Errorf("invalid matrix %q: %w", matrix, err)
+		}
+		v[i] = value
+	}
+	// The rows of the 3MF matrix are the columns of the 4x4 matrix
+	for r := 0; r < 4; r++ {
+		for c := 0; c < 3; c++ {
+			m[r*3+c] = v[c*4+r]
+		}
+	}
+	return m, nil
+}
+
+// FormatMatrix formats a 3MF transformation matrix as 4x4 matrix of the Bambu
+// Studio model settings, the inverse of ParseMatrix
+func FormatMatrix(m [12]float64) string {
+	fields := make([]string, 0, 16)
+	for c := 0; c < 3; c++ {
+		for r := 0; r < 4; r++ {
+			fields = append(fields, strconv.FormatFloat(m[r*3+c], 'g', -1, 64))
+		}
+	}
+	return strings.Join(append(fields, "0", "0", "0", "1"), " ")
+}
+
+// MultiplyTransforms returns the 3MF transformation matrix that applies first and then then,
+// e.g. the transform of a component followed by the transform of the build item
+func MultiplyTransforms(first, then [12]float64) [12]float64 {
+	var m [12]float64
+	for r := 0; r < 4; r++ {
+		for c := 0; c < 3; c++ {
+			v := first[r*3]*then[c] + first[r*3+1]*then[3+c] + first[r*3+2]*then[6+c]
+			if r == 3 {
+				v += then[9+c]
+			}
+			m[r*3+c] = v
+		}
+	}
+	return m
+}
+
+// ComposeTransforms returns the 3MF transformation matrix that applies the given
+// transforms in order, e.g. the transforms of nested components from the
+// innermost to the build item. No transforms are the identity.
+func ComposeTransforms(transforms ...[12]float64) [12]float64 {
+	m := IdentityTransform
+	for _, t := range transforms {
+		m = MultiplyTransforms(m, t)
+	}
+	return m
+}
+
+// InvertTransform returns the 3MF transformation matrix that undoes m. Matrices
+// that flatten objects (e.g. a scale of 0) cannot be inverted.
+func InvertTransform(m [12]float64) ([12]float64, error) {
+	det := determinant(m)
+	if math.Abs(det) < 1e-12 {
+		return IdentityTransform, fmt.Errorf("transform %q cannot be inverted", FormatTransform(m))
+	}
+
+	// Inverse of the linear part from its adjugate
+	var inv [12]float64
+	for r := 0; r < 3; r++ {
+		for c := 0; c < 3; c++ {
+			r1, r2 := (c+1)%3, (c+2)%3
+			c1, c2 := (r+1)%3, (r+2)%3
+			inv[r*3+c] = (m[r1*3+c1]*m[r2*3+c2] - m[r1*3+c2]*m[r2*3+c1]) / det
+		}
+	}
+	// The translation is undone after the linear part: -t * inverse
+	for c := 0; c < 3; c++ {
+		inv[9+c] = -(m[9]*inv[c] + m[10]*inv[3+c] + m[11]*inv[6+c])
+	}
+	return inv, nil
+}
+
+// determinant returns the determinant of the linear part of a 3MF transformation
+// matrix, negative for mirrors
+func determinant(m [12]float64) float64 {
+	return m[0]*(m[4]*m[8]-m[5]*m[7]) - m[1]*(m[3]*m[8]-m[5]*m[6]) + m[2]*(m[3]*m[7]-m[4]*m[6])
+}
+
+// TransformPoint applies a 3MF transformation matrix to a point
+func TransformPoint(m [12]float64, x, y, z float64) (float64, float64, float64) {
+	return x*m[0] + y*m[3] + z*m[6] + m[9],
+		x*m[1] + y*m[4] + z*m[7] + m[10],
+		x*m[2] + y*m[5] + z*m[8] + m[11]
+}
+
+// TransformPointXY applies a 3MF transformation matrix to a point on the build plate.
+// The Z coordinate of the point is assumed to be 0.
+func TransformPointXY(m [12]float64, p Point) Point {
+	return Point{
+		X: p.X*m[0] + p.Y*m[3] + m[9],
+		Y: p.X*m[1] + p.Y*m[4] + m[10],
+	}
+}
+
+// Decomposition is a 3MF transformation matrix split into scale, rotation and translation
+type Decomposition struct {
+	Translation [3]float64 // Translation along X, Y and Z in mm
+	Rotation    [3]float64 // Rotation around X, Y and Z in degrees, in the order of BuildRotationTransform
+	Scale       [3]float64 // Scale along the local X, Y and Z axes; negative along X if mirrored
+}
+
+// Decompose splits a 3MF transformation matrix into scale, rotation and translation.
+// A mirror (negative determinant) is returned as negative scale along X. Shear cannot
+// be represented and ends up in the rotation.
+func Decompose(m [12]float64) Decomposition {
+	d := Decomposition{Translation: [3]float64{m[9], m[10], m[11]}}
+
+	// The rows of the matrix are the rows of the rotation scaled along the local axes
+	var r [9]float64
+	for i := 0; i < 3; i++ {
+		row := m[i*3 : i*3+3]
+		d.Scale[i] = math.Sqrt(row[0]*row[0] + row[1]*row[1] + row[2]*row[2])
+		for j := 0; j < 3; j++ {
+			if d.Scale[i] != 0 {
+				r[i*3+j] = row[j] / d.Scale[i]
+			}
+		}
+	}
+	if determinant(m) < 0 {
+		d.Scale[0] = -d.Scale[0]
+		r[0], r[1], r[2] = -r[0], -r[1], -r[2]
+	}
+
+	// Inverse of BuildRotationTransform: m13 = -sin(y), m23/m33 and m12/m11 give x and z
+	sinY := math.Max(-1, math.Min(1, -r[2]))
+	ry := math.Asin(sinY)
+	var rx, rz float64
+	if math.Abs(sinY) < 1-1e-9 {
+		rx = math.Atan2(r[5], r[8])
+		rz = math.Atan2(r[1], r[0])
+	} else {
+		// Gimbal lock: the rotation around Z is folded into the one around X
+		rx = math.Atan2(-r[7], r[4])
+	}
+	d.Rotation = [3]float64{rx * 180 / math.Pi, ry * 180 / math.Pi, rz * 180 / math.Pi}
+	return d
+}
+
+// Transform composes the 3MF transformation matrix of the decomposition, the
+// inverse of Decompose for transforms without shear
+func (d Decomposition) Transform() [12]float64 {
+	r := rotationMatrix(d.Rotation[0], d.Rotation[1], d.Rotation[2])
+	var m [12]float64
+	for i := 0; i < 9; i++ {
+		m[i] = d.Scale[i/3] * r[i]
+	}
+	copy(m[9:], d.Translation[:])
+	return m
+}
+
+// Mirrored reports whether the transform mirrors the object
+func (d Decomposition) Mirrored() bool {
+	return d.Scale[0] < 0 || d.Scale[1] < 0 || d.Scale[2] < 0
+}
+
+// NonUniformScale reports whether the object is scaled differently along its axes
+func (d Decomposition) NonUniformScale() bool {
+	x, y, z := math.Abs(d.Scale[0]), math.Abs(d.Scale[1]), math.Abs(d.Scale[2])
+	return !nearlyEqual(x, y) || !nearlyEqual(x, z)
+}
+
+// nearlyEqual reports whether two matrix values are equal within the precision of 3MF files
+func nearlyEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-6
 }
@@ -117,3 +117,212 @@ func parseFloat(s string, f *float64) (int, error) {
 	n, err := fmt.Sscanf(s, "%f", f)
 	return n, err
 }
+
+func TestDecompose(t *testing.T) {
+	tests := []struct {
+		name            string
+		transform       string
+		rotation        [3]float64
+		scale           [3]float64
+		mirrored        bool
+		nonUniformScale bool
+	}{
+		{"identity", "", [3]float64{0, 0, 0}, [3]float64{1, 1, 1}, false, false},
+		{"rotation", BuildRotationTransform(30, -20, 45, 1, 2, 3), [3]float64{30, -20, 45}, [3]float64{1, 1, 1}, false, false},
+		{"gimbal lock", BuildRotationTransform(30, 90, 0, 0, 0, 0), [3]float64{30, 90, 0}, [3]float64{1, 1, 1}, false, false},
+		{"uniform scale", "2 0 0 0 2 0 0 0 2 0 0 0", [3]float64{0, 0, 0}, [3]float64{2, 2, 2}, false, false},
+		{"non-uniform scale", "0 2 0 -1 0 0 0 0 1 0 0 0", [3]float64{0, 0, 90}, [3]float64{2, 1, 1}, false, true},
+		{"mirror", "-1 0 0 0 1 0 0 0 1 0 0 0", [3]float64{0, 0, 0}, [3]float64{-1, 1, 1}, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := ParseTransform(tt.transform)
+			if err != nil {
+				t.Fatal(err)
+			}
+			d := Decompose(m)
+			for i := 0; i < 3; i++ {
+				if math.Abs(d.Rotation[i]-tt.rotation[i]) > 1e-4 {
+					t.Errorf("Rotation = %v, want %v", d.Rotation, tt.rotation)
+					break
+				}
+				if math.Abs(d.Scale[i]-tt.scale[i]) > 1e-6 {
+					t.Errorf("Scale = %v, want %v", d.Scale, tt.scale)
+					break
+				}
+			}
+			if d.Translation != [3]float64{m[9], m[10], m[11]} {
+				t.Errorf("Translation = %v", d.Translation)
+			}
+			if d.Mirrored() != tt.mirrored {
+				t.Errorf("Mirrored() = %v, want %v", d.Mirrored(), tt.mirrored)
+			}
+			if d.NonUniformScale() != tt.nonUniformScale {
+				t.Errorf("NonUniformScale() = %v, want %v", d.NonUniformScale(), tt.nonUniformScale)
+			}
+		})
+	}
+}
+
+func TestMultiplyTransforms(t *testing.T) {
+	// Rotate by 90° around Z, then move by (10, 0, 5)
+	rotate, _ := ParseTransform("0 1 0 -1 0 0 0 0 1 0 0 0")
+	move, _ := ParseTransform("1 0 0 0 1 0 0 0 1 10 0 5")
+
+	m := MultiplyTransforms(rotate, move)
+	// (1, 0, 0) is rotated to (0, 1, 0) and moved to (10, 1, 5)
+	x, y, z := 1*m[0]+m[9], 1*m[1]+m[10], 1*m[2]+m[11]
+	if x != 10 || y != 1 || z != 5 {
+		t.Errorf("rotate then move: (1, 0, 0) -> (%v, %v, %v), want (10, 1, 5)", x, y, z)
+	}
+
+	m = MultiplyTransforms(move, rotate)
+	// (1, 0, 0) is moved to (11, 0, 5) and rotated to (0, 11, 5)
+	x, y, z = 1*m[0]+m[9], 1*m[1]+m[10], 1*m[2]+m[11]
+	if x != 0 || y != 11 || z != 5 {
+		t.Errorf("move then rotate: (1, 0, 0) -> (%v, %v, %v), want (0, 11, 5)", x, y, z)
+	}
+}
+
+func TestParseMatrix(t *testing.T) {
+	tests := []struct {
+		name    string
+		matrix  string
+		want    [12]float64
+		wantErr bool
+	}{
+		{
+			name:   "identity",
+			matrix: "1 0 0 0 0 1 0 0 0 0 1 0 0 0 0 1",
+			want:   [12]float64{1, 0, 0, 0, 1, 0, 0, 0, 1, 0, 0, 0},
+		},
+		{
+			// Rotated by 90° around Z and moved by (10, 20, 5)
+			name:   "rotated and moved",
+			matrix: "0 -1 0 10 1 0 0 20 0 0 1 5 0 0 0 1",
+			want:   [12]float64{0, 1, 0, -1, 0, 0, 0, 0, 1, 10, 20, 5},
+		},
+		{name: "too short", matrix: "1 0 0 0", wantErr: true},
+		{name: "invalid", matrix: "1 0 0 0 0 1 0 0 0 0 x 0 0 0 0 1", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseMatrix(tt.matrix)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseMatrix() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ParseMatrix() = %v, want %v", got, tt.want)
+			}
+			if formatted := FormatMatrix(got); formatted != tt.matrix {
+				t.Errorf("FormatMatrix() = %q, want %q", formatted, tt.matrix)
+			}
+		})
+	}
+}
+
+// transformsEqual reports whether two transforms are equal within the precision of 3MF files
+func transformsEqual(a, b [12]float64) bool {
+	for i := range a {
+		if !nearlyEqual(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestInvertTransform(t *testing.T) {
+	tests := []struct {
+		name      string
+		transform string
+		wantErr   bool
+	}{
+		{name: "identity", transform: ""},
+		{name: "translation", transform: BuildTranslationTransform(10, -5, 2.5)},
+		{name: "rotation", transform: BuildRotationTransform(30, -20, 45, 1, 2, 3)},
+		{name: "non-uniform scale", transform: "0 2 0 -1 0 0 0 0 3 4 5 6"},
+		{name: "mirror", transform: "-1 0 0 0 1 0 0 0 1 7 0 0"},
+		{name: "shear", transform: "1 0 0 0.5 1 0 0 0 1 0 0 0"},
+		{name: "flattened", transform: "1 0 0 0 1 0 0 0 0 0 0 0", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := ParseTransform(tt.transform)
+			if err != nil {
+				t.Fatal(err)
+			}
+			inv, err := InvertTransform(m)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("InvertTransform() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := MultiplyTransforms(m, inv); !transformsEqual(got, IdentityTransform) {
+				t.Errorf("transform then inverse = %v, want identity", got)
+			}
+			if got := MultiplyTransforms(inv, m); !transformsEqual(got, IdentityTransform) {
+				t.Errorf("inverse then transform = %v, want identity", got)
+			}
+		})
+	}
+}
+
+func TestTransformPoint(t *testing.T) {
+	// Rotate by 90° around Z, then move by (10, 0, 5)
+	m, _ := ParseTransform("0 1 0 -1 0 0 0 0 1 10 0 5")
+	if x, y, z := TransformPoint(m, 1, 2, 3); x != 8 || y != 1 || z != 8 {
+		t.Errorf("TransformPoint(1, 2, 3) = (%v, %v, %v), want (8, 1, 8)", x, y, z)
+	}
+
+	inv, err := InvertTransform(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x, y, z := TransformPoint(inv, 8, 1, 8); math.Abs(x-1) > 1e-9 || math.Abs(y-2) > 1e-9 || math.Abs(z-3) > 1e-9 {
+		t.Errorf("TransformPoint(inverse, 8, 1, 8) = (%v, %v, %v), want (1, 2, 3)", x, y, z)
+	}
+}
+
+func TestComposeTransforms(t *testing.T) {
+	// A part moved within its object, the object rotated by 90° around Z, the build item moved
+	part, _ := ParseTransform(BuildTranslationTransform(5, 0, 0))
+	object, _ := ParseTransform(BuildRotationTransform(0, 0, 90, 0, 0, 0))
+	item, _ := ParseTransform(BuildTranslationTransform(100, 100, 0))
+
+	m := ComposeTransforms(part, object, item)
+	if x, y, z := TransformPoint(m, 1, 0, 0); math.Abs(x-100) > 1e-6 || math.Abs(y-106) > 1e-6 || z != 0 {
+		t.Errorf("composed (1, 0, 0) -> (%v, %v, %v), want (100, 106, 0)", x, y, z)
+	}
+	if got := ComposeTransforms(); got != IdentityTransform {
+		t.Errorf("ComposeTransforms() = %v, want identity", got)
+	}
+	if got := ComposeTransforms(object); got != object {
+		t.Errorf("ComposeTransforms(object) = %v, want %v", got, object)
+	}
+}
+
+func TestDecompositionTransform(t *testing.T) {
+	transforms := []string{
+		"",
+		BuildRotationTransform(30, -20, 45, 1, 2, 3),
+		BuildRotationTransform(0, 0, 180, -4, 0, 9),
+		"2 0 0 0 2 0 0 0 2 0 0 0",
+		"0 2 0 -1 0 0 0 0 1 0 0 0",
+		"-1 0 0 0 1 0 0 0 1 0 0 0",
+	}
+	for _, transform := range transforms {
+		m, err := ParseTransform(transform)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := Decompose(m).Transform(); !transformsEqual(got, m) {
+			t.Errorf("Decompose(%q).Transform() = %v, want %v", transform, got, m)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package geometry
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/philipparndt/go3mf/internal/models"
+)
+
+// MeshVolume returns the volume of a closed mesh in mm³, the sum of the signed
+// volumes of the tetrahedra between the origin and every triangle
+func MeshVolume(obj *models.Object) (float64, error) {
+	points, triangles, err := parseMesh(obj)
+	if err != nil {
+		return 0, err
+	}
+
+	var volume float64
+	for _, t := range triangles {
+		a, b, c := points[t.V1], points[t.V2], points[t.V3]
+		volume += a[0]*(b[1]*c[2]-b[2]*c[1]) - a[1]*(b[0]*c[2]-b[2]*c[0]) + a[2]*(b[0]*c[1]-b[1]*c[0])
+	}
+	return math.Abs(volume) / 6, nil
+}
+
+// MeshArea returns the surface area of a mesh in mm², the sum of the areas of
+// its triangles
+func MeshArea(obj *models.Object) (float64, error) {
+	points, triangles, err := parseMesh(obj)
+	if err != nil {
+		return 0, err
+	}
+
+	var area float64
+	for _, t := range triangles {
+		a, b, c := points[t.V1], points[t.V2], points[t.V3]
+		u := [3]float64{b[0] - a[0], b[1] - a[1], b[2] - a[2]}
+		v := [3]float64{c[0] - a[0], c[1] - a[1], c[2] - a[2]}
+		area += math.Sqrt(math.Pow(u[1]*v[2]-u[2]*v[1], 2)+math.Pow(u[2]*v[0]-u[0]*v[2], 2)+math.Pow(u[0]*v[1]-u[1]*v[0], 2)) / 2
+	}
+	return area, nil
+}
+
+// MeshTriangles returns the corners of the triangles of the mesh of an object,
+// e.g. for rendering
+func MeshTriangles(obj *models.Object) ([][3][3]float64, error) {
+	points, triangles, err := parseMesh(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([][3][3]float64, len(triangles))
+	for i, t := range triangles {
+		result[i] = [3][3]float64{points[t.V1], points[t.V2], points[t.V3]}
+	}
+	return result, nil
+}
+
+// parseMesh returns the vertex coordinates and the triangles of the mesh of an
+// object. Triangles only reference existing vertices.
+func parseMesh(obj *models.Object) ([][3]float64, []models.Triangle, error) {
+	if obj.Mesh == nil || obj.Mesh.Vertices == nil || obj.Mesh.Triangles == nil {
+		return nil, nil, fmt.Errorf("object has no mesh")
+	}
+
+	points := make([][3]float64, len(obj.Mesh.Vertices.Vertex))
+	for i, v := range obj.Mesh.Vertices.Vertex {
+		points[i] = [3]float64{v.X, v.Y, v.Z}
+	}
+	triangles := obj.Mesh.Triangles.Triangle
+	for _, t := range triangles {
+		if t.V1 < 0 || t.V2 < 0 || t.V3 < 0 || t.V1 >= len(points) || t.V2 >= len(points) || t.V3 >= len(points) {
+			return nil, nil, fmt.Errorf("triangle references unknown vertex")
+		}
+	}
+	return points, triangles, nil
+}
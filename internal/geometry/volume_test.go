@@ -0,0 +1,54 @@
+package geometry
+
+import (
+	"math"
+	"testing"
+
+	"github.com/philipparndt/go3mf/internal/models"
+)
+
+func TestMeshVolume(t *testing.T) {
+	// A 10 x 20 x 30 mm box away from the origin
+	cube := &models.Object{Mesh: &models.Mesh{
+		Vertices: &models.Vertices{Vertex: []models.Vertex{
+			{X: 5, Y: 5, Z: 5}, {X: 15, Y: 5, Z: 5}, {X: 15, Y: 25, Z: 5}, {X: 5, Y: 25, Z: 5},
+			{X: 5, Y: 5, Z: 35}, {X: 15, Y: 5, Z: 35}, {X: 15, Y: 25, Z: 35}, {X: 5, Y: 25, Z: 35},
+		}},
+		Triangles: &models.Triangles{Triangle: []models.Triangle{
+			{V1: 0, V2: 2, V3: 1}, {V1: 0, V2: 3, V3: 2},
+			{V1: 4, V2: 5, V3: 6}, {V1: 4, V2: 6, V3: 7},
+			{V1: 0, V2: 1, V3: 5}, {V1: 0, V2: 5, V3: 4},
+			{V1: 1, V2: 2, V3: 6}, {V1: 1, V2: 6, V3: 5},
+			{V1: 2, V2: 3, V3: 7}, {V1: 2, V2: 7, V3: 6},
+			{V1: 3, V2: 0, V3: 4}, {V1: 3, V2: 4, V3: 7},
+		}},
+	}}
+
+	volume, err := MeshVolume(cube)
+	if err != nil {
+		t.Fatalf("MeshVolume() error = %v", err)
+	}
+	if math.Abs(volume-6000) > 1e-9 {
+		t.Errorf("MeshVolume() = %f, want 6000", volume)
+	}
+
+	if _, err := MeshVolume(&models.Object{}); err == nil {
+		t.Error("MeshVolume() of an object without mesh should fail")
+	}
+
+	area, err := MeshArea(cube)
+	if err != nil {
+		t.Fatalf("MeshArea() error = %v", err)
+	}
+	if want := 2 * (10*20 + 10*30 + 20*30.0); math.Abs(area-want) > 1e-9 {
+		t.Errorf("MeshArea() = %f, want %f", area, want)
+	}
+
+	triangles, err := MeshTriangles(cube)
+	if err != nil {
+		t.Fatalf("MeshTriangles() error = %v", err)
+	}
+	if len(triangles) != 12 || triangles[0] != [3][3]float64{{5, 5, 5}, {15, 25, 5}, {15, 5, 5}} {
+		t.Errorf("MeshTriangles() = %d triangles, first %v", len(triangles), triangles[0])
+	}
+}
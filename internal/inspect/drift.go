@@ -0,0 +1,157 @@
+package inspect
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/philipparndt/go3mf/internal/manifest"
+	"github.com/philipparndt/go3mf/internal/models"
+)
+
+// Drift is a difference between a 3MF file and the configuration it was built from
+type Drift struct {
+	Object  string
+	Message string
+}
+
+// String returns the drift as "object: message"
+func (d Drift) String() string {
+	return d.Object + ": " + d.Message
+}
+
+// CompareConfig compares the objects of a 3MF file with the objects a YAML
+// configuration builds: objects missing in the 3MF or not in the config,
+// changed counts, missing or extra parts and parts with another filament.
+// Parts without a filament in the config (auto-assign) match any filament.
+func CompareConfig(m *manifest.Manifest, config *models.YamlConfig) []Drift {
+	actual := make(map[string]manifest.Object, len(m.Objects))
+	for _, obj := range m.Objects {
+		actual[obj.Name] = obj
+	}
+
+	objects := append([]models.YamlObject{}, config.Objects...)
+	for _, plate := range config.Plates {
+		objects = append(objects, plate.Objects...)
+	}
+	expected := make(map[string]bool)
+	for _, obj := range objects {
+		for _, name := range copyNames(obj) {
+			expected[name] = true
+		}
+	}
+
+	var drifts []Drift
+	matched := make(map[string]bool)
+	for _, obj := range objects {
+		// Copies are found by name, also if the count changed
+		var copies []string
+		if _, ok := actual[obj.Name]; ok {
+			copies = append(copies, obj.Name)
+		}
+		for _, actualObj := range m.Objects {
+			if isCopyOf(actualObj.Name, obj.Name) && (!expected[actualObj.Name] || objectCount(obj) > 1) {
+				copies = append(copies, actualObj.Name)
+			}
+		}
+
+		switch {
+		case len(copies) == 0:
+			drifts = append(drifts, Drift{obj.Name, "missing in the 3MF file"})
+			continue
+		case len(copies) != objectCount(obj):
+			drifts = append(drifts, Drift{obj.Name, fmt.Sprintf("count changed: %d in the config, %d in the 3MF file", objectCount(obj), len(copies))})
+		}
+		for _, name := range copies {
+			matched[name] = true
+			drifts = append(drifts, compareParts(name, obj.Parts, actual[name])...)
+		}
+	}
+
+	for _, obj := range m.Objects {
+		if !matched[obj.Name] {
+			drifts = append(drifts, Drift{obj.Name, "not in the config"})
+		}
+	}
+	return drifts
+}
+
+// compareParts compares the parts of an object in the config with the parts in the 3MF file
+func compareParts(name string, parts []models.YamlPart, obj manifest.Object) []Drift {
+	actual := make(map[string]int, len(obj.Parts))
+	for _, part := range obj.Parts {
+		actual[partName(name, part.Name)] = part.Filament
+	}
+
+	var drifts []Drift
+	configured := make(map[string]bool, len(parts))
+	for _, part := range parts {
+		partKey := part.Name
+		if len(parts) == 1 {
+			partKey = ""
+		}
+		configured[partKey] = true
+
+		filament, ok := actual[partKey]
+		switch {
+		case !ok && len(parts) == 1 && len(obj.Parts) == 1:
+			// A single part is named after the object, whatever its name in the config
+			filament = obj.Parts[0].Filament
+		case !ok:
+			drifts = append(drifts, Drift{name, fmt.Sprintf("part %s missing in the 3MF file", part.Name)})
+			continue
+		}
+		if part.Filament != 0 && part.Filament != filament {
+			drifts = append(drifts, Drift{name, fmt.Sprintf("part %s uses filament %d instead of %d", part.Name, filament, part.Filament)})
+		}
+	}
+
+	if len(parts) == 1 && len(obj.Parts) == 1 {
+		return drifts
+	}
+	for _, part := range obj.Parts {
+		if partKey := partName(name, part.Name); !configured[partKey] {
+			drifts = append(drifts, Drift{name, fmt.Sprintf("part %s not in the config", partKey)})
+		}
+	}
+	return drifts
+}
+
+// partName returns the name of a part without the object name ("" for the only part of an object)
+func partName(object, part string) string {
+	if part == object {
+		return ""
+	}
+	return strings.TrimPrefix(part, object+"/")
+}
+
+// copyNames returns the names of the copies of an object, e.g. box_1 and box_2
+func copyNames(obj models.YamlObject) []string {
+	count := objectCount(obj)
+	if count == 1 {
+		return []string{obj.Name}
+	}
+	names := make([]string, count)
+	for i := range names {
+		names[i] = fmt.Sprintf("%s_%d", obj.Name, i+1)
+	}
+	return names
+}
+
+// objectCount returns the number of copies of an object
+func objectCount(obj models.YamlObject) int {
+	if obj.Count < 1 {
+		return 1
+	}
+	return obj.Count
+}
+
+// isCopyOf reports whether name is the name of a numbered copy of an object
+func isCopyOf(name, object string) bool {
+	suffix, ok := strings.CutPrefix(name, object+"_")
+	if !ok {
+		return false
+	}
+	n, err := strconv.Atoi(suffix)
+	return err == nil && n > 0
+}
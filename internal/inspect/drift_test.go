@@ -0,0 +1,81 @@
+package inspect
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/philipparndt/go3mf/internal/manifest"
+	"github.com/philipparndt/go3mf/internal/models"
+)
+
+func TestCompareConfig(t *testing.T) {
+	box := func(name string, lidFilament int) manifest.Object {
+		return manifest.Object{Name: name, Parts: []manifest.Part{
+			{Name: name + "/base", Filament: 1},
+			{Name: name + "/lid", Filament: lidFilament},
+		}}
+	}
+	config := func(count, lidFilament int) *models.YamlConfig {
+		return &models.YamlConfig{
+			Objects: []models.YamlObject{
+				{Name: "box", Count: count, Parts: []models.YamlPart{{Name: "base"}, {Name: "lid", Filament: lidFilament}}},
+			},
+			Plates: []models.YamlPlate{
+				{Objects: []models.YamlObject{{Name: "pin", Parts: []models.YamlPart{{Name: "pin", Filament: 2}}}}},
+			},
+		}
+	}
+	pin := manifest.Object{Name: "pin", Parts: []manifest.Part{{Name: "pin", Filament: 2}}}
+
+	tests := []struct {
+		name    string
+		objects []manifest.Object
+		config  *models.YamlConfig
+		want    []Drift
+	}{
+		{
+			name:    "up to date",
+			objects: []manifest.Object{box("box_1", 2), box("box_2", 2), pin},
+			config:  config(2, 2),
+		},
+		{
+			name:    "auto-assigned filament",
+			objects: []manifest.Object{box("box", 4), pin},
+			config:  config(1, 0),
+		},
+		{
+			name:    "count changed",
+			objects: []manifest.Object{box("box", 2), pin},
+			config:  config(3, 2),
+			want:    []Drift{{"box", "count changed: 3 in the config, 1 in the 3MF file"}},
+		},
+		{
+			name:    "missing and extra objects",
+			objects: []manifest.Object{box("box", 2), {Name: "extra"}},
+			config:  config(1, 2),
+			want:    []Drift{{"pin", "missing in the 3MF file"}, {"extra", "not in the config"}},
+		},
+		{
+			name: "parts",
+			objects: []manifest.Object{
+				{Name: "box", Parts: []manifest.Part{{Name: "box/base", Filament: 1}, {Name: "box/handle", Filament: 1}}},
+				{Name: "pin", Parts: []manifest.Part{{Name: "pin", Filament: 3}}},
+			},
+			config: config(1, 2),
+			want: []Drift{
+				{"box", "part lid missing in the 3MF file"},
+				{"box", "part handle not in the config"},
+				{"pin", "part pin uses filament 3 instead of 2"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := CompareConfig(&manifest.Manifest{Objects: tt.objects}, tt.config)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CompareConfig() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
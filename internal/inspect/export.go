@@ -0,0 +1,105 @@
+package inspect
+
+import (
+	"archive/zip"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/philipparndt/go3mf/internal/extract"
+	"github.com/philipparndt/go3mf/internal/geometry"
+	"github.com/philipparndt/go3mf/internal/limits"
+	"github.com/philipparndt/go3mf/internal/manifest"
+	"github.com/philipparndt/go3mf/internal/models"
+	"github.com/philipparndt/go3mf/internal/threemf"
+)
+
+// ExportObjects writes every object of a 3MF file as standalone 3MF or STL file
+// to dir, named after the object. name maps the names of objects to the names of
+// the files, objects with the same name (e.g. the copies of an object) are
+// written once. An STL file holds the meshes of all parts of the object. Objects
+// keep their rotation, but are moved to the origin of the plate. The written
+// files are returned.
+func (i *Inspector) ExportObjects(filename, dir string, format models.OutputFormat, name func(string) string) ([]string, error) {
+	model, settings, err := i.read3MFFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("error reading 3MF file: %w", err)
+	}
+	m, err := manifest.FromModel(model, settings)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read objects: %w", err)
+	}
+
+	zr, err := limits.OpenZip(filename)
+	if err != nil {
+		return nil, fmt.Errorf("error opening file: %w", err)
+	}
+	defer zr.Close()
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("error creating directory: %w", err)
+	}
+
+	ext := ".3mf"
+	if format == models.OutputFormatSTL {
+		ext = ".stl"
+	}
+	exported := make(map[string]bool) // Names of the exported objects
+	used := make(map[string]bool)     // Names of the written files
+	var files []string
+	for idx := range model.Build.Items {
+		objectName := name(m.Objects[idx].Name)
+		if exported[objectName] {
+			continue
+		}
+		exported[objectName] = true
+
+		base := extract.SafeFilename(objectName, false)
+		file := base + ext
+		for n := 2; used[file]; n++ {
+			file = fmt.Sprintf("%s_%d%s", base, n, ext)
+		}
+		used[file] = true
+		path := filepath.Join(dir, file)
+
+		if format == models.OutputFormatSTL {
+			err = exportSTL(&zr.Reader, model, idx, objectName, path)
+		} else {
+			err = threemf.WriteObject(filename, path, model, idx)
+		}
+		if err != nil {
+			return files, fmt.Errorf("object %s: %w", objectName, err)
+		}
+		files = append(files, path)
+	}
+	return files, nil
+}
+
+// exportSTL writes the meshes of the parts of a build item to one STL file
+func exportSTL(zr *zip.Reader, model *models.Model, item int, name, path string) error {
+	objectModel, err := threemf.ObjectModel(model, item)
+	if err != nil {
+		return err
+	}
+	buildItem := objectModel.Build.Items[0]
+	itemTransform, err := geometry.ParseTransform(buildItem.Transform)
+	if err != nil {
+		return err
+	}
+
+	var obj models.Object
+	for _, o := range objectModel.Resources.Objects {
+		if o.ID == buildItem.ObjectID {
+			obj = o
+		}
+	}
+	components := models.ObjectComponents(&obj)
+	for j, component := range components {
+		m, err := geometry.ParseTransform(component.Transform)
+		if err != nil {
+			return err
+		}
+		components[j].Transform = geometry.FormatTransform(geometry.MultiplyTransforms(m, itemTransform))
+	}
+	return extract.NewExtractor().ExtractComponents(zr, components, name, path, true)
+}
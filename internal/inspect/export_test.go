@@ -0,0 +1,114 @@
+package inspect
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/philipparndt/go3mf/internal/models"
+	"github.com/philipparndt/go3mf/internal/stl"
+	"github.com/philipparndt/go3mf/internal/threemf"
+)
+
+const exportMesh = `<mesh><vertices><vertex x="0" y="0" z="0"/><vertex x="10" y="0" z="0"/><vertex x="0" y="10" z="0"/><vertex x="0" y="0" z="10"/></vertices>` +
+	`<triangles><triangle v1="0" v2="2" v3="1"/><triangle v1="0" v2="1" v3="3"/><triangle v1="0" v2="3" v3="2"/><triangle v1="1" v2="2" v3="3"/></triangles></mesh>`
+
+// writeExportArchive writes a 3MF file with a single mesh object and two copies
+// of an object with two parts
+func writeExportArchive(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "build.3mf")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	for name, content := range map[string]string{
+		"[Content_Types].xml": "types",
+		"_rels/.rels":         `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"/>`,
+		"3D/3dmodel.model": `<?xml version="1.0" encoding="UTF-8"?>
+<model xmlns="http://schemas.microsoft.com/3dmanufacturing/core/2015/02" unit="millimeter"><resources>
+<object id="1" name="pin" type="model">` + exportMesh + `</object>
+<object id="2" name="box_body" type="model">` + exportMesh + `</object>
+<object id="3" name="box_lid" type="model">` + exportMesh + `</object>
+<object id="4" name="box_1" type="model"><components><component objectid="2"/><component objectid="3" transform="1 0 0 0 1 0 0 0 1 0 0 10"/></components></object>
+<object id="5" name="box_2" type="model"><components><component objectid="2"/><component objectid="3" transform="1 0 0 0 1 0 0 0 1 0 0 10"/></components></object>
+</resources><build>
+<item objectid="1" transform="1 0 0 0 1 0 0 0 1 50 60 0"/>
+<item objectid="4" transform="1 0 0 0 1 0 0 0 1 100 60 0"/>
+<item objectid="5" transform="1 0 0 0 1 0 0 0 1 150 60 0"/>
+</build></model>`,
+	} {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	return path
+}
+
+func TestExportObjects(t *testing.T) {
+	source := writeExportArchive(t)
+	copies := func(name string) string {
+		return strings.TrimSuffix(strings.TrimSuffix(name, "_1"), "_2")
+	}
+
+	t.Run("stl", func(t *testing.T) {
+		dir := t.TempDir()
+		files, err := NewInspector().ExportObjects(source, dir, models.OutputFormatSTL, copies)
+		if err != nil {
+			t.Fatalf("ExportObjects() error = %v", err)
+		}
+		if want := []string{filepath.Join(dir, "pin.stl"), filepath.Join(dir, "box.stl")}; !reflect.DeepEqual(files, want) {
+			t.Fatalf("files = %v, want %v", files, want)
+		}
+
+		pin, err := stl.NewParser().Parse(files[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The object is moved to the origin
+		if v := pin.Triangles[0].V2; v != (stl.Vector3{X: 0, Y: 10, Z: 0}) {
+			t.Errorf("pin vertex = %v, want (0, 10, 0)", v)
+		}
+		box, err := stl.NewParser().Parse(files[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(box.Triangles) != 8 {
+			t.Errorf("box has %d triangles, want the 8 of both parts", len(box.Triangles))
+		}
+		if v := box.Triangles[4].V1; v != (stl.Vector3{X: 0, Y: 0, Z: 10}) {
+			t.Errorf("lid vertex = %v, want (0, 0, 10)", v)
+		}
+	})
+
+	t.Run("3mf", func(t *testing.T) {
+		dir := t.TempDir()
+		files, err := NewInspector().ExportObjects(source, dir, models.OutputFormat3MF, copies)
+		if err != nil {
+			t.Fatalf("ExportObjects() error = %v", err)
+		}
+		if len(files) != 2 || filepath.Base(files[1]) != "box.3mf" {
+			t.Fatalf("files = %v, want pin.3mf and box.3mf", files)
+		}
+		model, err := (&threemf.Reader{}).Read(files[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(model.Resources.Objects) != 3 || len(model.Build.Items) != 1 {
+			t.Errorf("box.3mf has %d objects and %d build items, want 3 and 1", len(model.Resources.Objects), len(model.Build.Items))
+		}
+		if item := model.Build.Items[0]; item.ObjectID != "4" || item.Transform != "1 0 0 0 1 0 0 0 1 0 0 0" {
+			t.Errorf("build item = %+v, want object 4 at the origin", item)
+		}
+	})
+}
@@ -2,13 +2,17 @@ package inspect
 
 import (
 	"archive/zip"
+	"encoding/json"
 	"encoding/xml"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 
+	"github.com/philipparndt/go3mf/internal/geometry"
+	"github.com/philipparndt/go3mf/internal/limits"
 	"github.com/philipparndt/go3mf/internal/models"
+	"github.com/philipparndt/go3mf/internal/threemf"
 	"github.com/philipparndt/go3mf/internal/ui"
 )
 
@@ -61,7 +65,7 @@ func (i *Inspector) Inspect(filename string) error {
 				printable = "✗ no"
 			}
 
-			// Get offset information from transform
+			// Get offset, rotation and scale from the transform
 			offsetInfo := ""
 			if item.Transform != "" {
 				if x, y, z, ok := ParseTransformOffset(item.Transform); ok {
@@ -69,6 +73,11 @@ func (i *Inspector) Inspect(filename string) error {
 						offsetInfo = fmt.Sprintf(" 📍 [%.2f, %.2f, %.2f]", x, y, z)
 					}
 				}
+				if m, err := geometry.ParseTransform(item.Transform); err == nil {
+					for _, detail := range TransformDetails(geometry.Decompose(m)) {
+						offsetInfo += " " + detail
+					}
+				}
 			}
 
 			ui.PrintItem(fmt.Sprintf("#%d Object %s: %s (printable: %s)%s", idx+1, item.ObjectID, objectName, printable, offsetInfo))
@@ -78,8 +87,19 @@ func (i *Inspector) Inspect(filename string) error {
 	// Print object hierarchy
 	ui.PrintHeader("Model Objects")
 	printer := NewModelPrinter()
+	printer.SetFilamentColors(i.ReadFilamentColors(filename))
 	printer.PrintObjectHierarchy(model, settings)
 
+	// Print the resources of the slice and beam lattice extensions
+	_, ext, err := (&threemf.Reader{}).ReadWithExtensions(filename)
+	if err != nil {
+		return fmt.Errorf("error reading 3MF file: %w", err)
+	}
+	if !ext.Empty() || len(ext.Required) > 0 {
+		ui.PrintHeader("Extensions")
+		i.printExtensions(model, ext)
+	}
+
 	ui.PrintSeparator()
 	ui.PrintSuccess("Inspection complete!")
 	// Convert to relative path if possible
@@ -92,6 +112,61 @@ func (i *Inspector) Inspect(filename string) error {
 	return nil
 }
 
+// printExtensions prints the slice stacks and beam lattices of a model
+func (i *Inspector) printExtensions(model *models.Model, ext *threemf.Extensions) {
+	unsupported := make(map[string]bool)
+	for _, namespace := range ext.Unsupported() {
+		unsupported[namespace] = true
+	}
+	for _, namespace := range ext.Required {
+		if unsupported[namespace] {
+			ui.PrintItem(fmt.Sprintf("Required: %s ⚠ not supported when combining", namespace))
+		} else {
+			ui.PrintItem("Required: " + namespace)
+		}
+	}
+
+	for _, stack := range ext.SliceStacks {
+		line := fmt.Sprintf("Slice stack %s: %d slices", stack.ID, len(stack.ZTops))
+		if len(stack.ZTops) > 0 {
+			line += fmt.Sprintf(" from z %.2f to %.2fmm", stack.ZBottom, stack.ZTops[len(stack.ZTops)-1])
+			minHeight, maxHeight := stack.LayerHeights()
+			if maxHeight-minHeight < 1e-6 {
+				line += fmt.Sprintf(", layer height %.2fmm", minHeight)
+			} else {
+				line += fmt.Sprintf(", layer heights %.2f-%.2fmm", minHeight, maxHeight)
+			}
+		}
+		if stack.References > 0 {
+			line += fmt.Sprintf(", %d referenced stack(s)", stack.References)
+		}
+		ui.PrintItem(line)
+	}
+
+	for _, obj := range model.Resources.Objects {
+		if stackID, ok := ext.SliceObjects[obj.ID]; ok {
+			ui.PrintItem(fmt.Sprintf("Object %s uses slice stack %s", objectLabel(model, obj.ID), stackID))
+		}
+	}
+
+	for _, lattice := range ext.BeamLattices {
+		ui.PrintItem(fmt.Sprintf("Beam lattice of object %s: %d beam(s), %d ball(s)", objectLabel(model, lattice.ObjectID), lattice.Beams, lattice.Balls))
+	}
+	if !ext.Empty() {
+		ui.PrintInfo("Objects made of slice stacks or beam lattices only are skipped when combining")
+	}
+}
+
+// objectLabel returns the ID of an object followed by its name, if it has one
+func objectLabel(model *models.Model, objectID string) string {
+	for _, obj := range model.Resources.Objects {
+		if obj.ID == objectID && obj.Name != "" {
+			return fmt.Sprintf("%s (%s)", objectID, obj.Name)
+		}
+	}
+	return objectID
+}
+
 // Read3MFFile reads a 3MF file and returns the model and settings (exported for use by other packages)
 func (i *Inspector) Read3MFFile(filename string) (*models.Model, *models.ModelSettings, error) {
 	return i.read3MFFile(filename)
@@ -99,7 +174,7 @@ func (i *Inspector) Read3MFFile(filename string) (*models.Model, *models.ModelSe
 
 // read3MFFile reads a 3MF file and returns the model and settings
 func (i *Inspector) read3MFFile(filename string) (*models.Model, *models.ModelSettings, error) {
-	zr, err := zip.OpenReader(filename)
+	zr, err := limits.OpenZip(filename)
 	if err != nil {
 		return nil, nil, fmt.Errorf("error opening file: %w", err)
 	}
@@ -136,6 +211,44 @@ func (i *Inspector) read3MFFile(filename string) (*models.Model, *models.ModelSe
 	return model, settings, nil
 }
 
+// ReadFilamentColors returns the filament colors of the project settings of a 3MF
+// file (slot 1 first), or nil if the file has none
+func (i *Inspector) ReadFilamentColors(filename string) []string {
+	zr, err := limits.OpenZip(filename)
+	if err != nil {
+		return nil
+	}
+	defer zr.Close()
+
+	for _, f := range zr.File {
+		if f.Name != threemf.ProjectSettingsFile {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil
+		}
+		defer rc.Close()
+		data, err := io.ReadAll(rc)
+		if err != nil {
+			return nil
+		}
+		return filamentColors(data)
+	}
+	return nil
+}
+
+// filamentColors returns the filament colors of JSON project settings
+func filamentColors(data []byte) []string {
+	var project struct {
+		FilamentColour []string `json:"filament_colour"`
+	}
+	if json.Unmarshal(data, &project) != nil {
+		return nil
+	}
+	return project.FilamentColour
+}
+
 // parseModel parses the 3D model XML
 func (i *Inspector) parseModel(file *zip.File) (*models.Model, error) {
 	rc, err := file.Open()
@@ -144,17 +257,12 @@ func (i *Inspector) parseModel(file *zip.File) (*models.Model, error) {
 	}
 	defer rc.Close()
 
-	data, err := io.ReadAll(rc)
+	model, _, err := threemf.DecodeModel(rc)
 	if err != nil {
-		return nil, fmt.Errorf("error reading model file: %w", err)
-	}
-
-	var model models.Model
-	if err := xml.Unmarshal(data, &model); err != nil {
-		return nil, fmt.Errorf("error parsing model XML: %w", err)
+		return nil, err
 	}
 
-	return &model, nil
+	return model, nil
 }
 
 // parseSettings parses the Bambu Studio settings file
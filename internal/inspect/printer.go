@@ -2,21 +2,49 @@ package inspect
 
 import (
 	"fmt"
+	"math"
 	"strconv"
 	"strings"
 
+	"github.com/charmbracelet/lipgloss"
+	"github.com/philipparndt/go3mf/internal/geometry"
 	"github.com/philipparndt/go3mf/internal/models"
 	"github.com/philipparndt/go3mf/internal/ui"
 )
 
 // ModelPrinter handles printing model hierarchy and details
-type ModelPrinter struct{}
+type ModelPrinter struct {
+	filamentColors []string // Color of every filament slot, slot 1 first
+}
 
 // NewModelPrinter creates a new ModelPrinter
 func NewModelPrinter() *ModelPrinter {
 	return &ModelPrinter{}
 }
 
+// SetFilamentColors sets the colors of the filament slots (slot 1 first), which
+// are shown as swatches next to the filament of every object and part
+func (p *ModelPrinter) SetFilamentColors(colors []string) {
+	p.filamentColors = colors
+}
+
+// filamentLabel returns the filament column for a filament slot, with a swatch
+// of the slot's color when it is known
+func (p *ModelPrinter) filamentLabel(slot string) string {
+	label := fmt.Sprintf("filament:%-2s", slot)
+	if n, err := strconv.Atoi(slot); err == nil && n >= 1 && n <= len(p.filamentColors) {
+		if swatch := ui.Swatch(p.filamentColors[n-1]); swatch != "" {
+			label += " " + swatch
+		}
+	}
+	return label
+}
+
+// padRight pads s with spaces to width visible characters, ignoring color codes
+func padRight(s string, width int) string {
+	return s + strings.Repeat(" ", max(width-lipgloss.Width(s), 0))
+}
+
 // ParseTransformOffset extracts X, Y, Z offset from a transform matrix string
 // Transform format: "m11 m12 m13 m21 m22 m23 m31 m32 m33 x y z"
 func ParseTransformOffset(transform string) (x, y, z float64, ok bool) {
@@ -36,11 +64,6 @@ func ParseTransformOffset(transform string) (x, y, z float64, ok bool) {
 	return x, y, z, true
 }
 
-// parseTransformOffset is a wrapper for backward compatibility
-func parseTransformOffset(transform string) (x, y, z float64, ok bool) {
-	return ParseTransformOffset(transform)
-}
-
 // PrintObjectHierarchy prints the object hierarchy with components and filaments
 func (p *ModelPrinter) PrintObjectHierarchy(model *models.Model, settings *models.ModelSettings) {
 	// Create a map of object IDs to settings info
@@ -97,7 +120,7 @@ func (p *ModelPrinter) printObject(model *models.Model, obj *models.Object, sett
 	if settings, ok := settingsMap[obj.ID]; ok {
 		for _, meta := range settings.Metadata {
 			if meta.Key == "extruder" && meta.Value != "" {
-				filament = fmt.Sprintf("filament:%-2s", meta.Value)
+				filament = p.filamentLabel(meta.Value)
 				break
 			}
 		}
@@ -111,6 +134,9 @@ func (p *ModelPrinter) printObject(model *models.Model, obj *models.Object, sett
 	if obj.Components != nil && len(obj.Components.Component) > 0 {
 		details = append(details, fmt.Sprintf("%d parts", len(obj.Components.Component)))
 	}
+	if obj.Type != "" && obj.Type != string(models.ObjectTypeModel) {
+		details = append(details, obj.Type)
+	}
 
 	// Format the line with proper spacing
 	detailStr := ""
@@ -118,7 +144,7 @@ func (p *ModelPrinter) printObject(model *models.Model, obj *models.Object, sett
 		detailStr = fmt.Sprintf("[%s]", strings.Join(details, ", "))
 	}
 
-	line := fmt.Sprintf("%-30s  id:%-6s  %-14s  %s", name, obj.ID, filament, detailStr)
+	line := fmt.Sprintf("%-30s  id:%-6s  %s  %s", name, obj.ID, padRight(filament, 14), detailStr)
 	ui.PrintItem(strings.TrimRight(line, " "))
 
 	// Print each component
@@ -150,7 +176,7 @@ func (p *ModelPrinter) printComponent(obj *models.Object, comp models.Component,
 	if part, ok := partsMap[obj.ID]; ok {
 		for _, meta := range part.Metadata {
 			if meta.Key == "extruder" && meta.Value != "" {
-				filament = fmt.Sprintf("filament:%-2s", meta.Value)
+				filament = p.filamentLabel(meta.Value)
 				break
 			}
 			if meta.Key == "name" && meta.Value != "" {
@@ -159,18 +185,66 @@ func (p *ModelPrinter) printComponent(obj *models.Object, comp models.Component,
 		}
 	}
 
-	// Get offset information from transform
-	offset := ""
-	if comp.Transform != "" {
-		if x, y, z, ok := parseTransformOffset(comp.Transform); ok {
-			// Only show offset if it's not zero
-			if x != 0 || y != 0 || z != 0 {
-				offset = fmt.Sprintf("[offset: %.1f, %.1f, %.1f]", x, y, z)
+	// Get offset, rotation and scale from the transform
+	var transform []string
+	if m, err := geometry.ParseTransform(comp.Transform); err == nil {
+		d := geometry.Decompose(m)
+		// Only show offset if it's not zero
+		if x, y, z := d.Translation[0], d.Translation[1], d.Translation[2]; x != 0 || y != 0 || z != 0 {
+			transform = append(transform, fmt.Sprintf("[offset: %.1f, %.1f, %.1f]", x, y, z))
+		}
+		transform = append(transform, TransformDetails(d)...)
+	}
+
+	// Meshes that are not models, e.g. supports
+	if obj.Type != "" && obj.Type != string(models.ObjectTypeModel) {
+		transform = append(transform, "[type: "+obj.Type+"]")
+	}
+
+	// Bambu Studio keeps the placement of the part within its object in its matrix
+	if part, ok := partsMap[obj.ID]; ok {
+		if m, err := geometry.ParseMatrix(models.MetadataValue(part.Metadata, "matrix")); err == nil {
+			d := geometry.Decompose(m)
+			if x, y, z := d.Translation[0], d.Translation[1], d.Translation[2]; x != 0 || y != 0 || z != 0 {
+				transform = append(transform, fmt.Sprintf("[matrix offset: %.1f, %.1f, %.1f]", x, y, z))
+			}
+			for _, detail := range TransformDetails(d) {
+				transform = append(transform, strings.Replace(detail, "[", "[matrix ", 1))
 			}
 		}
 	}
 
 	// Format the line with proper spacing
-	line := fmt.Sprintf("%-30s  id:%-6s  %-14s  %s", name, obj.ID, filament, offset)
+	line := fmt.Sprintf("%-30s  id:%-6s  %s  %s", name, obj.ID, padRight(filament, 14), strings.Join(transform, " "))
 	ui.PrintItem(strings.TrimRight(line, " "))
 }
+
+// TransformDetails describes the rotation and scale of a decomposed transform
+// and flags mirrors and non-uniform scale. Identity rotation and scale are left out.
+func TransformDetails(d geometry.Decomposition) []string {
+	var details []string
+	rx, ry, rz := roundAngle(d.Rotation[0]), roundAngle(d.Rotation[1]), roundAngle(d.Rotation[2])
+	if rx != 0 || ry != 0 || rz != 0 {
+		details = append(details, fmt.Sprintf("[rotation: %.1f°, %.1f°, %.1f°]", rx, ry, rz))
+	}
+	sx, sy, sz := d.Scale[0], d.Scale[1], d.Scale[2]
+	if math.Abs(sx-1) > 1e-6 || math.Abs(sy-1) > 1e-6 || math.Abs(sz-1) > 1e-6 {
+		details = append(details, fmt.Sprintf("[scale: %.3g, %.3g, %.3g]", sx, sy, sz))
+	}
+	if d.Mirrored() {
+		details = append(details, "⚠ mirrored")
+	}
+	if d.NonUniformScale() {
+		details = append(details, "⚠ non-uniform scale")
+	}
+	return details
+}
+
+// roundAngle rounds an angle to the precision shown, without -0
+func roundAngle(angle float64) float64 {
+	r := math.Round(angle*10) / 10
+	if r == 0 {
+		return 0
+	}
+	return r
+}
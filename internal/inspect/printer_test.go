@@ -0,0 +1,39 @@
+package inspect
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFilamentColors(t *testing.T) {
+	data := []byte(`{"filament_colour": ["#FF0000", "#FFFFFFFF"], "filament_type": ["PLA", "PLA"]}`)
+	if got, want := filamentColors(data), []string{"#FF0000", "#FFFFFFFF"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("filamentColors() = %v, want %v", got, want)
+	}
+	if got := filamentColors([]byte("not json")); got != nil {
+		t.Errorf("filamentColors() = %v, want nil", got)
+	}
+}
+
+func TestFilamentLabel(t *testing.T) {
+	printer := NewModelPrinter()
+	printer.SetFilamentColors([]string{"#FF0000", "red"})
+
+	tests := []struct {
+		slot string
+		want string
+	}{
+		{"1", "filament:1  ██"},
+		{"2", "filament:2 "}, // not a hex color
+		{"3", "filament:3 "}, // no color for the slot
+		{"0", "filament:0 "},
+	}
+	for _, tt := range tests {
+		if got := printer.filamentLabel(tt.slot); got != tt.want {
+			t.Errorf("filamentLabel(%q) = %q, want %q", tt.slot, got, tt.want)
+		}
+		if got := padRight(printer.filamentLabel(tt.slot), 14); len([]rune(got)) != 14 {
+			t.Errorf("padRight(filamentLabel(%q)) = %q, want 14 characters", tt.slot, got)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package inspect
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/philipparndt/go3mf/internal/geometry"
+	"github.com/philipparndt/go3mf/internal/models"
+	"github.com/philipparndt/go3mf/internal/ui"
+)
+
+// objectColumns are the columns of object lists
+var objectColumns = []string{"name", "id", "parts", "filament", "size", "triangles"}
+
+// objectColumnWidths are the widths of the object list columns in tables
+var objectColumnWidths = []int{30, 6, 5, 10, 24, 10}
+
+// ObjectRow is an object of a 3MF file in an object list
+type ObjectRow struct {
+	Name      string
+	ID        string
+	Parts     int
+	Filament  string // Filament slots of the object or its parts, e.g. "1" or "1/2"
+	Size      string // Width x depth x height in mm ("" = no mesh)
+	Triangles int
+}
+
+// columns returns the values of the row in the order of objectColumns
+func (r ObjectRow) columns() []string {
+	return []string{r.Name, r.ID, strconv.Itoa(r.Parts), r.Filament, r.Size, strconv.Itoa(r.Triangles)}
+}
+
+// ObjectRows returns a row for every top level object of a 3MF file
+func (i *Inspector) ObjectRows(filename string) ([]ObjectRow, error) {
+	model, settings, err := i.read3MFFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("error reading 3MF file: %w", err)
+	}
+	return objectRows(model, settings), nil
+}
+
+// objectRows returns a row for every object that is not only a component of
+// another object
+func objectRows(model *models.Model, settings *models.ModelSettings) []ObjectRow {
+	objects := make(map[string]*models.Object)
+	componentIDs := make(map[string]bool)
+	for idx := range model.Resources.Objects {
+		obj := &model.Resources.Objects[idx]
+		objects[obj.ID] = obj
+		if obj.Components != nil {
+			for _, comp := range obj.Components.Component {
+				componentIDs[comp.ObjectID] = true
+			}
+		}
+	}
+
+	settingsObjects := make(map[string]*models.SettingsObject)
+	if settings != nil {
+		for idx := range settings.Objects {
+			settingsObjects[settings.Objects[idx].ID] = &settings.Objects[idx]
+		}
+	}
+
+	var rows []ObjectRow
+	for idx := range model.Resources.Objects {
+		obj := &model.Resources.Objects[idx]
+		if obj.Components == nil && componentIDs[obj.ID] {
+			continue
+		}
+
+		row := ObjectRow{Name: obj.Name, ID: obj.ID}
+		if row.Name == "" {
+			row.Name = "(unnamed)"
+		}
+
+		// Filament slots of the object and its parts (part IDs are only unique
+		// within their object)
+		objectFilament := ""
+		var settingsParts []models.Part
+		if so, ok := settingsObjects[obj.ID]; ok {
+			objectFilament = models.MetadataValue(so.Metadata, "extruder")
+			settingsParts = so.Parts
+		}
+		partFilaments := make(map[string]string)
+		for _, part := range settingsParts {
+			partFilaments[part.ID] = models.MetadataValue(part.Metadata, "extruder")
+		}
+		var filaments []string
+		addFilament := func(filament string) {
+			if filament == "" {
+				filament = objectFilament
+			}
+			if filament != "" && !contains(filaments, filament) {
+				filaments = append(filaments, filament)
+			}
+		}
+
+		// The object itself or its components with their offsets
+		meshes := []models.Object{*obj}
+		transforms := []string{""}
+		if obj.Components != nil {
+			meshes, transforms = nil, nil
+			for _, comp := range obj.Components.Component {
+				part, ok := objects[comp.ObjectID]
+				if !ok {
+					continue
+				}
+				meshes = append(meshes, *part)
+				transforms = append(transforms, comp.Transform)
+				addFilament(partFilaments[comp.ObjectID])
+			}
+		} else {
+			for _, part := range settingsParts {
+				addFilament(partFilaments[part.ID])
+			}
+			if len(settingsParts) == 0 {
+				addFilament("")
+			}
+		}
+		row.Parts = len(meshes)
+		row.Filament = strings.Join(filaments, "/")
+
+		for _, mesh := range meshes {
+			row.Triangles += triangleCount(&mesh)
+		}
+		if bbox, err := geometry.CalculateCombinedBoundingBox(meshes, transforms); err == nil {
+			row.Size = fmt.Sprintf("%.1f x %.1f x %.1f", bbox.Width(), bbox.Height(), bbox.Depth())
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// PrintObjectTable prints the object rows as table
+func PrintObjectTable(rows []ObjectRow) {
+	ui.SetTableWidths(objectColumnWidths...)
+	defer ui.SetTableWidths()
+
+	ui.PrintTableHeader(objectColumns...)
+	for _, row := range rows {
+		ui.PrintTableRow(row.columns()...)
+	}
+}
+
+// WriteObjectCSV writes the object rows as CSV with a header line
+func WriteObjectCSV(w io.Writer, rows []ObjectRow) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(objectColumns); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if err := writer.Write(row.columns()); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// triangleCount returns the number of triangles of the mesh of an object (0 =
+// no mesh)
+func triangleCount(obj *models.Object) int {
+	if obj.Mesh == nil || obj.Mesh.Triangles == nil {
+		return 0
+	}
+	return len(obj.Mesh.Triangles.Triangle)
+}
+
+// contains reports whether values contains s
+func contains(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,78 @@
+package inspect
+
+import (
+	"bytes"
+	"encoding/xml"
+	"reflect"
+	"testing"
+
+	"github.com/philipparndt/go3mf/internal/models"
+)
+
+const tableModel = `<model unit="millimeter">
+  <resources>
+    <object id="1" name="base" type="model">
+      <mesh>
+        <vertices><vertex x="0" y="0" z="0"/><vertex x="20" y="0" z="0"/><vertex x="0" y="10" z="0"/><vertex x="0" y="0" z="5"/></vertices>
+        <triangles><triangle v1="0" v2="1" v3="2"/><triangle v1="0" v2="2" v3="3"/><triangle v1="0" v2="3" v3="1"/><triangle v1="1" v2="3" v3="2"/></triangles>
+      </mesh>
+    </object>
+    <object id="2" name="lid" type="model">
+      <mesh>
+        <vertices><vertex x="0" y="0" z="0"/><vertex x="20" y="0" z="0"/><vertex x="0" y="10" z="0"/></vertices>
+        <triangles><triangle v1="0" v2="1" v3="2"/></triangles>
+      </mesh>
+    </object>
+    <object id="3" name="Box, large" type="model">
+      <components>
+        <component objectid="1" transform="1 0 0 0 1 0 0 0 1 0 0 0"/>
+        <component objectid="2" transform="1 0 0 0 1 0 0 0 1 0 0 5"/>
+      </components>
+    </object>
+    <object id="4" type="model">
+      <mesh>
+        <vertices><vertex x="0" y="0" z="0"/><vertex x="1" y="2" z="3"/></vertices>
+      </mesh>
+    </object>
+  </resources>
+</model>`
+
+const tableSettings = `<config>
+  <object id="3">
+    <metadata key="extruder" value="1"/>
+    <part id="1"><metadata key="name" value="base"/></part>
+    <part id="2"><metadata key="extruder" value="2"/></part>
+  </object>
+</config>`
+
+func TestObjectRows(t *testing.T) {
+	var model models.Model
+	if err := xml.Unmarshal([]byte(tableModel), &model); err != nil {
+		t.Fatal(err)
+	}
+	var settings models.ModelSettings
+	if err := xml.Unmarshal([]byte(tableSettings), &settings); err != nil {
+		t.Fatal(err)
+	}
+
+	rows := objectRows(&model, &settings)
+
+	want := []ObjectRow{
+		{Name: "Box, large", ID: "3", Parts: 2, Filament: "1/2", Size: "20.0 x 10.0 x 5.0", Triangles: 5},
+		{Name: "(unnamed)", ID: "4", Parts: 1, Size: "1.0 x 2.0 x 3.0"},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("objectRows() = %+v, want %+v", rows, want)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteObjectCSV(&buf, rows); err != nil {
+		t.Fatal(err)
+	}
+	wantCSV := "name,id,parts,filament,size,triangles\n" +
+		"\"Box, large\",3,2,1/2,20.0 x 10.0 x 5.0,5\n" +
+		"(unnamed),4,1,,1.0 x 2.0 x 3.0,0\n"
+	if buf.String() != wantCSV {
+		t.Errorf("WriteObjectCSV() = %q, want %q", buf.String(), wantCSV)
+	}
+}
@@ -0,0 +1,240 @@
+package inspect
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/philipparndt/go3mf/internal/extract"
+	"github.com/philipparndt/go3mf/internal/geometry"
+	"github.com/philipparndt/go3mf/internal/limits"
+	"github.com/philipparndt/go3mf/internal/manifest"
+	"github.com/philipparndt/go3mf/internal/models"
+	"gopkg.in/yaml.v3"
+)
+
+// unsafeFilename matches the characters that are replaced in the names of part files
+var unsafeFilename = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// plateObject is an object of the reconstructed config with the plate it is on
+type plateObject struct {
+	models.YamlObject
+	Plate int
+}
+
+// ToYAML reconstructs a YAML config from the objects of a 3MF file: their names,
+// parts, filaments and the offsets of the parts, with numbered copies of equal
+// objects as count. The mesh of every part is written as STL file to a directory
+// next to the config (<config>_parts), equal meshes are written once. Rotations
+// of parts are applied to the meshes. The config is written to configFile and returned.
+func (i *Inspector) ToYAML(filename, configFile string) (*models.YamlConfig, error) {
+	model, settings, err := i.read3MFFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("error reading 3MF file: %w", err)
+	}
+	m, err := manifest.FromModel(model, settings)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read objects: %w", err)
+	}
+
+	zr, err := limits.OpenZip(filename)
+	if err != nil {
+		return nil, fmt.Errorf("error opening file: %w", err)
+	}
+	defer zr.Close()
+
+	base := strings.TrimSuffix(filepath.Base(configFile), filepath.Ext(configFile))
+	partsDir := filepath.Join(filepath.Dir(configFile), base+"_parts")
+	if err := os.MkdirAll(partsDir, 0755); err != nil {
+		return nil, fmt.Errorf("error creating parts directory: %w", err)
+	}
+	writer := &partWriter{
+		zr:        &zr.Reader,
+		dir:       partsDir,
+		extractor: extract.NewExtractor(),
+		hashes:    make(map[string]string),
+		used:      make(map[string]bool),
+	}
+
+	objects := make(map[string]*models.Object)
+	for idx := range model.Resources.Objects {
+		objects[model.Resources.Objects[idx].ID] = &model.Resources.Objects[idx]
+	}
+
+	var result []plateObject
+	for idx, item := range model.Build.Items {
+		obj := objects[item.ObjectID]
+		mObj := m.Objects[idx]
+
+		components := models.ObjectComponents(obj)
+
+		object := plateObject{YamlObject: models.YamlObject{Name: mObj.Name}, Plate: mObj.Plate}
+		for j, component := range components {
+			part, err := writer.write(mObj, j, component)
+			if err != nil {
+				return nil, fmt.Errorf("object %s: %w", mObj.Name, err)
+			}
+			object.Parts = append(object.Parts, part)
+		}
+		result = append(result, object)
+	}
+
+	config := &models.YamlConfig{Output: base + ".3mf"}
+	plates := make(map[int][]models.YamlObject)
+	for _, obj := range collapseCopies(result) {
+		plates[obj.Plate] = append(plates[obj.Plate], obj.YamlObject)
+	}
+	if len(plates[0]) > 0 {
+		config.Objects = plates[0]
+	} else {
+		numbers := make([]int, 0, len(plates))
+		for number := range plates {
+			numbers = append(numbers, number)
+		}
+		sort.Ints(numbers)
+		for _, number := range numbers {
+			config.Plates = append(config.Plates, models.YamlPlate{Objects: plates[number]})
+		}
+	}
+
+	if err := writeConfig(config, configFile); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// partWriter writes the meshes of parts to STL files
+type partWriter struct {
+	zr        *zip.Reader
+	dir       string
+	extractor *extract.Extractor
+	hashes    map[string]string // SHA-256 of a written file -> its name
+	used      map[string]bool   // Names of the written files
+}
+
+// write writes the mesh of the j-th part of an object and returns the part for the config.
+// The translation of the component becomes the position of the part.
+func (w *partWriter) write(obj manifest.Object, j int, component models.Component) (models.YamlPart, error) {
+	part := models.YamlPart{Name: fmt.Sprintf("part_%d", j+1)}
+	if j < len(obj.Parts) {
+		part.Filament = obj.Parts[j].Filament
+		// The only part of an object is named after the object
+		if name := partName(obj.Name, obj.Parts[j].Name); name != "" {
+			part.Name = name
+		}
+	}
+
+	m, err := geometry.ParseTransform(component.Transform)
+	if err != nil {
+		return part, err
+	}
+	part.PositionX, part.PositionY, part.PositionZ = roundPosition(m[9]), roundPosition(m[10]), roundPosition(m[11])
+	rotation := ""
+	if m != [12]float64{1, 0, 0, 0, 1, 0, 0, 0, 1, m[9], m[10], m[11]} {
+		r := m
+		r[9], r[10], r[11] = 0, 0, 0
+		rotation = geometry.FormatTransform(r)
+	}
+
+	name := unsafeFilename.ReplaceAllString(extract.Transliterate(obj.Name+"_"+part.Name), "_")
+	file := name + ".stl"
+	for n := 2; w.used[file]; n++ {
+		file = fmt.Sprintf("%s_%d.stl", name, n)
+	}
+	path := filepath.Join(w.dir, file)
+	if err := w.extractor.ExtractObject(w.zr, component.Path, component.ObjectID, rotation, part.Name, path, true); err != nil {
+		return part, fmt.Errorf("part %s: %w", part.Name, err)
+	}
+
+	hash, err := fileHash(path)
+	if err != nil {
+		return part, err
+	}
+	if existing, ok := w.hashes[hash]; ok {
+		os.Remove(path)
+		file = existing
+	} else {
+		w.hashes[hash] = file
+		w.used[file] = true
+	}
+	part.File = filepath.Base(w.dir) + "/" + file
+	return part, nil
+}
+
+// collapseCopies replaces consecutive numbered copies of an object (name_1,
+// name_2, ...) with equal parts on the same plate by one object with a count
+func collapseCopies(objects []plateObject) []plateObject {
+	var result []plateObject
+	for i := 0; i < len(objects); {
+		base, ok := strings.CutSuffix(objects[i].Name, "_1")
+		count := 1
+		for ok && i+count < len(objects) {
+			next := objects[i+count]
+			if next.Name != fmt.Sprintf("%s_%d", base, count+1) || next.Plate != objects[i].Plate || !reflect.DeepEqual(next.Parts, objects[i].Parts) {
+				break
+			}
+			count++
+		}
+		if count == 1 {
+			result = append(result, objects[i])
+			i++
+			continue
+		}
+		object := objects[i]
+		object.Name = base
+		object.Count = count
+		result = append(result, object)
+		i += count
+	}
+	return result
+}
+
+// writeConfig writes a config as YAML
+func writeConfig(config *models.YamlConfig, configFile string) error {
+	f, err := os.Create(configFile)
+	if err != nil {
+		return fmt.Errorf("error creating config file: %w", err)
+	}
+	defer f.Close()
+
+	enc := yaml.NewEncoder(f)
+	enc.SetIndent(2)
+	if err := enc.Encode(config); err != nil {
+		return fmt.Errorf("error writing config file: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return fmt.Errorf("error writing config file: %w", err)
+	}
+	return f.Close()
+}
+
+// fileHash returns the SHA-256 of a file
+func fileHash(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// roundPosition rounds a position to a micrometer
+func roundPosition(v float64) float64 {
+	r := math.Round(v*1000) / 1000
+	if r == 0 {
+		return 0 // Avoid -0
+	}
+	return r
+}
@@ -0,0 +1,44 @@
+package inspect
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/philipparndt/go3mf/internal/models"
+)
+
+func TestCollapseCopies(t *testing.T) {
+	object := func(name string, plate int, file string) plateObject {
+		return plateObject{
+			YamlObject: models.YamlObject{Name: name, Parts: []models.YamlPart{{Name: "part_1", File: file, Filament: 1}}},
+			Plate:      plate,
+		}
+	}
+	names := func(objects []plateObject) []string {
+		var result []string
+		for _, obj := range objects {
+			result = append(result, obj.Name+"×"+string(rune('0'+objectCount(obj.YamlObject))))
+		}
+		return result
+	}
+
+	tests := []struct {
+		name    string
+		objects []plateObject
+		want    []string
+	}{
+		{"copies", []plateObject{object("box_1", 0, "a"), object("box_2", 0, "a"), object("pin", 0, "b")}, []string{"box×2", "pin×1"}},
+		{"different parts", []plateObject{object("box_1", 0, "a"), object("box_2", 0, "b")}, []string{"box_1×1", "box_2×1"}},
+		{"different plates", []plateObject{object("box_1", 1, "a"), object("box_2", 2, "a")}, []string{"box_1×1", "box_2×1"}},
+		{"gap in numbers", []plateObject{object("box_1", 0, "a"), object("box_3", 0, "a")}, []string{"box_1×1", "box_3×1"}},
+		{"single numbered object", []plateObject{object("box_1", 0, "a")}, []string{"box_1×1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := names(collapseCopies(tt.objects)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("collapseCopies() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
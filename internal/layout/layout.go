@@ -0,0 +1,364 @@
+package layout
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/philipparndt/go3mf/internal/arrangement"
+	"github.com/philipparndt/go3mf/internal/geometry"
+	"github.com/philipparndt/go3mf/internal/models"
+)
+
+// filamentColors are the colors of the filament slots 1, 2, 3, ... in the SVG
+var filamentColors = []string{"#4C9BE8", "#E8574C", "#5CB85C", "#F0AD4E", "#9B59B6", "#1ABC9C", "#E67E22", "#95A5A6"}
+
+const (
+	pixelsPerMM = 2.0  // Scale of the SVG image (a 256mm plate is 512px wide)
+	padding     = 10.0 // Space around the plates in mm
+)
+
+// Layout is the arrangement of objects on the build plate(s) as seen from above
+type Layout struct {
+	PlateWidth     float64
+	PlateDepth     float64
+	Plates         int
+	ExclusionZones []models.ExclusionZone // Exclusion zones of the printer, repeated on every plate
+	Objects        []Object
+}
+
+// Object is an object placed on a build plate
+type Object struct {
+	Name      string
+	Plate     int                   // 1-based plate number (0 for single plate builds)
+	Placement arrangement.Placement // Position and rotation of the build item
+	Parts     []Part
+}
+
+// Part is the footprint (convex hull) of a part of an object
+type Part struct {
+	Outline  []geometry.Point
+	Filament int
+}
+
+// FromModel creates the layout of the build items of a 3MF model. Filament slots
+// and plates are taken from the Bambu Studio settings if available.
+func FromModel(model *models.Model, settings *models.ModelSettings, printer models.PrinterProfile) (*Layout, error) {
+	placements, err := arrangement.FromModel(model, settings)
+	if err != nil {
+		return nil, err
+	}
+
+	objects := make(map[string]*models.Object)
+	for i := range model.Resources.Objects {
+		objects[model.Resources.Objects[i].ID] = &model.Resources.Objects[i]
+	}
+	partSettings := make(map[string][]models.Part)
+	if settings != nil {
+		for _, obj := range settings.Objects {
+			partSettings[obj.ID] = obj.Parts
+		}
+	}
+
+	layout := &Layout{PlateWidth: printer.Width, PlateDepth: printer.Depth, Plates: 1, ExclusionZones: printer.ExclusionZones}
+	if settings != nil {
+		layout.Plates = max(layout.Plates, len(settings.Plates))
+	}
+	for i, item := range model.Build.Items {
+		itemTransform, err := geometry.ParseTransform(item.Transform)
+		if err != nil {
+			return nil, err
+		}
+		obj := objects[item.ObjectID]
+		if obj == nil {
+			return nil, fmt.Errorf("build item references unknown object %s", item.ObjectID)
+		}
+
+		// A build item is either a mesh or a set of components referencing meshes
+		type meshRef struct {
+			mesh      *models.Object
+			transform string
+		}
+		meshes := []meshRef{{mesh: obj}}
+		if obj.Components != nil {
+			meshes = nil
+			for _, component := range obj.Components.Component {
+				if mesh := objects[component.ObjectID]; mesh != nil {
+					meshes = append(meshes, meshRef{mesh: mesh, transform: component.Transform})
+				}
+			}
+		}
+
+		placed := Object{Name: placements.Objects[i].Name, Plate: placements.Objects[i].Plate, Placement: placements.Objects[i]}
+		for j, ref := range meshes {
+			componentTransform, err := geometry.ParseTransform(ref.transform)
+			if err != nil {
+				return nil, err
+			}
+			points, err := geometry.FootprintPoints(ref.mesh, 0, 0)
+			if err != nil {
+				continue
+			}
+			for k, p := range points {
+				points[k] = geometry.TransformPointXY(itemTransform, geometry.TransformPointXY(componentTransform, p))
+			}
+
+			placed.Parts = append(placed.Parts, Part{
+				Outline:  geometry.ConvexHull(points),
+				Filament: partFilament(partSettings[item.ObjectID], j),
+			})
+		}
+
+		layout.Plates = max(layout.Plates, placed.Plate)
+		layout.Objects = append(layout.Objects, placed)
+	}
+
+	return layout, nil
+}
+
+// PlateUsage is the area of a plate covered by object footprints
+type PlateUsage struct {
+	Plate     int     // 1-based plate number
+	Objects   int     // Number of objects on the plate
+	Footprint float64 // Total footprint of the objects in mm²
+	Area      float64 // Plate area in mm²
+}
+
+// Utilization returns the share of the plate area covered by objects in percent
+func (u PlateUsage) Utilization() float64 {
+	if u.Area <= 0 {
+		return 0
+	}
+	return u.Footprint / u.Area * 100
+}
+
+// Usage returns the usage of every plate. The footprint of an object is the convex
+// hull of all its parts, so parts stacked on top of each other are counted once.
+func (l *Layout) Usage() []PlateUsage {
+	usage := make([]PlateUsage, l.Plates)
+	for i := range usage {
+		usage[i] = PlateUsage{Plate: i + 1, Area: l.PlateWidth * l.PlateDepth}
+	}
+
+	for _, obj := range l.Objects {
+		plate := max(obj.Plate, 1) - 1
+		if plate >= len(usage) {
+			continue
+		}
+		var points []geometry.Point
+		for _, part := range obj.Parts {
+			points = append(points, part.Outline...)
+		}
+		usage[plate].Objects++
+		usage[plate].Footprint += geometry.PolygonArea(geometry.ConvexHull(points))
+	}
+	return usage
+}
+
+// partFilament returns the filament slot of the index-th part from the Bambu settings
+func partFilament(parts []models.Part, index int) int {
+	if index >= len(parts) {
+		return 1
+	}
+	for _, m := range parts[index].Metadata {
+		if m.Key == "extruder" {
+			if slot, err := strconv.Atoi(m.Value); err == nil && slot > 0 {
+				return slot
+			}
+		}
+	}
+	return 1
+}
+
+// FilamentColor returns the display color of a filament slot (1-based)
+func FilamentColor(slot int) string {
+	return filamentColors[(slot-1)%len(filamentColors)]
+}
+
+// SVG renders the layout as an SVG image: plate outlines, exclusion zones, object
+// footprints in their filament colors and object names. Plates are placed side by
+// side, matching the coordinates of multi-plate builds.
+func (l *Layout) SVG() string {
+	width := float64(l.Plates)*l.PlateWidth + 2*padding
+	height := l.PlateDepth + 2*padding
+
+	// The build plate Y axis points away from the viewer, the SVG Y axis down
+	toSVG := func(p geometry.Point) (float64, float64) {
+		return p.X + padding, l.PlateDepth - p.Y + padding
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%.0f" height="%.0f" viewBox="0 0 %s %s" font-family="sans-serif">`+"\n",
+		width*pixelsPerMM, height*pixelsPerMM, num(width), num(height))
+
+	for plate := 0; plate < l.Plates; plate++ {
+		x := float64(plate)*l.PlateWidth + padding
+		fmt.Fprintf(&b, `  <rect x="%s" y="%s" width="%s" height="%s" fill="#F4F4F4" stroke="#999999" stroke-width="0.5"/>`+"\n",
+			num(x), num(padding), num(l.PlateWidth), num(l.PlateDepth))
+		for _, zone := range l.ExclusionZones {
+			zx, zy := toSVG(geometry.Point{X: float64(plate)*l.PlateWidth + zone.X, Y: zone.Y + zone.Depth})
+			fmt.Fprintf(&b, `  <rect x="%s" y="%s" width="%s" height="%s" fill="#DDDDDD" stroke="#BBBBBB" stroke-width="0.3" stroke-dasharray="1,1"/>`+"\n",
+				num(zx), num(zy), num(zone.Width), num(zone.Depth))
+		}
+		if l.Plates > 1 {
+			fmt.Fprintf(&b, `  <text x="%s" y="%s" font-size="5" fill="#999999">Plate %d</text>`+"\n", num(x+2), num(padding-2), plate+1)
+		}
+	}
+
+	for _, obj := range l.Objects {
+		minX, minY, maxX, maxY := math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
+		fmt.Fprintf(&b, `  <g><title>%s</title>`+"\n", html.EscapeString(obj.Name))
+		for _, part := range obj.Parts {
+			var points []string
+			for _, p := range part.Outline {
+				x, y := toSVG(p)
+				points = append(points, num(x)+","+num(y))
+				minX, maxX = math.Min(minX, x), math.Max(maxX, x)
+				minY, maxY = math.Min(minY, y), math.Max(maxY, y)
+			}
+			fmt.Fprintf(&b, `    <polygon points="%s" fill="%s" fill-opacity="0.8" stroke="#333333" stroke-width="0.3"/>`+"\n",
+				strings.Join(points, " "), FilamentColor(part.Filament))
+		}
+		if !math.IsInf(minX, 1) {
+			// Shrink long names so that they fit into the footprint
+			fontSize := math.Max(2, math.Min(5, (maxX-minX)/(0.6*float64(len([]rune(obj.Name)))+1)))
+			fmt.Fprintf(&b, `    <text x="%s" y="%s" font-size="%s" text-anchor="middle" dominant-baseline="middle" fill="#000000">%s</text>`+"\n",
+				num((minX+maxX)/2), num((minY+maxY)/2), num(fontSize), html.EscapeString(obj.Name))
+		}
+		b.WriteString("  </g>\n")
+	}
+
+	b.WriteString("</svg>\n")
+	return b.String()
+}
+
+// ThumbnailSVG renders the footprint of an object as a small SVG image of the given
+// size in pixels, scaled to fit
+func (o Object) ThumbnailSVG(size float64) string {
+	minX, minY, maxX, maxY := math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
+	for _, part := range o.Parts {
+		for _, p := range part.Outline {
+			minX, maxX = math.Min(minX, p.X), math.Max(maxX, p.X)
+			minY, maxY = math.Min(minY, p.Y), math.Max(maxY, p.Y)
+		}
+	}
+	if math.IsInf(minX, 1) {
+		return ""
+	}
+
+	// Square view box around the footprint with a small margin
+	extent := math.Max(math.Max(maxX-minX, maxY-minY), 1) * 1.1
+	cx, cy := (minX+maxX)/2, (minY+maxY)/2
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%s" height="%s" viewBox="0 0 %s %s">`,
+		num(size), num(size), num(extent), num(extent))
+	for _, part := range o.Parts {
+		var points []string
+		for _, p := range part.Outline {
+			// The build plate Y axis points away from the viewer, the SVG Y axis down
+			points = append(points, num(p.X-cx+extent/2)+","+num(cy-p.Y+extent/2))
+		}
+		fmt.Fprintf(&b, `<polygon points="%s" fill="%s" fill-opacity="0.8" stroke="#333333" stroke-width="%s"/>`,
+			strings.Join(points, " "), FilamentColor(part.Filament), num(extent/100))
+	}
+	b.WriteString("</svg>")
+	return b.String()
+}
+
+// WriteSVG writes the layout as an SVG file
+func (l *Layout) WriteSVG(path string) error {
+	if err := os.WriteFile(path, []byte(l.SVG()), 0644); err != nil {
+		return fmt.Errorf("failed to write layout SVG: %w", err)
+	}
+	return nil
+}
+
+// Results are the final positions of the objects on their plates, for external
+// tools such as label printers or camera-based quality checks
+type Results struct {
+	PlateWidth float64         `json:"plate_width"`
+	PlateDepth float64         `json:"plate_depth"`
+	Plates     int             `json:"plates"`
+	Objects    []PackingResult `json:"objects"`
+}
+
+// PackingResult is the position of an object relative to the front left corner
+// of its plate
+type PackingResult struct {
+	Name      string  `json:"name"`
+	Plate     int     `json:"plate"` // 1-based plate number
+	X         float64 `json:"x"`     // Position of the object origin
+	Y         float64 `json:"y"`
+	Rotation  float64 `json:"rotation"`  // Rotation around Z in degrees
+	Footprint Bounds  `json:"footprint"` // Bounding box of the footprint
+}
+
+// Bounds is an axis-aligned rectangle on the plate
+type Bounds struct {
+	MinX float64 `json:"min_x"`
+	MinY float64 `json:"min_y"`
+	MaxX float64 `json:"max_x"`
+	MaxY float64 `json:"max_y"`
+}
+
+// Results returns the positions of the objects relative to their plates. Plates
+// are laid out side by side, so the plates before an object are subtracted.
+func (l *Layout) Results() Results {
+	results := Results{PlateWidth: l.PlateWidth, PlateDepth: l.PlateDepth, Plates: l.Plates, Objects: []PackingResult{}}
+	for _, obj := range l.Objects {
+		plate := max(obj.Plate, 1)
+		offset := float64(plate-1) * l.PlateWidth
+
+		result := PackingResult{
+			Name:     obj.Name,
+			Plate:    plate,
+			X:        round(obj.Placement.X - offset),
+			Y:        round(obj.Placement.Y),
+			Rotation: obj.Placement.RotationZ,
+		}
+		minX, minY, maxX, maxY := math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
+		for _, part := range obj.Parts {
+			for _, p := range part.Outline {
+				minX, maxX = math.Min(minX, p.X), math.Max(maxX, p.X)
+				minY, maxY = math.Min(minY, p.Y), math.Max(maxY, p.Y)
+			}
+		}
+		if !math.IsInf(minX, 1) {
+			result.Footprint = Bounds{MinX: round(minX - offset), MinY: round(minY), MaxX: round(maxX - offset), MaxY: round(maxY)}
+		}
+		results.Objects = append(results.Objects, result)
+	}
+	return results
+}
+
+// WriteResults writes the packing results as indented JSON to w
+func (l *Layout) WriteResults(w io.Writer) error {
+	data, err := json.MarshalIndent(l.Results(), "", "  ")
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write packing results: %w", err)
+	}
+	return nil
+}
+
+// round rounds a coordinate to two decimals
+func round(v float64) float64 {
+	r := math.Round(v*100) / 100
+	if r == 0 {
+		return 0 // Avoid -0 in the JSON output
+	}
+	return r
+}
+
+// num formats a coordinate with up to two decimals
+func num(v float64) string {
+	return strconv.FormatFloat(math.Round(v*100)/100, 'f', -1, 64)
+}
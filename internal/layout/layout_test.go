@@ -0,0 +1,124 @@
+package layout
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/philipparndt/go3mf/internal/arrangement"
+	"github.com/philipparndt/go3mf/internal/geometry"
+)
+
+func TestSVG(t *testing.T) {
+	l := &Layout{
+		PlateWidth: 100,
+		PlateDepth: 100,
+		Plates:     2,
+		Objects: []Object{
+			{Name: "a & b", Plate: 1, Parts: []Part{{Outline: []geometry.Point{{X: 0, Y: 0}, {X: 20, Y: 0}, {X: 20, Y: 10}}, Filament: 2}}},
+		},
+	}
+
+	svg := l.SVG()
+
+	for _, want := range []string{
+		`viewBox="0 0 220 120"`,
+		"Plate 2",
+		`points="10,110 30,110 30,100"`,
+		filamentColors[1],
+		"<title>a &amp; b</title>",
+	} {
+		if !strings.Contains(svg, want) {
+			t.Errorf("SVG does not contain %q:\n%s", want, svg)
+		}
+	}
+}
+
+func TestUsage(t *testing.T) {
+	square := func(x float64) []geometry.Point {
+		return []geometry.Point{{X: x, Y: 0}, {X: x + 10, Y: 0}, {X: x + 10, Y: 10}, {X: x, Y: 10}}
+	}
+	l := &Layout{
+		PlateWidth: 100,
+		PlateDepth: 100,
+		Plates:     3,
+		Objects: []Object{
+			// Two stacked parts count once
+			{Name: "a", Plate: 1, Parts: []Part{{Outline: square(0)}, {Outline: square(0)}}},
+			{Name: "b", Plate: 1, Parts: []Part{{Outline: square(20)}}},
+			{Name: "c", Plate: 3, Parts: []Part{{Outline: square(220)}}},
+		},
+	}
+
+	usage := l.Usage()
+
+	want := []struct {
+		objects     int
+		utilization float64
+	}{{2, 2}, {0, 0}, {1, 1}}
+	for i, w := range want {
+		if usage[i].Objects != w.objects || usage[i].Utilization() != w.utilization {
+			t.Errorf("plate %d: %d objects, %.1f%%, want %d objects, %.1f%%", i+1, usage[i].Objects, usage[i].Utilization(), w.objects, w.utilization)
+		}
+	}
+}
+
+func TestThumbnailSVG(t *testing.T) {
+	obj := Object{Name: "a", Parts: []Part{{Outline: []geometry.Point{{X: 100, Y: 50}, {X: 120, Y: 50}, {X: 120, Y: 60}}, Filament: 1}}}
+
+	svg := obj.ThumbnailSVG(64)
+
+	for _, want := range []string{
+		`width="64"`,
+		`viewBox="0 0 22 22"`,
+		`points="1,16 21,16 21,6"`,
+		filamentColors[0],
+	} {
+		if !strings.Contains(svg, want) {
+			t.Errorf("thumbnail does not contain %q:\n%s", want, svg)
+		}
+	}
+
+	if svg := (Object{Name: "empty"}).ThumbnailSVG(64); svg != "" {
+		t.Errorf("thumbnail of an object without parts = %q, want empty", svg)
+	}
+}
+
+func TestResults(t *testing.T) {
+	l := &Layout{
+		PlateWidth: 100,
+		PlateDepth: 80,
+		Plates:     2,
+		Objects: []Object{
+			{Name: "a", Plate: 0, Placement: arrangement.Placement{Name: "a", X: 10, Y: 20, RotationZ: 90},
+				Parts: []Part{{Outline: []geometry.Point{{X: 5, Y: 15}, {X: 15, Y: 15}, {X: 15, Y: 30}}}}},
+			{Name: "b", Plate: 2, Placement: arrangement.Placement{Name: "b", Plate: 2, X: 130, Y: 40},
+				Parts: []Part{{Outline: []geometry.Point{{X: 120, Y: 35}, {X: 140.004, Y: 45}}}}},
+			{Name: "empty", Plate: 1, Placement: arrangement.Placement{Name: "empty", X: 1, Y: 2}},
+		},
+	}
+
+	var b strings.Builder
+	if err := l.WriteResults(&b); err != nil {
+		t.Fatalf("WriteResults() error = %v", err)
+	}
+	var got Results
+	if err := json.Unmarshal([]byte(b.String()), &got); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, b.String())
+	}
+
+	want := Results{
+		PlateWidth: 100,
+		PlateDepth: 80,
+		Plates:     2,
+		Objects: []PackingResult{
+			{Name: "a", Plate: 1, X: 10, Y: 20, Rotation: 90, Footprint: Bounds{MinX: 5, MinY: 15, MaxX: 15, MaxY: 30}},
+			{Name: "b", Plate: 2, X: 30, Y: 40, Footprint: Bounds{MinX: 20, MinY: 35, MaxX: 40, MaxY: 45}},
+			{Name: "empty", Plate: 1, X: 1, Y: 2},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Results() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,94 @@
+package limits
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrExceeded is wrapped by the errors of files that exceed a limit
+var ErrExceeded = errors.New("limit exceeded")
+
+// Limits bound the files read, so a corrupt or malicious file fails instead of
+// exhausting the memory of the process. A limit of 0 disables the check.
+type Limits struct {
+	MaxVertices  int64 // Vertices of an STL file, a mesh or all meshes of a 3MF model
+	MaxEntries   int   // Entries of a 3MF archive
+	MaxEntrySize int64 // Uncompressed size of an entry of a 3MF archive in bytes
+}
+
+// Default are the limits of files read unless they are configured
+var Default = Limits{
+	MaxVertices:  50_000_000,
+	MaxEntries:   10_000,
+	MaxEntrySize: 1 << 30,
+}
+
+var (
+	mu      sync.RWMutex
+	current = Default
+)
+
+// Set configures the limits of all files read from now on
+func Set(l Limits) {
+	mu.Lock()
+	defer mu.Unlock()
+	current = l
+}
+
+// Get returns the configured limits
+func Get() Limits {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// OpenZip opens a 3MF archive and checks its entries against the limits
+func OpenZip(filename string) (*zip.ReadCloser, error) {
+	zr, err := zip.OpenReader(filename)
+	if err != nil {
+		return nil, err
+	}
+	if err := CheckArchive(&zr.Reader); err != nil {
+		zr.Close()
+		return nil, err
+	}
+	return zr, nil
+}
+
+// CheckArchive checks the number of entries of an archive and their declared
+// sizes. The ZIP reader fails on entries that are larger than declared, so the
+// declared sizes bound the memory needed to read them.
+func CheckArchive(zr *zip.Reader) error {
+	l := Get()
+	if l.MaxEntries > 0 && len(zr.File) > l.MaxEntries {
+		return fmt.Errorf("%w: the archive has %d entries, at most %d are allowed", ErrExceeded, len(zr.File), l.MaxEntries)
+	}
+	if l.MaxEntrySize > 0 {
+		for _, f := range zr.File {
+			if f.UncompressedSize64 > uint64(l.MaxEntrySize) {
+				return fmt.Errorf("%w: %s has %d bytes, at most %d are allowed", ErrExceeded, f.Name, f.UncompressedSize64, l.MaxEntrySize)
+			}
+		}
+	}
+	return nil
+}
+
+// CheckVertices checks the number of vertices of a mesh
+func CheckVertices(n int64) error {
+	return checkVertices("mesh", n)
+}
+
+// CheckModelVertices checks the number of vertices of all meshes of a 3MF model
+// document, e.g. counted while the model is parsed
+func CheckModelVertices(n int64) error {
+	return checkVertices("model", n)
+}
+
+func checkVertices(what string, n int64) error {
+	if l := Get(); l.MaxVertices > 0 && n > l.MaxVertices {
+		return fmt.Errorf("%w: the %s has %d vertices, at most %d are allowed", ErrExceeded, what, n, l.MaxVertices)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package limits
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// setLimits configures limits for the duration of a test
+func setLimits(t *testing.T, l Limits) {
+	previous := Get()
+	Set(l)
+	t.Cleanup(func() { Set(previous) })
+}
+
+func TestCheckArchive(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range map[string]string{"a.model": "aaaa", "b.model": "bbbbbbbb"} {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		limits Limits
+		want   string // Substring of the error ("" = within the limits)
+	}{
+		{name: "within", limits: Limits{MaxEntries: 2, MaxEntrySize: 8}},
+		{name: "unlimited", limits: Limits{}},
+		{name: "entries", limits: Limits{MaxEntries: 1}, want: "2 entries, at most 1"},
+		{name: "entry size", limits: Limits{MaxEntrySize: 7}, want: "b.model has 8 bytes, at most 7"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setLimits(t, tt.limits)
+			checkError(t, CheckArchive(zr), tt.want)
+		})
+	}
+}
+
+func TestCheckModelVertices(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxVertices int64
+		want        string
+	}{
+		{name: "within", maxVertices: 3},
+		{name: "unlimited", maxVertices: 0},
+		{name: "exceeded", maxVertices: 2, want: "the model has 3 vertices, at most 2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setLimits(t, Limits{MaxVertices: tt.maxVertices})
+			checkError(t, CheckModelVertices(3), tt.want)
+		})
+	}
+}
+
+func checkError(t *testing.T, err error, want string) {
+	t.Helper()
+	if want == "" {
+		if err != nil {
+			t.Errorf("error = %v, want none", err)
+		}
+		return
+	}
+	if !errors.Is(err, ErrExceeded) || !strings.Contains(err.Error(), want) {
+		t.Errorf("error = %v, want a limit error containing %q", err, want)
+	}
+}
@@ -0,0 +1,181 @@
+package manifest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/philipparndt/go3mf/internal/arrangement"
+	"github.com/philipparndt/go3mf/internal/models"
+)
+
+// Version is the current version of the manifest format
+const Version = 1
+
+// Manifest describes how a 3MF file was built: the input files with their hashes,
+// the render parameters of every part, the filament of every part and the final
+// transforms of objects and parts. It is written as JSON for archiving builds.
+type Manifest struct {
+	Version   int      `json:"version"`
+	Generator string   `json:"generator"` // go3mf and its version
+	Output    File     `json:"output"`
+	Config    *File    `json:"config,omitempty"` // YAML config the build was made from
+	Inputs    []File   `json:"inputs"`
+	Objects   []Object `json:"objects"`
+}
+
+// File is a file with its SHA-256
+type File struct {
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256"`
+}
+
+// Object is an object of the build
+type Object struct {
+	Name      string `json:"name"`
+	Plate     int    `json:"plate,omitempty"` // 1-based plate number for multi-plate builds
+	Transform string `json:"transform"`       // Transform of the build item (3MF matrix)
+	Parts     []Part `json:"parts"`
+}
+
+// Part is a part of an object
+type Part struct {
+	Name      string  `json:"name,omitempty"`
+	Filament  int     `json:"filament,omitempty"`  // Filament slot
+	Transform string  `json:"transform,omitempty"` // Transform of the part within the object (3MF matrix)
+	Source    *Source `json:"source,omitempty"`
+}
+
+// Source is the input a part was rendered from with its render parameters
+type Source struct {
+	File          string                 `json:"file,omitempty"`
+	Generator     string                 `json:"generator,omitempty"`
+	Params        map[string]interface{} `json:"params,omitempty"`
+	ConfigFiles   map[string]string      `json:"config_files,omitempty"` // SHA-256 of the SCAD config files by name
+	Fn            int                    `json:"fn,omitempty"`
+	Fa            float64                `json:"fa,omitempty"`
+	Fs            float64                `json:"fs,omitempty"`
+	Rotation      []float64              `json:"rotation,omitempty"` // Rotation around X, Y and Z in degrees, applied to the mesh
+	ExtrudeHeight float64                `json:"extrude_height,omitempty"`
+	WorkDir       string                 `json:"workdir,omitempty"`       // Directory OpenSCAD ran in, if not the config directory
+	LibraryPaths  []string               `json:"library_paths,omitempty"` // Directories added to OPENSCADPATH
+}
+
+// FromModel creates a manifest with the objects of a 3MF model: their names, plates,
+// transforms and parts with filament slots from the Bambu Studio settings if available
+func FromModel(model *models.Model, settings *models.ModelSettings) (*Manifest, error) {
+	placements, err := arrangement.FromModel(model, settings)
+	if err != nil {
+		return nil, err
+	}
+
+	objects := make(map[string]*models.Object)
+	for i := range model.Resources.Objects {
+		objects[model.Resources.Objects[i].ID] = &model.Resources.Objects[i]
+	}
+	partSettings := make(map[string][]models.Part)
+	objectFilaments := make(map[string]int)
+	if settings != nil {
+		for _, obj := range settings.Objects {
+			partSettings[obj.ID] = obj.Parts
+			objectFilaments[obj.ID], _ = strconv.Atoi(models.MetadataValue(obj.Metadata, "extruder"))
+		}
+	}
+
+	m := &Manifest{Version: Version, Inputs: []File{}, Objects: []Object{}}
+	for i, item := range model.Build.Items {
+		obj := objects[item.ObjectID]
+		if obj == nil {
+			return nil, fmt.Errorf("build item references unknown object %s", item.ObjectID)
+		}
+		object := Object{
+			Name:      placements.Objects[i].Name,
+			Plate:     placements.Objects[i].Plate,
+			Transform: item.Transform,
+			Parts:     []Part{},
+		}
+
+		parts := partSettings[item.ObjectID]
+		for j, component := range models.ObjectComponents(obj) {
+			// Parts without an extruder of their own use the one of the object
+			part := Part{Transform: component.Transform, Filament: objectFilaments[item.ObjectID]}
+			if j < len(parts) {
+				part.Name = models.MetadataValue(parts[j].Metadata, "name")
+				if filament, err := strconv.Atoi(models.MetadataValue(parts[j].Metadata, "extruder")); err == nil {
+					part.Filament = filament
+				}
+			}
+			object.Parts = append(object.Parts, part)
+		}
+		m.Objects = append(m.Objects, object)
+	}
+	return m, nil
+}
+
+// AddInput adds an input file, once per path
+func (m *Manifest) AddInput(file File) {
+	for _, input := range m.Inputs {
+		if input.Path == file.Path {
+			return
+		}
+	}
+	m.Inputs = append(m.Inputs, file)
+	sort.Slice(m.Inputs, func(i, j int) bool { return m.Inputs[i].Path < m.Inputs[j].Path })
+}
+
+// HashFile returns a file with its SHA-256
+func HashFile(path string) (File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return File{}, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return File{}, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return File{Path: path, SHA256: hex.EncodeToString(h.Sum(nil))}, nil
+}
+
+// HashContent returns the SHA-256 of content
+func HashContent(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// Save writes the manifest as indented JSON
+func (m *Manifest) Save(path string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	return nil
+}
+
+// RelativePath returns path relative to the directory of the manifest file, so
+// the manifest can be archived together with the sources
+func RelativePath(manifestFile, path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	dir, err := filepath.Abs(filepath.Dir(manifestFile))
+	if err != nil {
+		return path
+	}
+	rel, err := filepath.Rel(dir, abs)
+	if err != nil {
+		return filepath.ToSlash(abs)
+	}
+	return filepath.ToSlash(rel)
+}
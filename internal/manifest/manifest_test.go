@@ -0,0 +1,67 @@
+package manifest
+
+import (
+	"testing"
+
+	"github.com/philipparndt/go3mf/internal/models"
+)
+
+func TestFromModel(t *testing.T) {
+	model := &models.Model{
+		Resources: models.Resources{Objects: []models.Object{
+			{ID: "1", Name: "A/body", Mesh: &models.Mesh{}},
+			{ID: "2", Name: "A/lid", Mesh: &models.Mesh{}},
+			{ID: "3", Name: "A", Components: &models.Components{Component: []models.Component{
+				{ObjectID: "1", Transform: "1 0 0 0 1 0 0 0 1 0 0 0"},
+				{ObjectID: "2", Transform: "1 0 0 0 1 0 0 0 1 0 0 10"},
+			}}},
+			{ID: "4", Name: "B", Mesh: &models.Mesh{}},
+		}},
+		Build: models.Build{Items: []models.Item{
+			{ObjectID: "3", Transform: "1 0 0 0 1 0 0 0 1 20 30 0"},
+			{ObjectID: "4", Transform: "1 0 0 0 1 0 0 0 1 60 30 0"},
+		}},
+	}
+	settings := &models.ModelSettings{Objects: []models.SettingsObject{
+		{ID: "3", Metadata: []models.SettingsMetadata{{Key: "name", Value: "A"}, {Key: "extruder", Value: "1"}}, Parts: []models.Part{
+			{ID: "1", Metadata: []models.SettingsMetadata{{Key: "name", Value: "body"}}},
+			{ID: "2", Metadata: []models.SettingsMetadata{{Key: "name", Value: "lid"}, {Key: "extruder", Value: "3"}}},
+		}},
+	}}
+
+	m, err := FromModel(model, settings)
+	if err != nil {
+		t.Fatalf("FromModel() error = %v", err)
+	}
+	if len(m.Objects) != 2 {
+		t.Fatalf("got %d objects, want 2", len(m.Objects))
+	}
+
+	a := m.Objects[0]
+	if a.Name != "A" || a.Transform != "1 0 0 0 1 0 0 0 1 20 30 0" || len(a.Parts) != 2 {
+		t.Fatalf("object A = %+v", a)
+	}
+	// The body inherits the filament of the object, the lid has its own
+	if a.Parts[0].Name != "body" || a.Parts[0].Filament != 1 {
+		t.Errorf("part body = %+v", a.Parts[0])
+	}
+	if a.Parts[1].Name != "lid" || a.Parts[1].Filament != 3 || a.Parts[1].Transform != "1 0 0 0 1 0 0 0 1 0 0 10" {
+		t.Errorf("part lid = %+v", a.Parts[1])
+	}
+
+	b := m.Objects[1]
+	if b.Name != "B" || len(b.Parts) != 1 || b.Parts[0].Transform != "" {
+		t.Errorf("object B = %+v", b)
+	}
+}
+
+func TestAddInput(t *testing.T) {
+	m := &Manifest{}
+	m.AddInput(File{Path: "b.scad", SHA256: "2"})
+	m.AddInput(File{Path: "a.scad", SHA256: "1"})
+	m.AddInput(File{Path: "b.scad", SHA256: "2"})
+
+	if len(m.Inputs) != 2 || m.Inputs[0].Path != "a.scad" || m.Inputs[1].Path != "b.scad" {
+		t.Errorf("Inputs = %+v, want a.scad and b.scad once", m.Inputs)
+	}
+}
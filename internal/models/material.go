@@ -0,0 +1,51 @@
+package models
+
+import "strings"
+
+// DefaultMaterial is the filament type assumed for slots without a known type
+const DefaultMaterial = "PLA"
+
+// materialDensities are the densities in g/cm³ of common filament types by
+// normalized name (see materialKey)
+var materialDensities = map[string]float64{
+	"pla":  1.24,
+	"petg": 1.27,
+	"abs":  1.04,
+	"asa":  1.07,
+	"tpu":  1.21,
+	"pc":   1.20,
+	"pa":   1.14,
+	"pva":  1.23,
+	"hips": 1.04,
+}
+
+// materialKey normalizes a filament type, so that "petg" and "PETG" select the same material
+func materialKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// LookupDensity returns the density in g/cm³ of a filament type. Custom materials
+// take precedence over the bundled table. A variant such as "PETG-CF" or
+// "PLA Silk" falls back to its base type if it is not listed itself.
+func LookupDensity(material string, custom map[string]float64) (float64, bool) {
+	lookup := func(name string) (float64, bool) {
+		for customName, density := range custom {
+			if materialKey(customName) == materialKey(name) {
+				return density, true
+			}
+		}
+		density, ok := materialDensities[materialKey(name)]
+		return density, ok
+	}
+
+	if density, ok := lookup(material); ok {
+		return density, true
+	}
+	if base, _, found := strings.Cut(strings.TrimSpace(material), " "); found {
+		material = base
+	}
+	if base, _, found := strings.Cut(material, "-"); found {
+		material = base
+	}
+	return lookup(material)
+}
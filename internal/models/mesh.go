@@ -0,0 +1,117 @@
+package models
+
+import (
+	"encoding/xml"
+	"strconv"
+	"strings"
+)
+
+// DefaultCoordinatePrecision is the number of decimals of the vertex coordinates
+// written to 3MF files
+const DefaultCoordinatePrecision = 6
+
+// coordinatePrecision is the number of decimals of written vertex coordinates
+var coordinatePrecision = DefaultCoordinatePrecision
+
+// SetCoordinatePrecision sets the number of decimals of the vertex coordinates
+// written to 3MF files (0 = DefaultCoordinatePrecision)
+func SetCoordinatePrecision(decimals int) {
+	if decimals <= 0 {
+		decimals = DefaultCoordinatePrecision
+	}
+	coordinatePrecision = decimals
+}
+
+// FormatCoordinate formats a vertex coordinate with the configured precision,
+// without trailing zeros (10.500000 is written as 10.5)
+func FormatCoordinate(v float64) string {
+	s := strconv.FormatFloat(v, 'f', coordinatePrecision, 64)
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	if s == "-0" {
+		return "0"
+	}
+	return s
+}
+
+type Mesh struct {
+	Vertices  *Vertices  `xml:"vertices"`
+	Triangles *Triangles `xml:"triangles"`
+}
+
+// Vertices are the corners of a mesh, parsed once when the model is read
+type Vertices struct {
+	Vertex []Vertex `xml:"vertex"`
+}
+
+// Vertex is a corner of a mesh in mm
+type Vertex struct {
+	X float64 `xml:"x,attr"`
+	Y float64 `xml:"y,attr"`
+	Z float64 `xml:"z,attr"`
+}
+
+// Triangles are the faces of a mesh, parsed once when the model is read
+type Triangles struct {
+	Triangle []Triangle `xml:"triangle"`
+}
+
+// Triangle is a face of a mesh given by the indices of its corners. Other
+// attributes, the properties (pid, p1, p2, p3) and e.g. the paint_color of Bambu
+// Studio, are kept as they were read.
+type Triangle struct {
+	V1    int        `xml:"v1,attr"`
+	V2    int        `xml:"v2,attr"`
+	V3    int        `xml:"v3,attr"`
+	Attrs []xml.Attr `xml:",any,attr"`
+}
+
+// meshElements is the XML of the vertices or triangles of a mesh, written with
+// one self-closing element per line, which encoding/xml cannot do on its own
+type meshElements struct {
+	Inner string `xml:",innerxml"`
+}
+
+// MarshalXML writes the vertices with the configured precision
+func (v Vertices) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	var b strings.Builder
+	b.Grow(len(v.Vertex) * 48)
+	for _, vertex := range v.Vertex {
+		b.WriteString("\n\t\t\t\t\t" + FormatVertex(vertex.X, vertex.Y, vertex.Z))
+	}
+	b.WriteString("\n\t\t\t\t")
+	return e.EncodeElement(meshElements{Inner: b.String()}, start)
+}
+
+// MarshalXML writes the triangles with their other attributes. Attributes of a
+// namespace other than the prefix they were read with are left out, as the
+// model would not declare it.
+func (t Triangles) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	var b strings.Builder
+	b.Grow(len(t.Triangle) * 40)
+	for _, triangle := range t.Triangle {
+		b.WriteString("\n\t\t\t\t\t<triangle v1=\"" + strconv.Itoa(triangle.V1) +
+			"\" v2=\"" + strconv.Itoa(triangle.V2) + "\" v3=\"" + strconv.Itoa(triangle.V3) + "\"")
+		for _, attr := range triangle.Attrs {
+			name := attr.Name.Local
+			if attr.Name.Space != "" {
+				if strings.Contains(attr.Name.Space, "/") {
+					continue
+				}
+				name = attr.Name.Space + ":" + name
+			}
+			b.WriteString(" " + name + "=\"")
+			xml.EscapeText(&b, []byte(attr.Value))
+			b.WriteString("\"")
+		}
+		b.WriteString("/>")
+	}
+	b.WriteString("\n\t\t\t\t")
+	return e.EncodeElement(meshElements{Inner: b.String()}, start)
+}
+
+// FormatVertex returns the vertex element of a 3MF mesh at x, y, z
+func FormatVertex(x, y, z float64) string {
+	return `<vertex x="` + FormatCoordinate(x) + `" y="` + FormatCoordinate(y) + `" z="` + FormatCoordinate(z) + `"/>`
+}
@@ -2,6 +2,9 @@ package models
 
 import (
 	"encoding/xml"
+	"fmt"
+	"path"
+	"strconv"
 	"strings"
 )
 
@@ -16,6 +19,21 @@ const (
 	PackingAlgorithmCompact PackingAlgorithm = "compact"
 )
 
+// DefaultPackingDistance is the distance between objects in mm if none is configured
+const DefaultPackingDistance = 10.0
+
+// ParsePackingAlgorithm parses a packing algorithm name and rejects unknown algorithms
+func ParsePackingAlgorithm(s string) (PackingAlgorithm, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "default":
+		return PackingAlgorithmDefault, nil
+	case "compact":
+		return PackingAlgorithmCompact, nil
+	default:
+		return PackingAlgorithmDefault, fmt.Errorf("unknown packing algorithm %q (supported: default, compact)", s)
+	}
+}
+
 // NewPackingAlgorithm creates a PackingAlgorithm from a string, defaulting to PackingAlgorithmDefault
 func NewPackingAlgorithm(s string) PackingAlgorithm {
 	s = strings.ToLower(strings.TrimSpace(s))
@@ -32,6 +50,290 @@ func (pa PackingAlgorithm) String() string {
 	return string(pa)
 }
 
+// PackingOrder represents the order in which objects are placed on (and printed from) the build plate
+type PackingOrder string
+
+const (
+	// PackingOrderDefault lets the packing algorithm choose the order for the densest layout
+	PackingOrderDefault PackingOrder = "default"
+
+	// PackingOrderByHeight places objects row by row from the lowest to the tallest
+	PackingOrderByHeight PackingOrder = "by_height"
+)
+
+// ParsePackingOrder parses a packing order name and rejects unknown orders
+func ParsePackingOrder(s string) (PackingOrder, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "default":
+		return PackingOrderDefault, nil
+	case "by_height", "by-height":
+		return PackingOrderByHeight, nil
+	default:
+		return PackingOrderDefault, fmt.Errorf("unknown packing order %q (supported: default, by_height)", s)
+	}
+}
+
+// PackingOrigin is the corner of the build plate packed layouts start from.
+// Plates have their origin at the front left, so layouts grow from the front to
+// the back unless they start at the back.
+type PackingOrigin string
+
+const (
+	// PackingOriginFront starts the first row of a layout at the front left corner
+	PackingOriginFront PackingOrigin = "front"
+
+	// PackingOriginBack starts the first row of a layout at the back left corner,
+	// so it is at the top of the plate as seen from above in the slicer
+	PackingOriginBack PackingOrigin = "back"
+)
+
+// ParsePackingOrigin parses a packing origin name and rejects unknown origins
+func ParsePackingOrigin(s string) (PackingOrigin, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "front":
+		return PackingOriginFront, nil
+	case "back":
+		return PackingOriginBack, nil
+	default:
+		return PackingOriginFront, fmt.Errorf("unknown packing origin %q (supported: front, back)", s)
+	}
+}
+
+// Footprint represents the shape used for collision checks when packing objects
+type Footprint string
+
+const (
+	// FootprintBBox packs the axis-aligned bounding boxes of the objects
+	FootprintBBox Footprint = "bbox"
+
+	// FootprintHull packs the convex hulls of the objects projected onto the build plate
+	FootprintHull Footprint = "hull"
+)
+
+// ParseFootprint parses a footprint name and rejects unknown footprints
+func ParseFootprint(s string) (Footprint, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "bbox":
+		return FootprintBBox, nil
+	case "hull":
+		return FootprintHull, nil
+	default:
+		return FootprintBBox, fmt.Errorf("unknown footprint %q (supported: bbox, hull)", s)
+	}
+}
+
+// Normalization controls how objects are placed along Z
+type Normalization string
+
+const (
+	// NormalizationGround moves every object down (or up) onto the build plate
+	NormalizationGround Normalization = "ground"
+
+	// NormalizationOff keeps the Z coordinates of the meshes
+	NormalizationOff Normalization = "off"
+
+	// NormalizationPreserve keeps the Z coordinates of the meshes including the Z
+	// offsets of the build items of input 3MF files (e.g. pre-positioned assemblies)
+	NormalizationPreserve Normalization = "preserve"
+)
+
+// ParseNormalization parses a normalization mode and rejects unknown modes.
+// "true" and "false" are accepted so that YAML booleans can be used.
+func ParseNormalization(s string) (Normalization, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "true", "ground":
+		return NormalizationGround, nil
+	case "false", "off":
+		return NormalizationOff, nil
+	case "preserve":
+		return NormalizationPreserve, nil
+	default:
+		return NormalizationGround, fmt.Errorf("unknown normalization %q (supported: true, false, preserve)", s)
+	}
+}
+
+// AuxiliaryMerge selects the inputs whose auxiliary archive entries (everything
+// besides the model and its settings, e.g. thumbnails or custom metadata) are
+// copied to the output
+type AuxiliaryMerge string
+
+const (
+	// AuxiliaryMergeFirst copies the entries of the first input only
+	AuxiliaryMergeFirst AuxiliaryMerge = "first"
+
+	// AuxiliaryMergeAll copies the entries of all inputs; if several inputs
+	// contain the same entry, the first one wins
+	AuxiliaryMergeAll AuxiliaryMerge = "all"
+
+	// AuxiliaryMergeNamespace copies the entries of the first input as they are
+	// and those of the other inputs into a folder named after the input
+	AuxiliaryMergeNamespace AuxiliaryMerge = "namespace"
+)
+
+// ParseAuxiliaryMerge parses an auxiliary merge policy and rejects unknown policies
+func ParseAuxiliaryMerge(s string) (AuxiliaryMerge, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "first":
+		return AuxiliaryMergeFirst, nil
+	case "all":
+		return AuxiliaryMergeAll, nil
+	case "namespace":
+		return AuxiliaryMergeNamespace, nil
+	default:
+		return AuxiliaryMergeFirst, fmt.Errorf("unknown merge policy %q (supported: first, all, namespace)", s)
+	}
+}
+
+// AuxiliaryPolicy controls which auxiliary archive entries of the inputs are
+// copied to the output
+type AuxiliaryPolicy struct {
+	Merge    AuxiliaryMerge // Inputs the entries are copied from ("" = first)
+	Include  []string       // Glob patterns of the entries to copy (empty = all)
+	Exclude  []string       // Glob patterns of the entries not to copy
+	Settings string         // 3MF file whose entries (presets, ...) take precedence over those of the inputs ("" = none)
+}
+
+// Validate checks the glob patterns of the policy
+func (p AuxiliaryPolicy) Validate() error {
+	for _, pattern := range append(append([]string{}, p.Include...), p.Exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// Matches reports whether an archive entry is copied according to the include
+// and exclude patterns
+func (p AuxiliaryPolicy) Matches(name string) bool {
+	for _, pattern := range p.Exclude {
+		if ok, _ := path.Match(pattern, name); ok {
+			return false
+		}
+	}
+	if len(p.Include) == 0 {
+		return true
+	}
+	for _, pattern := range p.Include {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Anchor defines what the Z position of a part is measured from
+type Anchor string
+
+const (
+	// AnchorRelative places a part relative to the other parts of its object;
+	// the object as a whole is moved onto the build plate
+	AnchorRelative Anchor = "relative"
+
+	// AnchorBed places the lowest point of a part exactly position_z above the build
+	// plate, e.g. for parts floating on top of others, regardless of the other parts
+	AnchorBed Anchor = "bed"
+)
+
+// ParseAnchor parses an anchor name and rejects unknown anchors
+func ParseAnchor(s string) (Anchor, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "relative":
+		return AnchorRelative, nil
+	case "bed":
+		return AnchorBed, nil
+	default:
+		return AnchorRelative, fmt.Errorf("unknown anchor %q (supported: relative, bed)", s)
+	}
+}
+
+// ObjectType is the type of a 3MF object, telling slicers what its mesh is for
+type ObjectType string
+
+const (
+	// ObjectTypeModel is a printed body of the model
+	ObjectTypeModel ObjectType = "model"
+
+	// ObjectTypeSolidSupport is a support structure printed like the model
+	ObjectTypeSolidSupport ObjectType = "solidsupport"
+
+	// ObjectTypeSupport is a support structure the slicer may print differently
+	ObjectTypeSupport ObjectType = "support"
+
+	// ObjectTypeSurface is an open surface, e.g. a single layer of a label
+	ObjectTypeSurface ObjectType = "surface"
+
+	// ObjectTypeOther is not printed, e.g. a reference or a modifier mesh
+	ObjectTypeOther ObjectType = "other"
+)
+
+// ParseObjectType parses an object type and rejects types 3MF does not define.
+// An empty type is kept empty, leaving the type of the input unchanged.
+func ParseObjectType(s string) (ObjectType, error) {
+	switch t := ObjectType(strings.ToLower(strings.TrimSpace(s))); t {
+	case "", ObjectTypeModel, ObjectTypeSolidSupport, ObjectTypeSupport, ObjectTypeSurface, ObjectTypeOther:
+		return t, nil
+	default:
+		return "", fmt.Errorf("unknown object type %q (supported: model, solidsupport, support, surface, other)", s)
+	}
+}
+
+// Printable reports whether objects of the type are printed as solid bodies, so
+// their volume is material of the model. Objects without a type are models.
+func (t ObjectType) Printable() bool {
+	return t == "" || t == ObjectTypeModel || t == ObjectTypeSolidSupport
+}
+
+// PartAlign places a part relative to another part of the same object, computed
+// from the bounding boxes of both parts
+type PartAlign struct {
+	OnTopOf  string  `yaml:"on_top_of"`          // Name of the part to stack this part on
+	Centered bool    `yaml:"centered,omitempty"` // Center the part over the other part in X and Y
+	Gap      float64 `yaml:"gap,omitempty"`      // Vertical gap between the parts in mm
+}
+
+// Quality sets the OpenSCAD resolution variables $fn, $fa and $fs for rendering a
+// part. Zero values leave the default of the SCAD file.
+type Quality struct {
+	Fn int     `yaml:"fn,omitempty"` // Fixed number of fragments of circles
+	Fa float64 `yaml:"fa,omitempty"` // Minimum angle of a fragment in degrees
+	Fs float64 `yaml:"fs,omitempty"` // Minimum size of a fragment in mm
+}
+
+// Override returns q with the values set in other replacing its own
+func (q Quality) Override(other *Quality) Quality {
+	if other == nil {
+		return q
+	}
+	if other.Fn != 0 {
+		q.Fn = other.Fn
+	}
+	if other.Fa != 0 {
+		q.Fa = other.Fa
+	}
+	if other.Fs != 0 {
+		q.Fs = other.Fs
+	}
+	return q
+}
+
+// BuildProfile overrides settings of a config when it is selected with --profile,
+// e.g. for fast draft builds and high quality release builds
+type BuildProfile struct {
+	Quality          *Quality `yaml:"quality,omitempty"`           // OpenSCAD resolution, overrides the default quality (part qualities still take precedence)
+	PackingAlgorithm string   `yaml:"packing_algorithm,omitempty"` // Packing algorithm
+	OutputSuffix     string   `yaml:"output_suffix,omitempty"`     // Appended to the output file name before the extension, e.g. "_draft"
+}
+
+// SequentialPrint configures a layout for printing objects one by one ("print by object").
+// Objects are printed from the lowest to the tallest and kept far enough apart that
+// the print head clearance rectangle around the nozzle never touches a finished object.
+type SequentialPrint struct {
+	ClearanceX   float64 `yaml:"clearance_x"`             // Free space in mm the print head needs between objects along X
+	ClearanceY   float64 `yaml:"clearance_y"`             // Free space in mm the print head needs between objects along Y
+	GantryHeight float64 `yaml:"gantry_height,omitempty"` // Height in mm below the gantry; only the last object may be taller (0 = no limit)
+}
+
 // Model represents a 3MF model structure
 type Model struct {
 	XMLName            xml.Name   `xml:"model"`
@@ -89,18 +391,15 @@ type Component struct {
 	Transform string `xml:"transform,attr,omitempty"`
 }
 
-type Mesh struct {
-	Vertices   *Vertices  `xml:"vertices"`
-	Triangles  *Triangles `xml:"triangles"`
-	RawContent string     `xml:"-"`
-}
-
-type Vertices struct {
-	RawContent string `xml:",innerxml"`
-}
-
-type Triangles struct {
-	RawContent string `xml:",innerxml"`
+// ObjectComponents returns the meshes an object is made of as components. An
+// object is either a single mesh, returned as one component referencing the
+// object itself, or a set of components referencing meshes. The result is a
+// copy the caller may change.
+func ObjectComponents(obj *Object) []Component {
+	if obj.Components == nil {
+		return []Component{{ObjectID: obj.ID}}
+	}
+	return append([]Component{}, obj.Components.Component...)
 }
 
 type Build struct {
@@ -117,24 +416,211 @@ type Item struct {
 
 // ScadFile represents a SCAD file with its target name
 type ScadFile struct {
-	Path         string
-	Name         string
-	FilamentSlot int               // 1-4 for AMS slots, 0 for auto-assign
-	ConfigFiles  map[string]string // Map of config filename -> content
-	RotationX    float64           // Rotation around X axis in degrees
-	RotationY    float64           // Rotation around Y axis in degrees
-	RotationZ    float64           // Rotation around Z axis in degrees
-	PositionX    float64           // Relative position offset in X (mm)
-	PositionY    float64           // Relative position offset in Y (mm)
-	PositionZ    float64           // Relative position offset in Z (mm)
+	Path          string
+	Name          string
+	Group         string                 // Object the part belongs to ("" = the name up to the group separator)
+	FilamentSlot  int                    // 1-4 for AMS slots, 0 for auto-assign
+	Matrix        string                 // Bambu Studio matrix of the part of a 3MF input ("" = identity)
+	Type          ObjectType             // 3MF object type of the mesh ("" = type of the input)
+	ConfigFiles   map[string]string      // Map of config filename -> content
+	RotationX     float64                // Rotation around X axis in degrees
+	RotationY     float64                // Rotation around Y axis in degrees
+	RotationZ     float64                // Rotation around Z axis in degrees
+	PositionX     float64                // Relative position offset in X (mm)
+	PositionY     float64                // Relative position offset in Y (mm)
+	PositionZ     float64                // Relative position offset in Z (mm)
+	Anchor        Anchor                 // What PositionZ is measured from ("" = relative)
+	Align         *PartAlign             // Alignment with another part of the object (nil = none)
+	Generator     string                 // Built-in generator that creates the part ("" = Path is a file)
+	Params        map[string]interface{} // Parameters of the generator
+	ExtrudeHeight float64                // Height in mm to extrude a 2D SVG or DXF file to
+	Quality       Quality                // OpenSCAD resolution for rendering the part
+	SourcePath    string                 // Input file a generated wrapper in Path renders ("" = Path)
+	WorkDir       string                 // Directory OpenSCAD runs in and config files are written to ("" = config directory)
+	LibraryPaths  []string               // Directories added to OPENSCADPATH for use <...> and include <...>
+}
+
+// DefaultGroupSeparator separates the object from the part in the names of
+// parts without an explicit group, e.g. "box/lid"
+const DefaultGroupSeparator = "/"
+
+// ObjectName returns the name of the object the part belongs to: its group, or
+// the name up to the first separator. An empty separator disables splitting.
+func (s ScadFile) ObjectName(separator string) string {
+	if s.Group != "" {
+		return s.Group
+	}
+	if separator == "" {
+		return s.Name
+	}
+	name, _, _ := strings.Cut(s.Name, separator)
+	return name
 }
 
 // ObjectGroup represents a group of parts that form a single object
 type ObjectGroup struct {
-	ID                string     // Object ID in the 3MF model
-	Name              string     // Object name
-	Parts             []ScadFile // Parts in this object
-	NormalizePosition bool       // If true, normalize z-position to ground level
+	ID                string        // Object ID in the 3MF model
+	Name              string        // Object name
+	Parts             []ScadFile    // Parts in this object
+	PartIDs           []string      // Object IDs of the part meshes in the 3MF model (set when combining)
+	FaceCounts        []int         // Triangles of the part meshes (set when combining)
+	NormalizePosition bool          // If true, normalize z-position to ground level
+	Margin            float64       // Extra clearance in mm around this object, added to the packing distance
+	Slicer            SlicerOptions // Slicer settings of this object written into the model settings
+}
+
+// SlicerOptions are per-object slicer settings ("" keeps the setting of the slicer profile)
+type SlicerOptions struct {
+	Brim     string // Brim type: auto, none, outer_only, inner_only, outer_and_inner or ears
+	Supports string // Supports: none, normal or tree
+	Seam     string // Seam position: nearest, aligned, rear or random
+	Infill   string // Sparse infill density in percent, e.g. 40%
+}
+
+var (
+	brimTypes = map[string]string{
+		"auto":            "auto_brim",
+		"none":            "no_brim",
+		"outer_only":      "outer_only",
+		"inner_only":      "inner_only",
+		"outer_and_inner": "outer_and_inner",
+		"ears":            "brim_ears",
+	}
+	supportTypes = map[string]string{
+		"normal": "normal(auto)",
+		"tree":   "tree(auto)",
+	}
+	seamPositions = map[string]string{
+		"nearest": "nearest",
+		"aligned": "aligned",
+		"rear":    "back",
+		"random":  "random",
+	}
+)
+
+// SettingsMetadata returns the object metadata of the Bambu Studio model settings
+// for the options and rejects unknown values
+func (o SlicerOptions) SettingsMetadata() ([]SettingsMetadata, error) {
+	var metadata []SettingsMetadata
+	if o.Brim != "" {
+		value, ok := brimTypes[o.Brim]
+		if !ok {
+			return nil, fmt.Errorf("unknown brim %q (supported: auto, none, outer_only, inner_only, outer_and_inner, ears)", o.Brim)
+		}
+		metadata = append(metadata, SettingsMetadata{Key: "brim_type", Value: value})
+	}
+	switch o.Supports {
+	case "":
+	case "none":
+		metadata = append(metadata, SettingsMetadata{Key: "enable_support", Value: "0"})
+	default:
+		value, ok := supportTypes[o.Supports]
+		if !ok {
+			return nil, fmt.Errorf("unknown supports %q (supported: none, normal, tree)", o.Supports)
+		}
+		metadata = append(metadata,
+			SettingsMetadata{Key: "enable_support", Value: "1"},
+			SettingsMetadata{Key: "support_type", Value: value})
+	}
+	if o.Seam != "" {
+		value, ok := seamPositions[o.Seam]
+		if !ok {
+			return nil, fmt.Errorf("unknown seam %q (supported: nearest, aligned, rear, random)", o.Seam)
+		}
+		metadata = append(metadata, SettingsMetadata{Key: "seam_position", Value: value})
+	}
+	if o.Infill != "" {
+		density, err := ParseInfill(o.Infill)
+		if err != nil {
+			return nil, err
+		}
+		metadata = append(metadata, SettingsMetadata{Key: "sparse_infill_density", Value: strconv.FormatFloat(density, 'f', -1, 64) + "%"})
+	}
+	return metadata, nil
+}
+
+// ParseInfill parses an infill density in percent with or without % sign (e.g. 40% or 40)
+func ParseInfill(s string) (float64, error) {
+	density, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(s), "%")), 64)
+	if err != nil || density < 0 || density > 100 {
+		return 0, fmt.Errorf("invalid infill %q (expected a percentage from 0%% to 100%%, e.g. 40%%)", s)
+	}
+	return density, nil
+}
+
+// Renderer selects how OpenSCAD is run
+type Renderer string
+
+const (
+	// RendererLocal runs the OpenSCAD installed on the machine
+	RendererLocal Renderer = "local"
+
+	// RendererDocker runs OpenSCAD in a Docker container (see YamlConfig.RendererImage)
+	RendererDocker Renderer = "docker"
+)
+
+// ParseRenderer parses a renderer name and rejects unknown renderers
+func ParseRenderer(s string) (Renderer, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "local":
+		return RendererLocal, nil
+	case "docker":
+		return RendererDocker, nil
+	default:
+		return RendererLocal, fmt.Errorf("unknown renderer %q (supported: local, docker)", s)
+	}
+}
+
+// OutputFormat is the format of a further output of a build
+type OutputFormat string
+
+const (
+	// OutputFormatBambu is a copy of the build: a 3MF with the Bambu Studio project files
+	OutputFormatBambu OutputFormat = "bambu"
+
+	// OutputFormat3MF is a plain 3MF with the model only, for other slicers and viewers
+	OutputFormat3MF OutputFormat = "3mf"
+
+	// OutputFormatSTL is a directory with an STL file of every object
+	OutputFormatSTL OutputFormat = "stl"
+
+	// OutputFormatObjects is a directory with a standalone 3MF file of every object
+	OutputFormatObjects OutputFormat = "objects"
+)
+
+// IsDirectory reports whether the output is a directory with a file of every object
+func (f OutputFormat) IsDirectory() bool {
+	return f == OutputFormatSTL || f == OutputFormatObjects
+}
+
+// ParseOutputFormat parses an output format and rejects unknown formats
+func ParseOutputFormat(s string) (OutputFormat, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "bambu":
+		return OutputFormatBambu, nil
+	case "3mf":
+		return OutputFormat3MF, nil
+	case "stl":
+		return OutputFormatSTL, nil
+	case "objects":
+		return OutputFormatObjects, nil
+	default:
+		return OutputFormatBambu, fmt.Errorf("unknown output format %q (supported: bambu, 3mf, stl, objects)", s)
+	}
+}
+
+// YamlOutput is a further output of a build, written from the same rendered meshes
+type YamlOutput struct {
+	Path   string `yaml:"path"`             // File, or directory for stl and objects; may contain variables like output
+	Format string `yaml:"format,omitempty"` // bambu, 3mf, stl or objects (default: bambu)
+}
+
+// YamlThumbnails are the PNG previews of the objects of a build, rendered from
+// the front right and above in the filament colors
+type YamlThumbnails struct {
+	Dir   string `yaml:"dir,omitempty"`   // Directory the previews are written to, named after the objects; may contain variables like output
+	Embed bool   `yaml:"embed,omitempty"` // Embed the previews in the output below Auxiliaries/thumbnails/
+	Size  int    `yaml:"size,omitempty"`  // Width and height in pixels (default: 256)
 }
 
 // PlateGroup represents a build plate with its objects
@@ -145,12 +631,36 @@ type PlateGroup struct {
 
 // YamlConfig represents the complete YAML configuration file
 type YamlConfig struct {
-	Output           string       `yaml:"output"`
-	Printer          string       `yaml:"printer,omitempty"`            // Printer alias for plate size: H2D, A1mini, A1, X1C, P1S, etc.
-	PackingDistance  float64      `yaml:"packing_distance,omitempty"`   // Distance between objects in mm (default: 10.0)
-	PackingAlgorithm string       `yaml:"packing_algorithm,omitempty"`  // Packing algorithm: "default" or "compact" (default: "default")
-	Plates           []YamlPlate  `yaml:"plates,omitempty"`             // Optional: plates containing objects (for multi-plate builds)
-	Objects          []YamlObject `yaml:"objects,omitempty"`            // Objects (when not using plates)
+	Output           string                    `yaml:"output"`
+	Outputs          []YamlOutput              `yaml:"outputs,omitempty"`           // Optional: further files written from the same build
+	Thumbnails       *YamlThumbnails           `yaml:"thumbnails,omitempty"`        // Optional: PNG previews of the objects for catalogs and store listings
+	SettingsFrom     string                    `yaml:"settings_from,omitempty"`     // Optional: 3MF file (an input or a template) the printer and process presets are taken from
+	Printer          string                    `yaml:"printer,omitempty"`           // Printer profile: a bundled preset (X1C, P1S, A1mini, MK4, ...) or one of printers
+	Printers         map[string]PrinterProfile `yaml:"printers,omitempty"`          // Optional: custom printer profiles by name
+	Filaments        []YamlFilament            `yaml:"filaments,omitempty"`         // Optional: filaments by slot; their colors are written as 3MF base materials
+	Materials        map[string]float64        `yaml:"materials,omitempty"`         // Optional: filament densities in g/cm³ by type, for the weight estimates (extends the bundled table)
+	PackingDistance  float64                   `yaml:"packing_distance,omitempty"`  // Distance between objects in mm (default: 10.0)
+	PackingAlgorithm string                    `yaml:"packing_algorithm,omitempty"` // Packing algorithm: "default" or "compact" (default: "default")
+	PackingOrder     string                    `yaml:"packing_order,omitempty"`     // Packing order: "default" or "by_height" (default: "default")
+	Sequential       *SequentialPrint          `yaml:"sequential,omitempty"`        // Optional: lay out objects for sequential (one by one) printing
+	PlacementGrid    float64                   `yaml:"placement_grid,omitempty"`    // Snap packed positions to a grid of this size in mm (default: 0 = off)
+	Footprint        string                    `yaml:"footprint,omitempty"`         // Footprint for collision checks: "bbox" or "hull" (default: "bbox")
+	PackingOrigin    string                    `yaml:"packing_origin,omitempty"`    // Plate edge the first row is packed at: "front" or "back" (default: "front")
+	Normalize        string                    `yaml:"normalize,omitempty"`         // Z normalization: true, false or preserve (default: true, normalize_position of an object takes precedence)
+	MinUtilization   float64                   `yaml:"min_utilization,omitempty"`   // Fail if a used plate is covered less than this percentage (default: 0 = no limit)
+	MaxUtilization   float64                   `yaml:"max_utilization,omitempty"`   // Fail if a plate is covered more than this percentage (default: 0 = no limit)
+	Quality          *Quality                  `yaml:"quality,omitempty"`           // Default OpenSCAD resolution of all parts
+	Precision        int                       `yaml:"precision,omitempty"`         // Decimals of the vertex coordinates written to the 3MF file (default: 6)
+	Renderer         string                    `yaml:"renderer,omitempty"`          // How OpenSCAD is run: "local" or "docker" (default: "local")
+	RendererImage    string                    `yaml:"renderer_image,omitempty"`    // Docker image with OpenSCAD for the docker renderer (default: openscad/openscad:2021.01)
+	RenderWorkers    []string                  `yaml:"render_workers,omitempty"`    // Optional: SSH destinations (user@host) that render the SCAD parts in parallel
+	RenderJobs       int                       `yaml:"render_jobs,omitempty"`       // Number of SCAD parts rendered at a time on this machine (default: 0 = one per CPU)
+	Profiles         map[string]BuildProfile   `yaml:"profiles,omitempty"`          // Optional: build profiles by name, selected with --profile
+	Vars             map[string]string         `yaml:"vars,omitempty"`              // Optional: variables for enabled_if conditions, overridden with --var
+	Templates        map[string]YamlObject     `yaml:"templates,omitempty"`         // Optional: reusable objects by name, stamped out by instances
+	Instances        []YamlInstance            `yaml:"instances,omitempty"`         // Optional: objects created from templates (added to objects)
+	Plates           []YamlPlate               `yaml:"plates,omitempty"`            // Optional: plates containing objects (for multi-plate builds)
+	Objects          []YamlObject              `yaml:"objects,omitempty"`           // Objects (when not using plates)
 }
 
 // YamlPlate represents a build plate in the model
@@ -159,29 +669,52 @@ type YamlPlate struct {
 	Objects []YamlObject `yaml:"objects"`        // Objects on this plate
 }
 
-// PrinterPlateSize represents the build plate dimensions for a printer
-type PrinterPlateSize struct {
-	Width  float64
-	Height float64
-}
-
-// GetPrinterPlateSize returns the plate size for known printer aliases
-func GetPrinterPlateSize(printer string) PrinterPlateSize {
-	switch printer {
-	case "H2D":
-		return PrinterPlateSize{Width: 350, Height: 350}
-	case "X1", "X1C", "X1E":
-		return PrinterPlateSize{Width: 256, Height: 256}
-	case "P1S", "P1P":
-		return PrinterPlateSize{Width: 256, Height: 256}
-	case "A1":
-		return PrinterPlateSize{Width: 256, Height: 256}
-	case "A1mini", "A1-mini", "A1_mini":
-		return PrinterPlateSize{Width: 180, Height: 180}
-	default:
-		// Default to X1C size
-		return PrinterPlateSize{Width: 256, Height: 256}
+// Workspace lists the configs of a multi-product repository and the settings they
+// share (see config.WorkspaceFile). Configs use the shared settings they do not set themselves.
+type Workspace struct {
+	Builds           []string                  `yaml:"builds"`                      // Member configs, relative to the workspace file (glob patterns allowed)
+	Printer          string                    `yaml:"printer,omitempty"`           // Shared printer profile
+	Printers         map[string]PrinterProfile `yaml:"printers,omitempty"`          // Shared custom printer profiles
+	PackingDistance  float64                   `yaml:"packing_distance,omitempty"`  // Shared distance between objects in mm
+	PackingAlgorithm string                    `yaml:"packing_algorithm,omitempty"` // Shared packing algorithm
+	PackingOrder     string                    `yaml:"packing_order,omitempty"`     // Shared packing order
+	PlacementGrid    float64                   `yaml:"placement_grid,omitempty"`    // Shared placement grid in mm
+	Footprint        string                    `yaml:"footprint,omitempty"`         // Shared footprint for collision checks
+	PackingOrigin    string                    `yaml:"packing_origin,omitempty"`    // Shared plate edge the first row is packed at
+	Renderer         string                    `yaml:"renderer,omitempty"`          // Shared way to run OpenSCAD
+	RendererImage    string                    `yaml:"renderer_image,omitempty"`    // Shared Docker image of the docker renderer
+	RenderWorkers    []string                  `yaml:"render_workers,omitempty"`    // Shared SSH destinations of the render workers
+	RenderJobs       int                       `yaml:"render_jobs,omitempty"`       // Shared number of local renders at a time
+	Cache            string                    `yaml:"cache,omitempty"`             // Render cache directory, relative to the workspace file (default: .go3mf-cache)
+
+	Dir     string   `yaml:"-"` // Absolute directory of the workspace file
+	Members []string `yaml:"-"` // Absolute paths of the member configs
+}
+
+// YamlSettings holds the settings the apply-settings command writes into an existing 3MF
+type YamlSettings struct {
+	Filaments     []YamlFilament         `yaml:"filaments,omitempty"`      // Filaments by slot (first entry = slot 1)
+	PrintSettings map[string]interface{} `yaml:"print_settings,omitempty"` // Slicer project settings by key (e.g. layer_height)
+	Metadata      map[string]string      `yaml:"metadata,omitempty"`       // 3MF model metadata (e.g. Title, Designer); an empty value removes the entry
+}
+
+// YamlFilament describes the filament of a slot
+type YamlFilament struct {
+	Type    string `yaml:"type,omitempty"`    // Filament type, e.g. PLA or PETG
+	Color   string `yaml:"color,omitempty"`   // Color as #RRGGBB or #RRGGBBAA
+	Profile string `yaml:"profile,omitempty"` // Slicer filament profile name
+}
+
+// ValidateUtilizationLimits checks that the plate utilization limits are percentages
+// and that the minimum does not exceed the maximum (0 disables a limit)
+func ValidateUtilizationLimits(minUtilization, maxUtilization float64) error {
+	if minUtilization < 0 || minUtilization > 100 || maxUtilization < 0 || maxUtilization > 100 {
+		return fmt.Errorf("limits must be between 0 and 100 percent")
+	}
+	if maxUtilization > 0 && minUtilization > maxUtilization {
+		return fmt.Errorf("the minimum must not be greater than the maximum")
 	}
+	return nil
 }
 
 // YamlObject represents a single object in the model
@@ -190,21 +723,57 @@ type YamlObject struct {
 	Count             int                      `yaml:"count,omitempty"`              // Number of copies of this object (default: 1)
 	Config            []map[string]interface{} `yaml:"config,omitempty"`             // Array of config filename -> content maps (applied to all parts)
 	NormalizePosition *bool                    `yaml:"normalize_position,omitempty"` // If true, normalize z-position to ground level (default: true)
+	Margin            float64                  `yaml:"margin,omitempty"`             // Extra clearance in mm around this object (e.g. for brims), added to packing_distance
+	Brim              string                   `yaml:"brim,omitempty"`               // Brim type: auto, none, outer_only, inner_only, outer_and_inner or ears (default: slicer profile)
+	Supports          string                   `yaml:"supports,omitempty"`           // Supports: none, normal or tree (default: slicer profile)
+	Seam              string                   `yaml:"seam,omitempty"`               // Seam position: nearest, aligned, rear or random (default: slicer profile)
+	Infill            string                   `yaml:"infill,omitempty"`             // Sparse infill density, e.g. 40% (default: slicer profile)
+	Enabled           *bool                    `yaml:"enabled,omitempty"`            // Set to false to leave the object out (default: true)
+	EnabledIf         string                   `yaml:"enabled_if,omitempty"`         // Condition on vars, e.g. ${vars.with_lid}
+	Explode           bool                     `yaml:"explode,omitempty"`            // Build every part as an object of its own instead of grouping the parts
 	Parts             []YamlPart               `yaml:"parts"`
 }
 
+// SlicerOptions returns the slicer settings of the object
+func (o YamlObject) SlicerOptions() SlicerOptions {
+	return SlicerOptions{Brim: o.Brim, Supports: o.Supports, Seam: o.Seam, Infill: o.Infill}
+}
+
+// YamlInstance creates an object from a template of the config with per-instance overrides
+type YamlInstance struct {
+	Template  string                   `yaml:"template"`             // Name of the template
+	Name      string                   `yaml:"name"`                 // Object name
+	Count     int                      `yaml:"count,omitempty"`      // Number of copies (default: count of the template)
+	Filament  int                      `yaml:"filament,omitempty"`   // Filament slot of all parts (default: filaments of the template)
+	Config    []map[string]interface{} `yaml:"config,omitempty"`     // Config values merged into the config files of the template
+	Enabled   *bool                    `yaml:"enabled,omitempty"`    // Set to false to leave the instance out (default: true)
+	EnabledIf string                   `yaml:"enabled_if,omitempty"` // Condition on vars, e.g. ${vars.with_lid}
+}
+
 // YamlPart represents a part within an object
 type YamlPart struct {
-	Name      string                   `yaml:"name"`
-	File      string                   `yaml:"file"`
-	Config    []map[string]interface{} `yaml:"config,omitempty"`     // Array of config filename -> content maps (part-specific)
-	Filament  int                      `yaml:"filament,omitempty"`   // 1-4 for AMS slots, 0 for auto-assign
-	RotationX float64                  `yaml:"rotation_x,omitempty"` // Rotation around X axis in degrees
-	RotationY float64                  `yaml:"rotation_y,omitempty"` // Rotation around Y axis in degrees
-	RotationZ float64                  `yaml:"rotation_z,omitempty"` // Rotation around Z axis in degrees
-	PositionX float64                  `yaml:"position_x,omitempty"` // Relative position offset in X (mm)
-	PositionY float64                  `yaml:"position_y,omitempty"` // Relative position offset in Y (mm)
-	PositionZ float64                  `yaml:"position_z,omitempty"` // Relative position offset in Z (mm)
+	Name          string                   `yaml:"name"`
+	File          string                   `yaml:"file,omitempty"`
+	Generator     string                   `yaml:"generator,omitempty"`      // Built-in generator instead of a file (e.g. text)
+	Params        map[string]interface{}   `yaml:"params,omitempty"`         // Parameters of the generator
+	ExtrudeHeight float64                  `yaml:"extrude_height,omitempty"` // Height in mm to extrude a 2D SVG or DXF file to
+	Enabled       *bool                    `yaml:"enabled,omitempty"`        // Set to false to leave the part out (default: true)
+	Optional      bool                     `yaml:"optional,omitempty"`       // Skip the part with a warning if its file is missing
+	EnabledIf     string                   `yaml:"enabled_if,omitempty"`     // Condition on vars, e.g. ${vars.with_lid}
+	Quality       *Quality                 `yaml:"quality,omitempty"`        // OpenSCAD resolution, overrides the quality of the config
+	Workdir       string                   `yaml:"workdir,omitempty"`        // Directory OpenSCAD runs in and config files are written to (default: config directory)
+	LibraryPaths  []string                 `yaml:"library_paths,omitempty"`  // Directories added to OPENSCADPATH for use <...> and include <...>
+	Config        []map[string]interface{} `yaml:"config,omitempty"`         // Array of config filename -> content maps (part-specific)
+	Filament      int                      `yaml:"filament,omitempty"`       // 1-4 for AMS slots, 0 for auto-assign
+	RotationX     float64                  `yaml:"rotation_x,omitempty"`     // Rotation around X axis in degrees
+	RotationY     float64                  `yaml:"rotation_y,omitempty"`     // Rotation around Y axis in degrees
+	RotationZ     float64                  `yaml:"rotation_z,omitempty"`     // Rotation around Z axis in degrees
+	PositionX     float64                  `yaml:"position_x,omitempty"`     // Relative position offset in X (mm)
+	PositionY     float64                  `yaml:"position_y,omitempty"`     // Relative position offset in Y (mm)
+	PositionZ     float64                  `yaml:"position_z,omitempty"`     // Relative position offset in Z (mm)
+	Align         *PartAlign               `yaml:"align,omitempty"`          // Stack (and center) this part on another part of the object
+	Anchor        string                   `yaml:"anchor,omitempty"`         // relative (default) or bed to place the part's bottom at position_z above the build plate
+	Type          string                   `yaml:"type,omitempty"`           // 3MF object type: model (default), solidsupport, support, surface or other
 }
 
 // ModelSettings represents the Bambu Studio model_settings.config structure
@@ -227,6 +796,17 @@ type SettingsMetadata struct {
 	FaceCount int    `xml:"face_count,attr,omitempty"`
 }
 
+// MetadataValue returns the value of the settings metadata entry with the given
+// key ("" = not set)
+func MetadataValue(metadata []SettingsMetadata, key string) string {
+	for _, m := range metadata {
+		if m.Key == key {
+			return m.Value
+		}
+	}
+	return ""
+}
+
 type Part struct {
 	ID       string             `xml:"id,attr"`
 	Subtype  string             `xml:"subtype,attr"`
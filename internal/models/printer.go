@@ -0,0 +1,108 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DefaultPrinter is the printer profile used when no printer is selected
+const DefaultPrinter = "X1C"
+
+// DefaultFilamentSlots is the number of filament slots of a printer profile that does not define it
+const DefaultFilamentSlots = 4
+
+// PrinterProfile describes the build volume of a printer
+type PrinterProfile struct {
+	Width          float64         `yaml:"width"`                     // Plate size along X in mm
+	Depth          float64         `yaml:"depth"`                     // Plate size along Y in mm
+	Height         float64         `yaml:"height,omitempty"`          // Maximum print height in mm (0 = unknown)
+	FilamentSlots  int             `yaml:"filament_slots,omitempty"`  // Number of filament slots (default: 4)
+	ExclusionZones []ExclusionZone `yaml:"exclusion_zones,omitempty"` // Areas of the plate that must stay empty
+}
+
+// ExclusionZone is a rectangular area of the build plate that objects must not occupy
+type ExclusionZone struct {
+	X     float64 `yaml:"x"`
+	Y     float64 `yaml:"y"`
+	Width float64 `yaml:"width"`
+	Depth float64 `yaml:"depth"`
+}
+
+// bambuPurgeZone is the area in front of the purge chute of the Bambu Lab X1 and P1 series
+var bambuPurgeZone = []ExclusionZone{{X: 0, Y: 0, Width: 18, Depth: 28}}
+
+// printerPresets are the bundled printer profiles by normalized name (see printerKey)
+var printerPresets = map[string]PrinterProfile{
+	"h2d":    {Width: 350, Depth: 320, Height: 325, FilamentSlots: 4},
+	"x1":     {Width: 256, Depth: 256, Height: 256, FilamentSlots: 4, ExclusionZones: bambuPurgeZone},
+	"x1c":    {Width: 256, Depth: 256, Height: 256, FilamentSlots: 4, ExclusionZones: bambuPurgeZone},
+	"x1e":    {Width: 256, Depth: 256, Height: 256, FilamentSlots: 4, ExclusionZones: bambuPurgeZone},
+	"p1s":    {Width: 256, Depth: 256, Height: 256, FilamentSlots: 4, ExclusionZones: bambuPurgeZone},
+	"p1p":    {Width: 256, Depth: 256, Height: 256, FilamentSlots: 4, ExclusionZones: bambuPurgeZone},
+	"a1":     {Width: 256, Depth: 256, Height: 256, FilamentSlots: 4},
+	"a1mini": {Width: 180, Depth: 180, Height: 180, FilamentSlots: 4},
+	"mk4":    {Width: 250, Depth: 210, Height: 220, FilamentSlots: 1},
+}
+
+// printerKey normalizes a printer name, so that "A1 mini", "a1-mini" and "A1mini" select the same profile
+func printerKey(name string) string {
+	return strings.NewReplacer("-", "", "_", "", " ", "").Replace(strings.ToLower(name))
+}
+
+// PrinterPresets returns the names of the bundled printer profiles
+func PrinterPresets() []string {
+	names := make([]string, 0, len(printerPresets))
+	for name := range printerPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LookupPrinter returns the profile of a printer. Custom profiles take precedence
+// over the bundled presets; an empty name selects the default printer.
+func LookupPrinter(name string, custom map[string]PrinterProfile) (PrinterProfile, error) {
+	if name == "" {
+		name = DefaultPrinter
+	}
+
+	var profile PrinterProfile
+	found := false
+	for customName, p := range custom {
+		if printerKey(customName) == printerKey(name) {
+			profile, found = p, true
+			break
+		}
+	}
+	if !found {
+		profile, found = printerPresets[printerKey(name)]
+	}
+	if !found {
+		return PrinterProfile{}, fmt.Errorf("unknown printer %q (presets: %s)", name, strings.Join(PrinterPresets(), ", "))
+	}
+
+	if profile.FilamentSlots == 0 {
+		profile.FilamentSlots = DefaultFilamentSlots
+	}
+	return profile, nil
+}
+
+// Validate checks that the profile describes a usable build volume
+func (p PrinterProfile) Validate() error {
+	if p.Width <= 0 || p.Depth <= 0 {
+		return fmt.Errorf("width and depth must be positive")
+	}
+	if p.Height < 0 {
+		return fmt.Errorf("height must not be negative")
+	}
+	if p.FilamentSlots < 0 {
+		return fmt.Errorf("filament_slots must not be negative")
+	}
+	for i, zone := range p.ExclusionZones {
+		if zone.Width <= 0 || zone.Depth <= 0 {
+			return fmt.Errorf("exclusion zone %d: width and depth must be positive", i+1)
+		}
+	}
+	return nil
+}
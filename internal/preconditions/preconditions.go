@@ -1,9 +1,11 @@
 package preconditions
 
 import (
+	"archive/zip"
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 )
 
@@ -33,6 +35,22 @@ func checkOpenSCAD() error {
 	return nil
 }
 
+// CheckDocker verifies Docker is available to run OpenSCAD in a container
+func CheckDocker() error {
+	if _, err := exec.LookPath("docker"); err != nil {
+		return fmt.Errorf("not found in PATH. Please install Docker from https://docs.docker.com/get-docker/ or use the local renderer")
+	}
+	return nil
+}
+
+// CheckSSH verifies ssh is available to delegate renders to remote workers
+func CheckSSH() error {
+	if _, err := exec.LookPath("ssh"); err != nil {
+		return fmt.Errorf("not found in PATH. Please install an OpenSSH client to use render workers")
+	}
+	return nil
+}
+
 // ValidateFiles checks if files exist and are readable
 // Supports SCAD, STL, and 3MF files
 func ValidateFiles(paths []string) error {
@@ -76,6 +94,12 @@ func IsScadFile(path string) bool {
 	return strings.HasSuffix(strings.ToLower(path), ".scad")
 }
 
+// IsOutlineFile checks if a file is a 2D outline (.svg or .dxf) that OpenSCAD can extrude
+func IsOutlineFile(path string) bool {
+	lowerPath := strings.ToLower(path)
+	return strings.HasSuffix(lowerPath, ".svg") || strings.HasSuffix(lowerPath, ".dxf")
+}
+
 // IsSTLFile checks if a file has a .stl extension
 func IsSTLFile(path string) bool {
 	return strings.HasSuffix(strings.ToLower(path), ".stl")
@@ -86,35 +110,38 @@ func Is3MFFile(path string) bool {
 	return strings.HasSuffix(strings.ToLower(path), ".3mf")
 }
 
-// ValidateOutputPath checks if the output path is writable
+// ValidateOutputPath checks that an output file can be created at path: its directory
+// must exist and the path must not be a directory. Whether the directory is writable
+// is only known once a file is created in it.
 func ValidateOutputPath(path string) error {
-	// Check if parent directory exists and is writable
-	dir := path
-	if dir == "" {
-		dir = "."
+	dir := filepath.Dir(path)
+	info, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("output directory %s does not exist", dir)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("output directory %s is not a directory", dir)
+	}
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		return fmt.Errorf("output %s is a directory", path)
 	}
+	return nil
+}
 
-	// Get the directory of the output path
-	for dir != "" && dir != "." && dir != "/" {
-		info, err := os.Stat(dir)
-		if err == nil {
-			if info.IsDir() && (info.Mode()&0200) != 0 {
+// CheckOverwrite refuses to replace an existing file that is not a 3MF model. Such
+// a file is no earlier build output, e.g. after a mistyped "-o config.yaml".
+func CheckOverwrite(path string) error {
+	if _, err := os.Stat(path); err != nil {
+		return nil
+	}
+	zr, err := zip.OpenReader(path)
+	if err == nil {
+		defer zr.Close()
+		for _, file := range zr.File {
+			if file.Name == "3D/3dmodel.model" {
 				return nil
 			}
 		}
-		parent := dir[:len(dir)-1]
-		if idx := len(parent) - 1; idx >= 0 && parent[idx] == '/' {
-			dir = parent
-		} else {
-			break
-		}
-	}
-
-	// If parent doesn't exist or isn't writable, check current directory
-	dir = "."
-	if info, err := os.Stat(dir); err != nil || !info.IsDir() || (info.Mode()&0200) == 0 {
-		return fmt.Errorf("output directory is not writable")
 	}
-
-	return nil
+	return fmt.Errorf("refusing to overwrite %s, which is not a 3MF file (use --force to overwrite it)", path)
 }
@@ -0,0 +1,69 @@
+package preconditions
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidateOutputPath(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name    string
+		path    string
+		wantErr bool
+	}{
+		{"new file", filepath.Join(dir, "out.3mf"), false},
+		{"missing directory", filepath.Join(dir, "missing", "out.3mf"), true},
+		{"directory", dir, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateOutputPath(tt.path); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateOutputPath(%q) error = %v, wantErr %v", tt.path, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckOverwrite(t *testing.T) {
+	dir := t.TempDir()
+
+	model := filepath.Join(dir, "model.3mf")
+	f, err := os.Create(model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	if _, err := zw.Create("3D/3dmodel.model"); err != nil {
+		t.Fatal(err)
+	}
+	zw.Close()
+	f.Close()
+
+	config := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(config, []byte("objects: []\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		wantErr bool
+	}{
+		{"missing file", filepath.Join(dir, "new.3mf"), false},
+		{"3mf file", model, false},
+		{"other file", config, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := CheckOverwrite(tt.path); (err != nil) != tt.wantErr {
+				t.Errorf("CheckOverwrite(%q) error = %v, wantErr %v", tt.path, err, tt.wantErr)
+			}
+		})
+	}
+}
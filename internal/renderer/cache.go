@@ -0,0 +1,342 @@
+package renderer
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/philipparndt/go3mf/internal/models"
+)
+
+// Cache stores rendered SCAD files together with hashes of all files the SCAD
+// file depended on (as reported by OpenSCAD), so unchanged files are not rendered
+// again. A cache directory can be shared by several builds and processes.
+type Cache struct {
+	dir string
+}
+
+// cacheEntry lists the files a cached render depends on with their SHA-256 hashes
+type cacheEntry struct {
+	ScadFile     string            `json:"scad_file"`
+	Dependencies map[string]string `json:"dependencies"`
+}
+
+var (
+	versionsMu       sync.Mutex
+	openSCADVersions = make(map[string]string) // Docker image or worker ("" = installed OpenSCAD) -> version
+)
+
+// fileHash is a hash of a file kept in memory with the size and modification
+// time the file had when it was hashed
+type fileHash struct {
+	size    int64
+	modTime time.Time
+	hash    string
+}
+
+// keptEntry is a cache entry kept in memory with the size and modification
+// time its file had when it was read
+type keptEntry struct {
+	size    int64
+	modTime time.Time
+	entry   cacheEntry
+}
+
+var (
+	hashesMu sync.Mutex
+	hashes   map[string]fileHash  // Path -> hash (nil = hashes are not kept)
+	entries  map[string]keptEntry // Entry file -> its dependencies (nil = not kept)
+)
+
+// KeepHashes keeps the dependencies of cached renders and the hashes of their
+// files in memory, so a long-running process only reads an entry or hashes a
+// file again once its size or modification time changed. Checking a cached
+// render then mostly needs no reads.
+func KeepHashes() {
+	hashesMu.Lock()
+	defer hashesMu.Unlock()
+	if hashes == nil {
+		hashes = make(map[string]fileHash)
+		entries = make(map[string]keptEntry)
+	}
+}
+
+// NewCache creates a render cache in dir
+func NewCache(dir string) *Cache {
+	return &Cache{dir: dir}
+}
+
+// Render renders scadFile like RenderSCADWithQuality, unless a render of the same
+// file and variant exists whose dependencies are unchanged; then the cached 3MF is
+// copied to outputFile. The variant distinguishes renders of the same file with
+// different config files. Returns whether the cached render was used.
+func (c *Cache) Render(workDir, scadFile, outputFile, variant string, quality models.Quality, libraryPaths []string) (bool, error) {
+	absScadFile := scadFile
+	if !filepath.IsAbs(scadFile) {
+		absScadFile = filepath.Join(workDir, scadFile)
+	}
+	args := qualityArgs(quality)
+	variant += "\x00" + strings.Join(args, " ")
+	if len(libraryPaths) > 0 {
+		variant += "\x00" + strings.Join(libraryPaths, string(os.PathListSeparator))
+	}
+	key := c.key(absScadFile, variant)
+	modelFile := filepath.Join(c.dir, key+".3mf")
+	entryFile := filepath.Join(c.dir, key+".json")
+
+	if c.valid(entryFile) {
+		if err := copyFile(modelFile, outputFile); err == nil {
+			return true, nil
+		}
+	}
+
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return false, fmt.Errorf("failed to create render cache: %w", err)
+	}
+	// Concurrent builds may render the same file, so the dependency file name is unique
+	deps, err := os.CreateTemp(c.dir, key+".*.d")
+	if err != nil {
+		return false, fmt.Errorf("failed to create render cache: %w", err)
+	}
+	deps.Close()
+	depsFile := deps.Name()
+	defer os.Remove(depsFile)
+
+	args = append([]string{"-d", depsFile}, args...)
+	if err := render(workDir, scadFile, outputFile, libraryPaths, append(args, absScadFile)...); err != nil {
+		return false, fmt.Errorf("failed to render %s: %w", scadFile, err)
+	}
+
+	// A render that cannot be cached is still a successful render
+	_ = c.store(workDir, absScadFile, outputFile, depsFile, modelFile, entryFile)
+	return false, nil
+}
+
+// key identifies the renders of a SCAD file and variant with the installed OpenSCAD version
+func (c *Cache) key(absScadFile, variant string) string {
+	sum := sha256.Sum256([]byte(version() + "\x00" + absScadFile + "\x00" + variant))
+	return hex.EncodeToString(sum[:16])
+}
+
+// Version returns the version of OpenSCAD as printed by the configured renderer
+// ("" if OpenSCAD cannot be run)
+func Version() string {
+	return version()
+}
+
+// version returns the version of OpenSCAD, queried once per Docker image or
+// first worker. These are part of the version, so their renders are kept apart.
+func version() string {
+	runner, cmd := dockerImage, openSCADCommand("", nil, "--version")
+	if len(workerHosts) > 0 {
+		// All workers are expected to have the same version as the first one
+		runner, cmd = "ssh:"+workerHosts[0], sshCommand(workerHosts[0], "openscad --version")
+	}
+
+	versionsMu.Lock()
+	defer versionsMu.Unlock()
+	v, ok := openSCADVersions[runner]
+	if !ok {
+		// OpenSCAD prints its version to stderr
+		out, _ := cmd.CombinedOutput()
+		v = strings.TrimSpace(string(out))
+		if runner != "" {
+			v = runner + " " + v
+		}
+		openSCADVersions[runner] = v
+	}
+	return v
+}
+
+// valid reports whether the cache entry exists and all its dependencies are unchanged
+func (c *Cache) valid(entryFile string) bool {
+	entry, err := readEntry(entryFile)
+	if err != nil || len(entry.Dependencies) == 0 {
+		return false
+	}
+	for path, hash := range entry.Dependencies {
+		if current, err := hashFile(path); err != nil || current != hash {
+			return false
+		}
+	}
+	return true
+}
+
+// readEntry reads a cache entry, or returns the kept one while its file is unchanged
+func readEntry(entryFile string) (cacheEntry, error) {
+	info, err := os.Stat(entryFile)
+	if err != nil {
+		return cacheEntry{}, err
+	}
+	hashesMu.Lock()
+	kept, ok := entries[entryFile]
+	hashesMu.Unlock()
+	if ok && kept.size == info.Size() && kept.modTime.Equal(info.ModTime()) {
+		return kept.entry, nil
+	}
+
+	data, err := os.ReadFile(entryFile)
+	if err != nil {
+		return cacheEntry{}, err
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return cacheEntry{}, err
+	}
+
+	hashesMu.Lock()
+	if entries != nil {
+		entries[entryFile] = keptEntry{size: info.Size(), modTime: info.ModTime(), entry: entry}
+	}
+	hashesMu.Unlock()
+	return entry, nil
+}
+
+// store saves a render and the hashes of its dependencies. Relative dependencies
+// are resolved against workDir, where OpenSCAD ran.
+func (c *Cache) store(workDir, absScadFile, outputFile, depsFile, modelFile, entryFile string) error {
+	deps, err := parseDependencies(depsFile)
+	if err != nil {
+		return err
+	}
+	entry := cacheEntry{ScadFile: absScadFile, Dependencies: make(map[string]string, len(deps)+1)}
+	for _, dep := range append(deps, absScadFile) {
+		if !filepath.IsAbs(dep) {
+			dep = filepath.Join(workDir, dep)
+		}
+		hash, err := hashFile(dep)
+		if err != nil {
+			return err
+		}
+		entry.Dependencies[dep] = hash
+	}
+
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return err
+	}
+	model, err := os.Open(outputFile)
+	if err != nil {
+		return err
+	}
+	defer model.Close()
+	if err := writeAtomic(modelFile, model); err != nil {
+		return err
+	}
+	return writeAtomic(entryFile, bytes.NewReader(data))
+}
+
+// writeAtomic writes a file under a temporary name and renames it, so that
+// concurrent builds sharing the cache never read partially written files
+func writeAtomic(path string, r io.Reader) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// parseDependencies reads the files listed in a make style dependency file
+// ("target: dep1 dep2 \" with spaces in names escaped as "\ ") written by OpenSCAD -d
+func parseDependencies(depsFile string) ([]string, error) {
+	data, err := os.ReadFile(depsFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dependencies: %w", err)
+	}
+	content := strings.ReplaceAll(string(data), "\\\r\n", " ")
+	content = strings.ReplaceAll(content, "\\\n", " ")
+	_, list, found := strings.Cut(content, ": ")
+	if !found {
+		return nil, fmt.Errorf("invalid dependency file %s", depsFile)
+	}
+
+	var deps []string
+	var current strings.Builder
+	for i := 0; i < len(list); i++ {
+		switch ch := list[i]; {
+		case ch == '\\' && i+1 < len(list) && list[i+1] == ' ':
+			current.WriteByte(' ')
+			i++
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+			if current.Len() > 0 {
+				deps = append(deps, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteByte(ch)
+		}
+	}
+	if current.Len() > 0 {
+		deps = append(deps, current.String())
+	}
+	return deps, nil
+}
+
+// hashFile returns the hex encoded SHA-256 hash of a file
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+	hashesMu.Lock()
+	kept, ok := hashes[path]
+	hashesMu.Unlock()
+	if ok && kept.size == info.Size() && kept.modTime.Equal(info.ModTime()) {
+		return kept.hash, nil
+	}
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	hash := hex.EncodeToString(h.Sum(nil))
+
+	hashesMu.Lock()
+	if hashes != nil {
+		hashes[path] = fileHash{size: info.Size(), modTime: info.ModTime(), hash: hash}
+	}
+	hashesMu.Unlock()
+	return hash, nil
+}
+
+// copyFile copies the file src to dst
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
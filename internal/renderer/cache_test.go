@@ -0,0 +1,93 @@
+package renderer
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseDependencies(t *testing.T) {
+	depsFile := filepath.Join(t.TempDir(), "render.d")
+	content := "/tmp/out.3mf: /work/main.scad \\\n\t/work/lib/my\\ parts.scad \\\n\t/work/cfg.scad\n"
+	if err := os.WriteFile(depsFile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	deps, err := parseDependencies(depsFile)
+	if err != nil {
+		t.Fatalf("parseDependencies() error = %v", err)
+	}
+
+	want := []string{"/work/main.scad", "/work/lib/my parts.scad", "/work/cfg.scad"}
+	if !reflect.DeepEqual(deps, want) {
+		t.Errorf("parseDependencies() = %q, want %q", deps, want)
+	}
+}
+
+func TestKeepHashes(t *testing.T) {
+	KeepHashes()
+	t.Cleanup(func() { hashes, entries = nil, nil })
+
+	path := filepath.Join(t.TempDir(), "part.scad")
+	if err := os.WriteFile(path, []byte("cube(10);"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	first, err := hashFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kept, ok := hashes[path]; !ok || kept.hash != first {
+		t.Fatalf("hash of %s not kept", path)
+	}
+
+	// A changed file is hashed again
+	if err := os.WriteFile(path, []byte("sphere(10);"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	modTime := time.Now().Add(time.Second)
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+	second, err := hashFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second == first {
+		t.Errorf("hashFile() returned the kept hash of the changed file")
+	}
+}
+
+func TestKeepEntries(t *testing.T) {
+	KeepHashes()
+	t.Cleanup(func() { hashes, entries = nil, nil })
+
+	entryFile := filepath.Join(t.TempDir(), "render.json")
+	if err := os.WriteFile(entryFile, []byte(`{"scad_file": "/work/a.scad", "dependencies": {"/work/a.scad": "1"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	entry, err := readEntry(entryFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kept, ok := entries[entryFile]; !ok || !reflect.DeepEqual(kept.entry, entry) {
+		t.Fatalf("entry %s not kept", entryFile)
+	}
+
+	// A rewritten entry is read again
+	if err := os.WriteFile(entryFile, []byte(`{"scad_file": "/work/a.scad", "dependencies": {"/work/a.scad": "2", "/work/b.scad": "3"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	modTime := time.Now().Add(time.Second)
+	if err := os.Chtimes(entryFile, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+	entry, err = readEntry(entryFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entry.Dependencies) != 2 {
+		t.Errorf("readEntry() = %+v, want the rewritten entry", entry)
+	}
+}
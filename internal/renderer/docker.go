@@ -0,0 +1,118 @@
+package renderer
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// DefaultDockerImage is the image OpenSCAD runs in with the docker renderer
+const DefaultDockerImage = "openscad/openscad:2021.01"
+
+// dockerImage is the image OpenSCAD runs in ("" = run the installed OpenSCAD)
+var dockerImage string
+
+// UseDocker runs OpenSCAD in a Docker container of the image instead of the
+// installed OpenSCAD ("" switches back to the installed one)
+func UseDocker(image string) {
+	dockerImage = image
+}
+
+// openSCADCommand returns the command running OpenSCAD with args in workDir ("" =
+// current directory), with libraryPaths in front of OPENSCADPATH
+func openSCADCommand(workDir string, libraryPaths []string, args ...string) *exec.Cmd {
+	if dockerImage != "" {
+		// Volumes are mounted by absolute paths
+		if workDir == "" {
+			workDir = "."
+		}
+		workDir = absPath(workDir)
+		absLibraryPaths := make([]string, len(libraryPaths))
+		for i, path := range libraryPaths {
+			absLibraryPaths[i] = absPath(path)
+		}
+		return exec.Command("docker", dockerArgs(dockerImage, workDir, absLibraryPaths, args)...)
+	}
+	cmd := exec.Command("openscad", args...)
+	cmd.Dir = workDir
+	if len(libraryPaths) > 0 {
+		cmd.Env = append(os.Environ(), "OPENSCADPATH="+openSCADPath(libraryPaths, os.Getenv("OPENSCADPATH")))
+	}
+	return cmd
+}
+
+// absPath returns the absolute path of path (path itself if it cannot be determined)
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+// dockerArgs returns the arguments of docker to run OpenSCAD with args in a
+// container of image. The directories of the work dir, the library paths and
+// all absolute paths in args are mounted at the same paths, so the arguments
+// and the paths in written dependency files stay valid.
+func dockerArgs(image, workDir string, libraryPaths []string, args []string) []string {
+	result := []string{"run", "--rm"}
+	// Files written by the container belong to the user running go3mf
+	if runtime.GOOS == "linux" {
+		result = append(result, "--user", fmt.Sprintf("%d:%d", os.Getuid(), os.Getgid()))
+	}
+
+	paths := append([]string{}, libraryPaths...)
+	if workDir != "" {
+		paths = append(paths, workDir)
+	}
+	for _, arg := range args {
+		// Options with a value, e.g. -D name="/path", cannot be mounted
+		if filepath.IsAbs(arg) {
+			paths = append(paths, filepath.Dir(arg))
+		}
+	}
+	for _, dir := range mountDirs(paths) {
+		result = append(result, "-v", dir+":"+dir)
+	}
+
+	if workDir != "" {
+		result = append(result, "-w", workDir)
+	}
+	if len(libraryPaths) > 0 {
+		// The container is Linux, so the paths are separated by colons
+		result = append(result, "-e", "OPENSCADPATH="+strings.Join(libraryPaths, ":"))
+	}
+	result = append(result, image, "openscad")
+	return append(result, args...)
+}
+
+// mountDirs returns the sorted directories to mount for paths, without
+// duplicates and directories within another mounted directory
+func mountDirs(paths []string) []string {
+	dirs := make([]string, 0, len(paths))
+	for _, path := range paths {
+		dirs = append(dirs, filepath.Clean(path))
+	}
+	sort.Strings(dirs)
+
+	var result []string
+	for _, dir := range dirs {
+		if !withinAny(dir, result) {
+			result = append(result, dir)
+		}
+	}
+	return result
+}
+
+// withinAny reports whether dir is one of dirs or within one of them
+func withinAny(dir string, dirs []string) bool {
+	for _, parent := range dirs {
+		if dir == parent || strings.HasPrefix(dir, strings.TrimSuffix(parent, string(filepath.Separator))+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
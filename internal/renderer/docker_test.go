@@ -0,0 +1,45 @@
+package renderer
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestMountDirs(t *testing.T) {
+	tests := []struct {
+		name  string
+		paths []string
+		want  []string
+	}{
+		{"duplicates", []string{"/a", "/a/"}, []string{"/a"}},
+		{"nested", []string{"/a/b/c", "/a", "/a/b"}, []string{"/a"}},
+		{"siblings", []string{"/a/b-x", "/a/b", "/a/b/c"}, []string{"/a/b", "/a/b-x"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mountDirs(tt.paths); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("mountDirs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDockerArgs(t *testing.T) {
+	var user []string
+	if runtime.GOOS == "linux" {
+		user = []string{"--user", fmt.Sprintf("%d:%d", os.Getuid(), os.Getgid())}
+	}
+
+	got := dockerArgs("image", "/work", []string{"/libs"}, []string{"-o", "/tmp/out/part.3mf", "-d", "/tmp/out/part.deps", "part.scad"})
+	want := append(append([]string{"run", "--rm"}, user...),
+		"-v", "/libs:/libs", "-v", "/tmp/out:/tmp/out", "-v", "/work:/work",
+		"-w", "/work", "-e", "OPENSCADPATH=/libs",
+		"image", "openscad", "-o", "/tmp/out/part.3mf", "-d", "/tmp/out/part.deps", "part.scad")
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("dockerArgs() = %v, want %v", got, want)
+	}
+}
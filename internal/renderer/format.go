@@ -0,0 +1,160 @@
+package renderer
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/philipparndt/go3mf/internal/stl"
+	"github.com/philipparndt/go3mf/internal/ui"
+)
+
+// exportFormat is the format OpenSCAD renders parts to before they are combined
+type exportFormat int
+
+const (
+	// export3MF renders directly to 3MF
+	export3MF exportFormat = iota
+	// exportBinarySTL renders to binary STL for OpenSCAD builds without 3MF export
+	exportBinarySTL
+	// exportASCIISTL renders to ASCII STL for versions without --export-format
+	exportASCIISTL
+)
+
+var (
+	formatsMu       sync.Mutex
+	detectedFormats = make(map[string]exportFormat) // Docker image ("" = installed OpenSCAD) or "ssh:" worker -> format
+)
+
+// probeSCAD is rendered to detect the export formats of the installed OpenSCAD
+const probeSCAD = "cube(1);\n"
+
+// outputFormat returns the best export format of the installed OpenSCAD, or of
+// the OpenSCAD of the worker host if it is set. Builds without lib3mf (e.g. older
+// distribution packages) do not write 3MF files, some of them without failing,
+// so each format is probed once by rendering a cube.
+func outputFormat(host string) exportFormat {
+	key := dockerImage
+	if host != "" {
+		key = "ssh:" + host
+	}
+	formatsMu.Lock()
+	defer formatsMu.Unlock()
+	if format, ok := detectedFormats[key]; ok {
+		return format
+	}
+	format := detectFormat(host)
+	detectedFormats[key] = format
+	return format
+}
+
+// detectFormat probes the export formats of OpenSCAD
+func detectFormat(host string) exportFormat {
+	dir, err := os.MkdirTemp("", "go3mf_probe")
+	if err != nil {
+		return export3MF
+	}
+	defer os.RemoveAll(dir)
+	scadFile := filepath.Join(dir, "probe.scad")
+	if err := os.WriteFile(scadFile, []byte(probeSCAD), 0644); err != nil {
+		return export3MF
+	}
+
+	format := export3MF
+	switch {
+	case probe(host, filepath.Join(dir, "probe.3mf"), scadFile):
+	case probe(host, filepath.Join(dir, "probe.stl"), scadFile, "--export-format", "binstl"):
+		format = exportBinarySTL
+	case probe(host, filepath.Join(dir, "probe_ascii.stl"), scadFile):
+		format = exportASCIISTL
+	default:
+		// Nothing works: keep 3MF, so the render errors are those of OpenSCAD
+	}
+	switch {
+	case format == export3MF:
+	case host != "":
+		ui.PrintWarning(fmt.Sprintf("OpenSCAD of worker %s cannot export 3MF files, rendering its parts to STL instead", host))
+	default:
+		ui.PrintWarning("OpenSCAD cannot export 3MF files, rendering parts to STL instead")
+	}
+	return format
+}
+
+// probe renders scadFile to outputFile, on the worker host if it is set, and
+// reports whether a non-empty file was written
+func probe(host, outputFile, scadFile string, args ...string) bool {
+	renderArgs := append(append([]string{"-o", outputFile}, args...), scadFile)
+	if host != "" {
+		if err := renderRemote(host, filepath.Dir(scadFile), scadFile, nil, renderArgs); err != nil {
+			return false
+		}
+	} else if err := openSCADCommand("", nil, renderArgs...).Run(); err != nil {
+		return false
+	}
+	data, err := os.ReadFile(outputFile)
+	if err != nil || len(data) == 0 {
+		return false
+	}
+	// OpenSCAD versions that ignore --export-format write ASCII STL
+	if strings.HasSuffix(outputFile, ".stl") && len(args) > 0 && bytes.HasPrefix(data, []byte("solid")) {
+		return false
+	}
+	return true
+}
+
+// render runs OpenSCAD in workDir to render the SCAD file (as given in args) to
+// the 3MF file outputFile, with libraryPaths in front of OPENSCADPATH. If the
+// OpenSCAD cannot export 3MF, it renders to STL and converts the result. With
+// remote workers, the next idle worker renders, probed like a local OpenSCAD.
+func render(workDir, scadFile, outputFile string, libraryPaths []string, args ...string) error {
+	host := ""
+	if workers != nil {
+		host = <-workers
+		defer func() { workers <- host }()
+	}
+
+	format := outputFormat(host)
+	target := outputFile
+	switch format {
+	case exportBinarySTL:
+		target = strings.TrimSuffix(outputFile, filepath.Ext(outputFile)) + ".stl"
+		args = append([]string{"--export-format", "binstl"}, args...)
+	case exportASCIISTL:
+		target = strings.TrimSuffix(outputFile, filepath.Ext(outputFile)) + ".stl"
+	}
+	if target != outputFile {
+		defer os.Remove(target)
+	}
+
+	args = append([]string{"-o", target}, args...)
+	if host != "" {
+		if err := renderRemote(host, workDir, scadFile, libraryPaths, args); err != nil {
+			return err
+		}
+	} else if err := runOpenSCAD(openSCADCommand(workDir, libraryPaths, args...), scadFile); err != nil {
+		return err
+	}
+
+	// Some OpenSCAD builds exit successfully without writing the file
+	if info, err := os.Stat(target); err != nil || info.Size() == 0 {
+		return fmt.Errorf("OpenSCAD did not write %s", filepath.Base(target))
+	}
+	if target != outputFile {
+		if err := stl.NewConverter().ConvertTo3MF(target, outputFile); err != nil {
+			return fmt.Errorf("error converting the STL render: %w", err)
+		}
+	}
+	return nil
+}
+
+// openSCADPath returns the OPENSCADPATH with the library paths in front of the current one
+func openSCADPath(libraryPaths []string, current string) string {
+	paths := append([]string{}, libraryPaths...)
+	if current != "" {
+		paths = append(paths, current)
+	}
+	return strings.Join(paths, string(os.PathListSeparator))
+}
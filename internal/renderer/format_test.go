@@ -0,0 +1,279 @@
+package renderer
+
+import (
+	"archive/zip"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/philipparndt/go3mf/internal/stl"
+	"github.com/philipparndt/go3mf/internal/ui"
+)
+
+const tetraSTL = `solid tetra
+facet normal 0 0 0
+outer loop
+vertex 0 0 0
+vertex 10 0 0
+vertex 0 10 0
+endloop
+endfacet
+facet normal 0 0 0
+outer loop
+vertex 0 0 0
+vertex 0 10 0
+vertex 0 0 10
+endloop
+endfacet
+facet normal 0 0 0
+outer loop
+vertex 0 0 0
+vertex 0 0 10
+vertex 10 0 0
+endloop
+endfacet
+facet normal 0 0 0
+outer loop
+vertex 10 0 0
+vertex 0 0 10
+vertex 0 10 0
+endloop
+endfacet
+endsolid tetra
+`
+
+// Behaviours of the fake openscad. $out is the output file, $ext its extension
+// (3mf or stl), $binstl is set with --export-format binstl and $fixtures holds
+// ascii.stl and binary.stl.
+const (
+	// writes3MF is an OpenSCAD build with 3MF export
+	writes3MF = `[ "$ext" = 3mf ] || exit 1; echo model > "$out"`
+	// writesBinarySTL is a build without lib3mf that fails on 3MF
+	writesBinarySTL = `[ "$ext" = stl ] || exit 1; if [ -n "$binstl" ]; then cp "$fixtures/binary.stl" "$out"; else cp "$fixtures/ascii.stl" "$out"; fi`
+	// writesASCIISTL is an old version that ignores --export-format and exits
+	// successfully without writing 3MF files
+	writesASCIISTL = `[ "$ext" = 3mf ] && exit 0; cp "$fixtures/ascii.stl" "$out"`
+	// failsAlways is a broken installation
+	failsAlways = `exit 1`
+)
+
+// fakeOpenSCAD puts an openscad script running body for every call in front of
+// PATH and forgets the detected export formats
+func fakeOpenSCAD(t *testing.T, body string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake openscad is a shell script")
+	}
+	fixtures := t.TempDir()
+	if err := os.WriteFile(filepath.Join(fixtures, "ascii.stl"), []byte(tetraSTL), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	mesh, err := stl.NewParser().Parse(filepath.Join(fixtures, "ascii.stl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := stl.NewWriter().WriteBinary(mesh, filepath.Join(fixtures, "binary.stl")); err != nil {
+		t.Fatal(err)
+	}
+
+	script := `#!/bin/sh
+fixtures='` + fixtures + `'
+out= binstl=
+while [ $# -gt 0 ]; do
+	case "$1" in
+	-o) out="$2"; shift ;;
+	--export-format) [ "$2" = binstl ] && binstl=1; shift ;;
+	esac
+	shift
+done
+case "$out" in *.3mf) ext=3mf ;; *) ext=stl ;; esac
+` + body + "\n"
+	bin := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, "openscad"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	savedImage, savedFormats := dockerImage, detectedFormats
+	dockerImage, detectedFormats = "", make(map[string]exportFormat)
+	t.Cleanup(func() { dockerImage, detectedFormats = savedImage, savedFormats })
+}
+
+func TestOutputFormat(t *testing.T) {
+	tests := []struct {
+		name        string
+		openscad    string
+		want        exportFormat
+		wantWarning bool
+	}{
+		{name: "3MF export", openscad: writes3MF, want: export3MF},
+		{name: "binary STL", openscad: writesBinarySTL, want: exportBinarySTL, wantWarning: true},
+		{name: "ASCII STL", openscad: writesASCIISTL, want: exportASCIISTL, wantWarning: true},
+		{name: "nothing works", openscad: failsAlways, want: export3MF},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeOpenSCAD(t, tt.openscad)
+			warnings := len(ui.Warnings())
+
+			if got := outputFormat(""); got != tt.want {
+				t.Errorf("outputFormat() = %v, want %v", got, tt.want)
+			}
+			if got := len(ui.Warnings()) > warnings; got != tt.wantWarning {
+				t.Errorf("warned = %v, want %v (warnings: %v)", got, tt.wantWarning, ui.Warnings()[warnings:])
+			}
+
+			// The format is probed once, a broken OpenSCAD is not probed again
+			probed := detectedFormats
+			fakeOpenSCAD(t, failsAlways)
+			detectedFormats = probed
+			if got := outputFormat(""); got != tt.want {
+				t.Errorf("outputFormat() = %v on the second call, want the probed %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name     string
+		openscad string
+		wantErr  string
+	}{
+		{name: "3MF export", openscad: writes3MF},
+		{name: "binary STL converted", openscad: writesBinarySTL},
+		{name: "ASCII STL converted", openscad: writesASCIISTL},
+		{name: "render fails", openscad: failsAlways, wantErr: "exit status 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeOpenSCAD(t, tt.openscad)
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "part.scad"), []byte(probeSCAD), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			output := filepath.Join(dir, "part.3mf")
+
+			err := render(dir, "part.scad", output, nil)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("render() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("render() error = %v", err)
+			}
+			data, err := os.ReadFile(output)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.openscad == writes3MF {
+				if string(data) != "model\n" {
+					t.Errorf("output = %q, want the file written by OpenSCAD", data)
+				}
+				return
+			}
+			// The STL render was converted to a 3MF package and removed
+			assertModelEntry(t, output)
+			if _, err := os.Stat(filepath.Join(dir, "part.stl")); !os.IsNotExist(err) {
+				t.Errorf("the STL render was not removed: %v", err)
+			}
+		})
+	}
+}
+
+func TestRenderNotWritten(t *testing.T) {
+	fakeOpenSCAD(t, `exit 0`)
+	dir := t.TempDir()
+	detectedFormats[""] = export3MF
+
+	err := render(dir, "part.scad", filepath.Join(dir, "part.3mf"), nil)
+	if err == nil || !strings.Contains(err.Error(), "OpenSCAD did not write part.3mf") {
+		t.Errorf("render() error = %v, want that the output was not written", err)
+	}
+}
+
+// assertModelEntry fails unless path is a 3MF package with a model
+func assertModelEntry(t *testing.T, path string) {
+	t.Helper()
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatalf("output is not a 3MF package: %v", err)
+	}
+	defer zr.Close()
+	for _, f := range zr.File {
+		if f.Name != "3D/3dmodel.model" {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rc.Close()
+		data, err := io.ReadAll(rc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(data), "<triangle ") {
+			t.Errorf("the model has no triangles:\n%s", data)
+		}
+		return
+	}
+	t.Errorf("%s has no 3D/3dmodel.model", path)
+}
+
+// fakeWorker makes host a remote worker whose ssh runs the script locally, with
+// the fake openscad of fakeOpenSCAD
+func fakeWorker(t *testing.T, host string) {
+	t.Helper()
+	bin := t.TempDir()
+	// ssh -o BatchMode=yes HOST SCRIPT
+	if err := os.WriteFile(filepath.Join(bin, "ssh"), []byte("#!/bin/sh\nexec sh -c \"$4\"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	UseWorkers([]string{host})
+	t.Cleanup(func() { UseWorkers(nil) })
+}
+
+func TestRenderOnWorker(t *testing.T) {
+	tests := []struct {
+		name     string
+		openscad string
+		want     exportFormat
+	}{
+		{name: "3MF export", openscad: writes3MF, want: export3MF},
+		{name: "binary STL converted", openscad: writesBinarySTL, want: exportBinarySTL},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeOpenSCAD(t, tt.openscad)
+			fakeWorker(t, "farm1")
+			dir := t.TempDir()
+			scadFile := filepath.Join(dir, "part.scad")
+			if err := os.WriteFile(scadFile, []byte(probeSCAD), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			output := filepath.Join(dir, "part.3mf")
+
+			if err := render(dir, scadFile, output, nil, scadFile); err != nil {
+				t.Fatalf("render() error = %v", err)
+			}
+			// The worker is probed separately from the installed OpenSCAD
+			if got, ok := detectedFormats["ssh:farm1"]; !ok || got != tt.want {
+				t.Errorf("format of the worker = %v (probed: %v), want %v", got, ok, tt.want)
+			}
+			if _, ok := detectedFormats[""]; ok {
+				t.Error("the installed OpenSCAD was probed for a render on a worker")
+			}
+			if tt.openscad == writes3MF {
+				return
+			}
+			assertModelEntry(t, output)
+		})
+	}
+}
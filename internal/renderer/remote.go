@@ -0,0 +1,252 @@
+package renderer
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/philipparndt/go3mf/internal/ui"
+)
+
+var (
+	// workerHosts are the SSH destinations renders are delegated to (nil = render on this machine)
+	workerHosts []string
+	// workers holds the idle workers; a render takes one and returns it when done
+	workers chan string
+)
+
+// UseWorkers delegates renders to remote workers over SSH (nil renders on this
+// machine again). Each worker renders one part at a time.
+func UseWorkers(hosts []string) {
+	workerHosts = hosts
+	workers = nil
+	if len(hosts) == 0 {
+		return
+	}
+	workers = make(chan string, len(hosts))
+	for _, host := range hosts {
+		workers <- host
+	}
+}
+
+// Workers returns the number of remote workers (0 = renders run on this machine)
+func Workers() int {
+	return len(workerHosts)
+}
+
+// sshCommand returns the command running script on a worker
+func sshCommand(host, script string) *exec.Cmd {
+	// BatchMode fails instead of asking for passwords the build cannot answer
+	return exec.Command("ssh", "-o", "BatchMode=yes", host, script)
+}
+
+// remoteJob is a render on a worker. The files OpenSCAD reads are uploaded to a
+// temporary directory of the worker below which every file keeps its absolute
+// local path, so relative includes between them keep working.
+type remoteJob struct {
+	workDir      string
+	libraryPaths []string
+	args         []string
+	uploads      []string // Local files and directories uploaded to the worker
+	outputs      []string // Local files OpenSCAD writes (-o and -d), downloaded after the render
+}
+
+// newRemoteJob prepares the render of args (OpenSCAD arguments with absolute
+// paths) in workDir. The work dir, the library paths and all absolute input
+// files of args are uploaded.
+func newRemoteJob(workDir string, libraryPaths []string, args []string) *remoteJob {
+	job := &remoteJob{workDir: absPath(workDir), args: args}
+	for _, path := range libraryPaths {
+		job.libraryPaths = append(job.libraryPaths, absPath(path))
+	}
+	job.uploads = append([]string{job.workDir}, job.libraryPaths...)
+	for i, arg := range args {
+		switch {
+		case i > 0 && (args[i-1] == "-o" || args[i-1] == "-d"):
+			job.outputs = append(job.outputs, arg)
+		case filepath.IsAbs(arg):
+			job.uploads = append(job.uploads, arg)
+		}
+	}
+	return job
+}
+
+// script returns the shell script that unpacks the upload (stdin), renders, and
+// writes the outputs as tar archive to stdout
+func (j *remoteJob) script() string {
+	var b strings.Builder
+	b.WriteString("set -e\n")
+	b.WriteString("d=$(mktemp -d)\n")
+	b.WriteString("trap 'rm -rf \"$d\"' EXIT\n")
+	b.WriteString("tar -xf - -C \"$d\"\n")
+	for _, output := range j.outputs {
+		fmt.Fprintf(&b, "mkdir -p %s\n", remotePath(filepath.Dir(output)))
+	}
+	fmt.Fprintf(&b, "cd %s\n", remotePath(j.workDir))
+
+	if len(j.libraryPaths) > 0 {
+		paths := make([]string, len(j.libraryPaths))
+		for i, path := range j.libraryPaths {
+			paths[i] = remotePath(path)
+		}
+		fmt.Fprintf(&b, "OPENSCADPATH=%s ", strings.Join(paths, ":"))
+	}
+	b.WriteString("openscad")
+	for _, arg := range j.args {
+		if filepath.IsAbs(arg) {
+			b.WriteString(" " + remotePath(arg))
+		} else {
+			b.WriteString(" " + shellQuote(arg))
+		}
+	}
+	// The standard output carries the results
+	b.WriteString(" >&2\n")
+
+	var outputs []string
+	for i, arg := range j.args {
+		if i > 0 && j.args[i-1] == "-d" {
+			// Dependency files list the files with their paths on the worker
+			fmt.Fprintf(&b, "sed \"s|$d||g\" %s > \"$d/deps.tmp\" && mv \"$d/deps.tmp\" %s\n", remotePath(arg), remotePath(arg))
+		}
+	}
+	for _, output := range j.outputs {
+		outputs = append(outputs, shellQuote(archiveName(output)))
+	}
+	fmt.Fprintf(&b, "tar -cf - -C \"$d\" %s\n", strings.Join(outputs, " "))
+	return b.String()
+}
+
+// upload writes the files to upload as tar archive to w
+func (j *remoteJob) upload(w io.Writer) error {
+	tw := tar.NewWriter(w)
+	written := make(map[string]bool)
+	add := func(path string, info fs.FileInfo) error {
+		name := archiveName(path)
+		if written[name] || !info.Mode().IsRegular() {
+			return nil
+		}
+		written[name] = true
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: info.Size(), ModTime: info.ModTime()}); err != nil {
+			return err
+		}
+		_, err = io.Copy(tw, f)
+		return err
+	}
+
+	for _, upload := range j.uploads {
+		info, err := os.Stat(upload)
+		if err != nil {
+			// Missing library paths fail the render on the worker like they would locally
+			continue
+		}
+		if !info.IsDir() {
+			if err := add(upload, info); err != nil {
+				return err
+			}
+			continue
+		}
+		err = filepath.WalkDir(upload, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() && d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			if d.IsDir() {
+				return nil
+			}
+			info, err := os.Stat(path)
+			if err != nil {
+				return nil // Broken links are skipped
+			}
+			return add(path, info)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
+
+// download extracts the outputs from the tar archive the worker wrote
+func (j *remoteJob) download(r io.Reader) error {
+	outputs := make(map[string]string, len(j.outputs))
+	for _, output := range j.outputs {
+		outputs[archiveName(output)] = output
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("cannot read the render of the worker: %w", err)
+		}
+		local, ok := outputs[header.Name]
+		if !ok {
+			continue // Only the expected outputs are written
+		}
+		if err := writeAtomic(local, tr); err != nil {
+			return err
+		}
+	}
+}
+
+// renderRemote runs OpenSCAD with args on the worker host
+func renderRemote(host, workDir, scadFile string, libraryPaths []string, args []string) error {
+	job := newRemoteJob(workDir, libraryPaths, args)
+	cmd := sshCommand(host, job.script())
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	pr, pw := io.Pipe()
+	cmd.Stdin = pr
+	go func() {
+		pw.CloseWithError(job.upload(pw))
+	}()
+
+	err := cmd.Run()
+	pr.Close()
+	if ui.IsVerbose() && stderr.Len() > 0 {
+		fmt.Fprint(ui.Output(), stderr.String())
+	}
+	if err != nil {
+		return &RenderError{
+			ScadFile: scadFile,
+			Stderr:   stderr.String(),
+			Err:      fmt.Errorf("worker %s: %w", host, err),
+		}
+	}
+	if err := job.download(&stdout); err != nil {
+		return fmt.Errorf("worker %s: %w", host, err)
+	}
+	return nil
+}
+
+// archiveName returns the name of a local file in the archives exchanged with workers
+func archiveName(path string) string {
+	return strings.TrimPrefix(filepath.ToSlash(path), "/")
+}
+
+// remotePath returns the shell word of a local absolute path on the worker
+func remotePath(path string) string {
+	return "\"$d\"" + shellQuote(filepath.ToSlash(path))
+}
+
+// shellQuote quotes s for a POSIX shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,36 @@
+package renderer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNewRemoteJob(t *testing.T) {
+	job := newRemoteJob("/work", []string{"/libs"}, []string{"-o", "/tmp/part.3mf", "-d", "/cache/part.d", "-D", "$fn=32", "/gen/part.scad"})
+
+	if want := []string{"/work", "/libs", "/gen/part.scad"}; !reflect.DeepEqual(job.uploads, want) {
+		t.Errorf("uploads = %v, want %v", job.uploads, want)
+	}
+	if want := []string{"/tmp/part.3mf", "/cache/part.d"}; !reflect.DeepEqual(job.outputs, want) {
+		t.Errorf("outputs = %v, want %v", job.outputs, want)
+	}
+
+	script := job.script()
+	for _, want := range []string{
+		`cd "$d"'/work'`,
+		`OPENSCADPATH="$d"'/libs' openscad '-o' "$d"'/tmp/part.3mf' '-d' "$d"'/cache/part.d' '-D' '$fn=32' "$d"'/gen/part.scad' >&2`,
+		`sed "s|$d||g" "$d"'/cache/part.d'`,
+		`tar -cf - -C "$d" 'tmp/part.3mf' 'cache/part.d'`,
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("expected script to contain %q, got:\n%s", want, script)
+		}
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	if got, want := shellQuote("it's"), `'it'\''s'`; got != want {
+		t.Errorf("shellQuote() = %s, want %s", got, want)
+	}
+}
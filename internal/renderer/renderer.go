@@ -2,18 +2,51 @@ package renderer
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/philipparndt/go3mf/internal/models"
 	"github.com/philipparndt/go3mf/internal/ui"
 )
 
-// runOpenSCAD executes openscad command and captures output
+// RenderError is returned when OpenSCAD fails and carries the captured output
+type RenderError struct {
+	ScadFile string
+	Stdout   string
+	Stderr   string
+	Err      error
+}
+
+// Error returns the message of the underlying error
+func (e *RenderError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *RenderError) Unwrap() error {
+	return e.Err
+}
+
+// PrintError displays the OpenSCAD output of a failed render in a formatted box.
+// Errors that do not originate from OpenSCAD are ignored.
+func PrintError(err error) {
+	var renderErr *RenderError
+	if errors.As(err, &renderErr) {
+		displayOpenSCADError(renderErr.ScadFile, renderErr.Stdout, renderErr.Stderr)
+	}
+}
+
+// runOpenSCAD executes openscad command and captures output.
+// On failure a *RenderError with the captured output is returned; use PrintError to display it.
 func runOpenSCAD(cmd *exec.Cmd, scadFile string) error {
 	var stdout, stderr bytes.Buffer
 
@@ -26,17 +59,20 @@ func runOpenSCAD(cmd *exec.Cmd, scadFile string) error {
 	// If in verbose mode, print output regardless of error
 	if ui.IsVerbose() {
 		if stdout.Len() > 0 {
-			fmt.Print(stdout.String())
+			fmt.Fprint(ui.Output(), stdout.String())
 		}
 		if stderr.Len() > 0 {
-			fmt.Print(stderr.String())
+			fmt.Fprint(ui.Output(), stderr.String())
 		}
 	}
 
-	// If error occurred, display nicely formatted output
 	if err != nil {
-		displayOpenSCADError(scadFile, stdout.String(), stderr.String())
-		return err
+		return &RenderError{
+			ScadFile: scadFile,
+			Stdout:   stdout.String(),
+			Stderr:   stderr.String(),
+			Err:      err,
+		}
 	}
 
 	return nil
@@ -93,26 +129,45 @@ func displayOpenSCADError(scadFile, stdout, stderr string) {
 		}
 	}
 
-	fmt.Println(errorStyle.Render(content.String()))
+	fmt.Fprintln(ui.Output(), errorStyle.Render(content.String()))
 }
 
 // RenderSCAD renders a SCAD file to 3MF format
 func RenderSCAD(workDir, scadFile, outputFile string) error {
+	return RenderSCADWithQuality(workDir, scadFile, outputFile, models.Quality{}, nil)
+}
+
+// RenderSCADWithQuality renders a SCAD file to 3MF format with the given resolution.
+// libraryPaths are added to OPENSCADPATH.
+func RenderSCADWithQuality(workDir, scadFile, outputFile string, quality models.Quality, libraryPaths []string) error {
 	// Convert scadFile to absolute path if it's relative
 	absScadFile := scadFile
 	if !filepath.IsAbs(scadFile) {
 		absScadFile = filepath.Join(workDir, scadFile)
 	}
 
-	cmd := exec.Command("openscad", "-o", outputFile, absScadFile)
-	cmd.Dir = workDir
-
-	if err := runOpenSCAD(cmd, scadFile); err != nil {
+	args := append(qualityArgs(quality), absScadFile)
+	if err := render(workDir, scadFile, outputFile, libraryPaths, args...); err != nil {
 		return fmt.Errorf("failed to render %s: %w", scadFile, err)
 	}
 	return nil
 }
 
+// qualityArgs returns the OpenSCAD defines for the resolution variables that are set
+func qualityArgs(quality models.Quality) []string {
+	var args []string
+	if quality.Fn > 0 {
+		args = append(args, "-D", "$fn="+strconv.Itoa(quality.Fn))
+	}
+	if quality.Fa > 0 {
+		args = append(args, "-D", "$fa="+strconv.FormatFloat(quality.Fa, 'f', -1, 64))
+	}
+	if quality.Fs > 0 {
+		args = append(args, "-D", "$fs="+strconv.FormatFloat(quality.Fs, 'f', -1, 64))
+	}
+	return args
+}
+
 // RenderSCADWithConfig renders a SCAD file with optional config content to 3MF format
 func RenderSCADWithConfig(workDir, scadFile, outputFile, configContent string) error {
 	// Convert scadFile to absolute path if it's relative
@@ -134,10 +189,7 @@ func RenderSCADWithConfig(workDir, scadFile, outputFile, configContent string) e
 	}
 	defer os.Remove(configFile)
 
-	cmd := exec.Command("openscad", "-o", outputFile, "-D", "cfg_file=\""+configFile+"\"", absScadFile)
-	cmd.Dir = workDir
-
-	if err := runOpenSCAD(cmd, scadFile); err != nil {
+	if err := render(workDir, scadFile, outputFile, nil, "-D", "cfg_file=\""+configFile+"\"", absScadFile); err != nil {
 		return fmt.Errorf("failed to render %s with config: %w", scadFile, err)
 	}
 	return nil
@@ -156,19 +208,6 @@ func RenderSCADWithConfigFiles(workDir, scadFile, outputFile string, configFiles
 		return RenderSCAD(workDir, scadFile, outputFile)
 	}
 
-	// Copy the SCAD file to the working directory
-	scadFileName := filepath.Base(absScadFile)
-	workDirScadFile := filepath.Join(workDir, scadFileName)
-
-	scadContent, err := os.ReadFile(absScadFile)
-	if err != nil {
-		return fmt.Errorf("failed to read SCAD file %s: %w", absScadFile, err)
-	}
-
-	if err := os.WriteFile(workDirScadFile, scadContent, 0644); err != nil {
-		return fmt.Errorf("failed to copy SCAD file to working directory: %w", err)
-	}
-
 	// Write config files to the working directory
 	for filename, content := range configFiles {
 		configPath := filepath.Join(workDir, filename)
@@ -177,58 +216,130 @@ func RenderSCADWithConfigFiles(workDir, scadFile, outputFile string, configFiles
 		}
 	}
 
-	// Run OpenSCAD from the working directory with the local SCAD file
-	cmd := exec.Command("openscad", "-o", outputFile, scadFileName)
-	cmd.Dir = workDir
-
-	if err := runOpenSCAD(cmd, scadFile); err != nil {
+	// Run OpenSCAD from the working directory; the SCAD file is rendered where it
+	// is, so its relative use <...> and include <...> paths keep working
+	if err := render(workDir, scadFile, outputFile, nil, absScadFile); err != nil {
 		return fmt.Errorf("failed to render %s with config files: %w", scadFile, err)
 	}
 	return nil
 }
 
-// RenderMultipleSCAD renders multiple SCAD files and returns temporary file paths
-func RenderMultipleSCAD(baseDir string, scadFiles []string) ([]string, error) {
-	var tempFiles []string
+// localJobs is the number of renders run on this machine at a time (0 = one per CPU)
+var localJobs int
+
+// SetJobs sets the number of renders run on this machine at a time (0 = one per CPU)
+func SetJobs(jobs int) {
+	localJobs = jobs
+}
 
-	for i, scadFile := range scadFiles {
-		tempFile := fmt.Sprintf("/tmp/scad_render_%d.3mf", i)
-		tempFiles = append(tempFiles, tempFile)
+// Jobs returns the number of renders that run at a time: one per remote worker,
+// otherwise the configured number of local renders
+func Jobs() int {
+	if n := Workers(); n > 0 {
+		return n
+	}
+	if localJobs > 0 {
+		return localJobs
+	}
+	return runtime.NumCPU()
+}
 
-		if err := RenderSCAD(baseDir, scadFile, tempFile); err != nil {
-			return nil, err
+// renderAll calls render for the indices 0 to n-1, at most Jobs() at a time.
+// It returns the error of the lowest failed index; after a failure, the
+// remaining indices are skipped.
+func renderAll(n int, render func(i int) error) error {
+	errs := make([]error, n)
+	var failed atomic.Bool
+	var wg sync.WaitGroup
+	queue := make(chan int)
+
+	for range min(Jobs(), n) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				if failed.Load() {
+					continue
+				}
+				if errs[i] = render(i); errs[i] != nil {
+					failed.Store(true)
+				}
+			}
+		}()
+	}
+	for i := range n {
+		queue <- i
+	}
+	close(queue)
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
 		}
 	}
+	return nil
+}
 
+// RenderMultipleSCAD renders multiple SCAD files in parallel and returns
+// temporary file paths in the order of the files
+func RenderMultipleSCAD(baseDir string, scadFiles []string) ([]string, error) {
+	tempFiles := make([]string, len(scadFiles))
+	for i := range scadFiles {
+		tempFiles[i] = TempFile("scad_render", i)
+	}
+
+	err := renderAll(len(scadFiles), func(i int) error {
+		return RenderSCAD(baseDir, scadFiles[i], tempFiles[i])
+	})
+	if err != nil {
+		return nil, err
+	}
 	return tempFiles, nil
 }
 
-// RenderMultipleSCADWithConfigs renders multiple SCAD files with their config files and returns temporary file paths
+// RenderMultipleSCADWithConfigs renders multiple SCAD files with their config
+// files in parallel and returns temporary file paths in the order of the files.
+// Config files are written to baseDir, so parts with config files are rendered
+// one after another.
 func RenderMultipleSCADWithConfigs(baseDir string, scadFiles []models.ScadFile) ([]string, error) {
-	var tempFiles []string
+	tempFiles := make([]string, len(scadFiles))
+	for i := range scadFiles {
+		tempFiles[i] = TempFile("scad_render", i)
+	}
 
-	for i, scadFile := range scadFiles {
-		tempFile := fmt.Sprintf("/tmp/scad_render_%d.3mf", i)
-		tempFiles = append(tempFiles, tempFile)
+	var configMu sync.Mutex
+	err := renderAll(len(scadFiles), func(i int) error {
+		scadFile := scadFiles[i]
+		if len(scadFile.ConfigFiles) > 0 {
+			configMu.Lock()
+			defer configMu.Unlock()
+		}
 
 		// Write config files to the base directory with their original names
 		for filename, content := range scadFile.ConfigFiles {
 			configPath := filepath.Join(baseDir, filename)
 
 			if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
-				return nil, fmt.Errorf("failed to write config file %s: %w", configPath, err)
+				return fmt.Errorf("failed to write config file %s: %w", configPath, err)
 			}
 		}
 
 		// Render this part
-		if err := RenderSCAD(baseDir, scadFile.Path, tempFile); err != nil {
-			return nil, err
-		}
+		return RenderSCAD(baseDir, scadFile.Path, tempFiles[i])
+	})
+	if err != nil {
+		return nil, err
 	}
-
 	return tempFiles, nil
 }
 
+// TempFile returns the path of the index-th temporary 3MF file with the given prefix.
+// The name contains the process ID, so that parallel builds do not overwrite each other.
+func TempFile(prefix string, index int) string {
+	return filepath.Join(os.TempDir(), fmt.Sprintf("%s_%d_%d.3mf", prefix, os.Getpid(), index))
+}
+
 // CleanupTempFiles removes temporary files
 func CleanupTempFiles(files []string) {
 	for _, f := range files {
@@ -0,0 +1,124 @@
+package renderer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/philipparndt/go3mf/internal/models"
+)
+
+func TestQualityArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		quality models.Quality
+		want    []string
+	}{
+		{name: "unset", quality: models.Quality{}, want: nil},
+		{name: "fn only", quality: models.Quality{Fn: 128}, want: []string{"-D", "$fn=128"}},
+		{name: "all", quality: models.Quality{Fn: 32, Fa: 2, Fs: 0.2}, want: []string{"-D", "$fn=32", "-D", "$fa=2", "-D", "$fs=0.2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := qualityArgs(tt.quality); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestOpenSCADPath(t *testing.T) {
+	sep := string(os.PathListSeparator)
+	tests := []struct {
+		name    string
+		paths   []string
+		current string
+		want    string
+	}{
+		{"library paths only", []string{"/a", "/b"}, "", "/a" + sep + "/b"},
+		{"in front of the current path", []string{"/a"}, "/usr/share/openscad", "/a" + sep + "/usr/share/openscad"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := openSCADPath(tt.paths, tt.current); got != tt.want {
+				t.Errorf("openSCADPath() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJobs(t *testing.T) {
+	defer SetJobs(0)
+	defer UseWorkers(nil)
+
+	if got := Jobs(); got != runtime.NumCPU() {
+		t.Errorf("default jobs = %d, want one per CPU (%d)", got, runtime.NumCPU())
+	}
+	SetJobs(3)
+	if got := Jobs(); got != 3 {
+		t.Errorf("jobs = %d, want 3", got)
+	}
+	UseWorkers([]string{"farm1", "farm2"})
+	if got := Jobs(); got != 2 {
+		t.Errorf("jobs with workers = %d, want one per worker (2)", got)
+	}
+}
+
+func TestRenderAll(t *testing.T) {
+	defer SetJobs(0)
+	SetJobs(4)
+
+	// All indices run, at most Jobs() at a time
+	var running, peak atomic.Int32
+	done := make([]bool, 10)
+	err := renderAll(len(done), func(i int) error {
+		n := running.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		running.Add(-1)
+		done[i] = true
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i, ok := range done {
+		if !ok {
+			t.Errorf("index %d was not rendered", i)
+		}
+	}
+	if peak.Load() > 4 {
+		t.Errorf("%d renders ran at a time, want at most 4", peak.Load())
+	}
+
+	// The error of the lowest failed index is returned, even if it fails last
+	errFirst := errors.New("first")
+	started := make(chan struct{})
+	err = renderAll(4, func(i int) error {
+		switch i {
+		case 1:
+			close(started)
+			time.Sleep(5 * time.Millisecond)
+			return errFirst
+		case 2:
+			<-started
+			return fmt.Errorf("second")
+		}
+		return nil
+	})
+	if !errors.Is(err, errFirst) {
+		t.Errorf("error = %v, want the error of index 1", err)
+	}
+}
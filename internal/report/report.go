@@ -0,0 +1,224 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"os"
+	"time"
+
+	"github.com/philipparndt/go3mf/internal/bom"
+	"github.com/philipparndt/go3mf/internal/layout"
+	"github.com/philipparndt/go3mf/internal/telemetry"
+)
+
+// thumbnailSize is the size of the object thumbnails in pixels
+const thumbnailSize = 64
+
+// Report is a single-file HTML summary of a build for design reviews: the plate
+// layout, statistics of every object and part and the warnings of the build
+type Report struct {
+	Title    string
+	Output   string
+	Layout   *layout.Layout
+	Parts    []bom.Item // Parts of all objects, listed by object
+	Warnings []string
+	Steps    []telemetry.StepTiming // Duration and memory of the build steps
+	Density  bom.Densities          // Filament densities by slot for the weight estimates
+}
+
+// object is an object of the report with its thumbnail and parts
+type object struct {
+	Name      string
+	Plate     int
+	Thumbnail template.HTML
+	Parts     []part
+}
+
+// part is a part of the report with its formatted statistics
+type part struct {
+	Name     string
+	Filament int
+	Volume   string
+	Weight   string
+	Source   string
+}
+
+// step is a build step of the report with its formatted duration and memory
+type step struct {
+	Name             string
+	Duration         string
+	PeakMemory       string
+	RenderPeakMemory string
+}
+
+// Write writes the report as a standalone HTML file
+func (r *Report) Write(path string) error {
+	var objects []object
+	index := make(map[string]int)
+	if r.Layout != nil {
+		for _, obj := range r.Layout.Objects {
+			index[obj.Name] = len(objects)
+			objects = append(objects, object{
+				Name:      obj.Name,
+				Plate:     obj.Plate,
+				Thumbnail: template.HTML(obj.ThumbnailSVG(thumbnailSize)),
+			})
+		}
+	}
+	var totalVolume, totalWeight float64
+	for _, item := range r.Parts {
+		i, ok := index[item.Object]
+		if !ok {
+			index[item.Object] = len(objects)
+			i = len(objects)
+			objects = append(objects, object{Name: item.Object})
+		}
+		objects[i].Parts = append(objects[i].Parts, part{
+			Name:     item.Part,
+			Filament: item.Filament,
+			Volume:   fmt.Sprintf("%.2f", item.Volume/1000),
+			Weight:   fmt.Sprintf("%.1f", item.Weight(r.Density.Slot(item.Filament))),
+			Source:   item.Source,
+		})
+		totalVolume += item.Volume
+		totalWeight += item.Weight(r.Density.Slot(item.Filament))
+	}
+
+	var steps []step
+	for _, s := range r.Steps {
+		steps = append(steps, step{
+			Name:             s.Name,
+			Duration:         s.Duration.Round(time.Millisecond).String(),
+			PeakMemory:       formatMemory(s.PeakMemory),
+			RenderPeakMemory: formatMemory(s.RenderPeakMemory),
+		})
+	}
+
+	data := struct {
+		*Report
+		BuildSteps  []step
+		LayoutSVG   template.HTML
+		Usage       []layout.PlateUsage
+		Objects     []object
+		PartCount   int
+		TotalVolume string
+		TotalWeight string
+	}{
+		Report:      r,
+		BuildSteps:  steps,
+		Objects:     objects,
+		PartCount:   len(r.Parts),
+		TotalVolume: fmt.Sprintf("%.2f", totalVolume/1000),
+		TotalWeight: fmt.Sprintf("%.1f", totalWeight),
+	}
+	if r.Layout != nil {
+		data.LayoutSVG = template.HTML(r.Layout.SVG())
+		for _, u := range r.Layout.Usage() {
+			if u.Objects > 0 {
+				data.Usage = append(data.Usage, u)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := reportTemplate.Execute(&buf, data); err != nil {
+		return fmt.Errorf("failed to render report: %w", err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return nil
+}
+
+// formatMemory formats a memory size for display ("-" = unknown)
+func formatMemory(b uint64) string {
+	if b == 0 {
+		return "-"
+	}
+	return telemetry.FormatBytes(b)
+}
+
+var reportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #222; }
+h1 { margin-bottom: 0.2em; }
+.muted { color: #777; }
+.layout svg { max-width: 100%; height: auto; border: 1px solid #ddd; }
+table { border-collapse: collapse; margin-top: 1em; }
+th, td { border-bottom: 1px solid #e4e4e4; padding: 0.4em 0.8em; text-align: left; vertical-align: middle; }
+th { background: #f6f6f6; }
+td.num { text-align: right; font-variant-numeric: tabular-nums; }
+.warnings li { color: #a15c00; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p class="muted">Output: {{.Output}}</p>
+
+<h2>Summary</h2>
+<table>
+<tr><th>Objects</th><td class="num">{{len .Objects}}</td></tr>
+<tr><th>Parts</th><td class="num">{{.PartCount}}</td></tr>
+<tr><th>Volume</th><td class="num">{{.TotalVolume}} cm³</td></tr>
+<tr><th>Estimated weight</th><td class="num">{{.TotalWeight}} g</td></tr>
+{{- range .Usage}}
+<tr><th>Plate {{.Plate}}</th><td class="num">{{.Objects}} object(s), {{printf "%.1f" .Utilization}}% used</td></tr>
+{{- end}}
+</table>
+
+{{- if .Warnings}}
+<h2>Warnings</h2>
+<ul class="warnings">
+{{- range .Warnings}}
+<li>{{.}}</li>
+{{- end}}
+</ul>
+{{- end}}
+
+{{- if .LayoutSVG}}
+<h2>Layout</h2>
+<div class="layout">{{.LayoutSVG}}</div>
+{{- end}}
+
+<h2>Objects</h2>
+<table>
+<tr><th></th><th>Object</th><th>Plate</th><th>Part</th><th>Filament</th><th>Volume (cm³)</th><th>Weight (g)</th><th>Source</th></tr>
+{{- range .Objects}}
+{{- $obj := .}}
+{{- if not .Parts}}
+<tr><td>{{.Thumbnail}}</td><td>{{.Name}}</td><td class="num">{{if .Plate}}{{.Plate}}{{else}}1{{end}}</td><td colspan="5"></td></tr>
+{{- end}}
+{{- range $i, $part := .Parts}}
+<tr>
+{{- if eq $i 0}}
+<td rowspan="{{len $obj.Parts}}">{{$obj.Thumbnail}}</td>
+<td rowspan="{{len $obj.Parts}}">{{$obj.Name}}</td>
+<td rowspan="{{len $obj.Parts}}" class="num">{{if $obj.Plate}}{{$obj.Plate}}{{else}}1{{end}}</td>
+{{- end}}
+<td>{{$part.Name}}</td>
+<td class="num">{{if $part.Filament}}{{$part.Filament}}{{end}}</td>
+<td class="num">{{$part.Volume}}</td>
+<td class="num">{{$part.Weight}}</td>
+<td>{{$part.Source}}</td>
+</tr>
+{{- end}}
+{{- end}}
+</table>
+
+{{- if .BuildSteps}}
+<h2>Build Steps</h2>
+<table>
+<tr><th>Step</th><th>Time</th><th>Peak memory</th><th>OpenSCAD peak memory</th></tr>
+{{- range .BuildSteps}}
+<tr><td>{{.Name}}</td><td class="num">{{.Duration}}</td><td class="num">{{.PeakMemory}}</td><td class="num">{{.RenderPeakMemory}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+</body>
+</html>
+`))
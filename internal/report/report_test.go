@@ -0,0 +1,52 @@
+package report
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/philipparndt/go3mf/internal/bom"
+	"github.com/philipparndt/go3mf/internal/geometry"
+	"github.com/philipparndt/go3mf/internal/layout"
+)
+
+func TestWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.html")
+	r := &Report{
+		Title:  "clips.yaml",
+		Output: "clips.3mf",
+		Layout: &layout.Layout{PlateWidth: 100, PlateDepth: 100, Plates: 1, Objects: []layout.Object{
+			{Name: "Clip", Parts: []layout.Part{{Outline: []geometry.Point{{X: 0, Y: 0}, {X: 10, Y: 0}, {X: 10, Y: 10}}, Filament: 1}}},
+		}},
+		Parts: []bom.Item{
+			{Object: "Clip", Part: "body", Quantity: 1, Filament: 1, Volume: 2500, Source: "clip.scad"},
+			{Object: "Clip", Part: "pin", Quantity: 1, Filament: 2, Volume: 500, Source: "pin.scad"},
+		},
+		Warnings: []string{"Skipped optional part <x>"},
+		Density:  bom.Densities{1.24, 1.27},
+	}
+
+	if err := r.Write(path); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	html := string(data)
+
+	for _, want := range []string{
+		"<title>clips.yaml</title>",
+		"<td class=\"num\">3.00 cm³</td>",
+		"<td class=\"num\">3.7 g</td>",
+		"<li>Skipped optional part &lt;x&gt;</li>",
+		`<td rowspan="2">Clip</td>`,
+		"<td>pin.scad</td>",
+		`<svg xmlns="http://www.w3.org/2000/svg" width="64"`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("report does not contain %q", want)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package selftest
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/philipparndt/go3mf/internal/buildplan"
+	"github.com/philipparndt/go3mf/internal/inspect"
+	"github.com/philipparndt/go3mf/internal/renderer"
+	"github.com/philipparndt/go3mf/internal/ui"
+)
+
+//go:embed fixtures
+var fixtures embed.FS
+
+// Case is a build of the self-test: a YAML config of the fixtures and the
+// golden object list of its output
+type Case struct {
+	Name   string
+	Config string // YAML config in the fixtures
+	Golden string // Expected object list in the fixtures (as of inspect --format csv)
+	SCAD   bool   // The config renders SCAD files with OpenSCAD
+}
+
+// Cases are the builds of the self-test
+var Cases = []Case{
+	{Name: "STL files", Config: "stl.yaml", Golden: "stl.csv"},
+	{Name: "SCAD and STL files", Config: "mixed.yaml", Golden: "mixed.csv", SCAD: true},
+}
+
+// Result is the outcome of a case
+type Result struct {
+	Case    Case
+	Skipped bool
+	Err     error // Failure of the build or difference to the golden object list
+}
+
+// Tester runs the self-test cases in a work directory
+type Tester struct {
+	dir      string
+	skipSCAD bool
+}
+
+// NewTester creates a tester that writes the fixtures and outputs to dir.
+// Cases that need OpenSCAD are skipped if skipSCAD is set.
+func NewTester(dir string, skipSCAD bool) *Tester {
+	return &Tester{dir: dir, skipSCAD: skipSCAD}
+}
+
+// Run writes the fixtures, builds every case with the verification of the
+// output and compares the objects of the output with the golden object list
+func (t *Tester) Run() ([]Result, error) {
+	if err := t.writeFixtures(); err != nil {
+		return nil, err
+	}
+	results := make([]Result, 0, len(Cases))
+	for _, c := range Cases {
+		result := Result{Case: c}
+		if c.SCAD && t.skipSCAD {
+			result.Skipped = true
+		} else {
+			ui.PrintStep(fmt.Sprintf("Self-test: %s", c.Name))
+			result.Err = t.run(c)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// OpenSCADVersion returns the version of the installed OpenSCAD, or "" if it
+// cannot be run
+func OpenSCADVersion() string {
+	return renderer.Version()
+}
+
+// writeFixtures copies the embedded fixtures to the work directory
+func (t *Tester) writeFixtures() error {
+	entries, err := fs.ReadDir(fixtures, "fixtures")
+	if err != nil {
+		return fmt.Errorf("error reading fixtures: %w", err)
+	}
+	for _, entry := range entries {
+		data, err := fixtures.ReadFile("fixtures/" + entry.Name())
+		if err != nil {
+			return fmt.Errorf("error reading fixture %s: %w", entry.Name(), err)
+		}
+		if err := os.WriteFile(filepath.Join(t.dir, entry.Name()), data, 0644); err != nil {
+			return fmt.Errorf("error writing fixture %s: %w", entry.Name(), err)
+		}
+	}
+	return nil
+}
+
+// run builds a case and compares its output with the golden object list
+func (t *Tester) run(c Case) error {
+	buildplan.Reset()
+	buildplan.SetVerify(true)
+	buildplan.SetForce(true)
+
+	// The output is written next to the fixtures, not to the working directory
+	output := filepath.Join(t.dir, strings.TrimSuffix(c.Config, filepath.Ext(c.Config))+".3mf")
+	plan, err := buildplan.NewPlanner().CreatePlan([]string{filepath.Join(t.dir, c.Config)}, nil, output)
+	if err != nil {
+		return fmt.Errorf("failed to create build plan: %w", err)
+	}
+	if err := plan.Execute(); err != nil {
+		return fmt.Errorf("build failed: %w", err)
+	}
+
+	rows, err := inspect.NewInspector().ObjectRows(plan.OutputFile)
+	if err != nil {
+		return err
+	}
+	var got bytes.Buffer
+	if err := inspect.WriteObjectCSV(&got, rows); err != nil {
+		return err
+	}
+	want, err := fixtures.ReadFile("fixtures/" + c.Golden)
+	if err != nil {
+		return fmt.Errorf("error reading fixture %s: %w", c.Golden, err)
+	}
+	return compare(got.String(), string(want))
+}
+
+// compare returns an error listing the lines of the object lists that differ
+func compare(got, want string) error {
+	gotLines := strings.Split(strings.TrimSpace(got), "\n")
+	wantLines := strings.Split(strings.TrimSpace(want), "\n")
+	var diffs []string
+	for i := 0; i < len(gotLines) || i < len(wantLines); i++ {
+		var g, w string
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if g != w {
+			diffs = append(diffs, fmt.Sprintf("line %d: got %q, want %q", i+1, g, w))
+		}
+	}
+	if len(diffs) > 0 {
+		return fmt.Errorf("the objects differ from the expected objects:\n  %s", strings.Join(diffs, "\n  "))
+	}
+	return nil
+}
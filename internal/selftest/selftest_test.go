@@ -0,0 +1,51 @@
+package selftest
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRunWithoutSCAD(t *testing.T) {
+	results, err := NewTester(t.TempDir(), true).Run()
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(results) != len(Cases) {
+		t.Fatalf("Run() returned %d results, want %d", len(results), len(Cases))
+	}
+	for _, result := range results {
+		if result.Skipped != result.Case.SCAD {
+			t.Errorf("%s: skipped = %v, want %v", result.Case.Name, result.Skipped, result.Case.SCAD)
+		}
+		if result.Err != nil {
+			t.Errorf("%s: %v", result.Case.Name, result.Err)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name string
+		got  string
+		want string
+		diff string // Substring of the error ("" = equal)
+	}{
+		{name: "equal", got: "name,id\ncube,1\n", want: "name,id\ncube,1"},
+		{name: "changed", got: "name,id\ncube,2\n", want: "name,id\ncube,1\n", diff: `line 2: got "cube,2", want "cube,1"`},
+		{name: "missing", got: "name,id\n", want: "name,id\ncube,1\n", diff: `line 2: got "", want "cube,1"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := compare(tt.got, tt.want)
+			if tt.diff == "" {
+				if err != nil {
+					t.Errorf("compare() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.diff) {
+				t.Errorf("compare() error = %v, want %q", err, tt.diff)
+			}
+		})
+	}
+}
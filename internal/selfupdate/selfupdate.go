@@ -0,0 +1,338 @@
+package selfupdate
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultAPIURL is the GitHub API endpoint for the go3mf releases
+	DefaultAPIURL = "https://api.github.com/repos/philipparndt/go3mf/releases"
+
+	binaryName = "go3mf"
+)
+
+// Release is a published go3mf release
+type Release struct {
+	Tag    string  `json:"tag_name"`
+	Assets []Asset `json:"assets"`
+}
+
+// Asset is a file attached to a release
+type Asset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+}
+
+// Version returns the release version without the leading "v"
+func (r *Release) Version() string {
+	return strings.TrimPrefix(r.Tag, "v")
+}
+
+// Updater checks GitHub releases and replaces the running binary
+type Updater struct {
+	APIURL string
+	Client *http.Client
+	GOOS   string
+	GOARCH string
+}
+
+// NewUpdater creates a new updater for the current platform
+func NewUpdater() *Updater {
+	return &Updater{
+		APIURL: DefaultAPIURL,
+		Client: &http.Client{Timeout: 60 * time.Second},
+		GOOS:   runtime.GOOS,
+		GOARCH: runtime.GOARCH,
+	}
+}
+
+// FindRelease returns the latest release, or the release with the given version
+func (u *Updater) FindRelease(version string) (*Release, error) {
+	url := u.APIURL + "/latest"
+	if version != "" {
+		url = u.APIURL + "/tags/v" + strings.TrimPrefix(version, "v")
+	}
+
+	data, err := u.get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query releases: %w", err)
+	}
+
+	var release Release
+	if err := json.Unmarshal(data, &release); err != nil {
+		return nil, fmt.Errorf("failed to parse release information: %w", err)
+	}
+	if release.Tag == "" {
+		return nil, fmt.Errorf("release information from %s has no tag", url)
+	}
+	return &release, nil
+}
+
+// Download downloads the archive of release for the current platform, verifies its
+// checksum against the release checksums file, and returns the contained binary.
+// The checksums come from the same release, so only the integrity of the download
+// is checked, not its origin.
+func (u *Updater) Download(release *Release) ([]byte, error) {
+	archiveName := ArchiveName(u.GOOS, u.GOARCH)
+	archive := release.findAsset(func(name string) bool { return name == archiveName })
+	if archive == nil {
+		return nil, fmt.Errorf("release %s has no archive for %s/%s (expected %s)", release.Tag, u.GOOS, u.GOARCH, archiveName)
+	}
+	checksums := release.findAsset(func(name string) bool { return strings.HasSuffix(name, "checksums.txt") })
+	if checksums == nil {
+		return nil, fmt.Errorf("release %s has no checksums file, refusing to install an unverified binary", release.Tag)
+	}
+
+	checksumData, err := u.get(checksums.URL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", checksums.Name, err)
+	}
+	expected, err := FindChecksum(checksumData, archive.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	archiveData, err := u.get(archive.URL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", archive.Name, err)
+	}
+	if err := VerifyChecksum(archiveData, expected); err != nil {
+		return nil, fmt.Errorf("%s: %w", archive.Name, err)
+	}
+
+	return extractBinary(archiveData, archive.Name, u.GOOS)
+}
+
+// get performs a GET request and returns the response body
+func (u *Updater) get(url string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	// Authenticated requests have a much higher rate limit (useful in CI)
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" && strings.HasPrefix(url, "https://api.github.com/") {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := u.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// findAsset returns the first asset whose name matches
+func (r *Release) findAsset(match func(name string) bool) *Asset {
+	for i := range r.Assets {
+		if match(r.Assets[i].Name) {
+			return &r.Assets[i]
+		}
+	}
+	return nil
+}
+
+// ArchiveName returns the release archive name for a platform, following the
+// name template of .goreleaser.yaml (e.g. go3mf_linux_x86_64.tar.gz)
+func ArchiveName(goos, goarch string) string {
+	arch := goarch
+	switch goarch {
+	case "amd64":
+		arch = "x86_64"
+	case "386":
+		arch = "i386"
+	}
+
+	ext := ".tar.gz"
+	if goos == "windows" {
+		ext = ".zip"
+	}
+	return fmt.Sprintf("%s_%s_%s%s", binaryName, goos, arch, ext)
+}
+
+// FindChecksum returns the SHA-256 checksum of file from a checksums file
+// in the "<sha256>  <file>" format
+func FindChecksum(checksums []byte, file string) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[1] == file {
+			return fields[0], nil
+		}
+	}
+	return "", fmt.Errorf("no checksum found for %s", file)
+}
+
+// VerifyChecksum checks data against the expected hex encoded SHA-256 checksum
+func VerifyChecksum(data []byte, expected string) error {
+	sum := sha256.Sum256(data)
+	actual := hex.EncodeToString(sum[:])
+	if !strings.EqualFold(actual, expected) {
+		return fmt.Errorf("checksum mismatch (expected %s, got %s)", expected, actual)
+	}
+	return nil
+}
+
+// extractBinary returns the go3mf executable from a tar.gz or zip archive
+func extractBinary(data []byte, archiveName, goos string) ([]byte, error) {
+	name := binaryName
+	if goos == "windows" {
+		name += ".exe"
+	}
+
+	if strings.HasSuffix(archiveName, ".zip") {
+		reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", archiveName, err)
+		}
+		for _, file := range reader.File {
+			if filepath.Base(file.Name) != name {
+				continue
+			}
+			rc, err := file.Open()
+			if err != nil {
+				return nil, fmt.Errorf("failed to extract %s: %w", name, err)
+			}
+			defer rc.Close()
+			return io.ReadAll(rc)
+		}
+		return nil, fmt.Errorf("%s not found in %s", name, archiveName)
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", archiveName, err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil, fmt.Errorf("%s not found in %s", name, archiveName)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", archiveName, err)
+		}
+		if header.Typeflag == tar.TypeReg && filepath.Base(header.Name) == name {
+			return io.ReadAll(tr)
+		}
+	}
+}
+
+// Replace atomically replaces the executable at path with binary.
+// The new binary is written next to the old one and renamed over it, so a
+// failed update never leaves a partially written executable behind.
+func Replace(path string, binary []byte) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, "."+binaryName+"-update-*")
+	if err != nil {
+		return fmt.Errorf("cannot write to %s: %w", dir, err)
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+
+	if _, err := tmp.Write(binary); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpPath, info.Mode().Perm()|0111); err != nil {
+		return err
+	}
+
+	// A running executable cannot be overwritten on Windows, but it can be renamed
+	oldPath := path + ".old"
+	os.Remove(oldPath)
+	if err := os.Rename(path, oldPath); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		// Restore the previous binary
+		os.Rename(oldPath, path)
+		return err
+	}
+	os.Remove(oldPath)
+	return nil
+}
+
+// ManagedBy returns the package manager that installed the executable at path
+// ("brew" or "scoop"), or an empty string if it was installed manually
+func ManagedBy(path string) string {
+	p := strings.ReplaceAll(path, `\`, "/")
+	switch {
+	case strings.Contains(p, "/Cellar/") || strings.Contains(p, "/homebrew/") || strings.Contains(p, "/linuxbrew/"):
+		return "brew"
+	case strings.Contains(strings.ToLower(p), "/scoop/apps/"):
+		return "scoop"
+	default:
+		return ""
+	}
+}
+
+// CompareVersions compares two versions like "1.2.3" or "v1.2.3".
+// It returns -1 if a < b, 0 if they are equal and 1 if a > b.
+// Pre-release suffixes (e.g. "-rc1") are ignored.
+func CompareVersions(a, b string) int {
+	pa, pb := versionParts(a), versionParts(b)
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var x, y int
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	}
+	return 0
+}
+
+// versionParts parses the numeric parts of a version
+func versionParts(v string) []int {
+	v = strings.TrimPrefix(v, "v")
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v = v[:i]
+	}
+	var parts []int
+	for _, s := range strings.Split(v, ".") {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			break
+		}
+		parts = append(parts, n)
+	}
+	return parts
+}
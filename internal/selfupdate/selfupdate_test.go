@@ -0,0 +1,165 @@
+package selfupdate
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.2.3", "1.2.3", 0},
+		{"v1.2.3", "1.2.3", 0},
+		{"1.2.3", "1.10.0", -1},
+		{"2.0.0", "1.99.99", 1},
+		{"1.2", "1.2.1", -1},
+		{"1.3.0-rc1", "1.3.0", 0},
+	}
+
+	for _, tt := range tests {
+		if got := CompareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("CompareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestArchiveName(t *testing.T) {
+	tests := []struct {
+		goos, goarch, want string
+	}{
+		{"linux", "amd64", "go3mf_linux_x86_64.tar.gz"},
+		{"darwin", "arm64", "go3mf_darwin_arm64.tar.gz"},
+		{"windows", "amd64", "go3mf_windows_x86_64.zip"},
+	}
+
+	for _, tt := range tests {
+		if got := ArchiveName(tt.goos, tt.goarch); got != tt.want {
+			t.Errorf("ArchiveName(%q, %q) = %q, want %q", tt.goos, tt.goarch, got, tt.want)
+		}
+	}
+}
+
+// newReleaseServer serves a release with a tar.gz archive containing binary and a
+// checksums file, which lists checksum for the archive (or its real checksum if empty)
+func newReleaseServer(t *testing.T, binary []byte, checksum string) *httptest.Server {
+	t.Helper()
+
+	var archive bytes.Buffer
+	gz := gzip.NewWriter(&archive)
+	tw := tar.NewWriter(gz)
+	tw.WriteHeader(&tar.Header{Name: "README.md", Mode: 0644, Size: 2, Typeflag: tar.TypeReg})
+	tw.Write([]byte("hi"))
+	tw.WriteHeader(&tar.Header{Name: "go3mf", Mode: 0755, Size: int64(len(binary)), Typeflag: tar.TypeReg})
+	tw.Write(binary)
+	tw.Close()
+	gz.Close()
+
+	if checksum == "" {
+		sum := sha256.Sum256(archive.Bytes())
+		checksum = hex.EncodeToString(sum[:])
+	}
+	archiveName := ArchiveName("linux", "amd64")
+
+	mux := http.NewServeMux()
+	var server *httptest.Server
+	mux.HandleFunc("/releases/latest", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"tag_name":"v1.2.0","assets":[{"name":%q,"browser_download_url":%q},{"name":"go3mf_1.2.0_checksums.txt","browser_download_url":%q}]}`,
+			archiveName, server.URL+"/download/archive", server.URL+"/download/checksums")
+	})
+	mux.HandleFunc("/download/archive", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(archive.Bytes())
+	})
+	mux.HandleFunc("/download/checksums", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "0000  go3mf_darwin_arm64.tar.gz\n%s  %s\n", checksum, archiveName)
+	})
+	server = httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newTestUpdater(server *httptest.Server) *Updater {
+	updater := NewUpdater()
+	updater.APIURL = server.URL + "/releases"
+	updater.GOOS = "linux"
+	updater.GOARCH = "amd64"
+	return updater
+}
+
+func TestDownloadAndReplace(t *testing.T) {
+	server := newReleaseServer(t, []byte("new binary"), "")
+	updater := newTestUpdater(server)
+
+	release, err := updater.FindRelease("")
+	if err != nil {
+		t.Fatalf("FindRelease() error = %v", err)
+	}
+	if release.Version() != "1.2.0" {
+		t.Errorf("Version() = %q, want %q", release.Version(), "1.2.0")
+	}
+
+	binary, err := updater.Download(release)
+	if err != nil {
+		t.Fatalf("Download() error = %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "go3mf")
+	if err := os.WriteFile(path, []byte("old binary"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := Replace(path, binary); err != nil {
+		t.Fatalf("Replace() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "new binary" {
+		t.Errorf("binary = %q, want %q", data, "new binary")
+	}
+	if _, err := os.Stat(path + ".old"); !os.IsNotExist(err) {
+		t.Errorf("backup of the old binary should be removed")
+	}
+}
+
+func TestDownload_ChecksumMismatch(t *testing.T) {
+	server := newReleaseServer(t, []byte("tampered"), strings.Repeat("ab", 32))
+	updater := newTestUpdater(server)
+
+	release, err := updater.FindRelease("")
+	if err != nil {
+		t.Fatalf("FindRelease() error = %v", err)
+	}
+	if _, err := updater.Download(release); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Errorf("Download() error = %v, want checksum mismatch", err)
+	}
+}
+
+func TestManagedBy(t *testing.T) {
+	tests := []struct {
+		path, want string
+	}{
+		{"/opt/homebrew/Cellar/go3mf/1.2.0/bin/go3mf", "brew"},
+		{"/home/linuxbrew/.linuxbrew/bin/go3mf", "brew"},
+		{`C:\Users\me\scoop\apps\go3mf\current\go3mf.exe`, "scoop"},
+		{"/usr/local/bin/go3mf", ""},
+	}
+
+	for _, tt := range tests {
+		if got := ManagedBy(tt.path); got != tt.want {
+			t.Errorf("ManagedBy(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
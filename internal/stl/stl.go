@@ -7,9 +7,14 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
+	"math"
 	"os"
 	"path/filepath"
 	"strings"
+	"unicode/utf8"
+
+	"github.com/philipparndt/go3mf/internal/geometry"
+	"github.com/philipparndt/go3mf/internal/limits"
 )
 
 // Vector3 represents a 3D vector
@@ -45,22 +50,27 @@ func (p *Parser) Parse(filename string) (*Mesh, error) {
 	}
 	defer file.Close()
 
-	// Read first few bytes to detect format
-	header := make([]byte, 80)
-	if _, err := io.ReadFull(file, header); err != nil {
-		return nil, fmt.Errorf("error reading header: %w", err)
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("cannot stat file: %w", err)
 	}
+	return p.ParseReaderAt(file, info.Size(), filename)
+}
 
-	// Reset file position
-	if _, err := file.Seek(0, 0); err != nil {
-		return nil, fmt.Errorf("error seeking: %w", err)
+// ParseReaderAt reads the mesh data of an STL file of the given size from r,
+// e.g. a memory-mapped file. The name is used as mesh name of binary files.
+func (p *Parser) ParseReaderAt(r io.ReaderAt, size int64, name string) (*Mesh, error) {
+	// Read first few bytes to detect format
+	header := make([]byte, binaryHeaderSize)
+	if err := readAt(r, header, 0); err != nil {
+		return nil, fmt.Errorf("error reading header: %w", err)
 	}
 
 	// Check if it's ASCII (starts with "solid")
 	if strings.HasPrefix(string(header), "solid") {
-		return p.parseASCII(file, filename)
+		return p.parseASCII(io.NewSectionReader(r, 0, size), name)
 	}
-	return p.parseBinary(file, filename)
+	return p.parseBinary(r, size, name)
 }
 
 // parseASCII parses an ASCII STL file
@@ -73,6 +83,7 @@ func (p *Parser) parseASCII(reader io.Reader, filename string) (*Mesh, error) {
 
 	var currentTriangle Triangle
 	var vertexCount int
+	var vertices int64 // Vertices of the file, bounded by the limits
 
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
@@ -94,6 +105,10 @@ func (p *Parser) parseASCII(reader io.Reader, filename string) (*Mesh, error) {
 			}
 			vertexCount = 0
 		case "vertex":
+			vertices++
+			if err := limits.CheckVertices(vertices); err != nil {
+				return nil, err
+			}
 			if len(fields) >= 4 {
 				var v Vector3
 				fmt.Sscanf(strings.Join(fields[1:], " "), "%f %f %f", &v.X, &v.Y, &v.Z)
@@ -120,55 +135,106 @@ func (p *Parser) parseASCII(reader io.Reader, filename string) (*Mesh, error) {
 	return mesh, nil
 }
 
-// parseBinary parses a binary STL file
-func (p *Parser) parseBinary(reader io.Reader, filename string) (*Mesh, error) {
+const (
+	// binaryHeaderSize is the size of the header of a binary STL file
+	binaryHeaderSize = 80
+	// binaryTriangleSize is the size of a triangle in a binary STL file: normal,
+	// three vertices and the attribute byte count
+	binaryTriangleSize = 50
+	// binaryChunkTriangles is the number of triangles read at once
+	binaryChunkTriangles = 4096
+)
+
+// parseBinary parses a binary STL file. The triangles are read in chunks and
+// decoded directly from the buffer.
+func (p *Parser) parseBinary(r io.ReaderAt, size int64, filename string) (*Mesh, error) {
 	mesh := &Mesh{
 		Name: filepath.Base(filename),
 	}
 
-	// Read 80-byte header
-	header := make([]byte, 80)
-	if _, err := io.ReadFull(reader, header); err != nil {
-		return nil, fmt.Errorf("error reading header: %w", err)
+	// Read triangle count after the 80-byte header
+	countBytes := make([]byte, 4)
+	if err := readAt(r, countBytes, binaryHeaderSize); err != nil {
+		return nil, fmt.Errorf("error reading triangle count: %w", err)
 	}
+	triangleCount := int64(binary.LittleEndian.Uint32(countBytes))
 
-	// Read triangle count
-	var triangleCount uint32
-	if err := binary.Read(reader, binary.LittleEndian, &triangleCount); err != nil {
-		return nil, fmt.Errorf("error reading triangle count: %w", err)
+	// Check the size before allocating the triangles, a broken count must not
+	// allocate gigabytes
+	offset := int64(binaryHeaderSize + len(countBytes))
+	if available := (size - offset) / binaryTriangleSize; triangleCount > available {
+		return nil, fmt.Errorf("file is truncated: header declares %d triangles, but there is data for %d", triangleCount, available)
+	}
+	if err := limits.CheckVertices(triangleCount * 3); err != nil {
+		return nil, err
 	}
 
-	// Read triangles
 	mesh.Triangles = make([]Triangle, triangleCount)
-	for i := uint32(0); i < triangleCount; i++ {
-		var triangle Triangle
-
-		// Read normal
-		if err := binary.Read(reader, binary.LittleEndian, &triangle.Normal); err != nil {
-			return nil, fmt.Errorf("error reading normal: %w", err)
+	buf := make([]byte, min(triangleCount, binaryChunkTriangles)*binaryTriangleSize)
+	for i := int64(0); i < triangleCount; {
+		n := min(triangleCount-i, binaryChunkTriangles)
+		chunk := buf[:n*binaryTriangleSize]
+		if err := readAt(r, chunk, offset); err != nil {
+			return nil, fmt.Errorf("error reading triangle %d: %w", i+1, err)
 		}
-
-		// Read vertices
-		if err := binary.Read(reader, binary.LittleEndian, &triangle.V1); err != nil {
-			return nil, fmt.Errorf("error reading vertex 1: %w", err)
-		}
-		if err := binary.Read(reader, binary.LittleEndian, &triangle.V2); err != nil {
-			return nil, fmt.Errorf("error reading vertex 2: %w", err)
-		}
-		if err := binary.Read(reader, binary.LittleEndian, &triangle.V3); err != nil {
-			return nil, fmt.Errorf("error reading vertex 3: %w", err)
+		for j := int64(0); j < n; j++ {
+			mesh.Triangles[i+j] = decodeTriangle(chunk[j*binaryTriangleSize:])
 		}
+		offset += int64(len(chunk))
+		i += n
+	}
 
-		// Skip attribute byte count
-		var attributeCount uint16
-		if err := binary.Read(reader, binary.LittleEndian, &attributeCount); err != nil {
-			return nil, fmt.Errorf("error reading attribute count: %w", err)
-		}
+	return mesh, nil
+}
 
-		mesh.Triangles[i] = triangle
+// readAt fills buf from r at the given offset. Reaching the end of r exactly
+// with the last byte is not an error.
+func readAt(r io.ReaderAt, buf []byte, offset int64) error {
+	n, err := r.ReadAt(buf, offset)
+	if n == len(buf) {
+		return nil
+	}
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
 	}
+	return err
+}
 
-	return mesh, nil
+// decodeTriangle decodes a triangle of a binary STL file, the attribute byte
+// count is ignored
+func decodeTriangle(b []byte) Triangle {
+	return Triangle{
+		Normal: decodeVector(b[0:12]),
+		V1:     decodeVector(b[12:24]),
+		V2:     decodeVector(b[24:36]),
+		V3:     decodeVector(b[36:48]),
+	}
+}
+
+// encodeTriangle encodes a triangle of a binary STL file with an attribute byte
+// count of 0
+func encodeTriangle(b []byte, triangle Triangle) {
+	encodeVector(b[0:12], triangle.Normal)
+	encodeVector(b[12:24], triangle.V1)
+	encodeVector(b[24:36], triangle.V2)
+	encodeVector(b[36:48], triangle.V3)
+	binary.LittleEndian.PutUint16(b[48:50], 0)
+}
+
+// encodeVector encodes three little-endian float32 values
+func encodeVector(b []byte, v Vector3) {
+	binary.LittleEndian.PutUint32(b[0:4], math.Float32bits(v.X))
+	binary.LittleEndian.PutUint32(b[4:8], math.Float32bits(v.Y))
+	binary.LittleEndian.PutUint32(b[8:12], math.Float32bits(v.Z))
+}
+
+// decodeVector decodes three little-endian float32 values
+func decodeVector(b []byte) Vector3 {
+	return Vector3{
+		X: math.Float32frombits(binary.LittleEndian.Uint32(b[0:4])),
+		Y: math.Float32frombits(binary.LittleEndian.Uint32(b[4:8])),
+		Z: math.Float32frombits(binary.LittleEndian.Uint32(b[8:12])),
+	}
 }
 
 // Converter converts STL meshes to 3MF format
@@ -303,7 +369,7 @@ func (c *Converter) buildMeshXML(mesh *Mesh) (string, string) {
 
 	// Write vertices XML
 	for _, v := range vertices {
-		verticesBuf.WriteString(fmt.Sprintf("\t\t\t\t\t<vertex x=\"%.6f\" y=\"%.6f\" z=\"%.6f\"/>\n", v.X, v.Y, v.Z))
+		verticesBuf.WriteString("\t\t\t\t\t" + geometry.FormatVertex(float64(v.X), float64(v.Y), float64(v.Z)) + "\n")
 	}
 
 	// Write triangles XML
@@ -324,82 +390,144 @@ func NewWriter() *Writer {
 
 // WriteBinary writes a mesh to a binary STL file
 func (w *Writer) WriteBinary(mesh *Mesh, filename string) error {
-	file, err := os.Create(filename)
-	if err != nil {
-		return fmt.Errorf("error creating file: %w", err)
-	}
-	defer file.Close()
+	return w.write(mesh, filename, true)
+}
 
-	// Write 80-byte header
-	header := make([]byte, 80)
-	copy(header, []byte(fmt.Sprintf("Binary STL exported from %s", mesh.Name)))
-	if _, err := file.Write(header); err != nil {
-		return fmt.Errorf("error writing header: %w", err)
-	}
+// WriteASCII writes a mesh to an ASCII STL file
+func (w *Writer) WriteASCII(mesh *Mesh, filename string) error {
+	return w.write(mesh, filename, false)
+}
 
-	// Write triangle count
-	triangleCount := uint32(len(mesh.Triangles))
-	if err := binary.Write(file, binary.LittleEndian, triangleCount); err != nil {
-		return fmt.Errorf("error writing triangle count: %w", err)
+// write writes a mesh to a binary or ASCII STL file
+func (w *Writer) write(mesh *Mesh, filename string, binary bool) error {
+	stream, err := NewStreamWriter(filename, mesh.Name, binary)
+	if err != nil {
+		return err
 	}
-
-	// Write triangles
 	for _, triangle := range mesh.Triangles {
-		// Write normal
-		if err := binary.Write(file, binary.LittleEndian, triangle.Normal); err != nil {
-			return fmt.Errorf("error writing normal: %w", err)
+		if err := stream.Write(triangle); err != nil {
+			stream.Close()
+			return err
 		}
+	}
+	return stream.Close()
+}
 
-		// Write vertices
-		if err := binary.Write(file, binary.LittleEndian, triangle.V1); err != nil {
-			return fmt.Errorf("error writing vertex 1: %w", err)
-		}
-		if err := binary.Write(file, binary.LittleEndian, triangle.V2); err != nil {
-			return fmt.Errorf("error writing vertex 2: %w", err)
-		}
-		if err := binary.Write(file, binary.LittleEndian, triangle.V3); err != nil {
-			return fmt.Errorf("error writing vertex 3: %w", err)
-		}
+// StreamWriter writes the triangles of a mesh to an STL file as they are
+// produced, without holding the mesh in memory
+type StreamWriter struct {
+	file   *os.File
+	writer *bufio.Writer
+	name   string
+	binary bool
+	count  uint32
+}
 
-		// Write attribute byte count (0)
-		var attributeCount uint16 = 0
-		if err := binary.Write(file, binary.LittleEndian, attributeCount); err != nil {
-			return fmt.Errorf("error writing attribute count: %w", err)
+// NewStreamWriter creates an STL file and writes its header
+func NewStreamWriter(filename, name string, binary bool) (*StreamWriter, error) {
+	file, err := os.Create(filename)
+	if err != nil {
+		return nil, fmt.Errorf("error creating file: %w", err)
+	}
+	// Line breaks in the name would end the solid line of ASCII files
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return ' '
 		}
+		return r
+	}, name)
+	s := &StreamWriter{
+		file:   file,
+		writer: bufio.NewWriter(file),
+		name:   name,
+		binary: binary,
 	}
 
-	return nil
+	if binary {
+		// Write 80-byte header and a placeholder for the triangle count. Names
+		// are cut at a character boundary, so the header stays valid UTF-8.
+		header := make([]byte, binaryHeaderSize+4)
+		text := fmt.Sprintf("Binary STL exported from %s", name)
+		for len(text) > binaryHeaderSize {
+			_, size := utf8.DecodeLastRuneInString(text)
+			text = text[:len(text)-size]
+		}
+		copy(header[:binaryHeaderSize], text)
+		_, err = s.writer.Write(header)
+	} else {
+		_, err = fmt.Fprintf(s.writer, "solid %s\n", name)
+	}
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("error writing header: %w", err)
+	}
+	return s, nil
 }
 
-// WriteASCII writes a mesh to an ASCII STL file
-func (w *Writer) WriteASCII(mesh *Mesh, filename string) error {
-	file, err := os.Create(filename)
+// Write writes a triangle
+func (s *StreamWriter) Write(triangle Triangle) error {
+	if s.count == math.MaxUint32 {
+		return fmt.Errorf("too many triangles for an STL file")
+	}
+	s.count++
+
+	if s.binary {
+		var buf [binaryTriangleSize]byte
+		encodeTriangle(buf[:], triangle)
+		if _, err := s.writer.Write(buf[:]); err != nil {
+			return fmt.Errorf("error writing triangle: %w", err)
+		}
+		return nil
+	}
+
+	_, err := fmt.Fprintf(s.writer, "  facet normal %e %e %e\n"+
+		"    outer loop\n"+
+		"      vertex %e %e %e\n"+
+		"      vertex %e %e %e\n"+
+		"      vertex %e %e %e\n"+
+		"    endloop\n"+
+		"  endfacet\n",
+		triangle.Normal.X, triangle.Normal.Y, triangle.Normal.Z,
+		triangle.V1.X, triangle.V1.Y, triangle.V1.Z,
+		triangle.V2.X, triangle.V2.Y, triangle.V2.Z,
+		triangle.V3.X, triangle.V3.Y, triangle.V3.Z)
 	if err != nil {
-		return fmt.Errorf("error creating file: %w", err)
+		return fmt.Errorf("error writing triangle: %w", err)
 	}
-	defer file.Close()
+	return nil
+}
 
-	writer := bufio.NewWriter(file)
+// Count returns the number of triangles written
+func (s *StreamWriter) Count() int {
+	return int(s.count)
+}
 
-	// Write header
-	fmt.Fprintf(writer, "solid %s\n", mesh.Name)
+// Close writes the end of the file, or the triangle count of a binary file,
+// and closes it
+func (s *StreamWriter) Close() error {
+	err := s.finish()
+	if closeErr := s.file.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("error closing file: %w", closeErr)
+	}
+	return err
+}
 
-	// Write triangles
-	for _, triangle := range mesh.Triangles {
-		fmt.Fprintf(writer, "  facet normal %e %e %e\n",
-			triangle.Normal.X, triangle.Normal.Y, triangle.Normal.Z)
-		fmt.Fprintf(writer, "    outer loop\n")
-		fmt.Fprintf(writer, "      vertex %e %e %e\n",
-			triangle.V1.X, triangle.V1.Y, triangle.V1.Z)
-		fmt.Fprintf(writer, "      vertex %e %e %e\n",
-			triangle.V2.X, triangle.V2.Y, triangle.V2.Z)
-		fmt.Fprintf(writer, "      vertex %e %e %e\n",
-			triangle.V3.X, triangle.V3.Y, triangle.V3.Z)
-		fmt.Fprintf(writer, "    endloop\n")
-		fmt.Fprintf(writer, "  endfacet\n")
-	}
-
-	fmt.Fprintf(writer, "endsolid %s\n", mesh.Name)
-
-	return writer.Flush()
+// finish writes the end of the file and flushes it
+func (s *StreamWriter) finish() error {
+	if !s.binary {
+		if _, err := fmt.Fprintf(s.writer, "endsolid %s\n", s.name); err != nil {
+			return fmt.Errorf("error writing footer: %w", err)
+		}
+	}
+	if err := s.writer.Flush(); err != nil {
+		return fmt.Errorf("error writing file: %w", err)
+	}
+	if s.binary {
+		count := make([]byte, 4)
+		binary.LittleEndian.PutUint32(count, s.count)
+		if _, err := s.file.WriteAt(count, binaryHeaderSize); err != nil {
+			return fmt.Errorf("error writing triangle count: %w", err)
+		}
+	}
+	return nil
 }
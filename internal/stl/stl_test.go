@@ -0,0 +1,130 @@
+package stl
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/philipparndt/go3mf/internal/limits"
+)
+
+// testMesh returns a mesh with more triangles than fit into one chunk
+func testMesh() *Mesh {
+	mesh := &Mesh{Name: "test"}
+	for i := 0; i < binaryChunkTriangles+10; i++ {
+		f := float32(i)
+		mesh.Triangles = append(mesh.Triangles, Triangle{
+			Normal: Vector3{0, 0, 1},
+			V1:     Vector3{f, 0, 0},
+			V2:     Vector3{f + 1, 0.5, -2.25},
+			V3:     Vector3{f, 1, 3},
+		})
+	}
+	return mesh
+}
+
+func TestParseBinary(t *testing.T) {
+	mesh := testMesh()
+	filename := filepath.Join(t.TempDir(), "test.stl")
+	if err := NewWriter().WriteBinary(mesh, filename); err != nil {
+		t.Fatalf("WriteBinary() error = %v", err)
+	}
+
+	parsed, err := NewParser().Parse(filename)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if parsed.Name != "test.stl" {
+		t.Errorf("Name = %q, want test.stl", parsed.Name)
+	}
+	if len(parsed.Triangles) != len(mesh.Triangles) {
+		t.Fatalf("got %d triangles, want %d", len(parsed.Triangles), len(mesh.Triangles))
+	}
+	for i := range mesh.Triangles {
+		if parsed.Triangles[i] != mesh.Triangles[i] {
+			t.Fatalf("triangle %d = %v, want %v", i, parsed.Triangles[i], mesh.Triangles[i])
+		}
+	}
+}
+
+func TestParseReaderAt(t *testing.T) {
+	binary := func(count byte, triangles int) []byte {
+		data := make([]byte, binaryHeaderSize+4+triangles*binaryTriangleSize)
+		data[binaryHeaderSize] = count
+		return data
+	}
+
+	ascii := []byte("solid cube\n" +
+		"facet normal 0 0 1\nouter loop\nvertex 0 0 0\nvertex 1 0 0\nvertex 0 1 0\nendloop\nendfacet\n" +
+		"endsolid cube\n" + string(make([]byte, 80)))
+
+	tests := []struct {
+		name        string
+		data        []byte
+		maxVertices int64 // 0 = the default limits
+		triangles   int
+		wantErr     bool
+	}{
+		{"binary", binary(2, 2), 0, 2, false},
+		{"binary without triangles", binary(0, 0), 0, 0, false},
+		{"truncated triangles", binary(3, 2), 0, 0, true},
+		{"missing triangle count", make([]byte, binaryHeaderSize+2), 0, 0, true},
+		{"too short", []byte("abc"), 0, 0, true},
+		{"ascii", ascii, 0, 1, false},
+		{"binary at the vertex limit", binary(2, 2), 6, 2, false},
+		{"binary over the vertex limit", binary(2, 2), 5, 0, true},
+		{"ascii over the vertex limit", ascii, 2, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.maxVertices > 0 {
+				previous := limits.Get()
+				limits.Set(limits.Limits{MaxVertices: tt.maxVertices})
+				defer limits.Set(previous)
+			}
+			mesh, err := NewParser().ParseReaderAt(bytes.NewReader(tt.data), int64(len(tt.data)), "test.stl")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseReaderAt() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && len(mesh.Triangles) != tt.triangles {
+				t.Errorf("got %d triangles, want %d", len(mesh.Triangles), tt.triangles)
+			}
+		})
+	}
+}
+
+func TestStreamWriterNames(t *testing.T) {
+	name := "Würfel & 立方体\n" + strings.Repeat("ü", 40)
+	for _, binary := range []bool{true, false} {
+		filename := filepath.Join(t.TempDir(), "test.stl")
+		w, err := NewStreamWriter(filename, name, binary)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Write(testMesh().Triangles[0]); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if binary && !utf8.Valid(bytes.TrimRight(data[:binaryHeaderSize], "\x00")) {
+			t.Errorf("binary header %q is not valid UTF-8", data[:binaryHeaderSize])
+		}
+		mesh, err := NewParser().Parse(filename)
+		if err != nil {
+			t.Fatalf("binary=%v: Parse() error = %v", binary, err)
+		}
+		if len(mesh.Triangles) != 1 {
+			t.Errorf("binary=%v: got %d triangles, want 1", binary, len(mesh.Triangles))
+		}
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !unix
+
+package telemetry
+
+import "runtime"
+
+// peakMemory returns the memory obtained from the operating system by go3mf in
+// bytes. The memory of child processes is not available on this platform.
+func peakMemory() (self, children uint64) {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return stats.Sys, 0
+}
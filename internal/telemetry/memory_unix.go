@@ -0,0 +1,27 @@
+//go:build unix
+
+package telemetry
+
+import (
+	"runtime"
+	"syscall"
+)
+
+// peakMemory returns the peak resident memory of go3mf and of its largest
+// terminated child process (OpenSCAD) in bytes
+func peakMemory() (self, children uint64) {
+	return maxRSS(syscall.RUSAGE_SELF), maxRSS(syscall.RUSAGE_CHILDREN)
+}
+
+// maxRSS returns the peak resident memory reported by getrusage in bytes
+func maxRSS(who int) uint64 {
+	var usage syscall.Rusage
+	if err := syscall.Getrusage(who, &usage); err != nil || usage.Maxrss <= 0 {
+		return 0
+	}
+	// Linux and the BSDs report kilobytes, macOS bytes
+	if runtime.GOOS == "darwin" {
+		return uint64(usage.Maxrss)
+	}
+	return uint64(usage.Maxrss) * 1024
+}
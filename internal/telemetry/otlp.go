@@ -0,0 +1,203 @@
+package telemetry
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Exporter sends the steps of a build as OpenTelemetry spans to a collector,
+// using OTLP over HTTP with JSON encoding. A build is a root span with a child
+// span for every step.
+type Exporter struct {
+	Endpoint string // Collector URL, e.g. http://localhost:4318 (/v1/traces is appended)
+	Service  string // Service name of the spans
+	Version  string // Service version of the spans
+	client   *http.Client
+}
+
+// NewExporter creates a new Exporter
+func NewExporter(endpoint, service, version string) *Exporter {
+	return &Exporter{
+		Endpoint: endpoint,
+		Service:  service,
+		Version:  version,
+		client:   &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// Export sends a span named name with the given attributes and a child span for
+// every step. buildErr is the error of a failed build.
+func (e *Exporter) Export(name string, attributes map[string]string, steps []StepTiming, buildErr error) error {
+	if len(steps) == 0 {
+		return nil
+	}
+	body, err := json.Marshal(e.request(name, attributes, steps, buildErr))
+	if err != nil {
+		return err
+	}
+
+	resp, err := e.client.Post(e.tracesURL(), "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to export spans: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("failed to export spans: %s", resp.Status)
+	}
+	return nil
+}
+
+// tracesURL returns the URL of the traces endpoint of the collector
+func (e *Exporter) tracesURL() string {
+	url := strings.TrimRight(e.Endpoint, "/")
+	if strings.HasSuffix(url, "/v1/traces") {
+		return url
+	}
+	return url + "/v1/traces"
+}
+
+// OTLP/JSON messages (see opentelemetry-proto, trace/v1)
+type (
+	otlpRequest struct {
+		ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+	}
+	otlpResourceSpans struct {
+		Resource   otlpResource     `json:"resource"`
+		ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+	}
+	otlpResource struct {
+		Attributes []otlpAttribute `json:"attributes"`
+	}
+	otlpScopeSpans struct {
+		Scope otlpScope  `json:"scope"`
+		Spans []otlpSpan `json:"spans"`
+	}
+	otlpScope struct {
+		Name    string `json:"name"`
+		Version string `json:"version,omitempty"`
+	}
+	otlpSpan struct {
+		TraceID           string          `json:"traceId"`
+		SpanID            string          `json:"spanId"`
+		ParentSpanID      string          `json:"parentSpanId,omitempty"`
+		Name              string          `json:"name"`
+		Kind              int             `json:"kind"`
+		StartTimeUnixNano string          `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+		Attributes        []otlpAttribute `json:"attributes,omitempty"`
+		Status            otlpStatus      `json:"status"`
+	}
+	otlpAttribute struct {
+		Key   string    `json:"key"`
+		Value otlpValue `json:"value"`
+	}
+	otlpValue struct {
+		StringValue *string `json:"stringValue,omitempty"`
+		IntValue    *string `json:"intValue,omitempty"` // int64 values are strings in JSON
+	}
+	otlpStatus struct {
+		Code    int    `json:"code"` // 1 = ok, 2 = error
+		Message string `json:"message,omitempty"`
+	}
+)
+
+// spanKindInternal is the kind of spans that do not cross a process boundary
+const spanKindInternal = 1
+
+// request creates the OTLP request for a build
+func (e *Exporter) request(name string, attributes map[string]string, steps []StepTiming, buildErr error) otlpRequest {
+	traceID := randomID(16)
+	rootID := randomID(8)
+
+	last := steps[len(steps)-1]
+	root := otlpSpan{
+		TraceID:           traceID,
+		SpanID:            rootID,
+		Name:              name,
+		Kind:              spanKindInternal,
+		StartTimeUnixNano: unixNano(steps[0].Start),
+		EndTimeUnixNano:   unixNano(last.Start.Add(last.Duration)),
+		Status:            status(buildErr),
+	}
+	for _, key := range sortedKeys(attributes) {
+		root.Attributes = append(root.Attributes, stringAttribute(key, attributes[key]))
+	}
+
+	spans := []otlpSpan{root}
+	for _, step := range steps {
+		span := otlpSpan{
+			TraceID:           traceID,
+			SpanID:            randomID(8),
+			ParentSpanID:      rootID,
+			Name:              step.Name,
+			Kind:              spanKindInternal,
+			StartTimeUnixNano: unixNano(step.Start),
+			EndTimeUnixNano:   unixNano(step.Start.Add(step.Duration)),
+			Status:            status(step.Err),
+		}
+		if step.PeakMemory > 0 {
+			span.Attributes = append(span.Attributes, intAttribute("go3mf.peak_memory_bytes", step.PeakMemory))
+		}
+		if step.RenderPeakMemory > 0 {
+			span.Attributes = append(span.Attributes, intAttribute("go3mf.render_peak_memory_bytes", step.RenderPeakMemory))
+		}
+		spans = append(spans, span)
+	}
+
+	resource := []otlpAttribute{stringAttribute("service.name", e.Service)}
+	if e.Version != "" {
+		resource = append(resource, stringAttribute("service.version", e.Version))
+	}
+	return otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource:   otlpResource{Attributes: resource},
+		ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: e.Service, Version: e.Version}, Spans: spans}},
+	}}}
+}
+
+// status returns the span status of a step with the given error
+func status(err error) otlpStatus {
+	if err != nil {
+		return otlpStatus{Code: 2, Message: err.Error()}
+	}
+	return otlpStatus{Code: 1}
+}
+
+func stringAttribute(key, value string) otlpAttribute {
+	return otlpAttribute{Key: key, Value: otlpValue{StringValue: &value}}
+}
+
+func intAttribute(key string, value uint64) otlpAttribute {
+	s := strconv.FormatUint(value, 10)
+	return otlpAttribute{Key: key, Value: otlpValue{IntValue: &s}}
+}
+
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+// randomID returns a random trace or span ID of n bytes as hex
+func randomID(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// sortedKeys returns the keys of m in sorted order
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,77 @@
+package telemetry
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// StepTiming is the wall-clock duration and memory use of a build step
+type StepTiming struct {
+	Name             string
+	Start            time.Time
+	Duration         time.Duration
+	PeakMemory       uint64 // Peak memory of go3mf in bytes at the end of the step (0 = unknown)
+	RenderPeakMemory uint64 // Peak memory of the largest OpenSCAD process so far in bytes (0 = unknown)
+	Err              error  // Error of a failed step
+}
+
+// Recorder measures build steps. Steps may be measured concurrently.
+type Recorder struct {
+	Steps []StepTiming
+	mu    sync.Mutex
+}
+
+// NewRecorder creates a new Recorder
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+// Measure runs a step and records its duration and the peak memory after it
+func (r *Recorder) Measure(name string, step func() error) error {
+	start := time.Now()
+	err := step()
+	self, children := peakMemory()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Steps = append(r.Steps, StepTiming{
+		Name:             name,
+		Start:            start,
+		Duration:         time.Since(start),
+		PeakMemory:       self,
+		RenderPeakMemory: children,
+		Err:              err,
+	})
+	return err
+}
+
+// Total returns the duration from the start of the first to the end of the last step
+func (r *Recorder) Total() time.Duration {
+	if len(r.Steps) == 0 {
+		return 0
+	}
+	start, end := r.Steps[0].Start, r.Steps[0].Start
+	for _, step := range r.Steps {
+		if step.Start.Before(start) {
+			start = step.Start
+		}
+		if stepEnd := step.Start.Add(step.Duration); stepEnd.After(end) {
+			end = stepEnd
+		}
+	}
+	return end.Sub(start)
+}
+
+// FormatBytes formats a memory size in bytes for display, e.g. "12.3 MB"
+func FormatBytes(b uint64) string {
+	const unit = 1024
+	if b < unit {
+		return fmt.Sprintf("%d B", b)
+	}
+	div, exp := uint64(unit), 0
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(b)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,141 @@
+package telemetry
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRecorder(t *testing.T) {
+	r := NewRecorder()
+	failed := errors.New("failed")
+
+	r.Measure("first", func() error { return nil })
+	if err := r.Measure("second", func() error { return failed }); err != failed {
+		t.Errorf("Measure() error = %v, want the error of the step", err)
+	}
+
+	if len(r.Steps) != 2 {
+		t.Fatalf("got %d steps, want 2", len(r.Steps))
+	}
+	if r.Steps[0].Name != "first" || r.Steps[0].Err != nil {
+		t.Errorf("first step = %+v", r.Steps[0])
+	}
+	if r.Steps[1].Name != "second" || r.Steps[1].Err != failed {
+		t.Errorf("second step = %+v", r.Steps[1])
+	}
+	if r.Total() < r.Steps[0].Duration+r.Steps[1].Duration {
+		t.Errorf("Total() = %v, shorter than the steps", r.Total())
+	}
+}
+
+func TestRecorderConcurrentSteps(t *testing.T) {
+	r := NewRecorder()
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.Measure("parallel", func() error {
+				time.Sleep(10 * time.Millisecond)
+				return nil
+			})
+		}()
+	}
+	wg.Wait()
+
+	if len(r.Steps) != 8 {
+		t.Fatalf("got %d steps, want 8", len(r.Steps))
+	}
+	// The steps overlap, so the build takes less than the sum of the steps
+	var sum time.Duration
+	for _, step := range r.Steps {
+		sum += step.Duration
+		if r.Total() < step.Duration {
+			t.Errorf("Total() = %v, shorter than step %v", r.Total(), step.Duration)
+		}
+	}
+	if r.Total() >= sum {
+		t.Errorf("Total() = %v, want less than the sum of the steps %v", r.Total(), sum)
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		bytes uint64
+		want  string
+	}{
+		{512, "512 B"},
+		{2048, "2.0 KB"},
+		{5 * 1024 * 1024, "5.0 MB"},
+		{3 * 1024 * 1024 * 1024 / 2, "1.5 GB"},
+	}
+	for _, tt := range tests {
+		if got := FormatBytes(tt.bytes); got != tt.want {
+			t.Errorf("FormatBytes(%d) = %q, want %q", tt.bytes, got, tt.want)
+		}
+	}
+}
+
+func TestExport(t *testing.T) {
+	var path string
+	var request otlpRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &request); err != nil {
+			t.Errorf("invalid request: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	r := NewRecorder()
+	r.Measure("Load", func() error { return nil })
+	r.Measure("Render", func() error { return errors.New("render failed") })
+
+	exporter := NewExporter(server.URL, "go3mf", "1.0.0")
+	if err := exporter.Export("go3mf build", map[string]string{"go3mf.config": "a.yaml"}, r.Steps, errors.New("build failed")); err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+
+	if path != "/v1/traces" {
+		t.Errorf("path = %s, want /v1/traces", path)
+	}
+	spans := request.ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) != 3 {
+		t.Fatalf("got %d spans, want 3", len(spans))
+	}
+	root := spans[0]
+	if root.Name != "go3mf build" || root.ParentSpanID != "" || len(root.TraceID) != 32 || len(root.SpanID) != 16 {
+		t.Errorf("root span = %+v", root)
+	}
+	for _, span := range spans[1:] {
+		if span.ParentSpanID != root.SpanID || span.TraceID != root.TraceID {
+			t.Errorf("span %s is not a child of the root span", span.Name)
+		}
+	}
+	if root.Status.Code != 2 || root.Status.Message != "build failed" {
+		t.Errorf("status of the build = %+v", root.Status)
+	}
+	if spans[2].Status.Code != 2 || spans[2].Status.Message != "render failed" {
+		t.Errorf("status of the failed step = %+v", spans[2].Status)
+	}
+}
+
+func TestExport_Failure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	r := NewRecorder()
+	r.Measure("Load", func() error { return nil })
+	if err := NewExporter(server.URL+"/v1/traces", "go3mf", "").Export("go3mf build", nil, r.Steps, nil); err == nil {
+		t.Error("Export() should fail when the collector rejects the spans")
+	}
+}
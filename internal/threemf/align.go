@@ -0,0 +1,85 @@
+package threemf
+
+import (
+	"fmt"
+
+	"github.com/philipparndt/go3mf/internal/geometry"
+	"github.com/philipparndt/go3mf/internal/models"
+)
+
+// alignParts computes the positions of parts with alignment constraints from the
+// bounding boxes of their (rotated) meshes; meshes[i] belongs to scadFiles[i].
+// The positions of a part are added to the computed ones for fine-tuning.
+// Returns a copy of scadFiles with the resolved positions.
+func alignParts(meshes []models.Object, scadFiles []models.ScadFile, separator string) ([]models.ScadFile, error) {
+	resolved := make([]models.ScadFile, len(scadFiles))
+	copy(resolved, scadFiles)
+
+	const (
+		pending = iota
+		resolving
+		done
+	)
+	state := make([]int, len(scadFiles))
+
+	var resolve func(i int) error
+	resolve = func(i int) error {
+		switch state[i] {
+		case done:
+			return nil
+		case resolving:
+			return fmt.Errorf("%s: alignment constraints form a cycle", scadFiles[i].Name)
+		}
+		state[i] = resolving
+		defer func() { state[i] = done }()
+
+		align := scadFiles[i].Align
+		if align == nil || i >= len(meshes) {
+			return nil
+		}
+		target := findPart(scadFiles, i, align.OnTopOf, separator)
+		if target < 0 || target >= len(meshes) {
+			return fmt.Errorf("%s: part %q to align with not found", scadFiles[i].Name, align.OnTopOf)
+		}
+		if err := resolve(target); err != nil {
+			return err
+		}
+
+		targetBox, err := geometry.CalculateBoundingBox(&meshes[target])
+		if err != nil {
+			return fmt.Errorf("%s: %w", scadFiles[target].Name, err)
+		}
+		partBox, err := geometry.CalculateBoundingBox(&meshes[i])
+		if err != nil {
+			return fmt.Errorf("%s: %w", scadFiles[i].Name, err)
+		}
+
+		part := &resolved[i]
+		base := resolved[target]
+		part.PositionZ += base.PositionZ + targetBox.MaxZ + align.Gap - partBox.MinZ
+		if align.Centered {
+			part.PositionX += base.PositionX + (targetBox.MinX+targetBox.MaxX)/2 - (partBox.MinX+partBox.MaxX)/2
+			part.PositionY += base.PositionY + (targetBox.MinY+targetBox.MaxY)/2 - (partBox.MinY+partBox.MaxY)/2
+		}
+		return nil
+	}
+
+	for i := range scadFiles {
+		if err := resolve(i); err != nil {
+			return nil, err
+		}
+	}
+	return resolved, nil
+}
+
+// findPart returns the index of the part with the given name in the same object as
+// scadFiles[index], or -1. Parts of multi-part objects are named "object/part".
+func findPart(scadFiles []models.ScadFile, index int, partName, separator string) int {
+	objectName := scadFiles[index].ObjectName(separator)
+	for i, scadFile := range scadFiles {
+		if i != index && scadFile.ObjectName(separator) == objectName && scadFile.Name == objectName+"/"+partName {
+			return i
+		}
+	}
+	return -1
+}
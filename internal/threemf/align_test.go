@@ -0,0 +1,61 @@
+package threemf
+
+import (
+	"testing"
+
+	"github.com/philipparndt/go3mf/internal/models"
+)
+
+func TestFindPart(t *testing.T) {
+	scadFiles := []models.ScadFile{
+		{Name: "tools/wrench/head", Group: "tools/wrench"},
+		{Name: "tools/wrench/handle", Group: "tools/wrench"},
+		{Name: "tools/hammer/head", Group: "tools/hammer"},
+		{Name: "box/lid"},
+		{Name: "box/base"},
+		{Name: "box-lid"},
+		{Name: "box-base"},
+	}
+
+	tests := []struct {
+		name      string
+		index     int
+		part      string
+		separator string
+		want      int
+	}{
+		{"explicit group with slashes", 1, "head", "/", 0},
+		{"other group", 2, "handle", "/", -1},
+		{"name before separator", 3, "base", "/", 4},
+		{"custom separator", 5, "base", "-", -1},
+		{"no grouping", 3, "base", "", -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := findPart(scadFiles, tt.index, tt.part, tt.separator); got != tt.want {
+				t.Errorf("findPart() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestObjectName(t *testing.T) {
+	tests := []struct {
+		file      models.ScadFile
+		separator string
+		want      string
+	}{
+		{models.ScadFile{Name: "box/lid"}, models.DefaultGroupSeparator, "box"},
+		{models.ScadFile{Name: "box"}, models.DefaultGroupSeparator, "box"},
+		{models.ScadFile{Name: "box-lid"}, "-", "box"},
+		{models.ScadFile{Name: "box/lid"}, "", "box/lid"},
+		{models.ScadFile{Name: "tools/wrench/head", Group: "tools/wrench"}, models.DefaultGroupSeparator, "tools/wrench"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.file.ObjectName(tt.separator); got != tt.want {
+			t.Errorf("ObjectName(%q) of %q = %q, want %q", tt.separator, tt.file.Name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,309 @@
+package threemf
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/philipparndt/go3mf/internal/limits"
+	"github.com/philipparndt/go3mf/internal/models"
+)
+
+// ProjectSettingsFile is the archive entry holding the Bambu Studio project settings
+const ProjectSettingsFile = "Metadata/project_settings.config"
+
+// Defaults for filament slots that a settings file does not describe completely
+const (
+	defaultFilamentColor   = "#FFFFFF"
+	defaultFilamentType    = "PLA"
+	defaultFilamentProfile = "Generic PLA"
+)
+
+// ApplySettings writes filaments, print settings and metadata into an existing 3MF.
+// Only the project settings and the metadata of the model are rewritten; all other
+// entries, including the geometry, are copied unchanged. inputFile and outputFile
+// may be the same file.
+func ApplySettings(inputFile, outputFile string, settings *models.YamlSettings) error {
+	zr, err := limits.OpenZip(inputFile)
+	if err != nil {
+		return fmt.Errorf("error opening ZIP: %w", err)
+	}
+	defer zr.Close()
+
+	return replaceFile(outputFile, func(w io.Writer) error {
+		return applySettings(&zr.Reader, w, settings)
+	})
+}
+
+// replaceFile writes path through a temporary file next to it and renames it,
+// so a failure leaves an existing file untouched and the input of a command may
+// be replaced safely. The file keeps the mode of the file it replaces, new files
+// get 0644.
+func replaceFile(path string, write func(io.Writer) error) error {
+	mode := os.FileMode(0o644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("error creating output file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	// Temporary files are private, outputs are not
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error creating output file: %w", err)
+	}
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("error writing output file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("error writing output file: %w", err)
+	}
+	return nil
+}
+
+// applySettings copies the archive to w, patching the model metadata and project settings
+func applySettings(zr *zip.Reader, w io.Writer, settings *models.YamlSettings) error {
+	patchProject := len(settings.Filaments) > 0 || len(settings.PrintSettings) > 0
+	patchModel := len(settings.Metadata) > 0
+
+	outZip := zip.NewWriter(w)
+	foundModel := false
+	foundProject := false
+	for _, file := range zr.File {
+		var patch func([]byte) ([]byte, error)
+		switch {
+		case file.Name == "3D/3dmodel.model":
+			foundModel = true
+			if patchModel {
+				patch = func(data []byte) ([]byte, error) { return setModelMetadata(data, settings.Metadata) }
+			}
+		case file.Name == ProjectSettingsFile && patchProject:
+			foundProject = true
+			patch = func(data []byte) ([]byte, error) { return mergeProjectSettings(data, settings) }
+		}
+
+		if patch == nil {
+			// Copy without recompressing, so the entry stays byte for byte identical
+			if err := outZip.Copy(file); err != nil {
+				return fmt.Errorf("error copying %s: %w", file.Name, err)
+			}
+			continue
+		}
+		if err := patchEntry(outZip, file, patch); err != nil {
+			return err
+		}
+	}
+
+	if !foundModel {
+		return fmt.Errorf("3D/3dmodel.model not found in archive")
+	}
+	if patchProject && !foundProject {
+		data, err := mergeProjectSettings(nil, settings)
+		if err != nil {
+			return err
+		}
+		if err := writeEntry(outZip, ProjectSettingsFile, data); err != nil {
+			return err
+		}
+	}
+	return outZip.Close()
+}
+
+// patchEntry writes a modified copy of an archive entry
+func patchEntry(outZip *zip.Writer, file *zip.File, patch func([]byte) ([]byte, error)) error {
+	rc, err := file.Open()
+	if err != nil {
+		return fmt.Errorf("error opening %s: %w", file.Name, err)
+	}
+	data, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		return fmt.Errorf("error reading %s: %w", file.Name, err)
+	}
+
+	patched, err := patch(data)
+	if err != nil {
+		return fmt.Errorf("error updating %s: %w", file.Name, err)
+	}
+	return writeEntry(outZip, file.Name, patched)
+}
+
+// writeEntry writes a compressed archive entry
+func writeEntry(outZip *zip.Writer, name string, data []byte) error {
+	dst, err := outZip.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: time.Now()})
+	if err != nil {
+		return fmt.Errorf("error creating ZIP entry: %w", err)
+	}
+	if _, err := dst.Write(data); err != nil {
+		return fmt.Errorf("error writing %s: %w", name, err)
+	}
+	return nil
+}
+
+// setModelMetadata replaces the metadata elements of the model with the given names
+// (empty values remove them) and leaves the rest of the document untouched. Only the
+// part before the resources is parsed, as metadata precedes them.
+func setModelMetadata(data []byte, metadata map[string]string) ([]byte, error) {
+	type span struct{ start, end int64 }
+	var remove []span
+	insertAt := int64(-1)
+	removeStart := int64(-1)
+	depth := 0
+
+	dec := xml.NewDecoder(bytes.NewReader(data))
+parse:
+	for {
+		offset := dec.InputOffset()
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error parsing XML: %w", err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			depth++
+			if depth == 1 {
+				insertAt = dec.InputOffset()
+			}
+			if depth == 2 && t.Name.Local == "resources" {
+				break parse
+			}
+			if depth == 2 && t.Name.Local == "metadata" {
+				for _, attr := range t.Attr {
+					if _, ok := metadata[attr.Value]; ok && attr.Name.Local == "name" {
+						removeStart = offset
+					}
+				}
+			}
+		case xml.EndElement:
+			if depth == 2 && removeStart >= 0 {
+				remove = append(remove, span{trimIndent(data, removeStart), dec.InputOffset()})
+				removeStart = -1
+			}
+			depth--
+		}
+	}
+	if insertAt < 0 {
+		return nil, fmt.Errorf("model element not found")
+	}
+
+	names := make([]string, 0, len(metadata))
+	for name, value := range metadata {
+		if value != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var inserted bytes.Buffer
+	for _, name := range names {
+		inserted.WriteString("\n\t<metadata name=\"")
+		xml.EscapeText(&inserted, []byte(name))
+		inserted.WriteString("\">")
+		xml.EscapeText(&inserted, []byte(metadata[name]))
+		inserted.WriteString("</metadata>")
+	}
+
+	var out bytes.Buffer
+	out.Grow(len(data) + inserted.Len())
+	out.Write(data[:insertAt])
+	out.Write(inserted.Bytes())
+	pos := insertAt
+	for _, r := range remove {
+		out.Write(data[pos:r.start])
+		pos = r.end
+	}
+	out.Write(data[pos:])
+	return out.Bytes(), nil
+}
+
+// trimIndent moves an element start back over its indentation and line break
+func trimIndent(data []byte, start int64) int64 {
+	for start > 0 && (data[start-1] == ' ' || data[start-1] == '\t') {
+		start--
+	}
+	if start > 0 && data[start-1] == '\n' {
+		start--
+		if start > 0 && data[start-1] == '\r' {
+			start--
+		}
+	}
+	return start
+}
+
+// mergeProjectSettings sets the filaments and print settings in the JSON project
+// settings (data may be empty) and keeps all other keys
+func mergeProjectSettings(data []byte, settings *models.YamlSettings) ([]byte, error) {
+	project := map[string]interface{}{}
+	if len(bytes.TrimSpace(data)) > 0 {
+		if err := json.Unmarshal(data, &project); err != nil {
+			return nil, fmt.Errorf("error parsing project settings: %w", err)
+		}
+	}
+
+	if len(settings.Filaments) > 0 {
+		colors := make([]string, len(settings.Filaments))
+		types := make([]string, len(settings.Filaments))
+		profiles := make([]string, len(settings.Filaments))
+		for i, filament := range settings.Filaments {
+			colors[i] = filamentValue(filament.Color, project["filament_colour"], i, defaultFilamentColor)
+			types[i] = filamentValue(filament.Type, project["filament_type"], i, defaultFilamentType)
+			profiles[i] = filamentValue(filament.Profile, project["filament_settings_id"], i, defaultFilamentProfile)
+		}
+		project["filament_colour"] = colors
+		project["filament_type"] = types
+		project["filament_settings_id"] = profiles
+	}
+
+	// Bambu Studio stores all values as strings
+	for key, value := range settings.PrintSettings {
+		if list, ok := value.([]interface{}); ok {
+			values := make([]string, len(list))
+			for i, item := range list {
+				values[i] = fmt.Sprint(item)
+			}
+			project[key] = values
+		} else {
+			project[key] = fmt.Sprint(value)
+		}
+	}
+
+	out, err := json.MarshalIndent(project, "", "    ")
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling project settings: %w", err)
+	}
+	return append(out, '\n'), nil
+}
+
+// filamentValue returns the configured value of a filament slot, or else the value
+// the project already has for the slot, or else the default
+func filamentValue(value string, existing interface{}, slot int, def string) string {
+	if value != "" {
+		return value
+	}
+	if list, ok := existing.([]interface{}); ok && slot < len(list) {
+		if s, ok := list[slot].(string); ok && strings.TrimSpace(s) != "" {
+			return s
+		}
+	}
+	return def
+}
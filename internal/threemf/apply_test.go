@@ -0,0 +1,82 @@
+package threemf
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestReplaceFile(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file modes are not kept on Windows")
+	}
+	tests := []struct {
+		name     string
+		existing os.FileMode // 0 = no file to replace
+		want     os.FileMode
+	}{
+		{name: "new file", want: 0o644},
+		{name: "replaced file", existing: 0o640, want: 0o640},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "out.3mf")
+			if tt.existing != 0 {
+				if err := os.WriteFile(path, []byte("old"), tt.existing); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.Chmod(path, tt.existing); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err := replaceFile(path, func(w io.Writer) error {
+				_, err := w.Write([]byte("new"))
+				return err
+			})
+			if err != nil {
+				t.Fatalf("replaceFile() error = %v", err)
+			}
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := info.Mode().Perm(); got != tt.want {
+				t.Errorf("mode = %v, want %v", got, tt.want)
+			}
+			if data, _ := os.ReadFile(path); string(data) != "new" {
+				t.Errorf("content = %q, want %q", data, "new")
+			}
+		})
+	}
+}
+
+func TestReplaceFileError(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "out.3mf")
+	if err := os.WriteFile(path, []byte("old"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	failed := errors.New("failed")
+	err := replaceFile(path, func(w io.Writer) error {
+		w.Write([]byte("partial"))
+		return failed
+	})
+	if !errors.Is(err, failed) {
+		t.Fatalf("replaceFile() error = %v, want %v", err, failed)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "old" {
+		t.Errorf("content = %q after the error, want the file untouched", data)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("%d files in the directory, want the temporary file removed", len(entries))
+	}
+}
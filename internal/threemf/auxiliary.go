@@ -0,0 +1,243 @@
+package threemf
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/philipparndt/go3mf/internal/limits"
+	"github.com/philipparndt/go3mf/internal/models"
+)
+
+// CopyAuxiliaryFiles copies the archive entries besides the model from the
+// source files to the output according to the policy. Entries in skip are
+// written by the caller. The package entries ([Content_Types].xml, the package
+// relationships and everything below 3D/) are always taken from the first
+// source only, the policy applies to the other entries. The settings file of
+// the policy comes before the sources, as if it was the first of them.
+func CopyAuxiliaryFiles(outZip *zip.Writer, sourceFiles []string, policy models.AuxiliaryPolicy, skip ...string) error {
+	return Archives(nil).copyAuxiliaryFiles(outZip, sourceFiles, policy, skip...)
+}
+
+// copyAuxiliaryFiles copies the auxiliary files like CopyAuxiliaryFiles, taking
+// the source files held in memory from the archives
+func (a Archives) copyAuxiliaryFiles(outZip *zip.Writer, sourceFiles []string, policy models.AuxiliaryPolicy, skip ...string) error {
+	written := make(map[string]bool, len(skip))
+	for _, name := range skip {
+		written[name] = true
+	}
+	namespaces := make(map[string]bool)
+
+	first := 0 // Position of the first source among the files the entries are copied from
+	if policy.Settings != "" {
+		if err := a.copyEntries(outZip, policy.Settings, func(name string) (string, bool) {
+			return name, !isPackageEntry(name) && policy.Matches(name)
+		}, written); err != nil {
+			return fmt.Errorf("error copying entries of %s: %w", filepath.Base(policy.Settings), err)
+		}
+		first = 1
+	}
+
+	for i, sourceFile := range uniqueFiles(sourceFiles) {
+		merge := i+first == 0 || policy.Merge == models.AuxiliaryMergeAll || policy.Merge == models.AuxiliaryMergeNamespace
+		if i > 0 && !merge {
+			break
+		}
+		// The entries of a source used as settings file are copied already
+		if filepath.Clean(sourceFile) == filepath.Clean(policy.Settings) {
+			merge = false
+		}
+
+		prefix := ""
+		if i+first > 0 && policy.Merge == models.AuxiliaryMergeNamespace {
+			prefix = namespace(sourceFile, namespaces) + "/"
+		}
+
+		if err := a.copyEntries(outZip, sourceFile, func(name string) (string, bool) {
+			if isPackageEntry(name) {
+				return name, i == 0
+			}
+			return prefix + name, merge && policy.Matches(name)
+		}, written); err != nil {
+			return fmt.Errorf("error copying entries of %s: %w", filepath.Base(sourceFile), err)
+		}
+	}
+	return nil
+}
+
+// copyEntries copies the entries of a source file to the output. target returns
+// the name of an entry in the output and whether it is copied; entries already
+// written are skipped.
+func (a Archives) copyEntries(outZip *zip.Writer, sourceFile string, target func(string) (string, bool), written map[string]bool) error {
+	sourceZip, closeZip, err := a.open(sourceFile)
+	if err != nil {
+		return fmt.Errorf("error opening source ZIP: %w", err)
+	}
+	defer closeZip()
+
+	for _, file := range sourceZip.File {
+		name, ok := target(file.Name)
+		if !ok || written[name] {
+			continue
+		}
+		written[name] = true
+
+		srcFile, err := file.Open()
+		if err != nil {
+			return fmt.Errorf("error opening source file: %w", err)
+		}
+
+		dst, err := outZip.Create(name)
+		if err != nil {
+			srcFile.Close()
+			return fmt.Errorf("error creating ZIP entry: %w", err)
+		}
+
+		if _, err := io.Copy(dst, srcFile); err != nil {
+			srcFile.Close()
+			return fmt.Errorf("error copying file: %w", err)
+		}
+
+		srcFile.Close()
+	}
+	return nil
+}
+
+// isPackageEntry reports whether an entry belongs to the structure or the model
+// of the 3MF package rather than being auxiliary
+func isPackageEntry(name string) bool {
+	return name == "[Content_Types].xml" || strings.HasPrefix(name, "_rels/") || strings.HasPrefix(name, "3D/")
+}
+
+// namespace returns the folder for the entries of a source file: its name
+// without extension, numbered if several sources have the same name
+func namespace(sourceFile string, used map[string]bool) string {
+	base := strings.TrimSuffix(filepath.Base(sourceFile), filepath.Ext(sourceFile))
+	name := base
+	for n := 2; used[name]; n++ {
+		name = base + "_" + strconv.Itoa(n)
+	}
+	used[name] = true
+	return name
+}
+
+// uniqueFiles returns the files without duplicates, e.g. a 3MF file used for
+// several objects
+func uniqueFiles(files []string) []string {
+	seen := make(map[string]bool, len(files))
+	var result []string
+	for _, file := range files {
+		if !seen[file] {
+			seen[file] = true
+			result = append(result, file)
+		}
+	}
+	return result
+}
+
+// contentTypes is the [Content_Types].xml entry of a 3MF file
+type contentTypes struct {
+	XMLName   xml.Name      `xml:"http://schemas.openxmlformats.org/package/2006/content-types Types"`
+	Defaults  []contentType `xml:"Default"`
+	Overrides []contentType `xml:"Override"`
+}
+
+// contentType is the content type of a file extension (Default) or of a single entry (Override)
+type contentType struct {
+	Extension   string `xml:"Extension,attr,omitempty"`
+	PartName    string `xml:"PartName,attr,omitempty"`
+	ContentType string `xml:"ContentType,attr"`
+}
+
+// AddEntries adds entries to an existing 3MF file, e.g. images. Entries of the
+// same name are replaced and the content types of new file extensions are
+// declared. All other entries are copied unchanged.
+func AddEntries(file string, entries map[string][]byte) error {
+	zr, err := limits.OpenZip(file)
+	if err != nil {
+		return fmt.Errorf("error opening ZIP: %w", err)
+	}
+	defer zr.Close()
+
+	return replaceFile(file, func(w io.Writer) error {
+		return addEntries(&zr.Reader, w, entries)
+	})
+}
+
+// addEntries copies the archive to w with entries added or replaced
+func addEntries(zr *zip.Reader, w io.Writer, entries map[string][]byte) error {
+	outZip := zip.NewWriter(w)
+	for _, f := range zr.File {
+		if _, ok := entries[f.Name]; ok {
+			continue
+		}
+		if f.Name == "[Content_Types].xml" {
+			if err := patchEntry(outZip, f, func(data []byte) ([]byte, error) { return declareContentTypes(data, entries) }); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := outZip.Copy(f); err != nil {
+			return fmt.Errorf("error copying %s: %w", f.Name, err)
+		}
+	}
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := writeEntry(outZip, name, entries[name]); err != nil {
+			return err
+		}
+	}
+	if err := outZip.Close(); err != nil {
+		return fmt.Errorf("error writing output file: %w", err)
+	}
+	return nil
+}
+
+// declareContentTypes adds the content types of the file extensions of entries
+// that are not declared yet
+func declareContentTypes(data []byte, entries map[string][]byte) ([]byte, error) {
+	var types contentTypes
+	if err := xml.Unmarshal(data, &types); err != nil {
+		return nil, err
+	}
+	declared := make(map[string]bool)
+	for _, t := range types.Defaults {
+		declared[strings.ToLower(t.Extension)] = true
+	}
+	var extensions []string
+	for name := range entries {
+		ext := strings.ToLower(strings.TrimPrefix(path.Ext(name), "."))
+		if ext != "" && !declared[ext] {
+			declared[ext] = true
+			extensions = append(extensions, ext)
+		}
+	}
+	if len(extensions) == 0 {
+		return data, nil
+	}
+	sort.Strings(extensions)
+	for _, ext := range extensions {
+		t := mime.TypeByExtension("." + ext)
+		if t == "" {
+			t = "application/octet-stream"
+		}
+		types.Defaults = append(types.Defaults, contentType{Extension: ext, ContentType: t})
+	}
+
+	out, err := xml.MarshalIndent(types, "", "\t")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), out...), nil
+}
@@ -0,0 +1,233 @@
+package threemf
+
+import (
+	"archive/zip"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/philipparndt/go3mf/internal/models"
+)
+
+// writeArchive writes a ZIP file whose entries contain the archive and entry name
+func writeArchive(t *testing.T, dir, name string, entries ...string) string {
+	t.Helper()
+	file := filepath.Join(dir, name)
+	f, err := os.Create(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	for _, entry := range entries {
+		w, err := zw.Create(entry)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(name + ":" + entry))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestCopyAuxiliaryFiles(t *testing.T) {
+	dir := t.TempDir()
+	first := writeArchive(t, dir, "first.3mf", "[Content_Types].xml", "_rels/.rels", "3D/3dmodel.model",
+		"Metadata/model_settings.config", "Metadata/plate_1.png", "Metadata/custom.json")
+	second := writeArchive(t, dir, "second.3mf", "[Content_Types].xml", "_rels/.rels", "3D/3dmodel.model",
+		"3D/Objects/object_1.model", "Metadata/plate_1.png", "Metadata/notes.txt")
+	template := writeArchive(t, dir, "template.3mf", "[Content_Types].xml", "3D/3dmodel.model",
+		"Metadata/project_settings.config", "Metadata/plate_1.png")
+	sources := []string{first, second, first}
+
+	tests := []struct {
+		name   string
+		policy models.AuxiliaryPolicy
+		want   map[string]string
+	}{
+		{
+			name:   "first",
+			policy: models.AuxiliaryPolicy{Merge: models.AuxiliaryMergeFirst},
+			want: map[string]string{
+				"[Content_Types].xml":  "first.3mf",
+				"_rels/.rels":          "first.3mf",
+				"Metadata/plate_1.png": "first.3mf",
+				"Metadata/custom.json": "first.3mf",
+			},
+		},
+		{
+			name:   "all",
+			policy: models.AuxiliaryPolicy{Merge: models.AuxiliaryMergeAll},
+			want: map[string]string{
+				"[Content_Types].xml":  "first.3mf",
+				"_rels/.rels":          "first.3mf",
+				"Metadata/plate_1.png": "first.3mf",
+				"Metadata/custom.json": "first.3mf",
+				"Metadata/notes.txt":   "second.3mf",
+			},
+		},
+		{
+			name:   "namespace",
+			policy: models.AuxiliaryPolicy{Merge: models.AuxiliaryMergeNamespace},
+			want: map[string]string{
+				"[Content_Types].xml":         "first.3mf",
+				"_rels/.rels":                 "first.3mf",
+				"Metadata/plate_1.png":        "first.3mf",
+				"Metadata/custom.json":        "first.3mf",
+				"second/Metadata/plate_1.png": "second.3mf",
+				"second/Metadata/notes.txt":   "second.3mf",
+			},
+		},
+		{
+			name:   "settings from template",
+			policy: models.AuxiliaryPolicy{Settings: template},
+			want: map[string]string{
+				"[Content_Types].xml":              "first.3mf",
+				"_rels/.rels":                      "first.3mf",
+				"Metadata/project_settings.config": "template.3mf",
+				"Metadata/plate_1.png":             "template.3mf",
+			},
+		},
+		{
+			name:   "settings from template with namespaces",
+			policy: models.AuxiliaryPolicy{Merge: models.AuxiliaryMergeNamespace, Settings: template},
+			want: map[string]string{
+				"[Content_Types].xml":                  "first.3mf",
+				"_rels/.rels":                          "first.3mf",
+				"Metadata/project_settings.config":     "template.3mf",
+				"Metadata/plate_1.png":                 "template.3mf",
+				"first/Metadata/plate_1.png":           "first.3mf",
+				"first/Metadata/custom.json":           "first.3mf",
+				"first/Metadata/model_settings.config": "first.3mf",
+				"second/Metadata/plate_1.png":          "second.3mf",
+				"second/Metadata/notes.txt":            "second.3mf",
+			},
+		},
+		{
+			name:   "settings from second input",
+			policy: models.AuxiliaryPolicy{Merge: models.AuxiliaryMergeAll, Settings: second},
+			want: map[string]string{
+				"[Content_Types].xml":  "first.3mf",
+				"_rels/.rels":          "first.3mf",
+				"Metadata/plate_1.png": "second.3mf",
+				"Metadata/notes.txt":   "second.3mf",
+				"Metadata/custom.json": "first.3mf",
+			},
+		},
+		{
+			name: "include and exclude",
+			policy: models.AuxiliaryPolicy{
+				Merge:   models.AuxiliaryMergeAll,
+				Include: []string{"Metadata/*"},
+				Exclude: []string{"*/*.png"},
+			},
+			want: map[string]string{
+				"[Content_Types].xml":  "first.3mf",
+				"_rels/.rels":          "first.3mf",
+				"Metadata/custom.json": "first.3mf",
+				"Metadata/notes.txt":   "second.3mf",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := filepath.Join(t.TempDir(), "out.3mf")
+			f, err := os.Create(output)
+			if err != nil {
+				t.Fatal(err)
+			}
+			zw := zip.NewWriter(f)
+			if err := CopyAuxiliaryFiles(zw, sources, tt.policy, "3D/3dmodel.model", "Metadata/model_settings.config"); err != nil {
+				t.Fatalf("CopyAuxiliaryFiles() error = %v", err)
+			}
+			zw.Close()
+			f.Close()
+
+			zr, err := zip.OpenReader(output)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer zr.Close()
+			got := make(map[string]string)
+			for _, file := range zr.File {
+				rc, err := file.Open()
+				if err != nil {
+					t.Fatal(err)
+				}
+				data, _ := io.ReadAll(rc)
+				rc.Close()
+				source, _, _ := strings.Cut(string(data), ":")
+				got[file.Name] = source
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("entries = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddEntries(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "build.3mf")
+	f, err := os.Create(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	for _, entry := range []struct{ name, content string }{
+		{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+	<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+	<Default Extension="model" ContentType="application/vnd.ms-package.3dmanufacturing-3dmodel+xml"/>
+</Types>`},
+		{"3D/3dmodel.model", "model"},
+		{"Auxiliaries/thumbnails/a.png", "old"},
+	} {
+		w, err := zw.Create(entry.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(entry.content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	if err := AddEntries(file, map[string][]byte{
+		"Auxiliaries/thumbnails/a.png": []byte("new"),
+		"Auxiliaries/thumbnails/b.png": []byte("b"),
+	}); err != nil {
+		t.Fatalf("AddEntries() error = %v", err)
+	}
+
+	zr, err := zip.OpenReader(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+	entries := make(map[string]string)
+	var names []string
+	for _, f := range zr.File {
+		rc, _ := f.Open()
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		entries[f.Name] = string(data)
+		names = append(names, f.Name)
+	}
+	want := []string{"[Content_Types].xml", "3D/3dmodel.model", "Auxiliaries/thumbnails/a.png", "Auxiliaries/thumbnails/b.png"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("entries = %v, want %v", names, want)
+	}
+	if entries["3D/3dmodel.model"] != "model" || entries["Auxiliaries/thumbnails/a.png"] != "new" {
+		t.Errorf("model = %q, a.png = %q, want the model unchanged and the image replaced", entries["3D/3dmodel.model"], entries["Auxiliaries/thumbnails/a.png"])
+	}
+	if types := entries["[Content_Types].xml"]; !strings.Contains(types, `<Default Extension="png" ContentType="image/png"></Default>`) || !strings.Contains(types, `Extension="model"`) {
+		t.Errorf("content types should declare png and keep the others:\n%s", types)
+	}
+}
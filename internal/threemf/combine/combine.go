@@ -4,28 +4,65 @@ import (
 	"archive/zip"
 	"encoding/xml"
 	"fmt"
-	"io"
 	"os"
 	"path/filepath"
 	"strconv"
 	"time"
 
+	"github.com/philipparndt/go3mf/internal/geometry"
+	"github.com/philipparndt/go3mf/internal/limits"
 	"github.com/philipparndt/go3mf/internal/models"
+	"github.com/philipparndt/go3mf/internal/threemf"
 )
 
 // Combiner combines multiple 3MF files without rendering
-type Combiner struct{}
+type Combiner struct {
+	auxiliary  models.AuxiliaryPolicy // Entries of the input files copied besides the model
+	warnings   []string               // Problems found in the input files of the last combine
+	ignoreExt  bool                   // Combine inputs requiring unsupported extensions instead of failing
+	compactXML bool                   // Write the model XML without indentation
+	flatten    bool                   // Merge objects made of components into one mesh each
+}
 
 // NewCombiner creates a new 3MF combiner
 func NewCombiner() *Combiner {
 	return &Combiner{}
 }
 
+// SetAuxiliaryPolicy sets which auxiliary archive entries of the input files are
+// copied to the output
+func (c *Combiner) SetAuxiliaryPolicy(policy models.AuxiliaryPolicy) {
+	c.auxiliary = policy
+}
+
+// SetCompactXML writes the model XML without indentation
+func (c *Combiner) SetCompactXML(compact bool) {
+	c.compactXML = compact
+}
+
+// SetFlatten merges the objects of the inputs that are made of components,
+// possibly nested, into one mesh each
+func (c *Combiner) SetFlatten(flatten bool) {
+	c.flatten = flatten
+}
+
+// SetIgnoreExtensions sets the ignore flag of threemf.CheckRequiredExtensions for the inputs
+func (c *Combiner) SetIgnoreExtensions(ignore bool) {
+	c.ignoreExt = ignore
+}
+
+// Warnings returns the problems found in the input files of the last combine,
+// e.g. objects that were skipped because they cannot be combined
+func (c *Combiner) Warnings() []string {
+	return c.warnings
+}
+
 // Combine combines multiple 3MF files into one
 func (c *Combiner) Combine(inputFiles []string, outputFile string) error {
 	if len(inputFiles) < 2 {
 		return fmt.Errorf("at least 2 files required for combining")
 	}
+	c.warnings = nil
 
 	var allObjects []models.Object
 	var scadFiles []models.ScadFile
@@ -40,20 +77,34 @@ func (c *Combiner) Combine(inputFiles []string, outputFile string) error {
 		// Get name from filename
 		name := filepath.Base(inputFile[:len(inputFile)-len(filepath.Ext(inputFile))])
 
-		// Collect mesh objects
+		// Part names and filament slots of Bambu Studio inputs are kept
+		sourceParts, err := threemf.ReadSourceParts(inputFile, model)
+		if err != nil {
+			c.warnings = append(c.warnings, fmt.Sprintf("%s: model settings ignored: %v", filepath.Base(inputFile), err))
+		}
+
+		// Collect mesh objects, numbered in order as files may have had objects skipped
 		for _, obj := range model.Resources.Objects {
-			obj.ID = strconv.Itoa(i + 1)
-			obj.Name = name
+			part := sourceParts[obj.ID]
+			if part.Name == "" {
+				part.Name = name
+			}
+			obj.ID = strconv.Itoa(len(allObjects) + 1)
+			obj.Name = part.Name
 			obj.UUID = "" // Will be set in components
 			allObjects = append(allObjects, obj)
-		}
 
-		// Create ScadFile entry for settings (auto-assign filament)
-		scadFiles = append(scadFiles, models.ScadFile{
-			Path:         inputFile,
-			Name:         name,
-			FilamentSlot: 0, // Auto-assign
-		})
+			// Create ScadFile entry for settings (0 = auto-assign filament)
+			scadFiles = append(scadFiles, models.ScadFile{
+				Path:         inputFile,
+				Name:         part.Name,
+				FilamentSlot: part.Extruder,
+				Matrix:       part.Matrix,
+			})
+		}
+	}
+	if len(allObjects) == 0 {
+		return fmt.Errorf("the input files contain no mesh objects to combine")
 	}
 
 	// Create a parent object with components
@@ -63,7 +114,7 @@ func (c *Combiner) Combine(inputFiles []string, outputFile string) error {
 	for i := range allObjects {
 		// Position objects along the X axis with spacing
 		xOffset := float64(i) * spacing
-		transform := fmt.Sprintf("1 0 0 0 1 0 0 0 1 %.2f 0 0", xOffset)
+		transform := geometry.BuildTranslationTransform(xOffset, 0, 0)
 
 		components = append(components, models.Component{
 			ObjectID:  strconv.Itoa(i + 1),
@@ -74,7 +125,7 @@ func (c *Combiner) Combine(inputFiles []string, outputFile string) error {
 	parentID := strconv.Itoa(len(allObjects) + 1)
 	parentObject := models.Object{
 		ID:   parentID,
-		Type: "model",
+		Type: threemf.ParentType(allObjects),
 		Components: &models.Components{
 			Component: components,
 		},
@@ -100,12 +151,12 @@ func (c *Combiner) Combine(inputFiles []string, outputFile string) error {
 	}
 
 	// Write combined model
-	return c.writeModelBambu(outputFile, combinedModel, inputFiles[0], scadFiles)
+	return c.writeModelBambu(outputFile, combinedModel, inputFiles, scadFiles)
 }
 
 // readModel reads and parses a 3MF file
 func (c *Combiner) readModel(filename string) (*models.Model, string, error) {
-	zr, err := zip.OpenReader(filename)
+	zr, err := limits.OpenZip(filename)
 	if err != nil {
 		return nil, "", fmt.Errorf("error opening file: %w", err)
 	}
@@ -129,31 +180,36 @@ func (c *Combiner) readModel(filename string) (*models.Model, string, error) {
 	}
 	defer rc.Close()
 
-	data, err := io.ReadAll(rc)
+	// Unsupported required extensions and objects made of slice stacks or beam
+	// lattices only cannot be combined
+	model, ext, err := threemf.DecodeModel(rc)
 	if err != nil {
-		return nil, "", fmt.Errorf("error reading model file: %w", err)
+		return nil, "", err
 	}
-
-	var model models.Model
-	if err := xml.Unmarshal(data, &model); err != nil {
-		return nil, "", fmt.Errorf("error parsing XML: %w", err)
+	warning, err := threemf.CheckRequiredExtensions(ext, filepath.Base(filename), c.ignoreExt)
+	if err != nil {
+		return nil, "", err
+	}
+	if warning != "" {
+		c.warnings = append(c.warnings, warning)
+	}
+	if !ext.Empty() {
+		c.warnings = append(c.warnings, threemf.DropExtensionObjects(model, ext, filepath.Base(filename))...)
+	}
+	if c.flatten {
+		if _, err := threemf.FlattenComponents(model); err != nil {
+			return nil, "", err
+		}
 	}
 
-	return &model, filename, nil
+	return model, filename, nil
 }
 
 // writeModelBambu writes a model to a 3MF file with Bambu Studio support
-func (c *Combiner) writeModelBambu(outputFile string, model *models.Model, sourceFile string, scadFiles []models.ScadFile) error {
+func (c *Combiner) writeModelBambu(outputFile string, model *models.Model, sourceFiles []string, scadFiles []models.ScadFile) error {
 	// Add Bambu metadata
 	addBambuMetadata(model)
 
-	// Read source ZIP to get metadata
-	sourceZip, err := zip.OpenReader(sourceFile)
-	if err != nil {
-		return fmt.Errorf("error opening source file: %w", err)
-	}
-	defer sourceZip.Close()
-
 	// Create output ZIP
 	outFile, err := os.Create(outputFile)
 	if err != nil {
@@ -165,7 +221,7 @@ func (c *Combiner) writeModelBambu(outputFile string, model *models.Model, sourc
 	defer outZip.Close()
 
 	// Write model XML
-	modelXML, err := xml.MarshalIndent(model, "", "\t")
+	modelXML, err := threemf.MarshalModel(model, c.compactXML)
 	if err != nil {
 		return fmt.Errorf("error marshaling XML: %w", err)
 	}
@@ -185,43 +241,16 @@ func (c *Combiner) writeModelBambu(outputFile string, model *models.Model, sourc
 	}
 
 	// Write Bambu model settings
-	if err := writeModelSettings(outZip, scadFiles); err != nil {
+	if err := writeModelSettings(outZip, scadFiles, model.Resources.Objects); err != nil {
 		return fmt.Errorf("error writing model settings: %w", err)
 	}
 
-	// Copy other files from source
-	for _, file := range sourceZip.File {
-		if file.Name == "3D/3dmodel.model" || file.Name == "Metadata/model_settings.config" {
-			continue
-		}
-
-		srcFile, err := file.Open()
-		if err != nil {
-			continue
-		}
-
-		dst, err := outZip.Create(file.Name)
-		if err != nil {
-			srcFile.Close()
-			continue
-		}
-
-		io.Copy(dst, srcFile)
-		srcFile.Close()
-	}
-
-	return nil
+	// Copy other files from the inputs
+	return threemf.CopyAuxiliaryFiles(outZip, sourceFiles, c.auxiliary, "3D/3dmodel.model", "Metadata/model_settings.config")
 }
 
 // writeModel writes a model to a 3MF file
-func (c *Combiner) writeModel(outputFile string, model *models.Model, sourceFile string) error {
-	// Read source ZIP to get metadata
-	sourceZip, err := zip.OpenReader(sourceFile)
-	if err != nil {
-		return fmt.Errorf("error opening source file: %w", err)
-	}
-	defer sourceZip.Close()
-
+func (c *Combiner) writeModel(outputFile string, model *models.Model, sourceFiles []string) error {
 	// Create output ZIP
 	outFile, err := os.Create(outputFile)
 	if err != nil {
@@ -233,7 +262,7 @@ func (c *Combiner) writeModel(outputFile string, model *models.Model, sourceFile
 	defer outZip.Close()
 
 	// Write model XML
-	modelXML, err := xml.MarshalIndent(model, "", "\t")
+	modelXML, err := threemf.MarshalModel(model, c.compactXML)
 	if err != nil {
 		return fmt.Errorf("error marshaling XML: %w", err)
 	}
@@ -252,32 +281,8 @@ func (c *Combiner) writeModel(outputFile string, model *models.Model, sourceFile
 		return fmt.Errorf("error writing model XML: %w", err)
 	}
 
-	// Copy other files from source
-	for _, file := range sourceZip.File {
-		if file.Name == "3D/3dmodel.model" {
-			continue
-		}
-
-		srcFile, err := file.Open()
-		if err != nil {
-			return fmt.Errorf("error opening source file: %w", err)
-		}
-
-		dst, err := outZip.Create(file.Name)
-		if err != nil {
-			srcFile.Close()
-			return fmt.Errorf("error creating ZIP entry: %w", err)
-		}
-
-		if _, err := io.Copy(dst, srcFile); err != nil {
-			srcFile.Close()
-			return fmt.Errorf("error copying file: %w", err)
-		}
-
-		srcFile.Close()
-	}
-
-	return nil
+	// Copy other files from the inputs
+	return threemf.CopyAuxiliaryFiles(outZip, sourceFiles, c.auxiliary, "3D/3dmodel.model")
 }
 
 // getMaxObjectID finds the highest object ID in a model
@@ -306,8 +311,9 @@ func addBambuMetadata(model *models.Model) {
 	}, model.Metadata...)
 }
 
-// writeModelSettings writes the Bambu Studio model_settings.config file
-func writeModelSettings(outZip *zip.Writer, scadFiles []models.ScadFile) error {
+// writeModelSettings writes the Bambu Studio model_settings.config file for the
+// parts with the mesh objects of the model
+func writeModelSettings(outZip *zip.Writer, scadFiles []models.ScadFile, objects []models.Object) error {
 	// Create parts with filament assignments
 	var parts []models.Part
 	totalFaces := 0
@@ -318,7 +324,7 @@ func writeModelSettings(outZip *zip.Writer, scadFiles []models.ScadFile) error {
 			filamentSlot = ((i) % 4) + 1
 		}
 
-		faceCount := 12 // Placeholder - would need actual mesh analysis
+		faceCount := threemf.FaceCount(&objects[i])
 		totalFaces += faceCount
 
 		parts = append(parts, models.Part{
@@ -326,7 +332,7 @@ func writeModelSettings(outZip *zip.Writer, scadFiles []models.ScadFile) error {
 			Subtype: "normal_part",
 			Metadata: []models.SettingsMetadata{
 				{Key: "name", Value: scadFile.Name},
-				{Key: "matrix", Value: "1 0 0 0 0 1 0 0 0 0 1 0 0 0 0 1"},
+				{Key: "matrix", Value: threemf.PartMatrix(scadFile.Matrix)},
 				{Key: "source_file", Value: "combined.3mf"},
 				{Key: "source_object_id", Value: strconv.Itoa(i)},
 				{Key: "source_volume_id", Value: "0"},
@@ -0,0 +1,96 @@
+package threemf
+
+import (
+	"encoding/xml"
+	"fmt"
+
+	"github.com/philipparndt/go3mf/internal/geometry"
+	"github.com/philipparndt/go3mf/internal/models"
+)
+
+// WriteObject writes a build item of a 3MF file as standalone 3MF file: the
+// package entries of the source with a model of the object, the objects of its
+// parts and the materials. The metadata of the model is kept.
+func WriteObject(source, dest string, model *models.Model, item int) error {
+	objectModel, err := ObjectModel(model, item)
+	if err != nil {
+		return err
+	}
+	data, err := MarshalModel(objectModel, false)
+	if err != nil {
+		return fmt.Errorf("error marshaling XML: %w", err)
+	}
+	data = append([]byte(xml.Header), data...)
+	return writePackage(source, dest, map[string][]byte{"3D/3dmodel.model": data})
+}
+
+// ObjectModel returns a model with a single build item of a model and the objects
+// it references. The item keeps its rotation and height, but is moved to the
+// origin of the plate.
+func ObjectModel(model *models.Model, item int) (*models.Model, error) {
+	if item < 0 || item >= len(model.Build.Items) {
+		return nil, fmt.Errorf("build item %d not found", item+1)
+	}
+	objects := make(map[string]models.Object)
+	for _, obj := range model.Resources.Objects {
+		objects[obj.ID] = obj
+	}
+
+	// Collect the object and the objects of its components
+	used := make(map[string]bool)
+	var collect func(id string) error
+	collect = func(id string) error {
+		obj, ok := objects[id]
+		if !ok {
+			return fmt.Errorf("object %s not found", id)
+		}
+		used[id] = true
+		if obj.Components != nil {
+			for _, component := range obj.Components.Component {
+				// Components in other model files are kept with the package entries
+				if component.Path != "" || used[component.ObjectID] {
+					continue
+				}
+				if err := collect(component.ObjectID); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	buildItem := model.Build.Items[item]
+	if err := collect(buildItem.ObjectID); err != nil {
+		return nil, err
+	}
+
+	result := *model
+	declareNamespaces(&result, true)
+	result.Resources = models.Resources{BaseMaterials: model.Resources.BaseMaterials}
+	for _, obj := range model.Resources.Objects {
+		if used[obj.ID] {
+			result.Resources.Objects = append(result.Resources.Objects, obj)
+		}
+	}
+
+	transform, err := atOrigin(buildItem.Transform)
+	if err != nil {
+		return nil, err
+	}
+	buildItem.Transform = transform
+	result.Build = models.Build{UUID: model.Build.UUID, Items: []models.Item{buildItem}}
+	return &result, nil
+}
+
+// atOrigin returns a 3MF transformation matrix without its translation on the
+// plate. The height is kept.
+func atOrigin(transform string) (string, error) {
+	if transform == "" {
+		return "", nil
+	}
+	m, err := geometry.ParseTransform(transform)
+	if err != nil {
+		return "", err
+	}
+	m[9], m[10] = 0, 0
+	return geometry.FormatTransform(m), nil
+}
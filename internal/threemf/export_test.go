@@ -0,0 +1,58 @@
+package threemf
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/philipparndt/go3mf/internal/models"
+)
+
+func TestObjectModel(t *testing.T) {
+	model := &models.Model{
+		Metadata: []models.Metadata{{Name: "Application", Value: "go3mf"}},
+		Resources: models.Resources{
+			BaseMaterials: &models.BaseMaterials{ID: "9"},
+			Objects: []models.Object{
+				{ID: "1", Name: "a", Mesh: &models.Mesh{}},
+				{ID: "2", Name: "b_part", Mesh: &models.Mesh{}},
+				{ID: "3", Name: "b_lid", Mesh: &models.Mesh{}},
+				{ID: "4", Name: "b", Components: &models.Components{Component: []models.Component{
+					{ObjectID: "2"},
+					{ObjectID: "3", Transform: "1 0 0 0 1 0 0 0 1 0 0 10"},
+				}}},
+			},
+		},
+		Build: models.Build{Items: []models.Item{
+			{ObjectID: "1", Transform: "1 0 0 0 1 0 0 0 1 50 60 0"},
+			{ObjectID: "4", Transform: "0 1 0 -1 0 0 0 0 1 120 80 2.5"},
+		}},
+	}
+
+	got, err := ObjectModel(model, 1)
+	if err != nil {
+		t.Fatalf("ObjectModel() error = %v", err)
+	}
+	var ids []string
+	for _, obj := range got.Resources.Objects {
+		ids = append(ids, obj.ID)
+	}
+	if want := []string{"2", "3", "4"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("objects = %v, want %v", ids, want)
+	}
+	if want := []models.Item{{ObjectID: "4", Transform: "0 1 0 -1 0 0 0 0 1 0 0 2.5"}}; !reflect.DeepEqual(got.Build.Items, want) {
+		t.Errorf("build items = %v, want %v", got.Build.Items, want)
+	}
+	if got.Resources.BaseMaterials != model.Resources.BaseMaterials || len(got.Metadata) != 1 {
+		t.Error("materials and metadata should be kept")
+	}
+	if got.XmlnsP == "" || got.Lang != "en-US" {
+		t.Errorf("namespace p = %q, language = %q, want them declared", got.XmlnsP, got.Lang)
+	}
+	if len(model.Build.Items) != 2 || len(model.Resources.Objects) != 4 {
+		t.Error("ObjectModel() should not change the model")
+	}
+
+	if _, err := ObjectModel(model, 2); err == nil {
+		t.Error("ObjectModel() should fail for a missing build item")
+	}
+}
@@ -0,0 +1,220 @@
+package threemf
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/philipparndt/go3mf/internal/exitcode"
+	"github.com/philipparndt/go3mf/internal/models"
+)
+
+// SliceStack is a stack of 2D layers of the 3MF slice extension
+type SliceStack struct {
+	ID         string
+	ZBottom    float64
+	ZTops      []float64 // Top of every slice from bottom to top
+	References int       // Slice stacks of other model parts this stack refers to
+}
+
+// LayerHeights returns the smallest and the largest layer height of the stack
+func (s SliceStack) LayerHeights() (minHeight, maxHeight float64) {
+	bottom := s.ZBottom
+	for i, top := range s.ZTops {
+		height := top - bottom
+		if i == 0 || height < minHeight {
+			minHeight = height
+		}
+		if i == 0 || height > maxHeight {
+			maxHeight = height
+		}
+		bottom = top
+	}
+	return minHeight, maxHeight
+}
+
+// BeamLattice is the beam lattice of a mesh of the 3MF beam lattice extension
+type BeamLattice struct {
+	ObjectID string
+	Beams    int
+	Balls    int
+}
+
+// supportedExtensions are the namespaces of the required extensions go3mf can
+// combine. Slice stacks and beam lattices are skipped with a warning.
+var supportedExtensions = map[string]bool{
+	"http://schemas.microsoft.com/3dmanufacturing/production/2015/06":  true,
+	"http://schemas.microsoft.com/3dmanufacturing/slice/2015/07":       true,
+	"http://schemas.microsoft.com/3dmanufacturing/beamlattice/2017/02": true,
+	"http://schemas.bambulab.com/package/2021":                         true,
+}
+
+// Extensions are the resources of the slice and beam lattice extensions in a
+// model. The model types leave them out, so they are scanned separately.
+type Extensions struct {
+	Required     []string // Namespaces of the required extensions (undeclared prefixes as given)
+	SliceStacks  []SliceStack
+	BeamLattices []BeamLattice
+	SliceObjects map[string]string // Object ID -> ID of the slice stack it is made of
+}
+
+// Empty reports whether the model has no slice stacks and no beam lattices
+func (e *Extensions) Empty() bool {
+	return len(e.SliceStacks) == 0 && len(e.BeamLattices) == 0 && len(e.SliceObjects) == 0
+}
+
+// Unsupported returns the required extensions go3mf cannot combine
+func (e *Extensions) Unsupported() []string {
+	var unsupported []string
+	for _, namespace := range e.Required {
+		if !supportedExtensions[namespace] {
+			unsupported = append(unsupported, namespace)
+		}
+	}
+	return unsupported
+}
+
+// CheckRequiredExtensions fails if the model of source requires extensions go3mf
+// cannot combine, as the output would be broken. With ignore, it returns a
+// warning instead.
+func CheckRequiredExtensions(ext *Extensions, source string, ignore bool) (string, error) {
+	unsupported := ext.Unsupported()
+	if len(unsupported) == 0 {
+		return "", nil
+	}
+	if !ignore {
+		return "", exitcode.Wrap(exitcode.Input, fmt.Errorf("%s requires unsupported 3MF extension(s): %s (use --ignore-extensions to combine it anyway)", source, strings.Join(unsupported, ", ")))
+	}
+	return fmt.Sprintf("%s: ignoring unsupported 3MF extension(s) %s, the output may be broken", source, strings.Join(unsupported, ", ")), nil
+}
+
+// extensionScanner collects the extension resources of a model from the
+// elements of its XML, in document order
+type extensionScanner struct {
+	ext      *Extensions
+	stack    *SliceStack
+	lattice  *BeamLattice
+	objectID string
+}
+
+func newExtensionScanner() *extensionScanner {
+	return &extensionScanner{ext: &Extensions{SliceObjects: make(map[string]string)}}
+}
+
+// start processes a start element
+func (s *extensionScanner) start(t xml.StartElement) error {
+	switch t.Name.Local {
+	case "model":
+		s.ext.Required = requiredExtensions(t)
+	case "object":
+		s.objectID = attr(t, "id")
+		if id := attr(t, "slicestackid"); id != "" {
+			s.ext.SliceObjects[s.objectID] = id
+		}
+	case "slicestack":
+		s.stack = &SliceStack{ID: attr(t, "id")}
+		s.stack.ZBottom, _ = strconv.ParseFloat(attr(t, "zbottom"), 64)
+	case "slice":
+		if s.stack != nil {
+			top, err := strconv.ParseFloat(attr(t, "ztop"), 64)
+			if err != nil {
+				return fmt.Errorf("slice stack %s: invalid ztop %q", s.stack.ID, attr(t, "ztop"))
+			}
+			s.stack.ZTops = append(s.stack.ZTops, top)
+		}
+	case "sliceref":
+		if s.stack != nil {
+			s.stack.References++
+		}
+	case "beamlattice":
+		s.lattice = &BeamLattice{ObjectID: s.objectID}
+	case "beam":
+		if s.lattice != nil {
+			s.lattice.Beams++
+		}
+	case "ball":
+		if s.lattice != nil {
+			s.lattice.Balls++
+		}
+	}
+	return nil
+}
+
+// end processes an end element
+func (s *extensionScanner) end(t xml.EndElement) {
+	switch t.Name.Local {
+	case "slicestack":
+		if s.stack != nil {
+			s.ext.SliceStacks = append(s.ext.SliceStacks, *s.stack)
+		}
+		s.stack = nil
+	case "beamlattice":
+		if s.lattice != nil {
+			s.ext.BeamLattices = append(s.ext.BeamLattices, *s.lattice)
+		}
+		s.lattice = nil
+	case "object":
+		s.objectID = ""
+	}
+}
+
+// requiredExtensions returns the namespaces of the required extensions of the
+// model element
+func requiredExtensions(model xml.StartElement) []string {
+	namespaces := make(map[string]string)
+	for _, a := range model.Attr {
+		if a.Name.Space == "xmlns" {
+			namespaces[a.Name.Local] = a.Value
+		}
+	}
+
+	var required []string
+	for _, prefix := range strings.Fields(attr(model, "requiredextensions")) {
+		if namespace, ok := namespaces[prefix]; ok {
+			required = append(required, namespace)
+		} else {
+			required = append(required, prefix)
+		}
+	}
+	return required
+}
+
+// attr returns the value of the attribute of an element by its local name
+func attr(element xml.StartElement, name string) string {
+	for _, a := range element.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// DropExtensionObjects removes the objects whose geometry exists only as slice
+// stack or beam lattice, which cannot be combined, from the model. It returns a
+// warning for every removed object and for lattices dropped from kept meshes.
+func DropExtensionObjects(model *models.Model, ext *Extensions, source string) []string {
+	lattices := make(map[string]bool)
+	for _, lattice := range ext.BeamLattices {
+		lattices[lattice.ObjectID] = true
+	}
+
+	var warnings []string
+	var kept []models.Object
+	for _, obj := range model.Resources.Objects {
+		hasTriangles := obj.Mesh != nil && obj.Mesh.Triangles != nil && len(obj.Mesh.Triangles.Triangle) > 0
+		switch {
+		case ext.SliceObjects[obj.ID] != "" && !hasTriangles && obj.Components == nil:
+			warnings = append(warnings, fmt.Sprintf("%s: skipped object %s, it consists of a slice stack only", source, obj.ID))
+			continue
+		case lattices[obj.ID] && !hasTriangles:
+			warnings = append(warnings, fmt.Sprintf("%s: skipped object %s, it consists of a beam lattice only", source, obj.ID))
+			continue
+		case lattices[obj.ID]:
+			warnings = append(warnings, fmt.Sprintf("%s: the beam lattice of object %s is not combined, only its mesh", source, obj.ID))
+		}
+		kept = append(kept, obj)
+	}
+	model.Resources.Objects = kept
+	return warnings
+}
@@ -0,0 +1,102 @@
+package threemf
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/philipparndt/go3mf/internal/exitcode"
+)
+
+const extensionsModel = `<?xml version="1.0" encoding="UTF-8"?>
+<model unit="millimeter" xmlns="http://schemas.microsoft.com/3dmanufacturing/core/2015/02"
+  xmlns:s="http://schemas.microsoft.com/3dmanufacturing/slice/2015/07"
+  xmlns:b="http://schemas.microsoft.com/3dmanufacturing/beamlattice/2017/02" requiredextensions="s b">
+  <resources>
+    <s:slicestack id="1" zbottom="0">
+      <s:slice ztop="0.2"><s:vertices/></s:slice>
+      <s:slice ztop="0.4"><s:vertices/></s:slice>
+      <s:slice ztop="0.7"><s:vertices/></s:slice>
+    </s:slicestack>
+    <object id="2" type="model" s:slicestackid="1"><mesh><vertices/><triangles/></mesh></object>
+    <object id="3" type="model">
+      <mesh>
+        <vertices><vertex x="0" y="0" z="0"/><vertex x="0" y="0" z="10"/></vertices>
+        <triangles/>
+        <b:beamlattice radius="1" minlength="0.1">
+          <b:beams><b:beam v1="0" v2="1"/></b:beams>
+          <b:balls><b:ball vindex="0"/><b:ball vindex="1"/></b:balls>
+        </b:beamlattice>
+      </mesh>
+    </object>
+    <object id="4" type="model"><mesh><vertices/><triangles><triangle v1="0" v2="1" v3="2"/></triangles></mesh></object>
+  </resources>
+  <build><item objectid="4"/></build>
+</model>`
+
+// wantExtensions are the extension resources of extensionsModel
+var wantExtensions = &Extensions{
+	Required: []string{
+		"http://schemas.microsoft.com/3dmanufacturing/slice/2015/07",
+		"http://schemas.microsoft.com/3dmanufacturing/beamlattice/2017/02",
+	},
+	SliceStacks:  []SliceStack{{ID: "1", ZTops: []float64{0.2, 0.4, 0.7}}},
+	BeamLattices: []BeamLattice{{ObjectID: "3", Beams: 1, Balls: 2}},
+	SliceObjects: map[string]string{"2": "1"},
+}
+
+func TestDecodeModelExtensions(t *testing.T) {
+	_, ext, err := DecodeModel(strings.NewReader(extensionsModel))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(ext, wantExtensions) {
+		t.Errorf("DecodeModel() extensions = %+v, want %+v", ext, wantExtensions)
+	}
+
+	minHeight, maxHeight := ext.SliceStacks[0].LayerHeights()
+	if minHeight < 0.199 || minHeight > 0.201 || maxHeight < 0.299 || maxHeight > 0.301 {
+		t.Errorf("LayerHeights() = %v, %v, want 0.2, 0.3", minHeight, maxHeight)
+	}
+}
+
+func TestCheckRequiredExtensions(t *testing.T) {
+	ext := &Extensions{Required: []string{"http://schemas.microsoft.com/3dmanufacturing/production/2015/06", "v"}}
+
+	_, err := CheckRequiredExtensions(ext, "model.3mf", false)
+	if err == nil || !strings.Contains(err.Error(), "model.3mf requires unsupported 3MF extension(s): v") {
+		t.Errorf("expected error for the unsupported extension, got %v", err)
+	}
+	if code := exitcode.FromError(err); code != exitcode.Input {
+		t.Errorf("exit code = %v, want %v", code, exitcode.Input)
+	}
+
+	warning, err := CheckRequiredExtensions(ext, "model.3mf", true)
+	if err != nil || !strings.Contains(warning, "ignoring unsupported 3MF extension(s) v") {
+		t.Errorf("CheckRequiredExtensions(ignore) = %q, %v", warning, err)
+	}
+
+	ext.Required = ext.Required[:1]
+	if warning, err := CheckRequiredExtensions(ext, "model.3mf", false); warning != "" || err != nil {
+		t.Errorf("CheckRequiredExtensions(supported) = %q, %v", warning, err)
+	}
+}
+
+func TestDropExtensionObjects(t *testing.T) {
+	model, ext, err := DecodeModel(strings.NewReader(extensionsModel))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	warnings := DropExtensionObjects(model, ext, "lattice.3mf")
+	if len(model.Resources.Objects) != 1 || model.Resources.Objects[0].ID != "4" {
+		t.Errorf("kept objects = %+v, want object 4 only", model.Resources.Objects)
+	}
+	want := []string{
+		"lattice.3mf: skipped object 2, it consists of a slice stack only",
+		"lattice.3mf: skipped object 3, it consists of a beam lattice only",
+	}
+	if !reflect.DeepEqual(warnings, want) {
+		t.Errorf("warnings = %v, want %v", warnings, want)
+	}
+}
@@ -0,0 +1,130 @@
+package threemf
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/philipparndt/go3mf/internal/geometry"
+	"github.com/philipparndt/go3mf/internal/models"
+)
+
+// maxComponentDepth bounds the nesting of components, so cyclic references fail
+const maxComponentDepth = 32
+
+// errExternalComponent stops the resolution of an object with a component in
+// another model file of the archive, which is kept as it is
+var errExternalComponent = errors.New("component of another model file")
+
+// FlattenComponents replaces every object of a model that is made of components
+// by a single mesh with the meshes of all its components, resolving components
+// of components and composing their transforms. Objects only used as components
+// of others are removed unless they are build items themselves. Objects with
+// components in other model files of the archive (e.g. the objects of Bambu
+// Studio projects) are kept unchanged, together with the objects they use. It
+// returns the number of flattened objects.
+func FlattenComponents(model *models.Model) (int, error) {
+	objects := make(map[string]*models.Object)
+	used := make(map[string]bool) // Objects used as components
+	for i := range model.Resources.Objects {
+		obj := &model.Resources.Objects[i]
+		objects[obj.ID] = obj
+		if obj.Components != nil {
+			for _, component := range obj.Components.Component {
+				used[component.ObjectID] = true
+			}
+		}
+	}
+	items := make(map[string]bool) // Objects placed on the plate
+	for _, item := range model.Build.Items {
+		items[item.ObjectID] = true
+	}
+
+	var meshes []*models.Object
+	var transforms [][12]float64
+	var resolve func(id string, m [12]float64, depth int) error
+	resolve = func(id string, m [12]float64, depth int) error {
+		if depth > maxComponentDepth {
+			return fmt.Errorf("components of object %s are nested too deeply or refer to themselves", id)
+		}
+		obj, ok := objects[id]
+		if !ok {
+			return fmt.Errorf("component refers to unknown object %s", id)
+		}
+		if obj.Mesh != nil {
+			meshes = append(meshes, obj)
+			transforms = append(transforms, m)
+		}
+		if obj.Components == nil {
+			return nil
+		}
+		for _, component := range obj.Components.Component {
+			if component.Path != "" {
+				return errExternalComponent
+			}
+			t, err := geometry.ParseTransform(component.Transform)
+			if err != nil {
+				return fmt.Errorf("object %s: %w", obj.ID, err)
+			}
+			if err := resolve(component.ObjectID, geometry.MultiplyTransforms(t, m), depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// keepUsed marks the objects of this model an unflattened object uses
+	kept := make(map[string]bool)
+	var keepUsed func(obj *models.Object, depth int)
+	keepUsed = func(obj *models.Object, depth int) {
+		if obj.Components == nil || depth > maxComponentDepth {
+			return
+		}
+		for _, component := range obj.Components.Component {
+			if used, ok := objects[component.ObjectID]; ok && component.Path == "" && !kept[used.ID] {
+				kept[used.ID] = true
+				keepUsed(used, depth+1)
+			}
+		}
+	}
+
+	merged := make(map[string]*models.Mesh)
+	first := make(map[string]*models.Object) // Object -> its first part
+	for _, obj := range model.Resources.Objects {
+		if obj.Components == nil || (used[obj.ID] && !items[obj.ID]) {
+			continue
+		}
+		meshes, transforms = nil, nil
+		err := resolve(obj.ID, geometry.IdentityTransform, 0)
+		if errors.Is(err, errExternalComponent) {
+			keepUsed(&obj, 0)
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		if len(meshes) == 0 {
+			return 0, fmt.Errorf("object %s has no meshes", obj.ID)
+		}
+		mesh, err := geometry.MergeMeshes(meshes, transforms)
+		if err != nil {
+			return 0, err
+		}
+		merged[obj.ID], first[obj.ID] = mesh, meshes[0]
+	}
+
+	var result []models.Object
+	for _, obj := range model.Resources.Objects {
+		if used[obj.ID] && !items[obj.ID] && !kept[obj.ID] {
+			continue
+		}
+		if mesh, ok := merged[obj.ID]; ok {
+			// The merged mesh takes the material of its first part
+			obj.Mesh = mesh
+			obj.Components = nil
+			obj.PID, obj.PIndex = first[obj.ID].PID, first[obj.ID].PIndex
+		}
+		result = append(result, obj)
+	}
+	model.Resources.Objects = result
+	return len(merged), nil
+}
@@ -0,0 +1,176 @@
+package threemf
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/philipparndt/go3mf/internal/geometry"
+	"github.com/philipparndt/go3mf/internal/models"
+)
+
+func TestFlattenComponents(t *testing.T) {
+	mesh := func(id, pid string) models.Object {
+		obj := triangleModel(10).Resources.Objects[0]
+		obj.ID, obj.PID = id, pid
+		return obj
+	}
+	components := func(id string, refs ...models.Component) models.Object {
+		return models.Object{ID: id, Type: "model", Components: &models.Components{Component: refs}}
+	}
+
+	tests := []struct {
+		name       string
+		objects    []models.Object
+		items      []string // Object IDs of the build items
+		wantIDs    string   // IDs of the objects after flattening
+		components string   // IDs of the objects that keep their components
+		flattened  int
+		wantErr    string
+	}{
+		{
+			name:    "meshes only",
+			objects: []models.Object{mesh("1", "1"), mesh("2", "1")},
+			wantIDs: "1 2",
+		},
+		{
+			name: "nested",
+			objects: []models.Object{
+				mesh("1", "2"), mesh("2", "1"),
+				components("3", models.Component{ObjectID: "1", Transform: "1 0 0 0 1 0 0 0 1 10 0 0"}, models.Component{ObjectID: "2"}),
+				components("4", models.Component{ObjectID: "3", Transform: "1 0 0 0 1 0 0 0 1 0 0 5"}, models.Component{ObjectID: "1"}),
+				mesh("5", "1"),
+			},
+			wantIDs:   "4 5",
+			flattened: 1,
+		},
+		{
+			name:    "cycle",
+			objects: []models.Object{components("1", models.Component{ObjectID: "2"}), components("2", models.Component{ObjectID: "1"}), components("3", models.Component{ObjectID: "1"})},
+			wantErr: "nested too deeply",
+		},
+		{
+			name:    "unknown object",
+			objects: []models.Object{components("1", models.Component{ObjectID: "9"})},
+			wantErr: "unknown object 9",
+		},
+		{
+			name: "other model file",
+			objects: []models.Object{
+				mesh("1", "1"),
+				components("2", models.Component{ObjectID: "1"}),
+				components("3", models.Component{ObjectID: "1", Path: "/3D/Objects/object_1.model"}, models.Component{ObjectID: "2"}),
+				components("4", models.Component{ObjectID: "1"}),
+			},
+			wantIDs:    "1 2 3 4",
+			components: "2 3",
+			flattened:  1,
+		},
+		{
+			name:      "build item used as component",
+			objects:   []models.Object{mesh("1", "1"), components("2", models.Component{ObjectID: "1"})},
+			items:     []string{"1", "2"},
+			wantIDs:   "1 2",
+			flattened: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			model := &models.Model{Resources: models.Resources{Objects: tt.objects}}
+			for _, id := range tt.items {
+				model.Build.Items = append(model.Build.Items, models.Item{ObjectID: id})
+			}
+			flattened, err := FlattenComponents(model)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("FlattenComponents() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("FlattenComponents() error = %v", err)
+			}
+			if flattened != tt.flattened {
+				t.Errorf("FlattenComponents() = %d, want %d", flattened, tt.flattened)
+			}
+			var ids, withComponents []string
+			for _, obj := range model.Resources.Objects {
+				ids = append(ids, obj.ID)
+				if obj.Components != nil {
+					withComponents = append(withComponents, obj.ID)
+				} else if obj.Mesh == nil {
+					t.Errorf("object %s has neither a mesh nor components after flattening", obj.ID)
+				}
+			}
+			if got := strings.Join(ids, " "); got != tt.wantIDs {
+				t.Errorf("objects after flattening = %s, want %s", got, tt.wantIDs)
+			}
+			if got := strings.Join(withComponents, " "); got != tt.components {
+				t.Errorf("objects with components = %q, want %q", got, tt.components)
+			}
+		})
+	}
+}
+
+func TestFlattenComponentsTransforms(t *testing.T) {
+	model := triangleModel(10)
+	model.Resources.Objects[0].PID = "3"
+	model.Resources.Objects = append(model.Resources.Objects,
+		models.Object{ID: "2", Components: &models.Components{Component: []models.Component{
+			{ObjectID: "1", Transform: "1 0 0 0 1 0 0 0 1 20 0 0"},
+		}}},
+		models.Object{ID: "3", Components: &models.Components{Component: []models.Component{
+			{ObjectID: "2", Transform: "1 0 0 0 1 0 0 0 1 0 0 5"},
+			{ObjectID: "1"},
+		}}},
+	)
+	model.Build.Items = []models.Item{{ObjectID: "3"}}
+	if _, err := FlattenComponents(model); err != nil {
+		t.Fatal(err)
+	}
+	if len(model.Resources.Objects) != 1 {
+		t.Fatalf("%d objects after flattening, want 1", len(model.Resources.Objects))
+	}
+	obj := model.Resources.Objects[0]
+	if obj.PID != "3" {
+		t.Errorf("PID = %q, want the one of the first part", obj.PID)
+	}
+	bbox, err := geometry.CalculateBoundingBox(&obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The copy moved by both transforms spans X 20..30 and Z 5..15
+	if bbox.MinX != 0 || bbox.MaxX != 30 || bbox.MinZ != 0 || bbox.MaxZ != 15 {
+		t.Errorf("bounding box = %+v, want X 0..30, Z 0..15", bbox)
+	}
+}
+
+func TestReadMeshesFlatten(t *testing.T) {
+	for _, flatten := range []bool{false, true} {
+		model := triangleModel(10)
+		model.Resources.Objects = append(model.Resources.Objects, models.Object{ID: "2", Type: "model",
+			Components: &models.Components{Component: []models.Component{{ObjectID: "1"}}}})
+		model.Build.Items = []models.Item{{ObjectID: "2"}}
+		var input bytes.Buffer
+		if err := encodePackage(&input, model); err != nil {
+			t.Fatal(err)
+		}
+
+		combiner := NewCombiner()
+		combiner.SetFlatten(flatten)
+		if err := combiner.AddArchive("components.3mf", bytes.NewReader(input.Bytes()), int64(input.Len())); err != nil {
+			t.Fatal(err)
+		}
+		read, err := combiner.readMeshes("components.3mf")
+		if err != nil {
+			t.Fatalf("readMeshes() error = %v", err)
+		}
+		objects := read.Resources.Objects
+		if flatten && (len(objects) != 1 || objects[0].Components != nil) {
+			t.Errorf("flatten: %d objects, want one without components", len(objects))
+		}
+		if !flatten && (len(objects) != 2 || objects[1].Components == nil) {
+			t.Errorf("no flatten: %d objects, want the mesh and the object with components", len(objects))
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package threemf
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/philipparndt/go3mf/internal/geometry"
+	"github.com/philipparndt/go3mf/internal/models"
+	"github.com/philipparndt/go3mf/internal/stl"
+	"github.com/philipparndt/go3mf/internal/ui"
+)
+
+// fallbackSize is the width and depth in mm of an object whose size is unknown
+// when no other object has a known size to estimate it from
+const fallbackSize = 50.0
+
+// objectBoundingBox returns the combined bounding box of the parts of an object
+// (rotation is already baked into the meshes, the positions of the parts of
+// multi-part objects are applied). Parts without a mesh size are measured from
+// their source file. It returns nil if the size of no part is known.
+func (c *Combiner) objectBoundingBox(objectName string, groupObjects []models.Object, scadFiles []models.ScadFile) *geometry.BoundingBox {
+	var combined *geometry.BoundingBox
+	var unknown []string
+	for i := range groupObjects {
+		bbox, err := geometry.CalculateBoundingBox(&groupObjects[i])
+		if err != nil {
+			bbox, err = sourceBoundingBox(scadFiles[i])
+			if err != nil {
+				unknown = append(unknown, scadFiles[i].Name)
+				continue
+			}
+			c.warnings = append(c.warnings, fmt.Sprintf("%s: size of the mesh of %s unknown, using the size of %s",
+				objectName, scadFiles[i].Name, filepath.Base(sourcePath(scadFiles[i]))))
+		}
+		if len(groupObjects) > 1 {
+			bbox.MinX += scadFiles[i].PositionX
+			bbox.MaxX += scadFiles[i].PositionX
+			bbox.MinY += scadFiles[i].PositionY
+			bbox.MaxY += scadFiles[i].PositionY
+		}
+		if combined == nil {
+			combined = bbox
+			continue
+		}
+		combined.MinX = math.Min(combined.MinX, bbox.MinX)
+		combined.MinY = math.Min(combined.MinY, bbox.MinY)
+		combined.MaxX = math.Max(combined.MaxX, bbox.MaxX)
+		combined.MaxY = math.Max(combined.MaxY, bbox.MaxY)
+	}
+	if combined != nil && len(unknown) > 0 {
+		c.warnings = append(c.warnings, fmt.Sprintf("%s: size of %s unknown, packed with the size of the other parts",
+			objectName, strings.Join(unknown, ", ")))
+	}
+	if c.Debug && combined == nil {
+		fmt.Fprintf(ui.Output(), "DEBUG: %s - size unknown\n", objectName)
+	}
+	return combined
+}
+
+// sourcePath returns the input file of a part
+func sourcePath(scadFile models.ScadFile) string {
+	if scadFile.SourcePath != "" {
+		return scadFile.SourcePath
+	}
+	return scadFile.Path
+}
+
+// sourceBoundingBox returns the bounding box of the STL or 3MF input of a part,
+// rotated like the part
+func sourceBoundingBox(scadFile models.ScadFile) (*geometry.BoundingBox, error) {
+	rotation, err := geometry.ParseTransform(geometry.BuildRotationTransform(scadFile.RotationX, scadFile.RotationY, scadFile.RotationZ, 0, 0, 0))
+	if err != nil {
+		return nil, err
+	}
+
+	path := sourcePath(scadFile)
+	var points [][3]float64
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".stl":
+		mesh, err := stl.NewParser().Parse(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, triangle := range mesh.Triangles {
+			for _, v := range []stl.Vector3{triangle.V1, triangle.V2, triangle.V3} {
+				points = append(points, [3]float64{float64(v.X), float64(v.Y), float64(v.Z)})
+			}
+		}
+	case ".3mf":
+		model, err := (&Reader{}).Read(path)
+		if err != nil {
+			return nil, err
+		}
+		objects := make(map[string]models.Object, len(model.Resources.Objects))
+		for _, obj := range model.Resources.Objects {
+			objects[obj.ID] = obj
+		}
+		var items []models.Object
+		var transforms []string
+		for _, item := range model.Build.Items {
+			if obj, ok := objects[item.ObjectID]; ok {
+				items = append(items, obj)
+				transforms = append(transforms, item.Transform)
+			}
+		}
+		if len(items) == 0 {
+			return nil, fmt.Errorf("%s has no build items", filepath.Base(path))
+		}
+		bbox, err := geometry.CalculateCombinedBoundingBox(items, transforms)
+		if err != nil {
+			return nil, err
+		}
+		for _, x := range []float64{bbox.MinX, bbox.MaxX} {
+			for _, y := range []float64{bbox.MinY, bbox.MaxY} {
+				for _, z := range []float64{bbox.MinZ, bbox.MaxZ} {
+					points = append(points, [3]float64{x, y, z})
+				}
+			}
+		}
+	default:
+		return nil, fmt.Errorf("no size for %s", filepath.Base(path))
+	}
+	if len(points) == 0 {
+		return nil, fmt.Errorf("%s is empty", filepath.Base(path))
+	}
+
+	bbox := &geometry.BoundingBox{
+		MinX: math.MaxFloat64, MinY: math.MaxFloat64, MinZ: math.MaxFloat64,
+		MaxX: -math.MaxFloat64, MaxY: -math.MaxFloat64, MaxZ: -math.MaxFloat64,
+	}
+	for _, p := range points {
+		x, y, z := geometry.TransformPoint(rotation, p[0], p[1], p[2])
+		bbox.MinX, bbox.MaxX = math.Min(bbox.MinX, x), math.Max(bbox.MaxX, x)
+		bbox.MinY, bbox.MaxY = math.Min(bbox.MinY, y), math.Max(bbox.MaxY, y)
+		bbox.MinZ, bbox.MaxZ = math.Min(bbox.MinZ, z), math.Max(bbox.MaxZ, z)
+	}
+	return bbox, nil
+}
+
+// estimateFootprints sizes the packing rectangles of the objects whose size is
+// unknown like the known object of median area, keeping its aspect ratio, so they
+// neither overlap their neighbours nor waste the plate. Without any known object
+// they get fallbackSize. Each estimate is reported as a warning.
+func (c *Combiner) estimateFootprints(rects []*geometry.Rectangle, unknown map[string]bool) {
+	if len(unknown) == 0 {
+		return
+	}
+
+	var known []*geometry.Rectangle
+	for _, rect := range rects {
+		if !unknown[rect.Name] {
+			known = append(known, rect)
+		}
+	}
+	width, height := fallbackSize, fallbackSize
+	if len(known) > 0 {
+		sort.SliceStable(known, func(i, j int) bool {
+			return footprintArea(known[i]) < footprintArea(known[j])
+		})
+		median := known[len(known)/2]
+		width, height = median.Width-2*median.Margin, median.Height-2*median.Margin
+	}
+
+	for _, rect := range rects {
+		if !unknown[rect.Name] {
+			continue
+		}
+		rect.Width, rect.Height = width+2*rect.Margin, height+2*rect.Margin
+		rect.Outline = nil
+		c.warnings = append(c.warnings, fmt.Sprintf("%s: size unknown, packed as %.1f x %.1f mm", rect.Name, width, height))
+	}
+}
+
+// footprintArea returns the area of a packing rectangle without its margin
+func footprintArea(rect *geometry.Rectangle) float64 {
+	return (rect.Width - 2*rect.Margin) * (rect.Height - 2*rect.Margin)
+}
@@ -0,0 +1,84 @@
+package threemf
+
+import (
+	"math"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/philipparndt/go3mf/internal/geometry"
+	"github.com/philipparndt/go3mf/internal/models"
+	"github.com/philipparndt/go3mf/internal/stl"
+)
+
+func TestObjectBoundingBoxFromSTLSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bar.stl")
+	mesh := &stl.Mesh{Name: "bar", Triangles: []stl.Triangle{
+		{V1: stl.Vector3{X: 0, Y: 0, Z: 0}, V2: stl.Vector3{X: 40, Y: 0, Z: 0}, V3: stl.Vector3{X: 40, Y: 10, Z: 5}},
+	}}
+	if err := stl.NewWriter().WriteBinary(mesh, path); err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewCombiner()
+	empty := models.Object{ID: "1", Mesh: &models.Mesh{Vertices: &models.Vertices{}}}
+	bbox := c.objectBoundingBox("Bar", []models.Object{empty}, []models.ScadFile{{Name: "bar", Path: path, RotationZ: 90}})
+	if bbox == nil {
+		t.Fatal("expected the size of the STL source")
+	}
+	if math.Abs(bbox.Width()-10) > 1e-6 || math.Abs(bbox.Height()-40) > 1e-6 {
+		t.Errorf("expected the rotated size 10 x 40, got %.1f x %.1f", bbox.Width(), bbox.Height())
+	}
+	if len(c.Warnings()) != 1 || !strings.Contains(c.Warnings()[0], "bar.stl") {
+		t.Errorf("expected a warning naming the source, got %v", c.Warnings())
+	}
+
+	if bbox := c.objectBoundingBox("Blob", []models.Object{empty}, []models.ScadFile{{Name: "blob", Path: "blob.scad"}}); bbox != nil {
+		t.Errorf("expected an unknown size, got %+v", bbox)
+	}
+}
+
+func TestEstimateFootprints(t *testing.T) {
+	tests := []struct {
+		name       string
+		rects      []geometry.Rectangle
+		wantWidth  float64
+		wantHeight float64
+	}{
+		{
+			name: "median of the known objects",
+			rects: []geometry.Rectangle{
+				{Name: "small", Width: 10, Height: 10},
+				{Name: "long", Width: 80, Height: 20},
+				{Name: "large", Width: 100, Height: 100},
+				{Name: "unknown", Margin: 2},
+			},
+			wantWidth:  84,
+			wantHeight: 24,
+		},
+		{
+			name:       "nothing known",
+			rects:      []geometry.Rectangle{{Name: "unknown"}},
+			wantWidth:  fallbackSize,
+			wantHeight: fallbackSize,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rects := make([]*geometry.Rectangle, len(tt.rects))
+			for i := range tt.rects {
+				rects[i] = &tt.rects[i]
+			}
+			c := NewCombiner()
+			c.estimateFootprints(rects, map[string]bool{"unknown": true})
+
+			unknown := rects[len(rects)-1]
+			if unknown.Width != tt.wantWidth || unknown.Height != tt.wantHeight {
+				t.Errorf("expected %.0f x %.0f, got %.0f x %.0f", tt.wantWidth, tt.wantHeight, unknown.Width, unknown.Height)
+			}
+			if len(c.Warnings()) != 1 || !strings.HasPrefix(c.Warnings()[0], "unknown: ") {
+				t.Errorf("expected a warning naming the object, got %v", c.Warnings())
+			}
+		})
+	}
+}
@@ -0,0 +1,340 @@
+package threemf
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/philipparndt/go3mf/internal/geometry"
+	"github.com/philipparndt/go3mf/internal/limits"
+	"github.com/philipparndt/go3mf/internal/models"
+)
+
+// modelSettingsFile is the archive entry holding the Bambu Studio model settings
+const modelSettingsFile = "Metadata/model_settings.config"
+
+// Grouping names the objects of a 3MF file that become the parts of one object
+type Grouping struct {
+	Name    string   // Name of the new object
+	Objects []string // Names of the objects grouped into it
+}
+
+// ParseGrouping parses a grouping given as NAME=OBJECT,OBJECT...
+func ParseGrouping(value string) (Grouping, error) {
+	name, list, ok := strings.Cut(value, "=")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" {
+		return Grouping{}, fmt.Errorf("%q: expected NAME=OBJECT,OBJECT", value)
+	}
+	grouping := Grouping{Name: name}
+	for _, object := range strings.Split(list, ",") {
+		if object = strings.TrimSpace(object); object != "" {
+			grouping.Objects = append(grouping.Objects, object)
+		}
+	}
+	if len(grouping.Objects) == 0 {
+		return Grouping{}, fmt.Errorf("%q: no objects to group", value)
+	}
+	return grouping, nil
+}
+
+// Group writes a copy of a 3MF file in which the objects of every grouping are
+// the parts of one new object. The parts keep their positions, names and
+// filaments; other objects and all other archive entries are kept. inputFile
+// and outputFile may be the same file.
+func Group(inputFile, outputFile string, groupings []Grouping) error {
+	zr, err := limits.OpenZip(inputFile)
+	if err != nil {
+		return fmt.Errorf("error opening ZIP: %w", err)
+	}
+	defer zr.Close()
+
+	model, ext, err := (&Reader{}).readZip(&zr.Reader)
+	if err != nil {
+		return err
+	}
+	if !ext.Empty() {
+		return fmt.Errorf("objects of files with slice stacks or beam lattices cannot be grouped")
+	}
+	var settings *models.ModelSettings
+	for _, f := range zr.File {
+		if f.Name != modelSettingsFile {
+			continue
+		}
+		data, err := readEntry(f)
+		if err != nil {
+			return err
+		}
+		settings = &models.ModelSettings{}
+		if err := xml.Unmarshal(data, settings); err != nil {
+			return fmt.Errorf("error parsing %s: %w", f.Name, err)
+		}
+	}
+
+	if err := GroupObjects(model, settings, groupings); err != nil {
+		return err
+	}
+	declareNamespaces(model, settings != nil)
+
+	return replaceFile(outputFile, func(w io.Writer) error {
+		outZip := zip.NewWriter(w)
+		if err := writeGrouped(&zr.Reader, outZip, model, settings); err != nil {
+			return err
+		}
+		if err := outZip.Close(); err != nil {
+			return fmt.Errorf("error writing output file: %w", err)
+		}
+		return nil
+	})
+}
+
+// writeGrouped copies the archive to outZip with the model and model settings replaced
+func writeGrouped(zr *zip.Reader, outZip *zip.Writer, model *models.Model, settings *models.ModelSettings) error {
+	for _, file := range zr.File {
+		switch file.Name {
+		case "3D/3dmodel.model":
+			if err := (&Writer{}).writeModel(outZip, model); err != nil {
+				return err
+			}
+		case modelSettingsFile:
+			data, err := xml.MarshalIndent(settings, "", "  ")
+			if err != nil {
+				return fmt.Errorf("error marshaling settings XML: %w", err)
+			}
+			if err := writeEntry(outZip, file.Name, append([]byte(xml.Header), data...)); err != nil {
+				return err
+			}
+		default:
+			// Copy without recompressing, so the entry stays byte for byte identical
+			if err := outZip.Copy(file); err != nil {
+				return fmt.Errorf("error copying %s: %w", file.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// GroupObjects makes the objects of every grouping the parts of one new object
+// of the model. The components of grouped objects, or the grouped meshes, become
+// components of the new object with the transform of their build item applied,
+// so that nothing moves. The model settings (nil = none) get an object with the
+// parts of the grouped objects.
+func GroupObjects(model *models.Model, settings *models.ModelSettings, groupings []Grouping) error {
+	settingsObjects := make(map[string]*models.SettingsObject)
+	if settings != nil {
+		for i := range settings.Objects {
+			settingsObjects[settings.Objects[i].ID] = &settings.Objects[i]
+		}
+	}
+
+	// Objects on the build plate by name
+	items := make(map[string][]int) // object ID -> indices of its build items
+	for i, item := range model.Build.Items {
+		items[item.ObjectID] = append(items[item.ObjectID], i)
+	}
+	byName := make(map[string][]string)
+	nextID := 1
+	for _, obj := range model.Resources.Objects {
+		if id, err := strconv.Atoi(obj.ID); err == nil && id >= nextID {
+			nextID = id + 1
+		}
+		if len(items[obj.ID]) == 0 {
+			continue
+		}
+		name := obj.Name
+		if settingsObject, ok := settingsObjects[obj.ID]; ok && models.MetadataValue(settingsObject.Metadata, "name") != "" {
+			name = models.MetadataValue(settingsObject.Metadata, "name")
+		}
+		byName[name] = append(byName[name], obj.ID)
+	}
+
+	grouped := make(map[string]string) // object ID -> name of its grouping
+	for _, grouping := range groupings {
+		var members []string
+		for _, name := range grouping.Objects {
+			ids := byName[name]
+			switch {
+			case len(ids) == 0:
+				return fmt.Errorf("%s: unknown object %q", grouping.Name, name)
+			case len(ids) > 1:
+				return fmt.Errorf("%s: %d objects are named %q", grouping.Name, len(ids), name)
+			case len(items[ids[0]]) > 1:
+				return fmt.Errorf("%s: object %q has %d instances on the build plate", grouping.Name, name, len(items[ids[0]]))
+			}
+			if other, ok := grouped[ids[0]]; ok {
+				return fmt.Errorf("%s: object %q is already grouped into %s", grouping.Name, name, other)
+			}
+			grouped[ids[0]] = grouping.Name
+			members = append(members, ids[0])
+		}
+
+		id := strconv.Itoa(nextID)
+		nextID++
+		if err := groupMembers(model, settings, settingsObjects, items, members, id, grouping.Name); err != nil {
+			return err
+		}
+	}
+
+	// Remove the grouped objects: their meshes stay as parts, the objects made
+	// of components and the build items are replaced by the new objects
+	var objects []models.Object
+	for _, obj := range model.Resources.Objects {
+		if _, ok := grouped[obj.ID]; !ok || obj.Components == nil {
+			objects = append(objects, obj)
+		}
+	}
+	model.Resources.Objects = objects
+	var buildItems []models.Item
+	for _, item := range model.Build.Items {
+		if _, ok := grouped[item.ObjectID]; !ok {
+			buildItems = append(buildItems, item)
+		}
+	}
+	model.Build.Items = buildItems
+	if settings != nil {
+		removeSettingsObjects(settings, grouped)
+	}
+	return nil
+}
+
+// groupMembers adds the object with the given ID made of the members to the model
+// and the settings, and places it on the build plate
+func groupMembers(model *models.Model, settings *models.ModelSettings, settingsObjects map[string]*models.SettingsObject, items map[string][]int, members []string, id, name string) error {
+	objects := make(map[string]models.Object)
+	for _, obj := range model.Resources.Objects {
+		objects[obj.ID] = obj
+	}
+
+	parent := models.Object{ID: id, Name: name, Type: "model", Components: &models.Components{}}
+	var parts []models.Part
+	extruder := ""
+	for _, member := range members {
+		obj := objects[member]
+		placement, err := geometry.ParseTransform(model.Build.Items[items[member][0]].Transform)
+		if err != nil {
+			return fmt.Errorf("%s: object %s: %w", name, obj.Name, err)
+		}
+		settingsObject := settingsObjects[member]
+		memberExtruder := ""
+		if settingsObject != nil {
+			memberExtruder = models.MetadataValue(settingsObject.Metadata, "extruder")
+		}
+		if extruder == "" {
+			extruder = memberExtruder
+		}
+
+		for _, component := range models.ObjectComponents(&obj) {
+			transform, err := geometry.ParseTransform(component.Transform)
+			if err != nil {
+				return fmt.Errorf("%s: object %s: %w", name, obj.Name, err)
+			}
+			transform = geometry.MultiplyTransforms(transform, placement)
+			component.Transform = geometry.FormatTransform(transform)
+			parent.Components.Component = append(parent.Components.Component, component)
+
+			// Parts keep the matrix of their mesh, the placement is in the component
+			part := models.Part{ID: component.ObjectID, Subtype: "normal_part", Metadata: []models.SettingsMetadata{
+				{Key: "name", Value: obj.Name},
+				{Key: "matrix", Value: PartMatrix("")},
+			}}
+			if settingsObject != nil {
+				if p, ok := settingsPart(settingsObject, component.ObjectID); ok {
+					part = p
+				} else if n := models.MetadataValue(settingsObject.Metadata, "name"); n != "" {
+					part.Metadata[0].Value = n
+				}
+			}
+			if memberExtruder != "" && models.MetadataValue(part.Metadata, "extruder") == "" {
+				part.Metadata = setSettingsValue(part.Metadata, "extruder", memberExtruder)
+			}
+			parts = append(parts, part)
+		}
+	}
+
+	model.Resources.Objects = append(model.Resources.Objects, parent)
+	model.Build.Items = append(model.Build.Items, models.Item{ObjectID: id, Transform: geometry.FormatTransform(geometry.IdentityTransform), Printable: "1"})
+
+	if settings == nil {
+		return nil
+	}
+	metadata := []models.SettingsMetadata{{Key: "name", Value: name}}
+	if extruder != "" {
+		metadata = append(metadata, models.SettingsMetadata{Key: "extruder", Value: extruder})
+	}
+	settings.Objects = append(settings.Objects, models.SettingsObject{ID: id, Metadata: metadata, Parts: parts})
+
+	// The new object takes the place of the first member on its plate
+	instance := models.ModelInstance{Metadata: []models.SettingsMetadata{
+		{Key: "object_id", Value: id},
+		{Key: "instance_id", Value: "0"},
+		{Key: "identify_id", Value: id},
+	}}
+	for i := range settings.Plates {
+		for _, existing := range settings.Plates[i].ModelInstances {
+			if models.MetadataValue(existing.Metadata, "object_id") == members[0] {
+				settings.Plates[i].ModelInstances = append(settings.Plates[i].ModelInstances, instance)
+				break
+			}
+		}
+	}
+	settings.Assemble.Items = append(settings.Assemble.Items, models.AssembleItem{
+		ObjectID:   id,
+		InstanceID: "0",
+		Transform:  geometry.FormatTransform(geometry.IdentityTransform),
+		Offset:     "0 0 0",
+	})
+	return nil
+}
+
+// settingsPart returns a copy of the part of a settings object with the given ID
+func settingsPart(obj *models.SettingsObject, id string) (models.Part, bool) {
+	for _, part := range obj.Parts {
+		if part.ID == id {
+			part.Metadata = append([]models.SettingsMetadata{}, part.Metadata...)
+			return part, true
+		}
+	}
+	return models.Part{}, false
+}
+
+// setSettingsValue sets a metadata entry of the model settings, adding it if missing
+func setSettingsValue(metadata []models.SettingsMetadata, key, value string) []models.SettingsMetadata {
+	for i := range metadata {
+		if metadata[i].Key == key {
+			metadata[i].Value = value
+			return metadata
+		}
+	}
+	return append(metadata, models.SettingsMetadata{Key: key, Value: value})
+}
+
+// removeSettingsObjects removes the grouped objects, their plate instances and
+// assemble items from the model settings
+func removeSettingsObjects(settings *models.ModelSettings, grouped map[string]string) {
+	var objects []models.SettingsObject
+	for _, obj := range settings.Objects {
+		if _, ok := grouped[obj.ID]; !ok {
+			objects = append(objects, obj)
+		}
+	}
+	settings.Objects = objects
+	for i := range settings.Plates {
+		var instances []models.ModelInstance
+		for _, instance := range settings.Plates[i].ModelInstances {
+			if _, ok := grouped[models.MetadataValue(instance.Metadata, "object_id")]; !ok {
+				instances = append(instances, instance)
+			}
+		}
+		settings.Plates[i].ModelInstances = instances
+	}
+	var items []models.AssembleItem
+	for _, item := range settings.Assemble.Items {
+		if _, ok := grouped[item.ObjectID]; !ok {
+			items = append(items, item)
+		}
+	}
+	settings.Assemble.Items = items
+}
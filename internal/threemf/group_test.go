@@ -0,0 +1,147 @@
+package threemf
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/philipparndt/go3mf/internal/models"
+)
+
+func TestParseGrouping(t *testing.T) {
+	grouping, err := ParseGrouping("Case=top, bottom,")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if grouping.Name != "Case" || strings.Join(grouping.Objects, "|") != "top|bottom" {
+		t.Errorf("unexpected grouping %+v", grouping)
+	}
+	for _, value := range []string{"Case", "=top", "Case="} {
+		if _, err := ParseGrouping(value); err == nil {
+			t.Errorf("%q: expected an error", value)
+		}
+	}
+}
+
+// standaloneModel returns a model with a mesh object "top" on the build plate
+// and an object "bottom" made of two meshes
+func standaloneModel() (*models.Model, *models.ModelSettings) {
+	model := triangleModel(10)
+	model.Resources.Objects[0].Name = "top"
+	mesh := model.Resources.Objects[0]
+	mesh.ID, mesh.Name = "2", ""
+	mesh3 := mesh
+	mesh3.ID = "3"
+	model.Resources.Objects = append(model.Resources.Objects, mesh, mesh3, models.Object{
+		ID: "4", Name: "bottom", Type: "model", Components: &models.Components{Component: []models.Component{
+			{ObjectID: "2"},
+			{ObjectID: "3", Transform: "1 0 0 0 1 0 0 0 1 0 0 5"},
+		}},
+	})
+	model.Build.Items = []models.Item{
+		{ObjectID: "1", Transform: "1 0 0 0 1 0 0 0 1 100 0 0"},
+		{ObjectID: "4", Transform: "1 0 0 0 1 0 0 0 1 0 50 0"},
+	}
+
+	settings := &models.ModelSettings{
+		Objects: []models.SettingsObject{
+			{ID: "1", Metadata: []models.SettingsMetadata{{Key: "name", Value: "top"}, {Key: "extruder", Value: "2"}}},
+			{ID: "4", Metadata: []models.SettingsMetadata{{Key: "name", Value: "bottom"}, {Key: "extruder", Value: "1"}}, Parts: []models.Part{
+				{ID: "2", Subtype: "normal_part", Metadata: []models.SettingsMetadata{{Key: "name", Value: "shell"}}},
+				{ID: "3", Subtype: "normal_part", Metadata: []models.SettingsMetadata{{Key: "name", Value: "feet"}, {Key: "extruder", Value: "3"}}},
+			}},
+		},
+		Plates: []models.Plate{{ModelInstances: []models.ModelInstance{
+			{Metadata: []models.SettingsMetadata{{Key: "object_id", Value: "1"}}},
+			{Metadata: []models.SettingsMetadata{{Key: "object_id", Value: "4"}}},
+		}}},
+		Assemble: models.Assemble{Items: []models.AssembleItem{{ObjectID: "1"}, {ObjectID: "4"}}},
+	}
+	return model, settings
+}
+
+func TestGroupObjects(t *testing.T) {
+	model, settings := standaloneModel()
+	if err := GroupObjects(model, settings, []Grouping{{Name: "Case", Objects: []string{"top", "bottom"}}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var ids []string
+	for _, obj := range model.Resources.Objects {
+		ids = append(ids, obj.ID)
+	}
+	if got := strings.Join(ids, " "); got != "1 2 3 5" {
+		t.Fatalf("expected objects 1 2 3 5, got %s", got)
+	}
+	parent := model.Resources.Objects[3]
+	if parent.Name != "Case" || parent.Components == nil || len(parent.Components.Component) != 3 {
+		t.Fatalf("unexpected parent %+v", parent)
+	}
+	wantTransforms := []string{"1 0 0 0 1 0 0 0 1 100 0 0", "1 0 0 0 1 0 0 0 1 0 50 0", "1 0 0 0 1 0 0 0 1 0 50 5"}
+	for i, component := range parent.Components.Component {
+		if component.Transform != wantTransforms[i] {
+			t.Errorf("component %d: expected transform %q, got %q", i, wantTransforms[i], component.Transform)
+		}
+	}
+	if len(model.Build.Items) != 1 || model.Build.Items[0].ObjectID != "5" {
+		t.Errorf("unexpected build items %+v", model.Build.Items)
+	}
+
+	if len(settings.Objects) != 1 || settings.Objects[0].ID != "5" || models.MetadataValue(settings.Objects[0].Metadata, "extruder") != "2" {
+		t.Fatalf("unexpected settings objects %+v", settings.Objects)
+	}
+	var parts []string
+	for _, part := range settings.Objects[0].Parts {
+		parts = append(parts, part.ID+":"+models.MetadataValue(part.Metadata, "name")+":"+models.MetadataValue(part.Metadata, "extruder"))
+	}
+	if got := strings.Join(parts, " "); got != "1:top:2 2:shell:1 3:feet:3" {
+		t.Errorf("unexpected parts %s", got)
+	}
+	if matrix := models.MetadataValue(settings.Objects[0].Parts[0].Metadata, "matrix"); matrix != identityMatrix {
+		t.Errorf("unexpected matrix of the top %q", matrix)
+	}
+	if instances := settings.Plates[0].ModelInstances; len(instances) != 1 || models.MetadataValue(instances[0].Metadata, "object_id") != "5" {
+		t.Errorf("unexpected plate instances %+v", instances)
+	}
+	if items := settings.Assemble.Items; len(items) != 1 || items[0].ObjectID != "5" {
+		t.Errorf("unexpected assemble items %+v", items)
+	}
+}
+
+func TestGroupObjectsErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		groupings []Grouping
+		wantErr   string
+	}{
+		{name: "unknown", groupings: []Grouping{{Name: "Case", Objects: []string{"lid"}}}, wantErr: `Case: unknown object "lid"`},
+		{name: "twice", groupings: []Grouping{{Name: "A", Objects: []string{"top"}}, {Name: "B", Objects: []string{"top"}}}, wantErr: `B: object "top" is already grouped into A`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			model, settings := standaloneModel()
+			err := GroupObjects(model, settings, tt.groupings)
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("expected error %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestGroup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plate.3mf")
+	model, _ := standaloneModel()
+	if err := (&Writer{}).Write(path, model, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := Group(path, path, []Grouping{{Name: "Case", Objects: []string{"top", "bottom"}}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	grouped, err := (&Reader{}).Read(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(grouped.Build.Items) != 1 || grouped.Resources.Objects[len(grouped.Resources.Objects)-1].Name != "Case" {
+		t.Errorf("unexpected grouped model %+v", grouped)
+	}
+}
@@ -0,0 +1,116 @@
+package threemf
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/philipparndt/go3mf/internal/limits"
+	"github.com/philipparndt/go3mf/internal/models"
+)
+
+// Metadata written into the model of a build with checksums
+const (
+	VersionMetadata      = "go3mf:Version"
+	GeometryHashMetadata = "go3mf:GeometrySHA256"
+)
+
+// StampIntegrity writes the go3mf version and the geometry hash into the model
+// metadata. The hash of the whole file cannot be part of the file itself, so the
+// hash covers the model document from its resources element to the end, which
+// holds all meshes, components and build items and is left unchanged by metadata
+// updates.
+func StampIntegrity(file, version string) error {
+	hash, err := GeometryHash(file)
+	if err != nil {
+		return err
+	}
+	return ApplySettings(file, file, &models.YamlSettings{Metadata: map[string]string{
+		VersionMetadata:      version,
+		GeometryHashMetadata: hash,
+	}})
+}
+
+// GeometryHash returns the SHA-256 of the model of a 3MF file from its resources element to the end
+func GeometryHash(file string) (string, error) {
+	zr, err := limits.OpenZip(file)
+	if err != nil {
+		return "", fmt.Errorf("error opening ZIP: %w", err)
+	}
+	defer zr.Close()
+
+	for _, f := range zr.File {
+		if f.Name != "3D/3dmodel.model" {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return "", fmt.Errorf("error opening %s: %w", f.Name, err)
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return "", fmt.Errorf("error reading %s: %w", f.Name, err)
+		}
+		start, err := resourcesOffset(data)
+		if err != nil {
+			return "", err
+		}
+		sum := sha256.Sum256(data[start:])
+		return hex.EncodeToString(sum[:]), nil
+	}
+	return "", fmt.Errorf("3D/3dmodel.model not found in archive")
+}
+
+// resourcesOffset returns the offset of the resources element of a model document
+func resourcesOffset(data []byte) (int64, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	depth := 0
+	for {
+		offset := dec.InputOffset()
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, fmt.Errorf("error parsing XML: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			depth++
+			if depth == 2 && t.Name.Local == "resources" {
+				return offset, nil
+			}
+		case xml.EndElement:
+			depth--
+		}
+	}
+	return 0, fmt.Errorf("resources element not found")
+}
+
+// WriteChecksum writes a sidecar file with the SHA-256 of file in the format of
+// sha256sum, so the output can be verified with "sha256sum -c", and returns its path
+func WriteChecksum(file string) (string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return "", fmt.Errorf("error opening %s: %w", file, err)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("error reading %s: %w", file, err)
+	}
+
+	sidecar := file + ".sha256"
+	line := fmt.Sprintf("%s  %s\n", hex.EncodeToString(h.Sum(nil)), filepath.Base(file))
+	if err := os.WriteFile(sidecar, []byte(line), 0644); err != nil {
+		return "", fmt.Errorf("error writing %s: %w", sidecar, err)
+	}
+	return sidecar, nil
+}
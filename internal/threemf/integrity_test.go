@@ -0,0 +1,91 @@
+package threemf
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const integrityModel = `<?xml version="1.0" encoding="UTF-8"?>
+<model xmlns="http://schemas.microsoft.com/3dmanufacturing/core/2015/02" unit="millimeter">
+	<metadata name="Application">go3mf</metadata>
+	<resources>
+		<object id="1" type="model"><mesh><vertices><vertex x="0" y="0" z="0"/></vertices><triangles/></mesh></object>
+	</resources>
+	<build><item objectid="1"/></build>
+</model>`
+
+func TestStampIntegrity(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "out.3mf")
+	f, err := os.Create(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	w, err := zw.Create("3D/3dmodel.model")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte(integrityModel))
+	zw.Close()
+	f.Close()
+
+	geometry := integrityModel[strings.Index(integrityModel, "<resources>"):]
+	sum := sha256.Sum256([]byte(geometry))
+	want := hex.EncodeToString(sum[:])
+
+	if err := StampIntegrity(file, "1.2.3"); err != nil {
+		t.Fatalf("StampIntegrity() error = %v", err)
+	}
+	// The metadata does not change the geometry hash
+	got, err := GeometryHash(file)
+	if err != nil {
+		t.Fatalf("GeometryHash() error = %v", err)
+	}
+	if got != want {
+		t.Errorf("GeometryHash() = %s, want %s", got, want)
+	}
+
+	zr, err := zip.OpenReader(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rc, err := zr.File[0].Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(rc)
+	rc.Close()
+	zr.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	model := string(data)
+	for _, metadata := range []string{
+		`<metadata name="go3mf:Version">1.2.3</metadata>`,
+		`<metadata name="go3mf:GeometrySHA256">` + want + `</metadata>`,
+	} {
+		if !strings.Contains(model, metadata) {
+			t.Errorf("model does not contain %s", metadata)
+		}
+	}
+
+	sidecar, err := WriteChecksum(file)
+	if err != nil {
+		t.Fatalf("WriteChecksum() error = %v", err)
+	}
+	content, err := os.ReadFile(sidecar)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stamped, _ := os.ReadFile(file)
+	fileSum := sha256.Sum256(stamped)
+	if wantLine := hex.EncodeToString(fileSum[:]) + "  out.3mf\n"; string(content) != wantLine {
+		t.Errorf("sidecar = %q, want %q", content, wantLine)
+	}
+}
@@ -0,0 +1,63 @@
+package threemf
+
+import (
+	"bytes"
+	"encoding/xml"
+
+	"github.com/philipparndt/go3mf/internal/models"
+)
+
+// MarshalModel returns the XML of a model, indented with tabs or, if compact,
+// without any whitespace between the elements
+func MarshalModel(model *models.Model, compact bool) ([]byte, error) {
+	if !compact {
+		return xml.MarshalIndent(model, "", "\t")
+	}
+	data, err := xml.Marshal(model)
+	if err != nil {
+		return nil, err
+	}
+	// Meshes keep the indentation they were read or written with
+	return compactXML(data), nil
+}
+
+// declareNamespaces declares the namespaces and the language of a model that
+// was read back for writing it again: encoding/xml does not read back the
+// namespace declarations and the language. The Bambu Studio namespace is only
+// declared with bambu, for models written with their Bambu Studio settings.
+func declareNamespaces(model *models.Model, bambu bool) {
+	model.XmlnsP = "http://schemas.microsoft.com/3dmanufacturing/production/2015/06"
+	model.XmlnsBambuStudio = ""
+	if bambu {
+		model.XmlnsBambuStudio = "http://schemas.bambulab.com/package/2021"
+	}
+	if model.Lang == "" {
+		model.Lang = "en-US"
+	}
+}
+
+// compactXML removes whitespace-only text between elements. Text with other
+// characters, e.g. metadata values, is kept as it is.
+func compactXML(data []byte) []byte {
+	var out bytes.Buffer
+	out.Grow(len(data))
+	for i := 0; i < len(data); i++ {
+		out.WriteByte(data[i])
+		if data[i] != '>' {
+			continue
+		}
+		j := i + 1
+		for j < len(data) && isXMLSpace(data[j]) {
+			j++
+		}
+		if j < len(data) && data[j] == '<' {
+			i = j - 1
+		}
+	}
+	return out.Bytes()
+}
+
+// isXMLSpace reports whether b is whitespace in XML
+func isXMLSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r'
+}
@@ -0,0 +1,124 @@
+package threemf
+
+import (
+	"encoding/xml"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/philipparndt/go3mf/internal/models"
+)
+
+func TestMarshalModel(t *testing.T) {
+	model := &models.Model{
+		Unit:     "millimeter",
+		Metadata: []models.Metadata{{Name: "Title", Value: "Box  with < lid"}},
+		Resources: models.Resources{Objects: []models.Object{{
+			ID: "1",
+			Mesh: &models.Mesh{
+				Vertices:  &models.Vertices{Vertex: []models.Vertex{{X: 0, Y: 0, Z: 0}}},
+				Triangles: &models.Triangles{Triangle: []models.Triangle{{V1: 0, V2: 0, V3: 0}}},
+			},
+		}}},
+	}
+
+	pretty, err := MarshalModel(model, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compact, err := MarshalModel(model, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.ContainsAny(string(compact), "\n\t") {
+		t.Errorf("compact XML contains whitespace between elements:\n%s", compact)
+	}
+	if len(compact) >= len(pretty) {
+		t.Errorf("compact XML (%d bytes) is not smaller than pretty XML (%d bytes)", len(compact), len(pretty))
+	}
+
+	// Both parse to the same model, with the metadata text unchanged
+	var fromPretty, fromCompact models.Model
+	if err := xml.Unmarshal(pretty, &fromPretty); err != nil {
+		t.Fatal(err)
+	}
+	if err := xml.Unmarshal(compact, &fromCompact); err != nil {
+		t.Fatal(err)
+	}
+	if got := fromCompact.Metadata[0].Value; got != "Box  with < lid" {
+		t.Errorf("metadata = %q, want %q", got, "Box  with < lid")
+	}
+	if !reflect.DeepEqual(fromPretty.Metadata, fromCompact.Metadata) || fromPretty.Resources.Objects[0].ID != fromCompact.Resources.Objects[0].ID {
+		t.Errorf("compact model differs from pretty model")
+	}
+}
+
+func TestMarshalMesh(t *testing.T) {
+	data := `<model><resources><object id="1"><mesh>
+		<vertices><vertex x="1.5" y="-2" z="1e-1"/><vertex x="10" y="0" z="0"/><vertex x="0" y="10" z="0"/></vertices>
+		<triangles><triangle v1="0" v2="1" v3="2" pid="3" p1="1" paint_color="4 &amp; 8"/></triangles>
+	</mesh></object></resources></model>`
+	var model models.Model
+	if err := xml.Unmarshal([]byte(data), &model); err != nil {
+		t.Fatal(err)
+	}
+	mesh := model.Resources.Objects[0].Mesh
+	if v := mesh.Vertices.Vertex[0]; v != (models.Vertex{X: 1.5, Y: -2, Z: 0.1}) {
+		t.Errorf("unexpected vertex %+v", v)
+	}
+
+	out, err := MarshalModel(&model, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`<vertex x="1.5" y="-2" z="0.1"/>`,
+		`<triangle v1="0" v2="1" v3="2" pid="3" p1="1" paint_color="4 &amp; 8"/>`,
+	} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("expected %s in\n%s", want, out)
+		}
+	}
+
+	var again models.Model
+	if err := xml.Unmarshal(out, &again); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(again.Resources.Objects[0].Mesh, mesh) {
+		t.Errorf("mesh changed on the round trip: %+v", again.Resources.Objects[0].Mesh)
+	}
+}
+
+func TestDeclareNamespaces(t *testing.T) {
+	tests := []struct {
+		name      string
+		lang      string
+		bambu     bool
+		wantBambu bool
+		wantLang  string
+	}{
+		{name: "bambu", bambu: true, wantBambu: true, wantLang: "en-US"},
+		{name: "plain", wantLang: "en-US"},
+		{name: "language kept", lang: "de-DE", wantLang: "de-DE"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			model := &models.Model{Lang: tt.lang, XmlnsBambuStudio: "x"}
+			declareNamespaces(model, tt.bambu)
+
+			data, err := MarshalModel(model, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(string(data), `xmlns:p="http://schemas.microsoft.com/3dmanufacturing/production/2015/06"`) {
+				t.Errorf("production namespace not declared:\n%s", data)
+			}
+			if got := strings.Contains(string(data), `"http://schemas.bambulab.com/package/2021"`); got != tt.wantBambu {
+				t.Errorf("Bambu Studio namespace declared = %v, want %v:\n%s", got, tt.wantBambu, data)
+			}
+			if model.Lang != tt.wantLang {
+				t.Errorf("Lang = %q, want %q", model.Lang, tt.wantLang)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package threemf
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/philipparndt/go3mf/internal/models"
+)
+
+// defaultDisplayColor is the display color of filament slots without a color
+const defaultDisplayColor = "#FFFFFF"
+
+// applyMaterials adds a base material with the display color of every filament
+// slot and assigns the meshes of the model to the material of their slot, so that
+// viewers without Bambu support (e.g. 3D Builder) show the colors. Meshes carry
+// their filament slot as pid; without colored filaments the model is unchanged.
+func applyMaterials(model *models.Model, filaments []models.YamlFilament) {
+	colored := false
+	for _, filament := range filaments {
+		colored = colored || filament.Color != ""
+	}
+	if !colored {
+		return
+	}
+
+	slots := len(filaments)
+	for _, obj := range model.Resources.Objects {
+		if slot, err := strconv.Atoi(obj.PID); err == nil && obj.Mesh != nil && slot > slots {
+			slots = slot
+		}
+	}
+
+	id := strconv.Itoa(getMaxObjectID(model) + 1)
+	materials := &models.BaseMaterials{ID: id}
+	for slot := 1; slot <= slots; slot++ {
+		base := models.Base{Name: fmt.Sprintf("Filament %d", slot), DisplayColor: defaultDisplayColor}
+		if slot <= len(filaments) {
+			if filaments[slot-1].Type != "" {
+				base.Name = filaments[slot-1].Type
+			}
+			if filaments[slot-1].Color != "" {
+				base.DisplayColor = filaments[slot-1].Color
+			}
+		}
+		materials.Bases = append(materials.Bases, base)
+	}
+
+	for i := range model.Resources.Objects {
+		obj := &model.Resources.Objects[i]
+		slot, err := strconv.Atoi(obj.PID)
+		if err != nil || obj.Mesh == nil || slot < 1 {
+			continue
+		}
+		obj.PID = id
+		obj.PIndex = strconv.Itoa(slot - 1)
+	}
+	model.Resources.BaseMaterials = materials
+}
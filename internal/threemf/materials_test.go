@@ -0,0 +1,48 @@
+package threemf
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/philipparndt/go3mf/internal/models"
+)
+
+func TestApplyMaterials(t *testing.T) {
+	newModel := func() *models.Model {
+		return &models.Model{Resources: models.Resources{Objects: []models.Object{
+			{ID: "1", PID: "1", PIndex: "0", Mesh: &models.Mesh{}},
+			{ID: "2", PID: "3", PIndex: "0", Mesh: &models.Mesh{}},
+			{ID: "3", Components: &models.Components{}},
+		}}}
+	}
+
+	t.Run("colored filaments", func(t *testing.T) {
+		model := newModel()
+		applyMaterials(model, []models.YamlFilament{{Type: "PLA", Color: "#FF0000"}, {Color: "#00FF00"}})
+
+		want := &models.BaseMaterials{ID: "4", Bases: []models.Base{
+			{Name: "PLA", DisplayColor: "#FF0000"},
+			{Name: "Filament 2", DisplayColor: "#00FF00"},
+			{Name: "Filament 3", DisplayColor: "#FFFFFF"},
+		}}
+		if !reflect.DeepEqual(model.Resources.BaseMaterials, want) {
+			t.Errorf("BaseMaterials = %+v, want %+v", model.Resources.BaseMaterials, want)
+		}
+
+		var got [][2]string
+		for _, obj := range model.Resources.Objects {
+			got = append(got, [2]string{obj.PID, obj.PIndex})
+		}
+		if wantRefs := [][2]string{{"4", "0"}, {"4", "2"}, {"", ""}}; !reflect.DeepEqual(got, wantRefs) {
+			t.Errorf("material references = %v, want %v", got, wantRefs)
+		}
+	})
+
+	t.Run("no colors", func(t *testing.T) {
+		model := newModel()
+		applyMaterials(model, []models.YamlFilament{{Type: "PLA"}})
+		if !reflect.DeepEqual(model, newModel()) {
+			t.Errorf("model changed without colored filaments: %+v", model)
+		}
+	})
+}
@@ -0,0 +1,103 @@
+package threemf
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+
+	"github.com/philipparndt/go3mf/internal/limits"
+	"github.com/philipparndt/go3mf/internal/models"
+)
+
+// Archives are 3MF files held in memory by name, e.g. uploads of a server.
+// Files whose name is not among them are read from disk.
+type Archives map[string]*zip.Reader
+
+// open opens a 3MF file from memory or from disk. The returned function closes
+// the file.
+func (a Archives) open(name string) (*zip.Reader, func() error, error) {
+	if zr, ok := a[name]; ok {
+		return zr, func() error { return nil }, nil
+	}
+	zr, err := limits.OpenZip(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &zr.Reader, zr.Close, nil
+}
+
+// AddArchive makes a 3MF file of the given size held in memory an input of the
+// combines. The name is used in place of a file path in the list of inputs.
+func (c *Combiner) AddArchive(name string, ra io.ReaderAt, size int64) error {
+	zr, err := zip.NewReader(ra, size)
+	if err != nil {
+		return fmt.Errorf("error opening %s: %w", name, err)
+	}
+	if err := limits.CheckArchive(zr); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	if c.archives == nil {
+		c.archives = make(Archives)
+		c.writer.Archives = c.archives
+	}
+	c.archives[name] = zr
+	return nil
+}
+
+// AddModel makes a model composed in memory an input of the combines, like a
+// 3MF file with the model. The name is used in place of a file path in the
+// list of inputs.
+func (c *Combiner) AddModel(name string, model *models.Model) error {
+	var buf bytes.Buffer
+	if err := encodePackage(&buf, model); err != nil {
+		return fmt.Errorf("error encoding %s: %w", name, err)
+	}
+	return c.AddArchive(name, bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+}
+
+// packageEntries are the content types and relationships of a 3MF file with
+// only a model, in the order they are written
+var packageEntries = []struct{ name, content string }{
+	{"[Content_Types].xml", xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+	<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+	<Default Extension="model" ContentType="application/vnd.ms-package.3dmanufacturing-3dmodel+xml"/>
+</Types>`},
+	{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+	<Relationship Id="rel0" Target="/3D/3dmodel.model" Type="` + modelRelationship + `"/>
+</Relationships>`},
+}
+
+// encodePackage writes a 3MF file with only a model to out
+func encodePackage(out io.Writer, model *models.Model) error {
+	outZip := zip.NewWriter(out)
+	if err := (&Writer{CompactXML: true}).writeModel(outZip, model); err != nil {
+		return err
+	}
+	for _, entry := range packageEntries {
+		w, err := outZip.Create(entry.name)
+		if err != nil {
+			return fmt.Errorf("error creating %s: %w", entry.name, err)
+		}
+		if _, err := w.Write([]byte(entry.content)); err != nil {
+			return fmt.Errorf("error writing %s: %w", entry.name, err)
+		}
+	}
+	return outZip.Close()
+}
+
+// SetOutput writes the 3MF files of the combines to w instead of their output
+// file (nil = write the output file)
+func (c *Combiner) SetOutput(w io.Writer) {
+	c.output = w
+}
+
+// create writes a 3MF file with encode to the output of the combiner, or to
+// outputFile if it has none
+func (c *Combiner) create(outputFile string, encode func(io.Writer) error) error {
+	if c.output != nil {
+		return encode(c.output)
+	}
+	return createFile(outputFile, encode)
+}
@@ -0,0 +1,88 @@
+package threemf
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/philipparndt/go3mf/internal/models"
+)
+
+// triangleModel returns a model with a triangle mesh of the given size
+func triangleModel(size float64) *models.Model {
+	return &models.Model{
+		Unit: "millimeter",
+		Resources: models.Resources{Objects: []models.Object{{
+			ID:   "1",
+			Type: "model",
+			Mesh: &models.Mesh{
+				Vertices:  &models.Vertices{Vertex: []models.Vertex{{X: 0, Y: 0, Z: 0}, {X: size, Y: 0, Z: 0}, {X: 0, Y: size, Z: size}}},
+				Triangles: &models.Triangles{Triangle: []models.Triangle{{V1: 0, V2: 1, V3: 2}}},
+			},
+		}}},
+		Build: models.Build{Items: []models.Item{{ObjectID: "1"}}},
+	}
+}
+
+func TestCombineInMemory(t *testing.T) {
+	// Nothing may be written to the working directory
+	dir := t.TempDir()
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(cwd)
+
+	combiner := NewCombiner()
+	if err := combiner.AddModel("small", triangleModel(10)); err != nil {
+		t.Fatalf("AddModel() error = %v", err)
+	}
+	var input bytes.Buffer
+	if err := encodePackage(&input, triangleModel(20)); err != nil {
+		t.Fatal(err)
+	}
+	if err := combiner.AddArchive("large.3mf", bytes.NewReader(input.Bytes()), int64(input.Len())); err != nil {
+		t.Fatalf("AddArchive() error = %v", err)
+	}
+
+	var output bytes.Buffer
+	combiner.SetOutput(&output)
+	groups := []models.ObjectGroup{
+		{Name: "small", Parts: []models.ScadFile{{Path: "small", Name: "small"}}},
+		{Name: "large", Parts: []models.ScadFile{{Path: "large.3mf", Name: "large"}}},
+	}
+	if err := combiner.CombineWithObjectGroups([]string{"small", "large.3mf"}, groups, "combined.3mf", 10, models.PackingAlgorithmDefault); err != nil {
+		t.Fatalf("CombineWithObjectGroups() error = %v", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) > 0 {
+		t.Errorf("combine wrote %s to the working directory", entries[0].Name())
+	}
+
+	model, _, err := (&Reader{}).ReadArchive(bytes.NewReader(output.Bytes()), int64(output.Len()))
+	if err != nil {
+		t.Fatalf("ReadArchive() error = %v", err)
+	}
+	if len(model.Build.Items) != 2 {
+		t.Errorf("combined model has %d build items, want 2", len(model.Build.Items))
+	}
+	if problems := verifyBytes(t, output.Bytes()); len(problems) > 0 {
+		t.Errorf("combined model has problems: %v", problems)
+	}
+}
+
+// verifyBytes verifies a 3MF file held in memory
+func verifyBytes(t *testing.T, data []byte) []string {
+	file := t.TempDir() + "/combined.3mf"
+	if err := os.WriteFile(file, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return Verify(file)
+}
@@ -0,0 +1,33 @@
+package threemf
+
+import "github.com/philipparndt/go3mf/internal/models"
+
+// setObjectType sets the type of a mesh object to the type of its part. Parts
+// without a type keep the type of their input, objects without one are models.
+func setObjectType(obj *models.Object, objectType models.ObjectType) {
+	if objectType != "" {
+		obj.Type = string(objectType)
+	} else if obj.Type == "" {
+		obj.Type = string(models.ObjectTypeModel)
+	}
+}
+
+// ParentType returns the type of an object made of components referencing the
+// given meshes: the type of the meshes if they all share one, otherwise model
+func ParentType(meshes []models.Object) string {
+	parentType := ""
+	for _, mesh := range meshes {
+		meshType := mesh.Type
+		if meshType == "" {
+			meshType = string(models.ObjectTypeModel)
+		}
+		if parentType != "" && meshType != parentType {
+			return string(models.ObjectTypeModel)
+		}
+		parentType = meshType
+	}
+	if parentType == "" {
+		return string(models.ObjectTypeModel)
+	}
+	return parentType
+}
@@ -0,0 +1,53 @@
+package threemf
+
+import (
+	"testing"
+
+	"github.com/philipparndt/go3mf/internal/models"
+)
+
+func TestSetObjectType(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		objectType models.ObjectType
+		want       string
+	}{
+		{name: "default", want: "model"},
+		{name: "input type kept", input: "support", want: "support"},
+		{name: "part type", input: "model", objectType: models.ObjectTypeOther, want: "other"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj := models.Object{Type: tt.input}
+			setObjectType(&obj, tt.objectType)
+			if obj.Type != tt.want {
+				t.Errorf("setObjectType() type = %q, want %q", obj.Type, tt.want)
+			}
+		})
+	}
+}
+
+func TestParentType(t *testing.T) {
+	tests := []struct {
+		name  string
+		types []string
+		want  string
+	}{
+		{name: "no meshes", want: "model"},
+		{name: "models", types: []string{"model", ""}, want: "model"},
+		{name: "all support", types: []string{"support", "support"}, want: "support"},
+		{name: "mixed", types: []string{"model", "support"}, want: "model"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var meshes []models.Object
+			for _, meshType := range tt.types {
+				meshes = append(meshes, models.Object{Type: meshType})
+			}
+			if got := ParentType(meshes); got != tt.want {
+				t.Errorf("ParentType() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package threemf
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/philipparndt/go3mf/internal/limits"
+)
+
+// relationships is an OPC relationships part, e.g. _rels/.rels
+type relationships struct {
+	XMLName       xml.Name       `xml:"http://schemas.openxmlformats.org/package/2006/relationships Relationships"`
+	Relationships []relationship `xml:"Relationship"`
+}
+
+// relationship is a relationship of an OPC relationships part
+type relationship struct {
+	ID     string `xml:"Id,attr"`
+	Target string `xml:"Target,attr"`
+	Type   string `xml:"Type,attr"`
+}
+
+// WritePlain writes a copy of a 3MF file with the package entries only: the
+// model, the content types and the relationships. Slicer project files and
+// other auxiliary entries are left out, as are the relationships to them.
+func WritePlain(source, dest string) error {
+	return writePackage(source, dest, nil)
+}
+
+// writePackage writes the package entries of a 3MF file to dest, with the
+// content of the entries in replace instead of the content of the source
+func writePackage(source, dest string, replace map[string][]byte) error {
+	out, err := os.Create(dest)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", dest, err)
+	}
+	defer out.Close()
+
+	if err := copyPackage(source, out, replace); err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+// copyPackage writes the package entries of a 3MF file to w, with the content
+// of the entries in replace instead of the content of the source
+func copyPackage(source string, w io.Writer, replace map[string][]byte) error {
+	zr, err := limits.OpenZip(source)
+	if err != nil {
+		return fmt.Errorf("error opening 3MF file: %w", err)
+	}
+	defer zr.Close()
+
+	entries := make(map[string]bool)
+	for _, f := range zr.File {
+		if isPackageEntry(f.Name) {
+			entries[f.Name] = true
+		}
+	}
+
+	zw := zip.NewWriter(w)
+	for _, f := range zr.File {
+		if !entries[f.Name] {
+			continue
+		}
+		data, ok := replace[f.Name]
+		if !ok {
+			if data, err = readEntry(f); err != nil {
+				return err
+			}
+		}
+		if strings.HasSuffix(f.Name, ".rels") {
+			if data, err = keepRelationships(data, entries); err != nil {
+				return fmt.Errorf("%s: %w", f.Name, err)
+			}
+		}
+		entry, err := zw.Create(f.Name)
+		if err != nil {
+			return fmt.Errorf("error creating ZIP entry: %w", err)
+		}
+		if _, err := entry.Write(data); err != nil {
+			return fmt.Errorf("error writing %s: %w", f.Name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("error writing output file: %w", err)
+	}
+	return nil
+}
+
+// keepRelationships removes the relationships to entries that are not part of
+// the archive
+func keepRelationships(data []byte, entries map[string]bool) ([]byte, error) {
+	var rels relationships
+	if err := xml.Unmarshal(data, &rels); err != nil {
+		return nil, err
+	}
+	kept := rels.Relationships[:0]
+	for _, rel := range rels.Relationships {
+		if entries[strings.TrimPrefix(rel.Target, "/")] {
+			kept = append(kept, rel)
+		}
+	}
+	rels.Relationships = kept
+
+	out, err := xml.MarshalIndent(rels, "", "\t")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), out...), nil
+}
+
+// readEntry returns the content of a ZIP entry
+func readEntry(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("error opening %s: %w", f.Name, err)
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", f.Name, err)
+	}
+	return data, nil
+}
@@ -0,0 +1,81 @@
+package threemf
+
+import (
+	"archive/zip"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestWritePlain(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "bambu.3mf")
+	f, err := os.Create(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	for name, content := range map[string]string{
+		"[Content_Types].xml": "types",
+		"_rels/.rels": `<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+	<Relationship Id="rel0" Target="/3D/3dmodel.model" Type="http://schemas.microsoft.com/3dmanufacturing/2013/01/3dmodel"/>
+	<Relationship Id="rel1" Target="/Metadata/plate_1.png" Type="http://schemas.openxmlformats.org/package/2006/relationships/metadata/thumbnail"/>
+</Relationships>`,
+		"3D/3dmodel.model":                 "model",
+		"Metadata/model_settings.config":   "settings",
+		"Metadata/project_settings.config": "project",
+		"Metadata/plate_1.png":             "thumbnail",
+	} {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	dest := filepath.Join(dir, "plain.3mf")
+	if err := WritePlain(source, dest); err != nil {
+		t.Fatalf("WritePlain() error = %v", err)
+	}
+
+	zr, err := zip.OpenReader(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+	entries := make(map[string]string)
+	for _, file := range zr.File {
+		rc, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		entries[file.Name] = string(data)
+	}
+
+	var names []string
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if want := []string{"3D/3dmodel.model", "[Content_Types].xml", "_rels/.rels"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("entries = %v, want %v", names, want)
+	}
+	if entries["3D/3dmodel.model"] != "model" {
+		t.Errorf("model = %q, want it unchanged", entries["3D/3dmodel.model"])
+	}
+	rels := entries["_rels/.rels"]
+	if !strings.Contains(rels, `Target="/3D/3dmodel.model"`) || strings.Contains(rels, "plate_1.png") {
+		t.Errorf("relationships should only reference the model:\n%s", rels)
+	}
+}
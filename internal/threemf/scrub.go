@@ -0,0 +1,302 @@
+package threemf
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/philipparndt/go3mf/internal/limits"
+)
+
+// identifyingKeys are names of values that identify a person or a point in time,
+// as embedded by slicers and model platforms (lower case)
+var identifyingKeys = map[string]bool{
+	"author":           true,
+	"designer":         true,
+	"designeruserid":   true,
+	"designercover":    true,
+	"profileuserid":    true,
+	"profileusername":  true,
+	"profilecover":     true,
+	"creationdate":     true,
+	"modificationdate": true,
+	"sn":               true,
+}
+
+// identifyingParts are parts of names of values that identify a user, a machine
+// or a print host (lower case)
+var identifyingParts = []string{"user", "serial", "machine_sn", "dev_id", "device_id", "apikey", "api_key", "password", "printhost", "print_host", "timestamp"}
+
+// identifying reports whether a value of a model or slicer settings identifies
+// a person, a machine or a point in time by its name
+func identifying(key string) bool {
+	key = strings.ToLower(strings.TrimSpace(key))
+	if identifyingKeys[key] || strings.HasSuffix(key, "_sn") || (strings.HasSuffix(key, "date") && !strings.HasSuffix(key, "update")) {
+		return true
+	}
+	for _, part := range identifyingParts {
+		if strings.Contains(key, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// Scrub writes a copy of a 3MF file without identifying metadata: user names and
+// IDs, machine serials, print host credentials, creation and modification dates
+// of the model metadata, the slicer settings (JSON, XML and key = value files)
+// and the archive entries. Geometry and all other values are kept. inputFile and
+// outputFile may be the same file. The removed values are returned as
+// "entry: name".
+func Scrub(inputFile, outputFile string) ([]string, error) {
+	zr, err := limits.OpenZip(inputFile)
+	if err != nil {
+		return nil, fmt.Errorf("error opening ZIP: %w", err)
+	}
+	defer zr.Close()
+
+	var removed []string
+	err = replaceFile(outputFile, func(w io.Writer) error {
+		var err error
+		removed, err = scrub(&zr.Reader, w)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return removed, nil
+}
+
+// scrub copies the archive to w without identifying values. All entries are
+// written without modification time.
+func scrub(zr *zip.Reader, w io.Writer) ([]string, error) {
+	outZip := zip.NewWriter(w)
+	var removed []string
+	dated := false
+	for _, file := range zr.File {
+		// ZIP files without times have the earliest time the format supports
+		if file.Modified.After(time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)) {
+			dated = true
+		}
+		rc, err := file.Open()
+		if err != nil {
+			return nil, fmt.Errorf("error opening %s: %w", file.Name, err)
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s: %w", file.Name, err)
+		}
+
+		var keys []string
+		switch ext := strings.ToLower(path.Ext(file.Name)); {
+		case ext == ".model":
+			data, keys, err = scrubModel(data)
+		case ext == ".config" || ext == ".json" || ext == ".ini":
+			data, keys, err = scrubSettings(data)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error scrubbing %s: %w", file.Name, err)
+		}
+		for _, key := range keys {
+			removed = append(removed, file.Name+": "+key)
+		}
+
+		dst, err := outZip.CreateHeader(&zip.FileHeader{Name: file.Name, Method: zip.Deflate})
+		if err != nil {
+			return nil, fmt.Errorf("error creating ZIP entry: %w", err)
+		}
+		if _, err := dst.Write(data); err != nil {
+			return nil, fmt.Errorf("error writing %s: %w", file.Name, err)
+		}
+	}
+	if dated {
+		removed = append(removed, "archive: modification times of the entries")
+	}
+	return removed, outZip.Close()
+}
+
+// scrubModel removes the identifying metadata elements of a model
+func scrubModel(data []byte) ([]byte, []string, error) {
+	names, err := modelMetadataNames(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	remove := make(map[string]string)
+	var keys []string
+	for _, name := range names {
+		if identifying(name) {
+			remove[name] = ""
+			keys = append(keys, name)
+		}
+	}
+	if len(remove) == 0 {
+		return data, nil, nil
+	}
+	data, err = setModelMetadata(data, remove)
+	return data, keys, err
+}
+
+// modelMetadataNames returns the names of the metadata elements of a model
+func modelMetadataNames(data []byte) ([]string, error) {
+	var names []string
+	depth := 0
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return names, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error parsing XML: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			depth++
+			// Metadata precedes the resources
+			if depth == 2 && t.Name.Local == "resources" {
+				return names, nil
+			}
+			if depth == 2 && t.Name.Local == "metadata" {
+				for _, attr := range t.Attr {
+					if attr.Name.Local == "name" {
+						names = append(names, attr.Value)
+					}
+				}
+			}
+		case xml.EndElement:
+			depth--
+		}
+	}
+}
+
+// scrubSettings removes identifying values from slicer settings, which are JSON
+// (Bambu Studio project settings), XML with key attributes (model settings, slice
+// info) or key = value lines (PrusaSlicer)
+func scrubSettings(data []byte) ([]byte, []string, error) {
+	switch trimmed := bytes.TrimSpace(data); {
+	case len(trimmed) == 0:
+		return data, nil, nil
+	case trimmed[0] == '{':
+		return scrubJSON(data)
+	case trimmed[0] == '<':
+		return scrubXMLSettings(data)
+	default:
+		return scrubKeyValues(data)
+	}
+}
+
+// scrubJSON removes the identifying keys of a JSON object
+func scrubJSON(data []byte) ([]byte, []string, error) {
+	settings := map[string]interface{}{}
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return nil, nil, fmt.Errorf("error parsing JSON: %w", err)
+	}
+	var keys []string
+	for key := range settings {
+		if identifying(key) {
+			keys = append(keys, key)
+			delete(settings, key)
+		}
+	}
+	if len(keys) == 0 {
+		return data, nil, nil
+	}
+	sort.Strings(keys)
+	out, err := json.MarshalIndent(settings, "", "    ")
+	if err != nil {
+		return nil, nil, fmt.Errorf("error marshaling JSON: %w", err)
+	}
+	return append(out, '\n'), keys, nil
+}
+
+// scrubXMLSettings removes the elements whose key attribute is identifying, e.g.
+// <metadata key="..." value="..."/>, and leaves the rest of the document untouched
+func scrubXMLSettings(data []byte) ([]byte, []string, error) {
+	type span struct{ start, end int64 }
+	var remove []span
+	var keys []string
+	removeStart, removeDepth := int64(-1), 0
+	depth := 0
+
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		offset := dec.InputOffset()
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("error parsing XML: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			depth++
+			if removeStart >= 0 {
+				continue
+			}
+			for _, attr := range t.Attr {
+				if attr.Name.Local == "key" && identifying(attr.Value) {
+					removeStart, removeDepth = offset, depth
+					keys = append(keys, attr.Value)
+				}
+			}
+		case xml.EndElement:
+			if removeStart >= 0 && depth == removeDepth {
+				remove = append(remove, span{trimIndent(data, removeStart), dec.InputOffset()})
+				removeStart = -1
+			}
+			depth--
+		}
+	}
+	if len(remove) == 0 {
+		return data, nil, nil
+	}
+
+	var out bytes.Buffer
+	pos := int64(0)
+	for _, r := range remove {
+		out.Write(data[pos:r.start])
+		pos = r.end
+	}
+	out.Write(data[pos:])
+	return out.Bytes(), keys, nil
+}
+
+// scrubKeyValues removes the lines of identifying keys from key = value settings,
+// and the comment with the date the settings were generated
+func scrubKeyValues(data []byte) ([]byte, []string, error) {
+	var out bytes.Buffer
+	var keys []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, len(data)+1)
+	for scanner.Scan() {
+		line := scanner.Text()
+		content := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), ";"))
+		if strings.HasPrefix(content, "generated by ") {
+			keys = append(keys, "generated by")
+			continue
+		}
+		if key, _, ok := strings.Cut(content, "="); ok && identifying(key) {
+			keys = append(keys, strings.TrimSpace(key))
+			continue
+		}
+		out.WriteString(line)
+		out.WriteByte('\n')
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+	if len(keys) == 0 {
+		return data, nil, nil
+	}
+	return out.Bytes(), keys, nil
+}
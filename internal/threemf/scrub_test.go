@@ -0,0 +1,130 @@
+package threemf
+
+import (
+	"archive/zip"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIdentifying(t *testing.T) {
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{"Designer", true},
+		{"DesignerUserId", true},
+		{"CreationDate", true},
+		{"print_user", true},
+		{"printer_serial", true},
+		{"machine_sn", true},
+		{"printhost_apikey", true},
+		{"Title", false},
+		{"layer_height", false},
+		{"auto_update", false},
+		{"filament_settings_id", false},
+	}
+	for _, tt := range tests {
+		if got := identifying(tt.key); got != tt.want {
+			t.Errorf("identifying(%q) = %v, want %v", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestScrub(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "model.3mf")
+	f, err := os.Create(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	for _, entry := range []struct{ name, content string }{
+		{"3D/3dmodel.model", `<?xml version="1.0" encoding="UTF-8"?>
+<model xmlns="http://schemas.microsoft.com/3dmanufacturing/core/2015/02" unit="millimeter">
+	<metadata name="Title">Box</metadata>
+	<metadata name="Designer">jdoe</metadata>
+	<metadata name="CreationDate">2024-01-02</metadata>
+	<resources></resources>
+	<build></build>
+</model>`},
+		{"Metadata/project_settings.config", `{"layer_height": "0.2", "printer_serial": "01P00A123"}`},
+		{"Metadata/slice_info.config", `<config>
+	<header>
+		<header_item key="X-BBL-Client-Type" value="slicer"/>
+		<header_item key="user_id" value="42"/>
+	</header>
+</config>`},
+		{"Metadata/Slic3r_PE.config", "; generated by PrusaSlicer 2.7.1 on 2024-01-02 at 10:00:00 UTC\n; layer_height = 0.2\n; printhost_apikey = secret\n"},
+		{"Metadata/plate_1.png", "thumbnail"},
+	} {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: entry.name, Modified: time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(entry.content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	output := filepath.Join(dir, "scrubbed.3mf")
+	removed, err := Scrub(file, output)
+	if err != nil {
+		t.Fatalf("Scrub() error = %v", err)
+	}
+	want := []string{
+		"3D/3dmodel.model: Designer",
+		"3D/3dmodel.model: CreationDate",
+		"Metadata/project_settings.config: printer_serial",
+		"Metadata/slice_info.config: user_id",
+		"Metadata/Slic3r_PE.config: generated by",
+		"Metadata/Slic3r_PE.config: printhost_apikey",
+		"archive: modification times of the entries",
+	}
+	if !reflect.DeepEqual(removed, want) {
+		t.Errorf("Scrub() removed %v, want %v", removed, want)
+	}
+
+	zr, err := zip.OpenReader(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+	entries := make(map[string]string)
+	for _, file := range zr.File {
+		if file.Modified.Year() > 1980 {
+			t.Errorf("%s has a modification time", file.Name)
+		}
+		rc, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		entries[file.Name] = string(data)
+	}
+	for _, value := range []string{"jdoe", "2024-01-02", "01P00A123", "user_id", "secret"} {
+		for name, content := range entries {
+			if strings.Contains(content, value) {
+				t.Errorf("%s still contains %q:\n%s", name, value, content)
+			}
+		}
+	}
+	for name, value := range map[string]string{
+		"3D/3dmodel.model":                 "<metadata name=\"Title\">Box</metadata>",
+		"Metadata/project_settings.config": "layer_height",
+		"Metadata/slice_info.config":       "X-BBL-Client-Type",
+		"Metadata/Slic3r_PE.config":        "; layer_height = 0.2",
+		"Metadata/plate_1.png":             "thumbnail",
+	} {
+		if !strings.Contains(entries[name], value) {
+			t.Errorf("%s lost %q:\n%s", name, value, entries[name])
+		}
+	}
+}
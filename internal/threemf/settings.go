@@ -10,6 +10,35 @@ import (
 	"github.com/philipparndt/go3mf/internal/models"
 )
 
+// unknownFaceCount is the face count written for parts whose mesh is not known
+const unknownFaceCount = 12
+
+// FaceCount returns the number of triangles of the mesh of an object for the
+// mesh statistics of the model settings
+func FaceCount(obj *models.Object) int {
+	if obj.Mesh == nil || obj.Mesh.Triangles == nil {
+		return unknownFaceCount
+	}
+	return len(obj.Mesh.Triangles.Triangle)
+}
+
+// FaceCounts returns the face counts of the meshes of objects
+func FaceCounts(objects []models.Object) []int {
+	counts := make([]int, len(objects))
+	for i := range objects {
+		counts[i] = FaceCount(&objects[i])
+	}
+	return counts
+}
+
+// partFaceCount returns the face count of a part of an object group
+func partFaceCount(group models.ObjectGroup, volumeIndex int) int {
+	if volumeIndex < len(group.FaceCounts) {
+		return group.FaceCounts[volumeIndex]
+	}
+	return unknownFaceCount
+}
+
 // WriteModelSettings writes the Bambu Studio model_settings.config file
 func WriteModelSettings(outZip *zip.Writer, objectGroups []models.ObjectGroup, buildItems []models.Item) error {
 	var settingsObjects []models.SettingsObject
@@ -29,13 +58,13 @@ func WriteModelSettings(outZip *zip.Writer, objectGroups []models.ObjectGroup, b
 				filamentSlot = ((partID - 1) % 4) + 1
 			}
 
-			faceCount := 12 // Placeholder - would need actual mesh analysis
+			faceCount := partFaceCount(group, volumeIndex)
 			totalFaces += faceCount
 
 			// Build metadata list
 			metadata := []models.SettingsMetadata{
 				{Key: "name", Value: scadFile.Name},
-				{Key: "matrix", Value: "1 0 0 0 0 1 0 0 0 0 1 0 0 0 0 1"},
+				{Key: "matrix", Value: PartMatrix(scadFile.Matrix)},
 				{Key: "source_file", Value: "combined.3mf"},
 				{Key: "source_object_id", Value: strconv.Itoa(sourceObjectID)},
 				{Key: "source_volume_id", Value: strconv.Itoa(volumeIndex)},
@@ -49,8 +78,12 @@ func WriteModelSettings(outZip *zip.Writer, objectGroups []models.ObjectGroup, b
 				})
 			}
 
+			id := strconv.Itoa(partID)
+			if volumeIndex < len(group.PartIDs) {
+				id = group.PartIDs[volumeIndex]
+			}
 			parts = append(parts, models.Part{
-				ID:       strconv.Itoa(partID),
+				ID:       id,
 				Subtype:  "normal_part",
 				Metadata: metadata,
 				MeshStat: models.MeshStat{
@@ -61,14 +94,14 @@ func WriteModelSettings(outZip *zip.Writer, objectGroups []models.ObjectGroup, b
 		}
 		sourceObjectID++
 
+		objectMetadata, err := objectSettingsMetadata(group, totalFaces)
+		if err != nil {
+			return err
+		}
 		settingsObjects = append(settingsObjects, models.SettingsObject{
-			ID: group.ID,
-			Metadata: []models.SettingsMetadata{
-				{Key: "name", Value: group.Name},
-				{Key: "extruder", Value: "1"},
-				{FaceCount: totalFaces},
-			},
-			Parts: parts,
+			ID:       group.ID,
+			Metadata: objectMetadata,
+			Parts:    parts,
 		})
 
 		modelInstances = append(modelInstances, models.ModelInstance{
@@ -147,12 +180,12 @@ func WriteModelSettingsWithPlates(outZip *zip.Writer, objectGroups []models.Obje
 				filamentSlot = ((partID - 1) % 4) + 1
 			}
 
-			faceCount := 12
+			faceCount := partFaceCount(group, volumeIndex)
 			totalFaces += faceCount
 
 			metadata := []models.SettingsMetadata{
 				{Key: "name", Value: scadFile.Name},
-				{Key: "matrix", Value: "1 0 0 0 0 1 0 0 0 0 1 0 0 0 0 1"},
+				{Key: "matrix", Value: PartMatrix(scadFile.Matrix)},
 				{Key: "source_file", Value: "combined.3mf"},
 				{Key: "source_object_id", Value: strconv.Itoa(sourceObjectID)},
 				{Key: "source_volume_id", Value: strconv.Itoa(volumeIndex)},
@@ -177,14 +210,14 @@ func WriteModelSettingsWithPlates(outZip *zip.Writer, objectGroups []models.Obje
 		}
 		sourceObjectID++
 
+		objectMetadata, err := objectSettingsMetadata(group, totalFaces)
+		if err != nil {
+			return err
+		}
 		settingsObjects = append(settingsObjects, models.SettingsObject{
-			ID: group.ID,
-			Metadata: []models.SettingsMetadata{
-				{Key: "name", Value: group.Name},
-				{Key: "extruder", Value: "1"},
-				{FaceCount: totalFaces},
-			},
-			Parts: parts,
+			ID:       group.ID,
+			Metadata: objectMetadata,
+			Parts:    parts,
 		})
 	}
 
@@ -272,3 +305,18 @@ func AddBambuMetadata(model *models.Model) {
 		{Name: "ModificationDate", Value: time.Now().Format("2006-01-02")},
 	}, model.Metadata...)
 }
+
+// objectSettingsMetadata returns the metadata of an object in the model settings
+// with its slicer settings
+func objectSettingsMetadata(group models.ObjectGroup, faceCount int) ([]models.SettingsMetadata, error) {
+	slicer, err := group.Slicer.SettingsMetadata()
+	if err != nil {
+		return nil, fmt.Errorf("object %s: %w", group.Name, err)
+	}
+	metadata := []models.SettingsMetadata{
+		{Key: "name", Value: group.Name},
+		{Key: "extruder", Value: "1"},
+	}
+	metadata = append(metadata, slicer...)
+	return append(metadata, models.SettingsMetadata{FaceCount: faceCount}), nil
+}
@@ -0,0 +1,186 @@
+package threemf
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/philipparndt/go3mf/internal/models"
+)
+
+func TestObjectSettingsMetadata(t *testing.T) {
+	tests := []struct {
+		name    string
+		slicer  models.SlicerOptions
+		want    []models.SettingsMetadata
+		wantErr string
+	}{
+		{
+			name: "profile defaults",
+			want: []models.SettingsMetadata{{Key: "name", Value: "box"}, {Key: "extruder", Value: "1"}, {FaceCount: 12}},
+		},
+		{
+			name:   "brim, supports and seam",
+			slicer: models.SlicerOptions{Brim: "outer_only", Supports: "tree", Seam: "rear"},
+			want: []models.SettingsMetadata{
+				{Key: "name", Value: "box"},
+				{Key: "extruder", Value: "1"},
+				{Key: "brim_type", Value: "outer_only"},
+				{Key: "enable_support", Value: "1"},
+				{Key: "support_type", Value: "tree(auto)"},
+				{Key: "seam_position", Value: "back"},
+				{FaceCount: 12},
+			},
+		},
+		{
+			name:   "supports disabled",
+			slicer: models.SlicerOptions{Supports: "none"},
+			want:   []models.SettingsMetadata{{Key: "name", Value: "box"}, {Key: "extruder", Value: "1"}, {Key: "enable_support", Value: "0"}, {FaceCount: 12}},
+		},
+		{
+			name:   "infill",
+			slicer: models.SlicerOptions{Infill: "40"},
+			want:   []models.SettingsMetadata{{Key: "name", Value: "box"}, {Key: "extruder", Value: "1"}, {Key: "sparse_infill_density", Value: "40%"}, {FaceCount: 12}},
+		},
+		{name: "unknown brim", slicer: models.SlicerOptions{Brim: "wide"}, wantErr: `object box: unknown brim "wide"`},
+		{name: "infill above 100%", slicer: models.SlicerOptions{Infill: "120%"}, wantErr: `invalid infill "120%"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := objectSettingsMetadata(models.ObjectGroup{Name: "box", Slicer: tt.slicer}, 12)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("objectSettingsMetadata() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteModelSettingsNames(t *testing.T) {
+	names := []string{"Würfel & Deckel", "立方体 <1>", `"quoted" 'name'`}
+	var groups []models.ObjectGroup
+	for i, name := range names {
+		groups = append(groups, models.ObjectGroup{
+			Name:  name,
+			Parts: []models.ScadFile{{Name: name + " part"}},
+			ID:    strconv.Itoa(i + 1),
+		})
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	if err := WriteModelSettings(zw, groups, nil); err != nil {
+		t.Fatalf("WriteModelSettings() error = %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rc, err := zr.File[0].Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "Würfel &amp; Deckel") || !strings.Contains(string(data), "立方体 &lt;1&gt;") {
+		t.Errorf("names are not escaped as UTF-8 XML:\n%s", data)
+	}
+
+	var settings models.ModelSettings
+	if err := xml.Unmarshal(data, &settings); err != nil {
+		t.Fatalf("settings are no valid XML: %v", err)
+	}
+	for i, obj := range settings.Objects {
+		if got := obj.Metadata[0].Value; got != names[i] {
+			t.Errorf("object %d name = %q, want %q", i, got, names[i])
+		}
+		if got := obj.Parts[0].Metadata[0].Value; got != names[i]+" part" {
+			t.Errorf("part %d name = %q, want %q", i, got, names[i]+" part")
+		}
+	}
+}
+
+func TestWriteModelSettingsFaceCounts(t *testing.T) {
+	obj := triangleModel(10).Resources.Objects[0]
+	groups := []models.ObjectGroup{{
+		ID:         "3",
+		Name:       "box",
+		Parts:      []models.ScadFile{{Name: "body"}, {Name: "lid"}},
+		FaceCounts: FaceCounts([]models.Object{obj}),
+	}}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	if err := WriteModelSettings(zw, groups, nil); err != nil {
+		t.Fatalf("WriteModelSettings() error = %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rc, err := zr.File[0].Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var settings models.ModelSettings
+	err = xml.NewDecoder(rc).Decode(&settings)
+	rc.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The body has one triangle, the face count of the lid is unknown
+	parts := settings.Objects[0].Parts
+	if parts[0].MeshStat.FaceCount != 1 || parts[1].MeshStat.FaceCount != unknownFaceCount {
+		t.Errorf("unexpected face counts %d, %d", parts[0].MeshStat.FaceCount, parts[1].MeshStat.FaceCount)
+	}
+}
+
+func TestWriteReadNames(t *testing.T) {
+	name := "Würfel & 立方体 <\"1\">"
+	model := &models.Model{
+		Xmlns: "http://schemas.microsoft.com/3dmanufacturing/core/2015/02",
+		Unit:  "millimeter",
+		Resources: models.Resources{Objects: []models.Object{
+			{ID: "1", Name: name, Type: "model", Mesh: &models.Mesh{Vertices: &models.Vertices{}, Triangles: &models.Triangles{}}},
+		}},
+		Build: models.Build{Items: []models.Item{{ObjectID: "1"}}},
+	}
+
+	file := filepath.Join(t.TempDir(), "names.3mf")
+	if err := (&Writer{}).Write(file, model, nil); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	read, err := (&Reader{}).Read(file)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if got := read.Resources.Objects[0].Name; got != name {
+		t.Errorf("name = %q, want %q", got, name)
+	}
+}
@@ -0,0 +1,59 @@
+package threemf
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"hash"
+	"math"
+
+	"github.com/philipparndt/go3mf/internal/models"
+)
+
+// sharedMeshes finds mesh objects with identical geometry, filament and type, e.g. the
+// parts of object copies or the same part used by several objects. It maps the
+// 1-based index of every such mesh to the index of the first one, which is stored
+// once and referenced by all of them. Meshes without duplicates are not included.
+func sharedMeshes(meshes []models.Object) map[int]int {
+	first := make(map[[sha256.Size]byte]int, len(meshes))
+	shared := make(map[int]int)
+	for i, mesh := range meshes {
+		if mesh.Mesh == nil || mesh.Mesh.Vertices == nil || mesh.Mesh.Triangles == nil {
+			continue
+		}
+		h := sha256.New()
+		h.Write([]byte(mesh.PID + "\x00" + mesh.Type + "\x00"))
+		hashMesh(h, mesh.Mesh)
+		var key [sha256.Size]byte
+		copy(key[:], h.Sum(nil))
+
+		id := i + 1
+		if firstID, ok := first[key]; ok {
+			shared[firstID] = firstID
+			shared[id] = firstID
+		} else {
+			first[key] = id
+		}
+	}
+	return shared
+}
+
+// hashMesh writes the vertices and triangles of a mesh to h
+func hashMesh(h hash.Hash, mesh *models.Mesh) {
+	buf := make([]byte, 0, 24)
+	for _, v := range mesh.Vertices.Vertex {
+		buf = binary.LittleEndian.AppendUint64(buf[:0], math.Float64bits(v.X))
+		buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(v.Y))
+		buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(v.Z))
+		h.Write(buf)
+	}
+	h.Write([]byte{0})
+	for _, t := range mesh.Triangles.Triangle {
+		buf = binary.LittleEndian.AppendUint64(buf[:0], uint64(t.V1))
+		buf = binary.LittleEndian.AppendUint64(buf, uint64(t.V2))
+		buf = binary.LittleEndian.AppendUint64(buf, uint64(t.V3))
+		h.Write(buf)
+		for _, attr := range t.Attrs {
+			h.Write([]byte(attr.Name.Local + "=" + attr.Value + "\x00"))
+		}
+	}
+}
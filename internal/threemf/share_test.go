@@ -0,0 +1,34 @@
+package threemf
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/philipparndt/go3mf/internal/models"
+)
+
+func TestSharedMeshes(t *testing.T) {
+	mesh := func(pid string, vertices []models.Vertex) models.Object {
+		return models.Object{PID: pid, Mesh: &models.Mesh{
+			Vertices:  &models.Vertices{Vertex: vertices},
+			Triangles: &models.Triangles{Triangle: []models.Triangle{{V1: 0, V2: 1, V3: 2}}},
+		}}
+	}
+	cube := []models.Vertex{{X: 0, Y: 0, Z: 0}, {X: 1, Y: 0, Z: 0}, {X: 0, Y: 1, Z: 0}}
+	lifted := []models.Vertex{{X: 0, Y: 0, Z: 1}, {X: 1, Y: 0, Z: 1}, {X: 0, Y: 1, Z: 1}}
+
+	meshes := []models.Object{
+		mesh("1", cube),   // 1
+		mesh("2", cube),   // 2: other filament
+		mesh("1", lifted), // 3: other geometry
+		mesh("1", cube),   // 4: equal to 1
+		mesh("2", cube),   // 5: equal to 2
+		mesh("1", cube),   // 6: equal to 1
+		{PID: "1"},        // 7: no mesh
+	}
+
+	want := map[int]int{1: 1, 4: 1, 6: 1, 2: 2, 5: 2}
+	if got := sharedMeshes(meshes); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,125 @@
+package threemf
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strconv"
+
+	"github.com/philipparndt/go3mf/internal/geometry"
+	"github.com/philipparndt/go3mf/internal/models"
+)
+
+// SourcePart is the name, filament slot and matrix of a mesh in the model settings of an input file
+type SourcePart struct {
+	Name     string // Name of the part ("" = none)
+	Extruder int    // Filament slot (0 = none)
+	Matrix   string // Bambu Studio matrix of the part in its object ("" = identity)
+}
+
+// ReadSourceParts returns the names and filament slots of the meshes of a 3MF
+// input by the ID of the mesh object in its main model, from the Bambu Studio
+// model settings of the input. Inputs without model settings have none.
+func ReadSourceParts(filename string, model *models.Model) (map[string]SourcePart, error) {
+	return Archives(nil).sourceParts(filename, model)
+}
+
+// sourceParts reads the source parts like ReadSourceParts, taking the inputs
+// held in memory from the archives
+func (a Archives) sourceParts(filename string, model *models.Model) (map[string]SourcePart, error) {
+	zr, closeZip, err := a.open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("error opening file: %w", err)
+	}
+	defer closeZip()
+
+	for _, f := range zr.File {
+		if f.Name != "Metadata/model_settings.config" {
+			continue
+		}
+		data, err := readEntry(f)
+		if err != nil {
+			return nil, err
+		}
+		var settings models.ModelSettings
+		if err := xml.Unmarshal(data, &settings); err != nil {
+			return nil, fmt.Errorf("error parsing %s: %w", f.Name, err)
+		}
+		return SourceParts(model, &settings), nil
+	}
+	return nil, nil
+}
+
+// SourceParts returns the names, filament slots and matrices of the mesh objects of a
+// model by object ID. The parts of an object are looked up through its
+// components, as Bambu Studio numbers the parts of every object on its own.
+// Parts without an extruder of their own use the one of their object.
+func SourceParts(model *models.Model, settings *models.ModelSettings) map[string]SourcePart {
+	settingsObjects := make(map[string]models.SettingsObject)
+	for _, obj := range settings.Objects {
+		settingsObjects[obj.ID] = obj
+	}
+	part := func(obj models.SettingsObject, id string) (SourcePart, bool) {
+		extruder, _ := strconv.Atoi(models.MetadataValue(obj.Metadata, "extruder"))
+		for _, p := range obj.Parts {
+			if p.ID != id {
+				continue
+			}
+			result := SourcePart{Name: models.MetadataValue(p.Metadata, "name"), Extruder: extruder}
+			if slot, err := strconv.Atoi(models.MetadataValue(p.Metadata, "extruder")); err == nil {
+				result.Extruder = slot
+			}
+			result.Matrix = partMatrix(models.MetadataValue(p.Metadata, "matrix"))
+			return result, true
+		}
+		return SourcePart{Extruder: extruder}, false
+	}
+
+	parts := make(map[string]SourcePart)
+	for _, obj := range model.Resources.Objects {
+		settingsObject, ok := settingsObjects[obj.ID]
+		if !ok {
+			continue
+		}
+		if obj.Components == nil {
+			// A single mesh is a part of its own object, or is named after the object
+			p, found := part(settingsObject, obj.ID)
+			if !found {
+				p.Name = models.MetadataValue(settingsObject.Metadata, "name")
+			}
+			parts[obj.ID] = p
+			continue
+		}
+		for _, component := range obj.Components.Component {
+			if component.Path != "" {
+				continue
+			}
+			p, _ := part(settingsObject, component.ObjectID)
+			parts[component.ObjectID] = p
+		}
+	}
+	return parts
+}
+
+// identityMatrix is the matrix of parts placed at the origin of their object
+const identityMatrix = "1 0 0 0 0 1 0 0 0 0 1 0 0 0 0 1"
+
+// partMatrix normalizes the matrix of a part, returning "" for the identity
+// and for matrices that cannot be parsed
+func partMatrix(matrix string) string {
+	m, err := geometry.ParseMatrix(matrix)
+	if err != nil {
+		return ""
+	}
+	if formatted := geometry.FormatMatrix(m); formatted != identityMatrix {
+		return formatted
+	}
+	return ""
+}
+
+// PartMatrix returns the matrix of a part for the model settings
+func PartMatrix(matrix string) string {
+	if matrix == "" {
+		return identityMatrix
+	}
+	return matrix
+}
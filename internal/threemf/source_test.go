@@ -0,0 +1,72 @@
+package threemf
+
+import (
+	"testing"
+
+	"github.com/philipparndt/go3mf/internal/models"
+)
+
+func TestSourceParts(t *testing.T) {
+	model := &models.Model{Resources: models.Resources{Objects: []models.Object{
+		{ID: "1", Mesh: &models.Mesh{}},
+		{ID: "2", Mesh: &models.Mesh{}},
+		{ID: "3", Components: &models.Components{Component: []models.Component{
+			{ObjectID: "1"},
+			{ObjectID: "2"},
+		}}},
+		{ID: "4", Mesh: &models.Mesh{}},
+		{ID: "5", Mesh: &models.Mesh{}},
+	}}}
+	meta := func(pairs ...string) []models.SettingsMetadata {
+		var result []models.SettingsMetadata
+		for i := 0; i < len(pairs); i += 2 {
+			result = append(result, models.SettingsMetadata{Key: pairs[i], Value: pairs[i+1]})
+		}
+		return result
+	}
+	settings := &models.ModelSettings{Objects: []models.SettingsObject{
+		{
+			ID:       "3",
+			Metadata: meta("name", "box", "extruder", "2"),
+			Parts: []models.Part{
+				{ID: "1", Metadata: meta("name", "body", "matrix", "1 0 0 0 0 1 0 0 0 0 1 0 0 0 0 1")},
+				{ID: "2", Metadata: meta("name", "lid", "extruder", "4", "matrix", "1 0 0 5 0 1 0 0 0 0 1 20.5 0 0 0 1")},
+			},
+		},
+		{
+			ID:       "4",
+			Metadata: meta("name", "knob", "extruder", "3"),
+		},
+	}}
+
+	got := SourceParts(model, settings)
+	want := map[string]SourcePart{
+		"1": {Name: "body", Extruder: 2}, // Extruder of the object, identity matrix
+		"2": {Name: "lid", Extruder: 4, Matrix: "1 0 0 5 0 1 0 0 0 0 1 20.5 0 0 0 1"},
+		"4": {Name: "knob", Extruder: 3}, // Named after the object
+	}
+	if len(got) != len(want) {
+		t.Fatalf("SourceParts() = %v, want %v", got, want)
+	}
+	for id, part := range want {
+		if got[id] != part {
+			t.Errorf("SourceParts()[%s] = %+v, want %+v", id, got[id], part)
+		}
+	}
+}
+
+func TestReadSourceParts(t *testing.T) {
+	dir := t.TempDir()
+
+	plain := writeArchive(t, dir, "plain.3mf", "3D/3dmodel.model")
+	parts, err := ReadSourceParts(plain, &models.Model{})
+	if err != nil || len(parts) != 0 {
+		t.Errorf("ReadSourceParts() = %v, %v, want no parts for an input without model settings", parts, err)
+	}
+
+	// The entries of the archive are not valid XML
+	broken := writeArchive(t, dir, "broken.3mf", "3D/3dmodel.model", "Metadata/model_settings.config")
+	if _, err := ReadSourceParts(broken, &models.Model{}); err == nil {
+		t.Error("ReadSourceParts() should fail for invalid model settings")
+	}
+}
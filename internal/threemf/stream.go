@@ -0,0 +1,243 @@
+package threemf
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/philipparndt/go3mf/internal/limits"
+	"github.com/philipparndt/go3mf/internal/models"
+)
+
+// DecodeModel parses model XML from r in one pass, together with the resources
+// of the slice and beam lattice extensions. The XML is never held in memory as
+// a whole: vertices and triangles are converted as they are read and the
+// vertex limit is checked while the meshes grow, so a model with millions of
+// triangles takes little more memory than its parsed meshes.
+func DecodeModel(r io.Reader) (*models.Model, *Extensions, error) {
+	d := &modelDecoder{
+		decoder:     xml.NewDecoder(r),
+		scanner:     newExtensionScanner(),
+		maxVertices: limits.Get().MaxVertices,
+	}
+	if err := d.decode(); err != nil {
+		return nil, nil, err
+	}
+	return &d.model, d.scanner.ext, nil
+}
+
+// modelDecoder is the state of DecodeModel
+type modelDecoder struct {
+	decoder     *xml.Decoder
+	scanner     *extensionScanner
+	model       models.Model
+	object      *models.Object
+	path        []string // Local names of the open elements
+	vertices    int64    // Vertices of all meshes read so far
+	maxVertices int64
+}
+
+func (d *modelDecoder) decode() error {
+	for {
+		token, err := d.decoder.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error parsing XML: %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			consumed, err := d.start(t)
+			if err != nil {
+				return err
+			}
+			if consumed {
+				continue
+			}
+			if err := d.scanner.start(t); err != nil {
+				return err
+			}
+			d.path = append(d.path, t.Name.Local)
+		case xml.EndElement:
+			d.end(t)
+			d.scanner.end(t)
+			if len(d.path) > 0 {
+				d.path = d.path[:len(d.path)-1]
+			}
+		}
+	}
+}
+
+// parent returns the local name of the innermost open element
+func (d *modelDecoder) parent() string {
+	if len(d.path) == 0 {
+		return ""
+	}
+	return d.path[len(d.path)-1]
+}
+
+// start processes a start element. Small elements without mesh data are
+// decoded as a whole, including their end element, which is reported as
+// consumed.
+func (d *modelDecoder) start(t xml.StartElement) (consumed bool, err error) {
+	mesh := d.mesh()
+	switch parent := d.parent(); {
+	case parent == "":
+		return false, decodeAttrs(t, &d.model)
+	case t.Name.Local == "metadata" && parent == "model":
+		var metadata models.Metadata
+		if err := d.decoder.DecodeElement(&metadata, &t); err != nil {
+			return true, fmt.Errorf("error parsing XML: %w", err)
+		}
+		d.model.Metadata = append(d.model.Metadata, metadata)
+		return true, nil
+	case t.Name.Local == "basematerials" && parent == "resources":
+		var materials models.BaseMaterials
+		if err := d.decoder.DecodeElement(&materials, &t); err != nil {
+			return true, fmt.Errorf("error parsing XML: %w", err)
+		}
+		d.model.Resources.BaseMaterials = &materials
+		return true, nil
+	case t.Name.Local == "object" && parent == "resources":
+		d.object = &models.Object{}
+		return false, decodeAttrs(t, d.object)
+	case t.Name.Local == "components" && parent == "object" && d.object != nil:
+		var components models.Components
+		if err := d.decoder.DecodeElement(&components, &t); err != nil {
+			return true, fmt.Errorf("error parsing XML: %w", err)
+		}
+		d.object.Components = &components
+		return true, nil
+	case t.Name.Local == "mesh" && parent == "object" && d.object != nil:
+		d.object.Mesh = &models.Mesh{}
+	case t.Name.Local == "vertices" && parent == "mesh" && mesh != nil:
+		mesh.Vertices = &models.Vertices{}
+	case t.Name.Local == "vertex" && parent == "vertices" && mesh != nil && mesh.Vertices != nil:
+		return false, d.vertex(t, mesh.Vertices)
+	case t.Name.Local == "triangles" && parent == "mesh" && mesh != nil:
+		mesh.Triangles = &models.Triangles{}
+	case t.Name.Local == "triangle" && parent == "triangles" && mesh != nil && mesh.Triangles != nil:
+		return false, d.triangle(t, mesh.Triangles)
+	case t.Name.Local == "build" && parent == "model":
+		return false, decodeAttrs(t, &d.model.Build)
+	case t.Name.Local == "item" && parent == "build":
+		var item models.Item
+		if err := d.decoder.DecodeElement(&item, &t); err != nil {
+			return true, fmt.Errorf("error parsing XML: %w", err)
+		}
+		d.model.Build.Items = append(d.model.Build.Items, item)
+		return true, nil
+	}
+	return false, nil
+}
+
+// mesh returns the mesh of the object being read, if any
+func (d *modelDecoder) mesh() *models.Mesh {
+	if d.object == nil {
+		return nil
+	}
+	return d.object.Mesh
+}
+
+// end processes an end element
+func (d *modelDecoder) end(t xml.EndElement) {
+	if t.Name.Local == "object" && len(d.path) == 3 && d.object != nil {
+		d.model.Resources.Objects = append(d.model.Resources.Objects, *d.object)
+		d.object = nil
+	}
+}
+
+// vertex appends a vertex element to the vertices of the current mesh
+func (d *modelDecoder) vertex(t xml.StartElement, vertices *models.Vertices) error {
+	d.vertices++
+	if d.maxVertices > 0 && d.vertices > d.maxVertices {
+		return limits.CheckModelVertices(d.vertices)
+	}
+
+	var v models.Vertex
+	for _, a := range t.Attr {
+		var err error
+		switch a.Name.Local {
+		case "x":
+			v.X, err = parseFloatAttr(a.Value)
+		case "y":
+			v.Y, err = parseFloatAttr(a.Value)
+		case "z":
+			v.Z, err = parseFloatAttr(a.Value)
+		}
+		if err != nil {
+			return fmt.Errorf("error parsing XML: object %s: invalid vertex coordinate %s=%q", d.object.ID, a.Name.Local, a.Value)
+		}
+	}
+	vertices.Vertex = append(vertices.Vertex, v)
+	return nil
+}
+
+// triangle appends a triangle element to the triangles of the current mesh
+func (d *modelDecoder) triangle(t xml.StartElement, triangles *models.Triangles) error {
+	var tri models.Triangle
+	for _, a := range t.Attr {
+		var err error
+		switch a.Name.Local {
+		case "v1":
+			tri.V1, err = parseIntAttr(a.Value)
+		case "v2":
+			tri.V2, err = parseIntAttr(a.Value)
+		case "v3":
+			tri.V3, err = parseIntAttr(a.Value)
+		default:
+			tri.Attrs = append(tri.Attrs, a)
+		}
+		if err != nil {
+			return fmt.Errorf("error parsing XML: object %s: invalid triangle index %s=%q", d.object.ID, a.Name.Local, a.Value)
+		}
+	}
+	triangles.Triangle = append(triangles.Triangle, tri)
+	return nil
+}
+
+// parseFloatAttr parses a number attribute like encoding/xml, an empty value is 0
+func parseFloatAttr(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+// parseIntAttr parses an integer attribute like encoding/xml, an empty value is 0
+func parseIntAttr(s string) (int, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(s)
+}
+
+// decodeAttrs decodes the attributes of an element into v, leaving its child
+// elements to the caller
+func decodeAttrs(start xml.StartElement, v any) error {
+	tokens := &tokenList{tokens: []xml.Token{start, start.End()}}
+	if err := xml.NewTokenDecoder(tokens).Decode(v); err != nil {
+		return fmt.Errorf("error parsing XML: %w", err)
+	}
+	return nil
+}
+
+// tokenList is an xml.TokenReader of a fixed list of tokens
+type tokenList struct {
+	tokens []xml.Token
+}
+
+func (l *tokenList) Token() (xml.Token, error) {
+	if len(l.tokens) == 0 {
+		return nil, io.EOF
+	}
+	token := l.tokens[0]
+	l.tokens = l.tokens[1:]
+	return token, nil
+}
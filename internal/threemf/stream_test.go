@@ -0,0 +1,103 @@
+package threemf
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/philipparndt/go3mf/internal/limits"
+	"github.com/philipparndt/go3mf/internal/models"
+)
+
+const streamModel = `<?xml version="1.0" encoding="UTF-8"?>
+<model unit="millimeter" xml:lang="en-US" xmlns="http://schemas.microsoft.com/3dmanufacturing/core/2015/02"
+  xmlns:p="http://schemas.microsoft.com/3dmanufacturing/production/2015/06" requiredextensions="p">
+  <metadata name="Title">Box &amp; lid</metadata>
+  <metadata name="Application" preserve="1">CAD</metadata>
+  <resources>
+    <basematerials id="1"><base name="PLA" displaycolor="#FF0000"/></basematerials>
+    <object id="2" name="box" type="model" p:UUID="a" pid="1" pindex="0">
+      <mesh>
+        <vertices>
+          <vertex x="0" y="0" z="0"/>
+          <vertex x=" 10.5 " y="0" z="1e1"/>
+          <vertex x="0" y="-10" z=""/>
+        </vertices>
+        <triangles>
+          <triangle v1="0" v2="1" v3="2" pid="1" p1="0" paint_color="4"/>
+          <triangle v1="2" v2="1" v3="0"/>
+        </triangles>
+      </mesh>
+    </object>
+    <object id="3" type="model">
+      <components>
+        <component objectid="2" p:path="/3D/box.model" transform="1 0 0 0 1 0 0 0 1 5 5 0"/>
+      </components>
+    </object>
+  </resources>
+  <build p:UUID="b">
+    <item objectid="3" p:UUID="c" transform="1 0 0 0 1 0 0 0 1 100 100 0" printable="1"/>
+  </build>
+</model>`
+
+func TestDecodeModel(t *testing.T) {
+	streamExtensions := &Extensions{
+		Required:     []string{"http://schemas.microsoft.com/3dmanufacturing/production/2015/06"},
+		SliceObjects: map[string]string{},
+	}
+	for data, wantExt := range map[string]*Extensions{streamModel: streamExtensions, extensionsModel: wantExtensions} {
+		var want models.Model
+		if err := xml.Unmarshal([]byte(data), &want); err != nil {
+			t.Fatal(err)
+		}
+
+		model, ext, err := DecodeModel(strings.NewReader(data))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(model, &want) {
+			t.Errorf("DecodeModel() = %+v, want %+v", model, &want)
+		}
+		if !reflect.DeepEqual(ext, wantExt) {
+			t.Errorf("DecodeModel() extensions = %+v, want %+v", ext, wantExt)
+		}
+	}
+}
+
+func TestDecodeModelErrors(t *testing.T) {
+	const mesh = `<model><resources><object id="1"><mesh><vertices>%s</vertices><triangles>%s</triangles></mesh></object></resources></model>`
+	tests := []struct {
+		name        string
+		vertices    string
+		triangles   string
+		maxVertices int64
+		want        string
+	}{
+		{"within limit", `<vertex x="0" y="0" z="0"/><vertex x="1" y="0" z="0"/>`, "", 2, ""},
+		{"vertex limit", `<vertex x="0" y="0" z="0"/><vertex x="1" y="0" z="0"/><vertex x="2" y="0" z="0"/>`, "", 2, "the model has 3 vertices, at most 2 are allowed"},
+		{"invalid coordinate", `<vertex x="1,5" y="0" z="0"/>`, "", 0, `object 1: invalid vertex coordinate x="1,5"`},
+		{"invalid index", "", `<triangle v1="0" v2="a" v3="2"/>`, 0, `object 1: invalid triangle index v2="a"`},
+		{"malformed XML", `<vertex x="0" y="0" z="0">`, "", 0, "error parsing XML"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			saved := limits.Get()
+			limits.Set(limits.Limits{MaxVertices: tt.maxVertices})
+			defer limits.Set(saved)
+
+			_, _, err := DecodeModel(strings.NewReader(fmt.Sprintf(mesh, tt.vertices, tt.triangles)))
+			switch {
+			case tt.want == "" && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)):
+				t.Errorf("error = %v, want %q", err, tt.want)
+			}
+			if strings.Contains(tt.want, "at most") && !errors.Is(err, limits.ErrExceeded) {
+				t.Errorf("error %v does not wrap limits.ErrExceeded", err)
+			}
+		})
+	}
+}
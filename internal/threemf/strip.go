@@ -0,0 +1,58 @@
+package threemf
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+
+	"github.com/philipparndt/go3mf/internal/limits"
+	"github.com/philipparndt/go3mf/internal/models"
+)
+
+// StripMetadata rewrites a 3MF file as a plain geometric model: the auxiliary
+// entries (slicer project and model settings, thumbnails, files of other tools)
+// are removed, as are the metadata and the Bambu Studio namespace of the model.
+// The removed entries are returned.
+func StripMetadata(file string, compact bool) ([]string, error) {
+	zr, err := limits.OpenZip(file)
+	if err != nil {
+		return nil, fmt.Errorf("error opening 3MF file: %w", err)
+	}
+	var removed []string
+	var data []byte
+	for _, f := range zr.File {
+		switch {
+		case f.Name == "3D/3dmodel.model":
+			data, err = readEntry(f)
+		case !isPackageEntry(f.Name):
+			removed = append(removed, f.Name)
+		}
+		if err != nil {
+			zr.Close()
+			return nil, err
+		}
+	}
+	zr.Close()
+	if data == nil {
+		return nil, fmt.Errorf("3D/3dmodel.model not found")
+	}
+
+	var model models.Model
+	if err := xml.Unmarshal(data, &model); err != nil {
+		return nil, fmt.Errorf("error parsing XML: %w", err)
+	}
+	model.Metadata = nil
+	declareNamespaces(&model, false)
+	if data, err = MarshalModel(&model, compact); err != nil {
+		return nil, fmt.Errorf("error marshaling XML: %w", err)
+	}
+	data = append([]byte(xml.Header), data...)
+
+	err = replaceFile(file, func(w io.Writer) error {
+		return copyPackage(file, w, map[string][]byte{"3D/3dmodel.model": data})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return removed, nil
+}
@@ -0,0 +1,83 @@
+package threemf
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/philipparndt/go3mf/internal/models"
+)
+
+func TestStripMetadata(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "bambu.3mf")
+	f, err := os.Create(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	for _, entry := range []struct{ name, content string }{
+		{"[Content_Types].xml", "types"},
+		{"3D/3dmodel.model", `<?xml version="1.0" encoding="UTF-8"?>
+<model xmlns="http://schemas.microsoft.com/3dmanufacturing/core/2015/02" xmlns:p="http://schemas.microsoft.com/3dmanufacturing/production/2015/06" xmlns:BambuStudio="http://schemas.bambulab.com/package/2021" requiredextensions="p" unit="millimeter" xml:lang="en-US">
+	<metadata name="Application">BambuStudio-01.09.00.70</metadata>
+	<metadata name="BambuStudio:3mfVersion">1</metadata>
+	<resources><object id="1" type="model"><mesh><vertices></vertices><triangles></triangles></mesh></object></resources>
+	<build><item objectid="1" transform="1 0 0 0 1 0 0 0 1 10 20 0"/></build>
+</model>`},
+		{"Metadata/model_settings.config", "settings"},
+		{"Metadata/project_settings.config", "project"},
+		{"Metadata/plate_1.png", "thumbnail"},
+	} {
+		w, err := zw.Create(entry.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(entry.content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	removed, err := StripMetadata(file, false)
+	if err != nil {
+		t.Fatalf("StripMetadata() error = %v", err)
+	}
+	want := []string{"Metadata/model_settings.config", "Metadata/project_settings.config", "Metadata/plate_1.png"}
+	if !reflect.DeepEqual(removed, want) {
+		t.Errorf("StripMetadata() removed %v, want %v", removed, want)
+	}
+
+	zr, err := zip.OpenReader(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+	var names []string
+	var data []byte
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+		if f.Name == "3D/3dmodel.model" {
+			if data, err = readEntry(f); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if want := []string{"[Content_Types].xml", "3D/3dmodel.model"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("entries = %v, want %v", names, want)
+	}
+	if strings.Contains(string(data), "BambuStudio") {
+		t.Errorf("model still has Bambu Studio metadata:\n%s", data)
+	}
+	var model models.Model
+	if err := xml.Unmarshal(data, &model); err != nil {
+		t.Fatalf("stripped model is invalid: %v", err)
+	}
+	if len(model.Metadata) != 0 || len(model.Build.Items) != 1 || model.Build.Items[0].Transform != "1 0 0 0 1 0 0 0 1 10 20 0" {
+		t.Errorf("stripped model = %+v, want the build without metadata", model)
+	}
+}
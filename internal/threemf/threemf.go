@@ -7,10 +7,15 @@ import (
 	"io"
 	"math"
 	"os"
+	"path/filepath"
+	"sort"
 	"strconv"
 
+	"github.com/philipparndt/go3mf/internal/arrangement"
 	"github.com/philipparndt/go3mf/internal/geometry"
+	"github.com/philipparndt/go3mf/internal/limits"
 	"github.com/philipparndt/go3mf/internal/models"
+	"github.com/philipparndt/go3mf/internal/ui"
 )
 
 // Reader reads 3MF files
@@ -18,12 +23,36 @@ type Reader struct{}
 
 // Read reads and parses a 3MF file
 func (r *Reader) Read(filename string) (*models.Model, error) {
-	zr, err := zip.OpenReader(filename)
+	model, _, err := r.ReadWithExtensions(filename)
+	return model, err
+}
+
+// ReadWithExtensions reads and parses a 3MF file together with the resources of
+// the slice and beam lattice extensions
+func (r *Reader) ReadWithExtensions(filename string) (*models.Model, *Extensions, error) {
+	zr, err := limits.OpenZip(filename)
 	if err != nil {
-		return nil, fmt.Errorf("error opening ZIP: %w", err)
+		return nil, nil, fmt.Errorf("error opening ZIP: %w", err)
 	}
 	defer zr.Close()
+	return r.readZip(&zr.Reader)
+}
 
+// ReadArchive reads and parses a 3MF file of the given size held in memory, e.g.
+// an upload, together with the resources of the slice and beam lattice extensions
+func (r *Reader) ReadArchive(ra io.ReaderAt, size int64) (*models.Model, *Extensions, error) {
+	zr, err := zip.NewReader(ra, size)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error opening ZIP: %w", err)
+	}
+	if err := limits.CheckArchive(zr); err != nil {
+		return nil, nil, err
+	}
+	return r.readZip(zr)
+}
+
+// readZip reads the main model of an opened 3MF file
+func (r *Reader) readZip(zr *zip.Reader) (*models.Model, *Extensions, error) {
 	var modelFile *zip.File
 	for _, f := range zr.File {
 		if f.Name == "3D/3dmodel.model" {
@@ -33,130 +62,116 @@ func (r *Reader) Read(filename string) (*models.Model, error) {
 	}
 
 	if modelFile == nil {
-		return nil, fmt.Errorf("3D/3dmodel.model not found in archive")
+		return nil, nil, fmt.Errorf("3D/3dmodel.model not found in archive")
 	}
 
 	rc, err := modelFile.Open()
 	if err != nil {
-		return nil, fmt.Errorf("error opening model file: %w", err)
+		return nil, nil, fmt.Errorf("error opening model file: %w", err)
 	}
 	defer rc.Close()
 
-	data, err := io.ReadAll(rc)
-	if err != nil {
-		return nil, fmt.Errorf("error reading model file: %w", err)
-	}
-
-	var model models.Model
-	if err := xml.Unmarshal(data, &model); err != nil {
-		return nil, fmt.Errorf("error parsing XML: %w", err)
-	}
-
-	return &model, nil
+	return DecodeModel(rc)
 }
 
 // Writer writes 3MF files
-type Writer struct{}
+type Writer struct {
+	Auxiliary  models.AuxiliaryPolicy // Entries of the source files copied besides the model
+	Filaments  []models.YamlFilament  // Filaments by slot written as base materials (nil = none)
+	CompactXML bool                   // Write the model XML without indentation
+	Archives   Archives               // Source files held in memory (nil = all on disk)
+}
 
-// WriteBambu writes a model to a 3MF file with Bambu Studio support
-func (w *Writer) WriteBambu(outputFile string, model *models.Model, sourceFile string, objectGroups []models.ObjectGroup, buildItems []models.Item) error {
+// WriteBambu writes a model to a 3MF file with Bambu Studio support, copying
+// auxiliary files from sourceFiles
+func (w *Writer) WriteBambu(outputFile string, model *models.Model, sourceFiles []string, objectGroups []models.ObjectGroup, buildItems []models.Item) error {
+	return createFile(outputFile, func(out io.Writer) error {
+		return w.EncodeBambu(out, model, sourceFiles, objectGroups, buildItems)
+	})
+}
+
+// EncodeBambu writes a model as 3MF with Bambu Studio support to out, copying
+// auxiliary files from sourceFiles
+func (w *Writer) EncodeBambu(out io.Writer, model *models.Model, sourceFiles []string, objectGroups []models.ObjectGroup, buildItems []models.Item) error {
 	// Add Bambu metadata
 	AddBambuMetadata(model)
+	applyMaterials(model, w.Filaments)
 
-	// Read source ZIP to get metadata files
-	sourceZip, err := zip.OpenReader(sourceFile)
-	if err != nil {
-		return fmt.Errorf("error opening source ZIP: %w", err)
+	outZip := zip.NewWriter(out)
+	if err := w.writeModel(outZip, model); err != nil {
+		return err
 	}
-	defer sourceZip.Close()
 
-	// Create output ZIP
-	outFile, err := os.Create(outputFile)
-	if err != nil {
-		return fmt.Errorf("error creating output file: %w", err)
+	// Write Bambu model settings
+	if err := WriteModelSettings(outZip, objectGroups, buildItems); err != nil {
+		return fmt.Errorf("error writing model settings: %w", err)
 	}
-	defer outFile.Close()
 
-	outZip := zip.NewWriter(outFile)
-	defer outZip.Close()
-
-	// Write model XML
-	modelXML, err := xml.MarshalIndent(model, "", "\t")
-	if err != nil {
-		return fmt.Errorf("error marshaling XML: %w", err)
+	// Copy other files from the sources
+	if err := w.Archives.copyAuxiliaryFiles(outZip, sourceFiles, w.Auxiliary, "3D/3dmodel.model", "Metadata/model_settings.config"); err != nil {
+		return err
 	}
+	return outZip.Close()
+}
 
-	w_, err := outZip.Create("3D/3dmodel.model")
-	if err != nil {
-		return fmt.Errorf("error creating model entry: %w", err)
-	}
+// WriteBambuWithPlates writes a model to a 3MF file with Bambu Studio multi-plate support,
+// copying auxiliary files from sourceFiles
+func (w *Writer) WriteBambuWithPlates(outputFile string, model *models.Model, sourceFiles []string, objectGroups []models.ObjectGroup, buildItems []models.Item, plateGroups []models.PlateGroup, plateObjectIDs map[int][]string) error {
+	return createFile(outputFile, func(out io.Writer) error {
+		return w.EncodeBambuWithPlates(out, model, sourceFiles, objectGroups, buildItems, plateGroups, plateObjectIDs)
+	})
+}
 
-	// Write XML declaration
-	if _, err := w_.Write([]byte(xml.Header)); err != nil {
-		return fmt.Errorf("error writing XML header: %w", err)
-	}
+// EncodeBambuWithPlates writes a model as 3MF with Bambu Studio multi-plate
+// support to out, copying auxiliary files from sourceFiles
+func (w *Writer) EncodeBambuWithPlates(out io.Writer, model *models.Model, sourceFiles []string, objectGroups []models.ObjectGroup, buildItems []models.Item, plateGroups []models.PlateGroup, plateObjectIDs map[int][]string) error {
+	// Add Bambu metadata
+	AddBambuMetadata(model)
+	applyMaterials(model, w.Filaments)
 
-	if _, err := w_.Write(modelXML); err != nil {
-		return fmt.Errorf("error writing model XML: %w", err)
+	outZip := zip.NewWriter(out)
+	if err := w.writeModel(outZip, model); err != nil {
+		return err
 	}
 
-	// Write Bambu model settings
-	if err := WriteModelSettings(outZip, objectGroups, buildItems); err != nil {
+	// Write Bambu model settings with multi-plate support
+	if err := WriteModelSettingsWithPlates(outZip, objectGroups, buildItems, plateGroups, plateObjectIDs); err != nil {
 		return fmt.Errorf("error writing model settings: %w", err)
 	}
 
-	// Copy other files from source
-	for _, file := range sourceZip.File {
-		if file.Name == "3D/3dmodel.model" || file.Name == "Metadata/model_settings.config" {
-			continue
-		}
-
-		srcFile, err := file.Open()
-		if err != nil {
-			return fmt.Errorf("error opening source file: %w", err)
-		}
-
-		dst, err := outZip.Create(file.Name)
-		if err != nil {
-			srcFile.Close()
-			return fmt.Errorf("error creating ZIP entry: %w", err)
-		}
-
-		if _, err := io.Copy(dst, srcFile); err != nil {
-			srcFile.Close()
-			return fmt.Errorf("error copying file: %w", err)
-		}
-
-		srcFile.Close()
+	// Copy other files from the sources
+	if err := w.Archives.copyAuxiliaryFiles(outZip, sourceFiles, w.Auxiliary, "3D/3dmodel.model", "Metadata/model_settings.config"); err != nil {
+		return err
 	}
+	return outZip.Close()
+}
 
-	return nil
+// Write writes a model to a 3MF file, copying auxiliary files from sourceFiles
+func (w *Writer) Write(outputFile string, model *models.Model, sourceFiles []string) error {
+	return createFile(outputFile, func(out io.Writer) error {
+		return w.Encode(out, model, sourceFiles)
+	})
 }
 
-// WriteBambuWithPlates writes a model to a 3MF file with Bambu Studio multi-plate support
-func (w *Writer) WriteBambuWithPlates(outputFile string, model *models.Model, sourceFile string, objectGroups []models.ObjectGroup, buildItems []models.Item, plateGroups []models.PlateGroup, plateObjectIDs map[int][]string) error {
-	// Add Bambu metadata
-	AddBambuMetadata(model)
+// Encode writes a model as 3MF to out, copying auxiliary files from sourceFiles
+func (w *Writer) Encode(out io.Writer, model *models.Model, sourceFiles []string) error {
+	applyMaterials(model, w.Filaments)
 
-	// Read source ZIP to get metadata files
-	sourceZip, err := zip.OpenReader(sourceFile)
-	if err != nil {
-		return fmt.Errorf("error opening source ZIP: %w", err)
+	outZip := zip.NewWriter(out)
+	if err := w.writeModel(outZip, model); err != nil {
+		return err
 	}
-	defer sourceZip.Close()
 
-	// Create output ZIP
-	outFile, err := os.Create(outputFile)
-	if err != nil {
-		return fmt.Errorf("error creating output file: %w", err)
+	// Copy other files from the sources
+	if err := w.Archives.copyAuxiliaryFiles(outZip, sourceFiles, w.Auxiliary, "3D/3dmodel.model"); err != nil {
+		return err
 	}
-	defer outFile.Close()
-
-	outZip := zip.NewWriter(outFile)
-	defer outZip.Close()
+	return outZip.Close()
+}
 
-	// Write model XML
-	modelXML, err := xml.MarshalIndent(model, "", "\t")
+// writeModel writes the model entry of a 3MF file
+func (w *Writer) writeModel(outZip *zip.Writer, model *models.Model) error {
+	modelXML, err := MarshalModel(model, w.CompactXML)
 	if err != nil {
 		return fmt.Errorf("error marshaling XML: %w", err)
 	}
@@ -174,131 +189,244 @@ func (w *Writer) WriteBambuWithPlates(outputFile string, model *models.Model, so
 	if _, err := w_.Write(modelXML); err != nil {
 		return fmt.Errorf("error writing model XML: %w", err)
 	}
+	return nil
+}
 
-	// Write Bambu model settings with multi-plate support
-	if err := WriteModelSettingsWithPlates(outZip, objectGroups, buildItems, plateGroups, plateObjectIDs); err != nil {
-		return fmt.Errorf("error writing model settings: %w", err)
+// createFile writes a file with encode
+func createFile(outputFile string, encode func(io.Writer) error) error {
+	outFile, err := os.Create(outputFile)
+	if err != nil {
+		return fmt.Errorf("error creating output file: %w", err)
 	}
+	if err := encode(outFile); err != nil {
+		outFile.Close()
+		return err
+	}
+	if err := outFile.Close(); err != nil {
+		return fmt.Errorf("error writing output file: %w", err)
+	}
+	return nil
+}
 
-	// Copy other files from source
-	for _, file := range sourceZip.File {
-		if file.Name == "3D/3dmodel.model" || file.Name == "Metadata/model_settings.config" {
-			continue
-		}
+// Combiner combines multiple 3MF models
+type Combiner struct {
+	reader     *Reader
+	writer     *Writer
+	Debug      bool                    // Enable debug output
+	order      models.PackingOrder     // Order in which objects are placed on the plate
+	sequential *models.SequentialPrint // Sequential print layout (nil = regular packing)
+	grid       float64                 // Placement grid in mm (0 = no snapping)
+	rowWidth   float64                 // Maximum row width of the default packer in mm (0 = plate width)
+	footprint  models.Footprint        // Shape used for collision checks when packing
+	origin     models.PackingOrigin    // Plate edge packed layouts start at ("" = front)
+	printer    models.PrinterProfile   // Build volume the objects are packed for
+	normalize  models.Normalization    // Z placement of objects without a normalize_position setting
+	separator  string                  // Separates the object from the part in names of parts without group
+	ignoreExt  bool                    // Combine inputs requiring unsupported extensions instead of failing
+	flatten    bool                    // Merge objects of the inputs made of components into one mesh each
+	archives   Archives                // Inputs held in memory (nil = all on disk)
+	output     io.Writer               // Destination of the combined 3MF (nil = the output file)
+
+	arrangement *arrangement.Arrangement // Fixed placements that replace packing (nil = pack all objects)
+	placements  *arrangement.Arrangement // Final placements of the last combine
+	unarranged  []string                 // Objects of the last combine that were not in the arrangement
+	warnings    []string                 // Objects of the last combine that do not fit the printer or were skipped
+}
 
-		srcFile, err := file.Open()
-		if err != nil {
-			return fmt.Errorf("error opening source file: %w", err)
-		}
+// NewCombiner creates a new Combiner
+func NewCombiner() *Combiner {
+	printer, _ := models.LookupPrinter(models.DefaultPrinter, nil)
+	return &Combiner{
+		reader:    &Reader{},
+		writer:    &Writer{},
+		printer:   printer,
+		normalize: models.NormalizationGround,
+		separator: models.DefaultGroupSeparator,
+	}
+}
 
-		dst, err := outZip.Create(file.Name)
-		if err != nil {
-			srcFile.Close()
-			return fmt.Errorf("error creating ZIP entry: %w", err)
-		}
+// SetGroupSeparator sets the separator between object and part in the names of
+// parts without an explicit group ("" = every part is an object of its own)
+func (c *Combiner) SetGroupSeparator(separator string) {
+	c.separator = separator
+}
 
-		if _, err := io.Copy(dst, srcFile); err != nil {
-			srcFile.Close()
-			return fmt.Errorf("error copying file: %w", err)
-		}
+// SetAuxiliaryPolicy sets which auxiliary archive entries of the input files are
+// copied to the output
+func (c *Combiner) SetAuxiliaryPolicy(policy models.AuxiliaryPolicy) {
+	c.writer.Auxiliary = policy
+}
 
-		srcFile.Close()
-	}
+// SetCompactXML writes the model XML without indentation, which makes large
+// models smaller
+func (c *Combiner) SetCompactXML(compact bool) {
+	c.writer.CompactXML = compact
+}
 
-	return nil
+// SetFilaments sets the filaments by slot whose colors are written as base
+// materials of the meshes
+func (c *Combiner) SetFilaments(filaments []models.YamlFilament) {
+	c.writer.Filaments = filaments
 }
 
-// Write writes a model to a 3MF file, copying metadata from sourceFile
-func (w *Writer) Write(outputFile string, model *models.Model, sourceFile string) error {
-	// Read source ZIP to get metadata files
-	sourceZip, err := zip.OpenReader(sourceFile)
-	if err != nil {
-		return fmt.Errorf("error opening source ZIP: %w", err)
-	}
-	defer sourceZip.Close()
+// SetIgnoreExtensions sets the ignore flag of CheckRequiredExtensions for the inputs
+func (c *Combiner) SetIgnoreExtensions(ignore bool) {
+	c.ignoreExt = ignore
+}
 
-	// Create output ZIP
-	outFile, err := os.Create(outputFile)
-	if err != nil {
-		return fmt.Errorf("error creating output file: %w", err)
-	}
-	defer outFile.Close()
+// SetFlatten merges the objects of the inputs that are made of components,
+// possibly nested, into one mesh each
+func (c *Combiner) SetFlatten(flatten bool) {
+	c.flatten = flatten
+}
 
-	outZip := zip.NewWriter(outFile)
-	defer outZip.Close()
+// SetNormalization sets how objects are placed along Z. Object groups carry their
+// own setting, so it applies to ungrouped files, while preserve also keeps the Z
+// offsets of the build items of input 3MF files for all objects.
+func (c *Combiner) SetNormalization(normalize models.Normalization) {
+	c.normalize = normalize
+}
 
-	// Write model XML
-	modelXML, err := xml.MarshalIndent(model, "", "\t")
-	if err != nil {
-		return fmt.Errorf("error marshaling XML: %w", err)
-	}
+// SetPrinter sets the printer whose plate size and exclusion zones are used for packing
+func (c *Combiner) SetPrinter(printer models.PrinterProfile) {
+	c.printer = printer
+}
 
-	w_, err := outZip.Create("3D/3dmodel.model")
-	if err != nil {
-		return fmt.Errorf("error creating model entry: %w", err)
-	}
+// Warnings returns the problems found when placing the objects of the last combine,
+// like objects that are taller than the printer or leave the plate
+func (c *Combiner) Warnings() []string {
+	return c.warnings
+}
 
-	// Write XML declaration
-	if _, err := w_.Write([]byte(xml.Header)); err != nil {
-		return fmt.Errorf("error writing XML header: %w", err)
-	}
+// SetDebug enables or disables debug output
+func (c *Combiner) SetDebug(debug bool) {
+	c.Debug = debug
+}
 
-	if _, err := w_.Write(modelXML); err != nil {
-		return fmt.Errorf("error writing model XML: %w", err)
-	}
+// SetPackingOrder sets the order in which objects are placed on the plate and,
+// if sequential is not nil, lays them out for printing one by one
+func (c *Combiner) SetPackingOrder(order models.PackingOrder, sequential *models.SequentialPrint) {
+	c.order = order
+	c.sequential = sequential
+}
 
-	// Copy other files from source
-	for _, file := range sourceZip.File {
-		if file.Name == "3D/3dmodel.model" {
-			continue
-		}
+// SetPlacementGrid snaps the packed positions of all objects to a grid of the given size in mm
+func (c *Combiner) SetPlacementGrid(grid float64) {
+	c.grid = grid
+}
 
-		srcFile, err := file.Open()
-		if err != nil {
-			return fmt.Errorf("error opening source file: %w", err)
-		}
+// SetMaxRowWidth limits the rows of the default packer to the given width in mm
+// (0 = the width of the plate)
+func (c *Combiner) SetMaxRowWidth(width float64) {
+	c.rowWidth = width
+}
 
-		dst, err := outZip.Create(file.Name)
-		if err != nil {
-			srcFile.Close()
-			return fmt.Errorf("error creating ZIP entry: %w", err)
-		}
+// maxRowWidth returns the maximum row width of the default packer on a plate
+// of the given width
+func (c *Combiner) maxRowWidth(plateWidth float64) float64 {
+	if c.rowWidth > 0 {
+		return c.rowWidth
+	}
+	return plateWidth
+}
 
-		if _, err := io.Copy(dst, srcFile); err != nil {
-			srcFile.Close()
-			return fmt.Errorf("error copying file: %w", err)
-		}
+// SetFootprint sets the shape used for collision checks when packing objects
+func (c *Combiner) SetFootprint(footprint models.Footprint) {
+	c.footprint = footprint
+}
 
-		srcFile.Close()
-	}
+// SetPackingOrigin sets the plate edge packed layouts start at
+func (c *Combiner) SetPackingOrigin(origin models.PackingOrigin) {
+	c.origin = origin
+}
 
-	return nil
+// SetArrangement places the objects contained in the arrangement at their stored
+// position instead of packing them. Objects missing from it are packed as usual.
+func (c *Combiner) SetArrangement(a *arrangement.Arrangement) {
+	c.arrangement = a
 }
 
-// Combiner combines multiple 3MF models
-type Combiner struct {
-	reader *Reader
-	writer *Writer
-	Debug  bool // Enable debug output
+// Placements returns the final placement of all objects of the last combine
+func (c *Combiner) Placements() *arrangement.Arrangement {
+	return c.placements
 }
 
-// NewCombiner creates a new Combiner
-func NewCombiner() *Combiner {
-	return &Combiner{
-		reader: &Reader{},
-		writer: &Writer{},
-	}
+// UnarrangedObjects returns the objects of the last combine that were packed
+// because the arrangement did not contain them
+func (c *Combiner) UnarrangedObjects() []string {
+	return c.unarranged
 }
 
-// SetDebug enables or disables debug output
-func (c *Combiner) SetDebug(debug bool) {
-	c.Debug = debug
+// placeObject returns the build transform of an object: its placement from the
+// arrangement if there is one, otherwise the packed transform. The final
+// placement is recorded so that it can be exported.
+func (c *Combiner) placeObject(objectName string, plate int, packedTransform string) (string, error) {
+	transform := packedTransform
+	if c.arrangement != nil {
+		if placement, ok := c.arrangement.Lookup(objectName); ok {
+			transform = placement.Transform()
+		} else {
+			c.unarranged = append(c.unarranged, objectName)
+		}
+	}
+
+	placement, err := arrangement.FromTransform(objectName, plate, transform)
+	if err != nil {
+		return "", err
+	}
+	c.placements.Add(placement)
+	return transform, nil
 }
 
 // Combine combines multiple 3MF files into one
 func (c *Combiner) Combine(tempFiles []string, scadFiles []models.ScadFile, outputFile string) error {
-	c.CombineWithDistance(tempFiles, scadFiles, outputFile, 10.0)
-	return nil
+	return c.CombineWithDistance(tempFiles, scadFiles, outputFile, models.DefaultPackingDistance)
+}
+
+// readMeshes reads a 3MF file without the objects that cannot be combined
+// because their geometry exists only in slice or beam lattice extensions. Files
+// requiring unsupported extensions fail unless they are ignored. With flatten,
+// objects made of components become one mesh each.
+func (c *Combiner) readMeshes(filename string) (*models.Model, error) {
+	zr, closeZip, err := c.archives.open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("error opening ZIP: %w", err)
+	}
+	defer closeZip()
+	model, ext, err := c.reader.readZip(zr)
+	if err != nil {
+		return nil, err
+	}
+	warning, err := CheckRequiredExtensions(ext, filepath.Base(filename), c.ignoreExt)
+	if err != nil {
+		return nil, err
+	}
+	if warning != "" {
+		c.warnings = append(c.warnings, warning)
+	}
+	if !ext.Empty() {
+		c.warnings = append(c.warnings, DropExtensionObjects(model, ext, filepath.Base(filename))...)
+		if len(model.Resources.Objects) == 0 {
+			return nil, fmt.Errorf("%s contains no mesh objects to combine", filepath.Base(filename))
+		}
+	}
+	if c.flatten {
+		if _, err := FlattenComponents(model); err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Base(filename), err)
+		}
+	}
+	return model, nil
+}
+
+// readSourceParts returns the names and filament slots of the meshes of an
+// input from its model settings. Settings that cannot be read are reported and
+// ignored, as the meshes can be combined without them.
+func (c *Combiner) readSourceParts(filename string, model *models.Model) map[string]SourcePart {
+	parts, err := c.archives.sourceParts(filename, model)
+	if err != nil {
+		c.warnings = append(c.warnings, fmt.Sprintf("%s: model settings ignored: %v", filepath.Base(filename), err))
+	}
+	return parts
 }
 
 // CombineWithDistance combines multiple 3MF files with a configurable packing distance
@@ -307,7 +435,7 @@ func (c *Combiner) CombineWithDistance(tempFiles []string, scadFiles []models.Sc
 
 	// Read all models and collect their objects
 	for i, tempFile := range tempFiles {
-		model, err := c.reader.Read(tempFile)
+		model, err := c.readMeshes(tempFile)
 		if err != nil {
 			return fmt.Errorf("error reading 3MF file %d: %w", i, err)
 		}
@@ -326,6 +454,7 @@ func (c *Combiner) CombineWithDistance(tempFiles []string, scadFiles []models.Sc
 			}
 			obj.PID = strconv.Itoa(filamentSlot)
 			obj.PIndex = "0"
+			setObjectType(&obj, scadFiles[i].Type)
 
 			allObjects = append(allObjects, obj)
 		}
@@ -347,18 +476,18 @@ func (c *Combiner) CombineWithDistance(tempFiles []string, scadFiles []models.Sc
 		})
 
 		// Calculate width of this object for next position
-		bbox, err := geometry.CalculateBoundingBox(&allObjects[i])
-		if err == nil {
+		if bbox := c.objectBoundingBox(allObjects[i].Name, allObjects[i:i+1], scadFiles[i:i+1]); bbox != nil {
 			currentXOffset += bbox.Width() + margin
 		} else {
-			currentXOffset += 50.0 // fallback spacing
+			c.warnings = append(c.warnings, fmt.Sprintf("%s: size unknown, spaced by %.1f mm", allObjects[i].Name, fallbackSize))
+			currentXOffset += fallbackSize + margin
 		}
 	}
 
 	parentID := strconv.Itoa(len(allObjects) + 1)
 	parentObject := models.Object{
 		ID:   parentID,
-		Type: "model",
+		Type: ParentType(allObjects),
 		Components: &models.Components{
 			Component: components,
 		},
@@ -388,20 +517,22 @@ func (c *Combiner) CombineWithDistance(tempFiles []string, scadFiles []models.Sc
 	// Create single object group for settings
 	objectGroups := []models.ObjectGroup{
 		{
-			ID:    parentID,
-			Name:  "combined",
-			Parts: scadFiles,
+			ID:         parentID,
+			Name:       "combined",
+			Parts:      scadFiles,
+			FaceCounts: FaceCounts(allObjects),
 		},
 	}
 
 	// Write combined model to output file with Bambu support
-	return c.writer.WriteBambu(outputFile, combinedModel, tempFiles[0], objectGroups, buildItems)
+	return c.create(outputFile, func(out io.Writer) error {
+		return c.writer.EncodeBambu(out, combinedModel, tempFiles, objectGroups, buildItems)
+	})
 }
 
 // CombineWithGroups combines multiple 3MF files into one, grouping parts by object name
 func (c *Combiner) CombineWithGroups(tempFiles []string, scadFiles []models.ScadFile, outputFile string) error {
-	c.CombineWithGroupsAndDistance(tempFiles, scadFiles, outputFile, 10.0, models.PackingAlgorithmDefault)
-	return nil
+	return c.CombineWithGroupsAndDistance(tempFiles, scadFiles, outputFile, models.DefaultPackingDistance, models.PackingAlgorithmDefault)
 }
 
 // CombineWithObjectGroups combines multiple 3MF files with ObjectGroup metadata including normalization settings
@@ -412,6 +543,9 @@ func (c *Combiner) CombineWithObjectGroups(tempFiles []string, objectGroups []mo
 
 	for _, group := range objectGroups {
 		for _, part := range group.Parts {
+			if part.Group == "" {
+				part.Group = group.Name
+			}
 			scadFiles = append(scadFiles, part)
 			// Store the normalize_position setting for this object
 			objectGroupMap[group.Name] = group.NormalizePosition
@@ -428,23 +562,39 @@ func (c *Combiner) CombineWithGroupsAndDistance(tempFiles []string, scadFiles []
 }
 
 func (c *Combiner) combineWithGroupsAndDistanceInternal(tempFiles []string, scadFiles []models.ScadFile, objectGroups []models.ObjectGroup, outputFile string, packingDistance float64, algorithm models.PackingAlgorithm) error {
+	c.placements, c.unarranged, c.warnings = arrangement.New(), nil, nil
+
 	var allMeshObjects []models.Object
 	meshMinZ := make(map[int]float64) // mesh index -> minZ after rotation
 	nextID := 1
 
+	// Slots taken from the inputs must not change the parts of the caller
+	scadFiles = append([]models.ScadFile(nil), scadFiles...)
+
 	// Read all models and collect their mesh objects
 	for i, tempFile := range tempFiles {
-		model, err := c.reader.Read(tempFile)
+		model, err := c.readMeshes(tempFile)
 		if err != nil {
 			return fmt.Errorf("error reading 3MF file %d: %w", i, err)
 		}
+		sourceParts := c.readSourceParts(tempFile, model)
 
 		// Collect mesh objects
 		for _, obj := range model.Resources.Objects {
+			sourceID := obj.ID
 			obj.ID = strconv.Itoa(nextID)
 			obj.Name = scadFiles[i].Name
 			obj.UUID = "" // Will be set in components
 
+			// Parts without a filament slot keep the one of their input
+			if scadFiles[i].FilamentSlot == 0 {
+				scadFiles[i].FilamentSlot = sourceParts[sourceID].Extruder
+			}
+			// and the placement within their object
+			if scadFiles[i].Matrix == "" {
+				scadFiles[i].Matrix = sourceParts[sourceID].Matrix
+			}
+
 			// Set PID (Production ID) based on filament slot
 			filamentSlot := scadFiles[i].FilamentSlot
 			if filamentSlot == 0 {
@@ -453,6 +603,7 @@ func (c *Combiner) combineWithGroupsAndDistanceInternal(tempFiles []string, scad
 			}
 			obj.PID = strconv.Itoa(filamentSlot)
 			obj.PIndex = "0"
+			setObjectType(&obj, scadFiles[i].Type)
 
 			// Apply rotation only (no Z normalization yet - will be done at group level)
 			scadFile := scadFiles[i]
@@ -460,6 +611,14 @@ func (c *Combiner) combineWithGroupsAndDistanceInternal(tempFiles []string, scad
 			if err != nil {
 				return fmt.Errorf("error rotating mesh vertices for %s: %w", scadFile.Name, err)
 			}
+			if c.normalize == models.NormalizationPreserve {
+				if itemZ := buildItemZ(model, sourceID); itemZ != 0 {
+					if err := geometry.ApplyZOffset(&obj, itemZ); err != nil {
+						return fmt.Errorf("error applying Z offset to mesh: %w", err)
+					}
+					minZ += itemZ
+				}
+			}
 			meshMinZ[i] = minZ
 
 			allMeshObjects = append(allMeshObjects, obj)
@@ -467,19 +626,18 @@ func (c *Combiner) combineWithGroupsAndDistanceInternal(tempFiles []string, scad
 		}
 	}
 
-	// Group mesh objects by their base object name (before the '/')
+	// Place parts with alignment constraints using the rotated meshes
+	scadFiles, err := alignParts(allMeshObjects, scadFiles, c.separator)
+	if err != nil {
+		return err
+	}
+
+	// Group mesh objects by their object (explicit group or name before the separator)
 	objectGroupsMap := make(map[string][]int) // object name -> list of mesh object IDs
 	objectOrder := []string{}                 // preserve order of objects
 
 	for i, scadFile := range scadFiles {
-		// Extract object name (part before '/')
-		objectName := scadFile.Name
-		for j := 0; j < len(objectName); j++ {
-			if objectName[j] == '/' {
-				objectName = objectName[:j]
-				break
-			}
-		}
+		objectName := scadFile.ObjectName(c.separator)
 
 		// Track first occurrence for ordering
 		if _, exists := objectGroupsMap[objectName]; !exists {
@@ -507,6 +665,7 @@ func (c *Combiner) combineWithGroupsAndDistanceInternal(tempFiles []string, scad
 		bboxOffsetY  float64
 	})
 
+	unknownSize := make(map[string]bool)
 	packingID := 0
 	for _, objectName := range objectOrder {
 		meshIDs := objectGroupsMap[objectName]
@@ -523,64 +682,30 @@ func (c *Combiner) combineWithGroupsAndDistanceInternal(tempFiles []string, scad
 		// Note: Rotation is already baked into mesh vertices, so we use standard bounding box
 		var width, height float64
 		var bboxOffsetX, bboxOffsetY float64 // Offset to align bbox corner to origin
-		if len(meshIDs) == 1 {
-			// Use standard bounding box (rotation already baked into mesh)
-			bbox, err := geometry.CalculateBoundingBox(&groupObjects[0])
-			if err == nil {
-				width = bbox.Width()
-				height = bbox.Height()
-				// Store offset needed to bring the bbox corner to the expected position
-				bboxOffsetX = -bbox.MinX
-				bboxOffsetY = -bbox.MinY
-				if c.Debug {
-					fmt.Printf("DEBUG: %s - bbox(%.1f,%.1f)-(%.1f,%.1f) size(%.1f,%.1f) offset(%.1f,%.1f)\n",
-						objectName, bbox.MinX, bbox.MinY, bbox.MaxX, bbox.MaxY, width, height, bboxOffsetX, bboxOffsetY)
-				}
-			} else {
-				width, height = 50.0, 50.0 // fallback
-				if c.Debug {
-					fmt.Printf("DEBUG: %s - fallback size(50,50) - error: %v\n", objectName, err)
-				}
+		if bbox := c.objectBoundingBox(objectName, groupObjects, groupScadFiles); bbox != nil {
+			width = bbox.Width()
+			height = bbox.Height()
+			// Store offset needed to bring the bbox corner to the expected position
+			bboxOffsetX = -bbox.MinX
+			bboxOffsetY = -bbox.MinY
+			if c.Debug {
+				fmt.Fprintf(ui.Output(), "DEBUG: %s - bbox(%.1f,%.1f)-(%.1f,%.1f) size(%.1f,%.1f) offset(%.1f,%.1f)\n",
+					objectName, bbox.MinX, bbox.MinY, bbox.MaxX, bbox.MaxY, width, height, bboxOffsetX, bboxOffsetY)
 			}
 		} else {
-			// For multi-part objects, calculate combined bounding box
-			var combinedBBox *geometry.BoundingBox
-			for i, obj := range groupObjects {
-				scadFile := groupScadFiles[i]
-				bbox, err := geometry.CalculateBoundingBox(&obj)
-				if err != nil {
-					continue
-				}
-				// Apply position offsets
-				bbox.MinX += scadFile.PositionX
-				bbox.MaxX += scadFile.PositionX
-				bbox.MinY += scadFile.PositionY
-				bbox.MaxY += scadFile.PositionY
-
-				if combinedBBox == nil {
-					combinedBBox = bbox
-				} else {
-					combinedBBox.MinX = math.Min(combinedBBox.MinX, bbox.MinX)
-					combinedBBox.MinY = math.Min(combinedBBox.MinY, bbox.MinY)
-					combinedBBox.MaxX = math.Max(combinedBBox.MaxX, bbox.MaxX)
-					combinedBBox.MaxY = math.Max(combinedBBox.MaxY, bbox.MaxY)
-				}
-			}
-			if combinedBBox != nil {
-				width = combinedBBox.Width()
-				height = combinedBBox.Height()
-				// Store offset needed to bring the combined bbox corner to the expected position
-				bboxOffsetX = -combinedBBox.MinX
-				bboxOffsetY = -combinedBBox.MinY
-			} else {
-				width, height = 100.0, 100.0 // fallback
-			}
+			unknownSize[objectName] = true // estimated once all objects are measured
 		}
 
+		width, height, bboxOffsetX, bboxOffsetY = applyObjectMargin(objectGroups, objectName, width, height, bboxOffsetX, bboxOffsetY)
+
 		packingObjects = append(packingObjects, geometry.Rectangle{
-			Width:  width,
-			Height: height,
-			ID:     packingID,
+			Width:   width,
+			Height:  height,
+			Depth:   printHeight(groupObjects, groupScadFiles),
+			ID:      packingID,
+			Name:    objectName,
+			Outline: c.outline(groupObjects, groupScadFiles, bboxOffsetX, bboxOffsetY),
+			Margin:  objectMargin(objectGroups, objectName),
 		})
 
 		objectInfoMap[packingID] = struct {
@@ -594,12 +719,18 @@ func (c *Combiner) combineWithGroupsAndDistanceInternal(tempFiles []string, scad
 
 		packingID++
 	}
+	// Objects of unknown size are estimated from the others
+	rects := make([]*geometry.Rectangle, len(packingObjects))
+	for i := range packingObjects {
+		rects[i] = &packingObjects[i]
+	}
+	c.estimateFootprints(rects, unknownSize)
 
 	// Apply group-level Z normalization
 	// For each object group, calculate the minimum Z (considering PositionZ offsets) and normalize
 	for _, info := range objectInfoMap {
 		// Determine if we should normalize position for this group
-		normalizePosition := true // default to true
+		normalizePosition := c.normalize == models.NormalizationGround
 		if objectGroups != nil {
 			for _, og := range objectGroups {
 				if og.Name == info.objectName {
@@ -609,14 +740,20 @@ func (c *Combiner) combineWithGroupsAndDistanceInternal(tempFiles []string, scad
 			}
 		}
 
-		if !normalizePosition {
-			continue
-		}
-
-		// Calculate the minimum Z across all parts in this group (considering PositionZ)
+		// Calculate the minimum Z across all parts in this group (considering PositionZ).
+		// Parts anchored to the bed are placed on their own and do not move the group.
 		groupMinZ := math.MaxFloat64
 		for i, meshID := range info.meshIDs {
 			partMinZ := meshMinZ[meshID-1] // meshMinZ is 0-indexed, meshID is 1-indexed
+			if info.scadFiles[i].Anchor == models.AnchorBed {
+				// The part's bottom goes to Z=0, its position_z then lifts it above the plate
+				if partMinZ != 0 {
+					if err := geometry.ApplyZOffset(&allMeshObjects[meshID-1], -partMinZ); err != nil {
+						return fmt.Errorf("error applying Z offset to mesh: %w", err)
+					}
+				}
+				continue
+			}
 			partPositionZ := info.scadFiles[i].PositionZ
 			effectiveMinZ := partMinZ + partPositionZ
 			if effectiveMinZ < groupMinZ {
@@ -624,10 +761,17 @@ func (c *Combiner) combineWithGroupsAndDistanceInternal(tempFiles []string, scad
 			}
 		}
 
+		if !normalizePosition {
+			continue
+		}
+
 		// Apply the group-level Z offset to normalize to ground level
 		if groupMinZ != math.MaxFloat64 && groupMinZ != 0 {
 			zOffset := -groupMinZ
-			for _, meshID := range info.meshIDs {
+			for i, meshID := range info.meshIDs {
+				if info.scadFiles[i].Anchor == models.AnchorBed {
+					continue
+				}
 				if err := geometry.ApplyZOffset(&allMeshObjects[meshID-1], zOffset); err != nil {
 					return fmt.Errorf("error applying Z offset to mesh: %w", err)
 				}
@@ -636,14 +780,18 @@ func (c *Combiner) combineWithGroupsAndDistanceInternal(tempFiles []string, scad
 	}
 
 	// Use bin packing algorithm to arrange objects based on selected algorithm
-	packer := geometry.NewPacker(margin)
-	var packingResults []geometry.PackingResult
-	
-	switch algorithm {
-	case models.PackingAlgorithmCompact:
-		packingResults = packer.PackCompact(packingObjects)
-	default:
-		packingResults = packer.PackOptimal(packingObjects, 256.0) // 256mm typical build plate width
+	packingResults, err := c.pack(packingObjects, margin, algorithm, c.printer.Width)
+	if err != nil {
+		return err
+	}
+
+	// Equal meshes are stored once and referenced by the components of all their parts
+	shared := sharedMeshes(allMeshObjects)
+	meshObjectID := func(meshID int) string {
+		if firstID, ok := shared[meshID]; ok {
+			return strconv.Itoa(firstID)
+		}
+		return strconv.Itoa(meshID)
 	}
 
 	// Create objects and build items based on packing results
@@ -656,19 +804,21 @@ func (c *Combiner) combineWithGroupsAndDistanceInternal(tempFiles []string, scad
 		bboxOffsetY := info.bboxOffsetY
 
 		if c.Debug {
-			fmt.Printf("DEBUG PACK: %s - packer pos(%.1f,%.1f) size(%.1f,%.1f) offset(%.1f,%.1f) -> final pos(%.1f,%.1f) occupies(%.1f,%.1f)-(%.1f,%.1f)\n",
+			fmt.Fprintf(ui.Output(), "DEBUG PACK: %s - packer pos(%.1f,%.1f) size(%.1f,%.1f) offset(%.1f,%.1f) -> final pos(%.1f,%.1f) occupies(%.1f,%.1f)-(%.1f,%.1f)\n",
 				objectName, result.X, result.Y, result.Width, result.Height, bboxOffsetX, bboxOffsetY,
 				result.X+bboxOffsetX, result.Y+bboxOffsetY,
 				result.X, result.Y, result.X+result.Width, result.Y+result.Height)
 		}
 
 		// Determine if we should normalize position
-		normalizePosition := true // default to true
+		normalizePosition := c.normalize == models.NormalizationGround
+		var slicer models.SlicerOptions
 		if objectGroups != nil {
-			// Look up the normalize_position setting from objectGroups
+			// Look up the normalize_position and slicer settings from objectGroups
 			for _, og := range objectGroups {
 				if og.Name == objectName {
 					normalizePosition = og.NormalizePosition
+					slicer = og.Slicer
 					break
 				}
 			}
@@ -690,14 +840,17 @@ func (c *Combiner) combineWithGroupsAndDistanceInternal(tempFiles []string, scad
 		// Build transform for positioning on the build plate (translation only, rotation is baked)
 		var buildTransform string
 
-		// If only one part in this object, add it directly to build
-		if len(meshIDs) == 1 {
+		// If only one part in this object, add it directly to build (shared meshes are referenced by components)
+		if _, isShared := shared[meshIDs[0]]; len(meshIDs) == 1 && !isShared {
 			objectID := strconv.Itoa(meshIDs[0])
 
 			// Use translation-only transform since rotation is baked into mesh
 			scadFile := groupScadFiles[0]
-			buildTransform = geometry.BuildTranslationTransform(
-				result.X+scadFile.PositionX+bboxOffsetX, result.Y+scadFile.PositionY+bboxOffsetY, zOffset+scadFile.PositionZ)
+			buildTransform, err = c.placeObject(objectName, 0, geometry.BuildTranslationTransform(
+				result.X+scadFile.PositionX+bboxOffsetX, result.Y+scadFile.PositionY+bboxOffsetY, zOffset+scadFile.PositionZ))
+			if err != nil {
+				return err
+			}
 
 			buildItems = append(buildItems, models.Item{
 				ObjectID:  objectID,
@@ -710,12 +863,16 @@ func (c *Combiner) combineWithGroupsAndDistanceInternal(tempFiles []string, scad
 				ID:                objectID,
 				Name:              objectName,
 				Parts:             groupScadFiles,
+				PartIDs:           []string{objectID},
+				FaceCounts:        FaceCounts(info.groupObjects),
 				NormalizePosition: normalizePosition,
+				Slicer:            slicer,
 			})
 		} else {
 			// Create a parent object with multiple components
 			// Parts within an object maintain their relative positions
 			var components []models.Component
+			var partIDs []string
 
 			for i, meshID := range meshIDs {
 				// Apply only position offsets from ScadFile to each component (rotation is baked)
@@ -724,9 +881,10 @@ func (c *Combiner) combineWithGroupsAndDistanceInternal(tempFiles []string, scad
 					scadFile.PositionX, scadFile.PositionY, scadFile.PositionZ)
 
 				components = append(components, models.Component{
-					ObjectID:  strconv.Itoa(meshID),
+					ObjectID:  meshObjectID(meshID),
 					Transform: transform,
 				})
+				partIDs = append(partIDs, meshObjectID(meshID))
 			}
 
 			parentID := strconv.Itoa(nextID)
@@ -735,7 +893,7 @@ func (c *Combiner) combineWithGroupsAndDistanceInternal(tempFiles []string, scad
 			parentObject := models.Object{
 				ID:   parentID,
 				Name: objectName,
-				Type: "model",
+				Type: ParentType(info.groupObjects),
 				Components: &models.Components{
 					Component: components,
 				},
@@ -744,7 +902,10 @@ func (c *Combiner) combineWithGroupsAndDistanceInternal(tempFiles []string, scad
 			parentObjects = append(parentObjects, parentObject)
 
 			// Apply bboxOffset to position the object correctly
-			buildTransform = geometry.BuildTranslationTransform(result.X+bboxOffsetX, result.Y+bboxOffsetY, zOffset)
+			buildTransform, err = c.placeObject(objectName, 0, geometry.BuildTranslationTransform(result.X+bboxOffsetX, result.Y+bboxOffsetY, zOffset))
+			if err != nil {
+				return err
+			}
 
 			buildItems = append(buildItems, models.Item{
 				ObjectID:  parentID,
@@ -757,13 +918,22 @@ func (c *Combiner) combineWithGroupsAndDistanceInternal(tempFiles []string, scad
 				ID:                parentID,
 				Name:              objectName,
 				Parts:             groupScadFiles,
+				PartIDs:           partIDs,
+				FaceCounts:        FaceCounts(info.groupObjects),
 				NormalizePosition: normalizePosition,
+				Slicer:            slicer,
 			})
 		}
 	}
 
-	// Combine all objects
-	allObjects := append(allMeshObjects, parentObjects...)
+	// Combine all objects, leaving out the duplicates of shared meshes
+	var allObjects []models.Object
+	for i, obj := range allMeshObjects {
+		if firstID, ok := shared[i+1]; !ok || firstID == i+1 {
+			allObjects = append(allObjects, obj)
+		}
+	}
+	allObjects = append(allObjects, parentObjects...)
 
 	// Create the combined model
 	combinedModel := &models.Model{
@@ -779,7 +949,23 @@ func (c *Combiner) combineWithGroupsAndDistanceInternal(tempFiles []string, scad
 	}
 
 	// Write combined model to output file with Bambu support
-	return c.writer.WriteBambu(outputFile, combinedModel, tempFiles[0], settingsGroups, buildItems)
+	return c.create(outputFile, func(out io.Writer) error {
+		return c.writer.EncodeBambu(out, combinedModel, tempFiles, settingsGroups, buildItems)
+	})
+}
+
+// buildItemZ returns the Z translation of the build item placing the object in
+// a source model (0 if the object is not placed by a build item)
+func buildItemZ(model *models.Model, objectID string) float64 {
+	for _, item := range model.Build.Items {
+		if item.ObjectID != objectID || item.Transform == "" {
+			continue
+		}
+		if m, err := geometry.ParseTransform(item.Transform); err == nil {
+			return m[11]
+		}
+	}
+	return 0
 }
 
 func getMaxObjectID(model *models.Model) int {
@@ -792,8 +978,191 @@ func getMaxObjectID(model *models.Model) int {
 	return maxID
 }
 
+// pack arranges the packing objects on a plate of the given width using the
+// packing algorithm, or in print height order for by_height and sequential layouts.
+// Layouts start at the front of the plate unless the packing origin is the back.
+func (c *Combiner) pack(objects []geometry.Rectangle, margin float64, algorithm models.PackingAlgorithm, plateWidth float64) ([]geometry.PackingResult, error) {
+	if c.origin == models.PackingOriginBack {
+		objects = geometry.MirrorOutlinesY(objects)
+	}
+	results, err := c.packObjects(objects, margin, algorithm, plateWidth)
+	if err != nil {
+		return nil, err
+	}
+	if c.origin == models.PackingOriginBack {
+		// Gridded layouts stay on the grid if mirrored on a plate of whole grid cells
+		depth := c.printer.Depth
+		if c.grid > 0 {
+			depth = math.Floor(depth/c.grid) * c.grid
+		}
+		results = geometry.MirrorY(results, depth)
+	}
+
+	results = geometry.AvoidZones(results, c.printer.ExclusionZones, margin, c.grid, plateWidth, c.printer.Depth)
+	c.checkFit(objects, results, plateWidth)
+	return results, nil
+}
+
+// checkFit records a warning for every packed object that is taller than the printer,
+// leaves the plate or occupies an exclusion zone
+func (c *Combiner) checkFit(objects []geometry.Rectangle, results []geometry.PackingResult, plateWidth float64) {
+	const tolerance = 1e-6
+	byID := make(map[int]geometry.Rectangle, len(objects))
+	for _, obj := range objects {
+		byID[obj.ID] = obj
+	}
+
+	for _, r := range results {
+		obj := byID[r.ID]
+		if c.printer.Height > 0 && obj.Depth > c.printer.Height+tolerance {
+			c.warnings = append(c.warnings, fmt.Sprintf("%s is %.1fmm tall, the printer builds up to %.1fmm", obj.Name, obj.Depth, c.printer.Height))
+		}
+		if r.X+r.Width > plateWidth+tolerance || r.Y+r.Height > c.printer.Depth+tolerance {
+			c.warnings = append(c.warnings, fmt.Sprintf("%s does not fit on the %.0fx%.0fmm plate", obj.Name, plateWidth, c.printer.Depth))
+		}
+		for _, zone := range c.printer.ExclusionZones {
+			if geometry.OverlapsZone(r, zone, 0) {
+				c.warnings = append(c.warnings, fmt.Sprintf("%s occupies the exclusion zone at (%.0f,%.0f)", obj.Name, zone.X, zone.Y))
+				break
+			}
+		}
+	}
+}
+
+// packObjects arranges the objects with the configured footprint, order and algorithm
+func (c *Combiner) packObjects(objects []geometry.Rectangle, margin float64, algorithm models.PackingAlgorithm, plateWidth float64) ([]geometry.PackingResult, error) {
+	packer := geometry.NewPacker(margin)
+	packer.SetMaxRowWidth(c.maxRowWidth(plateWidth))
+
+	if c.footprint == models.FootprintHull && c.sequential == nil {
+		// Objects are placed on the grid positions scanned by the packer, so no snapping is needed
+		ordered := geometry.SortByPrintHeight(objects)
+		if c.order != models.PackingOrderByHeight {
+			ordered = sortByFootprintArea(objects)
+		}
+		return packer.PackHull(ordered, plateWidth, c.grid), nil
+	}
+
+	if c.grid > 0 {
+		gapX, gapY := margin, margin
+		if c.sequential != nil {
+			gapX = math.Max(margin, c.sequential.ClearanceX)
+			gapY = math.Max(margin, c.sequential.ClearanceY)
+		}
+		objects = geometry.SnapToGrid(objects, gapX, gapY, c.grid)
+	}
+
+	if c.sequential != nil {
+		sorted := geometry.SortByPrintHeight(objects)
+		// The gantry passes over everything printed before, so only the last object may be taller
+		if gantry := c.sequential.GantryHeight; gantry > 0 && len(sorted) > 1 && sorted[len(sorted)-2].Depth > gantry {
+			tooTall := 0
+			for _, obj := range sorted {
+				if obj.Depth > gantry {
+					tooTall++
+				}
+			}
+			return nil, fmt.Errorf("sequential printing: %d objects are taller than the gantry height of %.1fmm, only one (printed last) is allowed", tooTall, gantry)
+		}
+		return packer.PackSequential(sorted, plateWidth, c.sequential.ClearanceX, c.sequential.ClearanceY), nil
+	}
+
+	if c.order == models.PackingOrderByHeight {
+		return packer.PackSequential(geometry.SortByPrintHeight(objects), plateWidth, 0, 0), nil
+	}
+
+	switch algorithm {
+	case models.PackingAlgorithmCompact:
+		return packer.PackCompact(objects), nil
+	default:
+		return packer.PackOptimal(objects, c.maxRowWidth(plateWidth)), nil
+	}
+}
+
+// sortByFootprintArea returns the objects sorted by footprint area (largest first),
+// so that small objects can fill the gaps between large ones
+func sortByFootprintArea(objects []geometry.Rectangle) []geometry.Rectangle {
+	area := func(r geometry.Rectangle) float64 {
+		if len(r.Outline) >= 3 {
+			return geometry.PolygonArea(r.Outline)
+		}
+		return r.Width * r.Height
+	}
+
+	sorted := append([]geometry.Rectangle(nil), objects...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return area(sorted[i]) > area(sorted[j])
+	})
+	return sorted
+}
+
+// outline returns the convex footprint of an object relative to its packing
+// rectangle, or nil if the bounding box footprint is used
+func (c *Combiner) outline(groupObjects []models.Object, scadFiles []models.ScadFile, offsetX, offsetY float64) []geometry.Point {
+	if c.footprint != models.FootprintHull {
+		return nil
+	}
+
+	var points []geometry.Point
+	for i := range groupObjects {
+		partPoints, err := geometry.FootprintPoints(&groupObjects[i], scadFiles[i].PositionX+offsetX, scadFiles[i].PositionY+offsetY)
+		if err != nil {
+			continue
+		}
+		points = append(points, partPoints...)
+	}
+
+	hull := geometry.ConvexHull(points)
+	if len(hull) < 3 {
+		return nil // Degenerate footprint, use the rectangle
+	}
+	return hull
+}
+
+// printHeight returns the height of an object on the plate: the Z extent of all its
+// parts (rotation is already baked into the meshes, position offsets are applied)
+func printHeight(groupObjects []models.Object, scadFiles []models.ScadFile) float64 {
+	minZ, maxZ := math.MaxFloat64, -math.MaxFloat64
+	for i := range groupObjects {
+		bbox, err := geometry.CalculateBoundingBox(&groupObjects[i])
+		if err != nil {
+			continue
+		}
+		partMinZ, partMaxZ := bbox.MinZ+scadFiles[i].PositionZ, bbox.MaxZ+scadFiles[i].PositionZ
+		if scadFiles[i].Anchor == models.AnchorBed {
+			// The bottom of the part is placed position_z above the plate
+			partMinZ, partMaxZ = 0, bbox.MaxZ-bbox.MinZ+scadFiles[i].PositionZ
+		}
+		minZ = math.Min(minZ, partMinZ)
+		maxZ = math.Max(maxZ, partMaxZ)
+	}
+	if minZ > maxZ {
+		return 0
+	}
+	return maxZ - minZ
+}
+
+// applyObjectMargin enlarges the packing footprint of an object by its extra margin
+// on all sides and shifts the object so that it stays centered in the footprint
+func applyObjectMargin(objectGroups []models.ObjectGroup, objectName string, width, height, offsetX, offsetY float64) (float64, float64, float64, float64) {
+	margin := objectMargin(objectGroups, objectName)
+	return width + 2*margin, height + 2*margin, offsetX + margin, offsetY + margin
+}
+
+// objectMargin returns the extra margin of an object (0 if it has none)
+func objectMargin(objectGroups []models.ObjectGroup, objectName string) float64 {
+	for _, og := range objectGroups {
+		if og.Name == objectName && og.Margin > 0 {
+			return og.Margin
+		}
+	}
+	return 0
+}
+
 // CombineWithPlateGroups combines multiple 3MF files with multi-plate support
 func (c *Combiner) CombineWithPlateGroups(tempFiles []string, plateGroups []models.PlateGroup, outputFile string, packingDistance float64, algorithm models.PackingAlgorithm, plateWidth float64) error {
+	c.placements, c.unarranged, c.warnings = arrangement.New(), nil, nil
+
 	var allMeshObjects []models.Object
 	var allScadFiles []models.ScadFile
 	var allObjectGroups []models.ObjectGroup
@@ -814,14 +1183,23 @@ func (c *Combiner) CombineWithPlateGroups(tempFiles []string, plateGroups []mode
 	}
 
 	// Read all models and collect their mesh objects
+	sourceSlots := make([]int, len(tempFiles))       // Filament slot of the mesh of every input (0 = none)
+	sourceMatrices := make([]string, len(tempFiles)) // Matrix of the mesh of every input ("" = identity)
 	for i, tempFile := range tempFiles {
-		model, err := c.reader.Read(tempFile)
+		model, err := c.readMeshes(tempFile)
 		if err != nil {
 			return fmt.Errorf("error reading 3MF file %d: %w", i, err)
 		}
+		sourceParts := c.readSourceParts(tempFile, model)
 
 		// Collect mesh objects
 		for _, obj := range model.Resources.Objects {
+			if sourceSlots[i] == 0 {
+				sourceSlots[i] = sourceParts[obj.ID].Extruder
+			}
+			if sourceMatrices[i] == "" {
+				sourceMatrices[i] = sourceParts[obj.ID].Matrix
+			}
 			obj.ID = strconv.Itoa(nextID)
 			obj.UUID = ""
 			allMeshObjects = append(allMeshObjects, obj)
@@ -836,6 +1214,14 @@ func (c *Combiner) CombineWithPlateGroups(tempFiles []string, plateGroups []mode
 			allObjectGroups = append(allObjectGroups, obj)
 			for _, part := range obj.Parts {
 				part.Name = obj.Name
+				part.Group = obj.Name
+				// Parts without a filament slot keep the one of their input
+				if part.FilamentSlot == 0 && fileIdx < len(sourceSlots) {
+					part.FilamentSlot = sourceSlots[fileIdx]
+				}
+				if part.Matrix == "" && fileIdx < len(sourceMatrices) {
+					part.Matrix = sourceMatrices[fileIdx]
+				}
 				if len(obj.Parts) > 1 {
 					// Only use composite name for multi-part objects
 					// The part.Name has already been set correctly in ConvertToPlateGroups
@@ -845,19 +1231,18 @@ func (c *Combiner) CombineWithPlateGroups(tempFiles []string, plateGroups []mode
 			}
 		}
 	}
+	for i := range allMeshObjects {
+		if i < len(allScadFiles) {
+			setObjectType(&allMeshObjects[i], allScadFiles[i].Type)
+		}
+	}
 
 	// Group mesh objects by their base object name
 	objectGroupsMap := make(map[string][]int)
 	objectOrder := []string{}
 
 	for i, scadFile := range allScadFiles {
-		objectName := scadFile.Name
-		for j := 0; j < len(objectName); j++ {
-			if objectName[j] == '/' {
-				objectName = objectName[:j]
-				break
-			}
-		}
+		objectName := scadFile.ObjectName(c.separator)
 
 		if _, exists := objectGroupsMap[objectName]; !exists {
 			objectOrder = append(objectOrder, objectName)
@@ -905,6 +1290,7 @@ func (c *Combiner) CombineWithPlateGroups(tempFiles []string, plateGroups []mode
 		}
 	}
 
+	unknownSize := make(map[string]bool)
 	packingIDCounter := 0
 	for _, objectName := range objectOrder {
 		meshIDs := objectGroupsMap[objectName]
@@ -920,55 +1306,28 @@ func (c *Combiner) CombineWithPlateGroups(tempFiles []string, plateGroups []mode
 		// Calculate dimensions for packing (rotation already baked into mesh)
 		var width, height float64
 		var bboxOffsetX, bboxOffsetY float64
-		if len(meshIDs) == 1 {
-			bbox, err := geometry.CalculateBoundingBox(&groupObjects[0])
-			if err == nil {
-				width = bbox.Width()
-				height = bbox.Height()
-				bboxOffsetX = -bbox.MinX
-				bboxOffsetY = -bbox.MinY
-			} else {
-				width, height = 50.0, 50.0
-			}
+		if bbox := c.objectBoundingBox(objectName, groupObjects, groupScadFiles); bbox != nil {
+			width = bbox.Width()
+			height = bbox.Height()
+			bboxOffsetX = -bbox.MinX
+			bboxOffsetY = -bbox.MinY
 		} else {
-			var combinedBBox *geometry.BoundingBox
-			for i, obj := range groupObjects {
-				scadFile := groupScadFiles[i]
-				bbox, err := geometry.CalculateBoundingBox(&obj)
-				if err != nil {
-					continue
-				}
-				bbox.MinX += scadFile.PositionX
-				bbox.MaxX += scadFile.PositionX
-				bbox.MinY += scadFile.PositionY
-				bbox.MaxY += scadFile.PositionY
-
-				if combinedBBox == nil {
-					combinedBBox = bbox
-				} else {
-					combinedBBox.MinX = math.Min(combinedBBox.MinX, bbox.MinX)
-					combinedBBox.MinY = math.Min(combinedBBox.MinY, bbox.MinY)
-					combinedBBox.MaxX = math.Max(combinedBBox.MaxX, bbox.MaxX)
-					combinedBBox.MaxY = math.Max(combinedBBox.MaxY, bbox.MaxY)
-				}
-			}
-			if combinedBBox != nil {
-				width = combinedBBox.Width()
-				height = combinedBBox.Height()
-				bboxOffsetX = -combinedBBox.MinX
-				bboxOffsetY = -combinedBBox.MinY
-			} else {
-				width, height = 100.0, 100.0
-			}
+			unknownSize[objectName] = true // estimated once all objects are measured
 		}
 
+		width, height, bboxOffsetX, bboxOffsetY = applyObjectMargin(allObjectGroups, objectName, width, height, bboxOffsetX, bboxOffsetY)
+
 		packingID := packingIDCounter
 		packingIDCounter++
 
 		platePacking[plateIdx].packingObjects = append(platePacking[plateIdx].packingObjects, geometry.Rectangle{
-			Width:  width,
-			Height: height,
-			ID:     packingID,
+			Width:   width,
+			Height:  height,
+			Depth:   printHeight(groupObjects, groupScadFiles),
+			ID:      packingID,
+			Name:    objectName,
+			Outline: c.outline(groupObjects, groupScadFiles, bboxOffsetX, bboxOffsetY),
+			Margin:  objectMargin(allObjectGroups, objectName),
 		})
 
 		platePacking[plateIdx].objectInfoMap[packingID] = struct {
@@ -980,6 +1339,14 @@ func (c *Combiner) CombineWithPlateGroups(tempFiles []string, plateGroups []mode
 			bboxOffsetY  float64
 		}{meshIDs, objectName, groupObjects, groupScadFiles, bboxOffsetX, bboxOffsetY}
 	}
+	// Objects of unknown size are estimated from the objects of all plates
+	var rects []*geometry.Rectangle
+	for plateIdx := range plateGroups {
+		for i := range platePacking[plateIdx].packingObjects {
+			rects = append(rects, &platePacking[plateIdx].packingObjects[i])
+		}
+	}
+	c.estimateFootprints(rects, unknownSize)
 
 	// Track which build items belong to which plate
 	plateObjectIDs := make(map[int][]string) // plateIdx -> list of object IDs
@@ -992,14 +1359,9 @@ func (c *Combiner) CombineWithPlateGroups(tempFiles []string, plateGroups []mode
 			continue
 		}
 
-		packer := geometry.NewPacker(margin)
-		var packingResults []geometry.PackingResult
-
-		switch algorithm {
-		case models.PackingAlgorithmCompact:
-			packingResults = packer.PackCompact(info.packingObjects)
-		default:
-			packingResults = packer.PackOptimal(info.packingObjects, plateWidth)
+		packingResults, err := c.pack(info.packingObjects, margin, algorithm, plateWidth)
+		if err != nil {
+			return fmt.Errorf("plate %d: %w", plateIdx+1, err)
 		}
 
 		// Apply plate X offset
@@ -1013,11 +1375,13 @@ func (c *Combiner) CombineWithPlateGroups(tempFiles []string, plateGroups []mode
 			bboxOffsetX := objInfo.bboxOffsetX
 			bboxOffsetY := objInfo.bboxOffsetY
 
-			// Find normalization setting
-			normalizePosition := true
+			// Find normalization and slicer settings
+			normalizePosition := c.normalize == models.NormalizationGround
+			var slicer models.SlicerOptions
 			for _, og := range allObjectGroups {
 				if og.Name == objectName {
 					normalizePosition = og.NormalizePosition
+					slicer = og.Slicer
 					break
 				}
 			}
@@ -1035,8 +1399,11 @@ func (c *Combiner) CombineWithPlateGroups(tempFiles []string, plateGroups []mode
 				objectID = strconv.Itoa(meshIDs[0])
 				scadFile := groupScadFiles[0]
 				// Use translation-only transform since rotation is baked into mesh
-				buildTransform := geometry.BuildTranslationTransform(
-					posX+scadFile.PositionX+bboxOffsetX, posY+scadFile.PositionY+bboxOffsetY, zOffset+scadFile.PositionZ)
+				buildTransform, err := c.placeObject(objectName, plateIdx+1, geometry.BuildTranslationTransform(
+					posX+scadFile.PositionX+bboxOffsetX, posY+scadFile.PositionY+bboxOffsetY, zOffset+scadFile.PositionZ))
+				if err != nil {
+					return err
+				}
 
 				buildItems = append(buildItems, models.Item{
 					ObjectID:  objectID,
@@ -1048,7 +1415,9 @@ func (c *Combiner) CombineWithPlateGroups(tempFiles []string, plateGroups []mode
 					ID:                objectID,
 					Name:              objectName,
 					Parts:             groupScadFiles,
+					FaceCounts:        FaceCounts(objInfo.groupObjects),
 					NormalizePosition: normalizePosition,
+					Slicer:            slicer,
 				})
 			} else {
 				var components []models.Component
@@ -1070,14 +1439,17 @@ func (c *Combiner) CombineWithPlateGroups(tempFiles []string, plateGroups []mode
 				parentObjects = append(parentObjects, models.Object{
 					ID:   parentID,
 					Name: objectName,
-					Type: "model",
+					Type: ParentType(objInfo.groupObjects),
 					Components: &models.Components{
 						Component: components,
 					},
 				})
 
 				// Apply bboxOffset to position the object correctly
-				buildTransform := geometry.BuildTranslationTransform(posX+bboxOffsetX, posY+bboxOffsetY, zOffset)
+				buildTransform, err := c.placeObject(objectName, plateIdx+1, geometry.BuildTranslationTransform(posX+bboxOffsetX, posY+bboxOffsetY, zOffset))
+				if err != nil {
+					return err
+				}
 				buildItems = append(buildItems, models.Item{
 					ObjectID:  parentID,
 					Transform: buildTransform,
@@ -1088,7 +1460,9 @@ func (c *Combiner) CombineWithPlateGroups(tempFiles []string, plateGroups []mode
 					ID:                parentID,
 					Name:              objectName,
 					Parts:             groupScadFiles,
+					FaceCounts:        FaceCounts(objInfo.groupObjects),
 					NormalizePosition: normalizePosition,
+					Slicer:            slicer,
 				})
 			}
 
@@ -1113,5 +1487,7 @@ func (c *Combiner) CombineWithPlateGroups(tempFiles []string, plateGroups []mode
 	}
 
 	// Write combined model with multi-plate support
-	return c.writer.WriteBambuWithPlates(outputFile, combinedModel, tempFiles[0], settingsGroups, buildItems, plateGroups, plateObjectIDs)
+	return c.create(outputFile, func(out io.Writer) error {
+		return c.writer.EncodeBambuWithPlates(out, combinedModel, tempFiles, settingsGroups, buildItems, plateGroups, plateObjectIDs)
+	})
 }
@@ -0,0 +1,283 @@
+package threemf
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/philipparndt/go3mf/internal/geometry"
+	"github.com/philipparndt/go3mf/internal/limits"
+	"github.com/philipparndt/go3mf/internal/models"
+)
+
+// modelRelationship is the relationship type of the 3D model of a package
+const modelRelationship = "http://schemas.microsoft.com/3dmanufacturing/2013/01/3dmodel"
+
+// Verify reads a 3MF file back and checks its structure as a slicer would: the
+// content types and relationships of the package, the object IDs and references
+// of the models and their meshes, and the references of the Bambu Studio model
+// settings. The problems found are returned; a file without problems has none.
+func Verify(file string) []string {
+	zr, err := limits.OpenZip(file)
+	if err != nil {
+		return []string{fmt.Sprintf("cannot open the archive: %v", err)}
+	}
+	defer zr.Close()
+
+	entries := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		entries[f.Name] = f
+	}
+	v := &verifier{files: zr.File, entries: entries, models: make(map[string]*models.Model)}
+	v.checkContentTypes()
+	v.checkRelationships()
+	model := v.model("3D/3dmodel.model")
+	if model != nil {
+		v.checkSettings(model)
+	}
+	return v.problems
+}
+
+// verifier collects the problems of a 3MF file
+type verifier struct {
+	files    []*zip.File              // Entries in the order of the archive
+	entries  map[string]*zip.File     // Entries by name
+	models   map[string]*models.Model // Checked models by entry (nil = unreadable)
+	problems []string
+}
+
+func (v *verifier) problem(format string, args ...interface{}) {
+	v.problems = append(v.problems, fmt.Sprintf(format, args...))
+}
+
+// read returns the content of an entry, or reports it as missing
+func (v *verifier) read(name string) ([]byte, bool) {
+	f, ok := v.entries[name]
+	if !ok {
+		v.problem("%s is missing", name)
+		return nil, false
+	}
+	data, err := readEntry(f)
+	if err != nil {
+		v.problem("%s: %v", name, err)
+		return nil, false
+	}
+	return data, true
+}
+
+// checkContentTypes checks that the models and relationships have a content type
+func (v *verifier) checkContentTypes() {
+	data, ok := v.read("[Content_Types].xml")
+	if !ok {
+		return
+	}
+	var types contentTypes
+	if err := xml.Unmarshal(data, &types); err != nil {
+		v.problem("[Content_Types].xml: %v", err)
+		return
+	}
+	declared := make(map[string]bool)
+	for _, t := range types.Defaults {
+		declared[strings.ToLower(t.Extension)] = true
+	}
+	for _, t := range types.Overrides {
+		declared[strings.TrimPrefix(t.PartName, "/")] = true
+	}
+	for _, f := range v.files {
+		ext := strings.ToLower(strings.TrimPrefix(path.Ext(f.Name), "."))
+		if (ext == "model" || ext == "rels") && !declared[ext] && !declared[f.Name] {
+			v.problem("[Content_Types].xml: no content type for %s", f.Name)
+		}
+	}
+}
+
+// checkRelationships checks that the package refers to its model and that the
+// targets of all relationships exist
+func (v *verifier) checkRelationships() {
+	hasModel := false
+	for _, f := range v.files {
+		name := f.Name
+		if !strings.HasSuffix(name, ".rels") {
+			continue
+		}
+		data, err := readEntry(f)
+		if err != nil {
+			v.problem("%s: %v", name, err)
+			continue
+		}
+		var rels relationships
+		if err := xml.Unmarshal(data, &rels); err != nil {
+			v.problem("%s: %v", name, err)
+			continue
+		}
+		// Relative targets are relative to the folder of the relationships folder
+		base := path.Dir(path.Dir(name))
+		for _, rel := range rels.Relationships {
+			target := strings.TrimPrefix(rel.Target, "/")
+			if !strings.HasPrefix(rel.Target, "/") {
+				target = path.Join(base, rel.Target)
+			}
+			if _, ok := v.entries[target]; !ok {
+				v.problem("%s: relationship %s refers to the missing entry %s", name, rel.ID, rel.Target)
+			}
+			if name == "_rels/.rels" && rel.Type == modelRelationship {
+				hasModel = true
+			}
+		}
+	}
+	if _, ok := v.entries["_rels/.rels"]; !ok {
+		v.problem("_rels/.rels is missing")
+	} else if !hasModel {
+		v.problem("_rels/.rels: no relationship to the 3D model")
+	}
+}
+
+// model reads and checks a model entry once; nil if it cannot be read
+func (v *verifier) model(name string) *models.Model {
+	if model, ok := v.models[name]; ok {
+		return model
+	}
+	v.models[name] = nil
+	data, ok := v.read(name)
+	if !ok {
+		return nil
+	}
+	var model models.Model
+	if err := xml.Unmarshal(data, &model); err != nil {
+		v.problem("%s: %v", name, err)
+		return nil
+	}
+	v.models[name] = &model
+	v.checkModel(name, &model)
+	return &model
+}
+
+// checkModel checks the objects and build items of a model
+func (v *verifier) checkModel(name string, model *models.Model) {
+	objects := make(map[string]*models.Object)
+	for i := range model.Resources.Objects {
+		obj := &model.Resources.Objects[i]
+		if obj.ID == "" {
+			v.problem("%s: object %d has no ID", name, i+1)
+			continue
+		}
+		if objects[obj.ID] != nil {
+			v.problem("%s: object ID %s is used twice", name, obj.ID)
+		}
+		objects[obj.ID] = obj
+	}
+
+	for _, obj := range model.Resources.Objects {
+		// Without materials, the PID of an object is the filament slot of Bambu
+		// Studio, which slicers ignore
+		if bm := model.Resources.BaseMaterials; bm != nil && obj.PID != "" {
+			if bm.ID != obj.PID {
+				v.problem("%s: object %s refers to the unknown material group %s", name, obj.ID, obj.PID)
+			} else if index, err := strconv.Atoi(obj.PIndex); obj.PIndex != "" && (err != nil || index < 0 || index >= len(bm.Bases)) {
+				v.problem("%s: object %s refers to the unknown material %s", name, obj.ID, obj.PIndex)
+			}
+		}
+		if obj.Mesh != nil {
+			if _, err := geometry.MeshTriangles(&obj); err != nil {
+				v.problem("%s: object %s: %v", name, obj.ID, err)
+			}
+		}
+		if obj.Components == nil {
+			if obj.Mesh == nil {
+				v.problem("%s: object %s has neither a mesh nor components", name, obj.ID)
+			}
+			continue
+		}
+		for _, component := range obj.Components.Component {
+			if _, err := geometry.ParseTransform(component.Transform); err != nil {
+				v.problem("%s: object %s: %v", name, obj.ID, err)
+			}
+			if component.Path == "" {
+				if objects[component.ObjectID] == nil {
+					v.problem("%s: object %s refers to the unknown object %s", name, obj.ID, component.ObjectID)
+				}
+				continue
+			}
+			other := v.model(strings.TrimPrefix(component.Path, "/"))
+			if other != nil && !hasObject(other, component.ObjectID) {
+				v.problem("%s: object %s refers to the unknown object %s of %s", name, obj.ID, component.ObjectID, component.Path)
+			}
+		}
+	}
+
+	for i, item := range model.Build.Items {
+		if objects[item.ObjectID] == nil {
+			v.problem("%s: build item %d refers to the unknown object %s", name, i+1, item.ObjectID)
+		}
+		if _, err := geometry.ParseTransform(item.Transform); err != nil {
+			v.problem("%s: build item %d: %v", name, i+1, err)
+		}
+	}
+}
+
+// checkSettings checks that the Bambu Studio model settings refer to objects
+// and parts of the model
+func (v *verifier) checkSettings(model *models.Model) {
+	const name = "Metadata/model_settings.config"
+	if _, ok := v.entries[name]; !ok {
+		return
+	}
+	data, ok := v.read(name)
+	if !ok {
+		return
+	}
+	var settings models.ModelSettings
+	if err := xml.Unmarshal(data, &settings); err != nil {
+		v.problem("%s: %v", name, err)
+		return
+	}
+
+	objects := make(map[string]*models.Object)
+	for i := range model.Resources.Objects {
+		objects[model.Resources.Objects[i].ID] = &model.Resources.Objects[i]
+	}
+	for _, settingsObject := range settings.Objects {
+		obj := objects[settingsObject.ID]
+		if obj == nil {
+			v.problem("%s: settings of the unknown object %s", name, settingsObject.ID)
+			continue
+		}
+		// The parts of an object are its components, or the object itself
+		parts := map[string]bool{obj.ID: obj.Components == nil}
+		if obj.Components != nil {
+			for _, component := range obj.Components.Component {
+				parts[component.ObjectID] = true
+			}
+		}
+		for _, part := range settingsObject.Parts {
+			if !parts[part.ID] {
+				v.problem("%s: object %s has settings of the unknown part %s", name, obj.ID, part.ID)
+			}
+		}
+	}
+	for i, plate := range settings.Plates {
+		for _, instance := range plate.ModelInstances {
+			if id := models.MetadataValue(instance.Metadata, "object_id"); objects[id] == nil {
+				v.problem("%s: plate %d refers to the unknown object %s", name, i+1, id)
+			}
+		}
+	}
+	for _, item := range settings.Assemble.Items {
+		if objects[item.ObjectID] == nil {
+			v.problem("%s: assembly refers to the unknown object %s", name, item.ObjectID)
+		}
+	}
+}
+
+// hasObject reports whether a model has an object of an ID
+func hasObject(model *models.Model, id string) bool {
+	for _, obj := range model.Resources.Objects {
+		if obj.ID == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,151 @@
+package threemf
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// validPackage returns the entries of a valid 3MF file with a part in another model file
+func validPackage() map[string]string {
+	return map[string]string{
+		"[Content_Types].xml": `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+	<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+	<Default Extension="model" ContentType="application/vnd.ms-package.3dmanufacturing-3dmodel+xml"/>
+</Types>`,
+		"_rels/.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+	<Relationship Id="rel0" Target="/3D/3dmodel.model" Type="http://schemas.microsoft.com/3dmanufacturing/2013/01/3dmodel"/>
+</Relationships>`,
+		"3D/3dmodel.model": `<model xmlns="http://schemas.microsoft.com/3dmanufacturing/core/2015/02" xmlns:p="http://schemas.microsoft.com/3dmanufacturing/production/2015/06" unit="millimeter">
+	<resources>
+		<basematerials id="9"><base name="PLA" displaycolor="#FF0000"/></basematerials>
+		<object id="1" type="model" pid="9" pindex="0"><mesh><vertices><vertex x="0" y="0" z="0"/><vertex x="1" y="0" z="0"/><vertex x="0" y="1" z="0"/></vertices><triangles><triangle v1="0" v2="1" v3="2"/></triangles></mesh></object>
+		<object id="2" type="model"><components><component objectid="1"/><component objectid="1" p:path="/3D/Objects/part.model"/></components></object>
+	</resources>
+	<build><item objectid="2" transform="1 0 0 0 1 0 0 0 1 10 10 0"/></build>
+</model>`,
+		"3D/Objects/part.model": `<model xmlns="http://schemas.microsoft.com/3dmanufacturing/core/2015/02" unit="millimeter">
+	<resources><object id="1" type="model"><mesh><vertices><vertex x="0" y="0" z="0"/></vertices><triangles></triangles></mesh></object></resources>
+	<build></build>
+</model>`,
+		"Metadata/model_settings.config": `<config>
+	<object id="2"><part id="1"></part></object>
+	<plate><model_instance><metadata key="object_id" value="2"/></model_instance></plate>
+	<assemble><assemble_item object_id="2"/></assemble>
+</config>`,
+	}
+}
+
+func TestVerify(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(entries map[string]string)
+		want   string // Substring of the first problem ("" = none)
+	}{
+		{name: "valid", change: func(map[string]string) {}},
+		{
+			name:   "missing model",
+			change: func(e map[string]string) { delete(e, "3D/3dmodel.model") },
+			want:   "relationship rel0 refers to the missing entry /3D/3dmodel.model",
+		},
+		{
+			name: "missing content type",
+			change: func(e map[string]string) {
+				e["[Content_Types].xml"] = strings.Replace(e["[Content_Types].xml"], `Extension="model"`, `Extension="xml"`, 1)
+			},
+			want: "no content type for 3D/3dmodel.model",
+		},
+		{
+			name: "unknown object",
+			change: func(e map[string]string) {
+				e["3D/3dmodel.model"] = strings.Replace(e["3D/3dmodel.model"], `<item objectid="2"`, `<item objectid="7"`, 1)
+			},
+			want: "build item 1 refers to the unknown object 7",
+		},
+		{
+			name: "duplicate ID",
+			change: func(e map[string]string) {
+				e["3D/3dmodel.model"] = strings.Replace(e["3D/3dmodel.model"], `<object id="2"`, `<object id="1"`, 1)
+			},
+			want: "object ID 1 is used twice",
+		},
+		{
+			name: "unknown vertex",
+			change: func(e map[string]string) {
+				e["3D/3dmodel.model"] = strings.Replace(e["3D/3dmodel.model"], `v3="2"`, `v3="3"`, 1)
+			},
+			want: "triangle references unknown vertex",
+		},
+		{
+			name: "unknown material",
+			change: func(e map[string]string) {
+				e["3D/3dmodel.model"] = strings.Replace(e["3D/3dmodel.model"], `pindex="0"`, `pindex="1"`, 1)
+			},
+			want: "object 1 refers to the unknown material 1",
+		},
+		{
+			name: "unknown object of another model",
+			change: func(e map[string]string) {
+				e["3D/Objects/part.model"] = strings.Replace(e["3D/Objects/part.model"], `id="1"`, `id="5"`, 1)
+			},
+			want: "unknown object 1 of /3D/Objects/part.model",
+		},
+		{
+			name: "unknown part in settings",
+			change: func(e map[string]string) {
+				e["Metadata/model_settings.config"] = strings.Replace(e["Metadata/model_settings.config"], `<part id="1">`, `<part id="3">`, 1)
+			},
+			want: "object 2 has settings of the unknown part 3",
+		},
+		{
+			name: "unknown object on plate",
+			change: func(e map[string]string) {
+				e["Metadata/model_settings.config"] = strings.Replace(e["Metadata/model_settings.config"], `value="2"`, `value="4"`, 1)
+			},
+			want: "plate 1 refers to the unknown object 4",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries := validPackage()
+			tt.change(entries)
+			file := filepath.Join(t.TempDir(), "model.3mf")
+			f, err := os.Create(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			zw := zip.NewWriter(f)
+			names := make([]string, 0, len(entries))
+			for name := range entries {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				w, err := zw.Create(name)
+				if err != nil {
+					t.Fatal(err)
+				}
+				w.Write([]byte(entries[name]))
+			}
+			if err := zw.Close(); err != nil {
+				t.Fatal(err)
+			}
+			f.Close()
+
+			problems := Verify(file)
+			if tt.want == "" {
+				if len(problems) > 0 {
+					t.Errorf("Verify() = %v, want no problems", problems)
+				}
+				return
+			}
+			if len(problems) == 0 || !strings.Contains(problems[0], tt.want) {
+				t.Errorf("Verify() = %v, want a problem containing %q", problems, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package thumbnail
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/philipparndt/go3mf/internal/geometry"
+	"github.com/philipparndt/go3mf/internal/layout"
+	"github.com/philipparndt/go3mf/internal/manifest"
+	"github.com/philipparndt/go3mf/internal/models"
+)
+
+// Object is an object of a build with the faces of its parts
+type Object struct {
+	Name  string
+	Faces []Face
+}
+
+// FromModel returns the build items of a 3MF model with the faces of their parts
+// in the colors of their filament slots (colors[0] is slot 1). Slots without a
+// valid color use the colors of the layout SVG. Parts in other model files of
+// the archive are left out.
+func FromModel(model *models.Model, settings *models.ModelSettings, colors []string) ([]Object, error) {
+	m, err := manifest.FromModel(model, settings)
+	if err != nil {
+		return nil, err
+	}
+	objects := make(map[string]*models.Object)
+	for i := range model.Resources.Objects {
+		objects[model.Resources.Objects[i].ID] = &model.Resources.Objects[i]
+	}
+
+	var result []Object
+	for i, item := range model.Build.Items {
+		itemTransform, err := geometry.ParseTransform(item.Transform)
+		if err != nil {
+			return nil, err
+		}
+		obj := objects[item.ObjectID]
+
+		components := models.ObjectComponents(obj)
+
+		object := Object{Name: m.Objects[i].Name}
+		for j, component := range components {
+			mesh := objects[component.ObjectID]
+			if component.Path != "" || mesh == nil || mesh.Mesh == nil {
+				continue
+			}
+			transform, err := geometry.ParseTransform(component.Transform)
+			if err != nil {
+				return nil, err
+			}
+			transform = geometry.MultiplyTransforms(transform, itemTransform)
+			triangles, err := geometry.MeshTriangles(mesh)
+			if err != nil {
+				return nil, fmt.Errorf("object %s: %w", object.Name, err)
+			}
+
+			c := filamentColor(colors, m.Objects[i].Parts[j].Filament)
+			for _, triangle := range triangles {
+				face := Face{Color: c}
+				for k, p := range triangle {
+					face.Corners[k] = apply(transform, p)
+				}
+				object.Faces = append(object.Faces, face)
+			}
+		}
+		result = append(result, object)
+	}
+	return result, nil
+}
+
+// apply applies a 3MF transformation matrix to a point
+func apply(m [12]float64, p [3]float64) [3]float64 {
+	return [3]float64{
+		p[0]*m[0] + p[1]*m[3] + p[2]*m[6] + m[9],
+		p[0]*m[1] + p[1]*m[4] + p[2]*m[7] + m[10],
+		p[0]*m[2] + p[1]*m[5] + p[2]*m[8] + m[11],
+	}
+}
+
+// filamentColor returns the color of a filament slot
+func filamentColor(colors []string, slot int) color.NRGBA {
+	slot = max(slot, 1)
+	if slot <= len(colors) {
+		if c, err := ParseColor(colors[slot-1]); err == nil {
+			return c
+		}
+	}
+	c, _ := ParseColor(layout.FilamentColor(slot))
+	return c
+}
@@ -0,0 +1,198 @@
+package thumbnail
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"strconv"
+)
+
+// DefaultSize is the width and height of thumbnails in pixels
+const DefaultSize = 256
+
+const (
+	margin      = 0.05 // Space around the object as fraction of the image size
+	supersample = 2    // Pixels rendered per pixel of the image in each direction, for smooth edges
+	ambient     = 0.35 // Brightness of faces turned away from the light
+)
+
+var (
+	// view points from the object to the camera, which looks at the object from
+	// the front right and above
+	view = normalize([3]float64{1, -1, 1})
+	// right and up are the axes of the image
+	right = normalize([3]float64{1, 1, 0})
+	up    = normalize([3]float64{-1, 1, 2})
+	// light points from the object to the light, above the camera on the left
+	light = normalize([3]float64{-0.3, -1, 1.5})
+)
+
+// Face is a triangle of a mesh with its color
+type Face struct {
+	Corners [3][3]float64
+	Color   color.NRGBA
+}
+
+// Render renders faces as seen from the front right and above, scaled to fit
+// an image of size×size pixels. Faces are flat shaded, the background is
+// transparent.
+func Render(faces []Face, size int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, size, size))
+	if len(faces) == 0 || size < 1 {
+		return img
+	}
+
+	// Project the corners onto the image plane and fit the bounds into the image
+	projected := make([][3][3]float64, len(faces))
+	minX, minY, maxX, maxY := math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
+	for i, face := range faces {
+		for j, p := range face.Corners {
+			x, y := dot(p, right), dot(p, up)
+			projected[i][j] = [3]float64{x, y, dot(p, view)}
+			minX, maxX = math.Min(minX, x), math.Max(maxX, x)
+			minY, maxY = math.Min(minY, y), math.Max(maxY, y)
+		}
+	}
+	n := size * supersample
+	scale := float64(n) * (1 - 2*margin) / math.Max(math.Max(maxX-minX, maxY-minY), 1e-9)
+	offsetX := (float64(n) - (maxX-minX)*scale) / 2
+	offsetY := (float64(n) - (maxY-minY)*scale) / 2
+
+	pixels := make([]color.NRGBA, n*n)
+	depth := make([]float64, n*n)
+	for i := range depth {
+		depth[i] = math.Inf(-1)
+	}
+	for i, face := range faces {
+		var screen [3][3]float64
+		for j, p := range projected[i] {
+			// The Y axis of the image points down
+			screen[j] = [3]float64{offsetX + (p[0]-minX)*scale, float64(n) - offsetY - (p[1]-minY)*scale, p[2]}
+		}
+		fill(screen, shade(face), n, pixels, depth)
+	}
+	downsample(pixels, n, img)
+	return img
+}
+
+// EncodePNG returns an image as PNG
+func EncodePNG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ParseColor parses a "#RRGGBB" or "#RRGGBBAA" color
+func ParseColor(s string) (color.NRGBA, error) {
+	if (len(s) != 7 && len(s) != 9) || s[0] != '#' {
+		return color.NRGBA{}, fmt.Errorf("invalid color %q, expected #RRGGBB or #RRGGBBAA", s)
+	}
+	v, err := strconv.ParseUint(s[1:], 16, 32)
+	if err != nil {
+		return color.NRGBA{}, fmt.Errorf("invalid color %q, expected #RRGGBB or #RRGGBBAA", s)
+	}
+	if len(s) == 7 {
+		v = v<<8 | 0xFF
+	}
+	return color.NRGBA{R: uint8(v >> 24), G: uint8(v >> 16), B: uint8(v >> 8), A: uint8(v)}, nil
+}
+
+// shade returns the color of a face lit by the light. Both sides of a face are
+// lit, so meshes with flipped triangles are rendered as well.
+func shade(face Face) color.NRGBA {
+	a, b, c := face.Corners[0], face.Corners[1], face.Corners[2]
+	normal := normalize(cross(sub(b, a), sub(c, a)))
+	brightness := ambient + (1-ambient)*math.Abs(dot(normal, light))
+	result := face.Color
+	result.R = uint8(float64(result.R) * brightness)
+	result.G = uint8(float64(result.G) * brightness)
+	result.B = uint8(float64(result.B) * brightness)
+	return result
+}
+
+// fill rasterizes a triangle in image coordinates with its depth, keeping the
+// pixels closest to the camera
+func fill(t [3][3]float64, c color.NRGBA, n int, pixels []color.NRGBA, depth []float64) {
+	area := edge(t[0], t[1], t[2][0], t[2][1])
+	if area == 0 {
+		return
+	}
+	x0 := max(0, int(math.Floor(math.Min(t[0][0], math.Min(t[1][0], t[2][0])))))
+	x1 := min(n-1, int(math.Ceil(math.Max(t[0][0], math.Max(t[1][0], t[2][0])))))
+	y0 := max(0, int(math.Floor(math.Min(t[0][1], math.Min(t[1][1], t[2][1])))))
+	y1 := min(n-1, int(math.Ceil(math.Max(t[0][1], math.Max(t[1][1], t[2][1])))))
+	for y := y0; y <= y1; y++ {
+		for x := x0; x <= x1; x++ {
+			px, py := float64(x)+0.5, float64(y)+0.5
+			// Barycentric weights, all of the sign of the area inside the triangle
+			w0 := edge(t[1], t[2], px, py) / area
+			w1 := edge(t[2], t[0], px, py) / area
+			w2 := edge(t[0], t[1], px, py) / area
+			if w0 < 0 || w1 < 0 || w2 < 0 {
+				continue
+			}
+			z := w0*t[0][2] + w1*t[1][2] + w2*t[2][2]
+			if i := y*n + x; z > depth[i] {
+				depth[i] = z
+				pixels[i] = c
+			}
+		}
+	}
+}
+
+// edge returns twice the signed area of the triangle a, b, (x, y)
+func edge(a, b [3]float64, x, y float64) float64 {
+	return (b[0]-a[0])*(y-a[1]) - (b[1]-a[1])*(x-a[0])
+}
+
+// downsample averages the supersampled pixels into the image
+func downsample(pixels []color.NRGBA, n int, img *image.NRGBA) {
+	size := n / supersample
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			// Average the colors weighted by their alpha, so edges do not darken
+			var r, g, b, a float64
+			for sy := 0; sy < supersample; sy++ {
+				for sx := 0; sx < supersample; sx++ {
+					p := pixels[(y*supersample+sy)*n+x*supersample+sx]
+					alpha := float64(p.A)
+					r, g, b, a = r+float64(p.R)*alpha, g+float64(p.G)*alpha, b+float64(p.B)*alpha, a+alpha
+				}
+			}
+			if a == 0 {
+				continue
+			}
+			img.SetNRGBA(x, y, color.NRGBA{
+				R: uint8(math.Round(r / a)),
+				G: uint8(math.Round(g / a)),
+				B: uint8(math.Round(b / a)),
+				A: uint8(math.Round(a / (supersample * supersample))),
+			})
+		}
+	}
+}
+
+func dot(a, b [3]float64) float64 {
+	return a[0]*b[0] + a[1]*b[1] + a[2]*b[2]
+}
+
+func sub(a, b [3]float64) [3]float64 {
+	return [3]float64{a[0] - b[0], a[1] - b[1], a[2] - b[2]}
+}
+
+func cross(a, b [3]float64) [3]float64 {
+	return [3]float64{a[1]*b[2] - a[2]*b[1], a[2]*b[0] - a[0]*b[2], a[0]*b[1] - a[1]*b[0]}
+}
+
+func normalize(v [3]float64) [3]float64 {
+	length := math.Sqrt(dot(v, v))
+	if length == 0 {
+		return v
+	}
+	return [3]float64{v[0] / length, v[1] / length, v[2] / length}
+}
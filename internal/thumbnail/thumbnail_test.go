@@ -0,0 +1,135 @@
+package thumbnail
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/philipparndt/go3mf/internal/models"
+)
+
+// cube returns the faces of a cube with the given edge length and color
+func cube(size float64, c color.NRGBA) []Face {
+	corners := [8][3]float64{
+		{0, 0, 0}, {size, 0, 0}, {size, size, 0}, {0, size, 0},
+		{0, 0, size}, {size, 0, size}, {size, size, size}, {0, size, size},
+	}
+	var faces []Face
+	for _, t := range [][3]int{
+		{0, 2, 1}, {0, 3, 2}, {4, 5, 6}, {4, 6, 7}, {0, 1, 5}, {0, 5, 4},
+		{1, 2, 6}, {1, 6, 5}, {2, 3, 7}, {2, 7, 6}, {3, 0, 4}, {3, 4, 7},
+	} {
+		faces = append(faces, Face{Corners: [3][3]float64{corners[t[0]], corners[t[1]], corners[t[2]]}, Color: c})
+	}
+	return faces
+}
+
+func TestRender(t *testing.T) {
+	red := color.NRGBA{R: 255, A: 255}
+	img := Render(cube(30, red), 64)
+
+	if got := img.Bounds().Dx(); got != 64 {
+		t.Fatalf("width = %d, want 64", got)
+	}
+	if c := img.NRGBAAt(0, 0); c.A != 0 {
+		t.Errorf("corner = %v, want a transparent background", c)
+	}
+	center := img.NRGBAAt(32, 32)
+	if center.A != 255 || center.R == 0 || center.G != 0 || center.B != 0 {
+		t.Errorf("center = %v, want a shade of red", center)
+	}
+	// The top of the cube is lit differently than its sides
+	top, side := img.NRGBAAt(32, 12), img.NRGBAAt(20, 44)
+	if top.A != 255 || side.A != 255 || top == side {
+		t.Errorf("top = %v, side = %v, want different shades", top, side)
+	}
+
+	// The size of the object does not matter, it is scaled to fit
+	if small := Render(cube(1, red), 64); small.NRGBAAt(32, 32) != center {
+		t.Error("a small cube should be rendered as large as a large one")
+	}
+
+	if empty := Render(nil, 16); empty.NRGBAAt(8, 8).A != 0 {
+		t.Error("an image without faces should be transparent")
+	}
+
+	data, err := EncodePNG(img)
+	if err != nil {
+		t.Fatalf("EncodePNG() error = %v", err)
+	}
+	if _, err := png.Decode(bytes.NewReader(data)); err != nil {
+		t.Errorf("EncodePNG() wrote an invalid PNG: %v", err)
+	}
+}
+
+func TestParseColor(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    color.NRGBA
+		wantErr bool
+	}{
+		{input: "#FF8000", want: color.NRGBA{R: 255, G: 128, B: 0, A: 255}},
+		{input: "#00ff0080", want: color.NRGBA{G: 255, A: 128}},
+		{input: "FF8000", wantErr: true},
+		{input: "#GG0000", wantErr: true},
+		{input: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseColor(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseColor() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseColor() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFromModel(t *testing.T) {
+	tetrahedron := &models.Mesh{
+		Vertices:  &models.Vertices{Vertex: []models.Vertex{{X: 0, Y: 0, Z: 0}, {X: 10, Y: 0, Z: 0}, {X: 0, Y: 10, Z: 0}, {X: 0, Y: 0, Z: 10}}},
+		Triangles: &models.Triangles{Triangle: []models.Triangle{{V1: 0, V2: 2, V3: 1}, {V1: 0, V2: 1, V3: 3}, {V1: 0, V2: 3, V3: 2}, {V1: 1, V2: 2, V3: 3}}},
+	}
+	model := &models.Model{
+		Resources: models.Resources{Objects: []models.Object{
+			{ID: "1", Name: "body", Mesh: tetrahedron},
+			{ID: "2", Name: "lid", Mesh: tetrahedron},
+			{ID: "3", Name: "box", Components: &models.Components{Component: []models.Component{
+				{ObjectID: "1"},
+				{ObjectID: "2", Transform: "1 0 0 0 1 0 0 0 1 0 0 10"},
+			}}},
+		}},
+		Build: models.Build{Items: []models.Item{{ObjectID: "3", Transform: "1 0 0 0 1 0 0 0 1 100 50 0"}}},
+	}
+	settings := &models.ModelSettings{Objects: []models.SettingsObject{{
+		ID: "3",
+		Parts: []models.Part{
+			{Metadata: []models.SettingsMetadata{{Key: "extruder", Value: "1"}}},
+			{Metadata: []models.SettingsMetadata{{Key: "extruder", Value: "2"}}},
+		},
+	}}}
+
+	objects, err := FromModel(model, settings, []string{"#FF0000", "not a color"})
+	if err != nil {
+		t.Fatalf("FromModel() error = %v", err)
+	}
+	if len(objects) != 1 || len(objects[0].Faces) != 8 {
+		t.Fatalf("FromModel() = %d objects, want 1 with 8 faces", len(objects))
+	}
+	faces := objects[0].Faces
+	if faces[0].Color != (color.NRGBA{R: 255, A: 255}) {
+		t.Errorf("body color = %v, want the color of slot 1", faces[0].Color)
+	}
+	// Slot 2 has no valid color and falls back to the layout colors
+	if want, _ := ParseColor("#E8574C"); faces[4].Color != want {
+		t.Errorf("lid color = %v, want %v", faces[4].Color, want)
+	}
+	// The transforms of the part and the build item are applied
+	if got := faces[4].Corners[0]; got != [3]float64{100, 50, 10} {
+		t.Errorf("first corner of the lid = %v, want (100, 50, 10)", got)
+	}
+}
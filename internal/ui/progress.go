@@ -0,0 +1,207 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// taskState is the state of a task of a progress display
+type taskState int
+
+const (
+	taskQueued taskState = iota
+	taskRunning
+	taskDone
+	taskFailed
+)
+
+// progressTask is a row of a progress display
+type progressTask struct {
+	name   string
+	state  taskState
+	detail string // Shown after the state, e.g. "cached"
+	start  time.Time
+	end    time.Time
+}
+
+// elapsed returns the time the task ran so far, or in total once it ended
+func (t progressTask) elapsed(now time.Time) time.Duration {
+	switch {
+	case t.start.IsZero():
+		return 0
+	case t.end.IsZero():
+		return now.Sub(t.start)
+	default:
+		return t.end.Sub(t.start)
+	}
+}
+
+// Progress shows the state of tasks that run concurrently, e.g. renders. On a
+// terminal, every task has a row that is updated live; otherwise a line is
+// printed when a task starts and ends. The methods are safe for concurrent use
+// and do nothing on a nil Progress.
+type Progress struct {
+	mu      sync.Mutex
+	tasks   []progressTask
+	program *tea.Program  // nil = plain log lines
+	done    chan struct{} // Closed when the program exited
+}
+
+// NewProgress starts a progress display for tasks with the given names, all
+// queued. Stop must be called when the tasks are finished.
+func NewProgress(names []string) *Progress {
+	p := &Progress{tasks: make([]progressTask, len(names))}
+	for i, name := range names {
+		p.tasks[i] = progressTask{name: name}
+	}
+	if !isTerminal() {
+		return p
+	}
+
+	p.done = make(chan struct{})
+	p.program = tea.NewProgram(progressModel{progress: p, spinner: spinner.New(spinner.WithSpinner(spinner.MiniDot), spinner.WithStyle(lipgloss.NewStyle().Foreground(secondaryColor)))},
+		tea.WithOutput(output), tea.WithInput(nil), tea.WithoutSignalHandler())
+	go func() {
+		defer close(p.done)
+		_, _ = p.program.Run()
+	}()
+	return p
+}
+
+// Start marks task i as running
+func (p *Progress) Start(i int) {
+	p.update(i, func(t *progressTask) {
+		t.state = taskRunning
+		t.start = time.Now()
+	})
+}
+
+// Done marks task i as done, with an optional detail such as "cached"
+func (p *Progress) Done(i int, detail string) {
+	p.update(i, func(t *progressTask) {
+		t.state = taskDone
+		t.detail = detail
+		t.end = time.Now()
+	})
+}
+
+// Fail marks task i as failed
+func (p *Progress) Fail(i int) {
+	p.update(i, func(t *progressTask) {
+		t.state = taskFailed
+		t.end = time.Now()
+	})
+}
+
+// Stop ends the display, leaving the final state of the rows on a terminal
+func (p *Progress) Stop() {
+	if p == nil || p.program == nil {
+		return
+	}
+	p.program.Quit()
+	<-p.done
+}
+
+// update changes task i and shows the change
+func (p *Progress) update(i int, change func(*progressTask)) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	change(&p.tasks[i])
+	task := p.tasks[i]
+	p.mu.Unlock()
+
+	if p.program != nil {
+		p.program.Send(taskMsg{})
+		return
+	}
+	switch task.state {
+	case taskRunning:
+		PrintInfo(fmt.Sprintf("Rendering %s...", task.name))
+	case taskDone:
+		PrintItem(fmt.Sprintf("✓ %s %s", task.name, taskSummary(task, time.Now())))
+	case taskFailed:
+		PrintItem(fmt.Sprintf("✗ %s %s", task.name, taskSummary(task, time.Now())))
+	}
+}
+
+// taskSummary returns the state of a task with its elapsed time
+func taskSummary(task progressTask, now time.Time) string {
+	state := [...]string{"queued", "rendering", "done", "failed"}[task.state]
+	if task.detail != "" {
+		state += ", " + task.detail
+	}
+	if task.state == taskQueued {
+		return state
+	}
+	return fmt.Sprintf("%s (%s)", state, task.elapsed(now).Round(100*time.Millisecond))
+}
+
+// taskMsg tells the progress model that a task changed
+type taskMsg struct{}
+
+// progressModel is the bubbletea model of a progress display
+type progressModel struct {
+	progress *Progress
+	spinner  spinner.Model
+}
+
+func (m progressModel) Init() tea.Cmd {
+	return m.spinner.Tick
+}
+
+func (m progressModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case spinner.TickMsg:
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+	case taskMsg:
+		return m, nil
+	}
+	return m, nil
+}
+
+func (m progressModel) View() string {
+	m.progress.mu.Lock()
+	defer m.progress.mu.Unlock()
+
+	width := 0
+	for _, task := range m.progress.tasks {
+		width = max(width, lipgloss.Width(task.name))
+	}
+	now := time.Now()
+	var b strings.Builder
+	for _, task := range m.progress.tasks {
+		icon := dot.String()
+		switch task.state {
+		case taskRunning:
+			icon = m.spinner.View()
+		case taskDone:
+			icon = checkmark.String()
+		case taskFailed:
+			icon = cross.String()
+		}
+		name := task.name + strings.Repeat(" ", width-lipgloss.Width(task.name))
+		b.WriteString(stepStyle.Render(icon+" "+name+"  "+infoStyle.Render(taskSummary(task, now))) + "\n")
+	}
+	return b.String()
+}
+
+// isTerminal reports whether UI messages are written to a terminal
+func isTerminal() bool {
+	file, ok := output.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -2,12 +2,29 @@ package ui
 
 import (
 	"fmt"
+	"io"
 	"os"
+	"slices"
 	"strings"
+	"sync"
 
 	"github.com/charmbracelet/lipgloss"
 )
 
+// output is where all UI messages are written (stdout by default)
+var output io.Writer = os.Stdout
+
+// SetOutput redirects all UI messages to the given writer.
+// This is used when stdout carries binary data (e.g. "-o -").
+func SetOutput(w io.Writer) {
+	output = w
+}
+
+// Output returns the writer UI messages are written to
+func Output() io.Writer {
+	return output
+}
+
 var (
 	// Color palette
 	primaryColor   = lipgloss.Color("#7D56F4") // Purple
@@ -93,57 +110,94 @@ var (
 
 // PrintTitle prints a major title (for app name or major sections)
 func PrintTitle(title string) {
-	fmt.Println(titleStyle.Render("╭─ " + title + " ─╮"))
+	fmt.Fprintln(output, titleStyle.Render("╭─ "+title+" ─╮"))
 }
 
 // PrintHeader prints a section header
 func PrintHeader(title string) {
-	fmt.Println(headerStyle.Render("\n▸ " + title))
+	fmt.Fprintln(output, headerStyle.Render("\n▸ "+title))
 }
 
 // PrintStep prints a step with indentation
 func PrintStep(step string) {
-	fmt.Println(stepStyle.Render(arrow.String() + " " + step))
+	fmt.Fprintln(output, stepStyle.Render(arrow.String()+" "+step))
 }
 
 // PrintItem prints an item in a list
 func PrintItem(item string) {
-	fmt.Println(itemStyle.Render(dot.String() + " " + item))
+	fmt.Fprintln(output, itemStyle.Render(dot.String()+" "+item))
 }
 
 // PrintSuccess prints a success message
 func PrintSuccess(message string) {
-	fmt.Println(stepStyle.Render(checkmark.String() + " " + successStyle.Render(message)))
+	fmt.Fprintln(output, stepStyle.Render(checkmark.String()+" "+successStyle.Render(message)))
 }
 
 // PrintError prints an error message
 func PrintError(message string) {
-	fmt.Println(stepStyle.Render(cross.String() + " " + errorStyle.Render(message)))
+	fmt.Fprintln(output, stepStyle.Render(cross.String()+" "+errorStyle.Render(message)))
+}
+
+var (
+	warningsMu sync.Mutex
+	warnings   []string // Warnings printed so far, listed again in build reports
+)
+
+// Warnings returns the warnings printed so far
+func Warnings() []string {
+	warningsMu.Lock()
+	defer warningsMu.Unlock()
+	return slices.Clone(warnings)
+}
+
+// ResetWarnings forgets the warnings printed so far, e.g. before the next build
+// of a long-running process
+func ResetWarnings() {
+	warningsMu.Lock()
+	defer warningsMu.Unlock()
+	warnings = nil
 }
 
 // PrintWarning prints a warning message
 func PrintWarning(message string) {
-	fmt.Println(stepStyle.Render("⚠ " + warningStyle.Render(message)))
+	warningsMu.Lock()
+	warnings = append(warnings, message)
+	warningsMu.Unlock()
+	fmt.Fprintln(output, stepStyle.Render("⚠ "+warningStyle.Render(message)))
 }
 
 // PrintInfo prints an info message
 func PrintInfo(message string) {
-	fmt.Println(stepStyle.Render(infoStyle.Render(message)))
+	fmt.Fprintln(output, stepStyle.Render(infoStyle.Render(message)))
 }
 
 // PrintHighlight prints highlighted text
 func PrintHighlight(message string) {
-	fmt.Println(stepStyle.Render(star.String() + " " + highlightStyle.Render(message)))
+	fmt.Fprintln(output, stepStyle.Render(star.String()+" "+highlightStyle.Render(message)))
 }
 
 // PrintBox prints text in a rounded box
 func PrintBox(content string) {
-	fmt.Println(boxStyle.Render(content))
+	fmt.Fprintln(output, boxStyle.Render(content))
+}
+
+// Swatch returns a colored block for a "#RRGGBB" or "#RRGGBBAA" color, or "" for
+// other values. The alpha channel is ignored.
+func Swatch(color string) string {
+	if (len(color) != 7 && len(color) != 9) || color[0] != '#' {
+		return ""
+	}
+	for _, c := range color[1:] {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", c) {
+			return ""
+		}
+	}
+	return lipgloss.NewStyle().Foreground(lipgloss.Color(color[:7])).Render("██")
 }
 
 // PrintObjectList prints a list of objects
 func PrintObjectList(objects []string) {
-	fmt.Println(stepStyle.Render("Objects:"))
+	fmt.Fprintln(output, stepStyle.Render("Objects:"))
 	for _, obj := range objects {
 		PrintItem(obj)
 	}
@@ -154,7 +208,7 @@ func PrintSeparator() {
 	separator := lipgloss.NewStyle().
 		Foreground(mutedColor).
 		Render("─────────────────────────────────────────────")
-	fmt.Println(separator)
+	fmt.Fprintln(output, separator)
 }
 
 // PrintKeyValue prints a key-value pair with nice formatting
@@ -162,7 +216,22 @@ func PrintKeyValue(key, value string) {
 	keyStyle := lipgloss.NewStyle().
 		Foreground(secondaryColor).
 		Bold(true)
-	fmt.Println(stepStyle.Render(keyStyle.Render(key+":") + " " + value))
+	fmt.Fprintln(output, stepStyle.Render(keyStyle.Render(key+":")+" "+value))
+}
+
+// defaultTableWidths are the column widths of tables: name, ID, filament, info
+var defaultTableWidths = []int{30, 15, 20, 30}
+
+// tableWidths are the column widths of the tables printed
+var tableWidths = defaultTableWidths
+
+// SetTableWidths sets the column widths of the tables printed next. Without
+// widths, the default widths are restored.
+func SetTableWidths(widths ...int) {
+	if len(widths) == 0 {
+		widths = defaultTableWidths
+	}
+	tableWidths = widths
 }
 
 // PrintTableRow prints a formatted table row with columns
@@ -171,8 +240,7 @@ func PrintTableRow(columns ...string) {
 		return
 	}
 
-	// Define column widths
-	widths := []int{30, 15, 20, 30} // Name, ID, Filament, Info
+	widths := tableWidths
 
 	row := ""
 	for i, col := range columns {
@@ -193,7 +261,7 @@ func PrintTableRow(columns ...string) {
 		}
 	}
 
-	fmt.Println(stepStyle.Render(row))
+	fmt.Fprintln(output, stepStyle.Render(row))
 }
 
 // PrintTableHeader prints a table header
@@ -202,7 +270,7 @@ func PrintTableHeader(headers ...string) {
 		Foreground(secondaryColor).
 		Bold(true)
 
-	widths := []int{30, 15, 20, 30}
+	widths := tableWidths
 	row := ""
 
 	for i, header := range headers {
@@ -222,7 +290,7 @@ func PrintTableHeader(headers ...string) {
 		}
 	}
 
-	fmt.Println(stepStyle.Render(headerStyle.Render(row)))
+	fmt.Fprintln(output, stepStyle.Render(headerStyle.Render(row)))
 
 	// Print separator line
 	separator := ""
@@ -235,11 +303,28 @@ func PrintTableHeader(headers ...string) {
 			separator += "─┼─"
 		}
 	}
-	fmt.Println(stepStyle.Render(infoStyle.Render(separator)))
+	fmt.Fprintln(output, stepStyle.Render(infoStyle.Render(separator)))
+}
+
+// verbose overrides whether verbose output is enabled (nil = detect it)
+var verbose *bool
+
+// SetVerbose enables or disables verbose output regardless of the command line
+// and the environment, e.g. for a build run on behalf of another process
+func SetVerbose(enabled bool) {
+	verbose = &enabled
+}
+
+// ResetVerbose detects verbose output from the command line and the environment again
+func ResetVerbose() {
+	verbose = nil
 }
 
 // IsVerbose checks if verbose output is enabled
 func IsVerbose() bool {
+	if verbose != nil {
+		return *verbose
+	}
 	// Check for CI environment variable or --debug flag
 	if os.Getenv("CI") != "" {
 		return true
@@ -269,10 +354,10 @@ func PrintProgress(current, total int, message string) {
 	pct := (current * 100) / total
 
 	// Use carriage return to overwrite the line
-	fmt.Printf("\r  [%s] %d%% %s", bar, pct, message)
+	fmt.Fprintf(output, "\r  [%s] %d%% %s", bar, pct, message)
 
 	// Print newline on completion
 	if current >= total {
-		fmt.Println()
+		fmt.Fprintln(output)
 	}
 }
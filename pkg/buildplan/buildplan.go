@@ -0,0 +1,61 @@
+// Package buildplan creates the build plans of go3mf and runs them. It is the
+// public API for other Go programs that add their own steps to a build, e.g. a
+// QA check between rendering and combining:
+//
+//	plan, err := buildplan.NewPlanner().CreatePlan([]string{"box.yaml"}, nil, "")
+//	if err != nil {
+//		return err
+//	}
+//	err = plan.InsertBefore("Combine with groups", &buildplan.StepFunc{
+//		StepName: "QA check",
+//		Run: func(ctx *buildplan.Context) error {
+//			ctx.Lock()
+//			defer ctx.Unlock()
+//			return check(ctx.RenderedFiles)
+//		},
+//	})
+//	if err != nil {
+//		return err
+//	}
+//	err = plan.Execute()
+package buildplan
+
+import (
+	"github.com/philipparndt/go3mf/internal/buildplan"
+)
+
+// StdoutPath is the output path that streams the resulting 3MF to stdout
+const StdoutPath = buildplan.StdoutPath
+
+// BuildPlan is a plan of named steps building an output file
+type BuildPlan = buildplan.BuildPlan
+
+// BuildStep is a single step of a build plan
+type BuildStep = buildplan.BuildStep
+
+// StepDependencies is implemented by steps that only depend on some of the steps
+// before them
+type StepDependencies = buildplan.StepDependencies
+
+// StepFunc is a build step running a function with the shared build context
+type StepFunc = buildplan.StepFunc
+
+// Context is the context shared between the steps of a build
+type Context = buildplan.Context
+
+// ObjectGroup is a group of files combined into one object
+type ObjectGroup = buildplan.ObjectGroup
+
+// Planner creates build plans for input files
+type Planner = buildplan.Planner
+
+// NewPlanner creates a new build planner
+func NewPlanner() *Planner {
+	return buildplan.NewPlanner()
+}
+
+// SharedContext returns the context shared between the build steps, which is
+// also passed to StepFunc steps
+func SharedContext() *Context {
+	return buildplan.SharedContext()
+}
@@ -0,0 +1,202 @@
+package buildplan_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/philipparndt/go3mf/pkg/buildplan"
+)
+
+const tetraSTL = `solid tetra
+facet normal 0 0 0
+outer loop
+vertex 0 0 0
+vertex 10 0 0
+vertex 0 10 0
+endloop
+endfacet
+facet normal 0 0 0
+outer loop
+vertex 0 0 0
+vertex 0 10 0
+vertex 0 0 10
+endloop
+endfacet
+facet normal 0 0 0
+outer loop
+vertex 0 0 0
+vertex 0 0 10
+vertex 10 0 0
+endloop
+endfacet
+facet normal 0 0 0
+outer loop
+vertex 10 0 0
+vertex 0 0 10
+vertex 0 10 0
+endloop
+endfacet
+endsolid tetra
+`
+
+// stlPlan returns the plan combining two STL files into combined.3mf in a
+// temporary directory
+func stlPlan(t *testing.T) (*buildplan.BuildPlan, string) {
+	t.Helper()
+	dir := t.TempDir()
+	var inputs []string
+	for _, name := range []string{"a.stl", "b.stl"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(tetraSTL), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		inputs = append(inputs, path)
+	}
+	output := filepath.Join(dir, "combined.3mf")
+	plan, err := buildplan.NewPlanner().CreatePlan(inputs, nil, output)
+	if err != nil {
+		t.Fatalf("CreatePlan() error = %v", err)
+	}
+	return plan, output
+}
+
+// step returns a step called name that does nothing
+func step(name string) buildplan.BuildStep {
+	return &buildplan.StepFunc{StepName: name, Run: func(*buildplan.Context) error { return nil }}
+}
+
+func TestInsertSteps(t *testing.T) {
+	tests := []struct {
+		name   string
+		insert func(plan *buildplan.BuildPlan) error
+		want   []string
+	}{
+		{
+			name: "before",
+			insert: func(plan *buildplan.BuildPlan) error {
+				return plan.InsertBefore("Convert STL files to 3MF", step("A"), step("B"))
+			},
+			want: []string{"Validate STL files", "A", "B", "Convert STL files to 3MF", "Combine converted 3MF files"},
+		},
+		{
+			name: "after",
+			insert: func(plan *buildplan.BuildPlan) error {
+				return plan.InsertAfter("Convert STL files to 3MF", step("A"), step("B"))
+			},
+			want: []string{"Validate STL files", "Convert STL files to 3MF", "A", "B", "Combine converted 3MF files"},
+		},
+		{
+			name: "first and last",
+			insert: func(plan *buildplan.BuildPlan) error {
+				if err := plan.InsertBefore("Validate STL files", step("A")); err != nil {
+					return err
+				}
+				return plan.InsertAfter("Combine converted 3MF files", step("B"))
+			},
+			want: []string{"A", "Validate STL files", "Convert STL files to 3MF", "Combine converted 3MF files", "B"},
+		},
+		{
+			name: "next to an inserted step",
+			insert: func(plan *buildplan.BuildPlan) error {
+				if err := plan.InsertAfter("Validate STL files", step("A")); err != nil {
+					return err
+				}
+				if err := plan.InsertBefore("A", step("B")); err != nil {
+					return err
+				}
+				return plan.InsertAfter("A", step("C"))
+			},
+			want: []string{"Validate STL files", "B", "A", "C", "Convert STL files to 3MF", "Combine converted 3MF files"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, _ := stlPlan(t)
+			if err := tt.insert(plan); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := plan.StepNames(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("StepNames() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInsertStepsErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		anchor string
+		steps  []buildplan.BuildStep
+		want   string
+	}{
+		{"unknown anchor", "Render", []buildplan.BuildStep{step("A")}, `build plan has no step "Render" (steps: Validate STL files, Convert STL files to 3MF, Combine converted 3MF files)`},
+		{"name of a built-in step", "Validate STL files", []buildplan.BuildStep{step("Convert STL files to 3MF")}, `build plan already has a step "Convert STL files to 3MF"`},
+		{"duplicate inserted steps", "Validate STL files", []buildplan.BuildStep{step("A"), step("A")}, `build plan already has a step "A"`},
+		{"empty step", "Validate STL files", []buildplan.BuildStep{nil}, `cannot insert an empty step next to "Validate STL files"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, insert := range []string{"before", "after"} {
+				plan, _ := stlPlan(t)
+				want := plan.StepNames()
+
+				var err error
+				if insert == "before" {
+					err = plan.InsertBefore(tt.anchor, tt.steps...)
+				} else {
+					err = plan.InsertAfter(tt.anchor, tt.steps...)
+				}
+				if err == nil || !strings.Contains(err.Error(), tt.want) {
+					t.Errorf("insert %s: error = %v, want %q", insert, err, tt.want)
+				}
+				if got := plan.StepNames(); !reflect.DeepEqual(got, want) {
+					t.Errorf("insert %s: StepNames() = %v after the error, want the plan unchanged", insert, got)
+				}
+			}
+		})
+	}
+}
+
+func TestCustomStep(t *testing.T) {
+	plan, output := stlPlan(t)
+
+	var mu sync.Mutex
+	var ran []string
+	record := func(name string) *buildplan.StepFunc {
+		return &buildplan.StepFunc{StepName: name, Run: func(ctx *buildplan.Context) error {
+			if ctx != buildplan.SharedContext() {
+				t.Errorf("%s: the step got another context than SharedContext()", name)
+			}
+			ctx.Lock()
+			files := len(ctx.RenderedFiles)
+			ctx.Unlock()
+			mu.Lock()
+			defer mu.Unlock()
+			ran = append(ran, name)
+			if name == "QA check" && files != 2 {
+				t.Errorf("QA check: %d converted files, want 2", files)
+			}
+			return nil
+		}}
+	}
+	if err := plan.InsertBefore("Combine converted 3MF files", record("QA check")); err != nil {
+		t.Fatal(err)
+	}
+	if err := plan.InsertAfter("Combine converted 3MF files", record("Publish")); err != nil {
+		t.Fatal(err)
+	}
+	if err := plan.Execute(); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	if want := []string{"QA check", "Publish"}; !reflect.DeepEqual(ran, want) {
+		t.Errorf("custom steps ran as %v, want %v", ran, want)
+	}
+	if _, err := os.Stat(output); err != nil {
+		t.Errorf("output was not written: %v", err)
+	}
+}
@@ -0,0 +1,69 @@
+// Package geometry measures the meshes of 3MF objects and works with 3MF
+// transformation matrices. It is the public API of the geometry of go3mf for
+// other Go programs.
+//
+// Transforms are the 12 values of a 3MF transform attribute: the rows of the
+// 3x3 matrix followed by the translation.
+package geometry
+
+import (
+	"github.com/philipparndt/go3mf/internal/geometry"
+	"github.com/philipparndt/go3mf/internal/models"
+)
+
+// BoundingBox is an axis-aligned 3D bounding box in mm
+type BoundingBox = geometry.BoundingBox
+
+// Decomposition is a transform split into scale, rotation and translation
+type Decomposition = geometry.Decomposition
+
+// CalculateBoundingBox returns the bounding box of the mesh of an object
+func CalculateBoundingBox(obj *models.Object) (*BoundingBox, error) {
+	return geometry.CalculateBoundingBox(obj)
+}
+
+// MeshVolume returns the volume of the mesh of an object in mm³
+func MeshVolume(obj *models.Object) (float64, error) {
+	return geometry.MeshVolume(obj)
+}
+
+// MeshArea returns the surface area of the mesh of an object in mm²
+func MeshArea(obj *models.Object) (float64, error) {
+	return geometry.MeshArea(obj)
+}
+
+// RotationTransform returns the transform attribute rotating around X, Y and Z
+// (degrees, applied Z first) and translating by tx, ty and tz
+func RotationTransform(rotX, rotY, rotZ, tx, ty, tz float64) string {
+	return geometry.BuildRotationTransform(rotX, rotY, rotZ, tx, ty, tz)
+}
+
+// ParseTransform parses a transform attribute ("" is the identity)
+func ParseTransform(transform string) ([12]float64, error) {
+	return geometry.ParseTransform(transform)
+}
+
+// FormatTransform formats a transform as transform attribute
+func FormatTransform(m [12]float64) string {
+	return geometry.FormatTransform(m)
+}
+
+// ComposeTransforms returns the transform applying the transforms in order
+func ComposeTransforms(transforms ...[12]float64) [12]float64 {
+	return geometry.ComposeTransforms(transforms...)
+}
+
+// InvertTransform returns the inverse of a transform
+func InvertTransform(m [12]float64) ([12]float64, error) {
+	return geometry.InvertTransform(m)
+}
+
+// TransformPoint applies a transform to a point
+func TransformPoint(m [12]float64, x, y, z float64) (float64, float64, float64) {
+	return geometry.TransformPoint(m, x, y, z)
+}
+
+// Decompose splits a transform into scale, rotation and translation
+func Decompose(m [12]float64) Decomposition {
+	return geometry.Decompose(m)
+}
@@ -0,0 +1,60 @@
+package geometry_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/philipparndt/go3mf/pkg/geometry"
+	"github.com/philipparndt/go3mf/pkg/threemf"
+)
+
+func TestTransforms(t *testing.T) {
+	rotate, err := geometry.ParseTransform(geometry.RotationTransform(0, 0, 90, 10, 0, 0))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	move, err := geometry.ParseTransform("1 0 0 0 1 0 0 0 1 0 0 5")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	m := geometry.ComposeTransforms(rotate, move)
+	x, y, z := geometry.TransformPoint(m, 1, 0, 0)
+	if math.Abs(x-10) > 1e-6 || math.Abs(y-1) > 1e-6 || math.Abs(z-5) > 1e-6 {
+		t.Errorf("expected (10, 1, 5), got (%g, %g, %g)", x, y, z)
+	}
+
+	inverse, err := geometry.InvertTransform(m)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	x, y, z = geometry.TransformPoint(inverse, x, y, z)
+	if math.Abs(x-1) > 1e-6 || math.Abs(y) > 1e-6 || math.Abs(z) > 1e-6 {
+		t.Errorf("expected (1, 0, 0), got (%g, %g, %g)", x, y, z)
+	}
+	if d := geometry.Decompose(m); math.Abs(d.Rotation[2]-90) > 1e-6 || d.Translation[2] != 5 {
+		t.Errorf("unexpected decomposition %+v", d)
+	}
+}
+
+func TestMeasureMesh(t *testing.T) {
+	obj := &threemf.Object{Mesh: &threemf.Mesh{
+		Vertices:  &threemf.Vertices{Vertex: []threemf.Vertex{{X: 0, Y: 0, Z: 0}, {X: 10, Y: 0, Z: 0}, {X: 0, Y: 10, Z: 0}, {X: 0, Y: 0, Z: 10}}},
+		Triangles: &threemf.Triangles{Triangle: []threemf.Triangle{{V1: 0, V2: 2, V3: 1}, {V1: 0, V2: 1, V3: 3}, {V1: 0, V2: 3, V3: 2}, {V1: 1, V2: 2, V3: 3}}},
+	}}
+
+	box, err := geometry.CalculateBoundingBox(obj)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if box.Width() != 10 || box.Height() != 10 || box.Depth() != 10 {
+		t.Errorf("unexpected bounding box %+v", box)
+	}
+	volume, err := geometry.MeshVolume(obj)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if math.Abs(volume-1000.0/6) > 1e-6 {
+		t.Errorf("expected a volume of %g, got %g", 1000.0/6, volume)
+	}
+}
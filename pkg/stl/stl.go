@@ -0,0 +1,44 @@
+// Package stl reads and writes STL files and converts them to 3MF. It is the
+// public API of the STL handling of go3mf for other Go programs.
+package stl
+
+import (
+	"io"
+
+	"github.com/philipparndt/go3mf/internal/stl"
+)
+
+// Vector3 is a point or normal of a triangle
+type Vector3 = stl.Vector3
+
+// Triangle is a triangle of a mesh with its normal
+type Triangle = stl.Triangle
+
+// Mesh is the named triangle mesh of an STL file
+type Mesh = stl.Mesh
+
+// Parse reads the mesh of a binary or ASCII STL file
+func Parse(filename string) (*Mesh, error) {
+	return stl.NewParser().Parse(filename)
+}
+
+// ParseReaderAt reads the mesh of an STL file of the given size from r. The
+// name is used as mesh name of binary files.
+func ParseReaderAt(r io.ReaderAt, size int64, name string) (*Mesh, error) {
+	return stl.NewParser().ParseReaderAt(r, size, name)
+}
+
+// WriteBinary writes a mesh to a binary STL file
+func WriteBinary(mesh *Mesh, filename string) error {
+	return stl.NewWriter().WriteBinary(mesh, filename)
+}
+
+// WriteASCII writes a mesh to an ASCII STL file
+func WriteASCII(mesh *Mesh, filename string) error {
+	return stl.NewWriter().WriteASCII(mesh, filename)
+}
+
+// ConvertTo3MF converts an STL file to a 3MF file with one object
+func ConvertTo3MF(stlFile, outputFile string) error {
+	return stl.NewConverter().ConvertTo3MF(stlFile, outputFile)
+}
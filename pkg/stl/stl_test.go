@@ -0,0 +1,46 @@
+package stl_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/philipparndt/go3mf/pkg/stl"
+	"github.com/philipparndt/go3mf/pkg/threemf"
+)
+
+func TestWriteParseAndConvert(t *testing.T) {
+	dir := t.TempDir()
+	mesh := &stl.Mesh{Name: "wedge", Triangles: []stl.Triangle{
+		{Normal: stl.Vector3{Z: -1}, V1: stl.Vector3{}, V2: stl.Vector3{Y: 10}, V3: stl.Vector3{X: 10}},
+	}}
+
+	for _, binary := range []bool{true, false} {
+		path := filepath.Join(dir, "wedge.stl")
+		write := stl.WriteASCII
+		if binary {
+			write = stl.WriteBinary
+		}
+		if err := write(mesh, path); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		parsed, err := stl.Parse(path)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(parsed.Triangles) != 1 || parsed.Triangles[0].V2.Y != 10 {
+			t.Errorf("binary %v: unexpected triangles %+v", binary, parsed.Triangles)
+		}
+	}
+
+	output := filepath.Join(dir, "wedge.3mf")
+	if err := stl.ConvertTo3MF(filepath.Join(dir, "wedge.stl"), output); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	model, err := threemf.Read(output)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(model.Resources.Objects) != 1 || model.Resources.Objects[0].Mesh == nil {
+		t.Errorf("expected one mesh object, got %+v", model.Resources.Objects)
+	}
+}
@@ -0,0 +1,148 @@
+// Package threemf reads, writes and combines 3MF files. It is the public API of
+// the 3MF handling of go3mf for other Go programs; the go3mf command line is
+// built on the same code.
+//
+// Models are read into a Model with its objects, meshes and build items, can be
+// changed and written back:
+//
+//	model, err := threemf.Read("box.3mf")
+//	if err != nil {
+//		return err
+//	}
+//	model.Resources.Objects[0].Name = "Box"
+//	err = threemf.Write("renamed.3mf", model)
+package threemf
+
+import (
+	"io"
+
+	"github.com/philipparndt/go3mf/internal/models"
+	"github.com/philipparndt/go3mf/internal/threemf"
+	"github.com/philipparndt/go3mf/internal/threemf/combine"
+)
+
+// Model is a 3MF model with its resources and build items
+type Model = models.Model
+
+// Metadata is a metadata entry of a model
+type Metadata = models.Metadata
+
+// Resources are the objects and base materials of a model
+type Resources = models.Resources
+
+// Object is an object of a model, with either a mesh or components
+type Object = models.Object
+
+// Mesh is the mesh of an object
+type Mesh = models.Mesh
+
+// Vertices are the corners of a mesh
+type Vertices = models.Vertices
+
+// Vertex is a corner of a mesh in mm
+type Vertex = models.Vertex
+
+// Triangles are the faces of a mesh
+type Triangles = models.Triangles
+
+// Triangle is a face of a mesh given by the indices of its corners
+type Triangle = models.Triangle
+
+// Components are the components of an object made of other objects
+type Components = models.Components
+
+// Component references another object of the model with a transform
+type Component = models.Component
+
+// Build holds the build items of a model
+type Build = models.Build
+
+// Item places an object on the build plate with a transform
+type Item = models.Item
+
+// Read reads the model of a 3MF file
+func Read(filename string) (*Model, error) {
+	return (&threemf.Reader{}).Read(filename)
+}
+
+// ReadArchive reads the model of a 3MF file of the given size held in memory,
+// e.g. an upload
+func ReadArchive(ra io.ReaderAt, size int64) (*Model, error) {
+	model, _, err := (&threemf.Reader{}).ReadArchive(ra, size)
+	return model, err
+}
+
+// Writer writes models as 3MF files
+type Writer struct {
+	CompactXML bool // Write the model XML without indentation, which makes large files smaller
+}
+
+// Write writes a model to a 3MF file
+func (w *Writer) Write(filename string, model *Model) error {
+	return w.internal().Write(filename, model, nil)
+}
+
+// Encode writes a model as 3MF file to out
+func (w *Writer) Encode(out io.Writer, model *Model) error {
+	return w.internal().Encode(out, model, nil)
+}
+
+func (w *Writer) internal() *threemf.Writer {
+	return &threemf.Writer{CompactXML: w.CompactXML}
+}
+
+// Write writes a model to a 3MF file with an indented model XML
+func Write(filename string, model *Model) error {
+	return (&Writer{}).Write(filename, model)
+}
+
+// MarshalModel returns the XML of the model entry of a 3MF file
+func MarshalModel(model *Model, compact bool) ([]byte, error) {
+	return threemf.MarshalModel(model, compact)
+}
+
+// FlattenComponents merges every object made of components, possibly nested,
+// into one mesh with the transforms of all levels applied. It returns the
+// number of merged objects.
+func FlattenComponents(model *Model) (int, error) {
+	return threemf.FlattenComponents(model)
+}
+
+// Combiner combines 3MF files into one file with every object of the inputs as
+// a part of one object, keeping the part names, filaments and matrices of
+// Bambu Studio inputs
+type Combiner struct {
+	combiner *combine.Combiner
+}
+
+// NewCombiner creates a new 3MF combiner
+func NewCombiner() *Combiner {
+	return &Combiner{combiner: combine.NewCombiner()}
+}
+
+// SetCompactXML writes the model XML without indentation
+func (c *Combiner) SetCompactXML(compact bool) {
+	c.combiner.SetCompactXML(compact)
+}
+
+// SetFlatten merges the objects of the inputs that are made of components into
+// one mesh each
+func (c *Combiner) SetFlatten(flatten bool) {
+	c.combiner.SetFlatten(flatten)
+}
+
+// SetIgnoreExtensions combines inputs requiring unsupported 3MF extensions anyway
+func (c *Combiner) SetIgnoreExtensions(ignore bool) {
+	c.combiner.SetIgnoreExtensions(ignore)
+}
+
+// Combine combines at least two 3MF files into outputFile
+func (c *Combiner) Combine(inputFiles []string, outputFile string) error {
+	return c.combiner.Combine(inputFiles, outputFile)
+}
+
+// Warnings returns the problems found in the input files of the last combine,
+// e.g. objects that were skipped because they cannot be combined
+func (c *Combiner) Warnings() []string {
+	return c.combiner.Warnings()
+}
@@ -0,0 +1,86 @@
+package threemf_test
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/philipparndt/go3mf/pkg/threemf"
+)
+
+// triangleModel returns a model with one object made of a single triangle
+func triangleModel(name string) *threemf.Model {
+	return &threemf.Model{
+		Unit: "millimeter",
+		Resources: threemf.Resources{Objects: []threemf.Object{{
+			ID:   "1",
+			Name: name,
+			Type: "model",
+			Mesh: &threemf.Mesh{
+				Vertices:  &threemf.Vertices{Vertex: []threemf.Vertex{{X: 0, Y: 0, Z: 0}, {X: 10, Y: 0, Z: 0}, {X: 0, Y: 10, Z: 10}}},
+				Triangles: &threemf.Triangles{Triangle: []threemf.Triangle{{V1: 0, V2: 1, V3: 2}}},
+			},
+		}}},
+		Build: threemf.Build{Items: []threemf.Item{{ObjectID: "1"}}},
+	}
+}
+
+func TestWriteAndRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "box.3mf")
+	if err := threemf.Write(path, triangleModel("Box")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	model, err := threemf.Read(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(model.Resources.Objects) != 1 || model.Resources.Objects[0].Name != "Box" {
+		t.Errorf("unexpected objects %+v", model.Resources.Objects)
+	}
+
+	var buf bytes.Buffer
+	if err := (&threemf.Writer{CompactXML: true}).Encode(&buf, model); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	model, err = threemf.ReadArchive(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(model.Build.Items) != 1 || model.Build.Items[0].ObjectID != "1" {
+		t.Errorf("unexpected build items %+v", model.Build.Items)
+	}
+}
+
+func TestCombine(t *testing.T) {
+	dir := t.TempDir()
+	var inputs []string
+	for _, name := range []string{"top", "bottom"} {
+		path := filepath.Join(dir, name+".3mf")
+		if err := threemf.Write(path, triangleModel(name)); err != nil {
+			t.Fatal(err)
+		}
+		inputs = append(inputs, path)
+	}
+
+	output := filepath.Join(dir, "case.3mf")
+	combiner := threemf.NewCombiner()
+	if err := combiner.Combine(inputs, output); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	model, err := threemf.Read(output)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var meshes, parents int
+	for _, obj := range model.Resources.Objects {
+		switch {
+		case obj.Mesh != nil:
+			meshes++
+		case obj.Components != nil && len(obj.Components.Component) == 2:
+			parents++
+		}
+	}
+	if meshes != 2 || parents != 1 {
+		t.Errorf("expected 2 parts of one object, got %d meshes and %d parents", meshes, parents)
+	}
+}